@@ -0,0 +1,375 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 跳闸原因编码（30002-30004 跳闸记录 / 30024 最新跳闸原因）
+const (
+	tripLocal        uint16 = 0x0
+	tripOvercurrent  uint16 = 0x1
+	tripLeakage      uint16 = 0x2
+	tripOverTemp     uint16 = 0x3
+	tripOverload     uint16 = 0x4
+	tripOvervoltage  uint16 = 0x5
+	tripUndervoltage uint16 = 0x6
+	tripRemote       uint16 = 0x7
+	tripNone         uint16 = 0xF
+)
+
+// 保持寄存器下标（40001 -> 0）
+const (
+	holdAddress       = 0  // 40001 设备地址
+	holdBaudRate      = 1  // 40002 波特率
+	holdOvervoltage   = 2  // 40003 过压阈值 V
+	holdUndervoltage  = 3  // 40004 欠压阈值 V
+	holdOvercurrent   = 4  // 40005 过流阈值 0.01A
+	holdLeakage       = 5  // 40006 漏电阈值 mA
+	holdOverTemp      = 6  // 40007 过温阈值 °C
+	holdOverload      = 7  // 40008 过载功率 W
+	holdControlBits   = 12 // 40013 bit0 自动/手动, bit1 远程锁定
+	holdRemoteSwitch  = 13 // 40014 远程合/分闸
+	holdTripControl   = 15 // 40016 保护使能位
+	holdDelayOV       = 16 // 40017 过压跳闸延时 0.1s
+	holdDelayUV       = 17 // 40018 欠压跳闸延时
+	holdDelayLeakage  = 18 // 40019 漏电跳闸延时
+	holdDelayOC       = 19 // 40020 过流跳闸延时
+	holdDelayOverload = 20 // 40021 过载跳闸延时
+	holdingCount      = 32
+)
+
+// 40016 保护使能位
+const (
+	protectOV       uint16 = 1 << 0
+	protectUV       uint16 = 1 << 1
+	protectOC       uint16 = 1 << 2
+	protectLeakage  uint16 = 1 << 3
+	protectOverTemp uint16 = 1 << 4
+	protectOverload uint16 = 1 << 5
+)
+
+const maxTripRecords = 12
+
+// BreakerState 断路器初始状态/脚本可设置的字段
+type BreakerState struct {
+	StationID    uint8    `json:"station_id"`
+	Closed       *bool    `json:"closed,omitempty"`
+	Voltage      *float64 `json:"voltage,omitempty"`
+	LoadCurrent  *float64 `json:"load_current,omitempty"`
+	PowerFactor  *float64 `json:"power_factor,omitempty"`
+	Frequency    *float64 `json:"frequency,omitempty"`
+	Leakage      *float64 `json:"leakage,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	RemoteLocked *bool    `json:"remote_locked,omitempty"`
+	LocalLocked  *bool    `json:"local_locked,omitempty"`
+	Offline      *bool    `json:"offline,omitempty"`
+}
+
+// simBreaker 单台LX47LE-125断路器的状态模型
+type simBreaker struct {
+	mu sync.Mutex
+
+	stationID   uint8 // 修改时还需持有 bus.mu，见 bus.readdress
+	closed      bool
+	localLocked bool
+	offline     bool
+
+	holding [holdingCount]uint16
+
+	// 负载侧物理量，合闸时才体现到测量寄存器
+	voltage     float64
+	loadCurrent float64
+	powerFactor float64
+	frequency   float64
+	leakage     float64
+	temperature float64
+	energyWh    float64
+
+	tripRecords []uint16
+	latestTrip  uint16
+
+	// 各保护条件持续超限的起始时间
+	exceedSince map[uint16]time.Time
+}
+
+// newSimBreaker 以出厂默认参数创建断路器
+func newSimBreaker(stationID uint8) *simBreaker {
+	b := &simBreaker{
+		stationID:   stationID,
+		closed:      true,
+		voltage:     220,
+		loadCurrent: 3.5,
+		powerFactor: 0.95,
+		frequency:   50,
+		temperature: 32,
+		latestTrip:  tripNone,
+		exceedSince: make(map[uint16]time.Time),
+	}
+	b.resetConfig()
+	b.holding[holdAddress] = uint16(stationID)
+	b.holding[holdBaudRate] = 9600
+	b.holding[holdRemoteSwitch] = 0xFF00
+	return b
+}
+
+// resetConfig 恢复出厂保护参数（对应线圈00001复位配置，保留地址和波特率）
+func (b *simBreaker) resetConfig() {
+	b.holding[holdOvervoltage] = 275
+	b.holding[holdUndervoltage] = 160
+	b.holding[holdOvercurrent] = 6300
+	b.holding[holdLeakage] = 30
+	b.holding[holdOverTemp] = 80
+	b.holding[holdOverload] = 13000
+	b.holding[holdTripControl] = 0x3F
+	b.holding[holdDelayOV] = 10
+	b.holding[holdDelayUV] = 10
+	b.holding[holdDelayLeakage] = 1
+	b.holding[holdDelayOC] = 50
+	b.holding[holdDelayOverload] = 50
+	b.holding[21] = 15    // 40022 模块上报间隔
+	b.holding[28] = 17609 // 40029-40032 校准系数
+	b.holding[29] = 847
+	b.holding[30] = 2289
+	b.holding[31] = 1964
+}
+
+// apply 应用脚本/配置中的状态字段
+func (b *simBreaker) apply(s BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s.Voltage != nil {
+		b.voltage = *s.Voltage
+	}
+	if s.LoadCurrent != nil {
+		b.loadCurrent = *s.LoadCurrent
+	}
+	if s.PowerFactor != nil {
+		b.powerFactor = *s.PowerFactor
+	}
+	if s.Frequency != nil {
+		b.frequency = *s.Frequency
+	}
+	if s.Leakage != nil {
+		b.leakage = *s.Leakage
+	}
+	if s.Temperature != nil {
+		b.temperature = *s.Temperature
+	}
+	if s.RemoteLocked != nil {
+		b.setRemoteLock(*s.RemoteLocked)
+	}
+	if s.LocalLocked != nil {
+		b.localLocked = *s.LocalLocked
+	}
+	if s.Offline != nil {
+		b.offline = *s.Offline
+	}
+	if s.Closed != nil {
+		if *s.Closed {
+			b.closed = true
+			b.holding[holdRemoteSwitch] = 0xFF00
+		} else if b.closed {
+			b.tripLocked(tripLocal)
+		}
+	}
+}
+
+func (b *simBreaker) isOffline() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offline
+}
+
+func (b *simBreaker) remoteLocked() bool {
+	return b.holding[holdControlBits]&0x02 != 0
+}
+
+func (b *simBreaker) setRemoteLock(locked bool) {
+	if locked {
+		b.holding[holdControlBits] |= 0x02
+	} else {
+		b.holding[holdControlBits] &^= 0x02
+	}
+}
+
+// switchRemote 远程合/分闸（线圈00002或保持寄存器40014），锁定时拒绝合闸
+func (b *simBreaker) switchRemote(close bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if close {
+		if b.remoteLocked() || b.localLocked {
+			logrus.WithField("station", b.stationID).Warn("断路器已锁定，拒绝远程合闸")
+			return false
+		}
+		b.closed = true
+		b.holding[holdRemoteSwitch] = 0xFF00
+		b.exceedSince = make(map[uint16]time.Time)
+		logrus.WithField("station", b.stationID).Info("远程合闸")
+		return true
+	}
+
+	if b.closed {
+		b.tripLocked(tripRemote)
+		logrus.WithField("station", b.stationID).Info("远程分闸")
+	}
+	return true
+}
+
+// trip 以指定原因跳闸
+func (b *simBreaker) trip(reason uint16) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tripLocked(reason)
+}
+
+func (b *simBreaker) tripLocked(reason uint16) {
+	b.closed = false
+	b.holding[holdRemoteSwitch] = 0x0000
+	b.latestTrip = reason
+	b.tripRecords = append([]uint16{reason}, b.tripRecords...)
+	if len(b.tripRecords) > maxTripRecords {
+		b.tripRecords = b.tripRecords[:maxTripRecords]
+	}
+	b.exceedSince = make(map[uint16]time.Time)
+}
+
+// clearRecords 清除跳闸记录和电能统计（线圈00005）
+func (b *simBreaker) clearRecords() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tripRecords = nil
+	b.latestTrip = tripNone
+	b.energyWh = 0
+}
+
+// tick 推进物理量并执行保护判断
+func (b *simBreaker) tick(now time.Time, dt time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		return
+	}
+
+	b.energyWh += b.activePower() * dt.Hours()
+
+	enabled := b.holding[holdTripControl]
+	checks := []struct {
+		bit    uint16
+		reason uint16
+		exceed bool
+		delay  uint16
+	}{
+		{protectOV, tripOvervoltage, b.voltage > float64(b.holding[holdOvervoltage]), b.holding[holdDelayOV]},
+		{protectUV, tripUndervoltage, b.voltage < float64(b.holding[holdUndervoltage]), b.holding[holdDelayUV]},
+		{protectLeakage, tripLeakage, b.leakage > float64(b.holding[holdLeakage]), b.holding[holdDelayLeakage]},
+		{protectOC, tripOvercurrent, b.loadCurrent*100 > float64(b.holding[holdOvercurrent]), b.holding[holdDelayOC]},
+		{protectOverload, tripOverload, b.activePower() > float64(b.holding[holdOverload]), b.holding[holdDelayOverload]},
+		{protectOverTemp, tripOverTemp, b.temperature > float64(b.holding[holdOverTemp]), 0},
+	}
+
+	for _, c := range checks {
+		if enabled&c.bit == 0 || !c.exceed {
+			delete(b.exceedSince, c.reason)
+			continue
+		}
+		since, ok := b.exceedSince[c.reason]
+		if !ok {
+			b.exceedSince[c.reason] = now
+			since = now
+		}
+		if now.Sub(since) >= time.Duration(c.delay)*100*time.Millisecond {
+			logrus.WithFields(logrus.Fields{
+				"station": b.stationID,
+				"reason":  c.reason,
+			}).Warn("保护动作跳闸")
+			b.tripLocked(c.reason)
+			return
+		}
+	}
+}
+
+func (b *simBreaker) activePower() float64 {
+	return b.voltage * b.loadCurrent * b.powerFactor
+}
+
+// readInput 读取输入寄存器（0基地址，30001 -> 0）
+func (b *simBreaker) readInput(addr uint16) uint16 {
+	current, power, leakage := 0.0, 0.0, 0.0
+	if b.closed {
+		current = b.loadCurrent
+		power = b.activePower()
+		leakage = b.leakage
+	}
+	energy := uint32(b.energyWh) // 0.001kWh = 1Wh
+
+	switch addr {
+	case 0: // 30001 高字节本地锁定，低字节分合闸
+		var status uint16 = 0x0F
+		if b.closed {
+			status = 0xF0
+		}
+		if b.localLocked {
+			status |= 0x0100
+		}
+		return status
+	case 1, 2, 3: // 30002-30004 跳闸记录，每个寄存器4条，最新记录在低位
+		var v uint16
+		for i := 0; i < 4; i++ {
+			idx := int(addr-1)*4 + i
+			code := tripNone
+			if idx < len(b.tripRecords) {
+				code = b.tripRecords[idx]
+			}
+			v |= code << (4 * uint(i))
+		}
+		return v
+	case 4: // 30005 频率 0.1Hz
+		return uint16(b.frequency * 10)
+	case 5: // 30006 漏电流 mA
+		return uint16(leakage)
+	case 6: // 30007 N相温度 +40
+		return uint16(b.temperature + 40)
+	case 7: // 30008 A相电压
+		return uint16(b.voltage)
+	case 8: // 30009 A相电流 0.01A
+		return uint16(current * 100)
+	case 10: // 30011 功率因数 0.01
+		return uint16(b.powerFactor * 100)
+	case 11: // 30012 有功功率 W
+		return uint16(power)
+	case 12: // 30013 无功功率 var
+		return 0
+	case 13: // 30014 总有功电能高位
+		return uint16(energy >> 16)
+	case 14: // 30015 总有功电能低位
+		return uint16(energy)
+	case 22, 23: // 30023/30024 最新跳闸原因（协议文档两处地址不一致，同时提供）
+		return b.latestTrip
+	case 33: // 30034 总有功功率
+		return uint16(power)
+	case 35: // 30036 总视在功率
+		return uint16(b.voltage * current)
+	}
+	return 0
+}
+
+// readCoil 读取线圈（0基地址）
+func (b *simBreaker) readCoil(addr uint16) bool {
+	switch addr {
+	case 0: // 00001 电压故障
+		return b.voltage > float64(b.holding[holdOvervoltage]) || b.voltage < float64(b.holding[holdUndervoltage])
+	case 1: // 00002 合/分闸
+		return b.closed
+	case 2: // 00003 远程锁定
+		return b.remoteLocked()
+	case 3: // 00004 自动/手动
+		return b.holding[holdControlBits]&0x01 != 0
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// lx47le-sim 模拟RS485-ETH-M04网关后挂接的多台LX47LE-125智能断路器，
+// 用于在没有真实硬件时联调断路器读取、控制、锁定和跳闸相关功能。
+//
+// 用法:
+//
+//	go run ./cmd/lx47le-sim -listen :5020 -stations 1-4
+//	go run ./cmd/lx47le-sim -listen :5020 -framing rtu -scenario trip.json
+//	go run ./cmd/lx47le-sim -pty -stations 1,2   # 串口RTU，断路器串口设备填写日志中的从端路径
+//	go run ./cmd/lx47le-sim -listen :5020 -stations 1,1,1   # 三台出厂站号均为1的新设备，用于联调投运
+package main
+
+import (
+	"flag"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"smart-device-management/pkg/modbus"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	listen := flag.String("listen", ":502", "监听地址")
+	framing := flag.String("framing", "mbap", "报文格式: mbap (MODBUS TCP) 或 rtu (RTU over TCP)")
+	stations := flag.String("stations", "1", "站号列表，如 1,2,5-8，重复的站号表示地址冲突的多台设备（使用脚本时忽略）")
+	scenarioPath := flag.String("scenario", "", "模拟脚本文件(JSON)")
+	delay := flag.Duration("delay", 20*time.Millisecond, "模拟设备响应延时")
+	tick := flag.Duration("tick", 200*time.Millisecond, "物理量刷新及保护判断周期")
+	verbose := flag.Bool("v", false, "输出调试日志")
+	pty := flag.Bool("pty", false, "在伪终端上模拟串口RTU从站（忽略 -listen 和 -framing）")
+	flag.Parse()
+
+	if *verbose {
+		logrus.SetLevel(logrus.DebugLevel)
+	}
+	if *framing != "mbap" && *framing != "rtu" {
+		logrus.Fatalf("不支持的报文格式: %s", *framing)
+	}
+
+	b := newBus()
+
+	var sc *Scenario
+	if *scenarioPath != "" {
+		var err error
+		sc, err = loadScenario(*scenarioPath)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		for _, st := range sc.Breakers {
+			br := newSimBreaker(st.StationID)
+			br.apply(st)
+			b.add(br)
+		}
+	} else {
+		ids, err := parseStations(*stations)
+		if err != nil {
+			logrus.Fatalf("站号列表格式错误: %v", err)
+		}
+		for _, id := range ids {
+			b.add(newSimBreaker(id))
+		}
+	}
+
+	ids := make([]string, 0)
+	for _, br := range b.all() {
+		ids = append(ids, strconv.Itoa(int(br.stationID)))
+	}
+
+	go b.run(*tick)
+	if sc != nil {
+		go sc.play(b)
+	}
+
+	if *pty {
+		master, slave, err := modbus.OpenPTY()
+		if err != nil {
+			logrus.Fatalf("创建伪终端失败: %v", err)
+		}
+		logrus.WithFields(logrus.Fields{
+			"serial_device": slave,
+			"stations":      strings.Join(ids, ","),
+		}).Info("LX47LE-125模拟器启动（串口RTU）")
+
+		srv := &server{bus: b, framing: "rtu", delay: *delay}
+		if err := srv.servePTY(master); err != nil {
+			logrus.Fatalf("服务异常退出: %v", err)
+		}
+		return
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		logrus.Fatalf("监听失败: %v", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"listen":   ln.Addr().String(),
+		"framing":  *framing,
+		"stations": strings.Join(ids, ","),
+	}).Info("LX47LE-125模拟器启动")
+
+	srv := &server{bus: b, framing: *framing, delay: *delay}
+	if err := srv.serve(ln); err != nil {
+		logrus.Fatalf("服务异常退出: %v", err)
+	}
+}
+
+// parseStations 解析站号列表，支持逗号分隔和区间
+func parseStations(spec string) ([]uint8, error) {
+	var ids []uint8
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			lo, hi = part[:i], part[i+1:]
+		}
+		from, err := strconv.ParseUint(lo, 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		to, err := strconv.ParseUint(hi, 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		for id := from; id <= to; id++ {
+			if id == 0 || id > 247 {
+				continue
+			}
+			ids = append(ids, uint8(id))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Scenario 模拟脚本：初始断路器列表 + 按时间触发的事件
+//
+//	{
+//	  "breakers": [{"station_id": 1, "load_current": 5}, {"station_id": 2, "closed": false}],
+//	  "steps": [
+//	    {"at": "10s", "station_id": 1, "set": {"load_current": 80}},
+//	    {"at": "30s", "station_id": 2, "action": "trip", "reason": 2},
+//	    {"at": "45s", "station_id": 1, "set": {"offline": true}}
+//	  ]
+//	}
+type Scenario struct {
+	Breakers []BreakerState `json:"breakers"`
+	Steps    []ScenarioStep `json:"steps"`
+}
+
+// ScenarioStep 脚本事件
+type ScenarioStep struct {
+	At        string        `json:"at"`
+	StationID uint8         `json:"station_id"` // 0 表示所有断路器
+	Action    string        `json:"action,omitempty"`
+	Reason    uint16        `json:"reason,omitempty"`
+	Set       *BreakerState `json:"set,omitempty"`
+
+	offset time.Duration
+}
+
+// loadScenario 读取脚本文件
+func loadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取脚本失败: %w", err)
+	}
+
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("解析脚本失败: %w", err)
+	}
+
+	for i := range sc.Steps {
+		d, err := time.ParseDuration(sc.Steps[i].At)
+		if err != nil {
+			return nil, fmt.Errorf("第%d个事件时间格式错误: %w", i+1, err)
+		}
+		sc.Steps[i].offset = d
+		switch sc.Steps[i].Action {
+		case "", "trip", "close", "open", "lock", "unlock", "local_lock", "local_unlock":
+		default:
+			return nil, fmt.Errorf("第%d个事件动作不支持: %s", i+1, sc.Steps[i].Action)
+		}
+	}
+	sort.SliceStable(sc.Steps, func(i, j int) bool { return sc.Steps[i].offset < sc.Steps[j].offset })
+
+	return &sc, nil
+}
+
+// play 按时间顺序执行脚本事件
+func (sc *Scenario) play(b *bus) {
+	start := time.Now()
+	for _, step := range sc.Steps {
+		time.Sleep(time.Until(start.Add(step.offset)))
+
+		targets := b.all()
+		if step.StationID != 0 {
+			targets = nil
+			if br := b.get(step.StationID); br != nil {
+				targets = append(targets, br)
+			}
+		}
+		if len(targets) == 0 {
+			logrus.WithField("station", step.StationID).Warn("脚本事件目标站号不存在")
+			continue
+		}
+
+		for _, br := range targets {
+			step.execute(br)
+		}
+		logrus.WithFields(logrus.Fields{
+			"at":      step.At,
+			"station": step.StationID,
+			"action":  step.Action,
+		}).Info("执行脚本事件")
+	}
+	logrus.Info("脚本执行完毕")
+}
+
+func (step ScenarioStep) execute(br *simBreaker) {
+	if step.Set != nil {
+		br.apply(*step.Set)
+	}
+
+	boolPtr := func(v bool) *bool { return &v }
+	switch step.Action {
+	case "trip":
+		br.trip(step.Reason)
+	case "close":
+		br.apply(BreakerState{Closed: boolPtr(true)})
+	case "open":
+		br.apply(BreakerState{Closed: boolPtr(false)})
+	case "lock":
+		br.apply(BreakerState{RemoteLocked: boolPtr(true)})
+	case "unlock":
+		br.apply(BreakerState{RemoteLocked: boolPtr(false)})
+	case "local_lock":
+		br.apply(BreakerState{LocalLocked: boolPtr(true)})
+	case "local_unlock":
+		br.apply(BreakerState{LocalLocked: boolPtr(false)})
+	}
+}
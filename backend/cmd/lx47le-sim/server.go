@@ -0,0 +1,496 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"smart-device-management/pkg/modbus"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MODBUS异常码
+const (
+	exIllegalFunction byte = 0x01
+	exIllegalAddress  byte = 0x02
+	exIllegalValue    byte = 0x03
+)
+
+// bus 模拟RS485总线：一个网关端口下挂多台断路器。
+// 同一站号下可以有多台设备（新设备出厂均为站号1），此时它们会同时应答造成总线冲突。
+type bus struct {
+	mu       sync.RWMutex
+	stations map[uint8][]*simBreaker
+}
+
+func newBus() *bus {
+	return &bus{stations: make(map[uint8][]*simBreaker)}
+}
+
+func (b *bus) add(br *simBreaker) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stations[br.stationID] = append(b.stations[br.stationID], br)
+}
+
+// get 返回站号下的第一台设备，供模拟脚本按站号定位
+func (b *bus) get(id uint8) *simBreaker {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if list := b.stations[id]; len(list) > 0 {
+		return list[0]
+	}
+	return nil
+}
+
+// at 返回站号下的全部设备
+func (b *bus) at(id uint8) []*simBreaker {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]*simBreaker(nil), b.stations[id]...)
+}
+
+func (b *bus) all() []*simBreaker {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	list := make([]*simBreaker, 0, len(b.stations))
+	for _, devices := range b.stations {
+		list = append(list, devices...)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].stationID < list[j].stationID })
+	return list
+}
+
+// readdress 修改站号（写40001），新地址已被占用时两台设备都保留，形成地址冲突。
+// 站号同时持有 b.mu 和 br.mu 修改，总线索引在 b.mu 下读取，设备自身在 br.mu 下读取
+func (b *bus) readdress(br *simBreaker, newID uint8) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	list := b.stations[br.stationID]
+	for i, other := range list {
+		if other == br {
+			list = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(b.stations, br.stationID)
+	} else {
+		b.stations[br.stationID] = list
+	}
+	br.mu.Lock()
+	br.stationID = newID
+	br.mu.Unlock()
+	if len(b.stations[newID]) > 0 {
+		logrus.WithField("station", newID).Warn("站号冲突，总线上有多台设备使用同一地址")
+	}
+	b.stations[newID] = append(b.stations[newID], br)
+}
+
+// run 周期推进所有断路器的物理状态
+func (b *bus) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		for _, br := range b.all() {
+			br.tick(now, interval)
+		}
+	}
+}
+
+// handle 处理一帧请求PDU，返回总线上各设备的响应PDU；为空表示不应答（广播或站号不存在）
+func (b *bus) handle(unitID uint8, pdu []byte) [][]byte {
+	if len(pdu) < 1 {
+		return nil
+	}
+
+	if unitID == 0 {
+		// 广播：仅写操作有效，且不应答
+		for _, br := range b.all() {
+			if !br.isOffline() {
+				b.execute(br, pdu)
+			}
+		}
+		return nil
+	}
+
+	// 同一站号的所有设备都会执行请求（包括写站号），并各自应答
+	var resps [][]byte
+	for _, br := range b.at(unitID) {
+		if br.isOffline() {
+			continue
+		}
+		if resp := b.execute(br, pdu); resp != nil {
+			resps = append(resps, resp)
+		}
+	}
+	return resps
+}
+
+// onWire 多台设备应答叠加后总线上的RTU帧。
+// 只有一台应答时为正常帧；多台同时发送时，先发送的一方偶尔能完整占据总线，
+// 否则信号叠加（按线与合并）且发送时刻略有错位，接收方得到CRC错误的乱码。
+func onWire(unitID uint8, resps [][]byte) []byte {
+	frames := make([][]byte, len(resps))
+	for i, resp := range resps {
+		frames[i] = rtuFrame(unitID, resp)
+	}
+	if len(frames) == 1 || rand.Intn(3) == 0 {
+		return frames[rand.Intn(len(frames))]
+	}
+
+	logrus.WithFields(logrus.Fields{"station": unitID, "devices": len(frames)}).Warn("站号冲突，多台设备同时应答")
+	var merged []byte
+	for _, f := range frames {
+		for i, v := range f {
+			if i >= len(merged) {
+				merged = append(merged, v)
+			} else {
+				merged[i] &= v
+			}
+		}
+	}
+	merged[len(merged)-1] ^= 0x5A
+	return merged
+}
+
+func rtuFrame(unitID uint8, pdu []byte) []byte {
+	out := append([]byte{unitID}, pdu...)
+	return binary.LittleEndian.AppendUint16(out, modbus.CRC16(out))
+}
+
+func validCRC(frame []byte) bool {
+	n := len(frame)
+	return n >= 4 && modbus.CRC16(frame[:n-2]) == binary.LittleEndian.Uint16(frame[n-2:])
+}
+
+func (b *bus) execute(br *simBreaker, pdu []byte) []byte {
+	fc := pdu[0]
+	data := pdu[1:]
+
+	switch fc {
+	case 0x01:
+		if len(data) < 4 {
+			return exception(fc, exIllegalValue)
+		}
+		start, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if count == 0 || count > 2000 {
+			return exception(fc, exIllegalValue)
+		}
+		bits := make([]byte, (count+7)/8)
+		br.mu.Lock()
+		for i := uint16(0); i < count; i++ {
+			if br.readCoil(start + i) {
+				bits[i/8] |= 1 << (i % 8)
+			}
+		}
+		br.mu.Unlock()
+		return append([]byte{fc, byte(len(bits))}, bits...)
+
+	case 0x03, 0x04:
+		if len(data) < 4 {
+			return exception(fc, exIllegalValue)
+		}
+		start, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if count == 0 || count > 125 {
+			return exception(fc, exIllegalValue)
+		}
+		if fc == 0x03 && int(start)+int(count) > holdingCount {
+			return exception(fc, exIllegalAddress)
+		}
+		if fc == 0x04 && int(start)+int(count) > 38 {
+			return exception(fc, exIllegalAddress)
+		}
+		resp := []byte{fc, byte(count * 2)}
+		br.mu.Lock()
+		for i := uint16(0); i < count; i++ {
+			var v uint16
+			if fc == 0x03 {
+				v = br.holding[start+i]
+			} else {
+				v = br.readInput(start + i)
+			}
+			resp = binary.BigEndian.AppendUint16(resp, v)
+		}
+		br.mu.Unlock()
+		return resp
+
+	case 0x05:
+		if len(data) < 4 {
+			return exception(fc, exIllegalValue)
+		}
+		addr, value := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if value != 0xFF00 && value != 0x0000 {
+			return exception(fc, exIllegalValue)
+		}
+		if ex := b.writeCoil(br, addr, value == 0xFF00); ex != 0 {
+			return exception(fc, ex)
+		}
+		return append([]byte{fc}, data[:4]...)
+
+	case 0x06:
+		if len(data) < 4 {
+			return exception(fc, exIllegalValue)
+		}
+		addr, value := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if ex := b.writeHolding(br, addr, value); ex != 0 {
+			return exception(fc, ex)
+		}
+		return append([]byte{fc}, data[:4]...)
+
+	case 0x10:
+		if len(data) < 5 {
+			return exception(fc, exIllegalValue)
+		}
+		start, count := binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		byteCount := int(data[4])
+		if count == 0 || count > 123 || byteCount != int(count)*2 || len(data) < 5+byteCount {
+			return exception(fc, exIllegalValue)
+		}
+		for i := uint16(0); i < count; i++ {
+			value := binary.BigEndian.Uint16(data[5+2*i:])
+			if ex := b.writeHolding(br, start+i, value); ex != 0 {
+				return exception(fc, ex)
+			}
+		}
+		return append([]byte{fc}, data[:4]...)
+	}
+
+	return exception(fc, exIllegalFunction)
+}
+
+// writeCoil 写单个线圈（0基地址）
+func (b *bus) writeCoil(br *simBreaker, addr uint16, on bool) byte {
+	switch addr {
+	case 0: // 00001 复位配置
+		if on {
+			br.mu.Lock()
+			br.resetConfig()
+			br.mu.Unlock()
+		}
+	case 1: // 00002 远程合/分闸
+		br.switchRemote(on)
+	case 2: // 00003 远程锁定
+		br.mu.Lock()
+		br.setRemoteLock(on)
+		br.mu.Unlock()
+	case 3: // 00004 自动/手动
+		br.mu.Lock()
+		if on {
+			br.holding[holdControlBits] |= 0x01
+		} else {
+			br.holding[holdControlBits] &^= 0x01
+		}
+		br.mu.Unlock()
+	case 4: // 00005 清除记录
+		if on {
+			br.clearRecords()
+		}
+	case 5: // 00006 漏电试验按钮
+		if on {
+			br.mu.Lock()
+			if br.closed {
+				br.tripLocked(tripLeakage)
+				logrus.WithField("station", br.stationID).Info("漏电试验跳闸")
+			}
+			br.mu.Unlock()
+		}
+	default:
+		return exIllegalAddress
+	}
+	return 0
+}
+
+// writeHolding 写单个保持寄存器（0基地址）
+func (b *bus) writeHolding(br *simBreaker, addr uint16, value uint16) byte {
+	if int(addr) >= holdingCount {
+		return exIllegalAddress
+	}
+
+	switch addr {
+	case holdAddress:
+		id := uint8(value & 0xFF)
+		if id == 0 || id > 247 {
+			return exIllegalValue
+		}
+		br.mu.Lock()
+		br.holding[holdAddress] = value
+		br.mu.Unlock()
+		b.readdress(br, id)
+		return 0
+	case holdBaudRate:
+		switch value {
+		case 1200, 2400, 4800, 9600, 19200:
+		default:
+			return exIllegalValue
+		}
+	case holdRemoteSwitch:
+		if value != 0xFF00 && value != 0x0000 {
+			return exIllegalValue
+		}
+		br.switchRemote(value == 0xFF00)
+		return 0
+	}
+
+	br.mu.Lock()
+	br.holding[addr] = value
+	br.mu.Unlock()
+	return 0
+}
+
+func exception(fc byte, code byte) []byte {
+	return []byte{fc | 0x80, code}
+}
+
+// server 网关TCP服务端（仿RS485-ETH-M04）
+type server struct {
+	bus     *bus
+	framing string
+	delay   time.Duration
+}
+
+func (s *server) serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	remote := conn.RemoteAddr().String()
+	logrus.WithField("remote", remote).Debug("客户端连接")
+
+	r := bufio.NewReader(conn)
+	for {
+		var err error
+		if s.framing == "rtu" {
+			err = s.serveRTU(r, conn)
+		} else {
+			err = s.serveMBAP(r, conn)
+		}
+		if err != nil {
+			if err != io.EOF {
+				logrus.WithField("remote", remote).WithError(err).Debug("连接关闭")
+			}
+			return
+		}
+	}
+}
+
+// servePTY 在伪终端主端上按串口RTU提供服务，从端路径可作为断路器的串口设备。
+// 客户端关闭从端后主端读取返回EIO，此时等待客户端重新打开。
+func (s *server) servePTY(master *os.File) error {
+	r := bufio.NewReader(master)
+	for {
+		err := s.serveRTU(r, master)
+		if errors.Is(err, syscall.EIO) {
+			time.Sleep(100 * time.Millisecond)
+			r.Reset(master)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// serveMBAP 处理一帧MODBUS TCP（MBAP头）请求
+func (s *server) serveMBAP(r *bufio.Reader, w io.Writer) error {
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint16(header[4:6])
+	if length < 2 || length > 254 {
+		return fmt.Errorf("非法MBAP长度: %d", length)
+	}
+	pdu := make([]byte, length-1)
+	if _, err := io.ReadFull(r, pdu); err != nil {
+		return err
+	}
+
+	resps := s.bus.handle(header[6], pdu)
+	if len(resps) == 0 {
+		return nil
+	}
+	s.responseDelay()
+
+	// 网关校验总线上收到的RTU帧，CRC错误时丢弃，客户端表现为超时
+	frame := onWire(header[6], resps)
+	if !validCRC(frame) {
+		return nil
+	}
+	resp := frame[1 : len(frame)-2]
+
+	out := make([]byte, 7, 7+len(resp))
+	copy(out, header[:4])
+	binary.BigEndian.PutUint16(out[4:6], uint16(len(resp)+1))
+	out[6] = header[6]
+	_, err := w.Write(append(out, resp...))
+	return err
+}
+
+// serveRTU 处理一帧透传的MODBUS RTU请求（RTU over TCP）
+func (s *server) serveRTU(r *bufio.Reader, w io.Writer) error {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return err
+	}
+
+	var rest int
+	switch head[1] {
+	case 0x01, 0x03, 0x04, 0x05, 0x06:
+		rest = 4 + 2
+	case 0x10:
+		prefix := make([]byte, 5)
+		if _, err := io.ReadFull(r, prefix); err != nil {
+			return err
+		}
+		head = append(head, prefix...)
+		rest = int(prefix[4]) + 2
+	default:
+		// 无法确定帧长，丢弃缓冲区中的剩余数据
+		r.Discard(r.Buffered())
+		return nil
+	}
+
+	tail := make([]byte, rest)
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return err
+	}
+	frame := append(head, tail...)
+
+	if !validCRC(frame) {
+		logrus.WithField("frame", fmt.Sprintf("% X", frame)).Warn("CRC校验失败，丢弃请求")
+		return nil
+	}
+
+	resps := s.bus.handle(frame[0], frame[1:len(frame)-2])
+	if len(resps) == 0 {
+		return nil
+	}
+	s.responseDelay()
+
+	_, err := w.Write(onWire(frame[0], resps))
+	return err
+}
+
+func (s *server) responseDelay() {
+	if s.delay > 0 {
+		time.Sleep(s.delay)
+	}
+}
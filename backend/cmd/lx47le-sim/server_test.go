@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"smart-device-management/pkg/modbus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakerRegisterModel(t *testing.T) {
+	pdu := func(fc byte, words ...uint16) []byte {
+		out := []byte{fc}
+		for _, w := range words {
+			out = binary.BigEndian.AppendUint16(out, w)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		prepare func(br *simBreaker)
+		request []byte
+		want    []byte
+	}{
+		{"读地址和波特率", nil, pdu(0x03, 0, 2), []byte{0x03, 4, 0, 1, 0x25, 0x80}},
+		{"读越界保持寄存器", nil, pdu(0x03, holdingCount-1, 2), []byte{0x83, exIllegalAddress}},
+		{"读合闸状态", nil, pdu(0x04, 0, 1), []byte{0x04, 2, 0x00, 0xF0}},
+		{"分闸时电流为零", func(br *simBreaker) { br.trip(tripRemote) }, pdu(0x04, 8, 1), []byte{0x04, 2, 0, 0}},
+		{"读线圈", nil, pdu(0x01, 0, 4), []byte{0x01, 1, 0x02}},
+		{"远程分闸", nil, pdu(0x05, 1, 0x0000), pdu(0x05, 1, 0x0000)},
+		{"非法线圈值", nil, pdu(0x05, 1, 0x1234), []byte{0x85, exIllegalValue}},
+		{"锁定后拒绝合闸", func(br *simBreaker) { br.apply(BreakerState{Closed: boolPtr(false), RemoteLocked: boolPtr(true)}) }, pdu(0x05, 1, 0xFF00), pdu(0x05, 1, 0xFF00)},
+		{"非法波特率", nil, pdu(0x06, holdBaudRate, 4000), []byte{0x86, exIllegalValue}},
+		{"非法站号", nil, pdu(0x06, holdAddress, 0), []byte{0x86, exIllegalValue}},
+		{"写多个寄存器", nil, append(pdu(0x10, holdOvervoltage, 2), 4, 0x01, 0x0E, 0x00, 0xA5), pdu(0x10, holdOvervoltage, 2)},
+		{"不支持的功能码", nil, []byte{0x2B}, []byte{0xAB, exIllegalFunction}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBus()
+			br := newSimBreaker(1)
+			b.add(br)
+			if tt.prepare != nil {
+				tt.prepare(br)
+			}
+			resps := b.handle(1, tt.request)
+			require.Len(t, resps, 1)
+			assert.Equal(t, tt.want, resps[0])
+		})
+	}
+}
+
+func TestBreakerStateTransitions(t *testing.T) {
+	b := newBus()
+	br := newSimBreaker(1)
+	b.add(br)
+
+	// 远程锁定后合闸被拒绝，30001 仍为分闸
+	b.handle(1, []byte{0x05, 0, 1, 0x00, 0x00})
+	b.handle(1, []byte{0x05, 0, 2, 0xFF, 0x00})
+	b.handle(1, []byte{0x05, 0, 1, 0xFF, 0x00})
+	br.mu.Lock()
+	assert.False(t, br.closed)
+	assert.Equal(t, tripRemote, br.latestTrip)
+	br.mu.Unlock()
+
+	// 解锁后 40014 合闸生效
+	b.handle(1, []byte{0x05, 0, 2, 0x00, 0x00})
+	b.handle(1, []byte{0x06, 0, holdRemoteSwitch, 0xFF, 0x00})
+	assert.Equal(t, [][]byte{{0x04, 2, 0x00, 0xF0}}, b.handle(1, []byte{0x04, 0, 0, 0, 1}))
+
+	// 过流超过延时后跳闸，跳闸记录在 30002 低4位
+	br.apply(BreakerState{LoadCurrent: floatPtr(70)})
+	now := time.Now()
+	br.tick(now, time.Second)
+	br.tick(now.Add(6*time.Second), time.Second)
+	assert.Equal(t, [][]byte{{0x04, 2, 0xFF, 0x71}}, b.handle(1, []byte{0x04, 0, 1, 0, 1}))
+
+	// 写站号后旧地址不再应答，新地址与已有设备冲突时两台都应答
+	b.add(newSimBreaker(5))
+	b.handle(1, []byte{0x06, 0, holdAddress, 0, 5})
+	assert.Empty(t, b.handle(1, []byte{0x03, 0, 0, 0, 1}))
+	assert.Len(t, b.handle(5, []byte{0x03, 0, 0, 0, 1}), 2)
+
+	// 广播不应答
+	assert.Nil(t, b.handle(0, []byte{0x05, 0, 4, 0xFF, 0x00}))
+}
+
+func TestServerRoundTrip(t *testing.T) {
+	for _, framing := range []modbus.Framing{modbus.FramingMBAP, modbus.FramingRTUOverTCP} {
+		t.Run(string(framing), func(t *testing.T) {
+			b := newBus()
+			b.add(newSimBreaker(3))
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer ln.Close()
+			srv := &server{bus: b, framing: string(framing)}
+			if framing == modbus.FramingRTUOverTCP {
+				srv.framing = "rtu"
+			}
+			go srv.serve(ln)
+
+			transport, err := modbus.Open(modbus.Config{Framing: framing, Address: ln.Addr().String(), Timeout: time.Second})
+			require.NoError(t, err)
+			defer transport.Close()
+			client := modbus.NewModbusClientWithTransport(transport, 3)
+
+			values, err := client.ReadInputRegisters(7, 1)
+			require.NoError(t, err)
+			assert.Equal(t, []uint16{220}, values)
+
+			require.NoError(t, client.WriteSingleRegister(holdAddress, 9))
+			_, err = client.ReadHoldingRegisters(0, 1)
+			assert.Error(t, err, "旧站号不再应答")
+
+			client = modbus.NewModbusClientWithTransport(transport, 9)
+			require.NoError(t, client.WriteSingleCoil(1, false))
+			coils, err := client.ReadCoils(0, 2)
+			require.NoError(t, err)
+			assert.Equal(t, []bool{false, false}, coils)
+		})
+	}
+}
+
+func boolPtr(v bool) *bool { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -21,7 +22,10 @@ import (
 	"smart-device-management/internal/services"
 	"smart-device-management/internal/utils"
 	"smart-device-management/pkg/database"
+	"smart-device-management/pkg/infrared"
 	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+	"smart-device-management/pkg/validation"
 	"smart-device-management/pkg/websocket"
 )
 
@@ -54,11 +58,62 @@ func main() {
 	// 初始化WebSocket Hub
 	websocket.InitWebSocketHub()
 
+	// 配置MODBUS网关调度器
+	initModbusGateways(cfg)
+	defer modbus.DefaultGatewayPool().Close()
+
 	// 启动断路器状态监控服务
 	if err := startBreakerStatusMonitor(); err != nil {
 		logrus.Warn("启动断路器状态监控失败: ", err)
 	}
 
+	// 启动断路器遥测采集服务
+	if err := startBreakerTelemetryCollector(cfg); err != nil {
+		logrus.Warn("启动断路器遥测采集失败: ", err)
+	}
+
+	// 启动断路器漏电自检计划
+	if err := startBreakerSelfTestService(cfg); err != nil {
+		logrus.Warn("启动断路器漏电自检计划失败: ", err)
+	}
+
+	// 启动温度采集（与断路器共用网关调度器，同一端口的请求串行发送）
+	if err := startTemperatureCollector(cfg); err != nil {
+		logrus.Warn("启动温度采集失败: ", err)
+	}
+
+	// 启动温度数据降采样
+	if err := startTemperatureRollupService(cfg); err != nil {
+		logrus.Warn("启动温度数据降采样失败: ", err)
+	}
+
+	// 启动温度传感器故障告警推送
+	if err := startSensorFaultService(); err != nil {
+		logrus.Warn("启动传感器故障告警失败: ", err)
+	}
+
+	// 启动内置MODBUS TCP从站
+	if cfg.ModbusSlave.Enabled {
+		if err := startModbusSlave(cfg); err != nil {
+			logrus.Warn("启动MODBUS从站失败: ", err)
+		}
+	}
+
+	// 启动红外空调控制器服务（TCP客户端模式设备的监听端口）
+	if err := startIRControllerService(cfg); err != nil {
+		logrus.Warn("启动红外控制器监听端口失败: ", err)
+	}
+
+	// 启动制冷闭环控制（依赖红外控制器服务）
+	if err := startCoolingControlService(); err != nil {
+		logrus.Warn("启动制冷闭环控制失败: ", err)
+	}
+
+	// 启动服务器负载采集（AI策略 server_load 条件使用）
+	if err := startServerMetricsService(cfg); err != nil {
+		logrus.Warn("启动服务器负载采集失败: ", err)
+	}
+
 	// 启动AI策略监控服务
 	if err := startAIStrategyMonitor(); err != nil {
 		logrus.Warn("启动AI策略监控失败: ", err)
@@ -103,9 +158,31 @@ func main() {
 	logrus.Info("服务器已关闭")
 }
 
+// initModbusGateways 按配置设置网关调度参数（帧间隔、长连接空闲时间）和报文抓包目录
+func initModbusGateways(cfg *config.Config) {
+	opts := modbus.DefaultGatewayOptions()
+	opts.FrameGap = cfg.Modbus.FrameGap
+	opts.IdleTimeout = cfg.Modbus.IdleTimeout
+	modbus.DefaultGatewayPool().SetOptions(opts)
+	logrus.Infof("MODBUS网关调度器: 帧间隔=%v, 空闲断开=%v", opts.FrameGap, opts.IdleTimeout)
+
+	modbus.DefaultTracer().Configure(cfg.Modbus.CaptureDir, cfg.Modbus.CaptureRingSize, cfg.Modbus.CaptureMaxFrames)
+}
+
 // 全局变量保存监控服务引用
 var globalBreakerStatusMonitor *services.BreakerStatusMonitor
 var globalAIStrategyMonitor *services.AIStrategyMonitor
+var globalBreakerTelemetryCollector *services.BreakerTelemetryCollector
+var globalBreakerSelfTestService *services.BreakerSelfTestService
+var globalModbusSlaveService *services.ModbusSlaveService
+var globalTemperatureCollector *services.TemperatureCollector
+var globalTemperatureRollupService *services.TemperatureRollupService
+var globalSensorFaultService *services.TemperatureSensorFaultService
+var globalIRControllerService *services.IRControllerService
+var globalACActionService *services.ACActionService
+var globalIRCodeService *services.IRCodeService
+var globalCoolingControlService *services.CoolingControlService
+var globalServerMetricsService *services.ServerMetricsService
 
 // startBreakerStatusMonitor 启动断路器状态监控服务
 func startBreakerStatusMonitor() error {
@@ -131,6 +208,167 @@ func startBreakerStatusMonitor() error {
 	return nil
 }
 
+// startBreakerTelemetryCollector 启动断路器遥测采集服务（遥测入库与电量累计）
+func startBreakerTelemetryCollector(cfg *config.Config) error {
+	db := database.GetDB()
+	appLogger := logger.GetLogger()
+
+	collector := services.NewBreakerTelemetryCollector(db, appLogger, services.NewModbusService(appLogger, db), cfg.Telemetry.Interval, cfg.Telemetry.Retention)
+	if err := collector.Start(); err != nil {
+		return fmt.Errorf("启动断路器遥测采集失败: %w", err)
+	}
+
+	globalBreakerTelemetryCollector = collector
+
+	logrus.Info("断路器遥测采集服务已启动")
+	return nil
+}
+
+// startBreakerSelfTestService 启动断路器漏电自检计划（同一服务也用于手动自检和合规报告接口）
+func startBreakerSelfTestService(cfg *config.Config) error {
+	db := database.GetDB()
+	appLogger := logger.GetLogger()
+
+	globalBreakerSelfTestService = services.NewBreakerSelfTestService(db, appLogger, cfg.SelfTest.TripTimeout, cfg.SelfTest.MaxAgeDays)
+	if err := globalBreakerSelfTestService.Start(); err != nil {
+		return fmt.Errorf("启动断路器漏电自检计划失败: %w", err)
+	}
+
+	logrus.Info("断路器漏电自检计划已启动")
+	return nil
+}
+
+// startTemperatureCollector 启动温度采集（按传感器和通道间隔采集、健康检测与故障判定）
+func startTemperatureCollector(cfg *config.Config) error {
+	health := validation.HealthOptions{
+		StuckSamples: cfg.Temperature.StuckSamples,
+		SpikeDelta:   cfg.Temperature.SpikeDelta,
+		FaultSamples: cfg.Temperature.FaultSamples,
+	}
+	collector := services.NewTemperatureCollector(database.GetDB(), logger.GetLogger(), cfg.Temperature.ReloadInterval, health)
+	if err := collector.Start(); err != nil {
+		return fmt.Errorf("启动温度采集失败: %w", err)
+	}
+
+	globalTemperatureCollector = collector
+
+	logrus.Info("温度采集服务已启动")
+	return nil
+}
+
+// startTemperatureRollupService 启动温度数据降采样（1分钟/1小时/1天聚合与过期清理）
+func startTemperatureRollupService(cfg *config.Config) error {
+	retention := services.TemperatureRetention{
+		Raw:    cfg.Temperature.RawRetention,
+		Minute: cfg.Temperature.MinuteRetention,
+		Hour:   cfg.Temperature.HourRetention,
+		Day:    cfg.Temperature.DayRetention,
+	}
+	rollup := services.NewTemperatureRollupService(database.GetDB(), logger.GetLogger(), cfg.Temperature.RollupInterval, retention)
+	if err := rollup.Start(); err != nil {
+		return fmt.Errorf("启动温度数据降采样失败: %w", err)
+	}
+
+	globalTemperatureRollupService = rollup
+
+	logrus.Info("温度数据降采样服务已启动")
+	return nil
+}
+
+// startSensorFaultService 启动温度传感器故障告警推送（故障由温度采集服务的健康检测产生）
+func startSensorFaultService() error {
+	faultService := services.NewTemperatureSensorFaultService(database.GetDB(), logger.GetLogger())
+	if err := faultService.Start(); err != nil {
+		return fmt.Errorf("启动传感器故障告警失败: %w", err)
+	}
+
+	globalSensorFaultService = faultService
+
+	logrus.Info("传感器故障告警服务已启动")
+	return nil
+}
+
+// startModbusSlave 启动内置MODBUS TCP从站，供BMS/SCADA轮询温度、断路器状态、功率和告警数
+func startModbusSlave(cfg *config.Config) error {
+	db := database.GetDB()
+	appLogger := logger.GetLogger()
+
+	breakerService := services.NewBreakerService(repositories.NewBreakerRepository(db), repositories.NewServerRepository(db), appLogger, db)
+	slave, err := services.NewModbusSlaveService(db, breakerService, appLogger, services.ModbusSlaveOptions{
+		Listen:          cfg.ModbusSlave.Listen,
+		MapFile:         cfg.ModbusSlave.MapFile,
+		RefreshInterval: cfg.ModbusSlave.RefreshInterval,
+		AllowedClients:  strings.Split(cfg.ModbusSlave.AllowedClients, ","),
+		WriteUser:       cfg.ModbusSlave.WriteUser,
+	})
+	if err != nil {
+		return err
+	}
+	if err := slave.Start(); err != nil {
+		return err
+	}
+
+	globalModbusSlaveService = slave
+
+	logrus.Infof("MODBUS从站已启动: %s", cfg.ModbusSlave.Listen)
+	return nil
+}
+
+// startIRControllerService 创建红外空调控制器服务，配置了监听地址时接受TCP客户端模式设备的连接。
+// 监听失败时服务仍可管理其他通信模式的控制器
+func startIRControllerService(cfg *config.Config) error {
+	var listener *infrared.Listener
+	var err error
+	if cfg.IRController.Listen != "" {
+		listener = infrared.NewListener(cfg.IRController.Timeout)
+		if addr, listenErr := listener.Listen(cfg.IRController.Listen); listenErr != nil {
+			listener = nil
+			err = fmt.Errorf("监听 %s 失败: %w", cfg.IRController.Listen, listenErr)
+		} else {
+			logrus.Infof("红外控制器监听端口已启动: %s", addr)
+		}
+	}
+
+	globalIRControllerService = services.NewIRControllerService(database.GetDB(), logger.GetLogger(), listener, cfg.IRController.Timeout)
+	globalACActionService = services.NewACActionService(database.GetDB(), logger.GetLogger(), globalIRControllerService)
+	if resumeErr := globalACActionService.ResumePending(); resumeErr != nil {
+		logrus.Warnf("恢复空调动作验证失败: %v", resumeErr)
+	}
+	globalIRCodeService = services.NewIRCodeService(database.GetDB(), logger.GetLogger(), globalIRControllerService)
+	if cancelErr := globalIRCodeService.CancelStaleSessions(); cancelErr != nil {
+		logrus.Warnf("清理中断的红外学习会话失败: %v", cancelErr)
+	}
+	return err
+}
+
+// startCoolingControlService 启动各制冷区域的温度闭环控制
+func startCoolingControlService() error {
+	globalCoolingControlService = services.NewCoolingControlService(database.GetDB(), logger.GetLogger(), globalIRControllerService)
+	if err := globalCoolingControlService.Start(); err != nil {
+		return err
+	}
+
+	logrus.Info("制冷闭环控制服务已启动")
+	return nil
+}
+
+// startServerMetricsService 创建服务器负载指标服务，配置了采集间隔时通过SSH定时采集，
+// 未配置时只接收代理上报
+func startServerMetricsService(cfg *config.Config) error {
+	globalServerMetricsService = services.NewServerMetricsService(database.GetDB(), logger.GetLogger(),
+		cfg.ServerMetrics.Interval, cfg.ServerMetrics.MaxAge, cfg.ServerMetrics.Retention)
+	if cfg.ServerMetrics.Interval <= 0 {
+		logrus.Info("未配置服务器负载采集间隔，只接收代理上报")
+		return nil
+	}
+	if err := globalServerMetricsService.Start(); err != nil {
+		return err
+	}
+
+	logrus.Info("服务器负载采集服务已启动")
+	return nil
+}
+
 // startAIStrategyMonitor 启动AI策略监控服务
 func startAIStrategyMonitor() error {
 	db := database.GetDB()
@@ -143,7 +381,7 @@ func startAIStrategyMonitor() error {
 	serverService := services.NewServerService(serverRepo, appLogger)
 
 	// 创建AI策略监控服务
-	aiStrategyMonitor := services.NewAIStrategyMonitor(db, logrus.StandardLogger(), breakerService, serverService)
+	aiStrategyMonitor := services.NewAIStrategyMonitor(db, logrus.StandardLogger(), breakerService, serverService, globalACActionService, globalServerMetricsService)
 
 	// 启动监控
 	if err := aiStrategyMonitor.Start(); err != nil {
@@ -239,6 +477,14 @@ func setupRouter() *gin.Engine {
 		deviceGroup.PUT("/:id/status", middleware.AuthMiddleware(), middleware.RequireOperator(), deviceController.UpdateDeviceStatus)
 	}
 
+	// 设备驱动路由
+	driverController := controllers.NewDriverController()
+	driverGroup := apiV1.Group("/drivers")
+	{
+		driverGroup.GET("", middleware.AuthMiddleware(), driverController.GetDrivers)
+		driverGroup.GET("/:model", middleware.AuthMiddleware(), driverController.GetDriver)
+	}
+
 	// 系统概览路由
 	dashboardController := controllers.NewDashboardController()
 	dashboardGroup := apiV1.Group("/dashboard")
@@ -269,6 +515,17 @@ func setupRouter() *gin.Engine {
 		sensorsGroup.DELETE("/:id", middleware.AuthMiddleware(), api.DeleteTemperatureSensor)
 		sensorsGroup.DELETE("/:id/channels/:channel", middleware.AuthMiddleware(), api.DeleteTemperatureChannel)
 		sensorsGroup.POST("/:id/test", middleware.AuthMiddleware(), api.TestTemperatureSensor)
+
+		// 通道校准
+		calibrationController := controllers.NewTemperatureCalibrationController(services.NewTemperatureCalibrationService(database.GetDB(), logger.GetLogger()))
+		sensorsGroup.GET("/:id/channels/:channel/calibration", middleware.AuthMiddleware(), calibrationController.GetCalibration)
+		sensorsGroup.PUT("/:id/channels/:channel/calibration", middleware.AuthMiddleware(), middleware.RequireOperator(), calibrationController.UpdateCalibration)
+		sensorsGroup.POST("/:id/channels/:channel/calibration/reference", middleware.AuthMiddleware(), middleware.RequireOperator(), calibrationController.CalibrateFromReference)
+		sensorsGroup.GET("/:id/channels/:channel/calibration/history", middleware.AuthMiddleware(), calibrationController.GetCalibrationHistory)
+
+		// 传感器故障告警
+		sensorFaultController := controllers.NewTemperatureSensorFaultController(services.NewTemperatureSensorFaultService(database.GetDB(), logger.GetLogger()))
+		sensorsGroup.GET("/faults", middleware.AuthMiddleware(), sensorFaultController.ListFaults)
 	}
 
 	// 温度监控路由
@@ -287,6 +544,7 @@ func setupRouter() *gin.Engine {
 	// 服务器管理路由
 	serverService := services.NewServerService(repositories.NewServerRepository(database.GetDB()), logger.GetLogger())
 	serverController := controllers.NewServerController(serverService)
+	serverMetricsController := controllers.NewServerMetricsController(globalServerMetricsService)
 	serverGroup := apiV1.Group("/servers")
 	{
 		serverGroup.GET("", middleware.AuthMiddleware(), serverController.GetServers)
@@ -296,6 +554,8 @@ func setupRouter() *gin.Engine {
 		serverGroup.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), serverController.DeleteServer)
 		serverGroup.GET("/:id/status", middleware.AuthMiddleware(), serverController.GetServerStatus)
 		serverGroup.GET("/:id/hardware", middleware.AuthMiddleware(), serverController.GetServerHardware)
+		serverGroup.GET("/:id/metrics", middleware.AuthMiddleware(), serverMetricsController.GetMetrics)
+		serverGroup.POST("/:id/metrics", middleware.AuthMiddleware(), middleware.RequireOperator(), serverMetricsController.ReportMetrics)
 		serverGroup.POST("/:id/test", middleware.AuthMiddleware(), serverController.TestServerConnection)
 		serverGroup.POST("/detect-hardware", middleware.AuthMiddleware(), serverController.DetectServerHardware)
 		serverGroup.POST("/:id/execute", middleware.AuthMiddleware(), middleware.RequireOperator(), serverController.ExecuteCommand)
@@ -308,6 +568,8 @@ func setupRouter() *gin.Engine {
 	// 断路器管理路由
 	breakerService := services.NewBreakerService(repositories.NewBreakerRepository(database.GetDB()), repositories.NewServerRepository(database.GetDB()), logger.GetLogger(), database.GetDB())
 	breakerController := controllers.NewBreakerController(breakerService)
+	energyController := controllers.NewEnergyController(services.NewEnergyService(database.GetDB(), logger.GetLogger()))
+	selfTestController := controllers.NewSelfTestController(globalBreakerSelfTestService)
 	breakerGroup := apiV1.Group("/breakers")
 	{
 		breakerGroup.GET("", middleware.AuthMiddleware(), breakerController.GetBreakers)
@@ -316,6 +578,16 @@ func setupRouter() *gin.Engine {
 		breakerGroup.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), breakerController.UpdateBreaker)
 		breakerGroup.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), breakerController.DeleteBreaker)
 		breakerGroup.GET("/:id/realtime", middleware.AuthMiddleware(), breakerController.GetBreakerRealTimeData)
+		breakerGroup.GET("/:id/trips", middleware.AuthMiddleware(), breakerController.GetBreakerTrips)
+		breakerGroup.GET("/:id/energy", middleware.AuthMiddleware(), energyController.GetBreakerEnergy)
+		breakerGroup.GET("/:id/telemetry", middleware.AuthMiddleware(), energyController.GetBreakerTelemetry)
+		breakerGroup.GET("/:id/protection", middleware.AuthMiddleware(), middleware.RequireAdmin(), breakerController.GetBreakerProtection)
+		breakerGroup.PUT("/:id/protection", middleware.AuthMiddleware(), middleware.RequireAdmin(), breakerController.UpdateBreakerProtection)
+		breakerGroup.GET("/:id/protection/history", middleware.AuthMiddleware(), middleware.RequireAdmin(), breakerController.GetBreakerProtectionHistory)
+		breakerGroup.GET("/:id/self-test/schedule", middleware.AuthMiddleware(), selfTestController.GetSchedule)
+		breakerGroup.PUT("/:id/self-test/schedule", middleware.AuthMiddleware(), middleware.RequireOperator(), selfTestController.UpdateSchedule)
+		breakerGroup.POST("/:id/self-test", middleware.AuthMiddleware(), middleware.RequireOperator(), selfTestController.RunSelfTest)
+		breakerGroup.GET("/:id/self-tests", middleware.AuthMiddleware(), selfTestController.GetBreakerSelfTests)
 		breakerGroup.POST("/:id/control", middleware.AuthMiddleware(), middleware.RequireOperator(), breakerController.ControlBreaker)
 		breakerGroup.GET("/:id/control/:control_id", middleware.AuthMiddleware(), breakerController.GetControlStatus)
 		breakerGroup.POST("/:id/lock", middleware.AuthMiddleware(), middleware.RequireOperator(), breakerController.ControlBreakerLock)
@@ -325,6 +597,14 @@ func setupRouter() *gin.Engine {
 		breakerGroup.DELETE("/:id/bindings/:binding_id", middleware.AuthMiddleware(), middleware.RequireOperator(), breakerController.DeleteBinding)
 	}
 
+	// 漏电自检记录与合规报告路由
+	selfTestGroup := apiV1.Group("/self-tests")
+	{
+		selfTestGroup.GET("", middleware.AuthMiddleware(), selfTestController.ListSelfTests)
+		selfTestGroup.GET("/compliance", middleware.AuthMiddleware(), selfTestController.GetCompliance)
+		selfTestGroup.POST("/:id/reclose", middleware.AuthMiddleware(), middleware.RequireOperator(), selfTestController.ApproveReclose)
+	}
+
 	// 状态监控路由
 	statusMonitorController := controllers.NewStatusMonitorController(breakerService.GetStatusMonitorService(), globalBreakerStatusMonitor)
 	statusMonitorGroup := apiV1.Group("/status-monitor")
@@ -336,6 +616,124 @@ func setupRouter() *gin.Engine {
 		statusMonitorGroup.GET("/interval-options", middleware.AuthMiddleware(), statusMonitorController.GetMonitorIntervalOptions)
 		statusMonitorGroup.POST("/check", middleware.AuthMiddleware(), middleware.RequireOperator(), statusMonitorController.TriggerManualCheck)
 		statusMonitorGroup.GET("/history", middleware.AuthMiddleware(), statusMonitorController.GetMonitorHistory)
+		statusMonitorGroup.GET("/gateways", middleware.AuthMiddleware(), statusMonitorController.GetGatewayStats)
+	}
+
+	// 红外空调控制器路由
+	irControllerController := controllers.NewIRControllerController(globalIRControllerService)
+	irCodeController := controllers.NewIRCodeController(globalIRCodeService)
+	irGroup := apiV1.Group("/ir-controllers")
+	{
+		irGroup.GET("", middleware.AuthMiddleware(), irControllerController.GetControllers)
+		irGroup.POST("", middleware.AuthMiddleware(), middleware.RequireOperator(), irControllerController.CreateController)
+		irGroup.GET("/brands", middleware.AuthMiddleware(), irControllerController.GetBrands)
+		irGroup.GET("/connections", middleware.AuthMiddleware(), irControllerController.GetConnections)
+		irGroup.GET("/:id", middleware.AuthMiddleware(), irControllerController.GetController)
+		irGroup.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), irControllerController.UpdateController)
+		irGroup.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), irControllerController.DeleteController)
+		irGroup.POST("/:id/test", middleware.AuthMiddleware(), irControllerController.TestConnection)
+		irGroup.GET("/:id/status", middleware.AuthMiddleware(), irControllerController.GetStatus)
+		irGroup.POST("/:id/match", middleware.AuthMiddleware(), middleware.RequireOperator(), irControllerController.MatchAC)
+		irGroup.PUT("/:id/brand", middleware.AuthMiddleware(), middleware.RequireOperator(), irControllerController.SetACBrand)
+		irGroup.POST("/:id/ac", middleware.AuthMiddleware(), middleware.RequireOperator(), irControllerController.ControlAC)
+		irGroup.POST("/:id/learn", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.StartLearning)
+		irGroup.POST("/:id/send-code", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.SendCode)
+	}
+
+	// 红外码库路由
+	irCodeGroup := apiV1.Group("/ir-codes")
+	{
+		irCodeGroup.GET("/code-sets", middleware.AuthMiddleware(), irCodeController.GetCodeSets)
+		irCodeGroup.POST("/code-sets", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.CreateCodeSet)
+		irCodeGroup.GET("/code-sets/:id", middleware.AuthMiddleware(), irCodeController.GetCodeSet)
+		irCodeGroup.PUT("/code-sets/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.UpdateCodeSet)
+		irCodeGroup.DELETE("/code-sets/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.DeleteCodeSet)
+		irCodeGroup.DELETE("/code-sets/:id/codes/:button", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.DeleteLearnedCode)
+		irCodeGroup.GET("/learning-sessions/:id", middleware.AuthMiddleware(), irCodeController.GetLearningSession)
+		irCodeGroup.GET("/brand-codes", middleware.AuthMiddleware(), irCodeController.GetBrandCodes)
+		irCodeGroup.POST("/brand-codes/import", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.ImportBrandCodes)
+		irCodeGroup.GET("/key-codes", middleware.AuthMiddleware(), irCodeController.GetKeyCodes)
+		irCodeGroup.POST("/key-codes/import", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.ImportKeyCodes)
+	}
+
+	// 空调档案路由
+	acProfileGroup := apiV1.Group("/ac-profiles")
+	{
+		acProfileGroup.GET("", middleware.AuthMiddleware(), irCodeController.GetProfiles)
+		acProfileGroup.POST("", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.CreateProfile)
+		acProfileGroup.GET("/:id", middleware.AuthMiddleware(), irCodeController.GetProfile)
+		acProfileGroup.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.UpdateProfile)
+		acProfileGroup.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), irCodeController.DeleteProfile)
+	}
+
+	// 制冷区域闭环控制路由
+	coolingZoneController := controllers.NewCoolingZoneController(globalCoolingControlService)
+	coolingGroup := apiV1.Group("/cooling-zones")
+	{
+		coolingGroup.GET("", middleware.AuthMiddleware(), coolingZoneController.GetZones)
+		coolingGroup.POST("", middleware.AuthMiddleware(), middleware.RequireOperator(), coolingZoneController.CreateZone)
+		coolingGroup.GET("/:id", middleware.AuthMiddleware(), coolingZoneController.GetZone)
+		coolingGroup.PUT("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), coolingZoneController.UpdateZone)
+		coolingGroup.DELETE("/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), coolingZoneController.DeleteZone)
+		coolingGroup.PUT("/:id/enabled", middleware.AuthMiddleware(), middleware.RequireOperator(), coolingZoneController.SetEnabled)
+		coolingGroup.PUT("/:id/override", middleware.AuthMiddleware(), middleware.RequireOperator(), coolingZoneController.SetOverride)
+		coolingGroup.DELETE("/:id/override", middleware.AuthMiddleware(), middleware.RequireOperator(), coolingZoneController.ClearOverride)
+		coolingGroup.GET("/:id/decisions", middleware.AuthMiddleware(), coolingZoneController.GetDecisions)
+	}
+
+	// 用电量统计路由
+	energyGroup := apiV1.Group("/energy")
+	{
+		energyGroup.GET("/consumption", middleware.AuthMiddleware(), energyController.GetConsumption)
+	}
+
+	// MODBUS报文抓包路由（仅管理员）
+	modbusCaptureController := controllers.NewModbusCaptureController(services.NewModbusCaptureService(database.GetDB(), logger.GetLogger()))
+	modbusSlaveController := controllers.NewModbusSlaveController(globalModbusSlaveService)
+	modbusGroup := apiV1.Group("/modbus")
+	{
+		modbusGroup.GET("/captures", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusCaptureController.ListCaptures)
+		modbusGroup.POST("/captures", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusCaptureController.StartCapture)
+		modbusGroup.GET("/captures/:id", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusCaptureController.GetCapture)
+		modbusGroup.POST("/captures/:id/stop", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusCaptureController.StopCapture)
+		modbusGroup.GET("/captures/:id/download", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusCaptureController.DownloadCapture)
+		modbusGroup.GET("/slave", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusSlaveController.GetSlaveStatus)
+		modbusGroup.POST("/slave/reload", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusSlaveController.ReloadSlaveMap)
+		modbusGroup.DELETE("/captures/:id", middleware.AuthMiddleware(), middleware.RequireAdmin(), modbusCaptureController.DeleteCapture)
+	}
+
+	// 紧急断电路由（仅管理员，生成计划后需二次确认）
+	emergencyService := services.NewEmergencyPowerOffService(database.GetDB(), logger.GetLogger())
+	if err := emergencyService.ExpireStalePlans(); err != nil {
+		logrus.Warnf("清理遗留的紧急断电计划失败: %v", err)
+	}
+	emergencyController := controllers.NewEmergencyController(emergencyService)
+	emergencyGroup := apiV1.Group("/emergency")
+	{
+		emergencyGroup.GET("/power-off", middleware.AuthMiddleware(), middleware.RequireAdmin(), emergencyController.ListPowerOffs)
+		emergencyGroup.POST("/power-off", middleware.AuthMiddleware(), middleware.RequireAdmin(), emergencyController.PreparePowerOff)
+		emergencyGroup.GET("/power-off/:id", middleware.AuthMiddleware(), middleware.RequireAdmin(), emergencyController.GetPowerOff)
+		emergencyGroup.POST("/power-off/:id/execute", middleware.AuthMiddleware(), middleware.RequireAdmin(), emergencyController.ExecutePowerOff)
+		emergencyGroup.POST("/power-off/:id/cancel", middleware.AuthMiddleware(), middleware.RequireAdmin(), emergencyController.CancelPowerOff)
+	}
+
+	// 断路器投运调试路由（扫描站号需操作员，修改通信参数需管理员）
+	commissioningController := controllers.NewCommissioningController(services.NewCommissioningService(breakerService, database.GetDB(), logger.GetLogger()))
+	commissioningGroup := apiV1.Group("/commissioning")
+	{
+		commissioningGroup.POST("/scan", middleware.AuthMiddleware(), middleware.RequireOperator(), commissioningController.ScanStations)
+		commissioningGroup.POST("/breakers", middleware.AuthMiddleware(), middleware.RequireAdmin(), commissioningController.CommissionBreaker)
+	}
+
+	// 网络设备自动发现路由
+	discoveryController := controllers.NewDiscoveryController(services.NewDiscoveryService(breakerService, database.GetDB(), logger.GetLogger()))
+	discoveryGroup := apiV1.Group("/discovery")
+	{
+		discoveryGroup.GET("/jobs", middleware.AuthMiddleware(), middleware.RequireOperator(), discoveryController.ListDiscoveries)
+		discoveryGroup.POST("/jobs", middleware.AuthMiddleware(), middleware.RequireOperator(), discoveryController.StartDiscovery)
+		discoveryGroup.GET("/jobs/:id", middleware.AuthMiddleware(), middleware.RequireOperator(), discoveryController.GetDiscovery)
+		discoveryGroup.POST("/jobs/:id/cancel", middleware.AuthMiddleware(), middleware.RequireOperator(), discoveryController.CancelDiscovery)
+		discoveryGroup.POST("/jobs/:id/import", middleware.AuthMiddleware(), middleware.RequireOperator(), discoveryController.ImportCandidates)
 	}
 
 	// 告警管理路由
@@ -355,7 +753,7 @@ func setupRouter() *gin.Engine {
 
 	// AI智能控制路由
 	actionTemplateRepo := repositories.NewActionTemplateRepository(database.GetDB())
-	aiControlController := controllers.NewAIControlController(breakerService, serverService, actionTemplateRepo)
+	aiControlController := controllers.NewAIControlController(breakerService, serverService, actionTemplateRepo, globalACActionService)
 	aiControlGroup := apiV1.Group("/ai-control")
 	{
 		// 策略管理
@@ -367,6 +765,7 @@ func setupRouter() *gin.Engine {
 		aiControlGroup.PUT("/strategies/:id/toggle", middleware.AuthMiddleware(), middleware.RequireAdmin(), aiControlController.ToggleStrategy)
 		aiControlGroup.POST("/strategies/:id/execute", middleware.AuthMiddleware(), middleware.RequireOperator(), aiControlController.ExecuteStrategy)
 		aiControlGroup.GET("/executions", middleware.AuthMiddleware(), aiControlController.GetExecutions)
+		aiControlGroup.GET("/ac-actions", middleware.AuthMiddleware(), aiControlController.GetACActions)
 
 		// 动作模板管理
 		aiControlGroup.GET("/action-templates", middleware.AuthMiddleware(), aiControlController.GetActionTemplates)
@@ -440,10 +839,34 @@ func autoMigrate() error {
 		&models.Device{},
 		&models.DeviceConnection{},
 		&models.TemperatureSensor{},
+		&models.TemperatureReading{},
 		&models.Server{},
 		&models.Breaker{},
 		&models.BreakerServerBinding{},
 		&models.BreakerControl{},
+		&models.BreakerTripEvent{},
+		&models.BreakerProtectionChange{},
+		&models.BreakerTelemetrySample{},
+		&models.BreakerEnergyCounter{},
+		&models.BreakerEnergyHourly{},
+		&models.BreakerSelfTestSchedule{},
+		&models.BreakerSelfTest{},
+		&models.TemperatureRollup{},
+		&models.TemperatureRollupState{},
+		&models.TemperatureCalibrationRecord{},
+		&models.TemperatureSensorFault{},
+		&models.EmergencyPowerOff{},
+		&models.IRController{},
+		&models.ACActionRecord{},
+		&models.IRCodeSet{},
+		&models.IRLearnedCode{},
+		&models.IRLearningSession{},
+		&models.IRBrandCode{},
+		&models.IRKeyCode{},
+		&models.ACProfile{},
+		&models.CoolingZone{},
+		&models.CoolingDecision{},
+		&models.ServerMetric{},
 		&models.AIStrategy{},
 		&models.AIStrategyExecution{},
 		&models.ActionTemplate{},
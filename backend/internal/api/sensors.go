@@ -1,11 +1,13 @@
 package api
 
 import (
-	"encoding/binary"
 	"fmt"
-	"net"
 	"net/http"
+	"reflect"
+	"smart-device-management/internal/middleware"
 	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/modbus"
 	"strconv"
 	"time"
 
@@ -15,9 +17,14 @@ import (
 
 // SensorDetectionRequest 传感器检测请求
 type SensorDetectionRequest struct {
-	Address string `json:"address" binding:"required"`
-	Port    int    `json:"port" binding:"required"`
+	Address string `json:"address"` // TCP类报文格式必填
+	Port    int    `json:"port"`
 	Station int    `json:"station"`
+	Framing string `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
+	// DeviceType 设备型号，决定使用的驱动，为空时按KLT-18B20-6H1检测
+	DeviceType string `json:"device_type"`
+	// 串口参数（报文格式为 rtu 时使用）
+	models.SerialSettings
 }
 
 // SensorDetectionResponse 传感器检测响应
@@ -41,6 +48,10 @@ type TemperatureData struct {
 	Formatted string   `json:"formatted"`
 	Channel   string   `json:"channel"`
 	RawValue  int      `json:"rawValue"`
+	// Uncalibrated 通道已校准时为校准前的温度，Value 为校准后的温度
+	Uncalibrated *float64 `json:"uncalibrated,omitempty"`
+
+	channel int
 }
 
 // DetectSensor 自动检测传感器
@@ -61,8 +72,17 @@ func DetectSensor(c *gin.Context) {
 
 	startTime := time.Now()
 
+	endpoint, err := modbus.EndpointConfig(req.Framing, req.Address, req.Port, serialConfig(req.SerialSettings))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
 	// 执行设备检测
-	result, err := performSensorDetection(req.Address, req.Port, req.Station)
+	result, err := performSensorDetection(endpoint, req.Station, req.DeviceType)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code":    50000,
@@ -80,70 +100,53 @@ func DetectSensor(c *gin.Context) {
 	})
 }
 
-// performSensorDetection 执行传感器检测
-func performSensorDetection(address string, port, station int) (*SensorDetectionResponse, error) {
+// performSensorDetection 执行传感器检测，按设备类型选择驱动（为空时使用KLT-18B20-6H1）
+func performSensorDetection(endpoint modbus.Config, station int, deviceType string) (*SensorDetectionResponse, error) {
 	result := &SensorDetectionResponse{
 		ConnectionOK:  false,
 		DeviceTypeOK:  false,
 		TemperatureOK: false,
 	}
 
-	// 1. 建立TCP连接
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", address, port), 5*time.Second)
+	driver, err := drivers.TemperatureSensor(deviceType)
 	if err != nil {
-		return nil, fmt.Errorf("无法连接到设备 %s:%d - %v", address, port, err)
+		return nil, err
 	}
-	defer conn.Close()
 
-	result.ConnectionOK = true
-
-	// 2. 读取设备类型 (寄存器 0x0010)
-	deviceType, err := readModbusRegister(conn, station, 0x0010)
-	if err != nil {
-		return nil, fmt.Errorf("读取设备类型失败: %v", err)
+	// 1. 经网关调度器连接（TCP或本地串口），与该端口上的轮询、控制共用一条连接
+	gateway := modbus.DefaultGatewayPool().Gateway(endpoint)
+	if err := gateway.Connect(modbus.PriorityNormal); err != nil {
+		return nil, fmt.Errorf("无法连接到设备 %s - %v", modbus.GatewayKey(endpoint), err)
 	}
+	conn := modbus.NewModbusClientWithTransport(gateway.TransportWithTimeout(modbus.PriorityNormal, 5*time.Second), byte(station))
 
-	result.DeviceType = deviceType
-	result.DeviceTypeOK = (deviceType == 19) // KLT-18B20-6H1 的设备类型是 19
+	result.ConnectionOK = true
 
-	// 3. 读取设备地址 (寄存器 0x0011)
-	deviceAddr, err := readModbusRegister(conn, station, 0x0011)
-	if err == nil {
-		result.DeviceAddress = deviceAddr
+	// 2. 读取设备识别信息（设备类型、地址、波特率、CRC字节序）
+	identity, err := driver.Identify(conn)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. 读取波特率设置 (寄存器 0x0012)
-	baudRateCode, err := readModbusRegister(conn, station, 0x0012)
-	if err == nil {
-		result.BaudRate = getBaudRateString(baudRateCode)
-	}
+	result.DeviceType = identity.DeviceType
+	result.DeviceTypeOK = identity.Recognized
+	result.DeviceAddress = identity.Address
+	result.BaudRate = identity.BaudRate
+	result.CrcOrder = identity.CRCOrder
 
-	// 5. 读取CRC字节序 (寄存器 0x0013)
-	crcOrder, err := readModbusRegister(conn, station, 0x0013)
-	if err == nil {
-		if crcOrder == 0 {
-			result.CrcOrder = "高字节在前"
-		} else {
-			result.CrcOrder = "低字节在前"
-		}
-	}
-
-	// 6. 如果是KLT-18B20-6H1设备，读取6路温度
+	// 3. 设备类型匹配时读取各路温度
 	if result.DeviceTypeOK {
-		temperatures := make(map[string]interface{})
-		tempSuccess := 0
-
-		for i := 1; i <= 6; i++ {
-			tempData, err := readTemperatureChannel(conn, station, i)
-			if err == nil {
-				temperatures[fmt.Sprintf("channel%d", i)] = tempData
-				if tempData.Status == "OK" {
+		readings, err := driver.ReadTemperatures(conn)
+		if err == nil {
+			temperatures := make(map[string]interface{})
+			tempSuccess := 0
+			for _, reading := range readings {
+				tempData := toTemperatureData(reading)
+				temperatures[fmt.Sprintf("channel%d", reading.Channel)] = tempData
+				if tempData.Status == drivers.ChannelOK {
 					tempSuccess++
 				}
 			}
-		}
-
-		if len(temperatures) > 0 {
 			result.Temperatures = temperatures
 			result.TemperatureOK = tempSuccess > 0
 		}
@@ -152,187 +155,63 @@ func performSensorDetection(address string, port, station int) (*SensorDetection
 	return result, nil
 }
 
-// readModbusRegister 读取Modbus寄存器 (使用Modbus TCP协议)
-func readModbusRegister(conn net.Conn, station int, register int) (int, error) {
-	// 构建Modbus TCP请求帧
-	// MBAP Header (7字节) + PDU (6字节)
-	request := make([]byte, 12)
-
-	// MBAP Header
-	binary.BigEndian.PutUint16(request[0:2], 0x0001) // Transaction ID
-	binary.BigEndian.PutUint16(request[2:4], 0x0000) // Protocol ID (0 for Modbus)
-	binary.BigEndian.PutUint16(request[4:6], 0x0006) // Length (6 bytes following)
-	request[6] = byte(station)                       // Unit ID (设备地址)
-
-	// PDU (Protocol Data Unit)
-	request[7] = 0x03                                           // 功能码：读保持寄存器
-	binary.BigEndian.PutUint16(request[8:10], uint16(register)) // 寄存器地址
-	binary.BigEndian.PutUint16(request[10:12], 1)               // 读取1个寄存器
-
-	// 设置超时
-	conn.SetDeadline(time.Now().Add(5 * time.Second))
-
-	// 发送请求
-	_, err := conn.Write(request)
-	if err != nil {
-		return 0, fmt.Errorf("发送请求失败: %v", err)
-	}
-
-	// 读取响应
-	response := make([]byte, 256)
-	n, err := conn.Read(response)
-	if err != nil {
-		return 0, fmt.Errorf("读取响应失败: %v", err)
-	}
-
-	if n < 9 {
-		return 0, fmt.Errorf("响应数据长度不足: %d", n)
-	}
-
-	// 解析Modbus TCP响应
-	// MBAP Header (7字节) + PDU
-	transactionID := binary.BigEndian.Uint16(response[0:2])
-	protocolID := binary.BigEndian.Uint16(response[2:4])
-	_ = binary.BigEndian.Uint16(response[4:6]) // length - 暂不使用
-	unitID := response[6]
-	functionCode := response[7]
-
-	// 验证响应
-	if transactionID != 0x0001 {
-		return 0, fmt.Errorf("Transaction ID不匹配: 期望0x0001, 实际0x%04X", transactionID)
+// sensorEndpoint 传感器的传输层配置：rtu 报文格式使用串口参数，其余使用 IP:端口
+func sensorEndpoint(sensor *models.TemperatureSensor) (modbus.Config, error) {
+	if drivers.IsVirtualSensor(sensor.DeviceType) {
+		return modbus.Config{}, drivers.ErrVirtualSensor
 	}
-	if protocolID != 0x0000 {
-		return 0, fmt.Errorf("Protocol ID不匹配: 期望0x0000, 实际0x%04X", protocolID)
-	}
-	if unitID != byte(station) {
-		return 0, fmt.Errorf("Unit ID不匹配: 期望%d, 实际%d", station, unitID)
-	}
-	if functionCode != 0x03 {
-		return 0, fmt.Errorf("功能码不匹配: 期望0x03, 实际0x%02X", functionCode)
-	}
-
-	// 检查是否是错误响应
-	if functionCode >= 0x80 {
-		if n >= 9 {
-			errorCode := response[8]
-			return 0, fmt.Errorf("Modbus异常响应: 功能码0x%02X, 错误码0x%02X", functionCode, errorCode)
-		}
-		return 0, fmt.Errorf("Modbus异常响应: 功能码0x%02X", functionCode)
-	}
-
-	dataLength := int(response[8])
-	if n < 9+dataLength {
-		return 0, fmt.Errorf("响应数据不完整: 期望%d字节, 实际%d字节", 9+dataLength, n)
-	}
-
-	// 提取数据值 (2字节)
-	if dataLength >= 2 {
-		value := binary.BigEndian.Uint16(response[9:11])
-		return int(value), nil
-	}
-
-	return 0, fmt.Errorf("数据长度不足: %d", dataLength)
+	return modbus.EndpointConfig(sensor.Framing, sensor.IPAddress, sensor.Port, serialConfig(sensor.SerialSettings))
 }
 
-// readTemperatureChannel 读取温度通道
-func readTemperatureChannel(conn net.Conn, station, channel int) (*TemperatureData, error) {
-	if channel < 1 || channel > 6 {
-		return nil, fmt.Errorf("温度通道必须在1-6之间")
-	}
-
-	// 温度寄存器地址：0x0000-0x0005
-	register := 0x0000 + (channel - 1)
-	rawValue, err := readModbusRegister(conn, station, register)
-	if err != nil {
-		return nil, err
+// serialConfig 把设备的串口参数转换为传输层串口配置
+func serialConfig(settings models.SerialSettings) modbus.SerialConfig {
+	return modbus.SerialConfig{
+		Device:   settings.SerialDevice,
+		BaudRate: settings.BaudRate,
+		Parity:   settings.Parity,
+		StopBits: settings.StopBits,
 	}
-
-	channelName := fmt.Sprintf("通道%d", channel)
-	return parseTemperature(rawValue, channelName), nil
 }
 
-// parseTemperature 解析温度值
-func parseTemperature(rawValue int, channelName string) *TemperatureData {
-	// 检查开路状态
-	if rawValue == 0xF8CE || rawValue == 63694 || rawValue == 65535 || rawValue == 32767 {
-		return &TemperatureData{
-			Value:     nil,
-			Status:    "OPEN_CIRCUIT",
-			Error:     "传感器开路",
-			Formatted: "开路",
-			Channel:   channelName,
-			RawValue:  rawValue,
-		}
-	}
-
-	// 检查异常高温值
-	if rawValue > 30000 {
-		return &TemperatureData{
-			Value:     nil,
-			Status:    "OPEN_CIRCUIT",
-			Error:     "传感器开路或异常",
-			Formatted: "开路",
-			Channel:   channelName,
-			RawValue:  rawValue,
+// calibrateDetection 按传感器的通道校准参数换算检测读到的温度，保留未校准读数
+func calibrateDetection(sensor *models.TemperatureSensor, result *SensorDetectionResponse) {
+	for _, value := range result.Temperatures {
+		data, ok := value.(*TemperatureData)
+		if !ok || data.Value == nil || data.Status != drivers.ChannelOK {
+			continue
 		}
-	}
-
-	// 处理负温度 (16位补码)
-	var temperature float64
-	if rawValue > 32767 {
-		temperature = float64(rawValue-65536) / 10.0
-	} else {
-		temperature = float64(rawValue) / 10.0
-	}
-
-	// 检查温度范围是否合理 (-55°C ~ +125°C)
-	if temperature < -55 || temperature > 125 {
-		return &TemperatureData{
-			Value:     nil,
-			Status:    "OUT_OF_RANGE",
-			Error:     fmt.Sprintf("温度超出范围: %.1f°C", temperature),
-			Formatted: "超范围",
-			Channel:   channelName,
-			RawValue:  rawValue,
+		calibration := sensor.ChannelCalibration(data.channel)
+		if calibration.IsZero() {
+			continue
 		}
-	}
-
-	return &TemperatureData{
-		Value:     &temperature,
-		Status:    "OK",
-		Formatted: fmt.Sprintf("%.1f°C", temperature),
-		Channel:   channelName,
-		RawValue:  rawValue,
+		raw := *data.Value
+		calibrated := calibration.Apply(raw)
+		data.Uncalibrated = &raw
+		data.Value = &calibrated
+		data.Formatted = fmt.Sprintf("%.1f°C", calibrated)
 	}
 }
 
-// getBaudRateString 获取波特率字符串
-func getBaudRateString(code int) string {
-	baudRates := map[int]string{
-		0: "300", 1: "1200", 2: "2400", 3: "4800", 4: "9600",
-		5: "19200", 6: "38400", 7: "57600", 8: "115200",
-	}
-
-	if rate, exists := baudRates[code]; exists {
-		return rate
-	}
-	return "未知"
-}
-
-// calculateCRC 计算CRC校验 (Modbus TCP不需要CRC，保留用于调试)
-func calculateCRC(data []byte) uint16 {
-	crc := uint16(0xFFFF)
-	for _, b := range data {
-		crc ^= uint16(b)
-		for i := 0; i < 8; i++ {
-			if crc&1 != 0 {
-				crc = (crc >> 1) ^ 0xA001
-			} else {
-				crc >>= 1
-			}
-		}
-	}
-	return crc
+// toTemperatureData 把驱动读数转换为接口返回格式
+func toTemperatureData(reading drivers.ChannelReading) *TemperatureData {
+	data := &TemperatureData{
+		Value:    reading.Value,
+		Status:   reading.Status,
+		Error:    reading.Error,
+		Channel:  fmt.Sprintf("通道%d", reading.Channel),
+		RawValue: int(reading.Raw),
+		channel:  reading.Channel,
+	}
+
+	switch reading.Status {
+	case drivers.ChannelOK:
+		data.Formatted = fmt.Sprintf("%.1f°C", *reading.Value)
+	case drivers.ChannelOpenCircuit:
+		data.Formatted = "开路"
+	default:
+		data.Formatted = "超范围"
+	}
+	return data
 }
 
 // 全局数据库变量 (应该通过依赖注入传入，这里简化处理)
@@ -354,6 +233,15 @@ func CreateTemperatureSensor(c *gin.Context) {
 		return
 	}
 
+	// 设备类型必须有对应的驱动
+	if _, err := drivers.TemperatureSensor(req.DeviceType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// 创建传感器记录
 	sensor := models.TemperatureSensor{
 		Name:       req.Name,
@@ -361,6 +249,7 @@ func CreateTemperatureSensor(c *gin.Context) {
 		IPAddress:  req.IPAddress,
 		Port:       req.Port,
 		SlaveID:    req.SlaveID,
+		Framing:    req.Framing,
 		Location:   req.Location,
 		MinTemp:    req.MinTemp,
 		MaxTemp:    req.MaxTemp,
@@ -368,6 +257,25 @@ func CreateTemperatureSensor(c *gin.Context) {
 		Interval:   req.Interval,
 		Enabled:    req.Enabled,
 		Channels:   req.Channels,
+		Virtual:    req.Virtual,
+
+		SerialSettings: req.SerialSettings,
+	}
+
+	// 校验连接参数：rtu 需要串口设备路径，其余需要IP地址；虚拟传感器校验聚合定义
+	if err := validateSensorSource(&sensor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := validateCalibrations(sensor.Channels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": err.Error(),
+		})
+		return
 	}
 
 	if err := db.Create(&sensor).Error; err != nil {
@@ -440,6 +348,15 @@ func UpdateTemperatureSensor(c *gin.Context) {
 		return
 	}
 
+	// 设备类型必须有对应的驱动
+	if _, err := drivers.TemperatureSensor(req.DeviceType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// 查找传感器
 	var sensor models.TemperatureSensor
 	if err := db.First(&sensor, sensorID).Error; err != nil {
@@ -456,15 +373,49 @@ func UpdateTemperatureSensor(c *gin.Context) {
 	sensor.IPAddress = req.IPAddress
 	sensor.Port = req.Port
 	sensor.SlaveID = req.SlaveID
+	if req.Framing != "" {
+		sensor.Framing = req.Framing
+	}
 	sensor.Location = req.Location
 	sensor.MinTemp = req.MinTemp
 	sensor.MaxTemp = req.MaxTemp
 	sensor.AlarmTemp = req.AlarmTemp
 	sensor.Interval = req.Interval
 	sensor.Enabled = req.Enabled
+	sensor.SerialSettings = req.SerialSettings
+	sensor.Virtual = req.Virtual
+	previous := sensor.Channels
 	sensor.Channels = req.Channels
 
-	if err := db.Save(&sensor).Error; err != nil {
+	if err := validateSensorSource(&sensor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := validateCalibrations(sensor.Channels); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    40000,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// 未携带校准参数的通道沿用原校准参数，校准参数有变化的通道记录校准历史
+	username, _ := middleware.GetCurrentUsername(c)
+	records := mergeCalibrations(sensor.ID, previous, sensor.Channels, username)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&sensor).Error; err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			return tx.Create(&records).Error
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    50000,
 			"message": "更新传感器失败: " + err.Error(),
@@ -479,6 +430,98 @@ func UpdateTemperatureSensor(c *gin.Context) {
 	})
 }
 
+// validateSensorSource 校验传感器的读数来源：物理传感器校验连接参数；虚拟传感器校验聚合定义和
+// 源通道（必须是已存在的物理传感器通道，不能嵌套虚拟传感器），并固定为单个通道1
+func validateSensorSource(sensor *models.TemperatureSensor) error {
+	if !drivers.IsVirtualSensor(sensor.DeviceType) {
+		sensor.Virtual = nil
+		_, err := sensorEndpoint(sensor)
+		return err
+	}
+
+	if err := sensor.Virtual.Validate(); err != nil {
+		return err
+	}
+	for _, source := range sensor.Virtual.Channels() {
+		if sensor.ID != 0 && source.SensorID == sensor.ID {
+			return fmt.Errorf("虚拟传感器不能引用自身")
+		}
+		var sources []models.TemperatureSensor
+		if err := db.Limit(1).Find(&sources, source.SensorID).Error; err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			return fmt.Errorf("源传感器 %d 不存在", source.SensorID)
+		}
+		if drivers.IsVirtualSensor(sources[0].DeviceType) {
+			return fmt.Errorf("源传感器 %s 是虚拟传感器，不能嵌套聚合", sources[0].Name)
+		}
+		if driver, err := drivers.TemperatureSensor(sources[0].DeviceType); err == nil && source.Channel > driver.Channels() {
+			return fmt.Errorf("源传感器 %s 没有通道%d", sources[0].Name, source.Channel)
+		}
+	}
+
+	// 虚拟传感器只有通道1，沿用请求中通道1的名称、间隔和校准参数
+	channel := models.TemperatureChannel{Channel: 1, Enabled: true}
+	for _, ch := range sensor.Channels {
+		if ch.Channel == 1 {
+			channel = ch
+		}
+	}
+	if channel.Name == "" {
+		channel.Name = sensor.Name
+	}
+	sensor.Channels = []models.TemperatureChannel{channel}
+	return nil
+}
+
+// validateCalibrations 校验各通道校准参数
+func validateCalibrations(channels []models.TemperatureChannel) error {
+	for _, ch := range channels {
+		if err := ch.Calibration.Validate(); err != nil {
+			return fmt.Errorf("通道%d: %w", ch.Channel, err)
+		}
+	}
+	return nil
+}
+
+// mergeCalibrations 合并更新前后的通道校准参数：未携带校准参数的通道沿用原参数，
+// 返回校准参数有变化的通道的校准记录
+func mergeCalibrations(sensorID uint, previous, updated []models.TemperatureChannel, operator string) []models.TemperatureCalibrationRecord {
+	old := make(map[int]*models.TemperatureCalibration, len(previous))
+	for _, ch := range previous {
+		old[ch.Channel] = ch.Calibration
+	}
+
+	var records []models.TemperatureCalibrationRecord
+	for i := range updated {
+		ch := &updated[i]
+		if ch.Calibration == nil {
+			ch.Calibration = old[ch.Channel]
+			continue
+		}
+		if ch.Calibration.IsZero() {
+			ch.Calibration = nil
+		}
+		if reflect.DeepEqual(ch.Calibration, old[ch.Channel]) {
+			continue
+		}
+		calibration := ch.Calibration
+		if calibration == nil {
+			calibration = &models.TemperatureCalibration{}
+		}
+		records = append(records, models.TemperatureCalibrationRecord{
+			SensorID:    sensorID,
+			Channel:     ch.Channel,
+			Previous:    old[ch.Channel],
+			Calibration: calibration,
+			Source:      models.CalibrationSourceSensor,
+			Operator:    operator,
+		})
+	}
+	return records
+}
+
 // DeleteTemperatureSensor 删除传感器
 func DeleteTemperatureSensor(c *gin.Context) {
 	sensorID := c.Param("id")
@@ -537,7 +580,14 @@ func TestTemperatureSensor(c *gin.Context) {
 	}
 
 	// 执行传感器检测
-	result, err := performSensorDetection(sensor.IPAddress, sensor.Port, sensor.SlaveID)
+	endpoint, err := sensorEndpoint(&sensor)
+	var result *SensorDetectionResponse
+	if err == nil {
+		result, err = performSensorDetection(endpoint, sensor.SlaveID, sensor.DeviceType)
+	}
+	if err == nil {
+		calibrateDetection(&sensor, result)
+	}
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"code": 20000,
@@ -569,11 +619,16 @@ func TestTemperatureSensor(c *gin.Context) {
 }
 
 // DetectSensorData 导出的传感器检测函数，供外部调用
-func DetectSensorData(address string, port int, station int) (map[string]interface{}, error) {
-	result, err := performSensorDetection(address, port, station)
+func DetectSensorData(sensor *models.TemperatureSensor) (map[string]interface{}, error) {
+	endpoint, err := sensorEndpoint(sensor)
+	if err != nil {
+		return nil, err
+	}
+	result, err := performSensorDetection(endpoint, sensor.SlaveID, sensor.DeviceType)
 	if err != nil {
 		return nil, err
 	}
+	calibrateDetection(sensor, result)
 
 	// 转换为map格式
 	data := map[string]interface{}{
@@ -611,7 +666,7 @@ func GetTemperatureChannels(c *gin.Context) {
 			// 获取实时温度（只有启用的通道才获取温度）
 			var realTimeTemp *string
 			if channel.Enabled {
-				realTimeTemp = getRealTimeTemperature(sensor.IPAddress, sensor.Port, sensor.SlaveID, channel.Channel)
+				realTimeTemp = getRealTimeTemperature(sensor.ID, channel.Channel)
 			}
 
 			channelItem := models.TemperatureChannelListItem{
@@ -652,23 +707,16 @@ type TemperatureReading struct {
 }
 
 // getRealTimeTemperature 获取实时温度（从数据库读取最新数据）
-func getRealTimeTemperature(ipAddress string, port int, slaveID int, channel int) *string {
-	// 根据IP地址和端口查找传感器ID
-	var sensor models.TemperatureSensor
-	if err := db.Where("ip_address = ? AND port = ?", ipAddress, port).First(&sensor).Error; err != nil {
-		// 如果找不到传感器，返回nil
-		return nil
-	}
-
+func getRealTimeTemperature(sensorID uint, channel int) *string {
 	// 首先尝试获取最近5分钟的温度数据
 	var reading TemperatureReading
-	err := db.Where("sensor_id = ? AND channel = ? AND recorded_at > NOW() - INTERVAL '5 minutes'", sensor.ID, channel).
+	err := db.Where("sensor_id = ? AND channel = ? AND recorded_at > NOW() - INTERVAL '5 minutes'", sensorID, channel).
 		Order("recorded_at DESC").
 		First(&reading).Error
 
 	// 如果没有最近5分钟的数据，查询数据库中的最新数据（不限时间）
 	if err != nil {
-		err = db.Where("sensor_id = ? AND channel = ?", sensor.ID, channel).
+		err = db.Where("sensor_id = ? AND channel = ?", sensorID, channel).
 			Order("recorded_at DESC").
 			First(&reading).Error
 
@@ -11,18 +11,26 @@ import (
 
 // Config 应用配置结构体
 type Config struct {
-	App       AppConfig       `json:"app"`
-	Database  DatabaseConfig  `json:"database"`
-	Redis     RedisConfig     `json:"redis"`
-	JWT       JWTConfig       `json:"jwt"`
-	Log       LogConfig       `json:"log"`
-	WebSocket WebSocketConfig `json:"websocket"`
-	Modbus    ModbusConfig    `json:"modbus"`
-	SSH       SSHConfig       `json:"ssh"`
-	DingTalk  DingTalkConfig  `json:"dingtalk"`
-	Email     EmailConfig     `json:"email"`
-	Security  SecurityConfig  `json:"security"`
-	Metrics   MetricsConfig   `json:"metrics"`
+	App         AppConfig         `json:"app"`
+	Database    DatabaseConfig    `json:"database"`
+	Redis       RedisConfig       `json:"redis"`
+	JWT         JWTConfig         `json:"jwt"`
+	Log         LogConfig         `json:"log"`
+	WebSocket   WebSocketConfig   `json:"websocket"`
+	Modbus      ModbusConfig      `json:"modbus"`
+	Telemetry   TelemetryConfig   `json:"telemetry"`
+	SelfTest    SelfTestConfig    `json:"self_test"`
+	ModbusSlave ModbusSlaveConfig `json:"modbus_slave"`
+	Temperature TemperatureConfig `json:"temperature"`
+	SSH         SSHConfig         `json:"ssh"`
+	DingTalk    DingTalkConfig    `json:"dingtalk"`
+	Email       EmailConfig       `json:"email"`
+	Security    SecurityConfig    `json:"security"`
+	Metrics     MetricsConfig     `json:"metrics"`
+
+	IRController IRControllerConfig `json:"ir_controller"`
+
+	ServerMetrics ServerMetricsConfig `json:"server_metrics"`
 }
 
 // AppConfig 应用配置
@@ -81,6 +89,60 @@ type ModbusConfig struct {
 	Timeout       time.Duration `json:"timeout"`
 	RetryCount    int           `json:"retry_count"`
 	RetryInterval time.Duration `json:"retry_interval"`
+	FrameGap      time.Duration `json:"frame_gap"`    // 同一网关相邻两帧的最小间隔
+	IdleTimeout   time.Duration `json:"idle_timeout"` // 网关长连接空闲关闭时间
+
+	CaptureDir       string `json:"capture_dir"`        // 报文抓包文件目录
+	CaptureRingSize  int    `json:"capture_ring_size"`  // 每个抓包任务在内存中保留的最近帧数
+	CaptureMaxFrames int    `json:"capture_max_frames"` // 单个抓包任务的最大帧数，达到后自动停止
+}
+
+// TelemetryConfig 断路器遥测采集配置
+type TelemetryConfig struct {
+	Interval  time.Duration `json:"interval"`  // 采样间隔
+	Retention time.Duration `json:"retention"` // 原始采样保留时长，小时电量不清理
+}
+
+// SelfTestConfig 断路器漏电自检配置
+type SelfTestConfig struct {
+	TripTimeout time.Duration `json:"trip_timeout"` // 按下试验按钮后等待跳闸的最长时间
+	MaxAgeDays  int           `json:"max_age_days"` // 合规报告要求的最长自检间隔（天）
+}
+
+// ModbusSlaveConfig 内置MODBUS TCP从站配置（供BMS/SCADA轮询）
+type ModbusSlaveConfig struct {
+	Enabled         bool          `json:"enabled"`
+	Listen          string        `json:"listen"`           // 监听地址，如 :1502
+	MapFile         string        `json:"map_file"`         // 寄存器映射文件(JSON)
+	RefreshInterval time.Duration `json:"refresh_interval"` // 寄存器数据刷新间隔
+	AllowedClients  string        `json:"allowed_clients"`  // 允许连接的客户端IP/网段，逗号分隔，为空不限制
+	WriteUser       string        `json:"write_user"`       // 写线圈控制断路器时使用的系统用户，为空禁止写入
+}
+
+// TemperatureConfig 温度采集、降采样与保留配置，保留时长为0表示永久保留
+type TemperatureConfig struct {
+	ReloadInterval  time.Duration `json:"reload_interval"`  // 采集服务重新加载传感器配置的间隔
+	StuckSamples    int           `json:"stuck_samples"`    // 连续多少个相同读数视为卡死，0不检测
+	SpikeDelta      float64       `json:"spike_delta"`      // 相邻读数跳变阈值（°C），0不检测
+	FaultSamples    int           `json:"fault_samples"`    // 连续多少个异常读数产生传感器故障告警
+	RollupInterval  time.Duration `json:"rollup_interval"`  // 聚合间隔
+	RawRetention    time.Duration `json:"raw_retention"`    // 原始读数保留时长
+	MinuteRetention time.Duration `json:"minute_retention"` // 1分钟数据保留时长
+	HourRetention   time.Duration `json:"hour_retention"`   // 1小时数据保留时长
+	DayRetention    time.Duration `json:"day_retention"`    // 1天数据保留时长
+}
+
+// IRControllerConfig 红外空调控制器配置
+type IRControllerConfig struct {
+	Listen  string        `json:"listen"`  // TCP客户端模式设备连接的监听地址，如 :50001，为空不监听
+	Timeout time.Duration `json:"timeout"` // 单条指令回复超时
+}
+
+// ServerMetricsConfig 服务器负载指标配置，指标通过SSH定时采集或由代理上报
+type ServerMetricsConfig struct {
+	Interval  time.Duration `json:"interval"`  // SSH采集间隔，0 表示不通过SSH采集（只接收代理上报）
+	MaxAge    time.Duration `json:"max_age"`   // 策略条件默认的指标有效时间
+	Retention time.Duration `json:"retention"` // 指标保留时间
 }
 
 // SSHConfig SSH配置
@@ -175,6 +237,48 @@ func LoadConfig() (*Config, error) {
 			Timeout:       getEnvAsDuration("MODBUS_TIMEOUT", "30s"),
 			RetryCount:    getEnvAsInt("MODBUS_RETRY_COUNT", 3),
 			RetryInterval: getEnvAsDuration("MODBUS_RETRY_INTERVAL", "5s"),
+			FrameGap:      getEnvAsDuration("MODBUS_FRAME_GAP", "50ms"),
+			IdleTimeout:   getEnvAsDuration("MODBUS_IDLE_TIMEOUT", "5m"),
+
+			CaptureDir:       getEnv("MODBUS_CAPTURE_DIR", "./data/modbus-captures"),
+			CaptureRingSize:  getEnvAsInt("MODBUS_CAPTURE_RING_SIZE", 1000),
+			CaptureMaxFrames: getEnvAsInt("MODBUS_CAPTURE_MAX_FRAMES", 100000),
+		},
+		Telemetry: TelemetryConfig{
+			Interval:  getEnvAsDuration("BREAKER_TELEMETRY_INTERVAL", "60s"),
+			Retention: getEnvAsDuration("BREAKER_TELEMETRY_RETENTION", "2160h"),
+		},
+		SelfTest: SelfTestConfig{
+			TripTimeout: getEnvAsDuration("BREAKER_SELFTEST_TRIP_TIMEOUT", "5s"),
+			MaxAgeDays:  getEnvAsInt("BREAKER_SELFTEST_MAX_AGE_DAYS", 30),
+		},
+		ModbusSlave: ModbusSlaveConfig{
+			Enabled:         getEnvAsBool("MODBUS_SLAVE_ENABLED", false),
+			Listen:          getEnv("MODBUS_SLAVE_LISTEN", ":1502"),
+			MapFile:         getEnv("MODBUS_SLAVE_MAP_FILE", "configs/modbus-slave-map.json"),
+			RefreshInterval: getEnvAsDuration("MODBUS_SLAVE_REFRESH_INTERVAL", "5s"),
+			AllowedClients:  getEnv("MODBUS_SLAVE_ALLOWED_CLIENTS", ""),
+			WriteUser:       getEnv("MODBUS_SLAVE_WRITE_USER", ""),
+		},
+		Temperature: TemperatureConfig{
+			ReloadInterval:  getEnvAsDuration("TEMPERATURE_COLLECTOR_RELOAD_INTERVAL", "15s"),
+			StuckSamples:    getEnvAsInt("TEMPERATURE_STUCK_SAMPLES", 40),
+			SpikeDelta:      getEnvAsFloat("TEMPERATURE_SPIKE_DELTA", 5),
+			FaultSamples:    getEnvAsInt("TEMPERATURE_FAULT_SAMPLES", 3),
+			RollupInterval:  getEnvAsDuration("TEMPERATURE_ROLLUP_INTERVAL", "1m"),
+			RawRetention:    getEnvAsDuration("TEMPERATURE_RAW_RETENTION", "168h"),
+			MinuteRetention: getEnvAsDuration("TEMPERATURE_MINUTE_RETENTION", "720h"),
+			HourRetention:   getEnvAsDuration("TEMPERATURE_HOUR_RETENTION", "8760h"),
+			DayRetention:    getEnvAsDuration("TEMPERATURE_DAY_RETENTION", "0"),
+		},
+		IRController: IRControllerConfig{
+			Listen:  getEnv("IR_CONTROLLER_LISTEN", ""),
+			Timeout: getEnvAsDuration("IR_CONTROLLER_TIMEOUT", "5s"),
+		},
+		ServerMetrics: ServerMetricsConfig{
+			Interval:  getEnvAsDuration("SERVER_METRICS_INTERVAL", "60s"),
+			MaxAge:    getEnvAsDuration("SERVER_METRICS_MAX_AGE", "5m"),
+			Retention: getEnvAsDuration("SERVER_METRICS_RETENTION", "168h"),
 		},
 		SSH: SSHConfig{
 			Timeout:    getEnvAsDuration("SSH_TIMEOUT", "30s"),
@@ -226,6 +330,16 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsFloat 获取环境变量并转换为float64
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 // getEnvAsBool 获取环境变量并转换为bool
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
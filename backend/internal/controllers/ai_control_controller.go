@@ -22,15 +22,17 @@ type AIControlController struct {
 	actionTemplateRepo  repositories.ActionTemplateRepository
 	breakerService      *services.BreakerService
 	serverService       *services.ServerService
+	acActionService     *services.ACActionService
 }
 
 // NewAIControlController 创建AI控制控制器实例
-func NewAIControlController(breakerService *services.BreakerService, serverService *services.ServerService, actionTemplateRepo repositories.ActionTemplateRepository) *AIControlController {
+func NewAIControlController(breakerService *services.BreakerService, serverService *services.ServerService, actionTemplateRepo repositories.ActionTemplateRepository, acActionService *services.ACActionService) *AIControlController {
 	return &AIControlController{
 		strategyRepo:       repositories.NewAIStrategyRepository(),
 		actionTemplateRepo: actionTemplateRepo,
 		breakerService:     breakerService,
 		serverService:      serverService,
+		acActionService:    acActionService,
 	}
 }
 
@@ -300,6 +302,15 @@ func (c *AIControlController) CreateStrategy(ctx *gin.Context) {
 		return
 	}
 
+	if err := validateStrategyActions(req.Actions); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "策略动作配置错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	// 获取当前用户ID（从JWT中获取，这里暂时使用默认值）
 	userID := uint(1) // TODO: 从JWT token中获取实际用户ID
 
@@ -346,6 +357,16 @@ func (c *AIControlController) CreateStrategy(ctx *gin.Context) {
 	})
 }
 
+// validateStrategyActions 校验策略动作配置，如空调控制动作的验证传感器
+func validateStrategyActions(actions []models.AIStrategyAction) error {
+	for i, action := range actions {
+		if err := action.Validate(); err != nil {
+			return fmt.Errorf("动作%d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
 // GetStrategy 获取单个AI控制策略
 // @Summary 获取单个AI控制策略
 // @Description 根据ID获取指定的AI控制策略详细信息
@@ -450,6 +471,14 @@ func (c *AIControlController) UpdateStrategy(ctx *gin.Context) {
 		strategy.ConditionsList = req.Conditions
 	}
 	if len(req.Actions) > 0 {
+		if err := validateStrategyActions(req.Actions); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.APIResponse{
+				Code:    http.StatusBadRequest,
+				Message: "策略动作配置错误",
+				Error:   err.Error(),
+			})
+			return
+		}
 		strategy.ActionsList = req.Actions
 	}
 	if req.LogicOperator != "" {
@@ -604,7 +633,7 @@ func (c *AIControlController) executeStrategyAsync(execution *models.AIStrategyE
 		}).Info("执行策略动作")
 
 		// 执行动作并获取详细结果
-		actionResult, err := c.executeActionWithValidation(action, i+1)
+		actionResult, err := c.executeActionWithValidation(execution, action, i+1)
 		if err != nil {
 			hasError = true
 			errorMsg := fmt.Sprintf("动作%d失败: %s", i+1, err.Error())
@@ -664,12 +693,14 @@ func (c *AIControlController) executeStrategyAsync(execution *models.AIStrategyE
 }
 
 // executeAction 执行单个动作
-func (c *AIControlController) executeAction(action models.AIStrategyAction) (string, error) {
+func (c *AIControlController) executeAction(execution *models.AIStrategyExecution, action models.AIStrategyAction) (string, error) {
 	switch action.Type {
 	case "server_control", "server":
 		return c.executeServerControl(action)
 	case "breaker_control", "breaker":
 		return c.executeBreakerControl(action)
+	case "ac_control", "ac":
+		return c.executeACControl(execution, action)
 	default:
 		return "", fmt.Errorf("不支持的动作类型: %s", action.Type)
 	}
@@ -747,6 +778,69 @@ func (c *AIControlController) executeBreakerControl(action models.AIStrategyActi
 	}
 }
 
+// executeACControl 执行空调控制动作，室温趋势验证在观察期结束后异步完成
+func (c *AIControlController) executeACControl(execution *models.AIStrategyExecution, action models.AIStrategyAction) (string, error) {
+	logrus.WithFields(logrus.Fields{
+		"device_id":   action.DeviceID,
+		"power":       action.Power,
+		"mode":        action.Mode,
+		"target_temp": action.TargetTemp,
+		"fan_speed":   action.FanSpeed,
+	}).Info("执行空调控制动作")
+
+	if c.acActionService == nil {
+		return "", fmt.Errorf("红外空调控制服务未启动")
+	}
+
+	record, err := c.acActionService.Execute(action, execution.StrategyID, execution.ID, execution.TriggerBy)
+	if err != nil {
+		return "", fmt.Errorf("空调 %s 控制失败: %v", action.DeviceName, err)
+	}
+	return services.DescribeACAction(action.DeviceName, record), nil
+}
+
+// GetACActions 获取空调控制动作记录
+// @Summary 获取空调控制动作记录
+// @Description 获取AI策略下发的空调状态及动作后的室温趋势验证结果
+// @Tags ai-control
+// @Produce json
+// @Param ir_controller_id query int false "红外控制器ID"
+// @Param limit query int false "返回条数" default(50)
+// @Success 200 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/ai-control/ac-actions [get]
+func (c *AIControlController) GetACActions(ctx *gin.Context) {
+	if c.acActionService == nil {
+		ctx.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "红外空调控制服务未启动",
+		})
+		return
+	}
+
+	controllerID, _ := strconv.ParseUint(ctx.Query("ir_controller_id"), 10, 32)
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	records, err := c.acActionService.ListRecords(uint(controllerID), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取空调控制动作记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取成功",
+		Data:    records,
+	})
+}
+
 // DeleteStrategy 删除AI控制策略
 // @Summary 删除AI控制策略
 // @Description 删除指定的AI控制策略
@@ -959,11 +1053,11 @@ type ActionExecutionResult struct {
 }
 
 // executeActionWithValidation 执行动作并进行验证
-func (c *AIControlController) executeActionWithValidation(action models.AIStrategyAction, actionIndex int) (*ActionExecutionResult, error) {
+func (c *AIControlController) executeActionWithValidation(execution *models.AIStrategyExecution, action models.AIStrategyAction, actionIndex int) (*ActionExecutionResult, error) {
 	startTime := time.Now()
 
 	// 执行基本动作
-	result, err := c.executeAction(action)
+	result, err := c.executeAction(execution, action)
 	if err != nil {
 		return nil, err
 	}
@@ -984,6 +1078,13 @@ func (c *AIControlController) executeActionWithValidation(action models.AIStrate
 	case "breaker_control", "breaker":
 		// 验证断路器状态是否改变
 		validationResult = c.validateBreakerOperation(action.DeviceID, action.Operation)
+	case "ac_control", "ac":
+		// 红外为单向控制，通过动作后的室温趋势验证，结果见空调控制动作记录
+		if action.VerifySensorID != "" {
+			validationResult = fmt.Sprintf("等待传感器 %s 的室温趋势验证", action.VerifySensorID)
+		} else {
+			validationResult = "未指定验证传感器，不验证室温趋势"
+		}
 	}
 
 	return &ActionExecutionResult{
@@ -131,6 +131,55 @@ func (c *BreakerController) GetBreaker(ctx *gin.Context) {
 	})
 }
 
+// GetBreakerTrips 获取断路器跳闸事件
+// @Summary 获取断路器跳闸事件
+// @Description 分页获取断路器的跳闸事件及解析后的跳闸原因
+// @Tags breakers
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.BreakerTripEventListResponse}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/trips [get]
+func (c *BreakerController) GetBreakerTrips(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 200 {
+		size = 20
+	}
+
+	events, err := c.breakerService.GetTripEvents(uint(id), page, size)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取跳闸事件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取跳闸事件成功",
+		Data:    events,
+	})
+}
+
 // CreateBreaker 创建断路器
 // @Summary 创建断路器
 // @Description 创建新的断路器配置
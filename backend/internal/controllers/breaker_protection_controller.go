@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBreakerProtection 获取断路器保护参数
+// @Summary 获取断路器保护参数
+// @Description 从设备读取过压/欠压、过流、漏电、过温、过载阈值及各项动作延时，并返回取值范围（仅管理员）
+// @Tags breakers
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Success 200 {object} models.APIResponse{data=models.BreakerProtectionResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/protection [get]
+func (c *BreakerController) GetBreakerProtection(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	protection, err := c.breakerService.GetProtection(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "读取保护参数失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "读取保护参数成功",
+		Data:    protection,
+	})
+}
+
+// UpdateBreakerProtection 修改断路器保护参数
+// @Summary 修改断路器保护参数
+// @Description 校验取值范围后写入设备并回读确认，记录修改人、原值和新值（仅管理员）。延时单位为秒，分辨率0.1s
+// @Tags breakers
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param request body models.UpdateBreakerProtectionRequest true "保护参数"
+// @Success 200 {object} models.APIResponse{data=models.BreakerProtectionUpdateResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse{data=models.BreakerProtectionUpdateResponse}
+// @Router /api/v1/breakers/{id}/protection [put]
+func (c *BreakerController) UpdateBreakerProtection(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.UpdateBreakerProtectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(ctx)
+	username, _ := middleware.GetCurrentUsername(ctx)
+
+	result, err := c.breakerService.UpdateProtection(uint(id), req, userID, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "修改保护参数失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !result.Verified {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "部分保护参数写入后回读校验失败",
+			Data:    result,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "保护参数修改成功",
+		Data:    result,
+	})
+}
+
+// GetBreakerProtectionHistory 获取保护参数修改记录
+// @Summary 获取保护参数修改记录
+// @Description 分页获取断路器保护参数的修改历史（仅管理员）
+// @Tags breakers
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.BreakerProtectionChangeListResponse}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/protection/history [get]
+func (c *BreakerController) GetBreakerProtectionHistory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 200 {
+		size = 20
+	}
+
+	history, err := c.breakerService.GetProtectionHistory(uint(id), page, size)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取保护参数修改记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取保护参数修改记录成功",
+		Data:    history,
+	})
+}
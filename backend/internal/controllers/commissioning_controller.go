@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CommissioningController 断路器投运调试控制器
+type CommissioningController struct {
+	commissioningService *services.CommissioningService
+}
+
+// NewCommissioningController 创建投运调试控制器
+func NewCommissioningController(commissioningService *services.CommissioningService) *CommissioningController {
+	return &CommissioningController{
+		commissioningService: commissioningService,
+	}
+}
+
+// ScanStations 扫描网关端口上的站号
+// @Summary 扫描网关端口上的断路器站号
+// @Description 逐个站号读取站号、波特率寄存器，有应答的站号重复读取以发现多台设备共用地址的冲突，并标注已登记的断路器
+// @Tags commissioning
+// @Accept json
+// @Produce json
+// @Param request body models.CommissioningScanRequest true "网关端口和扫描范围"
+// @Success 200 {object} models.APIResponse{data=services.CommissioningScanResult}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/commissioning/scan [post]
+func (c *CommissioningController) ScanStations(ctx *gin.Context) {
+	var req models.CommissioningScanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.commissioningService.Scan(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "扫描站号失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "扫描站号完成",
+		Data:    result,
+	})
+}
+
+// CommissionBreaker 投运断路器
+// @Summary 修改新断路器的站号和波特率并登记
+// @Description 确认当前站号只有一台设备应答后，按需修改站号(40001)和波特率(40002)并回读确认，可同时登记为断路器。失败时返回已执行的步骤
+// @Tags commissioning
+// @Accept json
+// @Produce json
+// @Param request body models.CommissionBreakerRequest true "投运参数"
+// @Success 200 {object} models.APIResponse{data=services.CommissionResult}
+// @Failure 400 {object} models.APIResponse{data=services.CommissionResult}
+// @Router /api/v1/commissioning/breakers [post]
+func (c *CommissioningController) CommissionBreaker(ctx *gin.Context) {
+	var req models.CommissionBreakerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	result, err := c.commissioningService.Commission(req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "断路器投运失败",
+			Data:    result,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "断路器投运完成",
+		Data:    result,
+	})
+}
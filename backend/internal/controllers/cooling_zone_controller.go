@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CoolingZoneController 制冷区域闭环控制控制器
+type CoolingZoneController struct {
+	coolingService *services.CoolingControlService
+}
+
+// NewCoolingZoneController 创建制冷区域控制器
+func NewCoolingZoneController(coolingService *services.CoolingControlService) *CoolingZoneController {
+	return &CoolingZoneController{
+		coolingService: coolingService,
+	}
+}
+
+// GetZones 获取制冷区域列表
+// @Summary 获取制冷区域列表
+// @Tags cooling-zones
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.CoolingZone}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/cooling-zones [get]
+func (c *CoolingZoneController) GetZones(ctx *gin.Context) {
+	zones, err := c.coolingService.ListZones()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取制冷区域列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取制冷区域列表成功",
+		Data:    zones,
+	})
+}
+
+// GetZone 获取制冷区域
+// @Summary 获取制冷区域详情
+// @Tags cooling-zones
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Success 200 {object} models.APIResponse{data=models.CoolingZone}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id} [get]
+func (c *CoolingZoneController) GetZone(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+
+	zone, err := c.coolingService.GetZone(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取制冷区域失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取制冷区域成功",
+		Data:    zone,
+	})
+}
+
+// CreateZone 创建制冷区域
+// @Summary 创建制冷区域
+// @Description 创建后闭环默认不启用，需通过 enabled 接口启用
+// @Tags cooling-zones
+// @Accept json
+// @Produce json
+// @Param request body models.CoolingZoneRequest true "制冷区域"
+// @Success 201 {object} models.APIResponse{data=models.CoolingZone}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/cooling-zones [post]
+func (c *CoolingZoneController) CreateZone(ctx *gin.Context) {
+	var req models.CoolingZoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	zone, err := c.coolingService.CreateZone(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "创建制冷区域失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Code:    http.StatusCreated,
+		Message: "创建制冷区域成功",
+		Data:    zone,
+	})
+}
+
+// UpdateZone 更新制冷区域
+// @Summary 更新制冷区域
+// @Tags cooling-zones
+// @Accept json
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Param request body models.CoolingZoneRequest true "制冷区域"
+// @Success 200 {object} models.APIResponse{data=models.CoolingZone}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id} [put]
+func (c *CoolingZoneController) UpdateZone(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CoolingZoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	zone, err := c.coolingService.UpdateZone(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "更新制冷区域失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新制冷区域成功",
+		Data:    zone,
+	})
+}
+
+// DeleteZone 删除制冷区域
+// @Summary 删除制冷区域
+// @Description 删除区域及其决策记录，空调保持当前状态
+// @Tags cooling-zones
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id} [delete]
+func (c *CoolingZoneController) DeleteZone(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.coolingService.DeleteZone(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "删除制冷区域失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除制冷区域成功",
+	})
+}
+
+// SetEnabled 启用或停用制冷闭环
+// @Summary 启用或停用制冷闭环
+// @Description 停用后空调保持当前状态
+// @Tags cooling-zones
+// @Accept json
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Param request body models.CoolingEnableRequest true "是否启用"
+// @Success 200 {object} models.APIResponse{data=models.CoolingZone}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id}/enabled [put]
+func (c *CoolingZoneController) SetEnabled(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CoolingEnableRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	zone, err := c.coolingService.SetEnabled(id, *req.Enabled, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "设置制冷闭环失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	message := "制冷闭环已停用"
+	if zone.IsEnabled {
+		message = "制冷闭环已启用"
+	}
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    zone,
+	})
+}
+
+// SetOverride 人工接管制冷区域
+// @Summary 人工接管制冷区域
+// @Description 全部关机或固定运行台数和设定温度，立即下发；到期或取消后恢复闭环。压缩机最短运行/停机时间仍然生效
+// @Tags cooling-zones
+// @Accept json
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Param request body models.CoolingOverrideRequest true "人工接管"
+// @Success 200 {object} models.APIResponse{data=models.CoolingDecision}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id}/override [put]
+func (c *CoolingZoneController) SetOverride(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.CoolingOverrideRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	decision, err := c.coolingService.SetOverride(id, req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "人工接管失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "人工接管已生效",
+		Data:    decision,
+	})
+}
+
+// ClearOverride 取消人工接管
+// @Summary 取消人工接管
+// @Tags cooling-zones
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Success 200 {object} models.APIResponse{data=models.CoolingZone}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id}/override [delete]
+func (c *CoolingZoneController) ClearOverride(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	zone, err := c.coolingService.ClearOverride(id, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "取消人工接管失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "已取消人工接管",
+		Data:    zone,
+	})
+}
+
+// GetDecisions 获取制冷闭环决策记录
+// @Summary 获取制冷闭环决策记录
+// @Tags cooling-zones
+// @Produce json
+// @Param id path int true "制冷区域ID"
+// @Param limit query int false "返回条数，默认100"
+// @Success 200 {object} models.APIResponse{data=[]models.CoolingDecision}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/cooling-zones/{id}/decisions [get]
+func (c *CoolingZoneController) GetDecisions(ctx *gin.Context) {
+	id, ok := coolingZoneID(ctx)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+
+	decisions, err := c.coolingService.ListDecisions(id, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取制冷决策记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取制冷决策记录成功",
+		Data:    decisions,
+	})
+}
+
+// coolingZoneID 解析路径中的制冷区域ID
+func coolingZoneID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的制冷区域ID",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
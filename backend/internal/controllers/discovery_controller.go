@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"net/http"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiscoveryController 网络设备自动发现控制器
+type DiscoveryController struct {
+	discoveryService *services.DiscoveryService
+}
+
+// NewDiscoveryController 创建设备发现控制器
+func NewDiscoveryController(discoveryService *services.DiscoveryService) *DiscoveryController {
+	return &DiscoveryController{
+		discoveryService: discoveryService,
+	}
+}
+
+// StartDiscovery 启动设备发现任务
+// @Summary 启动网络设备自动发现
+// @Description 扫描网段和端口范围内的网关，在每个端口上探测站号并按寄存器特征识别断路器和温度模块。任务在后台执行，进度通过WebSocket discovery_progress 推送
+// @Tags discovery
+// @Accept json
+// @Produce json
+// @Param request body models.StartDiscoveryRequest true "扫描范围"
+// @Success 202 {object} models.APIResponse{data=services.DiscoveryJob}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/discovery/jobs [post]
+func (c *DiscoveryController) StartDiscovery(ctx *gin.Context) {
+	var req models.StartDiscoveryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	job, err := c.discoveryService.Start(req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "启动设备发现失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.APIResponse{
+		Code:    http.StatusAccepted,
+		Message: "设备发现已启动",
+		Data:    job,
+	})
+}
+
+// ListDiscoveries 获取设备发现任务列表
+// @Summary 获取设备发现任务列表
+// @Description 获取最近的发现任务（不含候选设备明细），最新的在前
+// @Tags discovery
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]services.DiscoveryJob}
+// @Router /api/v1/discovery/jobs [get]
+func (c *DiscoveryController) ListDiscoveries(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取设备发现任务成功",
+		Data:    c.discoveryService.List(),
+	})
+}
+
+// GetDiscovery 获取设备发现任务详情
+// @Summary 获取设备发现任务详情
+// @Description 获取任务进度、发现的网关端口和候选设备，候选设备标注是否已登记或已导入
+// @Tags discovery
+// @Produce json
+// @Param id path string true "任务ID"
+// @Success 200 {object} models.APIResponse{data=services.DiscoveryJob}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/discovery/jobs/{id} [get]
+func (c *DiscoveryController) GetDiscovery(ctx *gin.Context) {
+	job, err := c.discoveryService.Get(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取设备发现任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取设备发现任务成功",
+		Data:    job,
+	})
+}
+
+// CancelDiscovery 取消设备发现任务
+// @Summary 取消设备发现任务
+// @Description 停止扫描，已发现的候选设备保留并可导入
+// @Tags discovery
+// @Produce json
+// @Param id path string true "任务ID"
+// @Success 200 {object} models.APIResponse{data=services.DiscoveryJob}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/discovery/jobs/{id}/cancel [post]
+func (c *DiscoveryController) CancelDiscovery(ctx *gin.Context) {
+	job, err := c.discoveryService.Cancel(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "取消设备发现任务失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "设备发现任务已取消",
+		Data:    job,
+	})
+}
+
+// ImportCandidates 导入发现的设备
+// @Summary 导入发现的设备
+// @Description 把选中的候选设备登记为断路器或温度传感器，逐个返回导入结果；已登记、未识别型号或已导入的候选设备跳过
+// @Tags discovery
+// @Accept json
+// @Produce json
+// @Param id path string true "任务ID"
+// @Param request body models.ImportDiscoveryRequest true "候选设备序号"
+// @Success 200 {object} models.APIResponse{data=[]services.DiscoveryImportResult}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/discovery/jobs/{id}/import [post]
+func (c *DiscoveryController) ImportCandidates(ctx *gin.Context) {
+	var req models.ImportDiscoveryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	results, err := c.discoveryService.Import(ctx.Param("id"), req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "导入设备失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "导入设备完成",
+		Data:    results,
+	})
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DriverController 设备驱动控制器
+type DriverController struct{}
+
+// NewDriverController 创建设备驱动控制器
+func NewDriverController() *DriverController {
+	return &DriverController{}
+}
+
+// GetDrivers 获取已注册的设备驱动
+// @Summary 获取设备驱动列表
+// @Description 获取已注册的设备型号、能力和配置项说明，可按类别筛选
+// @Tags drivers
+// @Accept json
+// @Produce json
+// @Param kind query string false "设备类别" Enums(breaker,temperature_sensor)
+// @Success 200 {object} models.APIResponse{data=[]drivers.Info}
+// @Router /api/v1/drivers [get]
+func (c *DriverController) GetDrivers(ctx *gin.Context) {
+	kind := drivers.Kind(ctx.Query("kind"))
+
+	infos := drivers.List()
+	if kind != "" {
+		filtered := make([]drivers.Info, 0, len(infos))
+		for _, info := range infos {
+			if info.Kind == kind {
+				filtered = append(filtered, info)
+			}
+		}
+		infos = filtered
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取设备驱动列表成功",
+		Data:    infos,
+	})
+}
+
+// GetDriver 获取指定型号的设备驱动
+// @Summary 获取设备驱动详情
+// @Description 按型号或别名获取设备驱动的能力和配置项说明
+// @Tags drivers
+// @Accept json
+// @Produce json
+// @Param model path string true "设备型号"
+// @Success 200 {object} models.APIResponse{data=drivers.Info}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/drivers/{model} [get]
+func (c *DriverController) GetDriver(ctx *gin.Context) {
+	driver, ok := drivers.Lookup(ctx.Param("model"))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "设备型号不存在",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取设备驱动成功",
+		Data:    driver.Info(),
+	})
+}
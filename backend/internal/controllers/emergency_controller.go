@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmergencyController 紧急断电控制器（仅管理员）
+type EmergencyController struct {
+	powerOffService *services.EmergencyPowerOffService
+}
+
+// NewEmergencyController 创建紧急断电控制器
+func NewEmergencyController(powerOffService *services.EmergencyPowerOffService) *EmergencyController {
+	return &EmergencyController{
+		powerOffService: powerOffService,
+	}
+}
+
+// PreparePowerOff 生成紧急断电计划
+// @Summary 生成紧急断电计划
+// @Description 第一步：按全站或单个网关确定要分闸的断路器及各网关的分闸方式（广播或逐台），返回确认令牌和确认口令，需在有效期内调用执行接口（仅管理员）
+// @Tags emergency
+// @Accept json
+// @Produce json
+// @Param request body models.PrepareEmergencyPowerOffRequest true "断电范围"
+// @Success 200 {object} models.APIResponse{data=models.EmergencyPowerOffPlan}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/emergency/power-off [post]
+func (c *EmergencyController) PreparePowerOff(ctx *gin.Context) {
+	var req models.PrepareEmergencyPowerOffRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(ctx)
+	username, _ := middleware.GetCurrentUsername(ctx)
+	plan, err := c.powerOffService.Prepare(req, userID, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "生成紧急断电计划失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "紧急断电计划已生成，请确认执行",
+		Data:    plan,
+	})
+}
+
+// ExecutePowerOff 确认执行紧急断电
+// @Summary 确认执行紧急断电
+// @Description 第二步：由发起人携带令牌并输入确认口令后执行。命令在后台下发，执行结果通过记录详情和WebSocket告警获取（仅管理员）
+// @Tags emergency
+// @Accept json
+// @Produce json
+// @Param id path string true "紧急断电记录UUID"
+// @Param request body models.ExecuteEmergencyPowerOffRequest true "确认信息"
+// @Success 202 {object} models.APIResponse{data=models.EmergencyPowerOff}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/emergency/power-off/{id}/execute [post]
+func (c *EmergencyController) ExecutePowerOff(ctx *gin.Context) {
+	var req models.ExecuteEmergencyPowerOffRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := middleware.GetCurrentUserID(ctx)
+	username, _ := middleware.GetCurrentUsername(ctx)
+	record, err := c.powerOffService.Execute(ctx.Param("id"), req, userID, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "执行紧急断电失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.APIResponse{
+		Code:    http.StatusAccepted,
+		Message: "紧急断电执行中",
+		Data:    record,
+	})
+}
+
+// CancelPowerOff 取消紧急断电计划
+// @Summary 取消紧急断电计划
+// @Description 取消尚未确认的紧急断电计划（仅管理员）
+// @Tags emergency
+// @Produce json
+// @Param id path string true "紧急断电记录UUID"
+// @Success 200 {object} models.APIResponse{data=models.EmergencyPowerOff}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/emergency/power-off/{id}/cancel [post]
+func (c *EmergencyController) CancelPowerOff(ctx *gin.Context) {
+	username, _ := middleware.GetCurrentUsername(ctx)
+	record, err := c.powerOffService.Cancel(ctx.Param("id"), username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "取消紧急断电失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "紧急断电计划已取消",
+		Data:    record,
+	})
+}
+
+// GetPowerOff 获取紧急断电记录
+// @Summary 获取紧急断电记录
+// @Description 获取紧急断电的审计信息及每台断路器的执行结果（仅管理员）
+// @Tags emergency
+// @Produce json
+// @Param id path string true "紧急断电记录UUID"
+// @Success 200 {object} models.APIResponse{data=models.EmergencyPowerOff}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/emergency/power-off/{id} [get]
+func (c *EmergencyController) GetPowerOff(ctx *gin.Context) {
+	record, err := c.powerOffService.Get(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "紧急断电记录不存在",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取紧急断电记录成功",
+		Data:    record,
+	})
+}
+
+// ListPowerOffs 获取紧急断电记录列表
+// @Summary 获取紧急断电记录列表
+// @Description 分页获取紧急断电审计记录（仅管理员）
+// @Tags emergency
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.EmergencyPowerOffListResponse}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/emergency/power-off [get]
+func (c *EmergencyController) ListPowerOffs(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 200 {
+		size = 20
+	}
+
+	list, err := c.powerOffService.List(page, size)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取紧急断电记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取紧急断电记录成功",
+		Data:    list,
+	})
+}
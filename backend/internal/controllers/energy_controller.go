@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnergyController 遥测与电量统计控制器
+type EnergyController struct {
+	energyService *services.EnergyService
+}
+
+// NewEnergyController 创建电量统计控制器
+func NewEnergyController(energyService *services.EnergyService) *EnergyController {
+	return &EnergyController{
+		energyService: energyService,
+	}
+}
+
+// GetConsumption 用电量统计
+// @Summary 用电量统计
+// @Description 按小时/日/月统计用电量，可按断路器、安装位置或绑定服务器分组（服务器分组时断路器电量在其绑定服务器间平均分摊）
+// @Tags energy
+// @Accept json
+// @Produce json
+// @Param granularity query string false "统计粒度 hour/day/month" default(day)
+// @Param group_by query string false "分组方式 breaker/location/server" default(breaker)
+// @Param start_time query string false "开始时间（RFC3339 或 2006-01-02）"
+// @Param end_time query string false "结束时间（RFC3339 或 2006-01-02，不含）"
+// @Param breaker_id query int false "断路器ID"
+// @Param location query string false "安装位置"
+// @Success 200 {object} models.APIResponse{data=models.EnergyConsumptionResponse}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/energy/consumption [get]
+func (c *EnergyController) GetConsumption(ctx *gin.Context) {
+	query := models.EnergyConsumptionQuery{
+		Granularity: ctx.Query("granularity"),
+		GroupBy:     ctx.Query("group_by"),
+		Location:    ctx.Query("location"),
+	}
+
+	var err error
+	if query.Start, err = parseTimeQuery(ctx, "start_time"); err == nil {
+		query.End, err = parseTimeQuery(ctx, "end_time")
+	}
+	if err == nil && ctx.Query("breaker_id") != "" {
+		var id uint64
+		id, err = strconv.ParseUint(ctx.Query("breaker_id"), 10, 32)
+		query.BreakerID = uint(id)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.energyService.GetConsumption(query)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "用电量统计失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取用电量统计成功",
+		Data:    result,
+	})
+}
+
+// GetBreakerEnergy 获取断路器累计电量
+// @Summary 获取断路器累计电量
+// @Description 获取断路器电量计数器（电能寄存器或功率积分）及最近一次遥测采样
+// @Tags energy
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Success 200 {object} models.APIResponse{data=models.BreakerEnergyResponse}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/energy [get]
+func (c *EnergyController) GetBreakerEnergy(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	energy, err := c.energyService.GetBreakerEnergy(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取断路器电量失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取断路器电量成功",
+		Data:    energy,
+	})
+}
+
+// GetBreakerTelemetry 获取断路器遥测历史
+// @Summary 获取断路器遥测历史
+// @Description 获取断路器时间范围内的遥测采样（电压、电流、功率、功率因数、频率、漏电流、温度）
+// @Tags energy
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param start_time query string false "开始时间，默认最近24小时"
+// @Param end_time query string false "结束时间"
+// @Param limit query int false "最多返回条数" default(1000)
+// @Success 200 {object} models.APIResponse{data=[]models.BreakerTelemetrySample}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/telemetry [get]
+func (c *EnergyController) GetBreakerTelemetry(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	start, err := parseTimeQuery(ctx, "start_time")
+	var end time.Time
+	if err == nil {
+		end, err = parseTimeQuery(ctx, "end_time")
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if start.IsZero() {
+		start = end.Add(-24 * time.Hour)
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "1000"))
+	if err != nil || limit < 1 || limit > 10000 {
+		limit = 1000
+	}
+
+	samples, err := c.energyService.GetTelemetry(uint(id), start, end, limit)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取遥测历史失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取遥测历史成功",
+		Data:    samples,
+	})
+}
+
+// parseTimeQuery 解析时间查询参数，支持 RFC3339 和本地日期 2006-01-02，为空时返回零值
+func parseTimeQuery(ctx *gin.Context, key string) (time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%s 时间格式无效: %s", key, value)
+}
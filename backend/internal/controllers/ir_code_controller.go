@@ -0,0 +1,644 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IRCodeController 红外码库控制器：红外学习、学习码组、品牌码库与按键表导入、空调档案
+type IRCodeController struct {
+	codeService *services.IRCodeService
+}
+
+// NewIRCodeController 创建红外码库控制器
+func NewIRCodeController(codeService *services.IRCodeService) *IRCodeController {
+	return &IRCodeController{
+		codeService: codeService,
+	}
+}
+
+// StartLearning 开始红外学习
+// @Summary 开始红外学习
+// @Description 控制器进入学习模式，在等待时间内用遥控器对准控制器按键，学习结果保存到码组的指定按键。通过学习会话查询结果
+// @Tags ir-codes
+// @Accept json
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Param request body models.StartIRLearningRequest true "学习参数"
+// @Success 200 {object} models.APIResponse{data=models.IRLearningSession}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/learn [post]
+func (c *IRCodeController) StartLearning(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.StartIRLearningRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	session, err := c.codeService.StartLearning(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "开始红外学习失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "请在等待时间内用遥控器对准控制器按键",
+		Data:    session,
+	})
+}
+
+// SendCode 发送学习码
+// @Summary 发送学习码
+// @Description 通过控制器发送学习码组中的按键，用于学习后测试
+// @Tags ir-codes
+// @Accept json
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Param request body models.SendIRCodeRequest true "学习码组和按键"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/send-code [post]
+func (c *IRCodeController) SendCode(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.SendIRCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if err := c.codeService.SendCode(id, req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "发送学习码失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "学习码已发送",
+	})
+}
+
+// GetLearningSession 获取红外学习会话
+// @Summary 获取红外学习会话
+// @Tags ir-codes
+// @Produce json
+// @Param id path int true "学习会话ID"
+// @Success 200 {object} models.APIResponse{data=models.IRLearningSession}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/ir-codes/learning-sessions/{id} [get]
+func (c *IRCodeController) GetLearningSession(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "学习会话ID")
+	if !ok {
+		return
+	}
+
+	session, err := c.codeService.GetSession(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取学习会话失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取学习会话成功",
+		Data:    session,
+	})
+}
+
+// GetCodeSets 获取学习码组列表
+// @Summary 获取学习码组列表
+// @Tags ir-codes
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.IRCodeSet}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/ir-codes/code-sets [get]
+func (c *IRCodeController) GetCodeSets(ctx *gin.Context) {
+	sets, err := c.codeService.ListCodeSets()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取学习码组列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取学习码组列表成功",
+		Data:    sets,
+	})
+}
+
+// GetCodeSet 获取学习码组及其学习码
+// @Summary 获取学习码组详情
+// @Tags ir-codes
+// @Produce json
+// @Param id path int true "学习码组ID"
+// @Success 200 {object} models.APIResponse{data=models.IRCodeSet}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/ir-codes/code-sets/{id} [get]
+func (c *IRCodeController) GetCodeSet(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "学习码组ID")
+	if !ok {
+		return
+	}
+
+	set, err := c.codeService.GetCodeSet(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取学习码组失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取学习码组成功",
+		Data:    set,
+	})
+}
+
+// CreateCodeSet 创建学习码组
+// @Summary 创建学习码组
+// @Tags ir-codes
+// @Accept json
+// @Produce json
+// @Param request body models.CreateIRCodeSetRequest true "学习码组"
+// @Success 201 {object} models.APIResponse{data=models.IRCodeSet}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-codes/code-sets [post]
+func (c *IRCodeController) CreateCodeSet(ctx *gin.Context) {
+	var req models.CreateIRCodeSetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	set, err := c.codeService.CreateCodeSet(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "创建学习码组失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Code:    http.StatusCreated,
+		Message: "创建学习码组成功",
+		Data:    set,
+	})
+}
+
+// UpdateCodeSet 更新学习码组
+// @Summary 更新学习码组
+// @Tags ir-codes
+// @Accept json
+// @Produce json
+// @Param id path int true "学习码组ID"
+// @Param request body models.UpdateIRCodeSetRequest true "学习码组"
+// @Success 200 {object} models.APIResponse{data=models.IRCodeSet}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-codes/code-sets/{id} [put]
+func (c *IRCodeController) UpdateCodeSet(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "学习码组ID")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateIRCodeSetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	set, err := c.codeService.UpdateCodeSet(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "更新学习码组失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新学习码组成功",
+		Data:    set,
+	})
+}
+
+// DeleteCodeSet 删除学习码组
+// @Summary 删除学习码组
+// @Description 删除学习码组及其学习码，被空调档案使用时不能删除
+// @Tags ir-codes
+// @Produce json
+// @Param id path int true "学习码组ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-codes/code-sets/{id} [delete]
+func (c *IRCodeController) DeleteCodeSet(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "学习码组ID")
+	if !ok {
+		return
+	}
+
+	if err := c.codeService.DeleteCodeSet(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "删除学习码组失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除学习码组成功",
+	})
+}
+
+// DeleteLearnedCode 删除学习码组中的按键
+// @Summary 删除学习码
+// @Tags ir-codes
+// @Produce json
+// @Param id path int true "学习码组ID"
+// @Param button path string true "按键标识"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-codes/code-sets/{id}/codes/{button} [delete]
+func (c *IRCodeController) DeleteLearnedCode(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "学习码组ID")
+	if !ok {
+		return
+	}
+
+	if err := c.codeService.DeleteLearnedCode(id, ctx.Param("button")); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "删除学习码失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除学习码成功",
+	})
+}
+
+// GetBrandCodes 获取导入的品牌码库
+// @Summary 获取导入的品牌码库
+// @Description 内置空调码库见 /ir-controllers/brands
+// @Tags ir-codes
+// @Produce json
+// @Param device_type query string false "设备类型" Enums(ac,tv,stb)
+// @Success 200 {object} models.APIResponse{data=[]models.IRBrandCode}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/ir-codes/brand-codes [get]
+func (c *IRCodeController) GetBrandCodes(ctx *gin.Context) {
+	codes, err := c.codeService.ListBrandCodes(ctx.Query("device_type"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取品牌码库失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取品牌码库成功",
+		Data:    codes,
+	})
+}
+
+// ImportBrandCodes 导入品牌码库
+// @Summary 导入品牌码库
+// @Description 导入厂家提供的品牌代码表，导入的空调品牌可用于设置空调品牌和空调档案
+// @Tags ir-codes
+// @Accept json
+// @Produce json
+// @Param request body models.ImportIRBrandCodesRequest true "品牌代码表"
+// @Success 200 {object} models.APIResponse{data=models.IRImportResult}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-codes/brand-codes/import [post]
+func (c *IRCodeController) ImportBrandCodes(ctx *gin.Context) {
+	var req models.ImportIRBrandCodesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.codeService.ImportBrandCodes(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "导入品牌码库失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "导入品牌码库成功",
+		Data:    result,
+	})
+}
+
+// GetKeyCodes 获取按键表
+// @Summary 获取按键表
+// @Tags ir-codes
+// @Produce json
+// @Param device_type query string false "设备类型" Enums(ac,tv,stb)
+// @Success 200 {object} models.APIResponse{data=[]models.IRKeyCode}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/ir-codes/key-codes [get]
+func (c *IRCodeController) GetKeyCodes(ctx *gin.Context) {
+	keys, err := c.codeService.ListKeyCodes(ctx.Query("device_type"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取按键表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取按键表成功",
+		Data:    keys,
+	})
+}
+
+// ImportKeyCodes 导入按键表
+// @Summary 导入按键表
+// @Tags ir-codes
+// @Accept json
+// @Produce json
+// @Param request body models.ImportIRKeyCodesRequest true "按键表"
+// @Success 200 {object} models.APIResponse{data=models.IRImportResult}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-codes/key-codes/import [post]
+func (c *IRCodeController) ImportKeyCodes(ctx *gin.Context) {
+	var req models.ImportIRKeyCodesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	result, err := c.codeService.ImportKeyCodes(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "导入按键表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "导入按键表成功",
+		Data:    result,
+	})
+}
+
+// GetProfiles 获取空调档案列表
+// @Summary 获取空调档案列表
+// @Tags ac-profiles
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.ACProfile}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/ac-profiles [get]
+func (c *IRCodeController) GetProfiles(ctx *gin.Context) {
+	profiles, err := c.codeService.ListProfiles()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取空调档案列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取空调档案列表成功",
+		Data:    profiles,
+	})
+}
+
+// GetProfile 获取空调档案
+// @Summary 获取空调档案详情
+// @Tags ac-profiles
+// @Produce json
+// @Param id path int true "空调档案ID"
+// @Success 200 {object} models.APIResponse{data=models.ACProfile}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/ac-profiles/{id} [get]
+func (c *IRCodeController) GetProfile(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "空调档案ID")
+	if !ok {
+		return
+	}
+
+	profile, err := c.codeService.GetProfile(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取空调档案失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取空调档案成功",
+		Data:    profile,
+	})
+}
+
+// CreateProfile 创建空调档案
+// @Summary 创建空调档案
+// @Description 将实体空调绑定到红外控制器，码源为内置码库代号或学习码组
+// @Tags ac-profiles
+// @Accept json
+// @Produce json
+// @Param request body models.ACProfileRequest true "空调档案"
+// @Success 201 {object} models.APIResponse{data=models.ACProfile}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ac-profiles [post]
+func (c *IRCodeController) CreateProfile(ctx *gin.Context) {
+	var req models.ACProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	profile, err := c.codeService.CreateProfile(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "创建空调档案失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Code:    http.StatusCreated,
+		Message: "创建空调档案成功",
+		Data:    profile,
+	})
+}
+
+// UpdateProfile 更新空调档案
+// @Summary 更新空调档案
+// @Tags ac-profiles
+// @Accept json
+// @Produce json
+// @Param id path int true "空调档案ID"
+// @Param request body models.ACProfileRequest true "空调档案"
+// @Success 200 {object} models.APIResponse{data=models.ACProfile}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ac-profiles/{id} [put]
+func (c *IRCodeController) UpdateProfile(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "空调档案ID")
+	if !ok {
+		return
+	}
+
+	var req models.ACProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	profile, err := c.codeService.UpdateProfile(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "更新空调档案失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新空调档案成功",
+		Data:    profile,
+	})
+}
+
+// DeleteProfile 删除空调档案
+// @Summary 删除空调档案
+// @Tags ac-profiles
+// @Produce json
+// @Param id path int true "空调档案ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ac-profiles/{id} [delete]
+func (c *IRCodeController) DeleteProfile(ctx *gin.Context) {
+	id, ok := irCodePathID(ctx, "空调档案ID")
+	if !ok {
+		return
+	}
+
+	if err := c.codeService.DeleteProfile(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "删除空调档案失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除空调档案成功",
+	})
+}
+
+// irCodePathID 解析路径中的ID，name 用于错误提示
+func irCodePathID(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的" + name,
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
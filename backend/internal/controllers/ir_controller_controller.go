@@ -0,0 +1,430 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IRControllerController 红外空调控制器控制器
+type IRControllerController struct {
+	irService *services.IRControllerService
+}
+
+// NewIRControllerController 创建红外空调控制器控制器
+func NewIRControllerController(irService *services.IRControllerService) *IRControllerController {
+	return &IRControllerController{
+		irService: irService,
+	}
+}
+
+// GetControllers 获取红外控制器列表
+// @Summary 获取红外控制器列表
+// @Tags ir-controllers
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.IRController}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/ir-controllers [get]
+func (c *IRControllerController) GetControllers(ctx *gin.Context) {
+	controllers, err := c.irService.ListControllers()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取红外控制器列表失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取红外控制器列表成功",
+		Data:    controllers,
+	})
+}
+
+// GetController 获取红外控制器详情
+// @Summary 获取红外控制器详情
+// @Tags ir-controllers
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Success 200 {object} models.APIResponse{data=models.IRController}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id} [get]
+func (c *IRControllerController) GetController(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	controller, err := c.irService.GetController(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取红外控制器失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取红外控制器成功",
+		Data:    controller,
+	})
+}
+
+// CreateController 创建红外控制器
+// @Summary 创建红外控制器
+// @Description 通信模式 tcp_server/udp 需填写设备IP和端口（默认50000），tcp_client 由设备连接平台监听端口并按IP识别，rtu 使用本地串口或串口服务器
+// @Tags ir-controllers
+// @Accept json
+// @Produce json
+// @Param request body models.CreateIRControllerRequest true "红外控制器信息"
+// @Success 201 {object} models.APIResponse{data=models.IRController}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers [post]
+func (c *IRControllerController) CreateController(ctx *gin.Context) {
+	var req models.CreateIRControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	controller, err := c.irService.CreateController(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "创建红外控制器失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Code:    http.StatusCreated,
+		Message: "创建红外控制器成功",
+		Data:    controller,
+	})
+}
+
+// UpdateController 更新红外控制器
+// @Summary 更新红外控制器
+// @Tags ir-controllers
+// @Accept json
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Param request body models.UpdateIRControllerRequest true "红外控制器信息"
+// @Success 200 {object} models.APIResponse{data=models.IRController}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id} [put]
+func (c *IRControllerController) UpdateController(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateIRControllerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	controller, err := c.irService.UpdateController(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "更新红外控制器失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "更新红外控制器成功",
+		Data:    controller,
+	})
+}
+
+// DeleteController 删除红外控制器
+// @Summary 删除红外控制器
+// @Tags ir-controllers
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id} [delete]
+func (c *IRControllerController) DeleteController(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.irService.DeleteController(id); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "删除红外控制器失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除红外控制器成功",
+	})
+}
+
+// TestConnection 测试红外控制器连接
+// @Summary 测试红外控制器连接
+// @Description 读取固件版本、芯片ID、RS485地址、波特率和MAC地址
+// @Tags ir-controllers
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Success 200 {object} models.APIResponse{data=infrared.DeviceInfo}
+// @Failure 502 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/test [post]
+func (c *IRControllerController) TestConnection(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	info, err := c.irService.TestConnection(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, models.APIResponse{
+			Code:    http.StatusBadGateway,
+			Message: "红外控制器连接测试失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "红外控制器连接正常",
+		Data:    info,
+	})
+}
+
+// MatchAC 空调一键匹配
+// @Summary 空调一键匹配
+// @Description 控制器进入匹配状态后，在等待时间内用空调遥控器对准控制器按电源键，匹配成功后保存码库代号
+// @Tags ir-controllers
+// @Accept json
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Param request body models.MatchACRequest false "等待时间"
+// @Success 200 {object} models.APIResponse{data=models.IRController}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/match [post]
+func (c *IRControllerController) MatchAC(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.MatchACRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.APIResponse{
+				Code:    http.StatusBadRequest,
+				Message: "请求参数错误",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	controller, err := c.irService.MatchAC(id, time.Duration(req.Timeout)*time.Second)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "空调一键匹配失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "空调一键匹配成功",
+		Data:    controller,
+	})
+}
+
+// SetACBrand 设置空调品牌
+// @Summary 设置空调品牌或码库代号
+// @Description 只指定品牌时使用内置码库中该品牌的第一个代号，并写入控制器
+// @Tags ir-controllers
+// @Accept json
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Param request body models.SetACBrandRequest true "品牌或码库代号"
+// @Success 200 {object} models.APIResponse{data=models.IRController}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/brand [put]
+func (c *IRControllerController) SetACBrand(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.SetACBrandRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	controller, err := c.irService.SetACBrand(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "设置空调品牌失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "设置空调品牌成功",
+		Data:    controller,
+	})
+}
+
+// ControlAC 控制空调
+// @Summary 控制空调
+// @Description 设置电源、模式、温度和风速，未指定的项沿用最后一次下发的状态
+// @Tags ir-controllers
+// @Accept json
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Param request body models.ACControlRequest true "空调状态"
+// @Success 200 {object} models.APIResponse{data=models.IRController}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/ac [post]
+func (c *IRControllerController) ControlAC(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ACControlRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	controller, err := c.irService.ControlAC(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "控制空调失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "控制空调成功",
+		Data:    controller,
+	})
+}
+
+// GetStatus 获取红外控制器实时状态
+// @Summary 获取红外控制器实时状态
+// @Description 读取码库代号、控制器最后一次发码的空调状态、互感器开关机检测、ADC 和 C01-C04 模拟量
+// @Tags ir-controllers
+// @Produce json
+// @Param id path int true "红外控制器ID"
+// @Success 200 {object} models.APIResponse{data=models.IRControllerStatus}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/ir-controllers/{id}/status [get]
+func (c *IRControllerController) GetStatus(ctx *gin.Context) {
+	id, ok := irControllerID(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := c.irService.GetStatus(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取红外控制器状态失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取红外控制器状态成功",
+		Data:    status,
+	})
+}
+
+// GetConnections 获取TCP客户端模式已连接的设备
+// @Summary 获取已连接监听端口的红外控制器
+// @Tags ir-controllers
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]infrared.ListenerConn}
+// @Router /api/v1/ir-controllers/connections [get]
+func (c *IRControllerController) GetConnections(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取已连接设备成功",
+		Data:    c.irService.Connections(),
+	})
+}
+
+// GetBrands 获取内置空调品牌码库
+// @Summary 获取内置空调品牌码库
+// @Tags ir-controllers
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]infrared.Brand}
+// @Router /api/v1/ir-controllers/brands [get]
+func (c *IRControllerController) GetBrands(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取空调品牌码库成功",
+		Data:    c.irService.Brands(),
+	})
+}
+
+// irControllerID 解析路径中的红外控制器ID，无效时直接返回400
+func irControllerID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的红外控制器ID",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModbusCaptureController MODBUS报文抓包控制器（仅管理员）
+type ModbusCaptureController struct {
+	captureService *services.ModbusCaptureService
+}
+
+// NewModbusCaptureController 创建抓包控制器
+func NewModbusCaptureController(captureService *services.ModbusCaptureService) *ModbusCaptureController {
+	return &ModbusCaptureController{
+		captureService: captureService,
+	}
+}
+
+// ListCaptures 获取抓包任务列表
+// @Summary 获取MODBUS抓包任务列表
+// @Description 获取进行中和已停止的抓包任务（仅管理员）
+// @Tags modbus
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]modbus.CaptureInfo}
+// @Router /api/v1/modbus/captures [get]
+func (c *ModbusCaptureController) ListCaptures(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取抓包任务成功",
+		Data:    c.captureService.ListCaptures(),
+	})
+}
+
+// StartCapture 开始抓包
+// @Summary 开始MODBUS抓包
+// @Description 记录指定断路器或温度传感器（或其所在整条总线）的每一帧请求和响应，包括时间、网关、站号、耗时和解码结果（仅管理员）
+// @Tags modbus
+// @Accept json
+// @Produce json
+// @Param request body models.StartModbusCaptureRequest true "抓包对象"
+// @Success 200 {object} models.APIResponse{data=modbus.CaptureInfo}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/modbus/captures [post]
+func (c *ModbusCaptureController) StartCapture(ctx *gin.Context) {
+	var req models.StartModbusCaptureRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	info, err := c.captureService.StartCapture(req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "开始抓包失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "开始抓包成功",
+		Data:    info,
+	})
+}
+
+// StopCapture 停止抓包
+// @Summary 停止MODBUS抓包
+// @Description 停止抓包任务，抓包文件保留以便下载（仅管理员）
+// @Tags modbus
+// @Produce json
+// @Param id path string true "抓包任务ID"
+// @Success 200 {object} models.APIResponse{data=modbus.CaptureInfo}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/modbus/captures/{id}/stop [post]
+func (c *ModbusCaptureController) StopCapture(ctx *gin.Context) {
+	username, _ := middleware.GetCurrentUsername(ctx)
+	info, err := c.captureService.StopCapture(ctx.Param("id"), username)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "停止抓包失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "停止抓包成功",
+		Data:    info,
+	})
+}
+
+// GetCapture 获取抓包详情
+// @Summary 获取MODBUS抓包详情
+// @Description 获取抓包任务信息及环形缓冲区中最近的报文（仅管理员）
+// @Tags modbus
+// @Produce json
+// @Param id path string true "抓包任务ID"
+// @Param limit query int false "最多返回帧数" default(200)
+// @Success 200 {object} models.APIResponse{data=services.ModbusCaptureDetail}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/modbus/captures/{id} [get]
+func (c *ModbusCaptureController) GetCapture(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "200"))
+	if err != nil || limit < 1 {
+		limit = 200
+	}
+
+	detail, err := c.captureService.GetCapture(ctx.Param("id"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取抓包详情失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取抓包详情成功",
+		Data:    detail,
+	})
+}
+
+// DownloadCapture 下载抓包文件
+// @Summary 下载MODBUS抓包文件
+// @Description 下载JSON Lines格式的抓包文件，可用 cmd/modbus-replay 回放（仅管理员）
+// @Tags modbus
+// @Produce application/x-ndjson
+// @Param id path string true "抓包任务ID"
+// @Success 200 {file} file
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/modbus/captures/{id}/download [get]
+func (c *ModbusCaptureController) DownloadCapture(ctx *gin.Context) {
+	path, name, err := c.captureService.CaptureFile(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "下载抓包文件失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Content-Type", "application/x-ndjson")
+	ctx.FileAttachment(path, name)
+}
+
+// DeleteCapture 删除抓包
+// @Summary 删除MODBUS抓包
+// @Description 删除已停止的抓包任务及其文件（仅管理员）
+// @Tags modbus
+// @Produce json
+// @Param id path string true "抓包任务ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/modbus/captures/{id} [delete]
+func (c *ModbusCaptureController) DeleteCapture(ctx *gin.Context) {
+	username, _ := middleware.GetCurrentUsername(ctx)
+	if err := c.captureService.DeleteCapture(ctx.Param("id"), username); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "删除抓包失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "删除抓包成功",
+	})
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModbusSlaveController 内置MODBUS TCP从站管理控制器（仅管理员）
+type ModbusSlaveController struct {
+	slaveService *services.ModbusSlaveService // 未启用从站时为空
+}
+
+// NewModbusSlaveController 创建从站管理控制器
+func NewModbusSlaveController(slaveService *services.ModbusSlaveService) *ModbusSlaveController {
+	return &ModbusSlaveController{
+		slaveService: slaveService,
+	}
+}
+
+// GetSlaveStatus 获取从站运行状态
+// @Summary 获取MODBUS从站状态
+// @Description 获取监听地址、寄存器映射规模、连接数、数据刷新时间和读写计数（仅管理员）
+// @Tags modbus
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=services.ModbusSlaveStatus}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/modbus/slave [get]
+func (c *ModbusSlaveController) GetSlaveStatus(ctx *gin.Context) {
+	if c.slaveService == nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "MODBUS从站未启用",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取MODBUS从站状态成功",
+		Data:    c.slaveService.GetStatus(),
+	})
+}
+
+// ReloadSlaveMap 重新加载寄存器映射
+// @Summary 重新加载MODBUS从站寄存器映射
+// @Description 重新读取寄存器映射文件并立即刷新数据，映射无效时保留原映射（仅管理员）
+// @Tags modbus
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=services.ModbusSlaveStatus}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/modbus/slave/reload [post]
+func (c *ModbusSlaveController) ReloadSlaveMap(ctx *gin.Context) {
+	if c.slaveService == nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "MODBUS从站未启用",
+		})
+		return
+	}
+
+	status, err := c.slaveService.Reload()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "重新加载寄存器映射失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "寄存器映射已重新加载",
+		Data:    status,
+	})
+}
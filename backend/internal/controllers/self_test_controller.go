@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SelfTestController 断路器漏电自检控制器
+type SelfTestController struct {
+	selfTestService *services.BreakerSelfTestService
+}
+
+// NewSelfTestController 创建漏电自检控制器
+func NewSelfTestController(selfTestService *services.BreakerSelfTestService) *SelfTestController {
+	return &SelfTestController{
+		selfTestService: selfTestService,
+	}
+}
+
+// GetSchedule 获取漏电自检计划
+// @Summary 获取断路器漏电自检计划
+// @Description 获取断路器的漏电自检周期、执行时间和下次执行时间，未设置时返回未启用的默认计划
+// @Tags self-tests
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Success 200 {object} models.APIResponse{data=models.BreakerSelfTestSchedule}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/self-test/schedule [get]
+func (c *SelfTestController) GetSchedule(ctx *gin.Context) {
+	id, ok := breakerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	schedule, err := c.selfTestService.GetSchedule(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取漏电自检计划失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取漏电自检计划成功",
+		Data:    schedule,
+	})
+}
+
+// UpdateSchedule 设置漏电自检计划
+// @Summary 设置断路器漏电自检计划
+// @Description 设置自检周期（天）和执行时间（点），可选自检通过后申请重新合闸（需操作员批准）
+// @Tags self-tests
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param request body models.UpdateSelfTestScheduleRequest true "自检计划"
+// @Success 200 {object} models.APIResponse{data=models.BreakerSelfTestSchedule}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/self-test/schedule [put]
+func (c *SelfTestController) UpdateSchedule(ctx *gin.Context) {
+	id, ok := breakerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSelfTestScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	schedule, err := c.selfTestService.UpdateSchedule(id, req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "设置漏电自检计划失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "设置漏电自检计划成功",
+		Data:    schedule,
+	})
+}
+
+// RunSelfTest 立即执行漏电自检
+// @Summary 立即执行断路器漏电自检
+// @Description 远程按下漏电试验按钮，确认断路器在超时内以漏电保护跳闸。断路器会断电，合闸状态下才能执行
+// @Tags self-tests
+// @Accept json
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param request body models.RunSelfTestRequest false "自检选项"
+// @Success 200 {object} models.APIResponse{data=models.BreakerSelfTest}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/self-test [post]
+func (c *SelfTestController) RunSelfTest(ctx *gin.Context) {
+	id, ok := breakerIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.RunSelfTestRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.APIResponse{
+				Code:    http.StatusBadRequest,
+				Message: "请求参数错误",
+				Error:   err.Error(),
+			})
+			return
+		}
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	test, err := c.selfTestService.RunSelfTest(id, models.SelfTestTriggerManual, req.RequestReclose, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "执行漏电自检失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "漏电自检已完成",
+		Data:    test,
+	})
+}
+
+// GetBreakerSelfTests 获取断路器漏电自检记录
+// @Summary 获取断路器漏电自检记录
+// @Description 分页获取断路器的漏电自检记录
+// @Tags self-tests
+// @Produce json
+// @Param id path int true "断路器ID"
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.BreakerSelfTestListResponse}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/breakers/{id}/self-tests [get]
+func (c *SelfTestController) GetBreakerSelfTests(ctx *gin.Context) {
+	id, ok := breakerIDParam(ctx)
+	if !ok {
+		return
+	}
+	c.listTests(ctx, repositories.BreakerSelfTestFilter{BreakerID: id})
+}
+
+// ListSelfTests 获取漏电自检记录
+// @Summary 获取漏电自检记录
+// @Description 分页获取全部断路器的漏电自检记录，可按结果和重新合闸状态过滤（如 reclose_status=pending 查询待批准的合闸）
+// @Tags self-tests
+// @Produce json
+// @Param status query string false "自检结果" Enums(running, passed, failed, skipped, error)
+// @Param reclose_status query string false "重新合闸状态" Enums(pending, closed, rejected, failed)
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.BreakerSelfTestListResponse}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/self-tests [get]
+func (c *SelfTestController) ListSelfTests(ctx *gin.Context) {
+	c.listTests(ctx, repositories.BreakerSelfTestFilter{
+		Status:        ctx.Query("status"),
+		RecloseStatus: ctx.Query("reclose_status"),
+	})
+}
+
+func (c *SelfTestController) listTests(ctx *gin.Context, filter repositories.BreakerSelfTestFilter) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	if err != nil || size < 1 || size > 200 {
+		size = 20
+	}
+
+	tests, err := c.selfTestService.ListTests(filter, page, size)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取漏电自检记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取漏电自检记录成功",
+		Data:    tests,
+	})
+}
+
+// ApproveReclose 批准或拒绝自检后重新合闸
+// @Summary 批准或拒绝漏电自检后重新合闸
+// @Description 自检通过且申请了重新合闸的记录，由操作员确认现场安全后批准合闸（回读确认）或拒绝保持分闸
+// @Tags self-tests
+// @Accept json
+// @Produce json
+// @Param id path int true "自检记录ID"
+// @Param request body models.ApproveRecloseRequest true "是否批准"
+// @Success 200 {object} models.APIResponse{data=models.BreakerSelfTest}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/self-tests/{id}/reclose [post]
+func (c *SelfTestController) ApproveReclose(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的自检记录ID",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	var req models.ApproveRecloseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	test, err := c.selfTestService.ApproveReclose(uint(id), req.Approve, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "处理重新合闸失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "处理重新合闸成功",
+		Data:    test,
+	})
+}
+
+// GetCompliance 获取漏电自检合规报告
+// @Summary 获取漏电自检合规报告
+// @Description 列出每台启用断路器最近一次自检和最近一次通过的时间，在要求周期内有通过记录的视为合规
+// @Tags self-tests
+// @Produce json
+// @Param max_age_days query int false "要求的最长自检间隔（天），默认使用配置值"
+// @Success 200 {object} models.APIResponse{data=models.SelfTestComplianceReport}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/self-tests/compliance [get]
+func (c *SelfTestController) GetCompliance(ctx *gin.Context) {
+	maxAgeDays, _ := strconv.Atoi(ctx.Query("max_age_days"))
+
+	report, err := c.selfTestService.ComplianceReport(maxAgeDays)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "生成漏电自检合规报告失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "生成漏电自检合规报告成功",
+		Data:    report,
+	})
+}
+
+// breakerIDParam 解析路径中的断路器ID，无效时直接返回400
+func breakerIDParam(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的断路器ID",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServerMetricsController 服务器负载指标控制器
+type ServerMetricsController struct {
+	metricsService *services.ServerMetricsService
+}
+
+// NewServerMetricsController 创建服务器负载指标控制器
+func NewServerMetricsController(metricsService *services.ServerMetricsService) *ServerMetricsController {
+	return &ServerMetricsController{
+		metricsService: metricsService,
+	}
+}
+
+// ReportMetrics 代理上报服务器负载
+// @Summary 上报服务器负载
+// @Description 由服务器上的代理定时上报CPU、内存、磁盘使用率，供AI策略的 server_load 条件使用
+// @Tags servers
+// @Accept json
+// @Produce json
+// @Param id path int true "服务器ID"
+// @Param request body models.ServerMetricReport true "负载指标"
+// @Success 201 {object} models.APIResponse{data=models.ServerMetric}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/servers/{id}/metrics [post]
+func (c *ServerMetricsController) ReportMetrics(ctx *gin.Context) {
+	id, ok := serverMetricsServerID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ServerMetricReport
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	metric, err := c.metricsService.Record(id, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "上报服务器负载失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Code:    http.StatusCreated,
+		Message: "上报服务器负载成功",
+		Data:    metric,
+	})
+}
+
+// GetMetrics 获取服务器负载历史
+// @Summary 获取服务器负载历史
+// @Tags servers
+// @Produce json
+// @Param id path int true "服务器ID"
+// @Param minutes query int false "最近多少分钟，默认60"
+// @Param limit query int false "返回条数，默认500"
+// @Success 200 {object} models.APIResponse{data=[]models.ServerMetric}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/servers/{id}/metrics [get]
+func (c *ServerMetricsController) GetMetrics(ctx *gin.Context) {
+	id, ok := serverMetricsServerID(ctx)
+	if !ok {
+		return
+	}
+	minutes, _ := strconv.Atoi(ctx.DefaultQuery("minutes", "60"))
+	if minutes <= 0 {
+		minutes = 60
+	}
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "500"))
+
+	since := time.Now().UTC().Add(-time.Duration(minutes) * time.Minute)
+	metrics, err := c.metricsService.ListMetrics(id, since, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取服务器负载失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取服务器负载成功",
+		Data:    metrics,
+	})
+}
+
+// serverMetricsServerID 解析路径中的服务器ID
+func serverMetricsServerID(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的服务器ID",
+			Error:   err.Error(),
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"smart-device-management/internal/models"
 	"smart-device-management/internal/services"
+	"smart-device-management/pkg/modbus"
 	"strconv"
 	"time"
 
@@ -264,3 +265,19 @@ func (c *StatusMonitorController) GetMonitorHistory(ctx *gin.Context) {
 		Data:    history,
 	})
 }
+
+// GetGatewayStats 获取MODBUS网关调度指标
+// @Summary 获取网关调度指标
+// @Description 获取每个网关端口的队列深度、请求延迟、失败与重连次数
+// @Tags status-monitor
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]modbus.GatewayStats}
+// @Router /api/v1/status-monitor/gateways [get]
+func (c *StatusMonitorController) GetGatewayStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取网关调度指标成功",
+		Data:    modbus.DefaultGatewayPool().Stats(),
+	})
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/middleware"
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TemperatureCalibrationController 温度通道校准控制器
+type TemperatureCalibrationController struct {
+	calibrationService *services.TemperatureCalibrationService
+}
+
+// NewTemperatureCalibrationController 创建温度通道校准控制器
+func NewTemperatureCalibrationController(calibrationService *services.TemperatureCalibrationService) *TemperatureCalibrationController {
+	return &TemperatureCalibrationController{
+		calibrationService: calibrationService,
+	}
+}
+
+// GetCalibration 获取通道校准参数
+// @Summary 获取温度通道校准参数
+// @Description 获取通道的偏移、增益和多点校准点，未校准时返回空
+// @Tags sensors
+// @Produce json
+// @Param id path int true "传感器ID"
+// @Param channel path int true "通道号"
+// @Success 200 {object} models.APIResponse{data=models.TemperatureCalibration}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/v1/sensors/{id}/channels/{channel}/calibration [get]
+func (c *TemperatureCalibrationController) GetCalibration(ctx *gin.Context) {
+	sensorID, channel, ok := sensorChannelParams(ctx)
+	if !ok {
+		return
+	}
+
+	calibration, err := c.calibrationService.GetCalibration(sensorID, channel)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, models.APIResponse{
+			Code:    http.StatusNotFound,
+			Message: "获取通道校准参数失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取通道校准参数成功",
+		Data:    calibration,
+	})
+}
+
+// UpdateCalibration 设置通道校准参数
+// @Summary 设置温度通道校准参数
+// @Description 设置偏移、增益或多点校准点（两个及以上校准点时按分段线性插值，再叠加偏移），全部为零值时清除校准
+// @Tags sensors
+// @Accept json
+// @Produce json
+// @Param id path int true "传感器ID"
+// @Param channel path int true "通道号"
+// @Param request body models.UpdateTemperatureCalibrationRequest true "校准参数"
+// @Success 200 {object} models.APIResponse{data=models.TemperatureCalibrationRecord}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/sensors/{id}/channels/{channel}/calibration [put]
+func (c *TemperatureCalibrationController) UpdateCalibration(ctx *gin.Context) {
+	sensorID, channel, ok := sensorChannelParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateTemperatureCalibrationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	record, err := c.calibrationService.UpdateCalibration(sensorID, channel, req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "设置通道校准参数失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "设置通道校准参数成功",
+		Data:    record,
+	})
+}
+
+// CalibrateFromReference 按参考温度计算偏移
+// @Summary 按参考温度校准通道
+// @Description 以参考温度计读数为准计算并保存通道偏移（保持增益和校准点不变），未提供探头读数时使用最近10分钟内采集的读数
+// @Tags sensors
+// @Accept json
+// @Produce json
+// @Param id path int true "传感器ID"
+// @Param channel path int true "通道号"
+// @Param request body models.ReferenceCalibrationRequest true "参考温度"
+// @Success 200 {object} models.APIResponse{data=models.TemperatureCalibrationRecord}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/v1/sensors/{id}/channels/{channel}/calibration/reference [post]
+func (c *TemperatureCalibrationController) CalibrateFromReference(ctx *gin.Context) {
+	sensorID, channel, ok := sensorChannelParams(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ReferenceCalibrationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	username, _ := middleware.GetCurrentUsername(ctx)
+	record, err := c.calibrationService.CalibrateFromReference(sensorID, channel, req, username)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "按参考温度校准失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "按参考温度校准成功",
+		Data:    record,
+	})
+}
+
+// GetCalibrationHistory 获取通道校准记录
+// @Summary 获取温度通道校准记录
+// @Description 获取通道校准参数的变更记录，按时间倒序
+// @Tags sensors
+// @Produce json
+// @Param id path int true "传感器ID"
+// @Param channel path int true "通道号"
+// @Param limit query int false "返回条数，默认100"
+// @Success 200 {object} models.APIResponse{data=[]models.TemperatureCalibrationRecord}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/sensors/{id}/channels/{channel}/calibration/history [get]
+func (c *TemperatureCalibrationController) GetCalibrationHistory(ctx *gin.Context) {
+	sensorID, channel, ok := sensorChannelParams(ctx)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+	records, err := c.calibrationService.GetHistory(sensorID, channel, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "获取通道校准记录失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "获取通道校准记录成功",
+		Data:    records,
+	})
+}
+
+// sensorChannelParams 解析路径中的传感器ID和通道号，无效时直接返回400
+func sensorChannelParams(ctx *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的传感器ID",
+		})
+		return 0, 0, false
+	}
+	channel, err := strconv.Atoi(ctx.Param("channel"))
+	if err != nil || channel < 1 {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: "无效的通道号",
+		})
+		return 0, 0, false
+	}
+	return uint(id), channel, true
+}
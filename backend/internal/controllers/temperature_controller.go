@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"net/http"
 	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
 	"smart-device-management/pkg/database"
+	"smart-device-management/pkg/logger"
 	"strconv"
 	"time"
 
@@ -97,14 +99,16 @@ func (c *TemperatureController) GetSensors(ctx *gin.Context) {
 
 // GetHistory 获取历史温度数据
 // @Summary 获取历史温度数据
-// @Description 获取指定时间范围内的温度历史数据
+// @Description 获取指定时间范围内的温度历史数据，未指定间隔时按时间范围自动选择原始读数或1分钟/1小时/1天聚合数据
 // @Tags temperature
 // @Accept json
 // @Produce json
 // @Param sensor_id query int false "传感器ID"
-// @Param start_time query string false "开始时间"
-// @Param end_time query string false "结束时间"
-// @Param interval query string false "数据间隔" Enums(minute,hour,day)
+// @Param channel query int false "通道"
+// @Param start_time query string false "开始时间(RFC3339)"
+// @Param end_time query string false "结束时间(RFC3339)，默认当前时间"
+// @Param hours query int false "未指定开始时间时查询最近几小时，默认1"
+// @Param interval query string false "数据间隔" Enums(raw,minute,hour,day)
 // @Success 200 {object} models.APIResponse{data=[]models.TemperatureHistory}
 // @Failure 400 {object} models.APIResponse
 // @Failure 500 {object} models.APIResponse
@@ -116,13 +120,18 @@ func (c *TemperatureController) GetHistory(ctx *gin.Context) {
 	interval := ctx.Query("interval")
 	hoursStr := ctx.Query("hours")
 
-	// 参数验证
-	if interval == "" {
-		interval = "minute"
+	query, err := parseHistoryQuery(sensorIDStr, ctx.Query("channel"), startTime, endTime, interval, hoursStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
 	}
 
 	// 获取真实的历史数据
-	historyData, err := c.getRealHistoryData(sensorIDStr, startTime, endTime, hoursStr)
+	resolution, historyData, err := c.getRealHistoryData(query)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Code:    http.StatusInternalServerError,
@@ -137,14 +146,81 @@ func (c *TemperatureController) GetHistory(ctx *gin.Context) {
 		Message: "获取历史数据成功",
 		Data: gin.H{
 			"sensor_id":  sensorIDStr,
-			"start_time": startTime,
-			"end_time":   endTime,
+			"start_time": query.Start.Format(time.RFC3339),
+			"end_time":   query.End.Format(time.RFC3339),
 			"interval":   interval,
+			"resolution": resolution,
 			"data":       historyData,
 		},
 	})
 }
 
+// historyIntervals 历史查询 interval 参数与数据分辨率的对应关系
+var historyIntervals = map[string]string{
+	"":                      "",
+	"auto":                  "",
+	"raw":                   models.ResolutionRaw,
+	"minute":                models.ResolutionMinute,
+	models.ResolutionMinute: models.ResolutionMinute,
+	"hour":                  models.ResolutionHour,
+	models.ResolutionHour:   models.ResolutionHour,
+	"day":                   models.ResolutionDay,
+	models.ResolutionDay:    models.ResolutionDay,
+}
+
+// parseHistoryQuery 解析历史查询参数。指定 start_time 时忽略 hours
+func parseHistoryQuery(sensorIDStr, channelStr, startTime, endTime, interval, hoursStr string) (models.TemperatureHistoryQuery, error) {
+	var query models.TemperatureHistoryQuery
+
+	if sensorIDStr != "" {
+		id, err := strconv.ParseUint(sensorIDStr, 10, 32)
+		if err != nil {
+			return query, fmt.Errorf("无效的传感器ID")
+		}
+		query.SensorID = uint(id)
+	}
+	if channelStr != "" {
+		channel, err := strconv.Atoi(channelStr)
+		if err != nil || channel < 1 {
+			return query, fmt.Errorf("无效的通道")
+		}
+		query.Channel = channel
+	}
+
+	resolution, ok := historyIntervals[interval]
+	if !ok {
+		return query, fmt.Errorf("无效的数据间隔: %s", interval)
+	}
+	query.Resolution = resolution
+
+	query.End = time.Now()
+	if endTime != "" {
+		end, err := time.Parse(time.RFC3339, endTime)
+		if err != nil {
+			return query, fmt.Errorf("无效的结束时间: %s", endTime)
+		}
+		query.End = end
+	}
+
+	if startTime != "" {
+		start, err := time.Parse(time.RFC3339, startTime)
+		if err != nil {
+			return query, fmt.Errorf("无效的开始时间: %s", startTime)
+		}
+		query.Start = start
+	} else {
+		hours := 1 // 默认1小时
+		if h, err := strconv.Atoi(hoursStr); err == nil && h > 0 {
+			hours = h
+		}
+		query.Start = query.End.Add(-time.Duration(hours) * time.Hour)
+	}
+	if !query.Start.Before(query.End) {
+		return query, fmt.Errorf("开始时间必须早于结束时间")
+	}
+	return query, nil
+}
+
 // GetRealtime 获取实时温度数据
 // @Summary 获取实时温度数据
 // @Description 获取所有传感器的实时温度数据
@@ -175,69 +251,48 @@ func (c *TemperatureController) GetRealtime(ctx *gin.Context) {
 
 // TemperatureReading 温度记录结构（与采集服务保持一致）
 type TemperatureReading struct {
-	ID          uint      `gorm:"primaryKey"`
-	SensorID    uint      `gorm:"not null"`
-	Channel     int       `gorm:"not null"`
-	Temperature float64   `gorm:"type:decimal(5,2);not null"`
-	Status      string    `gorm:"size:20;default:'normal'"`
-	RecordedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ID             uint      `gorm:"primaryKey"`
+	SensorID       uint      `gorm:"not null"`
+	Channel        int       `gorm:"not null"`
+	Temperature    float64   `gorm:"type:decimal(5,2);not null"` // 校准后的温度
+	RawTemperature *float64  `gorm:"type:decimal(5,2)"`          // 探头原始读数（未校准）
+	Status         string    `gorm:"size:20;default:'normal'"`
+	RecordedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 }
 
-// getRealHistoryData 获取真实的历史温度数据
-func (c *TemperatureController) getRealHistoryData(sensorIDStr, startTime, endTime, hoursStr string) ([]gin.H, error) {
+// getRealHistoryData 获取真实的历史温度数据，返回实际使用的分辨率
+func (c *TemperatureController) getRealHistoryData(query models.TemperatureHistoryQuery) (string, []gin.H, error) {
 	db := database.GetDB()
 	if db == nil {
-		return nil, fmt.Errorf("数据库连接未初始化")
-	}
-
-	// 解析传感器ID
-	var sensorID uint
-	if sensorIDStr != "" {
-		if id, err := strconv.ParseUint(sensorIDStr, 10, 32); err == nil {
-			sensorID = uint(id)
-		}
+		return "", nil, fmt.Errorf("数据库连接未初始化")
 	}
 
-	// 计算时间范围
-	endTimeObj := time.Now()
-	var startTimeObj time.Time
-
-	if hoursStr != "" {
-		if hours, err := strconv.Atoi(hoursStr); err == nil {
-			startTimeObj = endTimeObj.Add(-time.Duration(hours) * time.Hour)
-		} else {
-			startTimeObj = endTimeObj.Add(-1 * time.Hour) // 默认1小时
-		}
-	} else {
-		startTimeObj = endTimeObj.Add(-1 * time.Hour) // 默认1小时
-	}
-
-	// 查询数据库
-	var readings []TemperatureReading
-	query := db.Where("recorded_at BETWEEN ? AND ?", startTimeObj, endTimeObj)
-
-	if sensorID > 0 {
-		query = query.Where("sensor_id = ?", sensorID)
-	}
-
-	err := query.Order("recorded_at ASC").Find(&readings).Error
+	history := services.NewTemperatureRollupService(db, logger.GetLogger(), 0, services.TemperatureRetention{})
+	resolution, points, err := history.GetHistory(query)
 	if err != nil {
-		return nil, fmt.Errorf("查询历史数据失败: %v", err)
+		return "", nil, fmt.Errorf("查询历史数据失败: %v", err)
 	}
 
 	// 转换为API响应格式
-	var historyData []gin.H
-	for _, reading := range readings {
-		historyData = append(historyData, gin.H{
-			"timestamp":   reading.RecordedAt.Format(time.RFC3339),
-			"sensor_id":   reading.SensorID,
-			"channel":     reading.Channel,
-			"temperature": reading.Temperature,
-			"status":      reading.Status,
-		})
+	historyData := make([]gin.H, 0, len(points))
+	for _, point := range points {
+		item := gin.H{
+			"timestamp":   point.Timestamp.Format(time.RFC3339),
+			"sensor_id":   point.SensorID,
+			"channel":     point.Channel,
+			"temperature": point.Temperature,
+			"min":         point.Min,
+			"max":         point.Max,
+			"avg":         point.Avg,
+			"count":       point.Count,
+		}
+		if point.Status != "" {
+			item["status"] = point.Status
+		}
+		historyData = append(historyData, item)
 	}
 
-	return historyData, nil
+	return resolution, historyData, nil
 }
 
 // getRealRealtimeData 获取真实的实时温度数据
@@ -251,7 +306,7 @@ func (c *TemperatureController) getRealRealtimeData() (gin.H, error) {
 	var readings []TemperatureReading
 	err := db.Raw(`
 		SELECT DISTINCT ON (sensor_id, channel)
-			sensor_id, channel, temperature, status, recorded_at
+			sensor_id, channel, temperature, raw_temperature, status, recorded_at
 		FROM temperature_readings
 		WHERE recorded_at > NOW() - INTERVAL '5 minutes'
 		ORDER BY sensor_id, channel, recorded_at DESC
@@ -265,7 +320,7 @@ func (c *TemperatureController) getRealRealtimeData() (gin.H, error) {
 	if len(readings) == 0 {
 		err = db.Raw(`
 			SELECT DISTINCT ON (sensor_id, channel)
-				sensor_id, channel, temperature, status, recorded_at
+				sensor_id, channel, temperature, raw_temperature, status, recorded_at
 			FROM temperature_readings
 			ORDER BY sensor_id, channel, recorded_at DESC
 		`).Scan(&readings).Error
@@ -284,12 +339,16 @@ func (c *TemperatureController) getRealRealtimeData() (gin.H, error) {
 			sensorMap[reading.SensorID] = []gin.H{}
 		}
 
-		sensorMap[reading.SensorID] = append(sensorMap[reading.SensorID], gin.H{
+		channelData := gin.H{
 			"channel":     reading.Channel,
 			"temperature": reading.Temperature,
 			"status":      reading.Status,
 			"recorded_at": reading.RecordedAt.Format(time.RFC3339),
-		})
+		}
+		if reading.RawTemperature != nil {
+			channelData["raw_temperature"] = *reading.RawTemperature
+		}
+		sensorMap[reading.SensorID] = append(sensorMap[reading.SensorID], channelData)
 
 		allTemps = append(allTemps, reading.Temperature)
 	}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TemperatureSensorFaultController 温度传感器故障告警控制器
+type TemperatureSensorFaultController struct {
+	faultService *services.TemperatureSensorFaultService
+}
+
+// NewTemperatureSensorFaultController 创建温度传感器故障告警控制器
+func NewTemperatureSensorFaultController(faultService *services.TemperatureSensorFaultService) *TemperatureSensorFaultController {
+	return &TemperatureSensorFaultController{
+		faultService: faultService,
+	}
+}
+
+// ListFaults 查询传感器故障
+// @Summary 查询温度传感器故障
+// @Description 查询健康检测产生的传感器故障（探头断开、读数卡死、超量程、跳变），按产生时间倒序
+// @Tags sensors
+// @Produce json
+// @Param status query string false "状态" Enums(active,cleared)
+// @Param sensor_id query int false "传感器ID"
+// @Param limit query int false "返回条数，默认100"
+// @Success 200 {object} models.APIResponse{data=[]models.TemperatureSensorFault}
+// @Failure 500 {object} models.APIResponse
+// @Router /api/v1/sensors/faults [get]
+func (c *TemperatureSensorFaultController) ListFaults(ctx *gin.Context) {
+	sensorID, _ := strconv.ParseUint(ctx.Query("sensor_id"), 10, 32)
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+	faults, err := c.faultService.ListFaults(ctx.Query("status"), uint(sensorID), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Code:    http.StatusInternalServerError,
+			Message: "查询传感器故障失败",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Code:    http.StatusOK,
+		Message: "查询传感器故障成功",
+		Data:    faults,
+	})
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// 空调动作的室温趋势
+const (
+	ACTrendFalling = "falling"
+	ACTrendRising  = "rising"
+	ACTrendStable  = "stable"
+)
+
+// 空调动作验证结果
+const (
+	ACVerifyPending     = "pending"     // 等待观察时间结束
+	ACVerifyEffective   = "effective"   // 室温按预期变化
+	ACVerifyIneffective = "ineffective" // 室温未按预期变化
+	ACVerifyNoData      = "no_data"     // 观察期内没有有效温度读数
+	ACVerifySkipped     = "skipped"     // 未指定验证传感器或无预期趋势
+)
+
+// ACActionRecord AI策略空调控制动作记录：下发的空调状态和动作后的室温趋势验证
+type ACActionRecord struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	IRControllerID uint       `json:"ir_controller_id" gorm:"index;not null"`
+	StrategyID     uint       `json:"strategy_id" gorm:"index"`
+	ExecutionID    uint       `json:"execution_id" gorm:"index"`
+	TriggerBy      string     `json:"trigger_by" gorm:"size:20"`      // auto/manual
+	State          ACState    `json:"state" gorm:"serializer:json"`   // 下发的空调状态
+	Error          string     `json:"error" gorm:"type:text"`         // 下发失败原因
+	SensorID       string     `json:"sensor_id" gorm:"size:50"`       // 验证室温的传感器通道，格式 "传感器ID-通道"
+	ExpectedTrend  string     `json:"expected_trend" gorm:"size:20"`  // 预期趋势，空表示不验证
+	BaselineTemp   *float64   `json:"baseline_temp"`                  // 下发前的室温
+	FinalTemp      *float64   `json:"final_temp"`                     // 观察期结束时的室温
+	Trend          string     `json:"trend" gorm:"size:20"`           // 观察到的趋势
+	VerifyStatus   string     `json:"verify_status" gorm:"size:20"`   // 验证结果
+	VerifyMessage  string     `json:"verify_message" gorm:"size:500"` // 验证说明
+	VerifyDeadline *time.Time `json:"verify_deadline"`                // 观察期结束时间
+	VerifiedAt     *time.Time `json:"verified_at"`                    // 完成验证的时间
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (ACActionRecord) TableName() string {
+	return "ac_action_records"
+}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -34,13 +37,17 @@ type AIStrategyCondition struct {
 	StartTime   string      `json:"startTime"`    // 开始时间 (时间条件)
 	EndTime     string      `json:"endTime"`      // 结束时间 (时间条件)
 	ServerID    string      `json:"serverId"`     // 服务器ID (服务器负载条件)
-	LoadType    string      `json:"loadType"`     // 负载类型: cpu, memory, disk
+	LoadType    string      `json:"loadType"`     // 负载类型: cpu, memory, disk（使用率%）
 	Description string      `json:"description"`  // 条件描述
+
+	// 服务器负载条件：按时间窗口内的平均值比较，最新指标超过有效时间时条件结果未知（不触发）
+	Window int `json:"window,omitempty"` // 平均窗口（秒），0 表示只看最新值
+	MaxAge int `json:"maxAge,omitempty"` // 指标有效时间（秒），0 表示使用 server_metrics.max_age 配置
 }
 
 // AIStrategyAction 策略动作
 type AIStrategyAction struct {
-	Type           string `json:"type"`           // 动作类型: server_control, breaker_control, notification, template
+	Type           string `json:"type"`           // 动作类型: server_control, breaker_control, ac_control, notification, template
 	DeviceID       string `json:"deviceId"`       // 设备ID
 	DeviceName     string `json:"deviceName"`     // 设备名称
 	Operation      string `json:"operation"`      // 操作: shutdown, restart, off, on
@@ -49,6 +56,48 @@ type AIStrategyAction struct {
 	TemplateID     *uint  `json:"templateId"`     // 动作模板ID（可选）
 	TemplateName   string `json:"templateName"`   // 动作模板名称（用于显示）
 	UseTemplate    bool   `json:"useTemplate"`    // 是否使用动作模板
+
+	// 空调控制（ac_control）：DeviceID 为红外控制器ID，未指定的项沿用最后一次下发的状态
+	Power          *bool  `json:"power,omitempty"`             // 开关机
+	Mode           string `json:"mode,omitempty"`              // 模式: auto, cool, dry, fan, heat
+	TargetTemp     *int   `json:"targetTemperature,omitempty"` // 设定温度 16-30℃
+	FanSpeed       string `json:"fanSpeed,omitempty"`          // 风速: auto, low, medium, high
+	VerifySensorID string `json:"verifySensorId,omitempty"`    // 验证室温趋势的传感器通道，格式 "传感器ID-通道"，只写传感器ID时为通道1
+	VerifyMinutes  int    `json:"verifyMinutes,omitempty"`     // 观察室温的分钟数，默认10
+}
+
+// Validate 校验动作配置：空调控制动作的红外控制器ID和验证传感器通道
+func (a AIStrategyAction) Validate() error {
+	switch a.Type {
+	case "ac_control", "ac":
+		if _, err := strconv.ParseUint(a.DeviceID, 10, 32); err != nil {
+			return fmt.Errorf("无效的红外控制器ID: %s", a.DeviceID)
+		}
+		if a.VerifySensorID != "" {
+			if _, _, err := ParseSensorChannel(a.VerifySensorID); err != nil {
+				return fmt.Errorf("空调控制动作的验证传感器无效: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseSensorChannel 解析 "传感器ID-通道" 格式的传感器通道。只写传感器ID时为通道1，
+// 与温度条件的匹配方式一致（虚拟传感器只有通道1）
+func ParseSensorChannel(key string) (uint, int, error) {
+	idPart, channelPart, hasChannel := strings.Cut(key, "-")
+	id, err := strconv.ParseUint(idPart, 10, 32)
+	if err != nil || id == 0 {
+		return 0, 0, fmt.Errorf("无效的传感器通道: %s，格式应为 传感器ID 或 传感器ID-通道", key)
+	}
+	channel := 1
+	if hasChannel {
+		channel, err = strconv.Atoi(channelPart)
+		if err != nil || channel < 1 {
+			return 0, 0, fmt.Errorf("无效的传感器通道: %s，格式应为 传感器ID 或 传感器ID-通道", key)
+		}
+	}
+	return uint(id), channel, nil
 }
 
 // AIStrategy AI控制策略模型
@@ -29,23 +29,27 @@ type Breaker struct {
 	ID             uint           `json:"id" gorm:"primaryKey"`
 	DeviceID       uint           `json:"device_id" gorm:"not null"`
 	BreakerName    string         `json:"breaker_name" gorm:"size:100;not null"`
-	IPAddress      string         `json:"ip_address" gorm:"size:45;not null"`  // 断路器IP地址
-	Port           int            `json:"port" gorm:"default:502"`             // Modbus端口，默认502
-	StationID      int            `json:"station_id" gorm:"default:1"`         // Modbus站号，默认1
-	RatedVoltage   *float64       `json:"rated_voltage"`                       // 额定电压
-	RatedCurrent   *float64       `json:"rated_current"`                       // 额定电流
-	AlarmCurrent   *float64       `json:"alarm_current"`                       // 告警电流
-	Location       string         `json:"location" gorm:"size:200"`            // 安装位置
-	IsControllable bool           `json:"is_controllable" gorm:"default:true"` // 是否可控制
-	IsEnabled      bool           `json:"is_enabled" gorm:"default:true"`      // 是否启用
-	IsLocked       bool           `json:"is_locked" gorm:"default:false"`      // 是否锁定
-	Status         SwitchStatus   `json:"status" gorm:"default:'unknown'"`     // 当前状态
-	LastUpdate     *time.Time     `json:"last_update"`                         // 最后更新时间
-	Description    string         `json:"description" gorm:"type:text"`        // 描述
+	IPAddress      string         `json:"ip_address" gorm:"size:45;not null"`    // 断路器IP地址
+	Port           int            `json:"port" gorm:"default:502"`               // Modbus端口，默认502
+	StationID      int            `json:"station_id" gorm:"default:1"`           // Modbus站号，默认1
+	Framing        string         `json:"framing" gorm:"size:20;default:'mbap'"` // 报文格式：mbap/rtu_over_tcp/rtu（rtu 时使用串口参数）
+	RatedVoltage   *float64       `json:"rated_voltage"`                         // 额定电压
+	RatedCurrent   *float64       `json:"rated_current"`                         // 额定电流
+	AlarmCurrent   *float64       `json:"alarm_current"`                         // 告警电流
+	Location       string         `json:"location" gorm:"size:200"`              // 安装位置
+	IsControllable bool           `json:"is_controllable" gorm:"default:true"`   // 是否可控制
+	IsEnabled      bool           `json:"is_enabled" gorm:"default:true"`        // 是否启用
+	IsLocked       bool           `json:"is_locked" gorm:"default:false"`        // 是否锁定
+	Status         SwitchStatus   `json:"status" gorm:"default:'unknown'"`       // 当前状态
+	LastUpdate     *time.Time     `json:"last_update"`                           // 最后更新时间
+	Description    string         `json:"description" gorm:"type:text"`          // 描述
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// 串口参数
+	SerialSettings
+
 	// 关联
 	Device   *Device                `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
 	Bindings []BreakerServerBinding `json:"bindings,omitempty" gorm:"foreignKey:BreakerID"`
@@ -131,15 +135,20 @@ func (BreakerControl) TableName() string {
 // CreateBreakerRequest 创建断路器请求
 type CreateBreakerRequest struct {
 	BreakerName    string   `json:"breaker_name" binding:"required,max=100"`
-	IPAddress      string   `json:"ip_address" binding:"required,ip"`
+	IPAddress      string   `json:"ip_address" binding:"omitempty,ip"` // TCP类报文格式必填
 	Port           int      `json:"port" binding:"omitempty,min=1,max=65535"`
 	StationID      int      `json:"station_id" binding:"omitempty,min=1,max=255"`
+	Framing        string   `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
+	DeviceModel    string   `json:"device_model" binding:"omitempty,max=100"` // 设备型号，决定使用的驱动
 	RatedVoltage   *float64 `json:"rated_voltage" binding:"omitempty,min=0"`
 	RatedCurrent   *float64 `json:"rated_current" binding:"omitempty,min=0"`
 	AlarmCurrent   *float64 `json:"alarm_current" binding:"omitempty,min=0"`
 	Location       string   `json:"location" binding:"omitempty,max=200"`
 	IsControllable bool     `json:"is_controllable"`
 	Description    string   `json:"description" binding:"omitempty,max=1000"`
+
+	// 串口参数（报文格式为 rtu 时必填串口设备路径）
+	SerialSettings
 }
 
 // UpdateBreakerRequest 更新断路器请求
@@ -148,6 +157,8 @@ type UpdateBreakerRequest struct {
 	IPAddress      string   `json:"ip_address" binding:"omitempty,ip"`
 	Port           int      `json:"port" binding:"omitempty,min=1,max=65535"`
 	StationID      int      `json:"station_id" binding:"omitempty,min=1,max=255"`
+	Framing        string   `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
+	DeviceModel    string   `json:"device_model" binding:"omitempty,max=100"` // 设备型号，决定使用的驱动
 	RatedVoltage   *float64 `json:"rated_voltage" binding:"omitempty,min=0"`
 	RatedCurrent   *float64 `json:"rated_current" binding:"omitempty,min=0"`
 	AlarmCurrent   *float64 `json:"alarm_current" binding:"omitempty,min=0"`
@@ -155,6 +166,9 @@ type UpdateBreakerRequest struct {
 	IsControllable bool     `json:"is_controllable"`
 	IsEnabled      bool     `json:"is_enabled"`
 	Description    string   `json:"description" binding:"omitempty,max=1000"`
+
+	// 串口参数
+	SerialSettings
 }
 
 // BreakerControlRequest 断路器控制请求
@@ -191,6 +205,8 @@ type BreakerListResponse struct {
 	IPAddress      string       `json:"ip_address"`
 	Port           int          `json:"port"`
 	StationID      int          `json:"station_id"`
+	Framing        string       `json:"framing"`
+	DeviceModel    string       `json:"device_model"`
 	RatedVoltage   *float64     `json:"rated_voltage"`
 	RatedCurrent   *float64     `json:"rated_current"`
 	AlarmCurrent   *float64     `json:"alarm_current"`
@@ -203,6 +219,9 @@ type BreakerListResponse struct {
 	Description    string       `json:"description"`
 	CreatedAt      time.Time    `json:"created_at"`
 
+	// 串口参数
+	SerialSettings
+
 	// 绑定的服务器信息
 	BoundServers []BoundServerInfo `json:"bound_servers,omitempty"`
 
@@ -221,6 +240,14 @@ type BoundServerInfo struct {
 	IsActive   bool   `json:"is_active"`
 }
 
+// Model 设备型号（来自关联设备），未加载或未填写时返回空
+func (b *Breaker) Model() string {
+	if b.Device == nil {
+		return ""
+	}
+	return b.Device.DeviceModel
+}
+
 // ToListResponse 转换为列表响应格式
 func (b *Breaker) ToListResponse() BreakerListResponse {
 	response := BreakerListResponse{
@@ -229,6 +256,9 @@ func (b *Breaker) ToListResponse() BreakerListResponse {
 		IPAddress:      b.IPAddress,
 		Port:           b.Port,
 		StationID:      b.StationID,
+		Framing:        b.Framing,
+		SerialSettings: b.SerialSettings,
+		DeviceModel:    b.Model(),
 		RatedVoltage:   b.RatedVoltage,
 		RatedCurrent:   b.RatedCurrent,
 		AlarmCurrent:   b.AlarmCurrent,
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+)
+
+// 电量来源
+const (
+	EnergySourceMeter       = "meter"       // 设备电能寄存器
+	EnergySourceIntegration = "integration" // 有功功率积分
+)
+
+// BreakerTelemetrySample 断路器遥测采样（按采集间隔保存）
+type BreakerTelemetrySample struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	BreakerID      uint      `json:"breaker_id" gorm:"not null;index:idx_breaker_telemetry_time,priority:1"`
+	SampledAt      time.Time `json:"sampled_at" gorm:"not null;index:idx_breaker_telemetry_time,priority:2"`
+	Closed         bool      `json:"closed"`
+	Voltage        float64   `json:"voltage"`         // V
+	Current        float64   `json:"current"`         // A
+	ActivePower    float64   `json:"active_power"`    // W
+	PowerFactor    float64   `json:"power_factor"`    // 0-1
+	Frequency      float64   `json:"frequency"`       // Hz
+	LeakageCurrent float64   `json:"leakage_current"` // mA
+	Temperature    float64   `json:"temperature"`     // °C
+	MeterEnergy    *float64  `json:"meter_energy"`    // 设备电能寄存器读数 kWh，无电能寄存器时为空
+	EnergyDelta    float64   `json:"energy_delta"`    // 本采样周期电量 kWh
+	EnergyTotal    float64   `json:"energy_total"`    // 采样后累计电量 kWh
+}
+
+// TableName 指定表名
+func (BreakerTelemetrySample) TableName() string {
+	return "breaker_telemetry_samples"
+}
+
+// BreakerEnergyCounter 断路器累计电量计数器（每台断路器一行）
+type BreakerEnergyCounter struct {
+	BreakerID    uint       `json:"breaker_id" gorm:"primaryKey;autoIncrement:false"`
+	Source       string     `json:"source" gorm:"size:20"`                       // meter/integration
+	TotalKWh     float64    `json:"total_kwh" gorm:"column:total_kwh"`           // 累计电量
+	LastMeterKWh *float64   `json:"last_meter_kwh" gorm:"column:last_meter_kwh"` // 上次电能寄存器读数
+	LastPower    float64    `json:"last_power"`                                  // 上次有功功率 W
+	LastSampleAt *time.Time `json:"last_sample_at"`                              // 上次采样时间
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (BreakerEnergyCounter) TableName() string {
+	return "breaker_energy_counters"
+}
+
+// BreakerEnergyHourly 断路器小时电量，日/月统计由小时电量汇总
+type BreakerEnergyHourly struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BreakerID uint      `json:"breaker_id" gorm:"not null;uniqueIndex:idx_breaker_energy_hour,priority:1"`
+	Hour      time.Time `json:"hour" gorm:"not null;uniqueIndex:idx_breaker_energy_hour,priority:2"`
+	KWh       float64   `json:"kwh" gorm:"column:kwh"`
+	Samples   int       `json:"samples"`
+}
+
+// TableName 指定表名
+func (BreakerEnergyHourly) TableName() string {
+	return "breaker_energy_hourly"
+}
+
+// EnergyConsumptionQuery 用电量查询条件
+type EnergyConsumptionQuery struct {
+	Granularity string    // hour/day/month
+	GroupBy     string    // breaker/location/server
+	Start       time.Time // 含
+	End         time.Time // 不含
+	BreakerID   uint      // 可选，只统计指定断路器
+	Location    string    // 可选，只统计指定位置
+}
+
+// EnergyConsumptionBucket 单个统计周期的电量
+type EnergyConsumptionBucket struct {
+	Period time.Time `json:"period"`
+	KWh    float64   `json:"kwh"`
+}
+
+// EnergyConsumptionSeries 单个统计对象的电量序列
+type EnergyConsumptionSeries struct {
+	Key      string                    `json:"key"`  // 断路器ID/位置/服务器ID
+	Name     string                    `json:"name"` // 显示名称
+	TotalKWh float64                   `json:"total_kwh"`
+	Buckets  []EnergyConsumptionBucket `json:"buckets"`
+}
+
+// EnergyConsumptionResponse 用电量统计响应
+type EnergyConsumptionResponse struct {
+	Granularity string                    `json:"granularity"`
+	GroupBy     string                    `json:"group_by"`
+	Start       time.Time                 `json:"start"`
+	End         time.Time                 `json:"end"`
+	TotalKWh    float64                   `json:"total_kwh"`
+	Series      []EnergyConsumptionSeries `json:"series"`
+}
+
+// BreakerEnergyResponse 断路器累计电量及最近一次采样
+type BreakerEnergyResponse struct {
+	Counter      *BreakerEnergyCounter   `json:"counter"`
+	LatestSample *BreakerTelemetrySample `json:"latest_sample"`
+}
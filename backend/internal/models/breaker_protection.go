@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+)
+
+// BreakerProtectionChange 断路器保护参数修改记录（每个参数一条，同一次提交共用 BatchID）
+type BreakerProtectionChange struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BreakerID uint      `json:"breaker_id" gorm:"not null;index"`
+	BatchID   string    `json:"batch_id" gorm:"size:50;index"`     // 同一次提交的批次ID
+	Parameter string    `json:"parameter" gorm:"size:50;not null"` // 参数名，如 over_current
+	Label     string    `json:"label" gorm:"size:50"`              // 参数显示名称
+	Unit      string    `json:"unit" gorm:"size:10"`
+	OldValue  float64   `json:"old_value"`                     // 修改前设备中的值
+	NewValue  float64   `json:"new_value"`                     // 期望写入的值
+	ReadBack  *float64  `json:"read_back"`                     // 写入后回读的值
+	Verified  bool      `json:"verified" gorm:"default:false"` // 回读值与期望值一致
+	UserID    uint      `json:"user_id" gorm:"index"`          // 操作人
+	Username  string    `json:"username" gorm:"size:50"`
+	Reason    string    `json:"reason" gorm:"type:text"` // 修改原因
+	ErrorMsg  string    `json:"error_msg" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 关联
+	Breaker *Breaker `json:"breaker,omitempty" gorm:"foreignKey:BreakerID"`
+}
+
+// TableName 指定表名
+func (BreakerProtectionChange) TableName() string {
+	return "breaker_protection_changes"
+}
+
+// BreakerProtectionParameter 保护参数当前值及取值范围
+type BreakerProtectionParameter struct {
+	Key   string   `json:"key"`
+	Label string   `json:"label"`
+	Unit  string   `json:"unit"`
+	Type  string   `json:"type"`
+	Value float64  `json:"value"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Step  float64  `json:"step,omitempty"`
+}
+
+// BreakerProtectionResponse 保护参数读取响应
+type BreakerProtectionResponse struct {
+	BreakerID   uint                         `json:"breaker_id"`
+	DeviceModel string                       `json:"device_model"`
+	Parameters  []BreakerProtectionParameter `json:"parameters"`
+	ReadAt      time.Time                    `json:"read_at"`
+}
+
+// UpdateBreakerProtectionRequest 修改保护参数请求，值为工程单位（延时为秒）
+type UpdateBreakerProtectionRequest struct {
+	Parameters map[string]float64 `json:"parameters" binding:"required"`
+	Reason     string             `json:"reason"`
+}
+
+// BreakerProtectionUpdateResponse 修改保护参数结果
+type BreakerProtectionUpdateResponse struct {
+	BatchID  string                    `json:"batch_id"`
+	Verified bool                      `json:"verified"` // 全部参数写入并回读一致
+	Changes  []BreakerProtectionChange `json:"changes"`
+}
+
+// BreakerProtectionChangeListResponse 保护参数修改记录列表响应
+type BreakerProtectionChangeListResponse struct {
+	Changes []BreakerProtectionChange `json:"changes"`
+	Total   int64                     `json:"total"`
+	Page    int                       `json:"page"`
+	Size    int                       `json:"size"`
+}
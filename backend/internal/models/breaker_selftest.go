@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+)
+
+// 漏电自检结果
+const (
+	SelfTestStatusRunning = "running" // 执行中
+	SelfTestStatusPassed  = "passed"  // 按下试验按钮后以漏电保护跳闸
+	SelfTestStatusFailed  = "failed"  // 未跳闸或跳闸原因不是漏电保护
+	SelfTestStatusSkipped = "skipped" // 断路器分闸、锁定或禁用，未执行
+	SelfTestStatusError   = "error"   // 通信失败等，无法判定
+)
+
+// 漏电自检触发方式
+const (
+	SelfTestTriggerScheduled = "scheduled"
+	SelfTestTriggerManual    = "manual"
+)
+
+// 自检通过后的重新合闸状态
+const (
+	RecloseStatusNone     = ""         // 不需要重新合闸
+	RecloseStatusPending  = "pending"  // 等待操作员批准
+	RecloseStatusClosed   = "closed"   // 已批准并确认合闸
+	RecloseStatusRejected = "rejected" // 操作员拒绝，保持分闸
+	RecloseStatusFailed   = "failed"   // 已批准但合闸失败
+)
+
+// BreakerSelfTestSchedule 断路器漏电自检计划（每台断路器一条）
+type BreakerSelfTestSchedule struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	BreakerID      uint       `json:"breaker_id" gorm:"not null;uniqueIndex"`
+	Enabled        bool       `json:"enabled"`
+	IntervalDays   int        `json:"interval_days" gorm:"default:30"` // 自检周期（天）
+	Hour           int        `json:"hour"`                            // 在当天几点执行（服务器本地时间），便于安排在维护窗口
+	RequestReclose bool       `json:"request_reclose"`                 // 自检通过后申请重新合闸，需操作员批准
+	NextRunAt      *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastStatus     string     `json:"last_status" gorm:"size:20"`
+	UpdatedBy      string     `json:"updated_by" gorm:"size:50"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// 关联
+	Breaker *Breaker `json:"breaker,omitempty" gorm:"foreignKey:BreakerID"`
+}
+
+// TableName 指定表名
+func (BreakerSelfTestSchedule) TableName() string {
+	return "breaker_self_test_schedules"
+}
+
+// BreakerSelfTest 一次漏电自检记录
+type BreakerSelfTest struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	BreakerID     uint         `json:"breaker_id" gorm:"not null;index"`
+	Trigger       string       `json:"trigger" gorm:"size:20"`
+	Status        string       `json:"status" gorm:"size:20;index"`
+	StatusBefore  SwitchStatus `json:"status_before" gorm:"size:20"`
+	Tripped       bool         `json:"tripped"`                        // 试验后回读为分闸
+	TripMs        int64        `json:"trip_ms"`                        // 从下发试验命令到回读分闸的耗时
+	ReasonCode    uint16       `json:"reason_code"`                    // 设备上报的跳闸原因代码
+	HexCode       string       `json:"hex_code" gorm:"size:10"`        // 跳闸原因代码（十六进制）
+	Reasons       []string     `json:"reasons" gorm:"serializer:json"` // 跳闸原因
+	ErrorMsg      string       `json:"error_msg" gorm:"type:text"`     // 失败或跳过原因
+	Username      string       `json:"username" gorm:"size:50"`        // 手动触发人，计划执行为空
+	RecloseStatus string       `json:"reclose_status" gorm:"size:20;index"`
+	RecloseBy     string       `json:"reclose_by" gorm:"size:50"` // 批准或拒绝的操作员
+	RecloseAt     *time.Time   `json:"reclose_at"`
+	RecloseError  string       `json:"reclose_error" gorm:"type:text"`
+	StartedAt     time.Time    `json:"started_at" gorm:"not null;index"`
+	CompletedAt   *time.Time   `json:"completed_at"`
+
+	// 关联
+	Breaker *Breaker `json:"breaker,omitempty" gorm:"foreignKey:BreakerID"`
+}
+
+// TableName 指定表名
+func (BreakerSelfTest) TableName() string {
+	return "breaker_self_tests"
+}
+
+// UpdateSelfTestScheduleRequest 设置漏电自检计划请求
+type UpdateSelfTestScheduleRequest struct {
+	Enabled        bool `json:"enabled"`
+	IntervalDays   int  `json:"interval_days" binding:"required,min=1,max=366"`
+	Hour           int  `json:"hour" binding:"min=0,max=23"`
+	RequestReclose bool `json:"request_reclose"`
+}
+
+// RunSelfTestRequest 手动执行漏电自检请求
+type RunSelfTestRequest struct {
+	RequestReclose bool `json:"request_reclose"` // 自检通过后申请重新合闸
+}
+
+// ApproveRecloseRequest 批准或拒绝自检后重新合闸
+type ApproveRecloseRequest struct {
+	Approve bool `json:"approve"`
+}
+
+// BreakerSelfTestListResponse 漏电自检记录列表响应
+type BreakerSelfTestListResponse struct {
+	Tests []BreakerSelfTest `json:"tests"`
+	Total int64             `json:"total"`
+	Page  int               `json:"page"`
+	Size  int               `json:"size"`
+}
+
+// SelfTestComplianceItem 单台断路器的漏电自检合规情况
+type SelfTestComplianceItem struct {
+	BreakerID     uint       `json:"breaker_id"`
+	BreakerName   string     `json:"breaker_name"`
+	Location      string     `json:"location"`
+	Supported     bool       `json:"supported"` // 设备型号支持远程漏电试验
+	ScheduleDays  int        `json:"schedule_days"`
+	NextRunAt     *time.Time `json:"next_run_at"`
+	LastTestedAt  *time.Time `json:"last_tested_at"`
+	LastStatus    string     `json:"last_status"`
+	LastPassedAt  *time.Time `json:"last_passed_at"`
+	DaysSincePass *int       `json:"days_since_pass"`
+	Compliant     bool       `json:"compliant"` // 在要求周期内有一次通过的自检
+}
+
+// SelfTestComplianceReport 漏电自检合规报告
+type SelfTestComplianceReport struct {
+	MaxAgeDays   int                      `json:"max_age_days"`
+	GeneratedAt  time.Time                `json:"generated_at"`
+	Total        int                      `json:"total"`
+	Compliant    int                      `json:"compliant"`
+	NonCompliant int                      `json:"non_compliant"`
+	Items        []SelfTestComplianceItem `json:"items"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// BreakerTripEvent 断路器跳闸事件（状态监控检测到合闸→分闸且非本系统远程操作时记录）
+type BreakerTripEvent struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	BreakerID      uint      `json:"breaker_id" gorm:"not null;index"`
+	ReasonCode     uint16    `json:"reason_code" gorm:"not null"`         // 设备上报的跳闸原因代码
+	HexCode        string    `json:"hex_code" gorm:"size:10"`             // 跳闸原因代码（十六进制）
+	ReasonType     string    `json:"reason_type" gorm:"size:20"`          // single 单一原因 / composite 复合原因
+	Category       string    `json:"category" gorm:"size:20;index"`       // protection/operation/fault/limit/unknown
+	Reasons        []string  `json:"reasons" gorm:"serializer:json"`      // 跳闸原因
+	Description    string    `json:"description" gorm:"type:text"`        // 详细说明
+	Suggestions    []string  `json:"suggestions" gorm:"serializer:json"`  // 处理建议
+	TripRecords    []uint16  `json:"trip_records" gorm:"serializer:json"` // 设备内跳闸记录（从新到旧）
+	PreviousStatus string    `json:"previous_status" gorm:"size:20"`      // 跳闸前状态
+	TrippedAt      time.Time `json:"tripped_at" gorm:"not null;index"`    // 检测到跳闸的时间
+	CreatedAt      time.Time `json:"created_at"`
+
+	// 关联
+	Breaker *Breaker `json:"breaker,omitempty" gorm:"foreignKey:BreakerID"`
+}
+
+// TableName 指定表名
+func (BreakerTripEvent) TableName() string {
+	return "breaker_trip_events"
+}
+
+// BreakerTripEventListResponse 跳闸事件列表响应
+type BreakerTripEventListResponse struct {
+	Events []BreakerTripEvent `json:"events"`
+	Total  int64              `json:"total"`
+	Page   int                `json:"page"`
+	Size   int                `json:"size"`
+}
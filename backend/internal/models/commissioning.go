@@ -0,0 +1,42 @@
+package models
+
+// CommissioningEndpoint 投运调试的网关端口（或本地串口）及设备型号
+type CommissioningEndpoint struct {
+	Framing     string `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
+	IPAddress   string `json:"ip_address" binding:"omitempty,ip"` // TCP类报文格式必填
+	Port        int    `json:"port" binding:"omitempty,min=1,max=65535"`
+	DeviceModel string `json:"device_model" binding:"omitempty,max=100"` // 设备型号，未填写时使用默认断路器型号
+
+	// 串口参数（报文格式为 rtu 时必填串口设备路径）
+	SerialSettings
+}
+
+// CommissioningScanRequest 站号扫描请求，未指定站号列表时扫描 start_station 到 end_station
+type CommissioningScanRequest struct {
+	CommissioningEndpoint
+	StartStation int   `json:"start_station" binding:"omitempty,min=1,max=247"`
+	EndStation   int   `json:"end_station" binding:"omitempty,min=1,max=247"`
+	Stations     []int `json:"stations" binding:"omitempty,max=247,dive,min=1,max=247"`
+	Attempts     int   `json:"attempts" binding:"omitempty,min=2,max=10"`      // 有应答的站号重复读取次数，用于发现地址冲突
+	TimeoutMs    int   `json:"timeout_ms" binding:"omitempty,min=50,max=3000"` // 单帧响应超时
+}
+
+// CommissionBreakerRequest 投运单台断路器：修改站号、波特率（均可选）并登记
+type CommissionBreakerRequest struct {
+	CommissioningEndpoint
+	StationID    int                     `json:"station_id" binding:"required,min=1,max=247"` // 当前站号，新设备出厂为1
+	NewStationID int                     `json:"new_station_id" binding:"omitempty,min=1,max=247"`
+	NewBaudRate  int                     `json:"new_baud_rate" binding:"omitempty,oneof=1200 2400 4800 9600 19200"`
+	Register     *CommissionRegistration `json:"register"` // 为空时只修改通信参数，不登记断路器
+}
+
+// CommissionRegistration 投运完成后登记断路器的信息，连接参数取自投运结果
+type CommissionRegistration struct {
+	BreakerName    string   `json:"breaker_name" binding:"required,max=100"`
+	RatedVoltage   *float64 `json:"rated_voltage" binding:"omitempty,min=0"`
+	RatedCurrent   *float64 `json:"rated_current" binding:"omitempty,min=0"`
+	AlarmCurrent   *float64 `json:"alarm_current" binding:"omitempty,min=0"`
+	Location       string   `json:"location" binding:"omitempty,max=200"`
+	IsControllable bool     `json:"is_controllable"`
+	Description    string   `json:"description" binding:"omitempty,max=1000"`
+}
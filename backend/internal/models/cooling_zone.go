@@ -0,0 +1,156 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 制冷区域的输出方式
+const (
+	CoolingOutputStaged   = "staged"   // 按顺序启停多台空调（级数）
+	CoolingOutputSetPoint = "setpoint" // 空调全部运行，调节设定温度
+)
+
+// 制冷区域的人工接管方式
+const (
+	CoolingOverrideOff    = "off"    // 全部关机
+	CoolingOverrideManual = "manual" // 固定运行级数和设定温度
+)
+
+// 制冷闭环的决策动作
+const (
+	CoolingActionHold      = "hold"       // 保持当前输出
+	CoolingActionStageUp   = "stage_up"   // 增加运行的空调
+	CoolingActionStageDown = "stage_down" // 减少运行的空调
+	CoolingActionSetPoint  = "setpoint"   // 调整设定温度
+	CoolingActionOverride  = "override"   // 人工接管
+	CoolingActionNoData    = "no_data"    // 没有有效温度读数，保持当前输出
+)
+
+// CoolingZone 制冷区域：按区域温度闭环控制一组红外空调。
+// 温度取各通道最新有效读数的平均值或最大值，超出目标温度±死区时增减运行的空调或调整设定温度；
+// 启用 PID 时按 PID 输出（0-100%）映射到运行级数或设定温度。压缩机保护的最短运行/停机时间始终生效。
+type CoolingZone struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"size:100;not null"`
+	Location    string         `json:"location" gorm:"size:200"`
+	Description string         `json:"description" gorm:"type:text"`
+	IsEnabled   bool           `json:"is_enabled"` // 闭环是否启用，未启用时不下发任何指令
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 温度输入
+	SensorKeys   []string `json:"sensor_keys" gorm:"serializer:json"` // 温度通道，格式 "传感器ID-通道号"，可使用虚拟传感器
+	Aggregation  string   `json:"aggregation" gorm:"size:10"`         // avg/max，默认 avg
+	SensorMaxAge int      `json:"sensor_max_age"`                     // 读数最长有效时间（秒），0 表示120秒
+
+	// 控制参数
+	TargetTemp    float64 `json:"target_temp"`
+	Deadband      float64 `json:"deadband"`       // 死区（℃），温度在目标温度±死区内不动作
+	MinOnSeconds  int     `json:"min_on_seconds"` // 压缩机最短运行时间
+	MinOffSeconds int     `json:"min_off_seconds"`
+	Interval      int     `json:"interval"` // 控制周期（秒），0 表示60秒
+	Output        string  `json:"output" gorm:"size:20;not null"`
+	UnitIDs       []uint  `json:"unit_ids" gorm:"serializer:json"` // 红外控制器，分级启停时按顺序开机、逆序关机
+	MinSetPoint   int     `json:"min_set_point"`                   // 设定温度下限，分级启停时空调以该温度运行
+	MaxSetPoint   int     `json:"max_set_point"`
+
+	// PID 参数：误差为区域温度减目标温度（℃），积分按秒累计，输出 0-100%
+	PIDEnabled bool    `json:"pid_enabled"`
+	Kp         float64 `json:"kp"`
+	Ki         float64 `json:"ki"`
+	Kd         float64 `json:"kd"`
+
+	// 人工接管，到期后恢复闭环
+	OverrideMode     string     `json:"override_mode" gorm:"size:20"`
+	OverrideStages   int        `json:"override_stages"`
+	OverrideSetPoint int        `json:"override_set_point"`
+	OverrideUntil    *time.Time `json:"override_until"` // 为空表示直到取消
+	OverrideBy       string     `json:"override_by" gorm:"size:50"`
+
+	// 运行状态
+	Units      []CoolingUnitState `json:"units" gorm:"serializer:json"`
+	SetPoint   int                `json:"set_point"` // 当前下发的设定温度
+	Integral   float64            `json:"integral"`
+	LastError  *float64           `json:"last_error"`
+	LastTemp   *float64           `json:"last_temp"`
+	LastOutput *float64           `json:"last_output"`
+	LastAction string             `json:"last_action" gorm:"size:20"`
+	LastRunAt  *time.Time         `json:"last_run_at"`
+}
+
+// TableName 指定表名
+func (CoolingZone) TableName() string {
+	return "cooling_zones"
+}
+
+// CoolingUnitState 区域内一台空调的启停状态，ChangedAt 用于最短运行/停机时间
+type CoolingUnitState struct {
+	IRControllerID uint       `json:"ir_controller_id"`
+	On             bool       `json:"on"`
+	ChangedAt      *time.Time `json:"changed_at"`
+}
+
+// OverrideActive 人工接管是否生效
+func (z *CoolingZone) OverrideActive(now time.Time) bool {
+	return z.OverrideMode != "" && (z.OverrideUntil == nil || now.Before(*z.OverrideUntil))
+}
+
+// CoolingDecision 制冷闭环的一次决策记录
+type CoolingDecision struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ZoneID      uint      `json:"zone_id" gorm:"index;not null"`
+	Temperature *float64  `json:"temperature"`
+	TargetTemp  float64   `json:"target_temp"`
+	PIDOutput   *float64  `json:"pid_output"`
+	Action      string    `json:"action" gorm:"size:20"`
+	StagesOn    int       `json:"stages_on"` // 决策后运行的空调数
+	SetPoint    int       `json:"set_point"`
+	Reason      string    `json:"reason" gorm:"type:text"`
+	Commands    int       `json:"commands"` // 下发的空调指令数
+	Error       string    `json:"error" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (CoolingDecision) TableName() string {
+	return "cooling_decisions"
+}
+
+// CoolingZoneRequest 创建或更新制冷区域请求
+type CoolingZoneRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Location      string   `json:"location" binding:"omitempty,max=200"`
+	Description   string   `json:"description" binding:"omitempty,max=1000"`
+	SensorKeys    []string `json:"sensor_keys" binding:"required,min=1"`
+	Aggregation   string   `json:"aggregation" binding:"omitempty,oneof=avg max"`
+	SensorMaxAge  int      `json:"sensor_max_age" binding:"omitempty,min=10,max=3600"`
+	TargetTemp    float64  `json:"target_temp" binding:"required,min=10,max=40"`
+	Deadband      float64  `json:"deadband" binding:"omitempty,min=0,max=5"`
+	MinOnSeconds  int      `json:"min_on_seconds" binding:"omitempty,min=0,max=3600"`
+	MinOffSeconds int      `json:"min_off_seconds" binding:"omitempty,min=0,max=3600"`
+	Interval      int      `json:"interval" binding:"omitempty,min=10,max=3600"`
+	Output        string   `json:"output" binding:"required,oneof=staged setpoint"`
+	UnitIDs       []uint   `json:"unit_ids" binding:"required,min=1"`
+	MinSetPoint   int      `json:"min_set_point" binding:"omitempty,min=16,max=30"`
+	MaxSetPoint   int      `json:"max_set_point" binding:"omitempty,min=16,max=30"`
+	PIDEnabled    bool     `json:"pid_enabled"`
+	Kp            float64  `json:"kp" binding:"omitempty,min=0"`
+	Ki            float64  `json:"ki" binding:"omitempty,min=0"`
+	Kd            float64  `json:"kd" binding:"omitempty,min=0"`
+}
+
+// CoolingEnableRequest 启用或停用制冷闭环请求
+type CoolingEnableRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// CoolingOverrideRequest 人工接管制冷区域请求，Minutes 为0时直到取消
+type CoolingOverrideRequest struct {
+	Mode     string `json:"mode" binding:"required,oneof=off manual"`
+	Stages   int    `json:"stages" binding:"omitempty,min=0"`
+	SetPoint int    `json:"set_point" binding:"omitempty,min=16,max=30"`
+	Minutes  int    `json:"minutes" binding:"omitempty,min=1,max=10080"`
+}
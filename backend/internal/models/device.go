@@ -42,6 +42,7 @@ const (
 	DeviceTypeTemperatureSensor DeviceType = "temperature_sensor"
 	DeviceTypeBreaker           DeviceType = "breaker"
 	DeviceTypeServer            DeviceType = "server"
+	DeviceTypeIRController      DeviceType = "ir_controller"
 )
 
 // DeviceStatus 设备状态枚举
@@ -114,7 +115,7 @@ func (DeviceConnection) TableName() string {
 
 // CreateDeviceRequest 创建设备请求
 type CreateDeviceRequest struct {
-	DeviceType  DeviceType `json:"device_type" binding:"required,oneof=temperature_sensor breaker server"`
+	DeviceType  DeviceType `json:"device_type" binding:"required,oneof=temperature_sensor breaker server ir_controller"`
 	DeviceName  string     `json:"device_name" binding:"required,min=1,max=100"`
 	DeviceModel string     `json:"device_model" binding:"omitempty,max=100"`
 	IPAddress   string     `json:"ip_address" binding:"omitempty,ip"`
@@ -194,3 +195,8 @@ func (d *Device) IsBreaker() bool {
 func (d *Device) IsServer() bool {
 	return d.DeviceType == DeviceTypeServer
 }
+
+// IsIRController 检查是否为红外空调控制器
+func (d *Device) IsIRController() bool {
+	return d.DeviceType == DeviceTypeIRController
+}
@@ -0,0 +1,17 @@
+package models
+
+// StartDiscoveryRequest 网络设备自动发现请求
+type StartDiscoveryRequest struct {
+	CIDR        string `json:"cidr" binding:"required"`                                  // 网段，如 192.168.1.0/24，也可填写单个IP
+	Ports       string `json:"ports"`                                                    // 端口列表或范围，如 502,4196,8000-8003，默认 502
+	Stations    string `json:"stations"`                                                 // 每个端口探测的站号列表或范围，默认 1-8
+	Framing     string `json:"framing" binding:"omitempty,oneof=auto mbap rtu_over_tcp"` // 报文格式，auto 先试MODBUS TCP再试RTU透传
+	TimeoutMs   int    `json:"timeout_ms" binding:"omitempty,min=50,max=3000"`           // 单帧响应超时
+	Concurrency int    `json:"concurrency" binding:"omitempty,min=1,max=256"`            // 并发扫描的端口数
+}
+
+// ImportDiscoveryRequest 导入发现的设备，按候选序号选择
+type ImportDiscoveryRequest struct {
+	Candidates []int  `json:"candidates" binding:"required,min=1,dive,min=0"`
+	Location   string `json:"location" binding:"omitempty,max=200"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+)
+
+// 紧急断电范围
+const (
+	EmergencyScopeSite    = "site"    // 全站所有断路器
+	EmergencyScopeGateway = "gateway" // 单个网关端口（或串口总线）上的断路器
+)
+
+// 紧急断电状态
+const (
+	EmergencyStatusPending   = "pending"   // 已生成计划，等待二次确认
+	EmergencyStatusCancelled = "cancelled" // 已取消
+	EmergencyStatusExpired   = "expired"   // 确认超时
+	EmergencyStatusExecuting = "executing" // 执行中
+	EmergencyStatusCompleted = "completed" // 全部断路器确认分闸
+	EmergencyStatusPartial   = "partial"   // 部分断路器未能确认分闸
+	EmergencyStatusFailed    = "failed"    // 没有断路器确认分闸
+)
+
+// 分闸方式
+const (
+	EmergencyMethodBroadcast = "broadcast" // 站号0广播
+	EmergencyMethodUnicast   = "unicast"   // 逐台下发
+	EmergencyMethodSkipped   = "skipped"   // 未下发（已锁定、已禁用等）
+)
+
+// EmergencyPowerOff 紧急断电审计记录：申请人、确认人、范围、原因及每台断路器的执行结果
+type EmergencyPowerOff struct {
+	ID              uint                      `json:"id" gorm:"primaryKey"`
+	UUID            string                    `json:"uuid" gorm:"size:36;uniqueIndex;not null"`
+	Scope           string                    `json:"scope" gorm:"size:20;not null"`
+	Gateway         string                    `json:"gateway" gorm:"size:150"` // scope=gateway 时的网关标识
+	Reason          string                    `json:"reason" gorm:"type:text"`
+	Status          string                    `json:"status" gorm:"size:20;index;not null"`
+	RequestedBy     uint                      `json:"requested_by"`
+	RequestedByName string                    `json:"requested_by_name" gorm:"size:50"`
+	ConfirmedBy     uint                      `json:"confirmed_by"`
+	ConfirmedByName string                    `json:"confirmed_by_name" gorm:"size:50"`
+	BreakerCount    int                       `json:"breaker_count"`
+	SucceededCount  int                       `json:"succeeded_count"`
+	FailedCount     int                       `json:"failed_count"`
+	SkippedCount    int                       `json:"skipped_count"`
+	Gateways        []EmergencyGatewayPlan    `json:"gateways" gorm:"type:text;serializer:json"`
+	Results         []EmergencyPowerOffResult `json:"results" gorm:"type:text;serializer:json"`
+	ExpiresAt       time.Time                 `json:"expires_at"`
+	ConfirmedAt     *time.Time                `json:"confirmed_at"`
+	CompletedAt     *time.Time                `json:"completed_at"`
+	CreatedAt       time.Time                 `json:"created_at"`
+	UpdatedAt       time.Time                 `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (EmergencyPowerOff) TableName() string {
+	return "emergency_power_offs"
+}
+
+// EmergencyGatewayPlan 单个网关的分闸方式
+type EmergencyGatewayPlan struct {
+	Gateway      string `json:"gateway"`
+	Method       string `json:"method"`
+	BreakerCount int    `json:"breaker_count"`
+	Note         string `json:"note,omitempty"` // 不能广播的原因
+}
+
+// EmergencyPowerOffResult 单台断路器的执行结果
+type EmergencyPowerOffResult struct {
+	BreakerID    uint         `json:"breaker_id"`
+	BreakerName  string       `json:"breaker_name"`
+	Location     string       `json:"location"`
+	Gateway      string       `json:"gateway"`
+	StationID    int          `json:"station_id"`
+	Method       string       `json:"method"`
+	Fallback     bool         `json:"fallback"` // 广播后仍合闸，改为逐台下发
+	StatusBefore SwitchStatus `json:"status_before"`
+	StatusAfter  SwitchStatus `json:"status_after"`
+	Confirmed    bool         `json:"confirmed"` // 回读确认已分闸
+	Error        string       `json:"error,omitempty"`
+}
+
+// PrepareEmergencyPowerOffRequest 紧急断电第一步：生成执行计划
+type PrepareEmergencyPowerOffRequest struct {
+	Scope     string `json:"scope" binding:"required,oneof=site gateway"`
+	Gateway   string `json:"gateway"`    // 网关标识，如 mbap://192.168.1.10:502
+	BreakerID uint   `json:"breaker_id"` // 或指定该网关上任意一台断路器
+	Reason    string `json:"reason" binding:"required"`
+}
+
+// ExecuteEmergencyPowerOffRequest 紧急断电第二步：携带令牌并输入确认口令
+type ExecuteEmergencyPowerOffRequest struct {
+	Token        string `json:"token" binding:"required"`
+	Confirmation string `json:"confirmation" binding:"required"`
+}
+
+// EmergencyPowerOffPlan 紧急断电计划，需在 ExpiresAt 前由同一用户确认执行
+type EmergencyPowerOffPlan struct {
+	Record        *EmergencyPowerOff `json:"record"`
+	Token         string             `json:"token"`
+	ConfirmPhrase string             `json:"confirm_phrase"`
+	ExpiresAt     time.Time          `json:"expires_at"`
+}
+
+// EmergencyPowerOffListResponse 紧急断电记录列表响应
+type EmergencyPowerOffListResponse struct {
+	Records []EmergencyPowerOff `json:"records"`
+	Total   int64               `json:"total"`
+	Page    int                 `json:"page"`
+	Size    int                 `json:"size"`
+}
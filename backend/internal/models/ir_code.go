@@ -0,0 +1,244 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 红外码库设备类型（协议附件2：空调 00、电视 01、机顶盒 02）
+const (
+	IRDeviceAC  = "ac"
+	IRDeviceTV  = "tv"
+	IRDeviceSTB = "stb"
+)
+
+// 红外学习会话状态
+const (
+	IRLearningWaiting   = "waiting"   // 等待用户用遥控器对准控制器按键
+	IRLearningLearned   = "learned"   // 学习完成，学习码已保存
+	IRLearningTimeout   = "timeout"   // 等待时间内未收到遥控信号
+	IRLearningFailed    = "failed"    // 通信失败
+	IRLearningCancelled = "cancelled" // 服务重启等原因中断
+)
+
+// 空调档案的码源
+const (
+	ACProfileBrand   = "brand"   // 使用控制器内置码库代号
+	ACProfileLearned = "learned" // 使用学习码组
+)
+
+// IRCodeSet 学习码组：一台遥控器各按键学习到的红外码，用于内置码库不支持的空调型号
+type IRCodeSet struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"size:100;not null"`
+	DeviceType  string         `json:"device_type" gorm:"size:10"` // ac/tv/stb
+	Brand       string         `json:"brand" gorm:"size:50"`
+	Model       string         `json:"model" gorm:"size:100"` // 遥控器或空调型号
+	Description string         `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 关联
+	Codes []IRLearnedCode `json:"codes,omitempty" gorm:"foreignKey:CodeSetID"`
+}
+
+// TableName 指定表名
+func (IRCodeSet) TableName() string {
+	return "ir_code_sets"
+}
+
+// IRLearnedCode 学习到的按键红外码。波形保存在平台，发送时写入控制器的学习通道，
+// 因此同一码组可用于任意控制器
+type IRLearnedCode struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CodeSetID      uint      `json:"code_set_id" gorm:"not null;uniqueIndex:idx_ir_learned_code_button"`
+	Button         string    `json:"button" gorm:"size:50;not null;uniqueIndex:idx_ir_learned_code_button"` // 按键标识，空调按键见 ACButtonKey
+	Label          string    `json:"label" gorm:"size:100"`                                                 // 按键名称
+	Waveform       string    `json:"waveform" gorm:"size:200;not null"`                                     // 波形数据，100字节十六进制
+	IRControllerID uint      `json:"ir_controller_id"`                                                      // 学习时使用的控制器
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (IRLearnedCode) TableName() string {
+	return "ir_learned_codes"
+}
+
+// IRLearningSession 红外学习会话：控制器进入学习模式，等待用户按遥控器按键
+type IRLearningSession struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	IRControllerID uint       `json:"ir_controller_id" gorm:"index;not null"`
+	CodeSetID      uint       `json:"code_set_id" gorm:"not null"`
+	Button         string     `json:"button" gorm:"size:50;not null"`
+	Label          string     `json:"label" gorm:"size:100"`
+	Channel        int        `json:"channel"` // 使用的学习通道
+	Status         string     `json:"status" gorm:"size:20;index"`
+	Error          string     `json:"error" gorm:"type:text"`
+	CodeID         *uint      `json:"code_id"` // 学习完成后保存的学习码
+	Deadline       time.Time  `json:"deadline"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+}
+
+// TableName 指定表名
+func (IRLearningSession) TableName() string {
+	return "ir_learning_sessions"
+}
+
+// IRBrandCode 导入的品牌码库代号，补充控制器内置码库列表
+type IRBrandCode struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DeviceType string    `json:"device_type" gorm:"size:10;not null;uniqueIndex:idx_ir_brand_code"`
+	Brand      string    `json:"brand" gorm:"size:50;not null;index"`
+	English    string    `json:"english" gorm:"size:50"`
+	Variant    string    `json:"variant" gorm:"size:50"` // 系列，如 老款、新款、变频
+	Code       int       `json:"code" gorm:"not null;uniqueIndex:idx_ir_brand_code"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (IRBrandCode) TableName() string {
+	return "ir_brand_codes"
+}
+
+// IRKeyCode 导入的按键表（协议附件4），电视、机顶盒按键名与按键ID的对应关系
+type IRKeyCode struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DeviceType string    `json:"device_type" gorm:"size:10;not null;uniqueIndex:idx_ir_key_code"`
+	KeyID      int       `json:"key_id" gorm:"not null;uniqueIndex:idx_ir_key_code"`
+	Name       string    `json:"name" gorm:"size:50;not null"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (IRKeyCode) TableName() string {
+	return "ir_key_codes"
+}
+
+// ACProfile 空调档案：一台实体空调及其控制方式。每个红外控制器对应一台空调，
+// 码源为 brand 时使用控制器内置码库代号，为 learned 时按空调状态发送学习码组中对应的按键
+type ACProfile struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	Name           string         `json:"name" gorm:"size:100;not null"`
+	Location       string         `json:"location" gorm:"size:200"`
+	Model          string         `json:"model" gorm:"size:100"` // 空调型号
+	IRControllerID uint           `json:"ir_controller_id" gorm:"index;not null"`
+	Source         string         `json:"source" gorm:"size:20;not null"` // brand/learned
+	ACBrand        string         `json:"ac_brand" gorm:"size:50"`
+	ACCode         int            `json:"ac_code"`
+	CodeSetID      *uint          `json:"code_set_id"`
+	Description    string         `json:"description" gorm:"type:text"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 关联
+	IRController *IRController `json:"ir_controller,omitempty" gorm:"foreignKey:IRControllerID"`
+	CodeSet      *IRCodeSet    `json:"code_set,omitempty" gorm:"foreignKey:CodeSetID"`
+}
+
+// TableName 指定表名
+func (ACProfile) TableName() string {
+	return "ac_profiles"
+}
+
+// ACButtonKey 空调状态在学习码组中对应的按键标识：关机为 "off"，开机为 "模式_温度_风速"（如 cool_24_auto）。
+// 学习码组没有该风速时使用 "模式_温度"（如 cool_24），见 ACButtonKeys
+func ACButtonKey(state ACState) string {
+	if !state.Power {
+		return "off"
+	}
+	return fmt.Sprintf("%s_%d_%s", state.Mode, state.SetPoint, state.FanSpeed)
+}
+
+// ACButtonKeys 按优先级排列的空调状态按键标识
+func ACButtonKeys(state ACState) []string {
+	if !state.Power {
+		return []string{"off"}
+	}
+	return []string{ACButtonKey(state), fmt.Sprintf("%s_%d", state.Mode, state.SetPoint)}
+}
+
+// CreateIRCodeSetRequest 创建学习码组请求
+type CreateIRCodeSetRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	DeviceType  string `json:"device_type" binding:"omitempty,oneof=ac tv stb"` // 默认 ac
+	Brand       string `json:"brand" binding:"omitempty,max=50"`
+	Model       string `json:"model" binding:"omitempty,max=100"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+}
+
+// UpdateIRCodeSetRequest 更新学习码组请求
+type UpdateIRCodeSetRequest struct {
+	Name        string `json:"name" binding:"omitempty,max=100"`
+	Brand       string `json:"brand" binding:"omitempty,max=50"`
+	Model       string `json:"model" binding:"omitempty,max=100"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+}
+
+// StartIRLearningRequest 开始红外学习请求
+type StartIRLearningRequest struct {
+	CodeSetID uint   `json:"code_set_id" binding:"required"`
+	Button    string `json:"button" binding:"required,max=50"` // 空调按键使用 off、cool_24_auto、cool_24 等标识
+	Label     string `json:"label" binding:"omitempty,max=100"`
+	Timeout   int    `json:"timeout" binding:"omitempty,min=5,max=120"` // 等待按遥控器按键的秒数，默认30
+}
+
+// SendIRCodeRequest 发送学习码请求
+type SendIRCodeRequest struct {
+	CodeSetID uint   `json:"code_set_id" binding:"required"`
+	Button    string `json:"button" binding:"required,max=50"`
+}
+
+// IRBrandCodeEntry 导入的品牌代号
+type IRBrandCodeEntry struct {
+	Brand   string `json:"brand" binding:"required,max=50"`
+	English string `json:"english" binding:"omitempty,max=50"`
+	Variant string `json:"variant" binding:"omitempty,max=50"`
+	Code    int    `json:"code" binding:"required,min=1,max=65535"`
+}
+
+// ImportIRBrandCodesRequest 导入品牌码库请求，Replace 为 true 时先清空该设备类型已导入的代号
+type ImportIRBrandCodesRequest struct {
+	DeviceType string             `json:"device_type" binding:"required,oneof=ac tv stb"`
+	Replace    bool               `json:"replace"`
+	Brands     []IRBrandCodeEntry `json:"brands" binding:"required,min=1,dive"`
+}
+
+// IRKeyCodeEntry 导入的按键
+type IRKeyCodeEntry struct {
+	KeyID int    `json:"key_id" binding:"min=0,max=255"`
+	Name  string `json:"name" binding:"required,max=50"`
+}
+
+// ImportIRKeyCodesRequest 导入按键表请求，Replace 为 true 时先清空该设备类型的按键表
+type ImportIRKeyCodesRequest struct {
+	DeviceType string           `json:"device_type" binding:"required,oneof=ac tv stb"`
+	Replace    bool             `json:"replace"`
+	Keys       []IRKeyCodeEntry `json:"keys" binding:"required,min=1,dive"`
+}
+
+// IRImportResult 码表导入结果
+type IRImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ACProfileRequest 创建或更新空调档案请求：码源为 brand 时指定品牌或码库代号，为 learned 时指定学习码组
+type ACProfileRequest struct {
+	Name           string `json:"name" binding:"required,max=100"`
+	Location       string `json:"location" binding:"omitempty,max=200"`
+	Model          string `json:"model" binding:"omitempty,max=100"`
+	IRControllerID uint   `json:"ir_controller_id" binding:"required"`
+	Source         string `json:"source" binding:"required,oneof=brand learned"`
+	ACBrand        string `json:"ac_brand" binding:"omitempty,max=50"`
+	ACCode         int    `json:"ac_code" binding:"omitempty,min=1,max=65535"`
+	CodeSetID      *uint  `json:"code_set_id"`
+	Description    string `json:"description" binding:"omitempty,max=1000"`
+}
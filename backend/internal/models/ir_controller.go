@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 红外控制器通信模式
+const (
+	IRModeTCPServer = "tcp_server" // 设备作为 TCP 服务器，平台主动连接
+	IRModeTCPClient = "tcp_client" // 设备主动连接平台的监听端口
+	IRModeUDP       = "udp"
+	IRModeRTU       = "rtu" // RS485 Modbus-RTU，本地串口或经串口服务器透传
+)
+
+// IRController 红外空调控制器（如 CX-IR002E）。红外是单向的，State 记录平台最后一次下发的空调状态，
+// 不代表空调实际状态。
+type IRController struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	DeviceID    uint           `json:"device_id" gorm:"not null"`
+	Name        string         `json:"name" gorm:"size:100;not null"`
+	DeviceModel string         `json:"device_model" gorm:"size:100"`
+	Mode        string         `json:"mode" gorm:"size:20;not null"`
+	IPAddress   string         `json:"ip_address" gorm:"size:45"`    // TCP服务器/UDP模式为设备地址，TCP客户端模式为设备连接时的源IP
+	Port        int            `json:"port"`                         // 设备端口，RTU 模式为串口服务器端口
+	SlaveID     int            `json:"slave_id"`                     // RS485 设备地址，默认1
+	IRPort      int            `json:"ir_port"`                      // 红外输出口 1-4，0 表示四路同时发送
+	Location    string         `json:"location" gorm:"size:200"`     // 安装位置
+	Description string         `json:"description" gorm:"type:text"` // 描述
+	ACBrand     string         `json:"ac_brand" gorm:"size:50"`      // 空调品牌
+	ACCode      int            `json:"ac_code"`                      // 空调码库代号，0 表示使用控制器当前代号
+	IsEnabled   bool           `json:"is_enabled"`                   // 是否启用
+	Status      DeviceStatus   `json:"status" gorm:"size:20"`        // 最近一次通信结果：online/offline
+	LastSeen    *time.Time     `json:"last_seen"`                    // 最近一次通信成功时间
+	State       *ACState       `json:"state" gorm:"serializer:json"` // 最后一次下发的空调状态
+	StateAt     *time.Time     `json:"state_at"`                     // 最后一次下发空调状态的时间
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 串口参数（RTU 模式且串口设备路径不为空时使用本地串口）
+	SerialSettings
+
+	// 关联
+	Device *Device `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
+}
+
+// TableName 指定表名
+func (IRController) TableName() string {
+	return "ir_controllers"
+}
+
+// ACState 空调状态
+type ACState struct {
+	Power    bool   `json:"power"`
+	Mode     string `json:"mode"`      // auto/cool/dry/fan/heat
+	SetPoint int    `json:"set_point"` // 16-30℃
+	FanSpeed string `json:"fan_speed"` // auto/low/medium/high
+}
+
+// CreateIRControllerRequest 创建红外控制器请求
+type CreateIRControllerRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	DeviceModel string `json:"device_model" binding:"omitempty,max=100"` // 设备型号，决定使用的驱动
+	Mode        string `json:"mode" binding:"omitempty,oneof=tcp_server tcp_client udp rtu"`
+	IPAddress   string `json:"ip_address" binding:"omitempty,ip"` // 除本地串口外必填
+	Port        int    `json:"port" binding:"omitempty,min=1,max=65535"`
+	SlaveID     int    `json:"slave_id" binding:"omitempty,min=1,max=247"`
+	IRPort      int    `json:"ir_port" binding:"omitempty,min=0,max=4"`
+	Location    string `json:"location" binding:"omitempty,max=200"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	ACBrand     string `json:"ac_brand" binding:"omitempty,max=50"`
+	ACCode      int    `json:"ac_code" binding:"omitempty,min=1,max=65535"`
+
+	// 串口参数（RTU 模式使用本地串口时必填串口设备路径）
+	SerialSettings
+}
+
+// UpdateIRControllerRequest 更新红外控制器请求
+type UpdateIRControllerRequest struct {
+	Name        string `json:"name" binding:"omitempty,max=100"`
+	DeviceModel string `json:"device_model" binding:"omitempty,max=100"`
+	Mode        string `json:"mode" binding:"omitempty,oneof=tcp_server tcp_client udp rtu"`
+	IPAddress   string `json:"ip_address" binding:"omitempty,ip"`
+	Port        int    `json:"port" binding:"omitempty,min=1,max=65535"`
+	SlaveID     int    `json:"slave_id" binding:"omitempty,min=1,max=247"`
+	IRPort      *int   `json:"ir_port" binding:"omitempty,min=0,max=4"`
+	Location    string `json:"location" binding:"omitempty,max=200"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	IsEnabled   *bool  `json:"is_enabled"`
+
+	// 串口参数
+	SerialSettings
+}
+
+// SetACBrandRequest 设置空调品牌：指定码库代号，或只指定品牌时使用该品牌的第一个代号
+type SetACBrandRequest struct {
+	Brand string `json:"brand" binding:"omitempty,max=50"`
+	Code  int    `json:"code" binding:"omitempty,min=1,max=65535"`
+}
+
+// MatchACRequest 空调一键匹配请求
+type MatchACRequest struct {
+	Timeout int `json:"timeout" binding:"omitempty,min=5,max=120"` // 等待按遥控器电源键的秒数，默认60
+}
+
+// ACControlRequest 空调控制请求，未指定的项沿用最后一次下发的状态
+type ACControlRequest struct {
+	Power    *bool  `json:"power"`
+	Mode     string `json:"mode" binding:"omitempty,oneof=auto cool dry fan heat"`
+	SetPoint *int   `json:"set_point" binding:"omitempty,min=16,max=30"`
+	FanSpeed string `json:"fan_speed" binding:"omitempty,oneof=auto low medium high"`
+}
+
+// IRControllerStatus 红外控制器实时状态
+type IRControllerStatus struct {
+	Online      bool               `json:"online"`
+	Firmware    int                `json:"firmware,omitempty"`
+	MAC         string             `json:"mac,omitempty"`
+	ACCode      int                `json:"ac_code,omitempty"`
+	ACBrand     string             `json:"ac_brand,omitempty"`
+	State       *ACState           `json:"state,omitempty"` // 控制器寄存器中最后一次发码的状态
+	PowerSensed *bool              `json:"power_sensed,omitempty"`
+	ADCVoltage  *float64           `json:"adc_voltage,omitempty"`
+	Inputs      map[string]float64 `json:"inputs,omitempty"` // C01-C04 等模拟量
+	Error       string             `json:"error,omitempty"`
+	CheckedAt   time.Time          `json:"checked_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// 抓包对象类型
+const (
+	CaptureTargetBreaker           = "breaker"
+	CaptureTargetTemperatureSensor = "temperature_sensor"
+)
+
+// StartModbusCaptureRequest 开始MODBUS抓包请求
+type StartModbusCaptureRequest struct {
+	TargetType  string `json:"target_type" binding:"required,oneof=breaker temperature_sensor"`
+	TargetID    uint   `json:"target_id" binding:"required"`
+	WholeBus    bool   `json:"whole_bus"` // 抓取同一网关端口/串口上的全部站号，默认只抓目标设备站号
+	Description string `json:"description" binding:"omitempty,max=200"`
+}
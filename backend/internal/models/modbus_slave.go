@@ -0,0 +1,44 @@
+package models
+
+// 从站数据点来源
+const (
+	SlaveSourceTemperature    = "temperature"     // 温度通道最新读数 °C
+	SlaveSourceBreakerClosed  = "breaker_closed"  // 断路器合闸=1/分闸=0，线圈可写时用于分合闸
+	SlaveSourceBreakerLocked  = "breaker_locked"  // 断路器锁定=1
+	SlaveSourceBreakerPower   = "breaker_power"   // 最近一次遥测有功功率 W
+	SlaveSourceBreakerVoltage = "breaker_voltage" // 最近一次遥测电压 V
+	SlaveSourceBreakerCurrent = "breaker_current" // 最近一次遥测电流 A
+	SlaveSourceBreakerEnergy  = "breaker_energy"  // 累计电量 kWh
+	SlaveSourceAlarmCount     = "alarm_count"     // 未恢复的告警数，可按级别筛选
+)
+
+// 从站寄存器数据类型（32位类型占两个寄存器，高字在前）
+const (
+	SlaveTypeUint16  = "uint16"
+	SlaveTypeInt16   = "int16"
+	SlaveTypeUint32  = "uint32"
+	SlaveTypeInt32   = "int32"
+	SlaveTypeFloat32 = "float32"
+)
+
+// ModbusSlaveMap 从站寄存器映射（JSON文件）。
+// registers 同时响应03/04功能码，coils 同时响应01/02功能码，地址从0开始。
+type ModbusSlaveMap struct {
+	UnitID    int                `json:"unit_id"` // 0 表示响应任意单元号
+	Registers []ModbusSlavePoint `json:"registers"`
+	Coils     []ModbusSlavePoint `json:"coils"`
+}
+
+// ModbusSlavePoint 从站数据点
+type ModbusSlavePoint struct {
+	Address   uint16  `json:"address"`
+	Source    string  `json:"source"`
+	Name      string  `json:"name,omitempty"`       // 说明，便于与BMS点表对照
+	Type      string  `json:"type,omitempty"`       // 寄存器数据类型，默认 int16
+	Scale     float64 `json:"scale,omitempty"`      // 寄存器值 = 数据 × scale，默认 1
+	SensorID  uint    `json:"sensor_id,omitempty"`  // temperature
+	Channel   int     `json:"channel,omitempty"`    // temperature
+	BreakerID uint    `json:"breaker_id,omitempty"` // breaker_*
+	Severity  string  `json:"severity,omitempty"`   // alarm_count，为空统计全部级别
+	Writable  bool    `json:"writable,omitempty"`   // 仅 breaker_closed 线圈可写
+}
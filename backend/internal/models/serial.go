@@ -0,0 +1,9 @@
+package models
+
+// SerialSettings 本地串口RTU参数，报文格式为 rtu 时使用，其余报文格式忽略
+type SerialSettings struct {
+	SerialDevice string `json:"serial_device" gorm:"size:100" binding:"omitempty,max=100"`                                            // 串口设备路径，如 /dev/ttyUSB0
+	BaudRate     int    `json:"baud_rate" gorm:"default:9600" binding:"omitempty,oneof=1200 2400 4800 9600 19200 38400 57600 115200"` // 波特率
+	Parity       string `json:"parity" gorm:"size:1;default:'N'" binding:"omitempty,oneof=N E O"`                                     // 校验：N无/E偶/O奇
+	StopBits     int    `json:"stop_bits" gorm:"default:1" binding:"omitempty,oneof=1 2"`                                             // 停止位
+}
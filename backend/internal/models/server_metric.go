@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// 服务器负载指标来源
+const (
+	ServerMetricSourceSSH   = "ssh"
+	ServerMetricSourceAgent = "agent"
+)
+
+// ServerMetric 服务器负载指标采样，使用率为百分比。代理可以只上报部分指标，未上报的为空
+type ServerMetric struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ServerID    uint      `json:"server_id" gorm:"not null;index:idx_server_metric_time"`
+	CPUUsage    *float64  `json:"cpu_usage"`
+	MemoryUsage *float64  `json:"memory_usage"`
+	DiskUsage   *float64  `json:"disk_usage"` // 根分区
+	Load1       *float64  `json:"load1"`
+	Source      string    `json:"source" gorm:"size:10"` // ssh/agent
+	CollectedAt time.Time `json:"collected_at" gorm:"not null;index:idx_server_metric_time"`
+}
+
+// TableName 指定表名
+func (ServerMetric) TableName() string {
+	return "server_metrics"
+}
+
+// ServerMetricReport 代理上报的负载指标，CollectedAt 为空时使用接收时间
+type ServerMetricReport struct {
+	CPUUsage    *float64   `json:"cpu_usage" binding:"omitempty,min=0,max=100"`
+	MemoryUsage *float64   `json:"memory_usage" binding:"omitempty,min=0,max=100"`
+	DiskUsage   *float64   `json:"disk_usage" binding:"omitempty,min=0,max=100"`
+	Load1       *float64   `json:"load1" binding:"omitempty,min=0"`
+	CollectedAt *time.Time `json:"collected_at"`
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 校准记录来源
+const (
+	CalibrationSourceManual    = "manual"        // 手动设置校准参数
+	CalibrationSourceReference = "reference"     // 按参考温度计算偏移
+	CalibrationSourceSensor    = "sensor_update" // 随传感器配置更新
+)
+
+// CalibrationPoint 多点校准的校准点：探头读数与参考温度
+type CalibrationPoint struct {
+	Raw    float64 `json:"raw"`
+	Actual float64 `json:"actual"`
+}
+
+// TemperatureCalibration 通道校准参数。配置两个及以上校准点时按校准点分段线性插值
+// （两端按首尾线段外推），否则按增益换算；最后叠加偏移。
+type TemperatureCalibration struct {
+	Offset float64            `json:"offset"`
+	Gain   float64            `json:"gain"` // 0 视为1
+	Points []CalibrationPoint `json:"points,omitempty"`
+}
+
+// Apply 把探头读数换算为校准后的温度，未配置校准时原样返回
+func (c *TemperatureCalibration) Apply(raw float64) float64 {
+	if c == nil {
+		return raw
+	}
+	return math.Round((c.base(raw)+c.Offset)*100) / 100
+}
+
+// base 校准点插值或增益换算（不含偏移）
+func (c *TemperatureCalibration) base(raw float64) float64 {
+	if len(c.Points) >= 2 {
+		points := append([]CalibrationPoint(nil), c.Points...)
+		sort.Slice(points, func(i, j int) bool { return points[i].Raw < points[j].Raw })
+
+		i := sort.Search(len(points), func(i int) bool { return points[i].Raw >= raw })
+		switch {
+		case i == 0:
+			i = 1
+		case i == len(points):
+			i = len(points) - 1
+		}
+		p0, p1 := points[i-1], points[i]
+		return p0.Actual + (raw-p0.Raw)*(p1.Actual-p0.Actual)/(p1.Raw-p0.Raw)
+	}
+	if c.Gain == 0 {
+		return raw
+	}
+	return raw * c.Gain
+}
+
+// OffsetFor 保持增益和校准点不变，计算使读数 raw 校准后等于参考温度 reference 的偏移
+func (c *TemperatureCalibration) OffsetFor(raw, reference float64) float64 {
+	base := raw
+	if c != nil {
+		base = c.base(raw)
+	}
+	return math.Round((reference-base)*100) / 100
+}
+
+// IsZero 是否等同于未校准
+func (c *TemperatureCalibration) IsZero() bool {
+	return c == nil || (c.Offset == 0 && (c.Gain == 0 || c.Gain == 1) && len(c.Points) == 0)
+}
+
+// Validate 校验校准参数
+func (c *TemperatureCalibration) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if math.Abs(c.Offset) > 20 {
+		return fmt.Errorf("校准偏移超出范围 [-20, 20]: %.2f", c.Offset)
+	}
+	if c.Gain != 0 && (c.Gain < 0.5 || c.Gain > 1.5) {
+		return fmt.Errorf("校准增益超出范围 [0.5, 1.5]: %.3f", c.Gain)
+	}
+	if len(c.Points) == 1 {
+		return fmt.Errorf("多点校准至少需要两个校准点")
+	}
+	seen := make(map[float64]bool, len(c.Points))
+	for _, p := range c.Points {
+		if seen[p.Raw] {
+			return fmt.Errorf("校准点读数重复: %.2f", p.Raw)
+		}
+		seen[p.Raw] = true
+	}
+	return nil
+}
+
+// ChannelCalibration 通道的校准参数，通道未配置或未校准时返回 nil
+func (s *TemperatureSensor) ChannelCalibration(channel int) *TemperatureCalibration {
+	for i := range s.Channels {
+		if s.Channels[i].Channel == channel {
+			return s.Channels[i].Calibration
+		}
+	}
+	return nil
+}
+
+// CalibrateTemperature 按通道校准参数换算探头读数
+func (s *TemperatureSensor) CalibrateTemperature(channel int, raw float64) float64 {
+	return s.ChannelCalibration(channel).Apply(raw)
+}
+
+// TemperatureCalibrationRecord 通道校准变更记录
+type TemperatureCalibrationRecord struct {
+	ID            uint                    `json:"id" gorm:"primaryKey"`
+	SensorID      uint                    `json:"sensor_id" gorm:"not null;index:idx_temperature_calibration_channel,priority:1"`
+	Channel       int                     `json:"channel" gorm:"not null;index:idx_temperature_calibration_channel,priority:2"`
+	Previous      *TemperatureCalibration `json:"previous" gorm:"serializer:json"`
+	Calibration   *TemperatureCalibration `json:"calibration" gorm:"serializer:json"`
+	Source        string                  `json:"source" gorm:"size:20;not null"`
+	ReferenceTemp *float64                `json:"reference_temp,omitempty"` // 参考温度计读数
+	RawTemp       *float64                `json:"raw_temp,omitempty"`       // 计算偏移时使用的探头读数
+	Note          string                  `json:"note" gorm:"size:255"`
+	Operator      string                  `json:"operator" gorm:"size:50"`
+	CreatedAt     time.Time               `json:"created_at"`
+}
+
+// TableName 指定表名
+func (TemperatureCalibrationRecord) TableName() string {
+	return "temperature_calibration_records"
+}
+
+// UpdateTemperatureCalibrationRequest 设置通道校准参数请求，全部为零值时清除校准
+type UpdateTemperatureCalibrationRequest struct {
+	Offset float64            `json:"offset"`
+	Gain   float64            `json:"gain"`
+	Points []CalibrationPoint `json:"points"`
+	Note   string             `json:"note" binding:"max=255"`
+}
+
+// ReferenceCalibrationRequest 按参考温度计算偏移请求
+type ReferenceCalibrationRequest struct {
+	Reference *float64 `json:"reference" binding:"required,min=-50,max=150"` // 参考温度计读数
+	// RawTemperature 与参考温度同时刻的探头读数（未校准），为空时使用最近采集的读数
+	RawTemperature *float64 `json:"raw_temperature"`
+	Note           string   `json:"note" binding:"max=255"`
+}
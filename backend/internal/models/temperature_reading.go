@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// TemperatureReading 温度记录，由温度采集服务写入。Temperature 为按通道校准后的温度，
+// Status 为健康检测结果：normal 或 stuck/out_of_range/spike/disconnected
+type TemperatureReading struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SensorID       uint      `json:"sensor_id" gorm:"not null"`
+	Channel        int       `json:"channel" gorm:"not null"`
+	Temperature    float64   `json:"temperature" gorm:"type:decimal(5,2);not null"`
+	RawTemperature *float64  `json:"raw_temperature,omitempty" gorm:"type:decimal(5,2)"` // 探头原始读数（未校准）
+	Status         string    `json:"status" gorm:"size:20;default:'normal'"`
+	RecordedAt     time.Time `json:"recorded_at" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (TemperatureReading) TableName() string {
+	return "temperature_readings"
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// 温度数据分辨率
+const (
+	ResolutionRaw    = "raw" // 原始读数（temperature_readings）
+	ResolutionMinute = "1m"
+	ResolutionHour   = "1h"
+	ResolutionDay    = "1d"
+)
+
+// TemperatureRollup 温度聚合数据：1分钟由原始读数聚合，1小时由1分钟聚合，1天由1小时聚合
+type TemperatureRollup struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	Resolution  string    `json:"resolution" gorm:"size:4;not null;uniqueIndex:idx_temperature_rollup_bucket,priority:1"`
+	SensorID    uint      `json:"sensor_id" gorm:"not null;uniqueIndex:idx_temperature_rollup_bucket,priority:2"`
+	Channel     int       `json:"channel" gorm:"not null;uniqueIndex:idx_temperature_rollup_bucket,priority:3"`
+	BucketStart time.Time `json:"bucket_start" gorm:"not null;uniqueIndex:idx_temperature_rollup_bucket,priority:4"`
+	MinTemp     float64   `json:"min"`
+	MaxTemp     float64   `json:"max"`
+	AvgTemp     float64   `json:"avg"`
+	Count       int       `json:"count"` // 参与聚合的原始读数条数
+}
+
+// TableName 指定表名
+func (TemperatureRollup) TableName() string {
+	return "temperature_rollups"
+}
+
+// TemperatureRollupState 各分辨率的聚合进度和保留范围
+type TemperatureRollupState struct {
+	Resolution     string    `json:"resolution" gorm:"primaryKey;size:4"`
+	ProcessedUntil time.Time `json:"processed_until"` // 该时间之前的数据已聚合到本分辨率
+	RetainedFrom   time.Time `json:"retained_from"`   // 该时间之前的数据已按保留期清理
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (TemperatureRollupState) TableName() string {
+	return "temperature_rollup_states"
+}
+
+// TemperatureHistoryQuery 温度历史查询条件
+type TemperatureHistoryQuery struct {
+	SensorID   uint      // 可选，只查询指定传感器
+	Channel    int       // 可选，只查询指定通道
+	Start      time.Time // 含
+	End        time.Time // 不含
+	Resolution string    // 为空时按时间范围自动选择
+}
+
+// TemperatureHistoryPoint 温度历史数据点，原始读数的 min/max/avg 均为读数本身、count 为1
+type TemperatureHistoryPoint struct {
+	Timestamp   time.Time `json:"timestamp"`
+	SensorID    uint      `json:"sensor_id"`
+	Channel     int       `json:"channel"`
+	Temperature float64   `json:"temperature"` // 原始读数或区间平均值
+	Min         float64   `json:"min"`
+	Max         float64   `json:"max"`
+	Avg         float64   `json:"avg"`
+	Count       int       `json:"count"`
+	Status      string    `json:"status,omitempty"`
+}
@@ -14,6 +14,9 @@ type TemperatureChannel struct {
 	MinTemp  float64 `json:"min_temp" gorm:"default:-35"`
 	MaxTemp  float64 `json:"max_temp" gorm:"default:125"`
 	Interval int     `json:"interval" gorm:"default:30"`
+
+	// Calibration 校准参数，采集入库、实时读数和策略评估使用校准后的温度
+	Calibration *TemperatureCalibration `json:"calibration,omitempty"`
 }
 
 // TemperatureSensor 温度传感器配置
@@ -24,6 +27,7 @@ type TemperatureSensor struct {
 	IPAddress  string               `json:"ip_address" gorm:"size:45;not null"`
 	Port       int                  `json:"port" gorm:"not null"`
 	SlaveID    int                  `json:"slave_id" gorm:"default:1"`
+	Framing    string               `json:"framing" gorm:"size:20;default:'mbap'"` // 报文格式：mbap/rtu_over_tcp/rtu
 	Location   string               `json:"location" gorm:"size:200"`
 	MinTemp    float64              `json:"min_temp" gorm:"default:-35"`
 	MaxTemp    float64              `json:"max_temp" gorm:"default:125"`
@@ -34,6 +38,12 @@ type TemperatureSensor struct {
 	CreatedAt  time.Time            `json:"created_at"`
 	UpdatedAt  time.Time            `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt       `json:"-" gorm:"index"`
+
+	// 串口参数（报文格式为 rtu 时使用）
+	SerialSettings
+
+	// Virtual 虚拟传感器的聚合定义，设备类型为 VIRTUAL 时使用
+	Virtual *VirtualSensorDefinition `json:"virtual,omitempty" gorm:"serializer:json"`
 }
 
 // TableName 指定表名
@@ -45,9 +55,10 @@ func (TemperatureSensor) TableName() string {
 type CreateTemperatureSensorRequest struct {
 	Name       string               `json:"name" binding:"required,min=1,max=100"`
 	DeviceType string               `json:"device_type" binding:"required"`
-	IPAddress  string               `json:"ip_address" binding:"required,ip"`
-	Port       int                  `json:"port" binding:"required,min=1,max=65535"`
+	IPAddress  string               `json:"ip_address" binding:"omitempty,ip"` // TCP类报文格式必填
+	Port       int                  `json:"port" binding:"omitempty,min=1,max=65535"`
 	SlaveID    int                  `json:"slave_id" binding:"omitempty,min=1,max=255"`
+	Framing    string               `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
 	Location   string               `json:"location" binding:"omitempty,max=200"`
 	MinTemp    float64              `json:"min_temp" binding:"omitempty,min=-100,max=200"`
 	MaxTemp    float64              `json:"max_temp" binding:"omitempty,min=-100,max=200"`
@@ -55,15 +66,22 @@ type CreateTemperatureSensorRequest struct {
 	Interval   int                  `json:"interval" binding:"omitempty,min=1,max=3600"`
 	Enabled    bool                 `json:"enabled"`
 	Channels   []TemperatureChannel `json:"channels"`
+
+	// 虚拟传感器聚合定义（设备类型为 VIRTUAL 时必填）
+	Virtual *VirtualSensorDefinition `json:"virtual"`
+
+	// 串口参数
+	SerialSettings
 }
 
 // TemperatureSensorRequest 温度传感器请求（通用）
 type TemperatureSensorRequest struct {
 	Name       string               `json:"name" binding:"required,min=1,max=100"`
 	DeviceType string               `json:"device_type" binding:"required"`
-	IPAddress  string               `json:"ip_address" binding:"required,ip"`
-	Port       int                  `json:"port" binding:"required,min=1,max=65535"`
+	IPAddress  string               `json:"ip_address" binding:"omitempty,ip"` // TCP类报文格式必填
+	Port       int                  `json:"port" binding:"omitempty,min=1,max=65535"`
 	SlaveID    int                  `json:"slave_id" binding:"omitempty,min=1,max=255"`
+	Framing    string               `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
 	Location   string               `json:"location" binding:"omitempty,max=200"`
 	MinTemp    float64              `json:"min_temp" binding:"omitempty,min=-100,max=200"`
 	MaxTemp    float64              `json:"max_temp" binding:"omitempty,min=-100,max=200"`
@@ -71,6 +89,12 @@ type TemperatureSensorRequest struct {
 	Interval   int                  `json:"interval" binding:"omitempty,min=1,max=3600"`
 	Enabled    bool                 `json:"enabled"`
 	Channels   []TemperatureChannel `json:"channels"`
+
+	// 虚拟传感器聚合定义（设备类型为 VIRTUAL 时必填）
+	Virtual *VirtualSensorDefinition `json:"virtual"`
+
+	// 串口参数
+	SerialSettings
 }
 
 // UpdateTemperatureSensorRequest 更新温度传感器请求
@@ -80,6 +104,7 @@ type UpdateTemperatureSensorRequest struct {
 	IPAddress  string               `json:"ip_address" binding:"omitempty,ip"`
 	Port       int                  `json:"port" binding:"omitempty,min=1,max=65535"`
 	SlaveID    int                  `json:"slave_id" binding:"omitempty,min=1,max=255"`
+	Framing    string               `json:"framing" binding:"omitempty,oneof=mbap rtu_over_tcp rtu"`
 	Location   string               `json:"location" binding:"omitempty,max=200"`
 	MinTemp    float64              `json:"min_temp" binding:"omitempty,min=-100,max=200"`
 	MaxTemp    float64              `json:"max_temp" binding:"omitempty,min=-100,max=200"`
@@ -87,6 +112,12 @@ type UpdateTemperatureSensorRequest struct {
 	Interval   int                  `json:"interval" binding:"omitempty,min=1,max=3600"`
 	Enabled    *bool                `json:"enabled"`
 	Channels   []TemperatureChannel `json:"channels"`
+
+	// 虚拟传感器聚合定义（设备类型为 VIRTUAL 时必填）
+	Virtual *VirtualSensorDefinition `json:"virtual"`
+
+	// 串口参数
+	SerialSettings
 }
 
 // TemperatureSensorListResponse 温度传感器列表响应
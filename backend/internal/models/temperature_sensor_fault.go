@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// 传感器故障状态
+const (
+	SensorFaultActive  = "active"
+	SensorFaultCleared = "cleared"
+)
+
+// TemperatureSensorFault 温度传感器故障告警：通道连续多个读数被判定为断开、卡死、超量程或跳变时
+// 由温度采集服务产生，收到有效读数后自动恢复。异常读数不参与策略评估。
+type TemperatureSensorFault struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	SensorID  uint       `json:"sensor_id" gorm:"not null;index:idx_temperature_sensor_fault_channel,priority:1"`
+	Channel   int        `json:"channel" gorm:"not null;index:idx_temperature_sensor_fault_channel,priority:2"`
+	State     string     `json:"state" gorm:"size:20;not null"` // 健康状态：disconnected/stuck/out_of_range/spike
+	Reason    string     `json:"reason" gorm:"size:255"`
+	Value     *float64   `json:"value,omitempty"` // 触发告警的读数，开路时为空
+	Status    string     `json:"status" gorm:"size:20;not null;index"`
+	RaisedAt  time.Time  `json:"raised_at"`
+	ClearedAt *time.Time `json:"cleared_at,omitempty"`
+	Notified  bool       `json:"-"` // 已推送告警通知
+}
+
+// TableName 指定表名
+func (TemperatureSensorFault) TableName() string {
+	return "temperature_sensor_faults"
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 虚拟传感器聚合方式
+const (
+	VirtualAggregationAvg        = "avg"
+	VirtualAggregationMin        = "min"
+	VirtualAggregationMax        = "max"
+	VirtualAggregationMedian     = "median"
+	VirtualAggregationDifference = "difference" // 源通道平均值减去减数通道平均值，如热通道-冷通道温差
+)
+
+// VirtualSensorChannel 参与聚合的物理通道
+type VirtualSensorChannel struct {
+	SensorID uint `json:"sensor_id"`
+	Channel  int  `json:"channel"`
+}
+
+// String 通道标识，与策略条件的传感器ID格式一致（"传感器ID-通道号"）
+func (c VirtualSensorChannel) String() string {
+	return fmt.Sprintf("%d-%d", c.SensorID, c.Channel)
+}
+
+// VirtualSensorDefinition 虚拟传感器定义：按聚合方式对一组物理通道的最新有效读数计算出一个温度，
+// 由温度采集服务按传感器间隔计算并作为通道1的读数入库，历史、图表和策略条件与物理传感器一致。
+type VirtualSensorDefinition struct {
+	Aggregation string                 `json:"aggregation"`
+	Sources     []VirtualSensorChannel `json:"sources"`
+	// Subtrahend 差值的减数通道（如冷通道），仅 difference 使用
+	Subtrahend []VirtualSensorChannel `json:"subtrahend,omitempty"`
+	// MinSources 每组至少需要的有效读数个数，不足时本次不产生读数；0 表示1
+	MinSources int `json:"min_sources"`
+	// MaxAge 源读数的最长有效时间（秒），超过视为无读数；0 表示120秒
+	MaxAge int `json:"max_age"`
+}
+
+// Validate 校验虚拟传感器定义
+func (d *VirtualSensorDefinition) Validate() error {
+	if d == nil {
+		return fmt.Errorf("虚拟传感器必须配置聚合定义")
+	}
+	switch d.Aggregation {
+	case VirtualAggregationAvg, VirtualAggregationMin, VirtualAggregationMax, VirtualAggregationMedian:
+		if len(d.Subtrahend) > 0 {
+			return fmt.Errorf("只有 difference 聚合可以配置减数通道")
+		}
+	case VirtualAggregationDifference:
+		if len(d.Subtrahend) == 0 {
+			return fmt.Errorf("difference 聚合必须配置减数通道")
+		}
+	default:
+		return fmt.Errorf("不支持的聚合方式: %s", d.Aggregation)
+	}
+	if len(d.Sources) == 0 {
+		return fmt.Errorf("虚拟传感器至少需要一个源通道")
+	}
+	if d.MinSources < 0 || d.MaxAge < 0 {
+		return fmt.Errorf("最少有效通道数和读数有效时间不能为负数")
+	}
+
+	seen := make(map[VirtualSensorChannel]bool)
+	for _, ch := range d.Channels() {
+		if ch.SensorID == 0 || ch.Channel < 1 {
+			return fmt.Errorf("源通道 %s 无效", ch)
+		}
+		if seen[ch] {
+			return fmt.Errorf("源通道 %s 重复", ch)
+		}
+		seen[ch] = true
+	}
+	if d.MinSources > len(d.Sources) || (len(d.Subtrahend) > 0 && d.MinSources > len(d.Subtrahend)) {
+		return fmt.Errorf("最少有效通道数 %d 超过源通道数", d.MinSources)
+	}
+	return nil
+}
+
+// Channels 全部源通道（含减数通道）
+func (d *VirtualSensorDefinition) Channels() []VirtualSensorChannel {
+	return append(append([]VirtualSensorChannel(nil), d.Sources...), d.Subtrahend...)
+}
+
+// Compute 按聚合方式计算温度，readings 为各源通道的有效读数（键为通道标识），缺少的通道不参与计算
+func (d *VirtualSensorDefinition) Compute(readings map[VirtualSensorChannel]float64) (float64, error) {
+	minSources := d.MinSources
+	if minSources <= 0 {
+		minSources = 1
+	}
+
+	values, err := collectValues(d.Sources, readings, minSources)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	switch d.Aggregation {
+	case VirtualAggregationAvg:
+		result = average(values)
+	case VirtualAggregationMin:
+		sort.Float64s(values)
+		result = values[0]
+	case VirtualAggregationMax:
+		sort.Float64s(values)
+		result = values[len(values)-1]
+	case VirtualAggregationMedian:
+		sort.Float64s(values)
+		n := len(values)
+		result = values[n/2]
+		if n%2 == 0 {
+			result = (values[n/2-1] + values[n/2]) / 2
+		}
+	case VirtualAggregationDifference:
+		subtrahend, err := collectValues(d.Subtrahend, readings, minSources)
+		if err != nil {
+			return 0, fmt.Errorf("减数%w", err)
+		}
+		result = average(values) - average(subtrahend)
+	default:
+		return 0, fmt.Errorf("不支持的聚合方式: %s", d.Aggregation)
+	}
+	return math.Round(result*100) / 100, nil
+}
+
+func collectValues(channels []VirtualSensorChannel, readings map[VirtualSensorChannel]float64, minSources int) ([]float64, error) {
+	values := make([]float64, 0, len(channels))
+	for _, ch := range channels {
+		if v, ok := readings[ch]; ok {
+			values = append(values, v)
+		}
+	}
+	if len(values) < minSources {
+		return nil, fmt.Errorf("通道有效读数 %d 个，少于 %d 个", len(values), minSources)
+	}
+	return values, nil
+}
+
+func average(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
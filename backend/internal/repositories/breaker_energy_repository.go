@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"time"
+
+	"smart-device-management/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BreakerEnergyRepository 断路器遥测采样与电量仓库接口
+type BreakerEnergyRepository interface {
+	GetCounter(breakerID uint) (*models.BreakerEnergyCounter, error)
+	SaveSample(sample *models.BreakerTelemetrySample, counter *models.BreakerEnergyCounter) error
+	LatestSample(breakerID uint) (*models.BreakerTelemetrySample, error)
+	ListSamples(breakerID uint, start, end time.Time, limit int) ([]models.BreakerTelemetrySample, error)
+	ListHourly(breakerIDs []uint, start, end time.Time) ([]models.BreakerEnergyHourly, error)
+	DeleteSamplesBefore(before time.Time) (int64, error)
+}
+
+// breakerEnergyRepository 断路器遥测采样与电量仓库实现
+type breakerEnergyRepository struct {
+	db *gorm.DB
+}
+
+// NewBreakerEnergyRepository 创建断路器遥测采样与电量仓库
+func NewBreakerEnergyRepository(db *gorm.DB) BreakerEnergyRepository {
+	return &breakerEnergyRepository{db: db}
+}
+
+// GetCounter 获取断路器电量计数器，不存在时返回 nil
+func (r *breakerEnergyRepository) GetCounter(breakerID uint) (*models.BreakerEnergyCounter, error) {
+	var counters []models.BreakerEnergyCounter
+	if err := r.db.Where("breaker_id = ?", breakerID).Limit(1).Find(&counters).Error; err != nil {
+		return nil, err
+	}
+	if len(counters) == 0 {
+		return nil, nil
+	}
+	return &counters[0], nil
+}
+
+// SaveSample 在一个事务中保存采样、更新计数器，并把本周期电量累加到采样时间所在小时（UTC整点）
+func (r *breakerEnergyRepository) SaveSample(sample *models.BreakerTelemetrySample, counter *models.BreakerEnergyCounter) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sample).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(counter).Error; err != nil {
+			return err
+		}
+
+		hourly := models.BreakerEnergyHourly{
+			BreakerID: sample.BreakerID,
+			Hour:      sample.SampledAt.UTC().Truncate(time.Hour),
+			KWh:       sample.EnergyDelta,
+			Samples:   1,
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "breaker_id"}, {Name: "hour"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"kwh":     gorm.Expr("breaker_energy_hourly.kwh + ?", sample.EnergyDelta),
+				"samples": gorm.Expr("breaker_energy_hourly.samples + 1"),
+			}),
+		}).Create(&hourly).Error
+	})
+}
+
+// LatestSample 获取断路器最近一次采样，不存在时返回 nil
+func (r *breakerEnergyRepository) LatestSample(breakerID uint) (*models.BreakerTelemetrySample, error) {
+	var samples []models.BreakerTelemetrySample
+	if err := r.db.Where("breaker_id = ?", breakerID).Order("sampled_at DESC").Limit(1).Find(&samples).Error; err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, nil
+	}
+	return &samples[0], nil
+}
+
+// ListSamples 获取时间范围内的采样，按时间正序
+func (r *breakerEnergyRepository) ListSamples(breakerID uint, start, end time.Time, limit int) ([]models.BreakerTelemetrySample, error) {
+	var samples []models.BreakerTelemetrySample
+	err := r.db.Where("breaker_id = ? AND sampled_at >= ? AND sampled_at < ?", breakerID, start, end).
+		Order("sampled_at ASC").
+		Limit(limit).
+		Find(&samples).Error
+	return samples, err
+}
+
+// ListHourly 获取时间范围内的小时电量，breakerIDs 为空时返回全部断路器
+func (r *breakerEnergyRepository) ListHourly(breakerIDs []uint, start, end time.Time) ([]models.BreakerEnergyHourly, error) {
+	var rows []models.BreakerEnergyHourly
+	query := r.db.Where("hour >= ? AND hour < ?", start, end)
+	if len(breakerIDs) > 0 {
+		query = query.Where("breaker_id IN ?", breakerIDs)
+	}
+	err := query.Order("hour ASC").Find(&rows).Error
+	return rows, err
+}
+
+// DeleteSamplesBefore 清理过期的原始采样（小时电量保留）
+func (r *breakerEnergyRepository) DeleteSamplesBefore(before time.Time) (int64, error) {
+	result := r.db.Where("sampled_at < ?", before).Delete(&models.BreakerTelemetrySample{})
+	return result.RowsAffected, result.Error
+}
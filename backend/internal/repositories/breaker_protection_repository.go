@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"smart-device-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BreakerProtectionRepository 断路器保护参数修改记录仓库接口
+type BreakerProtectionRepository interface {
+	CreateChanges(changes []models.BreakerProtectionChange) error
+	ListByBreaker(breakerID uint, page, pageSize int) ([]models.BreakerProtectionChange, int64, error)
+}
+
+// breakerProtectionRepository 断路器保护参数修改记录仓库实现
+type breakerProtectionRepository struct {
+	db *gorm.DB
+}
+
+// NewBreakerProtectionRepository 创建断路器保护参数修改记录仓库
+func NewBreakerProtectionRepository(db *gorm.DB) BreakerProtectionRepository {
+	return &breakerProtectionRepository{db: db}
+}
+
+// CreateChanges 批量保存一次提交的修改记录
+func (r *breakerProtectionRepository) CreateChanges(changes []models.BreakerProtectionChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return r.db.Create(&changes).Error
+}
+
+// ListByBreaker 分页获取断路器的保护参数修改记录，按时间倒序
+func (r *breakerProtectionRepository) ListByBreaker(breakerID uint, page, pageSize int) ([]models.BreakerProtectionChange, int64, error) {
+	var changes []models.BreakerProtectionChange
+	var total int64
+
+	query := r.db.Model(&models.BreakerProtectionChange{}).Where("breaker_id = ?", breakerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&changes).Error
+	return changes, total, err
+}
@@ -10,12 +10,14 @@ import (
 type BreakerRepository interface {
 	GetAll() ([]models.Breaker, error)
 	GetByID(id uint) (*models.Breaker, error)
-	GetByIPAddress(ipAddress string, port int) (*models.Breaker, error)
+	GetByIPAddress(ipAddress string, port int, stationID int) (*models.Breaker, error)
+	GetBySerialDevice(device string, stationID int) (*models.Breaker, error)
 	GetEnabledBreakers() ([]*models.Breaker, error)
 	Create(breaker *models.Breaker, device *models.Device) error
 	Update(breaker *models.Breaker) error
 	Delete(id uint) error
 	UpdateBreakerLockStatus(id uint, isLocked bool) error
+	UpdateDeviceModel(breaker *models.Breaker, model string) error
 
 	// 控制相关
 	CreateControl(control *models.BreakerControl) error
@@ -61,10 +63,20 @@ func (r *breakerRepository) GetByID(id uint) (*models.Breaker, error) {
 	return &breaker, err
 }
 
-// GetByIPAddress 根据IP地址获取断路器
-func (r *breakerRepository) GetByIPAddress(ipAddress string, port int) (*models.Breaker, error) {
+// GetByIPAddress 根据网关IP、端口和站号获取断路器，同一网关端口下可挂接多个站号
+func (r *breakerRepository) GetByIPAddress(ipAddress string, port int, stationID int) (*models.Breaker, error) {
 	var breaker models.Breaker
-	err := r.db.Where("ip_address = ? AND port = ?", ipAddress, port).First(&breaker).Error
+	err := r.db.Where("framing <> ? AND ip_address = ? AND port = ? AND station_id = ?", "rtu", ipAddress, port, stationID).First(&breaker).Error
+	if err != nil {
+		return nil, err
+	}
+	return &breaker, nil
+}
+
+// GetBySerialDevice 根据串口设备路径和站号获取串口RTU断路器
+func (r *breakerRepository) GetBySerialDevice(device string, stationID int) (*models.Breaker, error) {
+	var breaker models.Breaker
+	err := r.db.Where("framing = ? AND serial_device = ? AND station_id = ?", "rtu", device, stationID).First(&breaker).Error
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +113,17 @@ func (r *breakerRepository) Update(breaker *models.Breaker) error {
 	return r.db.Save(breaker).Error
 }
 
+// UpdateDeviceModel 更新断路器关联设备的型号
+func (r *breakerRepository) UpdateDeviceModel(breaker *models.Breaker, model string) error {
+	if err := r.db.Model(&models.Device{}).Where("id = ?", breaker.DeviceID).Update("device_model", model).Error; err != nil {
+		return err
+	}
+	if breaker.Device != nil {
+		breaker.Device.DeviceModel = model
+	}
+	return nil
+}
+
 // Delete 删除断路器
 func (r *breakerRepository) Delete(id uint) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"time"
+
+	"smart-device-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BreakerSelfTestFilter 漏电自检记录查询条件，零值表示不过滤
+type BreakerSelfTestFilter struct {
+	BreakerID     uint
+	Status        string
+	RecloseStatus string
+}
+
+// BreakerSelfTestRepository 断路器漏电自检计划及记录仓库接口
+type BreakerSelfTestRepository interface {
+	GetSchedule(breakerID uint) (*models.BreakerSelfTestSchedule, error)
+	SaveSchedule(schedule *models.BreakerSelfTestSchedule) error
+	ListSchedules() ([]models.BreakerSelfTestSchedule, error)
+	DueSchedules(now time.Time) ([]models.BreakerSelfTestSchedule, error)
+	Create(test *models.BreakerSelfTest) error
+	Update(test *models.BreakerSelfTest) error
+	ClaimReclose(id uint, status string) (bool, error)
+	GetByID(id uint) (*models.BreakerSelfTest, error)
+	List(filter BreakerSelfTestFilter, page, pageSize int) ([]models.BreakerSelfTest, int64, error)
+	LatestByBreaker() (map[uint]models.BreakerSelfTest, error)
+	LatestPassedByBreaker() (map[uint]models.BreakerSelfTest, error)
+}
+
+// breakerSelfTestRepository 断路器漏电自检计划及记录仓库实现
+type breakerSelfTestRepository struct {
+	db *gorm.DB
+}
+
+// NewBreakerSelfTestRepository 创建断路器漏电自检仓库
+func NewBreakerSelfTestRepository(db *gorm.DB) BreakerSelfTestRepository {
+	return &breakerSelfTestRepository{db: db}
+}
+
+// GetSchedule 获取断路器的自检计划，不存在时返回 nil
+func (r *breakerSelfTestRepository) GetSchedule(breakerID uint) (*models.BreakerSelfTestSchedule, error) {
+	var schedules []models.BreakerSelfTestSchedule
+	if err := r.db.Where("breaker_id = ?", breakerID).Limit(1).Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, nil
+	}
+	return &schedules[0], nil
+}
+
+// SaveSchedule 创建或更新自检计划
+func (r *breakerSelfTestRepository) SaveSchedule(schedule *models.BreakerSelfTestSchedule) error {
+	return r.db.Save(schedule).Error
+}
+
+// ListSchedules 获取全部自检计划
+func (r *breakerSelfTestRepository) ListSchedules() ([]models.BreakerSelfTestSchedule, error) {
+	var schedules []models.BreakerSelfTestSchedule
+	err := r.db.Order("breaker_id").Find(&schedules).Error
+	return schedules, err
+}
+
+// DueSchedules 获取已到执行时间的启用计划
+func (r *breakerSelfTestRepository) DueSchedules(now time.Time) ([]models.BreakerSelfTestSchedule, error) {
+	var schedules []models.BreakerSelfTestSchedule
+	err := r.db.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// Create 创建自检记录
+func (r *breakerSelfTestRepository) Create(test *models.BreakerSelfTest) error {
+	return r.db.Create(test).Error
+}
+
+// Update 更新自检记录
+func (r *breakerSelfTestRepository) Update(test *models.BreakerSelfTest) error {
+	return r.db.Omit("Breaker").Save(test).Error
+}
+
+// ClaimReclose 把待批准的重新合闸改为指定状态，记录已不是待批准状态时返回 false。
+// 以条件更新保证并发的批准/拒绝只有一个生效
+func (r *breakerSelfTestRepository) ClaimReclose(id uint, status string) (bool, error) {
+	result := r.db.Model(&models.BreakerSelfTest{}).
+		Where("id = ? AND reclose_status = ?", id, models.RecloseStatusPending).
+		Update("reclose_status", status)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetByID 根据ID获取自检记录
+func (r *breakerSelfTestRepository) GetByID(id uint) (*models.BreakerSelfTest, error) {
+	var test models.BreakerSelfTest
+	if err := r.db.First(&test, id).Error; err != nil {
+		return nil, err
+	}
+	return &test, nil
+}
+
+// List 分页获取自检记录，按时间倒序
+func (r *breakerSelfTestRepository) List(filter BreakerSelfTestFilter, page, pageSize int) ([]models.BreakerSelfTest, int64, error) {
+	var tests []models.BreakerSelfTest
+	var total int64
+
+	query := r.db.Model(&models.BreakerSelfTest{})
+	if filter.BreakerID != 0 {
+		query = query.Where("breaker_id = ?", filter.BreakerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.RecloseStatus != "" {
+		query = query.Where("reclose_status = ?", filter.RecloseStatus)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("started_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&tests).Error
+	return tests, total, err
+}
+
+// LatestByBreaker 每台断路器最近一次实际执行（不含跳过）的自检
+func (r *breakerSelfTestRepository) LatestByBreaker() (map[uint]models.BreakerSelfTest, error) {
+	return r.latest(r.db.Where("status <> ?", models.SelfTestStatusSkipped))
+}
+
+// LatestPassedByBreaker 每台断路器最近一次通过的自检
+func (r *breakerSelfTestRepository) LatestPassedByBreaker() (map[uint]models.BreakerSelfTest, error) {
+	return r.latest(r.db.Where("status = ?", models.SelfTestStatusPassed))
+}
+
+func (r *breakerSelfTestRepository) latest(scope *gorm.DB) (map[uint]models.BreakerSelfTest, error) {
+	latestIDs := scope.Model(&models.BreakerSelfTest{}).Select("MAX(id)").Group("breaker_id")
+
+	var tests []models.BreakerSelfTest
+	if err := r.db.Where("id IN (?)", latestIDs).Find(&tests).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]models.BreakerSelfTest, len(tests))
+	for _, test := range tests {
+		result[test.BreakerID] = test
+	}
+	return result, nil
+}
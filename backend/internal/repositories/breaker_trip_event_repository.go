@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"smart-device-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BreakerTripEventRepository 断路器跳闸事件仓库接口
+type BreakerTripEventRepository interface {
+	Create(event *models.BreakerTripEvent) error
+	ListByBreaker(breakerID uint, page, pageSize int) ([]models.BreakerTripEvent, int64, error)
+}
+
+// breakerTripEventRepository 断路器跳闸事件仓库实现
+type breakerTripEventRepository struct {
+	db *gorm.DB
+}
+
+// NewBreakerTripEventRepository 创建断路器跳闸事件仓库
+func NewBreakerTripEventRepository(db *gorm.DB) BreakerTripEventRepository {
+	return &breakerTripEventRepository{db: db}
+}
+
+// Create 创建跳闸事件
+func (r *breakerTripEventRepository) Create(event *models.BreakerTripEvent) error {
+	return r.db.Create(event).Error
+}
+
+// ListByBreaker 分页获取断路器的跳闸事件，按时间倒序
+func (r *breakerTripEventRepository) ListByBreaker(breakerID uint, page, pageSize int) ([]models.BreakerTripEvent, int64, error) {
+	var events []models.BreakerTripEvent
+	var total int64
+
+	query := r.db.Model(&models.BreakerTripEvent{}).Where("breaker_id = ?", breakerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("tripped_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&events).Error
+	return events, total, err
+}
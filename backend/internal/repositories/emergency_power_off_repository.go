@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"smart-device-management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EmergencyPowerOffRepository 紧急断电审计记录仓库接口
+type EmergencyPowerOffRepository interface {
+	Create(record *models.EmergencyPowerOff) error
+	Update(record *models.EmergencyPowerOff) error
+	GetByUUID(uuid string) (*models.EmergencyPowerOff, error)
+	List(page, pageSize int) ([]models.EmergencyPowerOff, int64, error)
+}
+
+// emergencyPowerOffRepository 紧急断电审计记录仓库实现
+type emergencyPowerOffRepository struct {
+	db *gorm.DB
+}
+
+// NewEmergencyPowerOffRepository 创建紧急断电审计记录仓库
+func NewEmergencyPowerOffRepository(db *gorm.DB) EmergencyPowerOffRepository {
+	return &emergencyPowerOffRepository{db: db}
+}
+
+// Create 保存紧急断电记录
+func (r *emergencyPowerOffRepository) Create(record *models.EmergencyPowerOff) error {
+	return r.db.Create(record).Error
+}
+
+// Update 更新紧急断电记录
+func (r *emergencyPowerOffRepository) Update(record *models.EmergencyPowerOff) error {
+	return r.db.Save(record).Error
+}
+
+// GetByUUID 根据UUID获取紧急断电记录
+func (r *emergencyPowerOffRepository) GetByUUID(uuid string) (*models.EmergencyPowerOff, error) {
+	var record models.EmergencyPowerOff
+	err := r.db.Where("uuid = ?", uuid).First(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// List 分页获取紧急断电记录，按时间倒序
+func (r *emergencyPowerOffRepository) List(page, pageSize int) ([]models.EmergencyPowerOff, int64, error) {
+	var records []models.EmergencyPowerOff
+	var total int64
+
+	query := r.db.Model(&models.EmergencyPowerOff{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&records).Error
+	return records, total, err
+}
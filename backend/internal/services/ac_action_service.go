@@ -0,0 +1,300 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/infrared"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/websocket"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultACVerifyWindow 未指定观察时间时，下发后观察室温的时长
+	defaultACVerifyWindow = 10 * time.Minute
+	// acBaselineWindow 下发前取室温基线的时间范围
+	acBaselineWindow = 5 * time.Minute
+	// acSampleWindow 观察期结束时取室温的时间范围
+	acSampleWindow = 2 * time.Minute
+	// acTrendThreshold 室温变化超过该值（℃）才认为在上升或下降
+	acTrendThreshold = 0.3
+	// acSetPointMargin 室温已达到设定温度±该值（℃）时视为空调已生效
+	acSetPointMargin = 0.5
+)
+
+// ACActionService AI策略的空调控制动作：通过红外控制器下发空调状态并记录，
+// 指定了验证传感器时在观察期结束后根据室温趋势判断动作是否生效，未生效时推送告警。
+type ACActionService struct {
+	db                  *gorm.DB
+	logger              *logger.Logger
+	irControllerService *IRControllerService
+}
+
+// NewACActionService 创建空调控制动作服务
+func NewACActionService(db *gorm.DB, logger *logger.Logger, irControllerService *IRControllerService) *ACActionService {
+	return &ACActionService{
+		db:                  db,
+		logger:              logger,
+		irControllerService: irControllerService,
+	}
+}
+
+// Execute 执行 ac_control 动作。下发失败时同样保存记录并返回错误；
+// 室温验证在观察期结束后异步完成，结果更新到返回的记录。验证只是附加检查，
+// 室温基线读取失败时照常下发，验证结果记为无数据
+func (s *ACActionService) Execute(action models.AIStrategyAction, strategyID, executionID uint, triggerBy string) (*models.ACActionRecord, error) {
+	id, err := strconv.ParseUint(action.DeviceID, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的红外控制器ID: %s", action.DeviceID)
+	}
+	controller, err := s.irControllerService.GetController(uint(id))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	record := &models.ACActionRecord{
+		IRControllerID: controller.ID,
+		StrategyID:     strategyID,
+		ExecutionID:    executionID,
+		TriggerBy:      triggerBy,
+		SensorID:       action.VerifySensorID,
+		VerifyStatus:   models.ACVerifySkipped,
+		CreatedAt:      now,
+	}
+	record.State = MergeACState(controller, models.ACControlRequest{
+		Power:    action.Power,
+		Mode:     action.Mode,
+		SetPoint: action.TargetTemp,
+		FanSpeed: action.FanSpeed,
+	})
+
+	applyErr := s.irControllerService.ApplyACState(controller, record.State)
+	if applyErr != nil {
+		record.Error = applyErr.Error()
+	} else if record.SensorID != "" {
+		s.prepareVerify(record, action.VerifyMinutes, now)
+	}
+
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("保存空调动作记录失败: %w", err)
+	}
+	if applyErr != nil {
+		return record, applyErr
+	}
+
+	if record.VerifyStatus == models.ACVerifyPending {
+		s.scheduleVerify(record.ID, *record.VerifyDeadline)
+	}
+	return record, nil
+}
+
+// prepareVerify 取下发前的室温基线并设定观察期。传感器通道无效或读取失败时不再验证
+func (s *ACActionService) prepareVerify(record *models.ACActionRecord, verifyMinutes int, now time.Time) {
+	baseline, err := s.averageTemperature(record.SensorID, now.Add(-acBaselineWindow), now)
+	if err != nil {
+		s.logger.Warn("读取室温基线失败，不验证空调动作", "ir_controller_id", record.IRControllerID,
+			"sensor_id", record.SensorID, "error", err)
+		record.VerifyStatus = models.ACVerifyNoData
+		record.VerifyMessage = fmt.Sprintf("读取室温基线失败: %v", err)
+		return
+	}
+	record.BaselineTemp = baseline
+	record.ExpectedTrend = expectedACTrend(record.State)
+
+	window := defaultACVerifyWindow
+	if verifyMinutes > 0 {
+		window = time.Duration(verifyMinutes) * time.Minute
+	}
+	deadline := now.Add(window)
+	record.VerifyDeadline = &deadline
+	record.VerifyStatus = models.ACVerifyPending
+}
+
+// ResumePending 恢复服务重启前尚未完成的室温验证，已过观察期的立即验证
+func (s *ACActionService) ResumePending() error {
+	var records []models.ACActionRecord
+	if err := s.db.Select("id", "verify_deadline").Where("verify_status = ?", models.ACVerifyPending).Find(&records).Error; err != nil {
+		return fmt.Errorf("获取待验证的空调动作失败: %w", err)
+	}
+	for _, record := range records {
+		if record.VerifyDeadline != nil {
+			s.scheduleVerify(record.ID, *record.VerifyDeadline)
+		}
+	}
+	return nil
+}
+
+// ListRecords 获取空调动作记录，controllerID 为0时不按控制器筛选
+func (s *ACActionService) ListRecords(controllerID uint, limit int) ([]models.ACActionRecord, error) {
+	query := s.db.Order("created_at DESC")
+	if controllerID > 0 {
+		query = query.Where("ir_controller_id = ?", controllerID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var records []models.ACActionRecord
+	if err := query.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("获取空调动作记录失败: %w", err)
+	}
+	return records, nil
+}
+
+// scheduleVerify 在观察期结束时验证室温趋势
+func (s *ACActionService) scheduleVerify(id uint, deadline time.Time) {
+	time.AfterFunc(time.Until(deadline), func() {
+		if err := s.Verify(id); err != nil {
+			s.logger.Error("验证空调动作失败", "record_id", id, "error", err)
+		}
+	})
+}
+
+// Verify 比较观察期结束时与下发前的室温，得出趋势并判断动作是否生效
+func (s *ACActionService) Verify(id uint) error {
+	var record models.ACActionRecord
+	if err := s.db.First(&record, id).Error; err != nil {
+		return fmt.Errorf("获取空调动作记录失败: %w", err)
+	}
+	if record.VerifyStatus != models.ACVerifyPending || record.VerifyDeadline == nil {
+		return nil
+	}
+
+	deadline := *record.VerifyDeadline
+	final, err := s.averageTemperature(record.SensorID, deadline.Add(-acSampleWindow), deadline)
+	if err != nil {
+		return err
+	}
+	record.FinalTemp = final
+	record.Trend, record.VerifyStatus, record.VerifyMessage = evaluateACTrend(record)
+
+	now := time.Now().UTC()
+	record.VerifiedAt = &now
+	err = s.db.Model(&record).Select("FinalTemp", "Trend", "VerifyStatus", "VerifyMessage", "VerifiedAt").Updates(&record).Error
+	if err != nil {
+		return fmt.Errorf("保存空调动作验证结果失败: %w", err)
+	}
+
+	s.logger.Info("空调动作验证完成", "record_id", record.ID, "ir_controller_id", record.IRControllerID,
+		"trend", record.Trend, "verify_status", record.VerifyStatus)
+
+	if record.VerifyStatus == models.ACVerifyIneffective {
+		s.notifyIneffective(&record)
+	}
+	return nil
+}
+
+// averageTemperature 传感器通道在时间范围内有效读数的平均值，没有读数时返回 nil
+func (s *ACActionService) averageTemperature(sensorKey string, from, to time.Time) (*float64, error) {
+	sensorID, channel, err := models.ParseSensorChannel(sensorKey)
+	if err != nil {
+		return nil, err
+	}
+	// temperature_readings 由服务启动时迁移创建，不存在时按无数据处理
+	if !s.db.Migrator().HasTable("temperature_readings") {
+		return nil, nil
+	}
+
+	var result struct {
+		Count   int64
+		Average float64
+	}
+	err = s.db.Table("temperature_readings").
+		Select("COUNT(*) AS count, COALESCE(AVG(temperature), 0) AS average").
+		Where("sensor_id = ? AND channel = ? AND status = ? AND recorded_at BETWEEN ? AND ?", sensorID, channel, "normal", from, to).
+		Scan(&result).Error
+	if err != nil {
+		return nil, fmt.Errorf("读取温度失败: %w", err)
+	}
+	if result.Count == 0 {
+		return nil, nil
+	}
+	return &result.Average, nil
+}
+
+// notifyIneffective 推送空调动作未生效告警
+func (s *ACActionService) notifyIneffective(record *models.ACActionRecord) {
+	name := fmt.Sprintf("红外控制器%d", record.IRControllerID)
+	if controller, err := s.irControllerService.GetController(record.IRControllerID); err == nil {
+		name = controller.Name
+	}
+
+	websocket.BroadcastAlarmTriggered(map[string]interface{}{
+		"id":        fmt.Sprintf("ac-action-%d", record.ID),
+		"type":      "ac_ineffective",
+		"level":     "warning",
+		"status":    "active",
+		"title":     "空调控制未生效",
+		"message":   fmt.Sprintf("%s %s", name, record.VerifyMessage),
+		"deviceId":  record.SensorID,
+		"timestamp": record.VerifiedAt.Format(time.RFC3339),
+	})
+}
+
+// DescribeACAction 空调动作的执行结果描述，用于策略执行记录
+func DescribeACAction(deviceName string, record *models.ACActionRecord) string {
+	state := record.State
+	power := "关机"
+	if state.Power {
+		power = "开机"
+	}
+	result := fmt.Sprintf("空调 %s %s指令已发送（模式 %s，设定 %d℃，风速 %s）", deviceName, power, state.Mode, state.SetPoint, state.FanSpeed)
+	if record.VerifyStatus == models.ACVerifyPending {
+		result += fmt.Sprintf("，将于 %s 验证传感器 %s 的室温趋势", record.VerifyDeadline.Local().Format("15:04:05"), record.SensorID)
+	}
+	return result
+}
+
+// expectedACTrend 空调状态对应的预期室温趋势：开机制冷/除湿应降温，制热应升温，其他状态不验证
+func expectedACTrend(state models.ACState) string {
+	if !state.Power {
+		return ""
+	}
+	switch state.Mode {
+	case infrared.ModeCool, infrared.ModeDry:
+		return models.ACTrendFalling
+	case infrared.ModeHeat:
+		return models.ACTrendRising
+	}
+	return ""
+}
+
+// evaluateACTrend 根据下发前后的室温得出趋势、验证结果和说明。
+// 室温已达到设定温度时空调不再明显降温或升温，同样视为生效
+func evaluateACTrend(record models.ACActionRecord) (trend, status, message string) {
+	if record.BaselineTemp == nil || record.FinalTemp == nil {
+		return "", models.ACVerifyNoData, "观察期内没有有效温度读数"
+	}
+
+	baseline, final := *record.BaselineTemp, *record.FinalTemp
+	delta := final - baseline
+	switch {
+	case delta <= -acTrendThreshold:
+		trend = models.ACTrendFalling
+	case delta >= acTrendThreshold:
+		trend = models.ACTrendRising
+	default:
+		trend = models.ACTrendStable
+	}
+	message = fmt.Sprintf("室温 %.1f℃ → %.1f℃（%+.1f℃）", baseline, final, delta)
+
+	setPoint := float64(record.State.SetPoint)
+	switch record.ExpectedTrend {
+	case models.ACTrendFalling:
+		if trend == models.ACTrendFalling || final <= setPoint+acSetPointMargin {
+			return trend, models.ACVerifyEffective, message
+		}
+		return trend, models.ACVerifyIneffective, message + fmt.Sprintf("，制冷后室温未下降，设定温度 %d℃", record.State.SetPoint)
+	case models.ACTrendRising:
+		if trend == models.ACTrendRising || final >= setPoint-acSetPointMargin {
+			return trend, models.ACVerifyEffective, message
+		}
+		return trend, models.ACVerifyIneffective, message + fmt.Sprintf("，制热后室温未上升，设定温度 %d℃", record.State.SetPoint)
+	}
+	return trend, models.ACVerifySkipped, message + "，当前空调状态无预期趋势，仅记录室温变化"
+}
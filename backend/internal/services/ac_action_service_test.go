@@ -0,0 +1,114 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestACActionExecuteAndVerify(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.IRController{}, &models.ACActionRecord{}, &testTemperatureReading{}))
+
+	port, writes := startFakeIRController(t)
+	log := logger.NewLogger()
+	irService := NewIRControllerService(db, log, nil, time.Second)
+	controller, err := irService.CreateController(models.CreateIRControllerRequest{
+		Name: "机房1号空调", Mode: models.IRModeUDP, IPAddress: "127.0.0.1", Port: port, ACBrand: "美的",
+	})
+	require.NoError(t, err)
+
+	// 下发前室温28℃
+	now := time.Now().UTC()
+	require.NoError(t, db.Create(&[]testTemperatureReading{
+		{SensorID: 24, Channel: 1, Temperature: 28, Status: "normal", RecordedAt: now.Add(-2 * time.Minute)},
+		{SensorID: 24, Channel: 1, Temperature: 35, Status: "spike", RecordedAt: now.Add(-time.Minute)},
+	}).Error)
+
+	service := NewACActionService(db, log, irService)
+	on, setPoint := true, 22
+	record, err := service.Execute(models.AIStrategyAction{
+		Type: "ac_control", DeviceID: "1", Power: &on, Mode: "cool", TargetTemp: &setPoint,
+		VerifySensorID: "24-1", VerifyMinutes: 10,
+	}, 3, 7, "auto")
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{0x00A5, 0x8610, 0x0001}, <-writes)
+	assert.Equal(t, models.ACState{Power: true, Mode: "cool", SetPoint: 22, FanSpeed: "auto"}, record.State)
+	assert.Equal(t, models.ACTrendFalling, record.ExpectedTrend)
+	assert.Equal(t, models.ACVerifyPending, record.VerifyStatus)
+	require.NotNil(t, record.BaselineTemp)
+	assert.InDelta(t, 28, *record.BaselineTemp, 0.01)
+
+	// 观察期结束时室温降到26℃
+	require.NoError(t, db.Create(&testTemperatureReading{
+		SensorID: 24, Channel: 1, Temperature: 26, Status: "normal", RecordedAt: record.VerifyDeadline.Add(-time.Minute),
+	}).Error)
+	require.NoError(t, service.Verify(record.ID))
+
+	records, err := service.ListRecords(controller.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ACVerifyEffective, records[0].VerifyStatus)
+	assert.Equal(t, models.ACTrendFalling, records[0].Trend)
+	assert.Equal(t, uint(7), records[0].ExecutionID)
+
+	saved, err := irService.GetController(controller.ID)
+	require.NoError(t, err)
+	assert.Equal(t, record.State, *saved.State)
+
+	// 只写传感器ID时按通道1验证
+	record, err = service.Execute(models.AIStrategyAction{Type: "ac_control", DeviceID: "1", VerifySensorID: "24"}, 3, 8, "auto")
+	require.NoError(t, err)
+	<-writes
+	assert.Equal(t, models.ACVerifyPending, record.VerifyStatus)
+	require.NotNil(t, record.BaselineTemp)
+
+	// 验证传感器无效时照常下发，不验证
+	record, err = service.Execute(models.AIStrategyAction{Type: "ac_control", DeviceID: "1", VerifySensorID: "机房"}, 3, 9, "auto")
+	require.NoError(t, err)
+	<-writes
+	assert.Equal(t, models.ACVerifyNoData, record.VerifyStatus)
+	assert.Nil(t, record.VerifyDeadline)
+	assert.Empty(t, record.Error)
+}
+
+func TestACActionValidate(t *testing.T) {
+	action := models.AIStrategyAction{Type: "ac_control", DeviceID: "1"}
+	for key, valid := range map[string]bool{"": true, "15": true, "24-2": true, "24-": false, "24-0": false, "机房": false, "0-1": false} {
+		action.VerifySensorID = key
+		assert.Equal(t, valid, action.Validate() == nil, key)
+	}
+	assert.Error(t, models.AIStrategyAction{Type: "ac_control", DeviceID: "空调"}.Validate())
+	assert.NoError(t, models.AIStrategyAction{Type: "notification"}.Validate())
+}
+
+func TestEvaluateACTrend(t *testing.T) {
+	temp := func(v float64) *float64 { return &v }
+	cool := models.ACState{Power: true, Mode: "cool", SetPoint: 24, FanSpeed: "auto"}
+
+	tests := []struct {
+		name   string
+		record models.ACActionRecord
+		trend  string
+		status string
+	}{
+		{"降温", models.ACActionRecord{State: cool, ExpectedTrend: models.ACTrendFalling, BaselineTemp: temp(28), FinalTemp: temp(27)}, models.ACTrendFalling, models.ACVerifyEffective},
+		{"未降温", models.ACActionRecord{State: cool, ExpectedTrend: models.ACTrendFalling, BaselineTemp: temp(28), FinalTemp: temp(28.1)}, models.ACTrendStable, models.ACVerifyIneffective},
+		{"已达设定温度", models.ACActionRecord{State: cool, ExpectedTrend: models.ACTrendFalling, BaselineTemp: temp(24), FinalTemp: temp(24.2)}, models.ACTrendStable, models.ACVerifyEffective},
+		{"关机仅记录", models.ACActionRecord{State: models.ACState{Mode: "cool", SetPoint: 24}, BaselineTemp: temp(24), FinalTemp: temp(26)}, models.ACTrendRising, models.ACVerifySkipped},
+		{"无读数", models.ACActionRecord{State: cool, ExpectedTrend: models.ACTrendFalling, BaselineTemp: temp(28)}, "", models.ACVerifyNoData},
+	}
+	for _, tt := range tests {
+		trend, status, _ := evaluateACTrend(tt.record)
+		assert.Equal(t, tt.trend, trend, tt.name)
+		assert.Equal(t, tt.status, status, tt.name)
+	}
+}
@@ -24,6 +24,8 @@ type AIStrategyMonitor struct {
 	actionTemplateRepo   repositories.ActionTemplateRepository
 	breakerService       *BreakerService
 	serverService        *ServerService
+	acActionService      *ACActionService
+	serverMetricsService *ServerMetricsService
 	temperatureData      map[string]float64 // 传感器ID -> 最新温度
 	mutex                sync.RWMutex
 	running              bool
@@ -34,18 +36,20 @@ type AIStrategyMonitor struct {
 }
 
 // NewAIStrategyMonitor 创建AI策略监控服务
-func NewAIStrategyMonitor(db *gorm.DB, logger *logrus.Logger, breakerService *BreakerService, serverService *ServerService) *AIStrategyMonitor {
+func NewAIStrategyMonitor(db *gorm.DB, logger *logrus.Logger, breakerService *BreakerService, serverService *ServerService, acActionService *ACActionService, serverMetricsService *ServerMetricsService) *AIStrategyMonitor {
 	return &AIStrategyMonitor{
-		db:                 db,
-		logger:             logger,
-		strategyRepo:       repositories.NewAIStrategyRepository(),
-		actionTemplateRepo: repositories.NewActionTemplateRepository(db),
-		breakerService:     breakerService,
-		serverService:      serverService,
-		temperatureData:    make(map[string]float64),
-		stopChan:           make(chan bool, 1),
-		interval:           30 * time.Second, // 默认30秒检查一次
-		lastExecutionTime:  make(map[uint]time.Time),
+		db:                   db,
+		logger:               logger,
+		strategyRepo:         repositories.NewAIStrategyRepository(),
+		actionTemplateRepo:   repositories.NewActionTemplateRepository(db),
+		breakerService:       breakerService,
+		serverService:        serverService,
+		acActionService:      acActionService,
+		serverMetricsService: serverMetricsService,
+		temperatureData:      make(map[string]float64),
+		stopChan:             make(chan bool, 1),
+		interval:             30 * time.Second, // 默认30秒检查一次
+		lastExecutionTime:    make(map[uint]time.Time),
 	}
 }
 
@@ -152,6 +156,11 @@ func (m *AIStrategyMonitor) handleTemperatureData(data interface{}) {
 	defer m.mutex.Unlock()
 
 	for _, tempData := range tempDataList {
+		// 健康检测判为异常的读数不参与策略评估
+		if status, ok := tempData["status"].(string); ok && status != "normal" {
+			continue
+		}
+
 		// 处理传感器ID和通道号
 		if sensorID, ok := tempData["sensor_id"].(float64); ok {
 			if channel, ok := tempData["channel"].(float64); ok {
@@ -256,17 +265,33 @@ func (m *AIStrategyMonitor) evaluateStrategyConditions(strategy *models.AIStrate
 
 	// 评估所有条件
 	conditionResults := make([]bool, len(strategy.ConditionsList))
+	unknownCount := 0
 	for i, condition := range strategy.ConditionsList {
-		conditionResults[i] = m.evaluateSingleCondition(condition)
+		result, known := m.evaluateSingleCondition(condition)
+		conditionResults[i] = result
+		if !known {
+			unknownCount++
+		}
 		m.logger.Debug("单个条件评估结果",
 			"condition_index", i,
 			"condition_type", condition.Type,
-			"result", conditionResults[i])
+			"result", result,
+			"known", known)
+	}
+
+	// 根据逻辑操作符计算最终结果。有条件结果未知（如温度读数缺失或异常、服务器负载指标过期）时，
+	// 只有 OR 中其他条件已满足才触发，AND/NOT 无法确定结果，不触发
+	var finalResult bool
+	if unknownCount > 0 && logicOperator != "OR" {
+		m.logger.Info("策略存在结果未知的条件，不触发",
+			"strategy_id", strategy.ID,
+			"logic_operator", logicOperator,
+			"unknown_count", unknownCount)
+		finalResult = false
+	} else {
+		finalResult = m.calculateLogicResult(conditionResults, logicOperator)
 	}
 
-	// 根据逻辑操作符计算最终结果
-	finalResult := m.calculateLogicResult(conditionResults, logicOperator)
-
 	m.logger.Info("策略条件最终评估结果",
 		"strategy_id", strategy.ID,
 		"logic_operator", logicOperator,
@@ -321,33 +346,38 @@ func (m *AIStrategyMonitor) calculateLogicResult(conditionResults []bool, logicO
 	}
 }
 
-// evaluateSingleCondition 评估单个条件
-func (m *AIStrategyMonitor) evaluateSingleCondition(condition models.AIStrategyCondition) bool {
+// evaluateSingleCondition 评估单个条件，known 为 false 表示数据不足、结果未知
+func (m *AIStrategyMonitor) evaluateSingleCondition(condition models.AIStrategyCondition) (result bool, known bool) {
 	switch condition.Type {
 	case "temperature":
 		return m.evaluateTemperatureCondition(condition)
 	case "time":
-		return m.evaluateTimeCondition(condition)
+		return m.evaluateTimeCondition(condition), true
 	case "server_load":
 		return m.evaluateServerLoadCondition(condition)
 	default:
 		m.logger.Warn("不支持的条件类型", "type", condition.Type)
-		return false
+		return false, true
 	}
 }
 
-// evaluateTemperatureCondition 评估温度条件
-func (m *AIStrategyMonitor) evaluateTemperatureCondition(condition models.AIStrategyCondition) bool {
+// evaluateTemperatureCondition 评估温度条件。传感器没有有效读数（未采集、过期或被健康检测判为异常）时
+// 结果未知，不按不满足处理，避免 NOT 策略因探头断开而触发
+func (m *AIStrategyMonitor) evaluateTemperatureCondition(condition models.AIStrategyCondition) (bool, bool) {
 	temperature, exists := m.temperatureData[condition.SensorID]
+	if !exists && !strings.Contains(condition.SensorID, "-") {
+		// 未指定通道时使用通道1（虚拟传感器只有通道1）
+		temperature, exists = m.temperatureData[condition.SensorID+"-1"]
+	}
 	if !exists {
-		m.logger.Info("传感器数据不存在", "sensor_id", condition.SensorID, "available_sensors", m.getAvailableSensorIDs())
-		return false
+		m.logger.Info("传感器没有有效读数，条件结果未知", "sensor_id", condition.SensorID, "available_sensors", m.getAvailableSensorIDs())
+		return false, false
 	}
 
 	threshold, err := strconv.ParseFloat(fmt.Sprintf("%v", condition.Value), 64)
 	if err != nil {
 		m.logger.Error("解析温度阈值失败", "value", condition.Value, "error", err)
-		return false
+		return false, true
 	}
 
 	result := false
@@ -364,7 +394,7 @@ func (m *AIStrategyMonitor) evaluateTemperatureCondition(condition models.AIStra
 		result = temperature == threshold
 	default:
 		m.logger.Warn("不支持的操作符", "operator", condition.Operator)
-		return false
+		return false, true
 	}
 
 	m.logger.Info("温度条件评估",
@@ -374,7 +404,7 @@ func (m *AIStrategyMonitor) evaluateTemperatureCondition(condition models.AIStra
 		"threshold", threshold,
 		"result", result)
 
-	return result
+	return result, true
 }
 
 // getAvailableSensorIDs 获取可用的传感器ID列表
@@ -404,18 +434,19 @@ func (m *AIStrategyMonitor) startDatabaseTemperatureReader() {
 
 // loadLatestTemperatureData 从数据库加载最新温度数据
 func (m *AIStrategyMonitor) loadLatestTemperatureData() {
-	// 查询最近5分钟内每个传感器-通道的最新温度数据
+	// 查询最近5分钟内每个传感器-通道的最新温度数据（采集服务入库的温度已按通道校准）
 	var readings []struct {
 		SensorID    uint    `json:"sensor_id"`
 		Channel     int     `json:"channel"`
 		Temperature float64 `json:"temperature"`
+		Status      string  `json:"status"`
 		RecordedAt  time.Time `json:"recorded_at"`
 	}
 
 	// 使用子查询获取每个传感器-通道的最新记录
 	err := m.db.Raw(`
 		SELECT DISTINCT ON (sensor_id, channel)
-			sensor_id, channel, temperature, recorded_at
+			sensor_id, channel, temperature, status, recorded_at
 		FROM temperature_readings
 		WHERE recorded_at > NOW() - INTERVAL '5 minutes'
 		ORDER BY sensor_id, channel, recorded_at DESC
@@ -435,6 +466,11 @@ func (m *AIStrategyMonitor) loadLatestTemperatureData() {
 
 	// 添加新数据
 	for _, reading := range readings {
+		// 最新读数被健康检测判为异常（断开、卡死、超量程、跳变）时不参与策略评估，
+		// 对应条件结果未知，故障由传感器故障告警通知
+		if reading.Status != "normal" {
+			continue
+		}
 		// 构建传感器ID格式: "传感器ID-通道号" (如 "24-1", "24-2")
 		deviceID := fmt.Sprintf("%d-%d", reading.SensorID, reading.Channel)
 		m.temperatureData[deviceID] = reading.Temperature
@@ -516,11 +552,70 @@ func (m *AIStrategyMonitor) evaluateTimeCondition(condition models.AIStrategyCon
 	return false
 }
 
-// evaluateServerLoadCondition 评估服务器负载条件
-func (m *AIStrategyMonitor) evaluateServerLoadCondition(condition models.AIStrategyCondition) bool {
-	// TODO: 实现服务器负载检查
-	m.logger.Debug("服务器负载条件检查暂未实现", "server_id", condition.ServerID)
-	return false
+// evaluateServerLoadCondition 评估服务器负载条件：负载类型的使用率（窗口平均值）与阈值比较。
+// 没有有效期内的指标时结果未知；配置错误按不满足处理
+func (m *AIStrategyMonitor) evaluateServerLoadCondition(condition models.AIStrategyCondition) (bool, bool) {
+	if m.serverMetricsService == nil {
+		m.logger.Warn("服务器负载指标服务未启动", "server_id", condition.ServerID)
+		return false, false
+	}
+	serverID, err := strconv.ParseUint(condition.ServerID, 10, 32)
+	if err != nil {
+		m.logger.Error("解析服务器ID失败", "server_id", condition.ServerID, "error", err)
+		return false, true
+	}
+	if _, ok := serverMetricColumns[condition.LoadType]; !ok {
+		m.logger.Warn("不支持的负载类型", "load_type", condition.LoadType)
+		return false, true
+	}
+	threshold, err := strconv.ParseFloat(fmt.Sprintf("%v", condition.Value), 64)
+	if err != nil {
+		m.logger.Error("解析负载阈值失败", "value", condition.Value, "error", err)
+		return false, true
+	}
+
+	window := time.Duration(condition.Window) * time.Second
+	maxAge := time.Duration(condition.MaxAge) * time.Second
+	load, known, err := m.serverMetricsService.LoadAverage(uint(serverID), condition.LoadType, window, maxAge, time.Now().UTC())
+	if err != nil {
+		m.logger.Error("获取服务器负载失败", "server_id", serverID, "load_type", condition.LoadType, "error", err)
+		return false, false
+	}
+	if !known {
+		m.logger.Info("服务器负载指标不存在或已过期，条件结果未知",
+			"server_id", serverID,
+			"load_type", condition.LoadType,
+			"max_age", condition.MaxAge)
+		return false, false
+	}
+
+	result := false
+	switch condition.Operator {
+	case ">":
+		result = load > threshold
+	case "<":
+		result = load < threshold
+	case ">=":
+		result = load >= threshold
+	case "<=":
+		result = load <= threshold
+	case "==":
+		result = load == threshold
+	default:
+		m.logger.Warn("不支持的操作符", "operator", condition.Operator)
+		return false, true
+	}
+
+	m.logger.Info("服务器负载条件评估",
+		"server_id", serverID,
+		"load_type", condition.LoadType,
+		"window", condition.Window,
+		"current_load", load,
+		"operator", condition.Operator,
+		"threshold", threshold,
+		"result", result)
+
+	return result, true
 }
 
 // isInCooldown 检查是否在冷却期内
@@ -576,7 +671,7 @@ func (m *AIStrategyMonitor) executeStrategyAsync(execution *models.AIStrategyExe
 			"operation":   action.Operation,
 		}).Info("执行策略动作")
 
-		result, err := m.executeAction(action)
+		result, err := m.executeAction(execution, action)
 		if err != nil {
 			hasError = true
 			results = append(results, fmt.Sprintf("动作%d失败: %s", i+1, err.Error()))
@@ -615,7 +710,7 @@ func (m *AIStrategyMonitor) executeStrategyAsync(execution *models.AIStrategyExe
 }
 
 // executeAction 执行单个动作
-func (m *AIStrategyMonitor) executeAction(action models.AIStrategyAction) (string, error) {
+func (m *AIStrategyMonitor) executeAction(execution *models.AIStrategyExecution, action models.AIStrategyAction) (string, error) {
 	// 如果使用动作模板，直接执行模板
 	if action.UseTemplate && action.TemplateID != nil {
 		return m.executeActionTemplate(action)
@@ -627,11 +722,33 @@ func (m *AIStrategyMonitor) executeAction(action models.AIStrategyAction) (strin
 		return m.executeServerAction(action)
 	case "breaker":
 		return m.executeBreakerAction(action)
+	case "ac_control", "ac":
+		return m.executeACAction(execution, action)
 	default:
 		return "", fmt.Errorf("不支持的动作类型: %s", action.Type)
 	}
 }
 
+// executeACAction 执行空调控制动作，室温趋势验证在观察期结束后异步完成
+func (m *AIStrategyMonitor) executeACAction(execution *models.AIStrategyExecution, action models.AIStrategyAction) (string, error) {
+	if m.acActionService == nil {
+		return "", fmt.Errorf("红外空调控制服务未启动")
+	}
+
+	record, err := m.acActionService.Execute(action, execution.StrategyID, execution.ID, execution.TriggerBy)
+	if err != nil {
+		return "", fmt.Errorf("空调 %s 控制失败: %w", action.DeviceName, err)
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"ir_controller_id": record.IRControllerID,
+		"record_id":        record.ID,
+		"verify_status":    record.VerifyStatus,
+	}).Info("空调控制指令已发送")
+
+	return DescribeACAction(action.DeviceName, record), nil
+}
+
 // executeServerAction 执行服务器动作
 func (m *AIStrategyMonitor) executeServerAction(action models.AIStrategyAction) (string, error) {
 	m.logger.Info("执行服务器控制动作", "device_id", action.DeviceID, "operation", action.Operation)
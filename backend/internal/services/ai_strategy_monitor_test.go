@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"smart-device-management/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemperatureConditionUnknown(t *testing.T) {
+	// 25-1 的最新读数被健康检测判为异常，不在 temperatureData 中
+	monitor := &AIStrategyMonitor{logger: logrus.New(), temperatureData: map[string]float64{"24-1": 30}}
+
+	result, known := monitor.evaluateSingleCondition(models.AIStrategyCondition{Type: "temperature", SensorID: "24", Operator: ">", Value: 28})
+	assert.True(t, result, "未指定通道时使用通道1")
+	assert.True(t, known)
+	_, known = monitor.evaluateSingleCondition(models.AIStrategyCondition{Type: "temperature", SensorID: "25-1", Operator: ">", Value: 35})
+	assert.False(t, known)
+
+	// 探头异常时 NOT/AND 不触发，OR 仍按其他条件触发
+	strategy := &models.AIStrategy{LogicOperator: "NOT", ConditionsList: []models.AIStrategyCondition{
+		{Type: "temperature", SensorID: "25-1", Operator: ">", Value: 35},
+	}}
+	assert.False(t, monitor.evaluateStrategyConditions(strategy))
+	strategy.LogicOperator = "AND"
+	strategy.ConditionsList = append(strategy.ConditionsList, models.AIStrategyCondition{Type: "temperature", SensorID: "24-1", Operator: ">", Value: 28})
+	assert.False(t, monitor.evaluateStrategyConditions(strategy))
+	strategy.LogicOperator = "OR"
+	assert.True(t, monitor.evaluateStrategyConditions(strategy))
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+
+	"github.com/google/uuid"
+)
+
+// GetProtection 读取断路器当前保护参数及取值范围
+func (s *BreakerService) GetProtection(id uint) (*models.BreakerProtectionResponse, error) {
+	breaker, err := s.breakerRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+
+	configurator, err := s.modbusService.ProtectionDriver(breaker)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.modbusService.ReadProtection(breaker)
+	if err != nil {
+		s.logger.Error("读取保护参数失败", "breaker_id", id, "error", err)
+		return nil, fmt.Errorf("读取保护参数失败: %w", err)
+	}
+
+	response := &models.BreakerProtectionResponse{
+		BreakerID:   breaker.ID,
+		DeviceModel: breaker.Model(),
+		ReadAt:      time.Now(),
+	}
+	for _, field := range configurator.ProtectionSchema() {
+		response.Parameters = append(response.Parameters, models.BreakerProtectionParameter{
+			Key:   field.Key,
+			Label: field.Label,
+			Unit:  field.Unit,
+			Type:  field.Type,
+			Value: values[field.Key],
+			Min:   field.Min,
+			Max:   field.Max,
+			Step:  field.Step,
+		})
+	}
+	return response, nil
+}
+
+// UpdateProtection 校验并写入保护参数，回读确认后记录修改历史。
+// 与设备当前值相同的参数不写入；写入或回读不一致的参数同样记录，Verified 为 false。
+func (s *BreakerService) UpdateProtection(id uint, req models.UpdateBreakerProtectionRequest, userID uint, username string) (*models.BreakerProtectionUpdateResponse, error) {
+	s.logger.Info("修改断路器保护参数", "breaker_id", id, "parameters", req.Parameters, "user", username)
+
+	breaker, err := s.breakerRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+	if !breaker.IsEnabled {
+		return nil, fmt.Errorf("断路器已禁用")
+	}
+
+	configurator, err := s.modbusService.ProtectionDriver(breaker)
+	if err != nil {
+		return nil, err
+	}
+	if err := drivers.ValidateProtection(configurator, req.Parameters); err != nil {
+		return nil, err
+	}
+
+	before, err := s.modbusService.ReadProtection(breaker)
+	if err != nil {
+		return nil, fmt.Errorf("读取当前保护参数失败: %w", err)
+	}
+	if err := drivers.ValidateProtectionChange(before, req.Parameters); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]drivers.ConfigField)
+	for _, field := range configurator.ProtectionSchema() {
+		fields[field.Key] = field
+	}
+
+	pending := make(map[string]float64)
+	for key, value := range req.Parameters {
+		if !sameProtectionValue(fields[key], before[key], value) {
+			pending[key] = value
+		}
+	}
+
+	response := &models.BreakerProtectionUpdateResponse{
+		BatchID:  uuid.New().String(),
+		Verified: true,
+	}
+	if len(pending) == 0 {
+		s.logger.Info("保护参数与设备当前值一致，无需写入", "breaker_id", id)
+		return response, nil
+	}
+
+	writeErrs, readBack, readErr := s.modbusService.WriteProtection(breaker, pending)
+
+	for _, field := range configurator.ProtectionSchema() {
+		value, ok := pending[field.Key]
+		if !ok {
+			continue
+		}
+
+		change := models.BreakerProtectionChange{
+			BreakerID: breaker.ID,
+			BatchID:   response.BatchID,
+			Parameter: field.Key,
+			Label:     field.Label,
+			Unit:      field.Unit,
+			OldValue:  before[field.Key],
+			NewValue:  value,
+			UserID:    userID,
+			Username:  username,
+			Reason:    req.Reason,
+		}
+
+		var problems []string
+		if err := writeErrs[field.Key]; err != nil {
+			problems = append(problems, err.Error())
+		}
+		if readErr != nil {
+			problems = append(problems, readErr.Error())
+		} else {
+			actual := readBack[field.Key]
+			change.ReadBack = &actual
+			if !sameProtectionValue(field, actual, value) {
+				problems = append(problems, fmt.Sprintf("回读值 %v 与写入值 %v 不一致", actual, value))
+			}
+		}
+
+		change.Verified = len(problems) == 0
+		change.ErrorMsg = strings.Join(problems, "; ")
+		if !change.Verified {
+			response.Verified = false
+		}
+		response.Changes = append(response.Changes, change)
+	}
+
+	if err := s.protectionRepo.CreateChanges(response.Changes); err != nil {
+		s.logger.Error("保存保护参数修改记录失败", "breaker_id", id, "error", err)
+		return nil, fmt.Errorf("保存保护参数修改记录失败: %w", err)
+	}
+
+	s.logger.Info("保护参数修改完成", "breaker_id", id, "batch_id", response.BatchID, "verified", response.Verified)
+	return response, nil
+}
+
+// GetProtectionHistory 分页获取保护参数修改记录
+func (s *BreakerService) GetProtectionHistory(id uint, page, size int) (*models.BreakerProtectionChangeListResponse, error) {
+	if _, err := s.breakerRepo.GetByID(id); err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+
+	changes, total, err := s.protectionRepo.ListByBreaker(id, page, size)
+	if err != nil {
+		s.logger.Error("获取保护参数修改记录失败", "breaker_id", id, "error", err)
+		return nil, fmt.Errorf("获取保护参数修改记录失败: %w", err)
+	}
+
+	return &models.BreakerProtectionChangeListResponse{
+		Changes: changes,
+		Total:   total,
+		Page:    page,
+		Size:    size,
+	}, nil
+}
+
+// sameProtectionValue 按参数分辨率比较两个值（差值小于半个分辨率视为相同）
+func sameProtectionValue(field drivers.ConfigField, a, b float64) bool {
+	step := field.Step
+	if step <= 0 {
+		step = 1
+	}
+	return math.Abs(a-b) < step/2
+}
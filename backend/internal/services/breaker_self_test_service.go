@@ -0,0 +1,511 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/websocket"
+
+	"gorm.io/gorm"
+)
+
+const (
+	selfTestPollInterval  = 300 * time.Millisecond // 等待跳闸时的回读间隔
+	selfTestCheckInterval = time.Minute            // 检查到期计划的间隔
+	selfTestRetryDelay    = time.Hour              // 计划无法执行时的重试间隔
+	defaultSelfTestDays   = 30
+	defaultSelfTestHour   = 10
+)
+
+// selfTesting 正在进行漏电自检的断路器。自检跳闸由自检记录负责，状态监控不再重复记录为跳闸事件
+var selfTesting sync.Map
+
+// isSelfTesting 断路器是否正在进行漏电自检
+func isSelfTesting(breakerID uint) bool {
+	_, ok := selfTesting.Load(breakerID)
+	return ok
+}
+
+// BreakerSelfTestService 断路器漏电自检服务：远程按下试验按钮并确认以漏电保护跳闸，
+// 按计划定期执行，自检后的重新合闸需操作员批准
+type BreakerSelfTestService struct {
+	db            *gorm.DB
+	repo          repositories.BreakerSelfTestRepository
+	breakerRepo   repositories.BreakerRepository
+	modbusService *ModbusService
+	logger        *logger.Logger
+
+	tripTimeout time.Duration // 按下试验按钮后等待跳闸的最长时间
+	maxAgeDays  int           // 合规报告默认要求的最长自检间隔
+	settleDelay time.Duration // 重新合闸后等待设备动作再回读
+
+	mutex     sync.Mutex
+	isRunning bool
+	stopChan  chan struct{}
+}
+
+// NewBreakerSelfTestService 创建断路器漏电自检服务
+func NewBreakerSelfTestService(db *gorm.DB, logger *logger.Logger, tripTimeout time.Duration, maxAgeDays int) *BreakerSelfTestService {
+	if tripTimeout <= 0 {
+		tripTimeout = 5 * time.Second
+	}
+	if maxAgeDays <= 0 {
+		maxAgeDays = defaultSelfTestDays
+	}
+	return &BreakerSelfTestService{
+		db:            db,
+		repo:          repositories.NewBreakerSelfTestRepository(db),
+		breakerRepo:   repositories.NewBreakerRepository(db),
+		modbusService: NewModbusService(logger, db),
+		logger:        logger,
+		tripTimeout:   tripTimeout,
+		maxAgeDays:    maxAgeDays,
+		settleDelay:   time.Second,
+	}
+}
+
+// Start 启动计划自检
+func (s *BreakerSelfTestService) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("漏电自检计划已在运行")
+	}
+
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	go s.loop(s.stopChan)
+
+	s.logger.Info("启动断路器漏电自检计划", "trip_timeout", s.tripTimeout.String())
+	return nil
+}
+
+// Stop 停止计划自检
+func (s *BreakerSelfTestService) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning {
+		return fmt.Errorf("漏电自检计划未在运行")
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+	s.logger.Info("停止断路器漏电自检计划")
+	return nil
+}
+
+func (s *BreakerSelfTestService) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(selfTestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runDue()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// runDue 依次执行已到期的自检计划（同一时间只测一台，避免多路同时跳闸）
+func (s *BreakerSelfTestService) runDue() {
+	schedules, err := s.repo.DueSchedules(time.Now().UTC())
+	if err != nil {
+		s.logger.Error("获取到期漏电自检计划失败", "error", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		if _, err := s.RunSelfTest(schedule.BreakerID, models.SelfTestTriggerScheduled, schedule.RequestReclose, ""); err != nil {
+			s.logger.Error("计划漏电自检未能执行", "breaker_id", schedule.BreakerID, "error", err)
+			retry := time.Now().Add(selfTestRetryDelay).UTC()
+			schedule.NextRunAt = &retry
+			if err := s.repo.SaveSchedule(schedule); err != nil {
+				s.logger.Error("更新漏电自检计划失败", "breaker_id", schedule.BreakerID, "error", err)
+			}
+		}
+	}
+}
+
+// RunSelfTest 对断路器执行一次漏电自检并保存结果。断路器分闸、锁定或禁用时记录为跳过。
+// requestReclose 为 true 时，自检通过后进入待批准重新合闸状态。
+func (s *BreakerSelfTestService) RunSelfTest(breakerID uint, trigger string, requestReclose bool, username string) (*models.BreakerSelfTest, error) {
+	breaker, err := s.breakerRepo.GetByID(breakerID)
+	if err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+	if _, busy := selfTesting.LoadOrStore(breaker.ID, struct{}{}); busy {
+		return nil, fmt.Errorf("断路器正在进行漏电自检")
+	}
+	defer selfTesting.Delete(breaker.ID)
+
+	test := &models.BreakerSelfTest{
+		BreakerID:    breaker.ID,
+		Trigger:      trigger,
+		Status:       models.SelfTestStatusRunning,
+		StatusBefore: breaker.Status,
+		Username:     username,
+		StartedAt:    time.Now().UTC(),
+	}
+	if err := s.repo.Create(test); err != nil {
+		return nil, fmt.Errorf("保存漏电自检记录失败: %w", err)
+	}
+
+	s.logger.Info("开始漏电自检", "breaker_id", breaker.ID, "trigger", trigger, "user", username)
+	s.execute(breaker, test)
+
+	if test.Status == models.SelfTestStatusPassed && requestReclose {
+		test.RecloseStatus = models.RecloseStatusPending
+	}
+	completed := time.Now().UTC()
+	test.CompletedAt = &completed
+	if err := s.repo.Update(test); err != nil {
+		s.logger.Error("保存漏电自检结果失败", "breaker_id", breaker.ID, "error", err)
+	}
+	s.recordScheduleRun(test)
+
+	if test.Status == models.SelfTestStatusPassed || test.Status == models.SelfTestStatusSkipped {
+		s.logger.Info("漏电自检完成", "breaker_id", breaker.ID, "status", test.Status, "trip_ms", test.TripMs, "message", test.ErrorMsg)
+	} else {
+		s.logger.Error("漏电自检未通过", "breaker_id", breaker.ID, "status", test.Status, "reasons", test.Reasons, "error", test.ErrorMsg)
+	}
+	websocket.BroadcastBreakerSelfTest(map[string]interface{}{
+		"breaker_id":   breaker.ID,
+		"breaker_name": breaker.BreakerName,
+		"location":     breaker.Location,
+		"test":         test,
+	})
+	return test, nil
+}
+
+// execute 按下试验按钮，在超时内回读到分闸后读取跳闸原因判定结果
+func (s *BreakerSelfTestService) execute(breaker *models.Breaker, test *models.BreakerSelfTest) {
+	if !breaker.IsEnabled {
+		test.Status, test.ErrorMsg = models.SelfTestStatusSkipped, "断路器已禁用"
+		return
+	}
+	if breaker.IsLocked {
+		test.Status, test.ErrorMsg = models.SelfTestStatusSkipped, "断路器已锁定"
+		return
+	}
+
+	telemetry, err := s.modbusService.readTelemetry(breaker)
+	if err != nil {
+		test.Status, test.ErrorMsg = models.SelfTestStatusError, fmt.Sprintf("读取断路器状态失败: %v", err)
+		return
+	}
+	if !telemetry.Closed {
+		test.StatusBefore = models.SwitchStatusOff
+		test.Status, test.ErrorMsg = models.SelfTestStatusSkipped, "断路器处于分闸状态，无法验证漏电保护动作"
+		return
+	}
+	test.StatusBefore = models.SwitchStatusOn
+
+	start := time.Now()
+	if err := s.modbusService.TestLeakage(breaker); err != nil {
+		test.Status, test.ErrorMsg = models.SelfTestStatusError, err.Error()
+		return
+	}
+
+	deadline := start.Add(s.tripTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(selfTestPollInterval)
+		telemetry, err := s.modbusService.readTelemetry(breaker)
+		if err == nil && !telemetry.Closed {
+			test.Tripped = true
+			test.TripMs = time.Since(start).Milliseconds()
+			break
+		}
+	}
+	if !test.Tripped {
+		test.Status = models.SelfTestStatusFailed
+		test.ErrorMsg = fmt.Sprintf("按下试验按钮后 %s 内未跳闸，漏电保护可能失效", s.tripTimeout)
+		return
+	}
+
+	// 自检跳闸后同步数据库状态，状态监控不会再将其记录为跳闸事件
+	if err := s.db.Model(breaker).Updates(map[string]interface{}{
+		"status":      models.SwitchStatusOff,
+		"last_update": time.Now(),
+	}).Error; err != nil {
+		s.logger.Error("更新断路器状态失败", "breaker_id", breaker.ID, "error", err)
+	}
+
+	history, analysis, err := s.modbusService.ReadTripInfo(breaker)
+	if err != nil {
+		test.Status, test.ErrorMsg = models.SelfTestStatusError, fmt.Sprintf("已跳闸，但读取跳闸原因失败: %v", err)
+		return
+	}
+	test.ReasonCode = history.Latest
+	if analysis == nil {
+		test.Status, test.ErrorMsg = models.SelfTestStatusError, "已跳闸，但设备型号不支持解析跳闸原因"
+		return
+	}
+	test.HexCode = analysis.HexCode
+	test.Reasons = analysis.Reasons
+	if !analysis.Leakage {
+		test.Status, test.ErrorMsg = models.SelfTestStatusFailed, "已跳闸，但跳闸原因不是漏电保护"
+		return
+	}
+	test.Status = models.SelfTestStatusPassed
+}
+
+// recordScheduleRun 更新计划的最近执行时间和结果，并推算下次执行时间。
+// 只有得出结论（通过或未通过）的自检才开始新周期；计划自检被跳过或出错时稍后重试，手动自检则不影响计划。
+func (s *BreakerSelfTestService) recordScheduleRun(test *models.BreakerSelfTest) {
+	schedule, err := s.repo.GetSchedule(test.BreakerID)
+	if err != nil || schedule == nil {
+		return
+	}
+	switch {
+	case test.Status == models.SelfTestStatusPassed || test.Status == models.SelfTestStatusFailed:
+		started := test.StartedAt
+		schedule.LastRunAt = &started
+		schedule.LastStatus = test.Status
+		if schedule.Enabled {
+			next := nextSelfTestRun(time.Now(), schedule.LastRunAt, schedule.IntervalDays, schedule.Hour)
+			schedule.NextRunAt = &next
+		}
+	case test.Trigger == models.SelfTestTriggerScheduled:
+		retry := time.Now().Add(selfTestRetryDelay).UTC()
+		schedule.LastStatus = test.Status
+		schedule.NextRunAt = &retry
+	default:
+		return
+	}
+	if err := s.repo.SaveSchedule(schedule); err != nil {
+		s.logger.Error("更新漏电自检计划失败", "breaker_id", test.BreakerID, "error", err)
+	}
+}
+
+// ApproveReclose 操作员批准或拒绝自检后的重新合闸，批准时合闸并回读确认。
+// 断路器禁用、不可控制或已锁定时不能批准，记录保持待批准
+func (s *BreakerSelfTestService) ApproveReclose(testID uint, approve bool, username string) (*models.BreakerSelfTest, error) {
+	test, err := s.repo.GetByID(testID)
+	if err != nil {
+		return nil, fmt.Errorf("漏电自检记录不存在: %w", err)
+	}
+	if test.RecloseStatus != models.RecloseStatusPending {
+		return nil, fmt.Errorf("该自检记录没有待批准的重新合闸")
+	}
+
+	var breaker *models.Breaker
+	status := models.RecloseStatusRejected
+	if approve {
+		if breaker, err = s.breakerRepo.GetByID(test.BreakerID); err != nil {
+			return nil, fmt.Errorf("断路器不存在: %w", err)
+		}
+		if err := recloseAllowed(breaker); err != nil {
+			return nil, err
+		}
+		status = models.RecloseStatusClosed
+	}
+
+	// 先认领记录再合闸，同时到达的多个批准只有一个会下发合闸命令
+	claimed, err := s.repo.ClaimReclose(test.ID, status)
+	if err != nil {
+		return nil, fmt.Errorf("更新重新合闸状态失败: %w", err)
+	}
+	if !claimed {
+		return nil, fmt.Errorf("该自检记录没有待批准的重新合闸")
+	}
+
+	now := time.Now().UTC()
+	test.RecloseStatus = status
+	test.RecloseBy = username
+	test.RecloseAt = &now
+	if !approve {
+		s.logger.Info("拒绝漏电自检后重新合闸", "breaker_id", test.BreakerID, "test_id", test.ID, "user", username)
+		return test, s.repo.Update(test)
+	}
+
+	if err := s.modbusService.ControlBreaker(breaker, "on"); err != nil {
+		test.RecloseStatus, test.RecloseError = models.RecloseStatusFailed, err.Error()
+	} else {
+		time.Sleep(s.settleDelay)
+		if telemetry, err := s.modbusService.readTelemetry(breaker); err != nil {
+			test.RecloseStatus, test.RecloseError = models.RecloseStatusFailed, fmt.Sprintf("合闸后回读状态失败: %v", err)
+		} else if !telemetry.Closed {
+			test.RecloseStatus, test.RecloseError = models.RecloseStatusFailed, "合闸后回读仍为分闸"
+		}
+	}
+
+	s.logger.Info("漏电自检后重新合闸", "breaker_id", test.BreakerID, "test_id", test.ID, "status", test.RecloseStatus, "user", username)
+	if err := s.repo.Update(test); err != nil {
+		return nil, fmt.Errorf("保存重新合闸结果失败: %w", err)
+	}
+	return test, nil
+}
+
+// recloseAllowed 与手动分合闸相同的前置检查：断路器须启用、可控制且未锁定
+func recloseAllowed(breaker *models.Breaker) error {
+	switch {
+	case !breaker.IsEnabled:
+		return fmt.Errorf("断路器已禁用")
+	case !breaker.IsControllable:
+		return fmt.Errorf("断路器不可控制")
+	case breaker.IsLocked:
+		return fmt.Errorf("断路器已锁定，请解锁后再批准重新合闸")
+	}
+	return nil
+}
+
+// GetSchedule 获取断路器的自检计划，未设置时返回未启用的默认计划
+func (s *BreakerSelfTestService) GetSchedule(breakerID uint) (*models.BreakerSelfTestSchedule, error) {
+	if _, err := s.breakerRepo.GetByID(breakerID); err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+	schedule, err := s.repo.GetSchedule(breakerID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		schedule = &models.BreakerSelfTestSchedule{BreakerID: breakerID, IntervalDays: defaultSelfTestDays, Hour: defaultSelfTestHour}
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule 设置断路器的自检计划
+func (s *BreakerSelfTestService) UpdateSchedule(breakerID uint, req models.UpdateSelfTestScheduleRequest, username string) (*models.BreakerSelfTestSchedule, error) {
+	breaker, err := s.breakerRepo.GetByID(breakerID)
+	if err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+	if req.Enabled && !supportsLeakageTest(breaker) {
+		return nil, fmt.Errorf("设备型号 %s 不支持远程漏电试验", breaker.Model())
+	}
+
+	schedule, err := s.repo.GetSchedule(breakerID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		schedule = &models.BreakerSelfTestSchedule{BreakerID: breakerID}
+	}
+	schedule.Enabled = req.Enabled
+	schedule.IntervalDays = req.IntervalDays
+	schedule.Hour = req.Hour
+	schedule.RequestReclose = req.RequestReclose
+	schedule.UpdatedBy = username
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		next := nextSelfTestRun(time.Now(), schedule.LastRunAt, schedule.IntervalDays, schedule.Hour)
+		schedule.NextRunAt = &next
+	}
+
+	if err := s.repo.SaveSchedule(schedule); err != nil {
+		return nil, fmt.Errorf("保存漏电自检计划失败: %w", err)
+	}
+	s.logger.Info("设置漏电自检计划", "breaker_id", breakerID, "enabled", schedule.Enabled, "interval_days", schedule.IntervalDays, "hour", schedule.Hour, "user", username)
+	return schedule, nil
+}
+
+// ListTests 分页获取漏电自检记录
+func (s *BreakerSelfTestService) ListTests(filter repositories.BreakerSelfTestFilter, page, pageSize int) (*models.BreakerSelfTestListResponse, error) {
+	tests, total, err := s.repo.List(filter, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BreakerSelfTestListResponse{Tests: tests, Total: total, Page: page, Size: pageSize}, nil
+}
+
+// ComplianceReport 合规报告：每台启用断路器最近一次自检及最近一次通过的时间，
+// 在 maxAgeDays 天内有通过记录的视为合规，maxAgeDays 为0时使用配置值
+func (s *BreakerSelfTestService) ComplianceReport(maxAgeDays int) (*models.SelfTestComplianceReport, error) {
+	if maxAgeDays <= 0 {
+		maxAgeDays = s.maxAgeDays
+	}
+	breakers, err := s.breakerRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("获取断路器列表失败: %w", err)
+	}
+	schedules, err := s.repo.ListSchedules()
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.repo.LatestByBreaker()
+	if err != nil {
+		return nil, err
+	}
+	passed, err := s.repo.LatestPassedByBreaker()
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleByBreaker := make(map[uint]models.BreakerSelfTestSchedule, len(schedules))
+	for _, schedule := range schedules {
+		scheduleByBreaker[schedule.BreakerID] = schedule
+	}
+
+	now := time.Now().UTC()
+	report := &models.SelfTestComplianceReport{MaxAgeDays: maxAgeDays, GeneratedAt: now, Items: []models.SelfTestComplianceItem{}}
+	for i := range breakers {
+		breaker := &breakers[i]
+		if !breaker.IsEnabled {
+			continue
+		}
+		item := models.SelfTestComplianceItem{
+			BreakerID:   breaker.ID,
+			BreakerName: breaker.BreakerName,
+			Location:    breaker.Location,
+			Supported:   supportsLeakageTest(breaker),
+		}
+		if schedule, ok := scheduleByBreaker[breaker.ID]; ok && schedule.Enabled {
+			item.ScheduleDays = schedule.IntervalDays
+			item.NextRunAt = schedule.NextRunAt
+		}
+		if test, ok := latest[breaker.ID]; ok {
+			started := test.StartedAt
+			item.LastTestedAt = &started
+			item.LastStatus = test.Status
+		}
+		if test, ok := passed[breaker.ID]; ok {
+			started := test.StartedAt
+			days := int(now.Sub(started).Hours() / 24)
+			item.LastPassedAt = &started
+			item.DaysSincePass = &days
+			item.Compliant = days < maxAgeDays
+		}
+
+		report.Total++
+		if item.Compliant {
+			report.Compliant++
+		} else {
+			report.NonCompliant++
+		}
+		report.Items = append(report.Items, item)
+	}
+	return report, nil
+}
+
+// supportsLeakageTest 断路器型号是否支持远程漏电试验
+func supportsLeakageTest(breaker *models.Breaker) bool {
+	driver, err := drivers.Breaker(breaker.Model())
+	return err == nil && drivers.Supports(driver, drivers.CapLeakageTest)
+}
+
+// nextSelfTestRun 推算下次自检时间：距上次自检满 intervalDays 天后（从未执行则从现在起）的第一个 hour 点整，
+// 使用 now 所在时区
+func nextSelfTestRun(now time.Time, last *time.Time, intervalDays, hour int) time.Time {
+	base := now
+	if last != nil {
+		if due := last.AddDate(0, 0, intervalDays); due.After(now) {
+			base = due
+		}
+	}
+	base = base.In(now.Location())
+	next := time.Date(base.Year(), base.Month(), base.Day(), hour, 0, 0, 0, now.Location())
+	if next.Before(base) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next.UTC()
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNextSelfTestRun(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, loc) }
+
+	// 从未执行：今天10点已过，排到明天10点
+	assert.Equal(t, at(11, 10).UTC(), nextSelfTestRun(at(10, 15), nil, 30, 10))
+	// 从未执行：今天10点未到
+	assert.Equal(t, at(10, 10).UTC(), nextSelfTestRun(at(10, 8), nil, 30, 10))
+
+	// 上次在3月1日15点执行，周期7天：3月8日15点到期，当天10点已过，排到3月9日10点
+	last := at(1, 15)
+	assert.Equal(t, at(9, 10).UTC(), nextSelfTestRun(at(2, 9), &last, 7, 10))
+	// 已超期：从现在起的第一个执行时间
+	assert.Equal(t, at(20, 10).UTC(), nextSelfTestRun(at(20, 9), &last, 7, 10))
+}
+
+func TestApproveRecloseGuards(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.Server{}, &models.Breaker{}, &models.BreakerServerBinding{}, &models.BreakerSelfTest{}))
+
+	breaker := models.Breaker{DeviceID: 1, BreakerName: "机柜A1", IPAddress: "192.0.2.10", Port: 502, StationID: 1}
+	require.NoError(t, db.Create(&breaker).Error)
+	require.NoError(t, db.Model(&breaker).UpdateColumn("is_locked", true).Error)
+	pending := func() *models.BreakerSelfTest {
+		test := &models.BreakerSelfTest{BreakerID: breaker.ID, Status: models.SelfTestStatusPassed, RecloseStatus: models.RecloseStatusPending, StartedAt: time.Now().UTC()}
+		require.NoError(t, db.Create(test).Error)
+		return test
+	}
+	service := NewBreakerSelfTestService(db, logger.NewLogger(), 0, 0)
+
+	// 已锁定、不可控制时不能批准，记录保持待批准
+	test := pending()
+	_, err = service.ApproveReclose(test.ID, true, "operator")
+	assert.ErrorContains(t, err, "锁定")
+	require.NoError(t, db.Model(&breaker).Updates(map[string]interface{}{"is_locked": false, "is_controllable": false}).Error)
+	_, err = service.ApproveReclose(test.ID, true, "operator")
+	assert.ErrorContains(t, err, "不可控制")
+	saved, err := service.repo.GetByID(test.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RecloseStatusPending, saved.RecloseStatus)
+
+	// 拒绝后不能再批准
+	rejected, err := service.ApproveReclose(test.ID, false, "operator")
+	require.NoError(t, err)
+	assert.Equal(t, models.RecloseStatusRejected, rejected.RecloseStatus)
+	_, err = service.ApproveReclose(test.ID, true, "operator")
+	assert.Error(t, err)
+
+	// 并发批准只有一个能认领记录
+	repo := repositories.NewBreakerSelfTestRepository(db)
+	test = pending()
+	claimed, err := repo.ClaimReclose(test.ID, models.RecloseStatusClosed)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = repo.ClaimReclose(test.ID, models.RecloseStatusClosed)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+}
@@ -2,10 +2,11 @@ package services
 
 import (
 	"fmt"
-	"net"
 	"smart-device-management/internal/models"
 	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
 	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,8 @@ import (
 type BreakerService struct {
 	breakerRepo          repositories.BreakerRepository
 	serverRepo           repositories.ServerRepository
+	tripRepo             repositories.BreakerTripEventRepository
+	protectionRepo       repositories.BreakerProtectionRepository
 	modbusService        *ModbusService
 	statusMonitorService *StatusMonitorService
 	breakerStatusMonitor *BreakerStatusMonitor
@@ -25,10 +28,12 @@ type BreakerService struct {
 // NewBreakerService 创建断路器服务
 func NewBreakerService(breakerRepo repositories.BreakerRepository, serverRepo repositories.ServerRepository, logger *logger.Logger, db *gorm.DB) *BreakerService {
 	service := &BreakerService{
-		breakerRepo:   breakerRepo,
-		serverRepo:    serverRepo,
-		modbusService: NewModbusService(logger, db),
-		logger:        logger,
+		breakerRepo:    breakerRepo,
+		serverRepo:     serverRepo,
+		tripRepo:       repositories.NewBreakerTripEventRepository(db),
+		protectionRepo: repositories.NewBreakerProtectionRepository(db),
+		modbusService:  NewModbusService(logger, db),
+		logger:         logger,
 	}
 
 	// 创建状态监控服务
@@ -100,6 +105,26 @@ func (s *BreakerService) GetBreakerRealTimeData(id uint) (*models.BreakerRealTim
 	return s.getDefaultRealTimeData(breaker), nil
 }
 
+// GetTripEvents 分页获取断路器跳闸事件
+func (s *BreakerService) GetTripEvents(id uint, page, size int) (*models.BreakerTripEventListResponse, error) {
+	if _, err := s.breakerRepo.GetByID(id); err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+
+	events, total, err := s.tripRepo.ListByBreaker(id, page, size)
+	if err != nil {
+		s.logger.Error("获取跳闸事件失败", "breaker_id", id, "error", err)
+		return nil, fmt.Errorf("获取跳闸事件失败: %w", err)
+	}
+
+	return &models.BreakerTripEventListResponse{
+		Events: events,
+		Total:  total,
+		Page:   page,
+		Size:   size,
+	}, nil
+}
+
 // readModbusData 读取MODBUS数据
 func (s *BreakerService) readModbusData(breaker *models.Breaker) (*models.BreakerRealTimeData, error) {
 	// 使用MODBUS服务读取真实数据
@@ -241,27 +266,22 @@ func (s *BreakerService) GetBreaker(id uint) (*models.Breaker, error) {
 
 // CreateBreaker 创建断路器
 func (s *BreakerService) CreateBreaker(req models.CreateBreakerRequest) (*models.Breaker, error) {
-	s.logger.Info("创建断路器", "breaker_name", req.BreakerName, "ip_address", req.IPAddress)
-
-	// 检查IP地址是否已存在
-	existing, err := s.breakerRepo.GetByIPAddress(req.IPAddress, req.Port)
-	if err == nil && existing != nil {
-		return nil, fmt.Errorf("IP地址 %s:%d 已被使用", req.IPAddress, req.Port)
-	}
+	s.logger.Info("创建断路器", "breaker_name", req.BreakerName, "ip_address", req.IPAddress, "serial_device", req.SerialDevice)
 
-	// 测试连接
-	if err := s.testConnection(req.IPAddress, req.Port); err != nil {
-		s.logger.Warn("断路器连接测试失败", "ip_address", req.IPAddress, "port", req.Port, "error", err)
-		// 不阻止创建，只是记录警告
+	// 按型号选择驱动，未填写时使用默认型号
+	driver, err := drivers.Breaker(req.DeviceModel)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建设备记录
 	device := &models.Device{
-		DeviceName: req.BreakerName,
-		DeviceType: models.DeviceTypeBreaker,
-		IPAddress:  req.IPAddress,
-		Port:       req.Port,
-		Status:     models.DeviceStatusOffline,
+		DeviceName:  req.BreakerName,
+		DeviceType:  models.DeviceTypeBreaker,
+		DeviceModel: driver.Info().Model,
+		IPAddress:   req.IPAddress,
+		Port:        req.Port,
+		Status:      models.DeviceStatusOffline,
 	}
 
 	// 设置默认值
@@ -275,12 +295,19 @@ func (s *BreakerService) CreateBreaker(req models.CreateBreakerRequest) (*models
 		stationID = 1 // 默认站号
 	}
 
+	framing := req.Framing
+	if framing == "" {
+		framing = string(modbus.FramingMBAP)
+	}
+
 	// 创建断路器
 	breaker := &models.Breaker{
 		BreakerName:    req.BreakerName,
 		IPAddress:      req.IPAddress,
 		Port:           port,
 		StationID:      stationID,
+		Framing:        framing,
+		SerialSettings: req.SerialSettings,
 		RatedVoltage:   req.RatedVoltage,
 		RatedCurrent:   req.RatedCurrent,
 		AlarmCurrent:   req.AlarmCurrent,
@@ -291,6 +318,21 @@ func (s *BreakerService) CreateBreaker(req models.CreateBreakerRequest) (*models
 		Description:    req.Description,
 	}
 
+	// 校验连接参数并检查地址冲突
+	endpoint, err := breakerEndpoint(breaker)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAddressConflict(breaker); err != nil {
+		return nil, err
+	}
+
+	// 测试连接
+	if err := s.testConnection(endpoint); err != nil {
+		s.logger.Warn("断路器连接测试失败", "endpoint", modbus.GatewayKey(endpoint), "error", err)
+		// 不阻止创建，只是记录警告
+	}
+
 	// 保存到数据库
 	if err := s.breakerRepo.Create(breaker, device); err != nil {
 		s.logger.Error("创建断路器失败", "error", err)
@@ -313,18 +355,6 @@ func (s *BreakerService) UpdateBreaker(id uint, req models.UpdateBreakerRequest)
 		return nil, fmt.Errorf("断路器不存在: %w", err)
 	}
 
-	// 检查IP地址冲突
-	if req.IPAddress != "" && req.IPAddress != breaker.IPAddress {
-		port := req.Port
-		if port == 0 {
-			port = breaker.Port
-		}
-		existing, err := s.breakerRepo.GetByIPAddress(req.IPAddress, port)
-		if err == nil && existing != nil && existing.ID != id {
-			return nil, fmt.Errorf("IP地址 %s:%d 已被使用", req.IPAddress, port)
-		}
-	}
-
 	// 更新字段
 	if req.BreakerName != "" {
 		breaker.BreakerName = req.BreakerName
@@ -338,6 +368,33 @@ func (s *BreakerService) UpdateBreaker(id uint, req models.UpdateBreakerRequest)
 	if req.StationID > 0 {
 		breaker.StationID = req.StationID
 	}
+	if req.Framing != "" {
+		breaker.Framing = req.Framing
+	}
+	if req.SerialDevice != "" {
+		breaker.SerialDevice = req.SerialDevice
+	}
+	if req.BaudRate > 0 {
+		breaker.BaudRate = req.BaudRate
+	}
+	if req.Parity != "" {
+		breaker.Parity = req.Parity
+	}
+	if req.StopBits > 0 {
+		breaker.StopBits = req.StopBits
+	}
+	if _, err := breakerEndpoint(breaker); err != nil {
+		return nil, err
+	}
+	if err := s.checkAddressConflict(breaker); err != nil {
+		return nil, err
+	}
+	var driver drivers.Driver
+	if req.DeviceModel != "" {
+		if driver, err = drivers.Breaker(req.DeviceModel); err != nil {
+			return nil, err
+		}
+	}
 	if req.RatedVoltage != nil {
 		breaker.RatedVoltage = req.RatedVoltage
 	}
@@ -361,6 +418,13 @@ func (s *BreakerService) UpdateBreaker(id uint, req models.UpdateBreakerRequest)
 		return nil, fmt.Errorf("更新断路器失败: %w", err)
 	}
 
+	if driver != nil {
+		if err := s.breakerRepo.UpdateDeviceModel(breaker, driver.Info().Model); err != nil {
+			s.logger.Error("更新断路器型号失败", "breaker_id", id, "error", err)
+			return nil, fmt.Errorf("更新断路器型号失败: %w", err)
+		}
+	}
+
 	s.logger.Info("成功更新断路器", "breaker_id", id)
 	return breaker, nil
 }
@@ -447,14 +511,29 @@ func (s *BreakerService) GetControlStatus(controlID string) (*models.BreakerCont
 	return control, nil
 }
 
-// testConnection 测试连接
-func (s *BreakerService) testConnection(ipAddress string, port int) error {
-	timeout := 5 * time.Second
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ipAddress, port), timeout)
-	if err != nil {
+// testConnection 经网关调度器测试连接，不另开连接占用网关端口或串口
+func (s *BreakerService) testConnection(endpoint modbus.Config) error {
+	if err := modbus.DefaultGatewayPool().Gateway(endpoint).Connect(modbus.PriorityNormal); err != nil {
 		return fmt.Errorf("连接失败: %w", err)
 	}
-	defer conn.Close()
+	return nil
+}
+
+// checkAddressConflict 检查断路器地址是否被其他断路器占用：
+// 串口RTU按 串口设备+站号 判断，TCP类报文格式按 IP:端口+站号 判断
+func (s *BreakerService) checkAddressConflict(breaker *models.Breaker) error {
+	if breaker.Framing == string(modbus.FramingRTU) {
+		existing, err := s.breakerRepo.GetBySerialDevice(breaker.SerialDevice, breaker.StationID)
+		if err == nil && existing != nil && existing.ID != breaker.ID {
+			return fmt.Errorf("串口 %s 站号 %d 已被断路器 %s 使用", breaker.SerialDevice, breaker.StationID, existing.BreakerName)
+		}
+		return nil
+	}
+
+	existing, err := s.breakerRepo.GetByIPAddress(breaker.IPAddress, breaker.Port, breaker.StationID)
+	if err == nil && existing != nil && existing.ID != breaker.ID {
+		return fmt.Errorf("网关 %s:%d 站号 %d 已被断路器 %s 使用", breaker.IPAddress, breaker.Port, breaker.StationID, existing.BreakerName)
+	}
 	return nil
 }
 
@@ -466,7 +545,11 @@ func (s *BreakerService) detectHardwareInfo(breaker *models.Breaker) {
 	// 目前先更新连接状态
 	time.Sleep(2 * time.Second) // 模拟检测时间
 
-	if err := s.testConnection(breaker.IPAddress, breaker.Port); err == nil {
+	endpoint, err := breakerEndpoint(breaker)
+	if err == nil {
+		err = s.testConnection(endpoint)
+	}
+	if err == nil {
 		// 连接成功，更新状态
 		now := time.Now()
 		breaker.Status = models.SwitchStatusOff // 默认分闸状态
@@ -9,6 +9,8 @@ import (
 
 	"smart-device-management/internal/models"
 	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/websocket"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -19,6 +21,7 @@ type BreakerStatusMonitor struct {
 	db           *gorm.DB
 	logger       *logrus.Logger
 	breakerRepo  repositories.BreakerRepository
+	tripRepo     repositories.BreakerTripEventRepository
 	modbusService *ModbusService
 	ticker       *time.Ticker
 	stopChan     chan bool
@@ -41,6 +44,7 @@ func NewBreakerStatusMonitor(
 		db:            db,
 		logger:        logger,
 		breakerRepo:   breakerRepo,
+		tripRepo:      repositories.NewBreakerTripEventRepository(db),
 		modbusService: modbusService,
 		interval:      30 * time.Second, // 默认30秒检查一次
 		maxRetries:    3,
@@ -251,6 +255,11 @@ func (m *BreakerStatusMonitor) checkSingleBreaker(breaker *models.Breaker) {
 		lockChanged = true
 	}
 
+	// 合闸→分闸：读取设备跳闸原因并记录跳闸事件
+	if statusChanged && breaker.Status == models.SwitchStatusOn && newSwitchStatus == models.SwitchStatusOff {
+		m.recordTripEvent(breaker)
+	}
+
 	// 只有状态发生变化时才更新数据库
 	if statusChanged || lockChanged {
 		updates := make(map[string]interface{})
@@ -299,6 +308,62 @@ func (m *BreakerStatusMonitor) checkSingleBreaker(breaker *models.Breaker) {
 	}
 }
 
+// recordTripEvent 读取并解析跳闸原因，保存跳闸事件并通过WebSocket推送
+func (m *BreakerStatusMonitor) recordTripEvent(breaker *models.Breaker) {
+	if isSelfTesting(breaker.ID) {
+		m.logger.Info("断路器正在进行漏电自检，跳闸由自检记录", "breaker_id", breaker.ID)
+		return
+	}
+
+	event := &models.BreakerTripEvent{
+		BreakerID:      breaker.ID,
+		PreviousStatus: string(breaker.Status),
+		TrippedAt:      time.Now(),
+		Category:       drivers.TripCategoryUnknown,
+	}
+
+	history, analysis, err := m.modbusService.ReadTripInfo(breaker)
+	if err != nil {
+		// 读取失败也记录事件，避免遗漏跳闸
+		m.logger.Warn("读取断路器跳闸原因失败", "breaker_id", breaker.ID, "error", err)
+		event.Description = fmt.Sprintf("读取跳闸原因失败: %v", err)
+	} else {
+		event.ReasonCode = history.Latest
+		event.TripRecords = history.Records
+		if analysis != nil {
+			// 远程命令分闸已有控制记录，不作为跳闸事件
+			if analysis.Remote {
+				m.logger.Info("断路器为远程命令分闸，不记录跳闸事件", "breaker_id", breaker.ID)
+				return
+			}
+			event.HexCode = analysis.HexCode
+			event.ReasonType = analysis.Type
+			event.Category = analysis.Category
+			event.Reasons = analysis.Reasons
+			event.Description = analysis.Description
+			event.Suggestions = analysis.Suggestions
+		}
+	}
+
+	if err := m.tripRepo.Create(event); err != nil {
+		m.logger.Error("保存断路器跳闸事件失败", "breaker_id", breaker.ID, "error", err)
+		return
+	}
+
+	m.logger.Warn("检测到断路器跳闸",
+		"breaker_id", breaker.ID,
+		"name", breaker.BreakerName,
+		"reason_code", event.HexCode,
+		"reasons", strings.Join(event.Reasons, "+"))
+
+	websocket.BroadcastBreakerTrip(map[string]interface{}{
+		"breaker_id":   breaker.ID,
+		"breaker_name": breaker.BreakerName,
+		"location":     breaker.Location,
+		"event":        event,
+	})
+}
+
 // GetStatus 获取监控状态
 func (m *BreakerStatusMonitor) GetStatus() map[string]interface{} {
 	m.mutex.RLock()
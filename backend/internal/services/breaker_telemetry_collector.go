@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// BreakerTelemetryCollector 断路器遥测采集服务：按采集间隔读取全部启用断路器的遥测并累计电量
+type BreakerTelemetryCollector struct {
+	breakerRepo   repositories.BreakerRepository
+	modbusService *ModbusService
+	energyService *EnergyService
+	logger        *logger.Logger
+
+	interval    time.Duration // 采集间隔
+	retention   time.Duration // 原始采样保留时长
+	lastCleanup time.Time
+
+	mutex      sync.Mutex
+	isRunning  bool
+	collecting bool
+	stopChan   chan struct{}
+}
+
+// NewBreakerTelemetryCollector 创建断路器遥测采集服务
+func NewBreakerTelemetryCollector(db *gorm.DB, logger *logger.Logger, modbusService *ModbusService, interval, retention time.Duration) *BreakerTelemetryCollector {
+	if interval < 5*time.Second {
+		interval = 5 * time.Second
+	}
+	return &BreakerTelemetryCollector{
+		breakerRepo:   repositories.NewBreakerRepository(db),
+		modbusService: modbusService,
+		energyService: NewEnergyService(db, logger),
+		logger:        logger,
+		interval:      interval,
+		retention:     retention,
+	}
+}
+
+// Start 启动采集
+func (c *BreakerTelemetryCollector) Start() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.isRunning {
+		return fmt.Errorf("遥测采集已在运行")
+	}
+
+	c.isRunning = true
+	c.stopChan = make(chan struct{})
+	go c.loop(c.stopChan)
+
+	c.logger.Info("启动断路器遥测采集", "interval", c.interval.String(), "retention", c.retention.String())
+	return nil
+}
+
+// Stop 停止采集
+func (c *BreakerTelemetryCollector) Stop() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.isRunning {
+		return fmt.Errorf("遥测采集未在运行")
+	}
+
+	close(c.stopChan)
+	c.isRunning = false
+	c.logger.Info("停止断路器遥测采集")
+	return nil
+}
+
+func (c *BreakerTelemetryCollector) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.collectAll()
+	for {
+		select {
+		case <-ticker.C:
+			c.collectAll()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// collectAll 并发采集所有启用的断路器，上一轮未完成时跳过本轮
+func (c *BreakerTelemetryCollector) collectAll() {
+	c.mutex.Lock()
+	if c.collecting {
+		c.mutex.Unlock()
+		c.logger.Warn("上一轮遥测采集尚未完成，跳过本轮")
+		return
+	}
+	c.collecting = true
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		c.collecting = false
+		c.mutex.Unlock()
+	}()
+
+	breakers, err := c.breakerRepo.GetEnabledBreakers()
+	if err != nil {
+		c.logger.Error("获取断路器列表失败", "error", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, breaker := range breakers {
+		wg.Add(1)
+		go func(b *models.Breaker) {
+			defer wg.Done()
+			c.collectOne(b)
+		}(breaker)
+	}
+	wg.Wait()
+
+	if time.Since(c.lastCleanup) >= 24*time.Hour {
+		c.energyService.CleanupSamples(c.retention)
+		c.lastCleanup = time.Now()
+	}
+}
+
+func (c *BreakerTelemetryCollector) collectOne(breaker *models.Breaker) {
+	telemetry, err := c.modbusService.readTelemetry(breaker)
+	if err != nil {
+		c.logger.Debug("读取断路器遥测失败", "breaker_id", breaker.ID, "error", err)
+		return
+	}
+
+	if _, err := c.energyService.RecordSample(breaker.ID, telemetry, time.Now(), c.interval); err != nil {
+		c.logger.Error("保存断路器遥测失败", "breaker_id", breaker.ID, "error", err)
+	}
+}
+
+// GetStatus 获取采集状态
+func (c *BreakerTelemetryCollector) GetStatus() map[string]interface{} {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return map[string]interface{}{
+		"is_running": c.isRunning,
+		"interval":   c.interval.String(),
+		"retention":  c.retention.String(),
+	}
+}
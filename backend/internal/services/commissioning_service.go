@@ -0,0 +1,563 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+
+	"gorm.io/gorm"
+)
+
+const (
+	commissionProbeTimeout  = 300 * time.Millisecond // 扫描时单帧响应超时，无应答的站号需要快速跳过
+	commissionProbeAttempts = 3                      // 有应答的站号重复读取次数
+	commissionScanEnd       = 32                     // 未指定范围时默认扫描 1-32
+	commissionSettleDelay   = 500 * time.Millisecond // 写入通信参数后等待设备生效再回读
+)
+
+// 站号探测结果
+const (
+	ProbeStatusOK         = "ok"          // 每次读取都成功且通信参数一致
+	ProbeStatusConflict   = "conflict"    // 疑似多台设备使用同一站号
+	ProbeStatusError      = "error"       // 有应答但无法识别（异常响应、站号寄存器不一致）
+	ProbeStatusNoResponse = "no_response" // 无设备应答
+)
+
+// StationProbe 单个站号的探测结果
+type StationProbe struct {
+	StationID int                      `json:"station_id"`
+	Status    string                   `json:"status"`
+	Attempts  int                      `json:"attempts"`
+	Responses int                      `json:"responses"` // 成功读取次数
+	Timeouts  int                      `json:"timeouts"`
+	Corrupted int                      `json:"corrupted"` // CRC错误或响应格式错误次数
+	Identity  *drivers.BreakerIdentity `json:"identity,omitempty"`
+	Detail    string                   `json:"detail,omitempty"`
+	// 已登记在该网关该站号上的断路器
+	RegisteredBreakerID   *uint  `json:"registered_breaker_id,omitempty"`
+	RegisteredBreakerName string `json:"registered_breaker_name,omitempty"`
+}
+
+// CommissioningScanResult 站号扫描结果，只列出有应答或疑似冲突的站号
+type CommissioningScanResult struct {
+	Gateway     string         `json:"gateway"`
+	DeviceModel string         `json:"device_model"`
+	Scanned     int            `json:"scanned"`
+	Found       int            `json:"found"`
+	Conflicts   int            `json:"conflicts"`
+	Stations    []StationProbe `json:"stations"`
+	DurationMs  int64          `json:"duration_ms"`
+}
+
+// CommissionStep 投运步骤记录
+type CommissionStep struct {
+	Step    string `json:"step"`
+	Success bool   `json:"success"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// CommissionResult 单台断路器投运结果
+type CommissionResult struct {
+	Gateway          string                   `json:"gateway"`
+	StationID        int                      `json:"station_id"` // 投运后的站号
+	Identity         *drivers.BreakerIdentity `json:"identity,omitempty"`
+	AddressChanged   bool                     `json:"address_changed"`
+	BaudRateChanged  bool                     `json:"baud_rate_changed"`
+	BaudRateVerified bool                     `json:"baud_rate_verified"`
+	Steps            []CommissionStep         `json:"steps"`
+	Warnings         []string                 `json:"warnings,omitempty"`
+	Breaker          *models.Breaker          `json:"breaker,omitempty"`
+}
+
+func (r *CommissionResult) step(name string, err error, detail string) {
+	step := CommissionStep{Step: name, Success: err == nil, Detail: detail}
+	if err != nil {
+		step.Detail = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// commissionTarget 解析后的投运端口
+type commissionTarget struct {
+	cfg          modbus.Config
+	key          string
+	model        string
+	commissioner drivers.Commissioner
+	session      *modbus.GatewaySession // 投运期间对端口的独占会话
+}
+
+// CommissioningService 新断路器投运调试：扫描网关端口上的应答站号并识别地址冲突，
+// 修改站号（40001）和波特率（40002）后回读确认，最后登记为断路器
+type CommissioningService struct {
+	breakerService *BreakerService
+	breakerRepo    repositories.BreakerRepository
+	logger         *logger.Logger
+	probeTimeout   time.Duration
+	settleDelay    time.Duration
+
+	locks sync.Map // 网关标识 -> *sync.Mutex，同一总线同时只允许一个投运操作
+}
+
+// NewCommissioningService 创建投运调试服务
+func NewCommissioningService(breakerService *BreakerService, db *gorm.DB, logger *logger.Logger) *CommissioningService {
+	return &CommissioningService{
+		breakerService: breakerService,
+		breakerRepo:    repositories.NewBreakerRepository(db),
+		logger:         logger,
+		probeTimeout:   commissionProbeTimeout,
+		settleDelay:    commissionSettleDelay,
+	}
+}
+
+// Scan 逐个站号读取通信参数。有应答的站号重复读取多次：
+// 多台设备同时应答时总线上的帧会损坏，表现为CRC错误、时有时无或读数前后不一致
+func (s *CommissioningService) Scan(req models.CommissioningScanRequest) (*CommissioningScanResult, error) {
+	target, err := s.resolve(req.CommissioningEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	stations, err := scanStations(req)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := req.Attempts
+	if attempts == 0 {
+		attempts = commissionProbeAttempts
+	}
+	timeout := s.probeTimeout
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+
+	release, err := s.reserve(target)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.breakerService.testConnection(target.cfg); err != nil {
+		return nil, err
+	}
+	registered, err := s.registeredStations(target.key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("开始扫描站号", "gateway", target.key, "stations", len(stations), "attempts", attempts)
+	start := time.Now()
+	result := &CommissioningScanResult{
+		Gateway:     target.key,
+		DeviceModel: target.model,
+		Scanned:     len(stations),
+		Stations:    []StationProbe{},
+	}
+	for _, station := range stations {
+		probe := s.probe(target, station, attempts, timeout)
+		if probe.Status == ProbeStatusNoResponse {
+			continue
+		}
+		if breaker, ok := registered[station]; ok {
+			id := breaker.ID
+			probe.RegisteredBreakerID = &id
+			probe.RegisteredBreakerName = breaker.BreakerName
+		}
+		result.Found++
+		if probe.Status == ProbeStatusConflict {
+			result.Conflicts++
+		}
+		result.Stations = append(result.Stations, probe)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	s.logger.Info("站号扫描完成", "gateway", target.key, "found", result.Found, "conflicts", result.Conflicts, "duration_ms", result.DurationMs)
+	return result, nil
+}
+
+// Commission 投运单台断路器：确认当前站号只有一台设备应答，按需修改站号和波特率并回读确认，最后登记。
+// 出错时返回已完成的步骤，便于现场排查
+func (s *CommissioningService) Commission(req models.CommissionBreakerRequest, username string) (*CommissionResult, error) {
+	target, err := s.resolve(req.CommissioningEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := s.reserve(target)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.breakerService.testConnection(target.cfg); err != nil {
+		return nil, err
+	}
+	registered, err := s.registeredStations(target.key)
+	if err != nil {
+		return nil, err
+	}
+	if breaker, ok := registered[req.StationID]; ok {
+		return nil, fmt.Errorf("站号 %d 已登记为断路器 %s，新设备请使用未占用的站号", req.StationID, breaker.BreakerName)
+	}
+
+	s.logger.Info("开始投运断路器", "gateway", target.key, "station_id", req.StationID,
+		"new_station_id", req.NewStationID, "new_baud_rate", req.NewBaudRate, "username", username)
+	result := &CommissionResult{Gateway: target.key, StationID: req.StationID}
+
+	// 1. 当前站号必须只有一台设备应答，否则写站号会同时改掉所有设备
+	probe := s.probe(target, req.StationID, commissionProbeAttempts, s.probeTimeout)
+	if probe.Status != ProbeStatusOK {
+		err := fmt.Errorf("站号 %d 探测结果为 %s: %s", req.StationID, probe.Status, probeDetail(probe))
+		if probe.Status == ProbeStatusConflict {
+			err = fmt.Errorf("站号 %d 疑似有多台设备应答（%s），请只保留一台新设备接入总线后再投运", req.StationID, probe.Detail)
+		}
+		result.step("identify", err, "")
+		return result, err
+	}
+	result.Identity = probe.Identity
+	result.step("identify", nil, fmt.Sprintf("站号 %d，波特率 %d", probe.Identity.StationAddress, probe.Identity.BaudRate))
+
+	// 2. 修改站号
+	if req.NewStationID != 0 && req.NewStationID != req.StationID {
+		if err := s.changeAddress(target, registered, req.StationID, req.NewStationID, result); err != nil {
+			return result, err
+		}
+	}
+
+	// 3. 修改波特率
+	if req.NewBaudRate != 0 && req.NewBaudRate != result.Identity.BaudRate {
+		if err := s.changeBaudRate(target, req.NewBaudRate, result); err != nil {
+			return result, err
+		}
+	}
+
+	// 4. 登记断路器
+	if req.Register != nil {
+		serial := req.SerialSettings
+		if result.BaudRateChanged && target.cfg.Framing == modbus.FramingRTU {
+			serial.BaudRate = req.NewBaudRate
+		}
+		breaker, err := s.breakerService.CreateBreaker(models.CreateBreakerRequest{
+			BreakerName:    req.Register.BreakerName,
+			IPAddress:      req.IPAddress,
+			Port:           req.Port,
+			StationID:      result.StationID,
+			Framing:        string(target.cfg.Framing),
+			DeviceModel:    target.model,
+			RatedVoltage:   req.Register.RatedVoltage,
+			RatedCurrent:   req.Register.RatedCurrent,
+			AlarmCurrent:   req.Register.AlarmCurrent,
+			Location:       req.Register.Location,
+			IsControllable: req.Register.IsControllable,
+			Description:    req.Register.Description,
+			SerialSettings: serial,
+		})
+		if err != nil {
+			result.step("register", err, "")
+			return result, err
+		}
+		result.Breaker = breaker
+		result.step("register", nil, fmt.Sprintf("断路器ID %d", breaker.ID))
+	}
+
+	s.logger.Info("断路器投运完成", "gateway", target.key, "station_id", result.StationID,
+		"address_changed", result.AddressChanged, "baud_rate_changed", result.BaudRateChanged, "warnings", len(result.Warnings))
+	return result, nil
+}
+
+// changeAddress 修改站号：目标站号须未登记且无设备应答，写入后在新站号回读确认，并检查原站号是否还有其他设备
+func (s *CommissioningService) changeAddress(target *commissionTarget, registered map[int]models.Breaker, from, to int, result *CommissionResult) error {
+	if breaker, ok := registered[to]; ok {
+		err := fmt.Errorf("目标站号 %d 已登记为断路器 %s", to, breaker.BreakerName)
+		result.step("check_target", err, "")
+		return err
+	}
+	if probe := s.probe(target, to, 2, s.probeTimeout); probe.Status != ProbeStatusNoResponse {
+		err := fmt.Errorf("目标站号 %d 已有设备应答，修改后会形成地址冲突", to)
+		result.step("check_target", err, "")
+		return err
+	}
+	result.step("check_target", nil, fmt.Sprintf("站号 %d 空闲", to))
+
+	// 设备可能先切换站号再应答，写入超时不视为失败，以回读结果为准
+	err := target.commissioner.SetStationAddress(s.client(target, from, s.probeTimeout*2), to)
+	if err != nil && !modbus.IsTimeout(err) {
+		result.step("write_address", err, "")
+		return fmt.Errorf("写入站号失败: %w", err)
+	}
+	detail := ""
+	if err != nil {
+		detail = "写入无应答，以回读结果为准"
+	}
+	result.step("write_address", nil, detail)
+	time.Sleep(s.settleDelay)
+
+	probe := s.probe(target, to, commissionProbeAttempts, s.probeTimeout)
+	if probe.Status != ProbeStatusOK {
+		err := fmt.Errorf("在新站号 %d 上未能确认设备（%s）", to, probeDetail(probe))
+		result.step("verify_address", err, "")
+		return err
+	}
+	result.StationID = to
+	result.AddressChanged = true
+	result.Identity = probe.Identity
+	result.step("verify_address", nil, fmt.Sprintf("站号 %d 回读一致", to))
+
+	// 原站号仍有应答说明总线上还有使用该地址的设备（如另一台未投运的新设备）
+	if old := s.probe(target, from, 2, s.probeTimeout); old.Status != ProbeStatusNoResponse {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("原站号 %d 仍有设备应答，总线上还有其他使用该地址的设备", from))
+	}
+	return nil
+}
+
+// changeBaudRate 修改波特率后在当前链路上回读。设备若立即切换波特率则当前链路无法通信，
+// 需要把网关（或串口）改为新波特率后重新扫描确认，此时不视为失败
+func (s *CommissioningService) changeBaudRate(target *commissionTarget, baud int, result *CommissionResult) error {
+	// 设备切换波特率后旧链路上可能残留错位的字节，投运结束时关闭调度器，下次访问按新的串口参数重新连接
+	target.session.CloseOnRelease()
+
+	err := target.commissioner.SetBaudRate(s.client(target, result.StationID, s.probeTimeout*2), baud)
+	if err != nil && !modbus.IsTimeout(err) {
+		result.step("write_baud_rate", err, "")
+		return fmt.Errorf("写入波特率失败: %w", err)
+	}
+	result.step("write_baud_rate", nil, "")
+	result.BaudRateChanged = true
+	time.Sleep(s.settleDelay)
+
+	probe := s.probe(target, result.StationID, commissionProbeAttempts, s.probeTimeout)
+	switch {
+	case probe.Status == ProbeStatusOK && probe.Identity.BaudRate == baud:
+		result.BaudRateVerified = true
+		result.Identity = probe.Identity
+		result.step("verify_baud_rate", nil, fmt.Sprintf("波特率 %d 回读一致", baud))
+	case probe.Status == ProbeStatusOK:
+		err := fmt.Errorf("波特率回读为 %d，与写入值 %d 不一致", probe.Identity.BaudRate, baud)
+		result.step("verify_baud_rate", err, "")
+		return err
+	default:
+		result.step("verify_baud_rate", nil, "当前链路已无应答，设备可能已切换到新波特率")
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("请将%s波特率改为 %d 后重新扫描站号 %d 确认", linkName(target.cfg), baud, result.StationID))
+	}
+	return nil
+}
+
+// probe 对一个站号读取若干次通信参数并判定结果。前两次均超时视为无设备，不再继续读取
+func (s *CommissioningService) probe(target *commissionTarget, station, attempts int, timeout time.Duration) StationProbe {
+	client := s.client(target, station, timeout)
+
+	var tally probeTally
+	for i := 0; i < attempts; i++ {
+		identity, err := target.commissioner.ReadIdentity(client)
+		tally.add(identity, err)
+		if i == 1 && tally.timeouts == 2 {
+			break
+		}
+	}
+	return classifyProbe(station, tally)
+}
+
+// client 以扫描超时访问独占会话的调度器。TCP网关端口上与轮询、控制请求共用同一连接并按优先级排队；
+// 本地串口按扫描的波特率访问时，以工作波特率轮询的后台任务在投运期间暂停
+func (s *CommissioningService) client(target *commissionTarget, station int, timeout time.Duration) *modbus.ModbusClient {
+	return modbus.NewModbusClientWithTransport(target.session.TransportWithTimeout(modbus.PriorityNormal, timeout), byte(station))
+}
+
+// resolve 校验端口参数并选择支持投运调试的驱动
+func (s *CommissioningService) resolve(endpoint models.CommissioningEndpoint) (*commissionTarget, error) {
+	driver, err := drivers.Breaker(endpoint.DeviceModel)
+	if err != nil {
+		return nil, err
+	}
+	commissioner, ok := driver.(drivers.Commissioner)
+	if !ok || !drivers.Supports(driver, drivers.CapCommission) {
+		return nil, fmt.Errorf("型号 %s 不支持投运调试", driver.Info().Model)
+	}
+
+	port := endpoint.Port
+	if port == 0 {
+		port = 502
+	}
+	cfg, err := modbus.EndpointConfig(endpoint.Framing, endpoint.IPAddress, port, serialConfig(endpoint.SerialSettings))
+	if err != nil {
+		return nil, err
+	}
+	return &commissionTarget{
+		cfg:          cfg,
+		key:          modbus.GatewayKey(cfg),
+		model:        driver.Info().Model,
+		commissioner: commissioner,
+	}, nil
+}
+
+// registeredStations 已登记在该网关端口上的断路器，按站号索引
+func (s *CommissioningService) registeredStations(gatewayKey string) (map[int]models.Breaker, error) {
+	breakers, err := s.breakerRepo.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("获取断路器列表失败: %w", err)
+	}
+	result := make(map[int]models.Breaker)
+	for _, breaker := range breakers {
+		if breakerGatewayKey(&breaker) == gatewayKey {
+			result[breaker.StationID] = breaker
+		}
+	}
+	return result, nil
+}
+
+// reserve 同一总线同时只允许一个投运操作，并在投运期间独占端口：串口参数与后台轮询不同时，
+// 轮询请求返回 ErrGatewayBusy，而不是按各自的参数反复重建调度器、打断扫描
+func (s *CommissioningService) reserve(target *commissionTarget) (func(), error) {
+	value, _ := s.locks.LoadOrStore(target.key, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+
+	session, err := modbus.DefaultGatewayPool().Reserve(target.cfg)
+	if err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("独占网关端口 %s 失败: %w", target.key, err)
+	}
+	target.session = session
+	return func() {
+		session.Release()
+		mu.Unlock()
+	}, nil
+}
+
+// scanStations 扫描的站号列表：优先使用指定列表，否则为起止范围（默认 1-32）
+func scanStations(req models.CommissioningScanRequest) ([]int, error) {
+	if len(req.Stations) > 0 {
+		seen := make(map[int]bool, len(req.Stations))
+		stations := make([]int, 0, len(req.Stations))
+		for _, station := range req.Stations {
+			if !seen[station] {
+				seen[station] = true
+				stations = append(stations, station)
+			}
+		}
+		return stations, nil
+	}
+
+	start, end := req.StartStation, req.EndStation
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 {
+		end = commissionScanEnd
+		if end < start {
+			end = start
+		}
+	}
+	if end < start {
+		return nil, fmt.Errorf("站号范围无效: %d-%d", start, end)
+	}
+	stations := make([]int, 0, end-start+1)
+	for station := start; station <= end; station++ {
+		stations = append(stations, station)
+	}
+	return stations, nil
+}
+
+// probeTally 多次读取的结果统计
+type probeTally struct {
+	attempts   int
+	timeouts   int
+	corrupted  int
+	exceptions int
+	failures   int // 连接失败等其他链路错误
+	lastError  error
+	identities []drivers.BreakerIdentity
+}
+
+func (t *probeTally) add(identity *drivers.BreakerIdentity, err error) {
+	t.attempts++
+	var exception *modbus.ExceptionError
+	switch {
+	case err == nil:
+		t.identities = append(t.identities, *identity)
+		return
+	case modbus.IsTimeout(err):
+		t.timeouts++
+	case errors.Is(err, modbus.ErrCRCMismatch) || errors.Is(err, modbus.ErrInvalidResponse):
+		t.corrupted++
+	case errors.As(err, &exception):
+		t.exceptions++
+	default:
+		t.failures++
+	}
+	t.lastError = err
+}
+
+// classifyProbe 根据多次读取的结果判定站号状态
+func classifyProbe(station int, t probeTally) StationProbe {
+	probe := StationProbe{
+		StationID: station,
+		Attempts:  t.attempts,
+		Responses: len(t.identities),
+		Timeouts:  t.timeouts,
+		Corrupted: t.corrupted,
+	}
+	if len(t.identities) > 0 {
+		identity := t.identities[0]
+		probe.Identity = &identity
+	}
+
+	switch {
+	case probe.Responses == 0 && t.corrupted > 0:
+		probe.Status = ProbeStatusConflict
+		probe.Detail = fmt.Sprintf("%d 次应答损坏（%v），疑似多台设备同时应答", t.corrupted, t.lastError)
+	case probe.Responses == 0 && t.failures > 0:
+		probe.Status = ProbeStatusError
+		probe.Detail = fmt.Sprintf("通信失败: %v", t.lastError)
+	case probe.Responses == 0 && t.exceptions > 0:
+		probe.Status = ProbeStatusError
+		probe.Detail = fmt.Sprintf("设备返回异常响应（%v），可能不是所选型号", t.lastError)
+	case probe.Responses == 0:
+		probe.Status = ProbeStatusNoResponse
+	case probe.Responses < t.attempts:
+		probe.Status = ProbeStatusConflict
+		probe.Detail = fmt.Sprintf("%d 次读取中 %d 次成功，疑似多台设备共用站号或通信不稳定", t.attempts, probe.Responses)
+	case !sameIdentity(t.identities):
+		probe.Status = ProbeStatusConflict
+		probe.Detail = "多次读取的通信参数或分合闸状态不一致，疑似多台设备交替应答"
+	case probe.Identity.StationAddress != station:
+		probe.Status = ProbeStatusError
+		probe.Detail = fmt.Sprintf("站号寄存器为 %d，与应答站号 %d 不一致", probe.Identity.StationAddress, station)
+	default:
+		probe.Status = ProbeStatusOK
+	}
+	return probe
+}
+
+// sameIdentity 多次读取的站号、波特率和分合闸状态是否一致（电压电流会正常波动，不参与比较）
+func sameIdentity(identities []drivers.BreakerIdentity) bool {
+	first := identities[0]
+	for _, identity := range identities[1:] {
+		if identity.StationAddress != first.StationAddress || identity.BaudRate != first.BaudRate || identity.Closed != first.Closed {
+			return false
+		}
+	}
+	return true
+}
+
+func probeDetail(probe StationProbe) string {
+	if probe.Detail != "" {
+		return probe.Detail
+	}
+	return probe.Status
+}
+
+// linkName 需要同步修改波特率的链路
+func linkName(cfg modbus.Config) string {
+	if cfg.Framing == modbus.FramingRTU {
+		return "串口 " + cfg.Serial.Device + " "
+	}
+	return "网关 " + cfg.Address + " 串口"
+}
@@ -0,0 +1,141 @@
+//go:build linux
+
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestCommissioningScanWithPoller 本地串口上按出厂波特率扫描时，以工作波特率轮询的后台任务暂停，
+// 不会反复重建调度器打断扫描；扫描结束后轮询按原参数恢复
+func TestCommissioningScanWithPoller(t *testing.T) {
+	master, slavePath, err := modbus.OpenPTY()
+	if err != nil {
+		t.Skipf("无法创建伪终端: %v", err)
+	}
+	defer master.Close()
+	defer modbus.DefaultGatewayPool().Evict(modbus.Config{Framing: modbus.FramingRTU, Serial: modbus.SerialConfig{Device: slavePath}})
+	// 保持从端打开：调度器重建时从端全部关闭会使主端读取返回 EIO
+	hold, err := os.OpenFile(slavePath, os.O_RDWR|unix.O_NOCTTY, 0)
+	require.NoError(t, err)
+	defer hold.Close()
+
+	// 主端模拟站号1的新断路器；已登记的站号5不应答，轮询请求不会在链路上留下迟到的应答
+	go func() {
+		req := make([]byte, 8)
+		for {
+			if _, err := io.ReadFull(master, req); err != nil {
+				return
+			}
+			if req[0] != 1 {
+				continue
+			}
+			count := binary.BigEndian.Uint16(req[4:6])
+			resp := []byte{1, req[1], byte(count * 2)}
+			for i := uint16(0); i < count; i++ {
+				var value uint16
+				switch {
+				case req[1] == 0x03 && i == 0:
+					value = 1
+				case req[1] == 0x03 && i == 1:
+					value = 9600
+				case req[1] == 0x04 && i == 0:
+					value = 0x00F0
+				}
+				resp = binary.BigEndian.AppendUint16(resp, value)
+			}
+			master.Write(binary.LittleEndian.AppendUint16(resp, modbus.CRC16(resp)))
+		}
+	}()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.Server{}, &models.Breaker{}, &models.BreakerServerBinding{}))
+	log := logger.NewLogger()
+	breakerService := NewBreakerService(repositories.NewBreakerRepository(db), repositories.NewServerRepository(db), log, db)
+	service := NewCommissioningService(breakerService, db, log)
+
+	pollCfg, err := modbus.EndpointConfig("rtu", "", 0, modbus.SerialConfig{Device: slavePath, BaudRate: 19200})
+	require.NoError(t, err)
+	var (
+		mu                 sync.Mutex
+		busy, closed, done int
+	)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			transport := modbus.DefaultGatewayPool().Gateway(pollCfg).TransportWithTimeout(modbus.PriorityPoll, 20*time.Millisecond)
+			_, err := modbus.NewModbusClientWithTransport(transport, 5).ReadInputRegisters(0, 1)
+			mu.Lock()
+			switch {
+			case errors.Is(err, modbus.ErrGatewayBusy):
+				busy++
+			case errors.Is(err, modbus.ErrGatewayClosed):
+				closed++
+			default:
+				done++
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return done > 0
+	}, time.Second, 10*time.Millisecond)
+
+	result, err := service.Scan(models.CommissioningScanRequest{
+		CommissioningEndpoint: models.CommissioningEndpoint{Framing: "rtu", DeviceModel: "LX47LE-125",
+			SerialSettings: models.SerialSettings{SerialDevice: slavePath, BaudRate: 9600}},
+		StartStation: 1, EndStation: 3, TimeoutMs: 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Stations, 1)
+	assert.Equal(t, ProbeStatusOK, result.Stations[0].Status, result.Stations[0].Detail)
+	assert.Equal(t, 9600, result.Stations[0].Identity.BaudRate)
+
+	// 扫描期间轮询被暂停；扫描结束后按 19200 重建调度器继续轮询
+	mu.Lock()
+	pausedBusy, afterScan := busy, done
+	mu.Unlock()
+	assert.Positive(t, pausedBusy)
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return done > afterScan
+	}, time.Second, 10*time.Millisecond)
+	close(stop)
+	wg.Wait()
+	assert.LessOrEqual(t, closed, 1, "只有开始独占时正在排队的一次轮询被打断")
+
+	session, err := modbus.DefaultGatewayPool().Reserve(pollCfg)
+	require.NoError(t, err, "上一次独占已释放")
+	session.Release()
+}
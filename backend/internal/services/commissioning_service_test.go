@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/modbus"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestClassifyProbe(t *testing.T) {
+	identity := &drivers.BreakerIdentity{StationAddress: 1, BaudRate: 9600, Closed: true}
+	timeout := fmt.Errorf("读取MODBUS响应失败: %w", timeoutError{})
+	crc := fmt.Errorf("读取MODBUS响应失败: %w", modbus.ErrCRCMismatch)
+
+	tally := func(results ...error) probeTally {
+		var t probeTally
+		for _, err := range results {
+			if err == nil {
+				t.add(identity, nil)
+			} else {
+				t.add(nil, err)
+			}
+		}
+		return t
+	}
+
+	assert.Equal(t, ProbeStatusOK, classifyProbe(1, tally(nil, nil, nil)).Status)
+	assert.Equal(t, ProbeStatusNoResponse, classifyProbe(1, tally(timeout, timeout)).Status)
+
+	// 应答时有时无、CRC错误：疑似多台设备同时应答
+	assert.Equal(t, ProbeStatusConflict, classifyProbe(1, tally(nil, timeout, nil)).Status)
+	assert.Equal(t, ProbeStatusConflict, classifyProbe(1, tally(crc, crc, timeout)).Status)
+
+	// 两台设备交替应答，分合闸状态不一致
+	mixed := tally(nil, nil)
+	mixed.add(&drivers.BreakerIdentity{StationAddress: 1, BaudRate: 9600, Closed: false}, nil)
+	assert.Equal(t, ProbeStatusConflict, classifyProbe(1, mixed).Status)
+
+	// 站号寄存器与应答站号不一致
+	assert.Equal(t, ProbeStatusError, classifyProbe(2, tally(nil, nil, nil)).Status)
+	assert.Equal(t, ProbeStatusError, classifyProbe(1, tally(&modbus.ExceptionError{FunctionCode: 0x03, Code: 0x02})).Status)
+}
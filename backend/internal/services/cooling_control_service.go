@@ -0,0 +1,743 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/infrared"
+	"smart-device-management/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// coolingTickInterval 检查各区域是否到达控制周期的间隔
+	coolingTickInterval = 10 * time.Second
+	// defaultCoolingInterval 未配置控制周期时的默认值
+	defaultCoolingInterval = 60 * time.Second
+	// defaultCoolingSensorMaxAge 未配置读数有效时间时的默认值
+	defaultCoolingSensorMaxAge = 120 * time.Second
+	// defaultCoolingMinSetPoint、defaultCoolingMaxSetPoint 未配置时的设定温度范围
+	defaultCoolingMinSetPoint = 18
+	defaultCoolingMaxSetPoint = 26
+	// coolingDecisionRetention 决策记录保留时间
+	coolingDecisionRetention = 30 * 24 * time.Hour
+)
+
+// CoolingControlService 制冷闭环控制：按控制周期读取区域温度，根据目标温度、死区或 PID 输出
+// 启停空调或调整设定温度，每次决策都记录到 cooling_decisions。启用闭环或人工接管的区域才会下发指令。
+type CoolingControlService struct {
+	db                  *gorm.DB
+	logger              *logger.Logger
+	irControllerService *IRControllerService
+
+	// runMutex 保证同一时间只有一次决策，避免与接口修改区域运行状态交错
+	runMutex  sync.Mutex
+	lastPrune time.Time
+
+	mutex     sync.Mutex
+	isRunning bool
+	stopChan  chan struct{}
+}
+
+// NewCoolingControlService 创建制冷闭环控制服务
+func NewCoolingControlService(db *gorm.DB, logger *logger.Logger, irControllerService *IRControllerService) *CoolingControlService {
+	return &CoolingControlService{
+		db:                  db,
+		logger:              logger,
+		irControllerService: irControllerService,
+	}
+}
+
+// Start 启动制冷闭环控制
+func (s *CoolingControlService) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("制冷闭环控制已在运行")
+	}
+
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	go s.loop(s.stopChan)
+
+	s.logger.Info("启动制冷闭环控制", "interval", coolingTickInterval.String())
+	return nil
+}
+
+// Stop 停止制冷闭环控制
+func (s *CoolingControlService) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning {
+		return fmt.Errorf("制冷闭环控制未在运行")
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+	s.logger.Info("停止制冷闭环控制")
+	return nil
+}
+
+func (s *CoolingControlService) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(coolingTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.RunOnce(time.Now().UTC())
+		case <-stop:
+			return
+		}
+	}
+}
+
+// RunOnce 对到达控制周期的区域各做一次决策
+func (s *CoolingControlService) RunOnce(now time.Time) {
+	var zones []models.CoolingZone
+	if err := s.db.Where("is_enabled = ? OR override_mode <> ?", true, "").Find(&zones).Error; err != nil {
+		s.logger.Error("获取制冷区域失败", "error", err)
+		return
+	}
+
+	for i := range zones {
+		zone := &zones[i]
+		if zone.LastRunAt != nil && now.Sub(*zone.LastRunAt) < coolingInterval(zone) {
+			continue
+		}
+		if _, err := s.RunZone(zone.ID, now); err != nil {
+			s.logger.Error("制冷闭环决策失败", "zone_id", zone.ID, "error", err)
+		}
+	}
+
+	if now.Sub(s.lastPrune) >= time.Hour {
+		s.lastPrune = now
+		if err := s.db.Where("created_at < ?", now.Add(-coolingDecisionRetention)).Delete(&models.CoolingDecision{}).Error; err != nil {
+			s.logger.Error("清理制冷决策记录失败", "error", err)
+		}
+	}
+}
+
+// RunZone 对区域做一次决策并下发，返回决策记录。区域未启用且没有人工接管时不做决策，返回 nil
+func (s *CoolingControlService) RunZone(id uint, now time.Time) (*models.CoolingDecision, error) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	zone, err := s.GetZone(id)
+	if err != nil {
+		return nil, err
+	}
+	overrideExpired := zone.OverrideMode != "" && !zone.OverrideActive(now)
+	if overrideExpired {
+		s.logger.Info("制冷区域人工接管到期，恢复闭环", "zone_id", zone.ID, "override_by", zone.OverrideBy)
+		zone.OverrideMode, zone.OverrideStages, zone.OverrideSetPoint, zone.OverrideUntil, zone.OverrideBy = "", 0, 0, nil, ""
+	}
+	if !zone.IsEnabled && zone.OverrideMode == "" {
+		if overrideExpired {
+			return nil, s.saveRuntime(zone)
+		}
+		return nil, nil
+	}
+
+	zone.Units = s.unitStates(zone)
+	s.syncUnitPower(zone, now)
+	temp, valid, err := s.zoneTemperature(zone, now)
+	if err != nil {
+		return nil, err
+	}
+	plan := planCooling(zone, temp, now)
+	if temp != nil {
+		plan.reason = fmt.Sprintf("区域温度 %.1f℃（%d/%d 个通道）、目标 %.1f±%.1f℃，%s",
+			*temp, valid, len(zone.SensorKeys), zone.TargetTemp, zone.Deadband, plan.reason)
+	}
+
+	decision := &models.CoolingDecision{
+		ZoneID:      zone.ID,
+		Temperature: temp,
+		TargetTemp:  zone.TargetTemp,
+		PIDOutput:   plan.output,
+		Action:      plan.action,
+		SetPoint:    plan.setPoint,
+		Reason:      plan.reason,
+		CreatedAt:   now,
+	}
+	commands, applyErr := s.applyPlan(zone, plan, now)
+	decision.Commands = commands
+	decision.StagesOn = countCoolingUnitsOn(zone.Units)
+	if applyErr != nil {
+		decision.Error = applyErr.Error()
+	}
+
+	zone.Integral, zone.LastError, zone.LastOutput = plan.integral, plan.lastError, plan.output
+	zone.LastTemp, zone.LastAction, zone.LastRunAt = temp, plan.action, &now
+	if err := s.saveRuntime(zone); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(decision).Error; err != nil {
+		return nil, fmt.Errorf("保存制冷决策记录失败: %w", err)
+	}
+
+	s.logger.Info("制冷闭环决策", "zone_id", zone.ID, "temperature", temp, "action", decision.Action,
+		"stages_on", decision.StagesOn, "set_point", decision.SetPoint, "commands", commands, "reason", decision.Reason, "error", decision.Error)
+	return decision, nil
+}
+
+// ListZones 获取制冷区域列表
+func (s *CoolingControlService) ListZones() ([]models.CoolingZone, error) {
+	var zones []models.CoolingZone
+	if err := s.db.Order("id ASC").Find(&zones).Error; err != nil {
+		return nil, fmt.Errorf("获取制冷区域列表失败: %w", err)
+	}
+	return zones, nil
+}
+
+// GetZone 获取制冷区域
+func (s *CoolingControlService) GetZone(id uint) (*models.CoolingZone, error) {
+	var zone models.CoolingZone
+	if err := s.db.First(&zone, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("制冷区域不存在")
+		}
+		return nil, fmt.Errorf("获取制冷区域失败: %w", err)
+	}
+	return &zone, nil
+}
+
+// CreateZone 创建制冷区域，创建后闭环默认不启用
+func (s *CoolingControlService) CreateZone(req models.CoolingZoneRequest) (*models.CoolingZone, error) {
+	zone := &models.CoolingZone{}
+	if err := s.applyRequest(zone, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(zone).Error; err != nil {
+		return nil, fmt.Errorf("创建制冷区域失败: %w", err)
+	}
+
+	s.logger.Info("创建制冷区域", "zone_id", zone.ID, "name", zone.Name, "output", zone.Output, "units", zone.UnitIDs)
+	return zone, nil
+}
+
+// UpdateZone 更新制冷区域配置，保留运行状态；PID 参数变化时清零积分
+func (s *CoolingControlService) UpdateZone(id uint, req models.CoolingZoneRequest) (*models.CoolingZone, error) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	zone, err := s.GetZone(id)
+	if err != nil {
+		return nil, err
+	}
+	tuning := [4]float64{zone.TargetTemp, zone.Kp, zone.Ki, zone.Kd}
+	if err := s.applyRequest(zone, req); err != nil {
+		return nil, err
+	}
+	if tuning != [4]float64{zone.TargetTemp, zone.Kp, zone.Ki, zone.Kd} || !zone.PIDEnabled {
+		zone.Integral, zone.LastError = 0, nil
+	}
+	if err := s.db.Save(zone).Error; err != nil {
+		return nil, fmt.Errorf("更新制冷区域失败: %w", err)
+	}
+
+	s.logger.Info("更新制冷区域", "zone_id", zone.ID, "name", zone.Name)
+	return zone, nil
+}
+
+// DeleteZone 删除制冷区域及其决策记录，空调保持当前状态
+func (s *CoolingControlService) DeleteZone(id uint) error {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	zone, err := s.GetZone(id)
+	if err != nil {
+		return err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("zone_id = ?", zone.ID).Delete(&models.CoolingDecision{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(zone).Error
+	})
+	if err != nil {
+		return fmt.Errorf("删除制冷区域失败: %w", err)
+	}
+
+	s.logger.Info("删除制冷区域", "zone_id", zone.ID, "name", zone.Name)
+	return nil
+}
+
+// SetEnabled 启用或停用区域闭环。启用时清零 PID 状态并在下一个检查周期立即决策；
+// 停用后空调保持当前状态
+func (s *CoolingControlService) SetEnabled(id uint, enabled bool, operator string) (*models.CoolingZone, error) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	zone, err := s.GetZone(id)
+	if err != nil {
+		return nil, err
+	}
+	zone.IsEnabled = enabled
+	zone.Integral, zone.LastError, zone.LastRunAt = 0, nil, nil
+	err = s.db.Model(zone).Select("IsEnabled", "Integral", "LastError", "LastRunAt").Updates(zone).Error
+	if err != nil {
+		return nil, fmt.Errorf("更新制冷闭环状态失败: %w", err)
+	}
+
+	s.logger.Info("设置制冷闭环", "zone_id", zone.ID, "enabled", enabled, "operator", operator)
+	return zone, nil
+}
+
+// SetOverride 人工接管区域并立即下发，到期或取消后恢复闭环（闭环未启用时空调保持接管时的状态）
+func (s *CoolingControlService) SetOverride(id uint, req models.CoolingOverrideRequest, operator string) (*models.CoolingDecision, error) {
+	zone, err := s.GetZone(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Mode == models.CoolingOverrideManual && req.Stages > len(zone.UnitIDs) {
+		return nil, fmt.Errorf("运行台数 %d 超过区域空调数 %d", req.Stages, len(zone.UnitIDs))
+	}
+
+	s.runMutex.Lock()
+	now := time.Now().UTC()
+	zone.OverrideMode, zone.OverrideStages, zone.OverrideSetPoint, zone.OverrideBy = req.Mode, req.Stages, req.SetPoint, operator
+	zone.OverrideUntil = nil
+	if req.Minutes > 0 {
+		until := now.Add(time.Duration(req.Minutes) * time.Minute)
+		zone.OverrideUntil = &until
+	}
+	err = s.db.Model(zone).Select("OverrideMode", "OverrideStages", "OverrideSetPoint", "OverrideUntil", "OverrideBy").Updates(zone).Error
+	s.runMutex.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("保存人工接管失败: %w", err)
+	}
+
+	s.logger.Info("人工接管制冷区域", "zone_id", zone.ID, "mode", req.Mode, "stages", req.Stages,
+		"set_point", req.SetPoint, "minutes", req.Minutes, "operator", operator)
+	return s.RunZone(zone.ID, now)
+}
+
+// ClearOverride 取消人工接管，闭环在下一个检查周期恢复决策
+func (s *CoolingControlService) ClearOverride(id uint, operator string) (*models.CoolingZone, error) {
+	s.runMutex.Lock()
+	defer s.runMutex.Unlock()
+
+	zone, err := s.GetZone(id)
+	if err != nil {
+		return nil, err
+	}
+	zone.OverrideMode, zone.OverrideStages, zone.OverrideSetPoint, zone.OverrideUntil, zone.OverrideBy = "", 0, 0, nil, ""
+	zone.LastRunAt = nil
+	err = s.db.Model(zone).Select("OverrideMode", "OverrideStages", "OverrideSetPoint", "OverrideUntil", "OverrideBy", "LastRunAt").Updates(zone).Error
+	if err != nil {
+		return nil, fmt.Errorf("取消人工接管失败: %w", err)
+	}
+
+	s.logger.Info("取消制冷区域人工接管", "zone_id", zone.ID, "operator", operator)
+	return zone, nil
+}
+
+// ListDecisions 获取区域的决策记录，按时间倒序
+func (s *CoolingControlService) ListDecisions(zoneID uint, limit int) ([]models.CoolingDecision, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var decisions []models.CoolingDecision
+	if err := s.db.Where("zone_id = ?", zoneID).Order("id DESC").Limit(limit).Find(&decisions).Error; err != nil {
+		return nil, fmt.Errorf("获取制冷决策记录失败: %w", err)
+	}
+	return decisions, nil
+}
+
+// applyRequest 校验请求并写入区域配置
+func (s *CoolingControlService) applyRequest(zone *models.CoolingZone, req models.CoolingZoneRequest) error {
+	for _, key := range req.SensorKeys {
+		if _, _, err := parseSensorKey(key); err != nil {
+			return err
+		}
+	}
+	minSetPoint, maxSetPoint := req.MinSetPoint, req.MaxSetPoint
+	if minSetPoint == 0 {
+		minSetPoint = defaultCoolingMinSetPoint
+	}
+	if maxSetPoint == 0 {
+		maxSetPoint = defaultCoolingMaxSetPoint
+	}
+	if minSetPoint > maxSetPoint {
+		return fmt.Errorf("设定温度下限 %d℃ 高于上限 %d℃", minSetPoint, maxSetPoint)
+	}
+	if req.PIDEnabled && req.Kp == 0 && req.Ki == 0 {
+		return fmt.Errorf("启用 PID 时 Kp 和 Ki 不能都为0")
+	}
+
+	seen := make(map[uint]bool)
+	for _, unitID := range req.UnitIDs {
+		if seen[unitID] {
+			return fmt.Errorf("红外控制器 %d 重复", unitID)
+		}
+		seen[unitID] = true
+		if _, err := s.irControllerService.GetController(unitID); err != nil {
+			return fmt.Errorf("红外控制器 %d: %w", unitID, err)
+		}
+	}
+	var others []models.CoolingZone
+	if err := s.db.Select("id", "name", "unit_ids").Where("id <> ?", zone.ID).Find(&others).Error; err != nil {
+		return fmt.Errorf("检查制冷区域失败: %w", err)
+	}
+	for _, other := range others {
+		for _, unitID := range other.UnitIDs {
+			if seen[unitID] {
+				return fmt.Errorf("红外控制器 %d 已属于制冷区域 %s", unitID, other.Name)
+			}
+		}
+	}
+
+	zone.Name = req.Name
+	zone.Location = req.Location
+	zone.Description = req.Description
+	zone.SensorKeys = req.SensorKeys
+	zone.Aggregation = req.Aggregation
+	if zone.Aggregation == "" {
+		zone.Aggregation = "avg"
+	}
+	zone.SensorMaxAge = req.SensorMaxAge
+	zone.TargetTemp = req.TargetTemp
+	zone.Deadband = req.Deadband
+	zone.MinOnSeconds = req.MinOnSeconds
+	zone.MinOffSeconds = req.MinOffSeconds
+	zone.Interval = req.Interval
+	zone.Output = req.Output
+	zone.UnitIDs = req.UnitIDs
+	zone.MinSetPoint, zone.MaxSetPoint = minSetPoint, maxSetPoint
+	zone.PIDEnabled = req.PIDEnabled
+	zone.Kp, zone.Ki, zone.Kd = req.Kp, req.Ki, req.Kd
+	zone.Units = s.unitStates(zone)
+	return nil
+}
+
+// unitStates 按区域空调列表整理启停状态：沿用已有记录，新加入的空调取最后一次下发的电源状态
+func (s *CoolingControlService) unitStates(zone *models.CoolingZone) []models.CoolingUnitState {
+	existing := make(map[uint]models.CoolingUnitState, len(zone.Units))
+	for _, unit := range zone.Units {
+		existing[unit.IRControllerID] = unit
+	}
+
+	units := make([]models.CoolingUnitState, 0, len(zone.UnitIDs))
+	for _, unitID := range zone.UnitIDs {
+		unit, ok := existing[unitID]
+		if !ok {
+			unit = models.CoolingUnitState{IRControllerID: unitID}
+			if controller, err := s.irControllerService.GetController(unitID); err == nil && controller.State != nil {
+				unit.On = controller.State.Power
+			}
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+// syncUnitPower 以控制器最后一次下发的电源状态校正启停记录。空调可能被 AI 策略动作或红外接口单独开关，
+// 校正后按当前时刻重新计算最短运行/停机时间，与计划不一致的空调在本周期重新下发
+func (s *CoolingControlService) syncUnitPower(zone *models.CoolingZone, now time.Time) {
+	for i, unit := range zone.Units {
+		controller, err := s.irControllerService.GetController(unit.IRControllerID)
+		if err != nil || controller.State == nil || controller.State.Power == unit.On {
+			continue
+		}
+		s.logger.Warn("空调电源状态已被外部改变，按控制器状态校正", "zone_id", zone.ID,
+			"ir_controller_id", unit.IRControllerID, "recorded_on", unit.On, "power", controller.State.Power)
+		zone.Units[i] = models.CoolingUnitState{IRControllerID: unit.IRControllerID, On: controller.State.Power, ChangedAt: &now}
+	}
+}
+
+// zoneTemperature 区域温度：各通道在有效时间内的最新有效读数按区域聚合方式计算，返回值和有效通道数。
+// 没有有效读数时返回 nil
+func (s *CoolingControlService) zoneTemperature(zone *models.CoolingZone, now time.Time) (*float64, int, error) {
+	// temperature_readings 由服务启动时迁移创建，不存在时按无数据处理
+	if !s.db.Migrator().HasTable("temperature_readings") {
+		return nil, 0, nil
+	}
+	maxAge := defaultCoolingSensorMaxAge
+	if zone.SensorMaxAge > 0 {
+		maxAge = time.Duration(zone.SensorMaxAge) * time.Second
+	}
+
+	var values []float64
+	for _, key := range zone.SensorKeys {
+		sensorID, channel, err := parseSensorKey(key)
+		if err != nil {
+			return nil, 0, err
+		}
+		var readings []float64
+		err = s.db.Table("temperature_readings").Select("temperature").
+			Where("sensor_id = ? AND channel = ? AND status = ? AND recorded_at BETWEEN ? AND ?", sensorID, channel, "normal", now.Add(-maxAge), now).
+			Order("recorded_at DESC").Limit(1).Pluck("temperature", &readings).Error
+		if err != nil {
+			return nil, 0, fmt.Errorf("读取温度失败: %w", err)
+		}
+		values = append(values, readings...)
+	}
+	if len(values) == 0 {
+		return nil, 0, nil
+	}
+
+	result := values[0]
+	if zone.Aggregation == "max" {
+		for _, v := range values[1:] {
+			result = math.Max(result, v)
+		}
+	} else {
+		for _, v := range values[1:] {
+			result += v
+		}
+		result /= float64(len(values))
+	}
+	return &result, len(values), nil
+}
+
+// applyPlan 按计划启停空调和下发设定温度。下发成功的空调才更新启停状态，失败的在下一个周期重试
+func (s *CoolingControlService) applyPlan(zone *models.CoolingZone, plan coolingPlan, now time.Time) (int, error) {
+	commands := 0
+	var firstErr error
+	setPointChanged := plan.setPoint != zone.SetPoint
+	for i, desired := range plan.units {
+		current := zone.Units[i]
+		if desired.On == current.On && !(desired.On && setPointChanged) {
+			continue
+		}
+
+		controller, err := s.irControllerService.GetController(desired.IRControllerID)
+		if err == nil {
+			power := desired.On
+			state := MergeACState(controller, models.ACControlRequest{Power: &power, Mode: infrared.ModeCool, SetPoint: &plan.setPoint})
+			err = s.irControllerService.ApplyACState(controller, state)
+		}
+		commands++
+		if err != nil {
+			s.logger.Error("制冷闭环下发空调指令失败", "zone_id", zone.ID, "ir_controller_id", desired.IRControllerID, "error", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("红外控制器 %d: %w", desired.IRControllerID, err)
+			}
+			continue
+		}
+		if desired.On != current.On {
+			zone.Units[i] = models.CoolingUnitState{IRControllerID: desired.IRControllerID, On: desired.On, ChangedAt: &now}
+		}
+	}
+	if firstErr == nil {
+		zone.SetPoint = plan.setPoint
+	}
+	return commands, firstErr
+}
+
+// saveRuntime 保存区域运行状态和到期清除的人工接管
+func (s *CoolingControlService) saveRuntime(zone *models.CoolingZone) error {
+	err := s.db.Model(zone).Select("Units", "SetPoint", "Integral", "LastError", "LastTemp", "LastOutput", "LastAction", "LastRunAt",
+		"OverrideMode", "OverrideStages", "OverrideSetPoint", "OverrideUntil", "OverrideBy").Updates(zone).Error
+	if err != nil {
+		return fmt.Errorf("保存制冷区域运行状态失败: %w", err)
+	}
+	return nil
+}
+
+// coolingPlan 一次决策的结果
+type coolingPlan struct {
+	action    string
+	reason    string
+	setPoint  int
+	units     []models.CoolingUnitState // 考虑最短运行/停机时间后各空调的目标启停状态
+	output    *float64                  // PID 输出（%）
+	integral  float64
+	lastError *float64
+}
+
+// planCooling 根据区域温度计算目标运行台数和设定温度，再按最短运行/停机时间确定各空调启停。
+// temp 为 nil 表示没有有效读数，保持当前输出
+func planCooling(zone *models.CoolingZone, temp *float64, now time.Time) coolingPlan {
+	minSetPoint, maxSetPoint := zone.MinSetPoint, zone.MaxSetPoint
+	if minSetPoint == 0 {
+		minSetPoint = defaultCoolingMinSetPoint
+	}
+	if maxSetPoint == 0 {
+		maxSetPoint = defaultCoolingMaxSetPoint
+	}
+	total, current := len(zone.Units), countCoolingUnitsOn(zone.Units)
+
+	plan := coolingPlan{action: models.CoolingActionHold, integral: zone.Integral, lastError: zone.LastError}
+	stages := current
+	if zone.Output == models.CoolingOutputStaged {
+		plan.setPoint = minSetPoint
+	} else {
+		plan.setPoint = zone.SetPoint
+		if plan.setPoint == 0 {
+			plan.setPoint = int(math.Round(zone.TargetTemp))
+		}
+		plan.setPoint = clampInt(plan.setPoint, minSetPoint, maxSetPoint)
+	}
+
+	switch {
+	case zone.OverrideActive(now):
+		plan.action = models.CoolingActionOverride
+		if zone.OverrideMode == models.CoolingOverrideOff {
+			stages = 0
+			plan.reason = fmt.Sprintf("%s 人工接管：全部关机", zone.OverrideBy)
+		} else {
+			stages = clampInt(zone.OverrideStages, 0, total)
+			if zone.OverrideSetPoint > 0 {
+				plan.setPoint = zone.OverrideSetPoint
+			}
+			plan.reason = fmt.Sprintf("%s 人工接管：运行 %d 台，设定 %d℃", zone.OverrideBy, stages, plan.setPoint)
+		}
+	case temp == nil:
+		plan.action = models.CoolingActionNoData
+		plan.reason = "没有有效温度读数，保持当前输出"
+	default:
+		e := *temp - zone.TargetTemp
+		if zone.PIDEnabled {
+			output := plan.pid(zone, e, now)
+			plan.output = &output
+			if zone.Output == models.CoolingOutputStaged {
+				stages = int(math.Ceil(output/100*float64(total) - 1e-9))
+			} else {
+				stages = total
+				plan.setPoint = int(math.Round(float64(maxSetPoint) - output/100*float64(maxSetPoint-minSetPoint)))
+			}
+			plan.reason = fmt.Sprintf("PID 输出 %.0f%%", output)
+		} else {
+			switch {
+			case e > zone.Deadband:
+				plan.reason = "高于死区上限"
+				if zone.Output == models.CoolingOutputStaged {
+					stages = min(current+1, total)
+				} else {
+					plan.setPoint = max(plan.setPoint-1, minSetPoint)
+				}
+			case e < -zone.Deadband:
+				plan.reason = "低于死区下限"
+				if zone.Output == models.CoolingOutputStaged {
+					stages = max(current-1, 0)
+				} else {
+					plan.setPoint = min(plan.setPoint+1, maxSetPoint)
+				}
+			default:
+				plan.reason = "在死区内"
+			}
+			if zone.Output == models.CoolingOutputSetPoint {
+				stages = total
+			}
+		}
+	}
+
+	var blocked int
+	plan.units, blocked = stageCoolingUnits(zone, stages, now)
+	running := countCoolingUnitsOn(plan.units)
+	if blocked > 0 {
+		if stages > current {
+			plan.reason += fmt.Sprintf("，%d 台空调未到最短停机时间", blocked)
+		} else {
+			plan.reason += fmt.Sprintf("，%d 台空调未到最短运行时间", blocked)
+		}
+	}
+	if plan.action == models.CoolingActionHold {
+		switch {
+		case running > current:
+			plan.action = models.CoolingActionStageUp
+		case running < current:
+			plan.action = models.CoolingActionStageDown
+		case running > 0 && plan.setPoint != zone.SetPoint:
+			plan.action = models.CoolingActionSetPoint
+		}
+	}
+	plan.reason += fmt.Sprintf("，运行 %d/%d 台，设定 %d℃", running, total, plan.setPoint)
+	return plan
+}
+
+// pid 计算 PID 输出（0-100%）并更新积分和上次误差。误差在死区内时不累计积分；
+// 输出饱和时只丢弃使其更加饱和的积分（条件积分），反方向的误差照常累计以便尽快退出饱和
+func (p *coolingPlan) pid(zone *models.CoolingZone, e float64, now time.Time) float64 {
+	interval := coolingInterval(zone).Seconds()
+	dt := interval
+	if zone.LastRunAt != nil {
+		dt = math.Min(math.Max(now.Sub(*zone.LastRunAt).Seconds(), 1), 10*interval)
+	}
+
+	derivative := 0.0
+	if zone.LastError != nil {
+		derivative = (e - *zone.LastError) / dt
+	}
+	integral := zone.Integral
+	if math.Abs(e) > zone.Deadband {
+		integral += e * dt
+	}
+	output := zone.Kp*e + zone.Ki*integral + zone.Kd*derivative
+	if (output > 100 && e > 0) || (output < 0 && e < 0) {
+		integral = zone.Integral
+		output = zone.Kp*e + zone.Ki*integral + zone.Kd*derivative
+	}
+
+	p.integral, p.lastError = integral, &e
+	return math.Min(math.Max(output, 0), 100)
+}
+
+// stageCoolingUnits 按目标运行台数确定各空调启停：按顺序开机、逆序关机，
+// 跳过未到最短停机/运行时间的空调，返回因此未能启停的台数
+func stageCoolingUnits(zone *models.CoolingZone, stages int, now time.Time) ([]models.CoolingUnitState, int) {
+	units := make([]models.CoolingUnitState, len(zone.Units))
+	copy(units, zone.Units)
+	running := countCoolingUnitsOn(units)
+	elapsed := func(unit models.CoolingUnitState, seconds int) bool {
+		return unit.ChangedAt == nil || now.Sub(*unit.ChangedAt) >= time.Duration(seconds)*time.Second
+	}
+
+	for i := 0; i < len(units) && running < stages; i++ {
+		if !units[i].On && elapsed(units[i], zone.MinOffSeconds) {
+			units[i].On = true
+			running++
+		}
+	}
+	for i := len(units) - 1; i >= 0 && running > stages; i-- {
+		if units[i].On && elapsed(units[i], zone.MinOnSeconds) {
+			units[i].On = false
+			running--
+		}
+	}
+	if running > stages {
+		return units, running - stages
+	}
+	return units, stages - running
+}
+
+// coolingInterval 区域的控制周期
+func coolingInterval(zone *models.CoolingZone) time.Duration {
+	if zone.Interval > 0 {
+		return time.Duration(zone.Interval) * time.Second
+	}
+	return defaultCoolingInterval
+}
+
+func countCoolingUnitsOn(units []models.CoolingUnitState) int {
+	count := 0
+	for _, unit := range units {
+		if unit.On {
+			count++
+		}
+	}
+	return count
+}
+
+func clampInt(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+// parseSensorKey 解析 "传感器ID-通道号" 格式的温度通道
+func parseSensorKey(key string) (uint, int, error) {
+	var sensorID uint
+	var channel int
+	if _, err := fmt.Sscanf(key, "%d-%d", &sensorID, &channel); err != nil {
+		return 0, 0, fmt.Errorf("无效的温度通道: %s，格式应为 传感器ID-通道", key)
+	}
+	return sensorID, channel, nil
+}
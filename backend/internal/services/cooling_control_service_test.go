@@ -0,0 +1,172 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCoolingZoneStagedControl(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.IRController{}, &models.CoolingZone{}, &models.CoolingDecision{}, &testTemperatureReading{}))
+
+	log := logger.NewLogger()
+	irService := NewIRControllerService(db, log, nil, time.Second)
+	var unitIDs []uint
+	var writes []chan []uint16
+	for _, name := range []string{"UPS间1号空调", "UPS间2号空调"} {
+		port, w := startFakeIRController(t)
+		controller, err := irService.CreateController(models.CreateIRControllerRequest{
+			Name: name, Mode: models.IRModeUDP, IPAddress: "127.0.0.1", Port: port, ACBrand: "美的",
+		})
+		require.NoError(t, err)
+		unitIDs = append(unitIDs, controller.ID)
+		writes = append(writes, w)
+	}
+
+	service := NewCoolingControlService(db, log, irService)
+	zone, err := service.CreateZone(models.CoolingZoneRequest{
+		Name: "UPS间", SensorKeys: []string{"31-1"}, TargetTemp: 24, Deadband: 1,
+		MinOnSeconds: 300, Output: models.CoolingOutputStaged, UnitIDs: unitIDs,
+	})
+	require.NoError(t, err)
+	assert.False(t, zone.IsEnabled)
+	_, err = service.CreateZone(models.CoolingZoneRequest{
+		Name: "重复", SensorKeys: []string{"31-1"}, TargetTemp: 24, Output: models.CoolingOutputStaged, UnitIDs: unitIDs[1:],
+	})
+	assert.Error(t, err)
+
+	// 未启用时不决策
+	now := time.Now().UTC()
+	decision, err := service.RunZone(zone.ID, now)
+	require.NoError(t, err)
+	assert.Nil(t, decision)
+
+	_, err = service.SetEnabled(zone.ID, true, "admin")
+	require.NoError(t, err)
+
+	// 高于死区上限，逐台开机
+	require.NoError(t, db.Create(&testTemperatureReading{SensorID: 31, Channel: 1, Temperature: 27, Status: "normal", RecordedAt: now.Add(-10 * time.Second)}).Error)
+	decision, err = service.RunZone(zone.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.CoolingActionStageUp, decision.Action)
+	assert.Equal(t, 1, decision.StagesOn)
+	assert.Equal(t, []uint16{0x00A5, 0x8210, 0x0001}, <-writes[0]) // 开机制冷18℃
+
+	now = now.Add(time.Minute)
+	require.NoError(t, db.Create(&testTemperatureReading{SensorID: 31, Channel: 1, Temperature: 26.5, Status: "normal", RecordedAt: now.Add(-10 * time.Second)}).Error)
+	decision, err = service.RunZone(zone.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.CoolingActionStageUp, decision.Action)
+	assert.Equal(t, 2, decision.StagesOn)
+	<-writes[1]
+
+	// 低于死区下限，但未到最短运行时间，保持运行
+	now = now.Add(time.Minute)
+	require.NoError(t, db.Create(&testTemperatureReading{SensorID: 31, Channel: 1, Temperature: 22, Status: "normal", RecordedAt: now.Add(-10 * time.Second)}).Error)
+	decision, err = service.RunZone(zone.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.CoolingActionHold, decision.Action)
+	assert.Equal(t, 2, decision.StagesOn)
+	assert.Contains(t, decision.Reason, "最短运行时间")
+
+	// 满足最短运行时间后逆序关机
+	now = now.Add(5 * time.Minute)
+	require.NoError(t, db.Create(&testTemperatureReading{SensorID: 31, Channel: 1, Temperature: 22, Status: "normal", RecordedAt: now.Add(-10 * time.Second)}).Error)
+	decision, err = service.RunZone(zone.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, models.CoolingActionStageDown, decision.Action)
+	assert.Equal(t, 1, decision.StagesOn)
+	assert.Equal(t, []uint16{0x00A5, 0x0210, 0x0001}, <-writes[1])
+
+	// 读数过期按无数据保持
+	decision, err = service.RunZone(zone.ID, now.Add(10*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, models.CoolingActionNoData, decision.Action)
+	assert.Nil(t, decision.Temperature)
+
+	decisions, err := service.ListDecisions(zone.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, decisions, 5)
+	saved, err := service.GetZone(zone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, []bool{saved.Units[0].On, saved.Units[1].On})
+
+	// 空调被外部关机后，下一周期按控制器状态校正启停记录
+	require.NoError(t, db.Model(&models.IRController{}).Where("id = ?", unitIDs[0]).
+		Update("state", `{"power":false,"mode":"cool","set_point":18,"fan_speed":"auto"}`).Error)
+	_, err = service.RunZone(zone.ID, now.Add(11*time.Minute))
+	require.NoError(t, err)
+	saved, err = service.GetZone(zone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false}, []bool{saved.Units[0].On, saved.Units[1].On})
+}
+
+func TestPlanCooling(t *testing.T) {
+	now := time.Now().UTC()
+	longAgo, recent := now.Add(-time.Hour), now.Add(-10*time.Second)
+	temp := func(v float64) *float64 { return &v }
+
+	// PID 输出映射到设定温度：误差2℃、Kp=20 → 40% → 26-0.4×8=22.8 → 23℃
+	zone := &models.CoolingZone{
+		TargetTemp: 24, Deadband: 0.5, Output: models.CoolingOutputSetPoint, MinSetPoint: 18, MaxSetPoint: 26,
+		PIDEnabled: true, Kp: 20, SetPoint: 24,
+		Units: []models.CoolingUnitState{{IRControllerID: 1, On: true}, {IRControllerID: 2, On: true}},
+	}
+	plan := planCooling(zone, temp(26), now)
+	require.NotNil(t, plan.output)
+	assert.InDelta(t, 40, *plan.output, 0.01)
+	assert.Equal(t, 23, plan.setPoint)
+	assert.Equal(t, models.CoolingActionSetPoint, plan.action)
+
+	// 人工接管关机，未到最短运行时间的空调保持运行
+	zone = &models.CoolingZone{
+		TargetTemp: 24, Output: models.CoolingOutputStaged, MinOnSeconds: 300, OverrideMode: models.CoolingOverrideOff,
+		Units: []models.CoolingUnitState{{IRControllerID: 1, On: true, ChangedAt: &longAgo}, {IRControllerID: 2, On: true, ChangedAt: &recent}},
+	}
+	plan = planCooling(zone, temp(30), now)
+	assert.Equal(t, models.CoolingActionOverride, plan.action)
+	assert.Equal(t, []bool{false, true}, []bool{plan.units[0].On, plan.units[1].On})
+	assert.Contains(t, plan.reason, "1 台空调未到最短运行时间")
+
+	// 接管到期后恢复闭环；没有读数时保持
+	expired := now.Add(-time.Minute)
+	zone.OverrideUntil = &expired
+	plan = planCooling(zone, nil, now)
+	assert.Equal(t, models.CoolingActionNoData, plan.action)
+	assert.Equal(t, []bool{true, true}, []bool{plan.units[0].On, plan.units[1].On})
+}
+
+func TestCoolingPIDAntiWindup(t *testing.T) {
+	now := time.Now().UTC()
+	last := now.Add(-time.Minute)
+	zone := &models.CoolingZone{TargetTemp: 24, Deadband: 0.5, Interval: 60, Kp: 10, Ki: 1, Integral: 200, LastRunAt: &last}
+
+	// 已饱和且误差仍为正：不再累计积分
+	var plan coolingPlan
+	assert.Equal(t, 100.0, plan.pid(zone, 2, now))
+	assert.Equal(t, 200.0, plan.integral)
+
+	// 温度降到目标以下：虽然输出仍饱和，反向积分照常累计，积分逐步退出饱和
+	assert.Equal(t, 100.0, plan.pid(zone, -0.8, now))
+	assert.InDelta(t, 152, plan.integral, 0.001)
+	for i := 0; i < 3 && plan.integral > 0; i++ {
+		zone.Integral = plan.integral
+		plan.pid(zone, -0.8, now)
+	}
+	assert.Less(t, plan.integral, 100.0)
+	assert.Less(t, plan.pid(zone, -0.8, now), 100.0)
+
+	// 低于下限且误差为负：不再累计
+	zone.Integral = -20
+	assert.Equal(t, 0.0, plan.pid(zone, -2, now))
+	assert.Equal(t, -20.0, plan.integral)
+}
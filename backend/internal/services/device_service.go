@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"smart-device-management/internal/models"
 	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
 	"smart-device-management/pkg/logger"
 	"strconv"
 	"time"
@@ -67,7 +68,7 @@ func (s *DeviceService) GetDevicesByType(deviceType string) ([]models.Device, er
 	s.logger.Info("根据类型获取设备", "device_type", deviceType)
 
 	// 验证设备类型
-	validTypes := []string{"temperature_sensor", "breaker", "server"}
+	validTypes := []string{"temperature_sensor", "breaker", "server", "ir_controller"}
 	isValid := false
 	for _, validType := range validTypes {
 		if deviceType == validType {
@@ -96,7 +97,7 @@ func (s *DeviceService) CreateDevice(req *models.CreateDeviceRequest) (*models.D
 	s.logger.Info("创建设备", "device_name", req.DeviceName, "device_type", req.DeviceType)
 
 	// 验证设备类型
-	validTypes := []models.DeviceType{models.DeviceTypeTemperatureSensor, models.DeviceTypeBreaker, models.DeviceTypeServer}
+	validTypes := []models.DeviceType{models.DeviceTypeTemperatureSensor, models.DeviceTypeBreaker, models.DeviceTypeServer, models.DeviceTypeIRController}
 	isValid := false
 	for _, validType := range validTypes {
 		if req.DeviceType == validType {
@@ -110,6 +111,11 @@ func (s *DeviceService) CreateDevice(req *models.CreateDeviceRequest) (*models.D
 		return nil, errors.New("无效的设备类型")
 	}
 
+	if err := validateDriverConfig(req.DeviceType, req.DeviceModel, req.Config); err != nil {
+		s.logger.Error("设备配置校验失败", "device_model", req.DeviceModel, "error", err)
+		return nil, err
+	}
+
 	// 检查设备名称是否已存在
 	existingDevice, err := s.deviceRepo.FindByName(req.DeviceName)
 	if err != nil {
@@ -202,6 +208,11 @@ func (s *DeviceService) UpdateDevice(id string, req *models.UpdateDeviceRequest)
 	}
 	device.UpdatedAt = time.Now()
 
+	if err := validateDriverConfig(device.DeviceType, device.DeviceModel, device.Config); err != nil {
+		s.logger.Error("设备配置校验失败", "device_id", id, "device_model", device.DeviceModel, "error", err)
+		return nil, err
+	}
+
 	// 保存更新
 	err = s.deviceRepo.Update(device)
 	if err != nil {
@@ -213,6 +224,27 @@ func (s *DeviceService) UpdateDevice(id string, req *models.UpdateDeviceRequest)
 	return device, nil
 }
 
+// validateDriverConfig 断路器、温度传感器和红外控制器按型号查找驱动，并按驱动的配置项说明校验设备配置
+func validateDriverConfig(deviceType models.DeviceType, model string, config models.JSON) error {
+	var kind drivers.Kind
+	switch deviceType {
+	case models.DeviceTypeBreaker:
+		kind = drivers.KindBreaker
+	case models.DeviceTypeTemperatureSensor:
+		kind = drivers.KindTemperatureSensor
+	case models.DeviceTypeIRController:
+		kind = drivers.KindIRController
+	default:
+		return nil
+	}
+
+	driver, err := drivers.ForKind(kind, model)
+	if err != nil {
+		return err
+	}
+	return drivers.ValidateConfig(driver, config)
+}
+
 // DeleteDevice 删除设备
 func (s *DeviceService) DeleteDevice(id string) error {
 	s.logger.Info("删除设备", "device_id", id)
@@ -0,0 +1,811 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+	"smart-device-management/pkg/websocket"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	discoveryMaxHosts      = 4096                   // 单次最多扫描的主机数（/20）
+	discoveryMaxPorts      = 64                     // 单次最多扫描的端口数
+	discoveryMaxJobs       = 20                     // 内存中保留的任务数
+	discoveryDialTimeout   = 500 * time.Millisecond // 端口扫描连接超时
+	discoveryProbeTimeout  = 300 * time.Millisecond // 站号探测单帧响应超时
+	discoveryConcurrency   = 32                     // 默认并发扫描的端口数
+	discoveryProgressEvery = 500 * time.Millisecond // 进度推送最小间隔
+)
+
+// 发现任务状态
+const (
+	DiscoveryStatusRunning   = "running"
+	DiscoveryStatusCompleted = "completed"
+	DiscoveryStatusCancelled = "cancelled"
+)
+
+// 发现任务阶段
+const (
+	DiscoveryPhasePortScan = "port_scan" // TCP端口扫描，找出在线的网关端口
+	DiscoveryPhaseProbe    = "probe"     // 在网关端口上探测站号并识别型号
+	DiscoveryPhaseDone     = "done"
+)
+
+// DiscoveredGateway 发现的网关端口
+type DiscoveredGateway struct {
+	IPAddress  string `json:"ip_address"`
+	Port       int    `json:"port"`
+	Framing    string `json:"framing,omitempty"` // 有设备应答时确定的报文格式
+	Responders int    `json:"responders"`
+}
+
+// DiscoveryCandidate 可导入的候选设备
+type DiscoveryCandidate struct {
+	Index     int          `json:"index"`
+	Kind      drivers.Kind `json:"kind,omitempty"`  // 未识别型号时为空
+	Model     string       `json:"model,omitempty"` // 按寄存器特征识别的型号
+	IPAddress string       `json:"ip_address"`
+	Port      int          `json:"port"`
+	Framing   string       `json:"framing"`
+	StationID int          `json:"station_id"`
+	Detail    string       `json:"detail,omitempty"`
+	// 已登记的同地址设备（断路器或温度传感器）
+	ExistingID   *uint  `json:"existing_id,omitempty"`
+	ExistingName string `json:"existing_name,omitempty"`
+	// 本任务中导入后创建的设备
+	ImportedID *uint `json:"imported_id,omitempty"`
+}
+
+// DiscoveryJob 网络设备发现任务
+type DiscoveryJob struct {
+	ID          string                       `json:"id"`
+	Status      string                       `json:"status"`
+	Phase       string                       `json:"phase"`
+	Request     models.StartDiscoveryRequest `json:"request"`
+	StartedBy   string                       `json:"started_by"`
+	StartedAt   time.Time                    `json:"started_at"`
+	CompletedAt *time.Time                   `json:"completed_at,omitempty"`
+	// 进度：端口扫描阶段为 主机数×端口数，探测阶段为在线端口数
+	Total      int                  `json:"total"`
+	Done       int                  `json:"done"`
+	Gateways   []DiscoveredGateway  `json:"gateways"`
+	Candidates []DiscoveryCandidate `json:"candidates"`
+}
+
+// DiscoveryProgress 通过WebSocket推送的任务进度
+type DiscoveryProgress struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Phase      string `json:"phase"`
+	Total      int    `json:"total"`
+	Done       int    `json:"done"`
+	Gateways   int    `json:"gateways"`
+	Candidates int    `json:"candidates"`
+}
+
+// DiscoveryImportResult 单个候选设备的导入结果
+type DiscoveryImportResult struct {
+	Index int          `json:"index"`
+	Kind  drivers.Kind `json:"kind,omitempty"`
+	ID    uint         `json:"id,omitempty"`
+	Name  string       `json:"name,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+// discoveryRun 运行中的任务
+type discoveryRun struct {
+	job          *DiscoveryJob
+	cancel       context.CancelFunc
+	lastProgress time.Time
+}
+
+// discoveryPlan 解析后的扫描参数
+type discoveryPlan struct {
+	hosts       []string
+	ports       []int
+	stations    []int
+	framings    []modbus.Framing
+	timeout     time.Duration
+	concurrency int
+}
+
+// DiscoveryService 网络设备自动发现：扫描网段内的网关端口，在每个端口上探测站号，
+// 按寄存器特征识别 LX47LE-125 断路器和 18B20 温度模块，供一键导入
+type DiscoveryService struct {
+	db             *gorm.DB
+	breakerRepo    repositories.BreakerRepository
+	breakerService *BreakerService
+	logger         *logger.Logger
+
+	mu   sync.Mutex
+	runs map[string]*discoveryRun
+	// 保留顺序用于淘汰最早的任务
+	order []string
+}
+
+// NewDiscoveryService 创建设备发现服务
+func NewDiscoveryService(breakerService *BreakerService, db *gorm.DB, logger *logger.Logger) *DiscoveryService {
+	return &DiscoveryService{
+		db:             db,
+		breakerRepo:    repositories.NewBreakerRepository(db),
+		breakerService: breakerService,
+		logger:         logger,
+		runs:           make(map[string]*discoveryRun),
+	}
+}
+
+// Start 校验参数并在后台启动发现任务
+func (s *DiscoveryService) Start(req models.StartDiscoveryRequest, username string) (*DiscoveryJob, error) {
+	plan, err := newDiscoveryPlan(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &DiscoveryJob{
+		ID:         uuid.New().String(),
+		Status:     DiscoveryStatusRunning,
+		Phase:      DiscoveryPhasePortScan,
+		Request:    req,
+		StartedBy:  username,
+		StartedAt:  time.Now().UTC(),
+		Total:      len(plan.hosts) * len(plan.ports),
+		Gateways:   []DiscoveredGateway{},
+		Candidates: []DiscoveryCandidate{},
+	}
+
+	s.mu.Lock()
+	for _, run := range s.runs {
+		if run.job.Status == DiscoveryStatusRunning {
+			s.mu.Unlock()
+			cancel()
+			return nil, fmt.Errorf("已有发现任务正在运行: %s", run.job.ID)
+		}
+	}
+	s.runs[job.ID] = &discoveryRun{job: job, cancel: cancel}
+	s.order = append(s.order, job.ID)
+	for len(s.order) > discoveryMaxJobs {
+		delete(s.runs, s.order[0])
+		s.order = s.order[1:]
+	}
+	snapshot := copyDiscoveryJob(job)
+	s.mu.Unlock()
+
+	s.logger.Info("开始网络设备发现", "job_id", job.ID, "cidr", req.CIDR, "hosts", len(plan.hosts),
+		"ports", len(plan.ports), "stations", len(plan.stations), "username", username)
+	go s.run(ctx, job.ID, plan)
+	return snapshot, nil
+}
+
+// Cancel 取消运行中的任务，已发现的结果保留
+func (s *DiscoveryService) Cancel(id string) (*DiscoveryJob, error) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("发现任务不存在: %s", id)
+	}
+	run.cancel()
+	return s.Get(id)
+}
+
+// Get 获取任务快照
+func (s *DiscoveryService) Get(id string) (*DiscoveryJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("发现任务不存在: %s", id)
+	}
+	return copyDiscoveryJob(run.job), nil
+}
+
+// List 获取全部任务（不含候选设备明细），最新的在前
+func (s *DiscoveryService) List() []DiscoveryJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]DiscoveryJob, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		job := *s.runs[s.order[i]].job
+		job.Gateways = nil
+		job.Candidates = nil
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// Import 把选中的候选设备登记为断路器或温度传感器。已登记、未识别或已导入的候选设备跳过并说明原因
+func (s *DiscoveryService) Import(id string, req models.ImportDiscoveryRequest) ([]DiscoveryImportResult, error) {
+	job, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status == DiscoveryStatusRunning {
+		return nil, fmt.Errorf("发现任务仍在运行，请完成或取消后再导入")
+	}
+
+	results := make([]DiscoveryImportResult, 0, len(req.Candidates))
+	for _, index := range req.Candidates {
+		result := DiscoveryImportResult{Index: index}
+		if index < 0 || index >= len(job.Candidates) {
+			result.Error = "候选设备不存在"
+			results = append(results, result)
+			continue
+		}
+		candidate := job.Candidates[index]
+		result.Kind = candidate.Kind
+
+		switch {
+		case candidate.ImportedID != nil:
+			result.Error = "已导入"
+		case candidate.ExistingID != nil:
+			result.Error = fmt.Sprintf("该地址已登记为 %s", candidate.ExistingName)
+		case candidate.Model == "":
+			result.Error = "未识别设备型号"
+		default:
+			result.ID, result.Name, err = s.importCandidate(candidate, req.Location)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				s.markImported(id, index, result.ID)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *DiscoveryService) importCandidate(c DiscoveryCandidate, location string) (uint, string, error) {
+	name := fmt.Sprintf("%s-%s-%d-%d", c.Model, c.IPAddress, c.Port, c.StationID)
+
+	switch c.Kind {
+	case drivers.KindBreaker:
+		breaker, err := s.breakerService.CreateBreaker(models.CreateBreakerRequest{
+			BreakerName:    name,
+			IPAddress:      c.IPAddress,
+			Port:           c.Port,
+			StationID:      c.StationID,
+			Framing:        c.Framing,
+			DeviceModel:    c.Model,
+			Location:       location,
+			IsControllable: true,
+			Description:    "网络自动发现导入",
+		})
+		if err != nil {
+			return 0, "", err
+		}
+		return breaker.ID, breaker.BreakerName, nil
+
+	case drivers.KindTemperatureSensor:
+		reader, err := drivers.TemperatureSensor(c.Model)
+		if err != nil {
+			return 0, "", err
+		}
+		channels := make([]models.TemperatureChannel, 0, reader.Channels())
+		for ch := 1; ch <= reader.Channels(); ch++ {
+			channels = append(channels, models.TemperatureChannel{
+				Channel:  ch,
+				Name:     fmt.Sprintf("通道%d", ch),
+				Enabled:  true,
+				MinTemp:  -35,
+				MaxTemp:  125,
+				Interval: 30,
+			})
+		}
+		sensor := &models.TemperatureSensor{
+			Name:       name,
+			DeviceType: c.Model,
+			IPAddress:  c.IPAddress,
+			Port:       c.Port,
+			SlaveID:    c.StationID,
+			Framing:    c.Framing,
+			Location:   location,
+			Enabled:    true,
+			Channels:   channels,
+		}
+		if err := s.db.Create(sensor).Error; err != nil {
+			return 0, "", fmt.Errorf("创建温度传感器失败: %w", err)
+		}
+		return sensor.ID, sensor.Name, nil
+	}
+	return 0, "", fmt.Errorf("不支持导入的设备类别: %s", c.Kind)
+}
+
+func (s *DiscoveryService) markImported(id string, index int, deviceID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run, ok := s.runs[id]; ok && index < len(run.job.Candidates) {
+		run.job.Candidates[index].ImportedID = &deviceID
+	}
+}
+
+// run 执行发现任务：先并发扫描TCP端口，再并发探测在线端口上的站号
+func (s *DiscoveryService) run(ctx context.Context, id string, plan *discoveryPlan) {
+	existing := s.existingDevices()
+	registered := registeredPorts(existing)
+	open := s.scanPorts(ctx, id, plan, registered)
+
+	s.update(id, true, func(job *DiscoveryJob) {
+		job.Phase = DiscoveryPhaseProbe
+		job.Total = len(open)
+		job.Done = 0
+	})
+
+	sem := make(chan struct{}, plan.concurrency)
+	var wg sync.WaitGroup
+	for _, address := range open {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(address string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			gateway, candidates := s.probeGateway(ctx, address, plan, registered[address])
+			s.update(id, false, func(job *DiscoveryJob) {
+				job.Done++
+				job.Gateways = append(job.Gateways, gateway)
+				for _, candidate := range candidates {
+					if device, ok := existing[discoveryKey(candidate.Kind, candidate.Framing, candidate.IPAddress, candidate.Port, candidate.StationID)]; ok {
+						candidate.ExistingID = &device.id
+						candidate.ExistingName = device.name
+					}
+					candidate.Index = len(job.Candidates)
+					job.Candidates = append(job.Candidates, candidate)
+				}
+			})
+		}(address)
+	}
+	wg.Wait()
+
+	s.update(id, true, func(job *DiscoveryJob) {
+		sort.Slice(job.Gateways, func(i, j int) bool {
+			return lessEndpoint(job.Gateways[i].IPAddress, job.Gateways[i].Port, job.Gateways[j].IPAddress, job.Gateways[j].Port)
+		})
+		sort.Slice(job.Candidates, func(i, j int) bool {
+			a, b := job.Candidates[i], job.Candidates[j]
+			if a.IPAddress != b.IPAddress || a.Port != b.Port {
+				return lessEndpoint(a.IPAddress, a.Port, b.IPAddress, b.Port)
+			}
+			return a.StationID < b.StationID
+		})
+		for i := range job.Candidates {
+			job.Candidates[i].Index = i
+		}
+
+		now := time.Now().UTC()
+		job.CompletedAt = &now
+		job.Phase = DiscoveryPhaseDone
+		job.Status = DiscoveryStatusCompleted
+		if ctx.Err() != nil {
+			job.Status = DiscoveryStatusCancelled
+		}
+		s.logger.Info("网络设备发现结束", "job_id", id, "status", job.Status,
+			"gateways", len(job.Gateways), "candidates", len(job.Candidates))
+	})
+}
+
+// scanPorts 并发尝试建立TCP连接，返回可连接的 host:port。
+// 已登记设备所在的端口直接视为可连接，不额外占用网关的TCP连接数
+func (s *DiscoveryService) scanPorts(ctx context.Context, id string, plan *discoveryPlan, registered map[string][]modbus.Framing) []string {
+	var mu sync.Mutex
+	var open []string
+
+	sem := make(chan struct{}, plan.concurrency*4)
+	var wg sync.WaitGroup
+	dialer := net.Dialer{Timeout: discoveryDialTimeout}
+	for _, host := range plan.hosts {
+		for _, port := range plan.ports {
+			if ctx.Err() != nil {
+				break
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(address string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				_, known := registered[address]
+				if !known {
+					conn, err := dialer.DialContext(ctx, "tcp", address)
+					if err == nil {
+						conn.Close()
+						known = true
+					}
+				}
+				if known {
+					mu.Lock()
+					open = append(open, address)
+					mu.Unlock()
+				}
+				s.update(id, false, func(job *DiscoveryJob) { job.Done++ })
+			}(net.JoinHostPort(host, strconv.Itoa(port)))
+		}
+	}
+	wg.Wait()
+	return open
+}
+
+// probeGateway 按报文格式依次探测站号，首个有设备应答的报文格式即为网关的工作模式。
+// registered 为该端口上已登记设备使用的报文格式，非空时只按这些格式经调度器探测
+func (s *DiscoveryService) probeGateway(ctx context.Context, address string, plan *discoveryPlan, registered []modbus.Framing) (DiscoveredGateway, []DiscoveryCandidate) {
+	host, portText, _ := net.SplitHostPort(address)
+	port, _ := strconv.Atoi(portText)
+	gateway := DiscoveredGateway{IPAddress: host, Port: port}
+
+	framings := plan.framings
+	if len(registered) > 0 {
+		framings = registered
+	}
+	for _, framing := range framings {
+		candidates, err := s.probeStations(ctx, address, framing, plan, len(registered) > 0)
+		if err != nil {
+			s.logger.Debug("网关端口探测失败", "address", address, "framing", framing, "error", err)
+			continue
+		}
+		if len(candidates) > 0 {
+			gateway.Framing = string(framing)
+			gateway.Responders = len(candidates)
+			return gateway, candidates
+		}
+	}
+	return gateway, nil
+}
+
+// probeStations 逐个站号按驱动的寄存器特征识别设备。pooled 为真时端口上已有登记设备，
+// 经网关调度器以轮询优先级排队发送，与轮询、控制共用长连接；否则使用一条专用连接
+func (s *DiscoveryService) probeStations(ctx context.Context, address string, framing modbus.Framing, plan *discoveryPlan, pooled bool) ([]DiscoveryCandidate, error) {
+	var transport modbus.Transport
+	if pooled {
+		// 超时按请求指定，不改变调度器的连接配置
+		gateway := modbus.DefaultGatewayPool().Gateway(modbus.Config{Framing: framing, Address: address})
+		transport = gateway.TransportWithTimeout(modbus.PriorityPoll, plan.timeout)
+	} else {
+		t, err := modbus.Open(modbus.Config{
+			Framing:     framing,
+			Address:     address,
+			DialTimeout: discoveryDialTimeout,
+			Timeout:     plan.timeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer t.Close()
+		transport = t
+	}
+
+	host, portText, _ := net.SplitHostPort(address)
+	port, _ := strconv.Atoi(portText)
+	fingerprinters := discoveryFingerprinters()
+
+	var candidates []DiscoveryCandidate
+	for _, station := range plan.stations {
+		if ctx.Err() != nil {
+			break
+		}
+		client := modbus.NewModbusClientWithTransport(transport, byte(station))
+
+		// 依次尝试各型号的寄存器特征，任一读取有应答即说明该站号有设备
+		responded := false
+		var identified *DiscoveryCandidate
+		for _, fp := range fingerprinters {
+			matched, detail, err := fp.fingerprinter.Fingerprint(client, station)
+			if !discoveryResponded(err) {
+				continue
+			}
+			responded = true
+			if matched {
+				identified = &DiscoveryCandidate{
+					Kind:      fp.info.Kind,
+					Model:     fp.info.Model,
+					IPAddress: host,
+					Port:      port,
+					Framing:   string(framing),
+					StationID: station,
+					Detail:    detail,
+				}
+				break
+			}
+		}
+		switch {
+		case identified != nil:
+			candidates = append(candidates, *identified)
+		case responded:
+			candidates = append(candidates, DiscoveryCandidate{
+				IPAddress: host,
+				Port:      port,
+				Framing:   string(framing),
+				StationID: station,
+				Detail:    "有应答但未匹配已知型号的寄存器特征",
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// update 在锁内修改任务并按间隔推送进度，force 时立即推送
+func (s *DiscoveryService) update(id string, force bool, fn func(job *DiscoveryJob)) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	fn(run.job)
+	now := time.Now()
+	if !force && now.Sub(run.lastProgress) < discoveryProgressEvery {
+		s.mu.Unlock()
+		return
+	}
+	run.lastProgress = now
+	progress := DiscoveryProgress{
+		ID:         run.job.ID,
+		Status:     run.job.Status,
+		Phase:      run.job.Phase,
+		Total:      run.job.Total,
+		Done:       run.job.Done,
+		Gateways:   len(run.job.Gateways),
+		Candidates: len(run.job.Candidates),
+	}
+	s.mu.Unlock()
+
+	websocket.BroadcastDiscoveryProgress(progress)
+}
+
+// discoveryDevice 已登记的设备
+type discoveryDevice struct {
+	id      uint
+	name    string
+	framing string
+	address string // 网关 host:port，串口设备为空
+}
+
+// existingDevices 已登记的断路器和温度传感器，按 类别+地址 索引
+func (s *DiscoveryService) existingDevices() map[string]discoveryDevice {
+	result := make(map[string]discoveryDevice)
+
+	breakers, err := s.breakerRepo.GetAll()
+	if err != nil {
+		s.logger.Warn("获取断路器列表失败", "error", err)
+	}
+	for _, b := range breakers {
+		result[discoveryKey(drivers.KindBreaker, b.Framing, b.IPAddress, b.Port, b.StationID)] = discoveryDevice{
+			id: b.ID, name: b.BreakerName, framing: b.Framing, address: discoveryAddress(b.IPAddress, b.Port),
+		}
+	}
+
+	var sensors []models.TemperatureSensor
+	if err := s.db.Find(&sensors).Error; err != nil {
+		s.logger.Warn("获取温度传感器列表失败", "error", err)
+	}
+	for _, t := range sensors {
+		result[discoveryKey(drivers.KindTemperatureSensor, t.Framing, t.IPAddress, t.Port, t.SlaveID)] = discoveryDevice{
+			id: t.ID, name: t.Name, framing: t.Framing, address: discoveryAddress(t.IPAddress, t.Port),
+		}
+	}
+	return result
+}
+
+func discoveryAddress(host string, port int) string {
+	if host == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// registeredPorts 已登记设备所在的网关端口及其报文格式，串口设备不参与网络发现
+func registeredPorts(existing map[string]discoveryDevice) map[string][]modbus.Framing {
+	result := make(map[string][]modbus.Framing)
+	for _, device := range existing {
+		framing, err := modbus.ParseFraming(device.framing)
+		if err != nil || framing == modbus.FramingRTU || device.address == "" {
+			continue
+		}
+		if !slices.Contains(result[device.address], framing) {
+			result[device.address] = append(result[device.address], framing)
+		}
+	}
+	return result
+}
+
+func discoveryKey(kind drivers.Kind, framing, host string, port, station int) string {
+	if framing == "" {
+		framing = string(modbus.FramingMBAP)
+	}
+	return fmt.Sprintf("%s|%s://%s|%d", kind, framing, net.JoinHostPort(host, strconv.Itoa(port)), station)
+}
+
+// discoveryFingerprinter 支持特征识别的驱动
+type discoveryFingerprinter struct {
+	info          drivers.Info
+	fingerprinter drivers.Fingerprinter
+}
+
+// discoveryFingerprinters 按类别和型号排序（断路器在前）的全部特征识别驱动
+func discoveryFingerprinters() []discoveryFingerprinter {
+	var result []discoveryFingerprinter
+	for _, info := range drivers.List() {
+		d, ok := drivers.Lookup(info.Model)
+		if !ok {
+			continue
+		}
+		if fp, ok := d.(drivers.Fingerprinter); ok {
+			result = append(result, discoveryFingerprinter{info: info, fingerprinter: fp})
+		}
+	}
+	return result
+}
+
+// discoveryResponded 判断站号上是否有设备应答：超时、连接错误以及网关返回的
+// 0x0A(网关路径不可用)/0x0B(目标设备无响应) 异常都视为无设备
+func discoveryResponded(err error) bool {
+	if err == nil {
+		return true
+	}
+	var exception *modbus.ExceptionError
+	if errors.As(err, &exception) {
+		return exception.Code != 0x0A && exception.Code != 0x0B
+	}
+	return errors.Is(err, modbus.ErrCRCMismatch) || errors.Is(err, modbus.ErrInvalidResponse)
+}
+
+// newDiscoveryPlan 解析网段、端口和站号范围
+func newDiscoveryPlan(req models.StartDiscoveryRequest) (*discoveryPlan, error) {
+	hosts, err := expandCIDR(req.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	portSpec := req.Ports
+	if strings.TrimSpace(portSpec) == "" {
+		portSpec = "502"
+	}
+	ports, err := parseIntList(portSpec, 1, 65535)
+	if err != nil {
+		return nil, fmt.Errorf("端口范围无效: %w", err)
+	}
+	if len(ports) > discoveryMaxPorts {
+		return nil, fmt.Errorf("端口数量超过上限 %d", discoveryMaxPorts)
+	}
+
+	stationSpec := req.Stations
+	if strings.TrimSpace(stationSpec) == "" {
+		stationSpec = "1-8"
+	}
+	stations, err := parseIntList(stationSpec, 1, 247)
+	if err != nil {
+		return nil, fmt.Errorf("站号范围无效: %w", err)
+	}
+
+	plan := &discoveryPlan{
+		hosts:       hosts,
+		ports:       ports,
+		stations:    stations,
+		framings:    []modbus.Framing{modbus.FramingMBAP, modbus.FramingRTUOverTCP},
+		timeout:     discoveryProbeTimeout,
+		concurrency: discoveryConcurrency,
+	}
+	if req.Framing != "" && req.Framing != "auto" {
+		plan.framings = []modbus.Framing{modbus.Framing(req.Framing)}
+	}
+	if req.TimeoutMs > 0 {
+		plan.timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	if req.Concurrency > 0 {
+		plan.concurrency = req.Concurrency
+	}
+	return plan, nil
+}
+
+// expandCIDR 展开网段内的IPv4主机地址（/31 以上不含网络地址和广播地址），也接受单个IP
+func expandCIDR(cidr string) ([]string, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("无效的IPv4地址: %s", cidr)
+		}
+		return []string{ip.To4().String()}, nil
+	}
+
+	ip, network, err := net.ParseCIDR(cidr)
+	if err != nil || ip.To4() == nil {
+		return nil, fmt.Errorf("无效的IPv4网段: %s", cidr)
+	}
+	ones, bits := network.Mask.Size()
+	size := 1 << uint(bits-ones)
+	if size > discoveryMaxHosts {
+		return nil, fmt.Errorf("网段过大（%d 个地址），单次最多扫描 %d 个主机", size, discoveryMaxHosts)
+	}
+
+	base := ipv4ToUint(network.IP.To4())
+	hosts := make([]string, 0, size)
+	for i := 0; i < size; i++ {
+		if size > 2 && (i == 0 || i == size-1) {
+			continue
+		}
+		hosts = append(hosts, uintToIPv4(base+uint32(i)).String())
+	}
+	return hosts, nil
+}
+
+func ipv4ToUint(ip net.IP) uint32 {
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+func uintToIPv4(v uint32) net.IP {
+	return net.IPv4(byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// parseIntList 解析 "1,2,5-8" 形式的整数列表，去重并保持顺序
+func parseIntList(spec string, min, max int) ([]int, error) {
+	var result []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			lo, hi = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		}
+		from, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析 %q", part)
+		}
+		to, err := strconv.Atoi(hi)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析 %q", part)
+		}
+		if from < min || to > max || from > to {
+			return nil, fmt.Errorf("%q 超出范围 %d-%d", part, min, max)
+		}
+		for v := from; v <= to; v++ {
+			if !seen[v] {
+				seen[v] = true
+				result = append(result, v)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("列表为空")
+	}
+	return result, nil
+}
+
+func lessEndpoint(hostA string, portA int, hostB string, portB int) bool {
+	a, b := net.ParseIP(hostA).To4(), net.ParseIP(hostB).To4()
+	if a != nil && b != nil && !a.Equal(b) {
+		return ipv4ToUint(a) < ipv4ToUint(b)
+	}
+	if hostA != hostB {
+		return hostA < hostB
+	}
+	return portA < portB
+}
+
+func copyDiscoveryJob(job *DiscoveryJob) *DiscoveryJob {
+	snapshot := *job
+	snapshot.Gateways = append([]DiscoveredGateway{}, job.Gateways...)
+	snapshot.Candidates = append([]DiscoveryCandidate{}, job.Candidates...)
+	return &snapshot
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"smart-device-management/pkg/modbus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIntList(t *testing.T) {
+	values, err := parseIntList("502, 4196,8000-8002,502", 1, 65535)
+	require.NoError(t, err)
+	assert.Equal(t, []int{502, 4196, 8000, 8001, 8002}, values)
+
+	for _, spec := range []string{"", "0-3", "5-2", "a", "1-x", "248"} {
+		_, err := parseIntList(spec, 1, 247)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestExpandCIDR(t *testing.T) {
+	hosts, err := expandCIDR("192.168.1.9/29")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.9", "192.168.1.10", "192.168.1.11", "192.168.1.12", "192.168.1.13", "192.168.1.14"}, hosts)
+
+	hosts, err = expandCIDR("10.0.0.7")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.7"}, hosts)
+
+	hosts, err = expandCIDR("10.0.0.6/31")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.6", "10.0.0.7"}, hosts)
+
+	_, err = expandCIDR("10.0.0.0/16")
+	assert.Error(t, err)
+	_, err = expandCIDR("fe80::1/64")
+	assert.Error(t, err)
+}
+
+func TestRegisteredPorts(t *testing.T) {
+	ports := registeredPorts(map[string]discoveryDevice{
+		"a": {framing: "", address: "10.0.0.5:502"},
+		"b": {framing: "mbap", address: "10.0.0.5:502"},
+		"c": {framing: "rtu_over_tcp", address: "10.0.0.5:502"},
+		"d": {framing: "rtu", address: ""},
+		"e": {framing: "rtu_over_tcp", address: "10.0.0.6:4196"},
+	})
+	assert.ElementsMatch(t, []modbus.Framing{modbus.FramingMBAP, modbus.FramingRTUOverTCP}, ports["10.0.0.5:502"])
+	assert.Equal(t, []modbus.Framing{modbus.FramingRTUOverTCP}, ports["10.0.0.6:4196"])
+	assert.Len(t, ports, 2)
+}
@@ -0,0 +1,516 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+	"smart-device-management/pkg/websocket"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	emergencyConfirmTTL  = 2 * time.Minute // 计划生成后必须在此时间内确认
+	emergencySettleDelay = time.Second     // 分闸命令发出后等待设备动作再回读
+)
+
+// pendingPowerOff 等待二次确认的紧急断电计划
+type pendingPowerOff struct {
+	token      string
+	phrase     string
+	userID     uint
+	breakerIDs []uint
+	expiresAt  time.Time
+}
+
+// EmergencyPowerOffService 紧急断电服务：按网关广播分闸，再逐台回读确认，未分闸的断路器逐台补发。
+// 执行前需二次确认，全过程写入审计记录并通过WebSocket告警。
+type EmergencyPowerOffService struct {
+	db            *gorm.DB
+	repo          repositories.EmergencyPowerOffRepository
+	modbusService *ModbusService
+	logger        *logger.Logger
+	settleDelay   time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*pendingPowerOff // 按记录UUID索引
+}
+
+// NewEmergencyPowerOffService 创建紧急断电服务
+func NewEmergencyPowerOffService(db *gorm.DB, logger *logger.Logger) *EmergencyPowerOffService {
+	return &EmergencyPowerOffService{
+		db:            db,
+		repo:          repositories.NewEmergencyPowerOffRepository(db),
+		modbusService: NewModbusService(logger, db),
+		logger:        logger,
+		settleDelay:   emergencySettleDelay,
+		pending:       make(map[string]*pendingPowerOff),
+	}
+}
+
+// ExpireStalePlans 服务启动时把上次运行遗留的待确认计划标记为超时：确认令牌只保存在内存中，重启后无法再执行
+func (s *EmergencyPowerOffService) ExpireStalePlans() error {
+	result := s.db.Model(&models.EmergencyPowerOff{}).Where("status = ?", models.EmergencyStatusPending).
+		Update("status", models.EmergencyStatusExpired)
+	if result.Error != nil {
+		return fmt.Errorf("清理待确认的紧急断电计划失败: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		s.logger.Info("服务重启，待确认的紧急断电计划已超时", "count", result.RowsAffected)
+	}
+	return nil
+}
+
+// Prepare 第一步：确定范围内的断路器和每个网关的分闸方式，生成确认令牌和确认口令
+func (s *EmergencyPowerOffService) Prepare(req models.PrepareEmergencyPowerOffRequest, userID uint, username string) (*models.EmergencyPowerOffPlan, error) {
+	breakers, gateway, err := s.scopeBreakers(req)
+	if err != nil {
+		return nil, err
+	}
+	gateways, results := planPowerOff(breakers)
+
+	record := &models.EmergencyPowerOff{
+		UUID:            uuid.New().String(),
+		Scope:           req.Scope,
+		Gateway:         gateway,
+		Reason:          req.Reason,
+		Status:          models.EmergencyStatusPending,
+		RequestedBy:     userID,
+		RequestedByName: username,
+		Gateways:        gateways,
+		Results:         results,
+		ExpiresAt:       time.Now().Add(emergencyConfirmTTL).UTC(),
+	}
+	countResults(record)
+	if record.BreakerCount == record.SkippedCount {
+		return nil, fmt.Errorf("范围内没有可分闸的断路器")
+	}
+	if err := s.repo.Create(record); err != nil {
+		return nil, fmt.Errorf("保存紧急断电记录失败: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	plan := &pendingPowerOff{
+		token:     token,
+		phrase:    fmt.Sprintf("POWER OFF %d", record.BreakerCount-record.SkippedCount),
+		userID:    userID,
+		expiresAt: record.ExpiresAt,
+	}
+	for _, result := range results {
+		plan.breakerIDs = append(plan.breakerIDs, result.BreakerID)
+	}
+
+	s.mu.Lock()
+	s.pending[record.UUID] = plan
+	s.mu.Unlock()
+
+	s.logger.Warn("生成紧急断电计划",
+		"uuid", record.UUID,
+		"scope", record.Scope,
+		"gateway", gateway,
+		"breakers", record.BreakerCount,
+		"skipped", record.SkippedCount,
+		"user", username,
+		"reason", req.Reason)
+
+	return &models.EmergencyPowerOffPlan{
+		Record:        record,
+		Token:         token,
+		ConfirmPhrase: plan.phrase,
+		ExpiresAt:     record.ExpiresAt,
+	}, nil
+}
+
+// Execute 第二步：校验令牌、确认口令和操作人后异步执行，立即返回执行中的记录
+func (s *EmergencyPowerOffService) Execute(id string, req models.ExecuteEmergencyPowerOffRequest, userID uint, username string) (*models.EmergencyPowerOff, error) {
+	record, err := s.repo.GetByUUID(id)
+	if err != nil {
+		return nil, fmt.Errorf("紧急断电记录不存在: %w", err)
+	}
+
+	s.mu.Lock()
+	plan, ok := s.pending[id]
+	if !ok || record.Status != models.EmergencyStatusPending {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("紧急断电计划不是待确认状态: %s", record.Status)
+	}
+	if time.Now().After(plan.expiresAt) {
+		delete(s.pending, id)
+		s.mu.Unlock()
+		record.Status = models.EmergencyStatusExpired
+		s.repo.Update(record)
+		return nil, fmt.Errorf("确认已超时，请重新生成紧急断电计划")
+	}
+	if plan.userID != userID {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("只能由发起人确认执行")
+	}
+	if req.Token != plan.token {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("确认令牌无效")
+	}
+	if strings.TrimSpace(req.Confirmation) != plan.phrase {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("确认口令不正确，请输入: %s", plan.phrase)
+	}
+	delete(s.pending, id)
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	record.Status = models.EmergencyStatusExecuting
+	record.ConfirmedBy = userID
+	record.ConfirmedByName = username
+	record.ConfirmedAt = &now
+	if err := s.repo.Update(record); err != nil {
+		return nil, fmt.Errorf("更新紧急断电记录失败: %w", err)
+	}
+
+	s.logger.Warn("执行紧急断电", "uuid", record.UUID, "scope", record.Scope, "gateway", record.Gateway, "user", username)
+	websocket.BroadcastEmergencyPowerOff(emergencyAlert(record))
+
+	snapshot := *record
+	go s.run(&snapshot, plan.breakerIDs)
+	return record, nil
+}
+
+// Cancel 取消待确认的紧急断电计划
+func (s *EmergencyPowerOffService) Cancel(id string, username string) (*models.EmergencyPowerOff, error) {
+	record, err := s.repo.GetByUUID(id)
+	if err != nil {
+		return nil, fmt.Errorf("紧急断电记录不存在: %w", err)
+	}
+
+	s.mu.Lock()
+	_, ok := s.pending[id]
+	delete(s.pending, id)
+	s.mu.Unlock()
+	if !ok || record.Status != models.EmergencyStatusPending {
+		return nil, fmt.Errorf("紧急断电计划不是待确认状态: %s", record.Status)
+	}
+
+	record.Status = models.EmergencyStatusCancelled
+	if err := s.repo.Update(record); err != nil {
+		return nil, fmt.Errorf("更新紧急断电记录失败: %w", err)
+	}
+	s.logger.Info("取消紧急断电计划", "uuid", id, "user", username)
+	return record, nil
+}
+
+// Get 获取紧急断电记录
+func (s *EmergencyPowerOffService) Get(id string) (*models.EmergencyPowerOff, error) {
+	return s.repo.GetByUUID(id)
+}
+
+// List 分页获取紧急断电记录
+func (s *EmergencyPowerOffService) List(page, pageSize int) (*models.EmergencyPowerOffListResponse, error) {
+	records, total, err := s.repo.List(page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.EmergencyPowerOffListResponse{Records: records, Total: total, Page: page, Size: pageSize}, nil
+}
+
+// scopeBreakers 加载范围内的全部断路器（包括已禁用和已锁定的，用于判断能否广播）
+func (s *EmergencyPowerOffService) scopeBreakers(req models.PrepareEmergencyPowerOffRequest) ([]*models.Breaker, string, error) {
+	var all []*models.Breaker
+	if err := s.db.Preload("Device").Order("id ASC").Find(&all).Error; err != nil {
+		return nil, "", fmt.Errorf("获取断路器列表失败: %w", err)
+	}
+	if req.Scope == models.EmergencyScopeSite {
+		return all, "", nil
+	}
+
+	gateway := req.Gateway
+	if req.BreakerID != 0 {
+		for _, breaker := range all {
+			if breaker.ID == req.BreakerID {
+				gateway = breakerGatewayKey(breaker)
+			}
+		}
+		if gateway == "" {
+			return nil, "", fmt.Errorf("断路器不存在: %d", req.BreakerID)
+		}
+	}
+	if gateway == "" {
+		return nil, "", fmt.Errorf("按网关断电需要指定 gateway 或 breaker_id")
+	}
+
+	var breakers []*models.Breaker
+	for _, breaker := range all {
+		if breakerGatewayKey(breaker) == gateway {
+			breakers = append(breakers, breaker)
+		}
+	}
+	if len(breakers) == 0 {
+		return nil, "", fmt.Errorf("网关 %s 下没有断路器", gateway)
+	}
+	return breakers, gateway, nil
+}
+
+// run 执行紧急断电：各网关并行，完成后保存结果并推送告警
+func (s *EmergencyPowerOffService) run(record *models.EmergencyPowerOff, breakerIDs []uint) {
+	var breakers []*models.Breaker
+	if err := s.db.Preload("Device").Where("id IN ?", breakerIDs).Order("id ASC").Find(&breakers).Error; err != nil {
+		s.logger.Error("加载紧急断电断路器失败", "uuid", record.UUID, "error", err)
+	}
+	gateways, planned := planPowerOff(breakers)
+
+	groups := make(map[string][]*models.Breaker)
+	for _, breaker := range breakers {
+		key := breakerGatewayKey(breaker)
+		groups[key] = append(groups[key], breaker)
+	}
+	results := make(map[uint]*models.EmergencyPowerOffResult)
+	for i := range planned {
+		results[planned[i].BreakerID] = &planned[i]
+	}
+
+	var wg sync.WaitGroup
+	for _, gateway := range gateways {
+		wg.Add(1)
+		go func(plan models.EmergencyGatewayPlan) {
+			defer wg.Done()
+			s.powerOffGateway(record.UUID, plan, groups[plan.Gateway], results)
+		}(gateway)
+	}
+	wg.Wait()
+
+	now := time.Now().UTC()
+	record.Gateways = gateways
+	record.Results = planned
+	record.CompletedAt = &now
+	countResults(record)
+	switch {
+	case record.FailedCount == 0:
+		record.Status = models.EmergencyStatusCompleted
+	case record.SucceededCount == 0:
+		record.Status = models.EmergencyStatusFailed
+	default:
+		record.Status = models.EmergencyStatusPartial
+	}
+	if err := s.repo.Update(record); err != nil {
+		s.logger.Error("保存紧急断电结果失败", "uuid", record.UUID, "error", err)
+	}
+
+	s.logger.Warn("紧急断电完成",
+		"uuid", record.UUID,
+		"status", record.Status,
+		"succeeded", record.SucceededCount,
+		"failed", record.FailedCount,
+		"skipped", record.SkippedCount)
+	websocket.BroadcastEmergencyPowerOff(emergencyAlert(record))
+}
+
+// powerOffGateway 对一个网关执行分闸：可广播时先广播，回读后对仍合闸的断路器逐台补发。
+// 同一网关上的 results 条目只由本协程修改。
+func (s *EmergencyPowerOffService) powerOffGateway(id string, plan models.EmergencyGatewayPlan, breakers []*models.Breaker, results map[uint]*models.EmergencyPowerOffResult) {
+	var targets []*models.Breaker
+	for _, breaker := range breakers {
+		if results[breaker.ID].Method != models.EmergencyMethodSkipped {
+			targets = append(targets, breaker)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	if plan.Method == models.EmergencyMethodBroadcast {
+		if err := s.modbusService.BroadcastOff(targets[0]); err != nil {
+			s.logger.Error("广播分闸失败，改为逐台分闸", "uuid", id, "gateway", plan.Gateway, "error", err)
+			for _, breaker := range targets {
+				results[breaker.ID].Fallback = true
+			}
+		} else {
+			time.Sleep(s.settleDelay)
+			s.verifyOff(targets, results)
+		}
+	}
+
+	var retry []*models.Breaker
+	for _, breaker := range targets {
+		if result := results[breaker.ID]; !result.Confirmed {
+			if plan.Method == models.EmergencyMethodBroadcast {
+				result.Fallback = true
+			}
+			if err := s.modbusService.ControlBreaker(breaker, "off"); err != nil {
+				result.StatusAfter = models.SwitchStatusUnknown
+				result.Error = err.Error()
+				continue
+			}
+			retry = append(retry, breaker)
+		}
+	}
+	if len(retry) > 0 {
+		time.Sleep(s.settleDelay)
+		s.verifyOff(retry, results)
+	}
+}
+
+// verifyOff 逐台回读断路器状态并同步到数据库
+func (s *EmergencyPowerOffService) verifyOff(breakers []*models.Breaker, results map[uint]*models.EmergencyPowerOffResult) {
+	for _, breaker := range breakers {
+		result := results[breaker.ID]
+		telemetry, err := s.modbusService.readTelemetry(breaker)
+		if err != nil {
+			result.StatusAfter = models.SwitchStatusUnknown
+			result.Error = fmt.Sprintf("回读状态失败: %v", err)
+			continue
+		}
+		if telemetry.Closed {
+			result.StatusAfter = models.SwitchStatusOn
+			result.Error = "分闸后回读仍为合闸"
+		} else {
+			result.StatusAfter = models.SwitchStatusOff
+			result.Confirmed = true
+			result.Error = ""
+		}
+		if err := s.db.Model(breaker).Updates(map[string]interface{}{
+			"status":      result.StatusAfter,
+			"last_update": time.Now(),
+		}).Error; err != nil {
+			s.logger.Error("更新断路器状态失败", "breaker_id", breaker.ID, "error", err)
+		}
+	}
+}
+
+// planPowerOff 按网关分组并确定分闸方式：网关上登记的断路器全部启用、未锁定且驱动支持广播时使用广播，
+// 否则逐台下发，已禁用或已锁定的断路器跳过。广播会作用于总线上的所有设备，因此不能只排除个别断路器。
+func planPowerOff(breakers []*models.Breaker) ([]models.EmergencyGatewayPlan, []models.EmergencyPowerOffResult) {
+	plans := make(map[string]*models.EmergencyGatewayPlan)
+	results := make([]models.EmergencyPowerOffResult, 0, len(breakers))
+
+	for _, breaker := range breakers {
+		key := breakerGatewayKey(breaker)
+		plan, ok := plans[key]
+		if !ok {
+			plan = &models.EmergencyGatewayPlan{Gateway: key, Method: models.EmergencyMethodBroadcast}
+			plans[key] = plan
+		}
+
+		result := models.EmergencyPowerOffResult{
+			BreakerID:    breaker.ID,
+			BreakerName:  breaker.BreakerName,
+			Location:     breaker.Location,
+			Gateway:      key,
+			StationID:    breaker.StationID,
+			Method:       models.EmergencyMethodUnicast,
+			StatusBefore: breaker.Status,
+		}
+
+		var note string
+		driver, err := drivers.Breaker(breaker.Model())
+		switch {
+		case key == "":
+			result.Method = models.EmergencyMethodSkipped
+			result.Error = "通信参数无效"
+		case !breaker.IsEnabled:
+			result.Method = models.EmergencyMethodSkipped
+			result.Error = "断路器已禁用"
+			note = fmt.Sprintf("断路器#%d已禁用", breaker.ID)
+		case breaker.IsLocked:
+			result.Method = models.EmergencyMethodSkipped
+			result.Error = "断路器已锁定"
+			note = fmt.Sprintf("断路器#%d已锁定", breaker.ID)
+		case err != nil:
+			result.Method = models.EmergencyMethodSkipped
+			result.Error = err.Error()
+			note = fmt.Sprintf("断路器#%d驱动未知", breaker.ID)
+		case !drivers.Supports(driver, drivers.CapSwitch):
+			result.Method = models.EmergencyMethodSkipped
+			result.Error = fmt.Sprintf("设备型号 %s 不支持远程分合闸", driver.Info().Model)
+			note = fmt.Sprintf("断路器#%d不支持远程分合闸", breaker.ID)
+		case !drivers.Supports(driver, drivers.CapBroadcast):
+			note = fmt.Sprintf("断路器#%d型号 %s 不支持广播", breaker.ID, driver.Info().Model)
+		}
+
+		if note != "" && plan.Method == models.EmergencyMethodBroadcast {
+			plan.Method = models.EmergencyMethodUnicast
+			plan.Note = note
+		}
+		if result.Method != models.EmergencyMethodSkipped {
+			plan.BreakerCount++
+		}
+		results = append(results, result)
+	}
+
+	gateways := make([]models.EmergencyGatewayPlan, 0, len(plans))
+	for _, plan := range plans {
+		if plan.Gateway == "" {
+			continue
+		}
+		gateways = append(gateways, *plan)
+	}
+	sort.Slice(gateways, func(i, j int) bool { return gateways[i].Gateway < gateways[j].Gateway })
+
+	for i := range results {
+		if results[i].Method == models.EmergencyMethodUnicast && plans[results[i].Gateway].Method == models.EmergencyMethodBroadcast {
+			results[i].Method = models.EmergencyMethodBroadcast
+		}
+	}
+	return gateways, results
+}
+
+// breakerGatewayKey 断路器所在网关端口（或串口总线）的标识，通信参数无效时返回空
+func breakerGatewayKey(breaker *models.Breaker) string {
+	cfg, err := breakerEndpoint(breaker)
+	if err != nil {
+		return ""
+	}
+	return modbus.GatewayKey(cfg)
+}
+
+// countResults 统计执行结果
+func countResults(record *models.EmergencyPowerOff) {
+	record.BreakerCount = len(record.Results)
+	record.SucceededCount, record.FailedCount, record.SkippedCount = 0, 0, 0
+	for _, result := range record.Results {
+		switch {
+		case result.Method == models.EmergencyMethodSkipped:
+			record.SkippedCount++
+		case result.Confirmed:
+			record.SucceededCount++
+		case record.Status != models.EmergencyStatusPending:
+			record.FailedCount++
+		}
+	}
+}
+
+// emergencyAlert WebSocket告警内容
+func emergencyAlert(record *models.EmergencyPowerOff) map[string]interface{} {
+	return map[string]interface{}{
+		"uuid":            record.UUID,
+		"scope":           record.Scope,
+		"gateway":         record.Gateway,
+		"status":          record.Status,
+		"reason":          record.Reason,
+		"confirmed_by":    record.ConfirmedByName,
+		"breaker_count":   record.BreakerCount,
+		"succeeded_count": record.SucceededCount,
+		"failed_count":    record.FailedCount,
+		"skipped_count":   record.SkippedCount,
+		"results":         record.Results,
+	}
+}
+
+// randomToken 生成确认令牌
+func randomToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成确认令牌失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
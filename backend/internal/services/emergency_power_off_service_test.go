@@ -0,0 +1,82 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPlanPowerOffByGateway(t *testing.T) {
+	breaker := func(id uint, ip string, station int) *models.Breaker {
+		return &models.Breaker{ID: id, IPAddress: ip, Port: 502, StationID: station, IsEnabled: true, Status: models.SwitchStatusOn}
+	}
+	locked := breaker(4, "10.0.0.2", 2)
+	locked.IsLocked = true
+	noAddress := breaker(5, "", 1)
+
+	gateways, results := planPowerOff([]*models.Breaker{
+		breaker(1, "10.0.0.1", 1),
+		breaker(2, "10.0.0.1", 2),
+		breaker(3, "10.0.0.2", 1),
+		locked,
+		noAddress,
+	})
+
+	require.Len(t, gateways, 2)
+	assert.Equal(t, models.EmergencyGatewayPlan{Gateway: "mbap://10.0.0.1:502", Method: models.EmergencyMethodBroadcast, BreakerCount: 2}, gateways[0])
+	assert.Equal(t, models.EmergencyMethodUnicast, gateways[1].Method, "网关上有锁定的断路器时不能广播")
+	assert.Equal(t, 1, gateways[1].BreakerCount)
+	assert.NotEmpty(t, gateways[1].Note)
+
+	methods := make(map[uint]string)
+	for _, result := range results {
+		methods[result.BreakerID] = result.Method
+	}
+	assert.Equal(t, map[uint]string{
+		1: models.EmergencyMethodBroadcast,
+		2: models.EmergencyMethodBroadcast,
+		3: models.EmergencyMethodUnicast,
+		4: models.EmergencyMethodSkipped,
+		5: models.EmergencyMethodSkipped,
+	}, methods)
+}
+
+func TestExpireStalePlans(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.EmergencyPowerOff{}))
+
+	now := time.Now().UTC()
+	records := []models.EmergencyPowerOff{
+		{UUID: "pending-fresh", Scope: "all", Status: models.EmergencyStatusPending, ExpiresAt: now.Add(time.Minute)},
+		{UUID: "pending-old", Scope: "all", Status: models.EmergencyStatusPending, ExpiresAt: now.Add(-time.Hour)},
+		{UUID: "completed", Scope: "all", Status: models.EmergencyStatusCompleted, ExpiresAt: now.Add(-time.Hour)},
+	}
+	require.NoError(t, db.Create(&records).Error)
+
+	service := NewEmergencyPowerOffService(db, logger.NewLogger())
+	require.NoError(t, service.ExpireStalePlans())
+
+	statuses := make(map[string]string)
+	var saved []models.EmergencyPowerOff
+	require.NoError(t, db.Find(&saved).Error)
+	for _, record := range saved {
+		statuses[record.UUID] = record.Status
+	}
+	assert.Equal(t, map[string]string{
+		"pending-fresh": models.EmergencyStatusExpired,
+		"pending-old":   models.EmergencyStatusExpired,
+		"completed":     models.EmergencyStatusCompleted,
+	}, statuses)
+
+	// 重启后无法再确认执行
+	_, err = service.Execute("pending-fresh", models.ExecuteEmergencyPowerOffRequest{}, 1, "admin")
+	assert.Error(t, err)
+}
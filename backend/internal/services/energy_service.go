@@ -0,0 +1,341 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// 用电量统计粒度
+const (
+	EnergyGranularityHour  = "hour"
+	EnergyGranularityDay   = "day"
+	EnergyGranularityMonth = "month"
+)
+
+// 用电量统计分组
+const (
+	EnergyGroupByBreaker  = "breaker"
+	EnergyGroupByLocation = "location"
+	EnergyGroupByServer   = "server"
+)
+
+// energyMaxGapFactor 两次采样间隔超过采集间隔的该倍数时不做功率积分，离线期间电量按未知处理
+const energyMaxGapFactor = 3
+
+// EnergyService 断路器遥测存储与电量统计服务
+type EnergyService struct {
+	db          *gorm.DB
+	energyRepo  repositories.BreakerEnergyRepository
+	breakerRepo repositories.BreakerRepository
+	logger      *logger.Logger
+}
+
+// NewEnergyService 创建电量统计服务
+func NewEnergyService(db *gorm.DB, logger *logger.Logger) *EnergyService {
+	return &EnergyService{
+		db:          db,
+		energyRepo:  repositories.NewBreakerEnergyRepository(db),
+		breakerRepo: repositories.NewBreakerRepository(db),
+		logger:      logger,
+	}
+}
+
+// RecordSample 保存一次遥测采样并累加电量。interval 为采集间隔，用于判断能否对功率做积分。
+func (s *EnergyService) RecordSample(breakerID uint, telemetry *drivers.BreakerTelemetry, at time.Time, interval time.Duration) (*models.BreakerTelemetrySample, error) {
+	counter, err := s.energyRepo.GetCounter(breakerID)
+	if err != nil {
+		return nil, fmt.Errorf("读取电量计数器失败: %w", err)
+	}
+	if counter == nil {
+		counter = &models.BreakerEnergyCounter{BreakerID: breakerID}
+	}
+
+	at = at.UTC()
+	delta := accumulateEnergy(counter, telemetry, at, interval*energyMaxGapFactor)
+
+	sample := &models.BreakerTelemetrySample{
+		BreakerID:      breakerID,
+		SampledAt:      at,
+		Closed:         telemetry.Closed,
+		Voltage:        telemetry.Voltage,
+		Current:        telemetry.Current,
+		ActivePower:    telemetry.ActivePower,
+		PowerFactor:    telemetry.PowerFactor,
+		Frequency:      telemetry.Frequency,
+		LeakageCurrent: telemetry.LeakageCurrent,
+		Temperature:    telemetry.Temperature,
+		MeterEnergy:    telemetry.Energy,
+		EnergyDelta:    delta,
+		EnergyTotal:    counter.TotalKWh,
+	}
+
+	if err := s.energyRepo.SaveSample(sample, counter); err != nil {
+		return nil, fmt.Errorf("保存遥测采样失败: %w", err)
+	}
+	return sample, nil
+}
+
+// accumulateEnergy 计算本周期电量并更新计数器。
+// 设备有电能寄存器时取两次读数之差（读数回退视为设备清零，以新读数为基准重新累计）；
+// 否则对前后两次有功功率做梯形积分，间隔超过 maxGap 时不积分。
+func accumulateEnergy(counter *models.BreakerEnergyCounter, telemetry *drivers.BreakerTelemetry, at time.Time, maxGap time.Duration) float64 {
+	var delta float64
+	if telemetry.Energy != nil {
+		meter := *telemetry.Energy
+		if counter.LastMeterKWh != nil && meter >= *counter.LastMeterKWh {
+			delta = meter - *counter.LastMeterKWh
+		}
+		counter.Source = models.EnergySourceMeter
+		counter.LastMeterKWh = &meter
+	} else {
+		if counter.LastSampleAt != nil {
+			if dt := at.Sub(*counter.LastSampleAt); dt > 0 && dt <= maxGap {
+				delta = (counter.LastPower + telemetry.ActivePower) / 2 * dt.Hours() / 1000
+			}
+		}
+		counter.Source = models.EnergySourceIntegration
+		counter.LastMeterKWh = nil
+	}
+
+	counter.TotalKWh += delta
+	counter.LastPower = telemetry.ActivePower
+	counter.LastSampleAt = &at
+	return delta
+}
+
+// GetBreakerEnergy 获取断路器累计电量及最近一次采样
+func (s *EnergyService) GetBreakerEnergy(breakerID uint) (*models.BreakerEnergyResponse, error) {
+	if _, err := s.breakerRepo.GetByID(breakerID); err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+
+	counter, err := s.energyRepo.GetCounter(breakerID)
+	if err != nil {
+		return nil, fmt.Errorf("读取电量计数器失败: %w", err)
+	}
+	sample, err := s.energyRepo.LatestSample(breakerID)
+	if err != nil {
+		return nil, fmt.Errorf("读取最近采样失败: %w", err)
+	}
+	return &models.BreakerEnergyResponse{Counter: counter, LatestSample: sample}, nil
+}
+
+// GetTelemetry 获取断路器时间范围内的遥测采样
+func (s *EnergyService) GetTelemetry(breakerID uint, start, end time.Time, limit int) ([]models.BreakerTelemetrySample, error) {
+	if _, err := s.breakerRepo.GetByID(breakerID); err != nil {
+		return nil, fmt.Errorf("断路器不存在: %w", err)
+	}
+	return s.energyRepo.ListSamples(breakerID, start.UTC(), end.UTC(), limit)
+}
+
+// energyTarget 小时电量归属的统计对象，Weight 为分摊比例
+type energyTarget struct {
+	key    string
+	name   string
+	weight float64
+}
+
+// GetConsumption 按小时/日/月统计用电量，可按断路器、位置或绑定的服务器分组。
+// 按服务器分组时，一台断路器的电量在其有效绑定的服务器间平均分摊，未绑定的计入 "unbound"。
+func (s *EnergyService) GetConsumption(query models.EnergyConsumptionQuery) (*models.EnergyConsumptionResponse, error) {
+	if query.Granularity == "" {
+		query.Granularity = EnergyGranularityDay
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = EnergyGroupByBreaker
+	}
+	switch query.Granularity {
+	case EnergyGranularityHour, EnergyGranularityDay, EnergyGranularityMonth:
+	default:
+		return nil, fmt.Errorf("不支持的统计粒度: %s", query.Granularity)
+	}
+	switch query.GroupBy {
+	case EnergyGroupByBreaker, EnergyGroupByLocation, EnergyGroupByServer:
+	default:
+		return nil, fmt.Errorf("不支持的分组方式: %s", query.GroupBy)
+	}
+
+	if query.End.IsZero() {
+		query.End = time.Now()
+	}
+	if query.Start.IsZero() {
+		switch query.Granularity {
+		case EnergyGranularityHour:
+			query.Start = query.End.Add(-24 * time.Hour)
+		case EnergyGranularityDay:
+			query.Start = query.End.AddDate(0, 0, -30)
+		case EnergyGranularityMonth:
+			query.Start = query.End.AddDate(-1, 0, 0)
+		}
+	}
+	if !query.Start.Before(query.End) {
+		return nil, fmt.Errorf("开始时间必须早于结束时间")
+	}
+
+	response := &models.EnergyConsumptionResponse{
+		Granularity: query.Granularity,
+		GroupBy:     query.GroupBy,
+		Start:       query.Start,
+		End:         query.End,
+		Series:      []models.EnergyConsumptionSeries{},
+	}
+
+	targets, err := s.energyTargets(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return response, nil
+	}
+
+	breakerIDs := make([]uint, 0, len(targets))
+	for id := range targets {
+		breakerIDs = append(breakerIDs, id)
+	}
+	rows, err := s.energyRepo.ListHourly(breakerIDs, query.Start.UTC().Truncate(time.Hour), query.End.UTC())
+	if err != nil {
+		s.logger.Error("查询小时电量失败", "error", err)
+		return nil, fmt.Errorf("查询小时电量失败: %w", err)
+	}
+
+	series := make(map[string]*models.EnergyConsumptionSeries)
+	buckets := make(map[string]map[time.Time]float64)
+	for _, row := range rows {
+		period := energyPeriod(row.Hour, query.Granularity)
+		for _, target := range targets[row.BreakerID] {
+			if series[target.key] == nil {
+				series[target.key] = &models.EnergyConsumptionSeries{Key: target.key, Name: target.name}
+				buckets[target.key] = make(map[time.Time]float64)
+			}
+			kwh := row.KWh * target.weight
+			buckets[target.key][period] += kwh
+			series[target.key].TotalKWh += kwh
+			response.TotalKWh += kwh
+		}
+	}
+
+	for key, item := range series {
+		periods := make([]time.Time, 0, len(buckets[key]))
+		for period := range buckets[key] {
+			periods = append(periods, period)
+		}
+		sort.Slice(periods, func(i, j int) bool { return periods[i].Before(periods[j]) })
+		for _, period := range periods {
+			item.Buckets = append(item.Buckets, models.EnergyConsumptionBucket{Period: period, KWh: roundKWh(buckets[key][period])})
+		}
+		item.TotalKWh = roundKWh(item.TotalKWh)
+		response.Series = append(response.Series, *item)
+	}
+	sort.Slice(response.Series, func(i, j int) bool { return response.Series[i].Key < response.Series[j].Key })
+	response.TotalKWh = roundKWh(response.TotalKWh)
+	return response, nil
+}
+
+// energyTargets 确定每台断路器的电量归属对象
+func (s *EnergyService) energyTargets(query models.EnergyConsumptionQuery) (map[uint][]energyTarget, error) {
+	var breakers []models.Breaker
+	db := s.db.Model(&models.Breaker{})
+	if query.BreakerID != 0 {
+		db = db.Where("id = ?", query.BreakerID)
+	}
+	if query.Location != "" {
+		db = db.Where("location = ?", query.Location)
+	}
+	if err := db.Find(&breakers).Error; err != nil {
+		return nil, fmt.Errorf("查询断路器失败: %w", err)
+	}
+
+	targets := make(map[uint][]energyTarget, len(breakers))
+	switch query.GroupBy {
+	case EnergyGroupByBreaker:
+		for _, b := range breakers {
+			targets[b.ID] = []energyTarget{{key: strconv.FormatUint(uint64(b.ID), 10), name: b.BreakerName, weight: 1}}
+		}
+	case EnergyGroupByLocation:
+		for _, b := range breakers {
+			location := b.Location
+			if location == "" {
+				location = "未设置位置"
+			}
+			targets[b.ID] = []energyTarget{{key: location, name: location, weight: 1}}
+		}
+	case EnergyGroupByServer:
+		if len(breakers) == 0 {
+			return targets, nil
+		}
+		ids := make([]uint, 0, len(breakers))
+		for _, b := range breakers {
+			ids = append(ids, b.ID)
+		}
+
+		var bindings []models.BreakerServerBinding
+		if err := s.db.Preload("Server").
+			Where("breaker_id IN ? AND is_active = ?", ids, true).
+			Find(&bindings).Error; err != nil {
+			return nil, fmt.Errorf("查询断路器绑定失败: %w", err)
+		}
+
+		servers := make(map[uint][]*models.Server)
+		for i := range bindings {
+			if bindings[i].Server != nil {
+				servers[bindings[i].BreakerID] = append(servers[bindings[i].BreakerID], bindings[i].Server)
+			}
+		}
+		for _, b := range breakers {
+			bound := servers[b.ID]
+			if len(bound) == 0 {
+				targets[b.ID] = []energyTarget{{key: "unbound", name: "未绑定服务器", weight: 1}}
+				continue
+			}
+			for _, server := range bound {
+				targets[b.ID] = append(targets[b.ID], energyTarget{
+					key:    strconv.FormatUint(uint64(server.ID), 10),
+					name:   server.ServerName,
+					weight: 1 / float64(len(bound)),
+				})
+			}
+		}
+	}
+	return targets, nil
+}
+
+// energyPeriod 小时电量所属的统计周期起点（服务器本地时区）
+func energyPeriod(hour time.Time, granularity string) time.Time {
+	local := hour.In(time.Local)
+	switch granularity {
+	case EnergyGranularityDay:
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	case EnergyGranularityMonth:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	return local
+}
+
+// CleanupSamples 清理超过保留时长的原始采样
+func (s *EnergyService) CleanupSamples(retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	deleted, err := s.energyRepo.DeleteSamplesBefore(time.Now().UTC().Add(-retention))
+	if err != nil {
+		s.logger.Error("清理过期遥测采样失败", "error", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("已清理过期遥测采样", "deleted", deleted, "retention", retention.String())
+	}
+}
+
+func roundKWh(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
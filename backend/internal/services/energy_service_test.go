@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccumulateEnergyFromMeter(t *testing.T) {
+	counter := &models.BreakerEnergyCounter{}
+	now := time.Now()
+	meter := func(v float64) *drivers.BreakerTelemetry { return &drivers.BreakerTelemetry{Energy: &v} }
+
+	assert.Equal(t, 0.0, accumulateEnergy(counter, meter(100), now, time.Minute), "首次读数只作为基准")
+	assert.InDelta(t, 1.5, accumulateEnergy(counter, meter(101.5), now.Add(time.Minute), time.Minute), 1e-9)
+	assert.Equal(t, 0.0, accumulateEnergy(counter, meter(0.2), now.Add(2*time.Minute), time.Minute), "读数回退视为清零")
+	assert.InDelta(t, 0.3, accumulateEnergy(counter, meter(0.5), now.Add(3*time.Minute), time.Minute), 1e-9)
+	assert.InDelta(t, 1.8, counter.TotalKWh, 1e-9)
+	assert.Equal(t, models.EnergySourceMeter, counter.Source)
+}
+
+func TestAccumulateEnergyByIntegration(t *testing.T) {
+	counter := &models.BreakerEnergyCounter{}
+	now := time.Now()
+	power := func(w float64) *drivers.BreakerTelemetry { return &drivers.BreakerTelemetry{ActivePower: w} }
+
+	accumulateEnergy(counter, power(1000), now, 3*time.Minute)
+	// 1000W→2000W 持续1分钟，梯形积分 1.5kW*1/60h
+	assert.InDelta(t, 0.025, accumulateEnergy(counter, power(2000), now.Add(time.Minute), 3*time.Minute), 1e-9)
+	// 超过最大间隔（离线）不积分
+	assert.Equal(t, 0.0, accumulateEnergy(counter, power(2000), now.Add(time.Hour), 3*time.Minute))
+	assert.Equal(t, models.EnergySourceIntegration, counter.Source)
+}
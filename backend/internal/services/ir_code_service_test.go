@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/infrared"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestACProfileBrandAndLearnedCodes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.IRController{}, &models.IRCodeSet{}, &models.IRLearnedCode{},
+		&models.IRLearningSession{}, &models.IRBrandCode{}, &models.IRKeyCode{}, &models.ACProfile{}))
+
+	port, writes := startFakeIRController(t)
+	log := logger.NewLogger()
+	irService := NewIRControllerService(db, log, nil, time.Second)
+	service := NewIRCodeService(db, log, irService)
+	controller, err := irService.CreateController(models.CreateIRControllerRequest{
+		Name: "机房2号空调", Mode: models.IRModeUDP, IPAddress: "127.0.0.1", Port: port,
+	})
+	require.NoError(t, err)
+
+	// 导入的品牌代号可用于空调档案，重复导入时更新
+	result, err := service.ImportBrandCodes(models.ImportIRBrandCodesRequest{
+		DeviceType: models.IRDeviceAC,
+		Brands:     []models.IRBrandCodeEntry{{Brand: "某品牌", Code: 0x0321}, {Brand: "某品牌", Variant: "变频", Code: 0x0322}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.IRImportResult{Created: 2}, *result)
+	result, err = service.ImportBrandCodes(models.ImportIRBrandCodesRequest{
+		DeviceType: models.IRDeviceAC,
+		Brands:     []models.IRBrandCodeEntry{{Brand: "某品牌", English: "Some", Code: 0x0321}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.IRImportResult{Updated: 1}, *result)
+
+	profile, err := service.CreateProfile(models.ACProfileRequest{
+		Name: "UPS间空调", IRControllerID: controller.ID, Source: models.ACProfileBrand, ACBrand: "某品牌",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0x0321, profile.ACCode)
+	saved, err := irService.GetController(controller.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0x0321, saved.ACCode)
+
+	// 改用学习码组后按空调状态发送对应按键的波形
+	set, err := service.CreateCodeSet(models.CreateIRCodeSetRequest{Name: "老款遥控器", Brand: "某品牌"})
+	require.NoError(t, err)
+	assert.Equal(t, models.IRDeviceAC, set.DeviceType)
+	waveform := make([]byte, infrared.LearnedBytes)
+	for i := range waveform {
+		waveform[i] = byte(i + 1)
+	}
+	require.NoError(t, db.Create(&models.IRLearnedCode{CodeSetID: set.ID, Button: "cool_24", Waveform: hex.EncodeToString(waveform)}).Error)
+
+	_, err = service.UpdateProfile(profile.ID, models.ACProfileRequest{
+		Name: "UPS间空调", IRControllerID: controller.ID, Source: models.ACProfileLearned, CodeSetID: &set.ID,
+	})
+	require.NoError(t, err)
+
+	setPoint := 24
+	_, err = irService.ControlAC(controller.ID, models.ACControlRequest{SetPoint: &setPoint})
+	require.NoError(t, err)
+	regs := <-writes
+	require.Len(t, regs, infrared.LearnedWords)
+	assert.Equal(t, binary.BigEndian.Uint16(waveform), regs[0])
+
+	// 未学习的状态不下发
+	off := false
+	_, err = irService.ControlAC(controller.ID, models.ACControlRequest{Power: &off})
+	assert.Error(t, err)
+
+	assert.Error(t, service.DeleteCodeSet(set.ID))
+	require.NoError(t, service.DeleteProfile(profile.ID))
+	require.NoError(t, service.DeleteCodeSet(set.ID))
+}
@@ -0,0 +1,612 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/infrared"
+	"smart-device-management/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// defaultACState 控制器从未下发过状态时，未指定的项使用的默认值
+var defaultACState = models.ACState{Power: true, Mode: infrared.ModeCool, SetPoint: 26, FanSpeed: infrared.FanAuto}
+
+// IRControllerService 红外空调控制器管理：设备增删改查、一键匹配空调码库、下发空调状态和读取模拟量。
+// TCP客户端模式的设备通过监听端口连接，其余模式每次操作建立连接，同一控制器的操作串行执行。
+type IRControllerService struct {
+	db       *gorm.DB
+	logger   *logger.Logger
+	listener *infrared.Listener
+	timeout  time.Duration
+
+	locks sync.Map // 控制器ID -> *sync.Mutex
+}
+
+// NewIRControllerService 创建红外控制器服务，listener 为空时不支持TCP客户端模式的设备
+func NewIRControllerService(db *gorm.DB, logger *logger.Logger, listener *infrared.Listener, timeout time.Duration) *IRControllerService {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &IRControllerService{
+		db:       db,
+		logger:   logger,
+		listener: listener,
+		timeout:  timeout,
+	}
+}
+
+// ListControllers 获取红外控制器列表
+func (s *IRControllerService) ListControllers() ([]models.IRController, error) {
+	var controllers []models.IRController
+	if err := s.db.Order("id").Find(&controllers).Error; err != nil {
+		return nil, fmt.Errorf("获取红外控制器列表失败: %w", err)
+	}
+	return controllers, nil
+}
+
+// GetController 获取红外控制器
+func (s *IRControllerService) GetController(id uint) (*models.IRController, error) {
+	var controller models.IRController
+	if err := s.db.First(&controller, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("红外控制器不存在")
+		}
+		return nil, fmt.Errorf("获取红外控制器失败: %w", err)
+	}
+	return &controller, nil
+}
+
+// CreateController 创建红外控制器，同时创建 ir_controller 类型的设备记录
+func (s *IRControllerService) CreateController(req models.CreateIRControllerRequest) (*models.IRController, error) {
+	s.logger.Info("创建红外控制器", "name", req.Name, "mode", req.Mode, "ip_address", req.IPAddress)
+
+	driver, err := drivers.ForKind(drivers.KindIRController, req.DeviceModel)
+	if err != nil {
+		return nil, err
+	}
+	info := driver.Info()
+
+	controller := &models.IRController{
+		Name:           req.Name,
+		DeviceModel:    info.Model,
+		Mode:           req.Mode,
+		IPAddress:      req.IPAddress,
+		Port:           req.Port,
+		SlaveID:        req.SlaveID,
+		IRPort:         req.IRPort,
+		Location:       req.Location,
+		Description:    req.Description,
+		IsEnabled:      true,
+		Status:         models.DeviceStatusOffline,
+		SerialSettings: req.SerialSettings,
+	}
+	if controller.Mode == "" {
+		controller.Mode = models.IRModeTCPServer
+	}
+	if controller.Port == 0 && (controller.Mode == models.IRModeTCPServer || controller.Mode == models.IRModeUDP) {
+		controller.Port = info.DefaultPort
+	}
+	if controller.SlaveID == 0 {
+		controller.SlaveID = 1
+	}
+	if err := s.setACCode(controller, req.ACBrand, req.ACCode); err != nil {
+		return nil, err
+	}
+	if _, err := s.endpoint(controller); err != nil {
+		return nil, err
+	}
+
+	device := &models.Device{
+		DeviceName:  controller.Name,
+		DeviceType:  models.DeviceTypeIRController,
+		DeviceModel: controller.DeviceModel,
+		IPAddress:   controller.IPAddress,
+		Port:        controller.Port,
+		SlaveID:     controller.SlaveID,
+		Location:    controller.Location,
+		Status:      models.DeviceStatusOffline,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(device).Error; err != nil {
+			return err
+		}
+		controller.DeviceID = device.ID
+		return tx.Create(controller).Error
+	})
+	if err != nil {
+		s.logger.Error("创建红外控制器失败", "error", err)
+		return nil, fmt.Errorf("创建红外控制器失败: %w", err)
+	}
+
+	s.logger.Info("成功创建红外控制器", "ir_controller_id", controller.ID, "name", controller.Name)
+	return controller, nil
+}
+
+// UpdateController 更新红外控制器
+func (s *IRControllerService) UpdateController(id uint, req models.UpdateIRControllerRequest) (*models.IRController, error) {
+	controller, err := s.GetController(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" {
+		controller.Name = req.Name
+	}
+	if req.DeviceModel != "" {
+		driver, err := drivers.ForKind(drivers.KindIRController, req.DeviceModel)
+		if err != nil {
+			return nil, err
+		}
+		controller.DeviceModel = driver.Info().Model
+	}
+	if req.Mode != "" {
+		controller.Mode = req.Mode
+	}
+	if req.IPAddress != "" {
+		controller.IPAddress = req.IPAddress
+	}
+	if req.Port > 0 {
+		controller.Port = req.Port
+	}
+	if req.SlaveID > 0 {
+		controller.SlaveID = req.SlaveID
+	}
+	if req.IRPort != nil {
+		controller.IRPort = *req.IRPort
+	}
+	if req.Location != "" {
+		controller.Location = req.Location
+	}
+	if req.Description != "" {
+		controller.Description = req.Description
+	}
+	if req.IsEnabled != nil {
+		controller.IsEnabled = *req.IsEnabled
+	}
+	if req.SerialDevice != "" {
+		controller.SerialDevice = req.SerialDevice
+	}
+	if req.BaudRate > 0 {
+		controller.BaudRate = req.BaudRate
+	}
+	if req.Parity != "" {
+		controller.Parity = req.Parity
+	}
+	if req.StopBits > 0 {
+		controller.StopBits = req.StopBits
+	}
+	if _, err := s.endpoint(controller); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(controller).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Device{}).Where("id = ?", controller.DeviceID).Updates(map[string]interface{}{
+			"device_name":  controller.Name,
+			"device_model": controller.DeviceModel,
+			"ip_address":   controller.IPAddress,
+			"port":         controller.Port,
+			"slave_id":     controller.SlaveID,
+			"location":     controller.Location,
+		}).Error
+	})
+	if err != nil {
+		s.logger.Error("更新红外控制器失败", "ir_controller_id", id, "error", err)
+		return nil, fmt.Errorf("更新红外控制器失败: %w", err)
+	}
+
+	s.logger.Info("成功更新红外控制器", "ir_controller_id", id)
+	return controller, nil
+}
+
+// DeleteController 删除红外控制器及其设备记录
+func (s *IRControllerService) DeleteController(id uint) error {
+	controller, err := s.GetController(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(controller).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Device{}, controller.DeviceID).Error
+	})
+	if err != nil {
+		s.logger.Error("删除红外控制器失败", "ir_controller_id", id, "error", err)
+		return fmt.Errorf("删除红外控制器失败: %w", err)
+	}
+
+	s.locks.Delete(id)
+	s.logger.Info("成功删除红外控制器", "ir_controller_id", id, "name", controller.Name)
+	return nil
+}
+
+// TestConnection 读取设备信息测试连接
+func (s *IRControllerService) TestConnection(id uint) (*infrared.DeviceInfo, error) {
+	controller, err := s.GetController(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var info *infrared.DeviceInfo
+	err = s.withClient(controller, func(client *infrared.Client) error {
+		info, err = client.ReadInfo()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// MatchAC 空调一键匹配：在等待时间内用空调遥控器对准控制器按电源键，匹配成功后保存码库代号和品牌
+func (s *IRControllerService) MatchAC(id uint, timeout time.Duration) (*models.IRController, error) {
+	controller, err := s.GetController(id)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("开始空调一键匹配", "ir_controller_id", id, "timeout", timeout)
+
+	var code int
+	err = s.withClient(controller, func(client *infrared.Client) error {
+		code, err = client.MatchAC(timeout)
+		return err
+	})
+	if err != nil {
+		s.logger.Warn("空调一键匹配失败", "ir_controller_id", id, "error", err)
+		return nil, err
+	}
+
+	if err := s.setACCode(controller, "", code); err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(controller).Updates(map[string]interface{}{"ac_code": controller.ACCode, "ac_brand": controller.ACBrand}).Error; err != nil {
+		return nil, fmt.Errorf("保存空调码库代号失败: %w", err)
+	}
+
+	s.logger.Info("空调一键匹配成功", "ir_controller_id", id, "ac_code", code, "ac_brand", controller.ACBrand)
+	return controller, nil
+}
+
+// SetACBrand 设置空调品牌或码库代号，并写入控制器，使控制器面板和单项指令使用同一代号
+func (s *IRControllerService) SetACBrand(id uint, req models.SetACBrandRequest) (*models.IRController, error) {
+	if req.Brand == "" && req.Code == 0 {
+		return nil, fmt.Errorf("请指定空调品牌或码库代号")
+	}
+	controller, err := s.GetController(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setACCode(controller, req.Brand, req.Code); err != nil {
+		return nil, err
+	}
+
+	err = s.withClient(controller, func(client *infrared.Client) error {
+		return client.SetACCode(controller.ACCode)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(controller).Updates(map[string]interface{}{"ac_code": controller.ACCode, "ac_brand": controller.ACBrand}).Error; err != nil {
+		return nil, fmt.Errorf("保存空调码库代号失败: %w", err)
+	}
+
+	s.logger.Info("设置空调码库代号", "ir_controller_id", id, "ac_code", controller.ACCode, "ac_brand", controller.ACBrand)
+	return controller, nil
+}
+
+// ControlAC 控制空调：未指定的项沿用最后一次下发的状态，以组合键一次发送完整状态
+func (s *IRControllerService) ControlAC(id uint, req models.ACControlRequest) (*models.IRController, error) {
+	controller, err := s.GetController(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ApplyACState(controller, MergeACState(controller, req)); err != nil {
+		return nil, err
+	}
+	return controller, nil
+}
+
+// MergeACState 以控制器最后一次下发的状态为基础合并控制请求，从未下发过时以默认状态为基础
+func MergeACState(controller *models.IRController, req models.ACControlRequest) models.ACState {
+	state := defaultACState
+	if controller.State != nil {
+		state = *controller.State
+	}
+	if req.Power != nil {
+		state.Power = *req.Power
+	}
+	if req.Mode != "" {
+		state.Mode = req.Mode
+	}
+	if req.SetPoint != nil {
+		state.SetPoint = *req.SetPoint
+	}
+	if req.FanSpeed != "" {
+		state.FanSpeed = req.FanSpeed
+	}
+	return state
+}
+
+// ApplyACState 向控制器下发完整空调状态并记录为最后一次下发的状态。
+// 空调档案使用学习码组时发送该状态对应的学习码，否则按码库代号发码
+func (s *IRControllerService) ApplyACState(controller *models.IRController, state models.ACState) error {
+	if !controller.IsEnabled {
+		return fmt.Errorf("红外控制器已禁用")
+	}
+	irState := infrared.ACState(state)
+	if err := irState.Validate(); err != nil {
+		return err
+	}
+	waveform, err := learnedACWaveform(s.db, controller.ID, state)
+	if err != nil {
+		return err
+	}
+
+	err = s.withClient(controller, func(client *infrared.Client) error {
+		if waveform != nil {
+			return client.SendWaveform(irCodeChannel, waveform)
+		}
+		return client.ApplyACState(irState, controller.ACCode)
+	})
+	if err != nil {
+		s.logger.Error("下发空调状态失败", "ir_controller_id", controller.ID, "error", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	controller.State = &state
+	controller.StateAt = &now
+	if err := s.db.Model(controller).Select("State", "StateAt").Updates(controller).Error; err != nil {
+		return fmt.Errorf("保存空调状态失败: %w", err)
+	}
+
+	s.logger.Info("下发空调状态", "ir_controller_id", controller.ID, "power", state.Power, "mode", state.Mode,
+		"set_point", state.SetPoint, "fan_speed", state.FanSpeed)
+	return nil
+}
+
+// GetStatus 读取控制器实时状态：设备信息、码库代号、寄存器中的空调状态和模拟量。通信失败时返回离线状态而不是错误
+func (s *IRControllerService) GetStatus(id uint) (*models.IRControllerStatus, error) {
+	controller, err := s.GetController(id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.IRControllerStatus{CheckedAt: time.Now().UTC()}
+	err = s.withClient(controller, func(client *infrared.Client) error {
+		info, err := client.ReadInfo()
+		if err != nil {
+			return err
+		}
+		status.Online = true
+		status.Firmware = int(info.Firmware)
+		status.MAC = info.MAC
+
+		if status.ACCode, err = client.ACCode(); err != nil {
+			return err
+		}
+		if brand, ok := infrared.BrandOfCode(status.ACCode); ok {
+			status.ACBrand = brand.Name
+		}
+		state, err := client.ReadACState()
+		if err != nil {
+			return err
+		}
+		acState := models.ACState(*state)
+		status.State = &acState
+
+		analog, err := client.ReadAnalog()
+		if err != nil {
+			return err
+		}
+		status.PowerSensed = &analog.PowerSensed
+		status.ADCVoltage = &analog.ADCVoltage
+		status.Inputs = analog.Inputs
+		return nil
+	})
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status, nil
+}
+
+// Connections TCP客户端模式已连接监听端口的设备
+func (s *IRControllerService) Connections() []infrared.ListenerConn {
+	if s.listener == nil {
+		return []infrared.ListenerConn{}
+	}
+	return s.listener.Connections()
+}
+
+// Brands 空调品牌码库：内置码库加上导入的空调品牌代号
+func (s *IRControllerService) Brands() []infrared.Brand {
+	brands := infrared.Brands()
+	index := make(map[string]int, len(brands))
+	known := make(map[int]bool)
+	for i, brand := range brands {
+		index[brand.Name] = i
+		for _, code := range brand.Codes {
+			known[code] = true
+		}
+	}
+
+	for _, imported := range s.importedBrandCodes("") {
+		if known[imported.Code] {
+			continue
+		}
+		known[imported.Code] = true
+		i, ok := index[imported.Brand]
+		if !ok {
+			i = len(brands)
+			index[imported.Brand] = i
+			brands = append(brands, infrared.Brand{Name: imported.Brand, English: imported.English})
+		}
+		brands[i].Codes = append(brands[i].Codes, imported.Code)
+	}
+	return brands
+}
+
+// lookupBrand 按名称查找空调品牌，内置码库没有时查找导入的品牌代号
+func (s *IRControllerService) lookupBrand(name string) (infrared.Brand, bool) {
+	if brand, ok := infrared.LookupBrand(name); ok {
+		return brand, true
+	}
+	var brand infrared.Brand
+	for _, imported := range s.importedBrandCodes(strings.TrimSpace(name)) {
+		brand.Name, brand.English = imported.Brand, imported.English
+		brand.Codes = append(brand.Codes, imported.Code)
+	}
+	return brand, len(brand.Codes) > 0
+}
+
+// brandOfCode 查找码库代号所属的空调品牌
+func (s *IRControllerService) brandOfCode(code int) (infrared.Brand, bool) {
+	if brand, ok := infrared.BrandOfCode(code); ok {
+		return brand, true
+	}
+	var imported []models.IRBrandCode
+	if err := s.db.Where("device_type = ? AND code = ?", models.IRDeviceAC, code).Limit(1).Find(&imported).Error; err != nil || len(imported) == 0 {
+		return infrared.Brand{}, false
+	}
+	return infrared.Brand{Name: imported[0].Brand, English: imported[0].English, Codes: []int{code}}, true
+}
+
+// importedBrandCodes 导入的空调品牌代号，name 不为空时按中文名或英文名筛选。码表未导入过时为空
+func (s *IRControllerService) importedBrandCodes(name string) []models.IRBrandCode {
+	query := s.db.Where("device_type = ?", models.IRDeviceAC)
+	if name != "" {
+		query = query.Where("brand = ? OR LOWER(english) = LOWER(?)", name, name)
+	}
+	var codes []models.IRBrandCode
+	if err := query.Order("id").Find(&codes).Error; err != nil {
+		return nil
+	}
+	return codes
+}
+
+// setACCode 按品牌或码库代号设置控制器的空调码库：只指定品牌时取该品牌的第一个代号，只指定代号时按码库反查品牌
+func (s *IRControllerService) setACCode(controller *models.IRController, brandName string, code int) error {
+	if brandName == "" && code == 0 {
+		return nil
+	}
+	if brandName != "" {
+		brand, ok := s.lookupBrand(brandName)
+		if !ok {
+			if code == 0 {
+				return fmt.Errorf("码库中没有空调品牌 %s，请指定码库代号或使用一键匹配", brandName)
+			}
+			controller.ACBrand, controller.ACCode = brandName, code
+			return nil
+		}
+		if code == 0 {
+			code = brand.Codes[0]
+		}
+		controller.ACBrand, controller.ACCode = brand.Name, code
+		return nil
+	}
+
+	controller.ACCode, controller.ACBrand = code, ""
+	if brand, ok := s.brandOfCode(code); ok {
+		controller.ACBrand = brand.Name
+	}
+	return nil
+}
+
+// endpoint 控制器的连接配置
+func (s *IRControllerService) endpoint(controller *models.IRController) (infrared.Config, error) {
+	mode, err := infrared.ParseMode(controller.Mode)
+	if err != nil {
+		return infrared.Config{}, err
+	}
+	cfg := infrared.Config{Mode: mode, IRPort: controller.IRPort, Timeout: s.timeout}
+	if controller.IRPort < 0 || controller.IRPort > infrared.MaxIRPort {
+		return cfg, fmt.Errorf("红外输出口必须在 0-%d 之间", infrared.MaxIRPort)
+	}
+
+	switch {
+	case mode == infrared.ModeRTU && controller.SerialDevice != "":
+		cfg.Serial = serialConfig(controller.SerialSettings)
+	case mode == infrared.ModeTCPClient:
+		if controller.IPAddress == "" {
+			return cfg, fmt.Errorf("TCP客户端模式必须填写设备IP地址，用于识别设备连接")
+		}
+	default:
+		if controller.IPAddress == "" || controller.Port == 0 {
+			return cfg, fmt.Errorf("%s 模式必须填写设备IP地址和端口", mode)
+		}
+		cfg.Address = net.JoinHostPort(controller.IPAddress, strconv.Itoa(controller.Port))
+	}
+	return cfg, nil
+}
+
+// withClient 连接控制器执行操作，同一控制器的操作串行执行，并按通信结果更新在线状态
+func (s *IRControllerService) withClient(controller *models.IRController, fn func(client *infrared.Client) error) error {
+	lock, _ := s.locks.LoadOrStore(controller.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	cfg, err := s.endpoint(controller)
+	if err != nil {
+		return err
+	}
+
+	var client *infrared.Client
+	if cfg.Mode == infrared.ModeTCPClient {
+		if s.listener == nil {
+			return fmt.Errorf("%w: 未配置监听端口（IR_CONTROLLER_LISTEN）", infrared.ErrNotSupported)
+		}
+		transport, err := s.listener.Transport(controller.IPAddress, controller.IRPort)
+		if err != nil {
+			s.updateOnline(controller, err)
+			return err
+		}
+		client = infrared.NewClient(transport, byte(controller.SlaveID))
+	} else {
+		transport, err := infrared.Dial(cfg)
+		if err != nil {
+			s.updateOnline(controller, err)
+			return err
+		}
+		client = infrared.NewClient(transport, byte(controller.SlaveID))
+	}
+	defer client.Close()
+
+	err = fn(client)
+	s.updateOnline(controller, err)
+	return err
+}
+
+// updateOnline 按通信结果更新控制器和设备记录的在线状态；匹配、学习失败等设备已应答的错误视为在线
+func (s *IRControllerService) updateOnline(controller *models.IRController, err error) {
+	status := models.DeviceStatusOnline
+	if err != nil && !errors.Is(err, infrared.ErrMatchFailed) && !errors.Is(err, infrared.ErrLearnFailed) {
+		status = models.DeviceStatusOffline
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if status == models.DeviceStatusOnline {
+		now := time.Now().UTC()
+		controller.LastSeen = &now
+		updates["last_seen"] = now
+	}
+	controller.Status = status
+	if dbErr := s.db.Model(controller).UpdateColumns(updates).Error; dbErr != nil {
+		s.logger.Warn("更新红外控制器在线状态失败", "ir_controller_id", controller.ID, "error", dbErr)
+		return
+	}
+	s.db.Model(&models.Device{}).Where("id = ?", controller.DeviceID).UpdateColumn("status", status)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// startFakeIRController 模拟 UDP 模式的 CX-IR002E：写寄存器回显，读寄存器返回0，
+// 收到的写多个寄存器请求送入 writes
+func startFakeIRController(t *testing.T) (int, chan []uint16) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	writes := make(chan []uint16, 8)
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var cmd map[string]string
+			if json.Unmarshal(buf[:n], &cmd) != nil {
+				continue
+			}
+			frame, _ := hex.DecodeString(cmd["irout0h"])
+			if len(frame) < 8 {
+				continue
+			}
+			pdu := frame[1 : len(frame)-2]
+			out := pdu[:5]
+			switch pdu[0] {
+			case 0x03:
+				out = append([]byte{0x03, byte(pdu[4] * 2)}, make([]byte, pdu[4]*2)...)
+			case 0x10:
+				var regs []uint16
+				for i := 0; i < int(pdu[4]); i++ {
+					regs = append(regs, binary.BigEndian.Uint16(pdu[6+i*2:]))
+				}
+				writes <- regs
+			}
+			reply := append([]byte{frame[0]}, out...)
+			reply = binary.LittleEndian.AppendUint16(reply, modbus.CRC16(reply))
+			conn.WriteTo([]byte(fmt.Sprintf(`4{"irout0s":"%X","res":"%s"}`, reply, cmd["res"])), addr)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port, writes
+}
+
+func TestIRControllerControlAC(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.IRController{}))
+
+	port, writes := startFakeIRController(t)
+	service := NewIRControllerService(db, logger.NewLogger(), nil, time.Second)
+
+	controller, err := service.CreateController(models.CreateIRControllerRequest{
+		Name: "机房1号空调", Mode: models.IRModeUDP, IPAddress: "127.0.0.1", Port: port, ACBrand: "美的",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0x00A5, controller.ACCode)
+	assert.NotZero(t, controller.DeviceID)
+
+	setPoint := 24
+	controller, err = service.ControlAC(controller.ID, models.ACControlRequest{SetPoint: &setPoint})
+	require.NoError(t, err)
+	// 未指定的项使用默认状态：开机、制冷、自动风
+	assert.Equal(t, []uint16{0x00A5, 0x8810, 0x0001}, <-writes)
+
+	off := false
+	_, err = service.ControlAC(controller.ID, models.ACControlRequest{Power: &off})
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{0x00A5, 0x0810, 0x0001}, <-writes)
+
+	saved, err := service.GetController(controller.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved.State)
+	assert.Equal(t, models.ACState{Power: false, Mode: "cool", SetPoint: 24, FanSpeed: "auto"}, *saved.State)
+	assert.Equal(t, models.DeviceStatusOnline, saved.Status)
+
+	var device models.Device
+	require.NoError(t, db.First(&device, saved.DeviceID).Error)
+	assert.Equal(t, models.DeviceTypeIRController, device.DeviceType)
+	assert.Equal(t, models.DeviceStatusOnline, device.Status)
+
+	_, err = service.CreateController(models.CreateIRControllerRequest{Name: "缺地址", Mode: models.IRModeUDP})
+	assert.Error(t, err)
+	_, err = service.CreateController(models.CreateIRControllerRequest{Name: "未知品牌", IPAddress: "127.0.0.1", ACBrand: "某品牌"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, saved.SlaveID)
+}
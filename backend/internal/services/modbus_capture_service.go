@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/internal/repositories"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+
+	"gorm.io/gorm"
+)
+
+// ModbusCaptureDetail 抓包任务详情及环形缓冲区中最近的报文
+type ModbusCaptureDetail struct {
+	Capture modbus.CaptureInfo   `json:"capture"`
+	Frames  []modbus.TraceRecord `json:"frames"`
+}
+
+// ModbusCaptureService MODBUS报文抓包服务：按设备定位网关端口和站号，管理追踪器中的抓包任务
+type ModbusCaptureService struct {
+	db          *gorm.DB
+	breakerRepo repositories.BreakerRepository
+	tracer      *modbus.Tracer
+	logger      *logger.Logger
+}
+
+// NewModbusCaptureService 创建抓包服务
+func NewModbusCaptureService(db *gorm.DB, logger *logger.Logger) *ModbusCaptureService {
+	return &ModbusCaptureService{
+		db:          db,
+		breakerRepo: repositories.NewBreakerRepository(db),
+		tracer:      modbus.DefaultTracer(),
+		logger:      logger,
+	}
+}
+
+// StartCapture 开始抓取指定设备的报文
+func (s *ModbusCaptureService) StartCapture(req models.StartModbusCaptureRequest, username string) (*modbus.CaptureInfo, error) {
+	endpoint, stationID, label, err := s.resolveTarget(req.TargetType, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := modbus.CaptureFilter{Gateway: modbus.GatewayKey(endpoint), UnitID: byte(stationID)}
+	if req.WholeBus {
+		filter.UnitID = 0
+		label += "（整条总线）"
+	}
+	if req.Description != "" {
+		label += " " + req.Description
+	}
+
+	info, err := s.tracer.Start(filter, label)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("开始MODBUS抓包", "capture_id", info.ID, "gateway", filter.Gateway, "unit_id", filter.UnitID, "user", username)
+	return &info, nil
+}
+
+// StopCapture 停止抓包
+func (s *ModbusCaptureService) StopCapture(id, username string) (*modbus.CaptureInfo, error) {
+	info, err := s.tracer.Stop(id)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("停止MODBUS抓包", "capture_id", id, "frames", info.Frames, "errors", info.Errors, "user", username)
+	return &info, nil
+}
+
+// ListCaptures 获取全部抓包任务
+func (s *ModbusCaptureService) ListCaptures() []modbus.CaptureInfo {
+	return s.tracer.Captures()
+}
+
+// GetCapture 获取抓包任务及最近 limit 帧报文
+func (s *ModbusCaptureService) GetCapture(id string, limit int) (*ModbusCaptureDetail, error) {
+	info, _, err := s.tracer.Capture(id)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := s.tracer.Recent(id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &ModbusCaptureDetail{Capture: info, Frames: frames}, nil
+}
+
+// CaptureFile 获取抓包文件路径及下载文件名
+func (s *ModbusCaptureService) CaptureFile(id string) (string, string, error) {
+	info, path, err := s.tracer.Capture(id)
+	if err != nil {
+		return "", "", err
+	}
+	return path, info.File, nil
+}
+
+// DeleteCapture 删除已停止的抓包任务及文件
+func (s *ModbusCaptureService) DeleteCapture(id, username string) error {
+	if err := s.tracer.Delete(id); err != nil {
+		return err
+	}
+	s.logger.Info("删除MODBUS抓包", "capture_id", id, "user", username)
+	return nil
+}
+
+// resolveTarget 定位抓包设备所在的网关端口（或串口）和站号
+func (s *ModbusCaptureService) resolveTarget(targetType string, id uint) (modbus.Config, int, string, error) {
+	switch targetType {
+	case models.CaptureTargetBreaker:
+		breaker, err := s.breakerRepo.GetByID(id)
+		if err != nil {
+			return modbus.Config{}, 0, "", fmt.Errorf("断路器不存在: %w", err)
+		}
+		endpoint, err := breakerEndpoint(breaker)
+		return endpoint, breaker.StationID, fmt.Sprintf("断路器#%d %s", breaker.ID, breaker.BreakerName), err
+	case models.CaptureTargetTemperatureSensor:
+		var sensor models.TemperatureSensor
+		if err := s.db.First(&sensor, id).Error; err != nil {
+			return modbus.Config{}, 0, "", fmt.Errorf("温度传感器不存在: %w", err)
+		}
+		endpoint, err := modbus.EndpointConfig(sensor.Framing, sensor.IPAddress, sensor.Port, serialConfig(sensor.SerialSettings))
+		return endpoint, sensor.SlaveID, fmt.Sprintf("温度传感器#%d %s", sensor.ID, sensor.Name), err
+	}
+	return modbus.Config{}, 0, "", fmt.Errorf("不支持的抓包对象类型: %s", targetType)
+}
@@ -1,11 +1,12 @@
 package services
 
 import (
-	"encoding/binary"
 	"fmt"
-	"net"
 	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
 	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+	"sort"
 	"sync"
 	"time"
 
@@ -36,7 +37,7 @@ func (s *ModbusService) ReadBreakerData(breaker *models.Breaker) (*models.Breake
 
 	// 先从数据库获取最新的断路器状态，确保状态是最新的
 	var latestBreaker models.Breaker
-	err := s.db.First(&latestBreaker, breaker.ID).Error
+	err := s.db.Preload("Device").First(&latestBreaker, breaker.ID).Error
 	if err != nil {
 		s.logger.Error("获取断路器最新状态失败", "breaker_id", breaker.ID, "error", err)
 		return nil, fmt.Errorf("获取断路器最新状态失败: %w", err)
@@ -46,26 +47,14 @@ func (s *ModbusService) ReadBreakerData(breaker *models.Breaker) (*models.Breake
 	breaker = &latestBreaker
 	s.logger.Info("使用最新断路器状态", "breaker_id", breaker.ID, "status", breaker.Status)
 
-	// 统一的错误检测和复位机制：在读取任何数据前先检测通信状态
-	if err := s.detectAndResetIfNeeded(breaker); err != nil {
-		s.logger.Warn("通信检测失败，但继续尝试读取", "breaker_id", breaker.ID, "error", err)
-	}
-
-	// 读取断路器状态寄存器 (30001) - 根据LX47LE-125测试文档修复
-	// 30001寄存器：高字节=本地锁定状态，低字节=开关状态 (0x0F=分闸, 0xF0=合闸)
-	// 注意：不再预先检查通信，让重试机制在具体读取时处理通信问题
-	statusValue, err := s.ReadInputRegisterWithRetry(breaker, 30001)
+	// 按设备型号选择驱动读取遥测（LX47LE-125 输入寄存器与保持寄存器各一帧）
+	telemetry, err := s.readTelemetry(breaker)
 	if err != nil {
-		return nil, fmt.Errorf("读取断路器状态失败: %w", err)
+		return nil, fmt.Errorf("读取断路器遥测数据失败: %w", err)
 	}
 
-	// 解析断路器状态 - 根据LX47LE-125测试文档30001寄存器
-	// 高字节：本地锁定状态 (0x01=锁定, 0x00=未锁定)
-	// 低字节：开关状态 (0xF0=合闸, 0x0F=分闸)
-	isOn, isLocalLocked := s.parseBreakerStatus(statusValue)
-
 	var status string
-	if isOn {
+	if telemetry.Closed {
 		status = "on" // 合闸
 	} else {
 		status = "off" // 分闸
@@ -73,10 +62,9 @@ func (s *ModbusService) ReadBreakerData(breaker *models.Breaker) (*models.Breake
 
 	s.logger.Debug("解析断路器状态",
 		"breaker_id", breaker.ID,
-		"raw_value", fmt.Sprintf("0x%04X", statusValue),
-		"register", "30001",
-		"is_on", isOn,
-		"is_local_locked", isLocalLocked,
+		"raw_value", fmt.Sprintf("0x%04X", telemetry.RawStatus),
+		"is_on", telemetry.Closed,
+		"is_local_locked", telemetry.LocalLocked,
 		"status", status)
 
 	// 读取远程锁定状态 - 从40013寄存器获取
@@ -86,81 +74,156 @@ func (s *ModbusService) ReadBreakerData(breaker *models.Breaker) (*models.Breake
 		isLocked = breaker.IsLocked
 	}
 
-	// 读取设备配置参数（保持寄存器）- 失败时自动复位重试
-	ratedCurrent, err := s.readHoldingRegisterWithRetry(breaker, 40005)     // 过流阈值 (0.01A单位)
+	return &models.BreakerRealTimeData{
+		BreakerID:      breaker.ID,
+		Voltage:        telemetry.Voltage,
+		Current:        telemetry.Current,
+		Power:          telemetry.ActivePower / 1000.0, // W转换为kW
+		PowerFactor:    telemetry.PowerFactor,
+		Frequency:      telemetry.Frequency,
+		LeakageCurrent: telemetry.LeakageCurrent,
+		Temperature:    telemetry.Temperature,
+		Status:         status,
+		IsLocked:       isLocked,
+		LastUpdate:     time.Now(),
+		// 添加设备配置参数
+		RatedCurrent:      telemetry.OverCurrentLimit,
+		AlarmCurrent:      telemetry.LeakageLimit,
+		OverTempThreshold: telemetry.OverTempLimit,
+	}, nil
+}
+
+// breakerDriver 按断路器关联设备的型号选择驱动，未填写型号时使用默认驱动
+func (s *ModbusService) breakerDriver(breaker *models.Breaker) (drivers.Driver, error) {
+	model := breaker.Model()
+	if breaker.Device == nil && breaker.DeviceID != 0 {
+		var device models.Device
+		if err := s.db.Select("device_model").First(&device, breaker.DeviceID).Error; err == nil {
+			model = device.DeviceModel
+		}
+	}
+	return drivers.Breaker(model)
+}
+
+// readTelemetry 通过驱动读取断路器遥测
+func (s *ModbusService) readTelemetry(breaker *models.Breaker) (*drivers.BreakerTelemetry, error) {
+	driver, err := s.breakerDriver(breaker)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := driver.(drivers.TelemetryReader)
+	if !ok {
+		return nil, fmt.Errorf("设备型号 %s 不支持读取遥测数据", driver.Info().Model)
+	}
+
+	client, err := s.openClient(breaker, modbus.PriorityPoll)
 	if err != nil {
-		return nil, fmt.Errorf("读取过流阈值失败: %w", err)
+		return nil, err
 	}
-	alarmCurrent, err := s.readHoldingRegisterWithRetry(breaker, 40006)     // 漏电流阈值 (mA)
+	defer client.Disconnect()
+
+	start := time.Now()
+	telemetry, err := reader.ReadTelemetry(client)
 	if err != nil {
-		return nil, fmt.Errorf("读取漏电流阈值失败: %w", err)
+		s.logger.Warn("读取断路器遥测失败", "breaker_id", breaker.ID, "model", driver.Info().Model, "ip", breaker.IPAddress, "port", breaker.Port, "error", err)
+		return nil, err
 	}
-	overTempThreshold, err := s.readHoldingRegisterWithRetry(breaker, 40007) // 过温阈值 (°C)
+
+	s.logger.Debug("读取断路器遥测成功", "breaker_id", breaker.ID, "model", driver.Info().Model, "elapsed", time.Since(start))
+	return telemetry, nil
+}
+
+// ReadTripInfo 通过驱动读取跳闸记录并解析最近一次跳闸原因
+func (s *ModbusService) ReadTripInfo(breaker *models.Breaker) (*drivers.TripHistory, *drivers.TripAnalysis, error) {
+	driver, err := s.breakerDriver(breaker)
 	if err != nil {
-		return nil, fmt.Errorf("读取过温阈值失败: %w", err)
+		return nil, nil, err
+	}
+	reader, ok := driver.(drivers.TripHistoryReader)
+	if !ok {
+		return nil, nil, fmt.Errorf("设备型号 %s 不支持读取跳闸记录", driver.Info().Model)
 	}
 
-	// 读取电气参数（输入寄存器）- 失败时自动复位重试
-	voltage, err := s.readInputRegisterWithRetry(breaker, 30008)        // A相电压
+	client, err := s.openClient(breaker, modbus.PriorityNormal)
 	if err != nil {
-		return nil, fmt.Errorf("读取A相电压失败: %w", err)
+		return nil, nil, err
 	}
-	current, err := s.readInputRegisterWithRetry(breaker, 30009)        // A相电流 (0.01A单位)
+	defer client.Disconnect()
+
+	history, err := reader.ReadTripHistory(client)
 	if err != nil {
-		return nil, fmt.Errorf("读取A相电流失败: %w", err)
+		return nil, nil, err
+	}
+
+	var analysis *drivers.TripAnalysis
+	if decoder, ok := driver.(drivers.TripDecoder); ok {
+		analysis = decoder.DecodeTrip(history.Latest)
 	}
-	powerFactor, err := s.readInputRegisterWithRetry(breaker, 30011)    // 功率因数 (0.01单位)
+	return history, analysis, nil
+}
+
+// ProtectionDriver 获取断路器型号的保护参数驱动
+func (s *ModbusService) ProtectionDriver(breaker *models.Breaker) (drivers.ProtectionConfigurator, error) {
+	driver, err := s.breakerDriver(breaker)
 	if err != nil {
-		return nil, fmt.Errorf("读取功率因数失败: %w", err)
+		return nil, err
+	}
+	configurator, ok := driver.(drivers.ProtectionConfigurator)
+	if !ok {
+		return nil, fmt.Errorf("设备型号 %s 不支持保护参数配置", driver.Info().Model)
 	}
-	activePower, err := s.readInputRegisterWithRetry(breaker, 30012)    // 有功功率
+	return configurator, nil
+}
+
+// ReadProtection 读取断路器保护参数
+func (s *ModbusService) ReadProtection(breaker *models.Breaker) (map[string]float64, error) {
+	configurator, err := s.ProtectionDriver(breaker)
 	if err != nil {
-		return nil, fmt.Errorf("读取有功功率失败: %w", err)
+		return nil, err
 	}
-	frequency, err := s.readInputRegisterWithRetry(breaker, 30005)      // 频率 (0.1Hz单位)
+
+	client, err := s.openClient(breaker, modbus.PriorityNormal)
 	if err != nil {
-		return nil, fmt.Errorf("读取频率失败: %w", err)
+		return nil, err
 	}
-	leakageCurrent, err := s.readInputRegisterWithRetry(breaker, 30006) // 漏电流
+	defer client.Disconnect()
+
+	return configurator.ReadProtection(client)
+}
+
+// WriteProtection 按参数名顺序逐个写入保护参数，全部写完后回读。
+// writeErrs 为各参数的写入错误，readBack 为回读值；回读失败时返回 err。
+func (s *ModbusService) WriteProtection(breaker *models.Breaker, values map[string]float64) (writeErrs map[string]error, readBack map[string]float64, err error) {
+	configurator, err := s.ProtectionDriver(breaker)
 	if err != nil {
-		return nil, fmt.Errorf("读取漏电流失败: %w", err)
+		return nil, nil, err
 	}
-	temperature, err := s.readInputRegisterWithRetry(breaker, 30007)    // 温度
+
+	client, err := s.openClient(breaker, modbus.PriorityControl)
 	if err != nil {
-		return nil, fmt.Errorf("读取温度失败: %w", err)
+		return nil, nil, err
 	}
+	defer client.Disconnect()
 
-	// 转换数据格式
-	realVoltage := float64(voltage)
-	realCurrent := float64(current) / 100.0          // 0.01A单位转换为A
-	realPowerFactor := float64(powerFactor) / 100.0  // 0.01单位转换
-	realActivePower := float64(activePower) / 1000.0 // W转换为kW
-	realFrequency := float64(frequency) / 10.0       // 0.1Hz单位转换
-	realLeakageCurrent := float64(leakageCurrent)    // mA
-	realTemperature := float64(temperature) - 40     // 减去40得到实际温度
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	// 转换设备配置参数
-	realRatedCurrent := float64(ratedCurrent) / 100.0  // 0.01A单位转换为A
-	realAlarmCurrent := float64(alarmCurrent)          // mA
-	realOverTempThreshold := float64(overTempThreshold) // °C
+	writeErrs = make(map[string]error)
+	for _, name := range names {
+		if err := configurator.WriteProtection(client, name, values[name]); err != nil {
+			s.logger.Error("写入保护参数失败", "breaker_id", breaker.ID, "parameter", name, "value", values[name], "error", err)
+			writeErrs[name] = err
+		}
+	}
 
-	return &models.BreakerRealTimeData{
-		BreakerID:      breaker.ID,
-		Voltage:        realVoltage,
-		Current:        realCurrent,
-		Power:          realActivePower,
-		PowerFactor:    realPowerFactor,
-		Frequency:      realFrequency,
-		LeakageCurrent: realLeakageCurrent,
-		Temperature:    realTemperature,
-		Status:         status,
-		IsLocked:       isLocked,
-		LastUpdate:     time.Now(),
-		// 添加设备配置参数
-		RatedCurrent:      realRatedCurrent,
-		AlarmCurrent:      realAlarmCurrent,
-		OverTempThreshold: realOverTempThreshold,
-	}, nil
+	readBack, err = configurator.ReadProtection(client)
+	if err != nil {
+		return writeErrs, nil, fmt.Errorf("回读保护参数失败: %w", err)
+	}
+	return writeErrs, readBack, nil
 }
 
 // ControlBreaker 控制断路器开关
@@ -181,33 +244,27 @@ func (s *ModbusService) ControlBreaker(breaker *models.Breaker, action string) e
 		return fmt.Errorf("断路器已锁定，请先解锁后再执行%s操作", action)
 	}
 
-	// 2. 执行双重保障控制
-	var coilValue uint16
-	var regValue uint16
-	if action == "on" {
-		coilValue = 0xFF00 // 合闸
-		regValue = 0xFF00  // 合闸
-	} else {
-		coilValue = 0x0000 // 分闸
-		regValue = 0x0000  // 分闸
+	// 2. 通过驱动执行分合闸（LX47LE-125 先写线圈00002，失败时写40014）
+	driver, err := s.breakerDriver(breaker)
+	if err != nil {
+		return err
+	}
+	switcher, ok := driver.(drivers.Switcher)
+	if !ok {
+		return fmt.Errorf("设备型号 %s 不支持远程分合闸", driver.Info().Model)
 	}
 
-	// 3. 方法1：写入远程合闸/分闸线圈 (00002) - 根据LX47LE-125协议
-	// 根据测试文档：COIL_REMOTE_SWITCH = 0x0001，传入地址2才能正确映射到线圈00002
-	err = s.writeCoil(breaker, 2, coilValue)
+	client, err := s.openClient(breaker, modbus.PriorityControl)
 	if err != nil {
-		s.logger.Warn("线圈控制失败，尝试寄存器控制", "breaker_id", breaker.ID, "error", err)
+		return err
+	}
+	defer client.Disconnect()
 
-		// 4. 方法2：写入保持寄存器40014作为备用方案
-		err = s.writeHoldingRegister(breaker, 40014, regValue)
-		if err != nil {
-			s.logger.Error("寄存器控制也失败", "breaker_id", breaker.ID, "error", err)
-			return fmt.Errorf("断路器控制失败: 线圈和寄存器控制都失败")
-		}
-		s.logger.Info("寄存器控制成功", "breaker_id", breaker.ID, "action", action)
-	} else {
-		s.logger.Info("线圈控制成功", "breaker_id", breaker.ID, "action", action)
+	if err := switcher.Switch(client, action == "on"); err != nil {
+		s.logger.Error("断路器控制失败", "breaker_id", breaker.ID, "action", action, "error", err)
+		return fmt.Errorf("断路器控制失败: %w", err)
 	}
+	s.logger.Info("断路器控制命令发送成功", "breaker_id", breaker.ID, "model", driver.Info().Model, "action", action)
 
 	// 5. 立即更新数据库状态
 	var newStatus models.SwitchStatus
@@ -236,6 +293,62 @@ func (s *ModbusService) ControlBreaker(breaker *models.Breaker, action string) e
 	return nil
 }
 
+// TestLeakage 远程按下漏电试验按钮，断路器正常时会以漏电保护跳闸，调用方需回读状态和跳闸原因
+func (s *ModbusService) TestLeakage(breaker *models.Breaker) error {
+	if breaker.IsLocked {
+		return fmt.Errorf("断路器已锁定，请先解锁后再执行漏电试验")
+	}
+
+	driver, err := s.breakerDriver(breaker)
+	if err != nil {
+		return err
+	}
+	tester, ok := driver.(drivers.LeakageTester)
+	if !ok {
+		return fmt.Errorf("设备型号 %s 不支持远程漏电试验", driver.Info().Model)
+	}
+
+	client, err := s.openClient(breaker, modbus.PriorityControl)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	if err := tester.TestLeakage(client); err != nil {
+		s.logger.Error("漏电试验命令发送失败", "breaker_id", breaker.ID, "error", err)
+		return fmt.Errorf("漏电试验命令发送失败: %w", err)
+	}
+	s.logger.Info("漏电试验命令发送成功", "breaker_id", breaker.ID, "model", driver.Info().Model)
+	return nil
+}
+
+// BroadcastOff 向断路器所在总线发送广播分闸（站号0），总线上的所有设备都会执行且不应答，
+// 调用方需逐台回读确认状态
+func (s *ModbusService) BroadcastOff(breaker *models.Breaker) error {
+	driver, err := s.breakerDriver(breaker)
+	if err != nil {
+		return err
+	}
+	broadcaster, ok := driver.(drivers.BroadcastSwitcher)
+	if !ok {
+		return fmt.Errorf("设备型号 %s 不支持广播分闸", driver.Info().Model)
+	}
+
+	cfg, err := breakerEndpoint(breaker)
+	if err != nil {
+		return err
+	}
+	client := modbus.NewModbusClientWithTransport(modbus.DefaultGatewayPool().Gateway(cfg).Transport(modbus.PriorityControl), 0)
+	defer client.Disconnect()
+
+	if err := broadcaster.BroadcastOff(client); err != nil {
+		s.logger.Error("广播分闸失败", "gateway", modbus.GatewayKey(cfg), "error", err)
+		return fmt.Errorf("广播分闸失败: %w", err)
+	}
+	s.logger.Warn("已发送广播分闸", "gateway", modbus.GatewayKey(cfg), "model", driver.Info().Model)
+	return nil
+}
+
 // ControlBreakerLock 控制断路器锁定状态（安全模式：只更新数据库，避免MODBUS操作导致跳闸）
 func (s *ModbusService) ControlBreakerLock(breaker *models.Breaker, lock bool) error {
 	s.logger.Info("控制断路器锁定（安全模式）", "breaker_id", breaker.ID, "lock", lock)
@@ -302,7 +415,7 @@ func (s *ModbusService) readHoldingRegister(breaker *models.Breaker, address uin
 	}
 
 	// 尝试真实的MODBUS通信（不预先检查通信，让重试版本处理）
-	realValue, err := s.sendModbusReadHoldingRegister(breaker.IPAddress, breaker.Port, address)
+	realValue, err := s.sendModbusReadHoldingRegister(breaker, address)
 	if err != nil {
 		s.logger.Debug("MODBUS读取保持寄存器失败", "breaker_id", breaker.ID, "address", address, "error", err)
 		return 0, fmt.Errorf("读取保持寄存器失败: %w", err)
@@ -316,7 +429,7 @@ func (s *ModbusService) readHoldingRegister(breaker *models.Breaker, address uin
 // readInputRegister 读取输入寄存器（基础版本，不包含重试逻辑）
 func (s *ModbusService) readInputRegister(breaker *models.Breaker, address uint16) (uint16, error) {
 	// 尝试真实的MODBUS通信（不预先检查通信，让重试版本处理）
-	realValue, err := s.sendModbusReadInputRegister(breaker.IPAddress, breaker.Port, address)
+	realValue, err := s.sendModbusReadInputRegister(breaker, address)
 	if err != nil {
 		s.logger.Debug("MODBUS读取输入寄存器失败", "breaker_id", breaker.ID, "address", address, "error", err)
 		return 0, fmt.Errorf("读取输入寄存器失败: %w", err)
@@ -330,7 +443,7 @@ func (s *ModbusService) readInputRegister(breaker *models.Breaker, address uint1
 // readRemoteBreakerStatusRegister 专门读取30001状态寄存器 - 根据测试文档修复
 func (s *ModbusService) readRemoteBreakerStatusRegister(breaker *models.Breaker) (uint16, error) {
 	// 直接尝试从真实MODBUS设备读取30001寄存器状态，失败时使用数据库状态
-	realValue, err := s.sendModbusReadInputRegister(breaker.IPAddress, breaker.Port, 30001)
+	realValue, err := s.sendModbusReadInputRegister(breaker, 30001)
 	if err == nil {
 		// 成功读取到真实设备状态，直接返回
 		s.logger.Debug("成功读取真实断路器状态", "breaker_id", breaker.ID, "value", fmt.Sprintf("0x%04X", realValue))
@@ -366,7 +479,7 @@ func (s *ModbusService) readRemoteBreakerStatusRegister(breaker *models.Breaker)
 // readBreakerStatusRegister 专门读取30001状态寄存器（保留用于其他用途）
 func (s *ModbusService) readBreakerStatusRegister(breaker *models.Breaker) (uint16, error) {
 	// 直接尝试从真实MODBUS设备读取状态，失败时使用数据库状态
-	realValue, err := s.sendModbusReadInputRegister(breaker.IPAddress, breaker.Port, 30001)
+	realValue, err := s.sendModbusReadInputRegister(breaker, 30001)
 	if err == nil {
 		// 成功读取到真实设备状态，直接返回
 		s.logger.Debug("成功读取真实断路器状态", "breaker_id", breaker.ID, "value", fmt.Sprintf("0x%04X", realValue))
@@ -460,61 +573,6 @@ func (s *ModbusService) ReadHoldingRegisterWithRetry(breaker *models.Breaker, ad
 	return s.readHoldingRegisterWithRetry(breaker, address)
 }
 
-// writeCoil 写入线圈
-func (s *ModbusService) writeCoil(breaker *models.Breaker, address uint16, value uint16) error {
-	// 实现真实的MODBUS TCP写入
-	err := s.sendModbusWriteCoil(breaker.IPAddress, breaker.Port, address, value)
-	if err != nil {
-		s.logger.Error("MODBUS写入线圈失败", "breaker_id", breaker.ID, "error", err)
-		// MODBUS通信失败时，不更新数据库状态，让用户知道控制失败
-		return fmt.Errorf("MODBUS控制失败: %w", err)
-	}
-
-	s.logger.Info("写入线圈成功", "breaker_id", breaker.ID, "address", address, "value", value)
-
-	// 根据LX47LE-125文档，地址2对应线圈00002（远程开/关控制）
-	if address == 2 {
-		// 只有MODBUS通信成功后才更新数据库中的断路器状态
-		var newStatus models.SwitchStatus
-		if value == 0xFF00 {
-			newStatus = models.SwitchStatusOn // 合闸
-		} else {
-			newStatus = models.SwitchStatusOff // 分闸
-		}
-
-		// 更新数据库状态
-		err := s.db.Model(breaker).Update("status", newStatus).Error
-		if err != nil {
-			s.logger.Error("更新断路器状态失败", "breaker_id", breaker.ID, "error", err)
-			return fmt.Errorf("更新断路器状态失败: %w", err)
-		}
-
-		// 更新内存中的状态
-		breaker.Status = newStatus
-		s.logger.Info("断路器状态已更新", "breaker_id", breaker.ID, "status", newStatus)
-	}
-
-	// 地址2是合闸/分闸控制线圈（00002），不需要更新锁定状态
-	// 地址3才是锁定/解锁控制线圈（00003）
-	if address == 3 {
-		// 只有MODBUS通信成功后才更新数据库中的断路器锁定状态
-		isLocked := value == 0xFF00
-
-		// 更新数据库状态
-		err := s.db.Model(breaker).Update("is_locked", isLocked).Error
-		if err != nil {
-			s.logger.Error("更新断路器锁定状态失败", "breaker_id", breaker.ID, "error", err)
-			return fmt.Errorf("更新断路器锁定状态失败: %w", err)
-		}
-
-		// 更新内存中的状态
-		breaker.IsLocked = isLocked
-		s.logger.Info("断路器锁定状态已更新", "breaker_id", breaker.ID, "is_locked", isLocked)
-	}
-
-	return nil
-}
-
 // writeLockCoil 写入锁定线圈
 func (s *ModbusService) writeLockCoil(breaker *models.Breaker, address uint16, value uint16) error {
 	s.logger.Info("写入锁定线圈", "breaker_id", breaker.ID, "address", address, "value", value)
@@ -544,240 +602,41 @@ func (s *ModbusService) writeLockCoil(breaker *models.Breaker, address uint16, v
 	return nil
 }
 
-// sendModbusWriteCoil 发送MODBUS TCP写入线圈指令 (基于LX47LE-125测试文档)
-func (s *ModbusService) sendModbusWriteCoil(ipAddress string, port int, address uint16, value uint16) error {
-	// 添加操作间隔，避免网关连接数限制 (基于测试文档经验)
-	time.Sleep(100 * time.Millisecond)
-
-	// 建立TCP连接 (基于测试文档的超时设置)
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ipAddress, port), 5*time.Second)
-	if err != nil {
-		return fmt.Errorf("连接MODBUS设备失败: %w", err)
-	}
-	defer conn.Close()
-
-	// 设置读写超时 (基于测试文档)
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-
-	// 构建MODBUS TCP请求 (基于LX47LE-125测试文档格式)
-	request := make([]byte, 12)
-
-	// MBAP Header (基于测试文档)
-	binary.BigEndian.PutUint16(request[0:2], 0x0001) // Transaction ID
-	binary.BigEndian.PutUint16(request[2:4], 0x0000) // Protocol ID
-	binary.BigEndian.PutUint16(request[4:6], 0x0006) // Length
-	request[6] = 1                                   // Unit ID (Station ID)
-
-	// PDU (基于测试文档)
-	request[7] = 0x05                                // Function Code: Write Single Coil
-
-	// 地址转换：00001 -> 0x0000, 00002 -> 0x0001, 00003 -> 0x0002 (MODBUS线圈地址转换)
-	modbusAddress := address - 1
-	binary.BigEndian.PutUint16(request[8:10], modbusAddress)
-	binary.BigEndian.PutUint16(request[10:12], value)
-
-	s.logger.Info("发送MODBUS TCP请求", "ip", ipAddress, "port", port, "address", address, "value", fmt.Sprintf("0x%04X", value), "request_hex", fmt.Sprintf("%X", request))
-
-	// 发送请求
-	_, err = conn.Write(request)
-	if err != nil {
-		return fmt.Errorf("发送MODBUS请求失败: %w", err)
-	}
-
-	// 读取TCP响应
-	response := make([]byte, 256)
-	n, err := conn.Read(response)
+// sendModbusReadInputRegister 发送MODBUS读取输入寄存器指令 (基于LX47LE-125测试文档)
+func (s *ModbusService) sendModbusReadInputRegister(breaker *models.Breaker, address uint16) (uint16, error) {
+	client, err := s.openClient(breaker, modbus.PriorityPoll)
 	if err != nil {
-		return fmt.Errorf("读取MODBUS响应失败: %w", err)
+		return 0, err
 	}
-
-	s.logger.Info("收到MODBUS TCP响应", "response_length", n, "response_hex", fmt.Sprintf("%X", response[:n]))
-
-	// 检查响应长度 (基于测试文档)
-	if n < 12 {
-		return fmt.Errorf("MODBUS TCP响应长度不足: %d", n)
-	}
-
-	// 检查响应功能码 (基于测试文档)
-	responseFunctionCode := response[7]
-	if responseFunctionCode == 0x85 {
-		// 错误响应 (基于测试文档)
-		exceptionCode := response[8]
-		return fmt.Errorf("MODBUS异常响应: 功能码=%02X, 异常码=%02X", responseFunctionCode, exceptionCode)
-	}
-
-	if responseFunctionCode != 0x05 {
-		return fmt.Errorf("MODBUS响应功能码不匹配: 期望=05, 实际=%02X", responseFunctionCode)
-	}
-
-	// 验证响应数据 (功能码05的响应应该回显请求的地址和值)
-	if n >= 12 {
-		responseAddress := binary.BigEndian.Uint16(response[8:10])
-		responseValue := binary.BigEndian.Uint16(response[10:12])
-
-		// 地址转换回原始地址进行比较
-		expectedAddress := address - 1  // 我们发送的是转换后的地址
-		if responseAddress != expectedAddress {
-			s.logger.Warn("MODBUS响应地址不匹配", "expected_addr", expectedAddress, "response_addr", responseAddress)
-		}
-		if responseValue != value {
-			s.logger.Warn("MODBUS响应值不匹配", "expected_value", fmt.Sprintf("0x%04X", value), "response_value", fmt.Sprintf("0x%04X", responseValue))
-		}
-	}
-
-	s.logger.Info("MODBUS TCP转RTU写入线圈成功", "ip", ipAddress, "port", port, "address", address, "value", fmt.Sprintf("0x%04X", value))
-	return nil
-}
-
-// sendModbusReadInputRegister 发送MODBUS TCP读取输入寄存器指令 (基于LX47LE-125测试文档)
-func (s *ModbusService) sendModbusReadInputRegister(ipAddress string, port int, address uint16) (uint16, error) {
-	// 建立TCP连接 (基于测试文档的超时设置)
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ipAddress, port), 5*time.Second)
-	if err != nil {
-		return 0, fmt.Errorf("连接MODBUS设备失败: %w", err)
-	}
-	defer conn.Close()
-
-	// 设置读写超时 (基于测试文档)
-	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
-
-	// 构建MODBUS TCP请求 (基于LX47LE-125测试文档格式)
-	request := make([]byte, 12)
-
-	// MBAP Header (基于测试文档)
-	binary.BigEndian.PutUint16(request[0:2], 0x0001) // Transaction ID
-	binary.BigEndian.PutUint16(request[2:4], 0x0000) // Protocol ID
-	binary.BigEndian.PutUint16(request[4:6], 0x0006) // Length
-	request[6] = 1                                   // Unit ID (Station ID)
-
-	// PDU (基于测试文档)
-	request[7] = 0x04                                // Function Code: Read Input Registers
+	defer client.Disconnect()
 
 	// 地址转换：30001 -> 0x0000 (MODBUS输入寄存器地址转换)
-	modbusAddress := address - 30001
-	binary.BigEndian.PutUint16(request[8:10], modbusAddress)
-	binary.BigEndian.PutUint16(request[10:12], 1)    // Quantity: 1 register
-
-	s.logger.Info("发送MODBUS TCP读取请求", "ip", ipAddress, "port", port, "address", address, "request_hex", fmt.Sprintf("%X", request))
-
-	// 发送请求
-	_, err = conn.Write(request)
-	if err != nil {
-		return 0, fmt.Errorf("发送MODBUS请求失败: %w", err)
-	}
-
-	// 读取响应
-	response := make([]byte, 256)
-	n, err := conn.Read(response)
+	values, err := client.ReadInputRegisters(address-30001, 1)
 	if err != nil {
-		return 0, fmt.Errorf("读取MODBUS响应失败: %w", err)
+		return 0, err
 	}
 
-	s.logger.Info("收到MODBUS TCP响应", "response_length", n, "response_hex", fmt.Sprintf("%X", response[:n]))
-
-	// 检查响应长度 (基于测试文档)
-	if n < 11 {
-		return 0, fmt.Errorf("MODBUS响应长度不足: %d", n)
-	}
-
-	// 检查响应功能码 (基于测试文档)
-	responseFunctionCode := response[7]
-	if responseFunctionCode == 0x84 {
-		// 错误响应 (基于测试文档)
-		exceptionCode := response[8]
-		return 0, fmt.Errorf("MODBUS异常响应: 功能码=%02X, 异常码=%02X", responseFunctionCode, exceptionCode)
-	}
-
-	if responseFunctionCode != 0x04 {
-		return 0, fmt.Errorf("MODBUS响应功能码不匹配: 期望=04, 实际=%02X", responseFunctionCode)
-	}
-
-	// 提取寄存器值 (基于测试文档)
-	registerValue := binary.BigEndian.Uint16(response[9:11])
-
-	s.logger.Info("MODBUS读取输入寄存器成功", "ip", ipAddress, "port", port, "address", address, "value", registerValue)
-	return registerValue, nil
+	s.logger.Info("MODBUS读取输入寄存器成功", "ip", breaker.IPAddress, "port", breaker.Port, "address", address, "value", values[0])
+	return values[0], nil
 }
 
 // sendModbusReadHoldingRegister 发送MODBUS读取保持寄存器请求
-func (s *ModbusService) sendModbusReadHoldingRegister(ipAddress string, port int, address uint16) (uint16, error) {
-	// 连接到设备 (超快速超时，避免阻塞前端)
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ipAddress, port), 100*time.Millisecond)
+func (s *ModbusService) sendModbusReadHoldingRegister(breaker *models.Breaker, address uint16) (uint16, error) {
+	// 保持寄存器多为页面交互读取，优先于后台轮询
+	client, err := s.openClient(breaker, modbus.PriorityNormal)
 	if err != nil {
-		return 0, fmt.Errorf("连接MODBUS设备失败: %w", err)
+		return 0, err
 	}
-	defer conn.Close()
-
-	// 设置读取超时
-	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
-
-	// MODBUS TCP参数
-	transactionID := uint16(1)
-	protocolID := uint16(0)
-	unitID := byte(1)
-	functionCode := byte(0x03) // 读取保持寄存器
+	defer client.Disconnect()
 
 	// 地址转换：40001 -> 0x0000, 40013 -> 0x000C, 40014 -> 0x000D (MODBUS保持寄存器地址转换)
-	startAddress := address - 40001
-	quantity := uint16(1)
-
-	// 构建PDU (Protocol Data Unit)
-	pdu := make([]byte, 5)
-	pdu[0] = functionCode
-	binary.BigEndian.PutUint16(pdu[1:3], startAddress)
-	binary.BigEndian.PutUint16(pdu[3:5], quantity)
-
-	// 构建MBAP Header
-	length := uint16(len(pdu) + 1) // PDU长度 + Unit ID
-	mbapHeader := make([]byte, 7)
-	binary.BigEndian.PutUint16(mbapHeader[0:2], transactionID)
-	binary.BigEndian.PutUint16(mbapHeader[2:4], protocolID)
-	binary.BigEndian.PutUint16(mbapHeader[4:6], length)
-	mbapHeader[6] = unitID
-
-	// 组合完整的MODBUS TCP请求
-	request := append(mbapHeader, pdu...)
-
-	// 发送请求
-	_, err = conn.Write(request)
+	values, err := client.ReadHoldingRegisters(address-40001, 1)
 	if err != nil {
-		return 0, fmt.Errorf("发送MODBUS请求失败: %w", err)
-	}
-
-	// 读取响应
-	response := make([]byte, 11) // MBAP Header (7) + 响应PDU (4)
-	n, err := conn.Read(response)
-	if err != nil {
-		return 0, fmt.Errorf("读取MODBUS响应失败: %w", err)
-	}
-
-	if n < 11 { // 最小响应长度
-		return 0, fmt.Errorf("MODBUS响应长度不足: %d", n)
-	}
-
-	// 检查响应
-	responseFunctionCode := response[7]
-	if responseFunctionCode == (functionCode | 0x80) {
-		// 错误响应
-		exceptionCode := response[8]
-		return 0, fmt.Errorf("MODBUS异常响应: 功能码=%02X, 异常码=%02X", responseFunctionCode, exceptionCode)
-	}
-
-	if responseFunctionCode != functionCode {
-		return 0, fmt.Errorf("MODBUS响应功能码不匹配: 期望=%02X, 实际=%02X", functionCode, responseFunctionCode)
-	}
-
-	// 解析数据
-	byteCount := response[8]
-	if byteCount != 2 {
-		return 0, fmt.Errorf("MODBUS响应数据长度不正确: %d", byteCount)
+		return 0, err
 	}
 
-	// 提取寄存器值
-	registerValue := binary.BigEndian.Uint16(response[9:11])
-
-	s.logger.Info("MODBUS读取保持寄存器成功", "ip", ipAddress, "port", port, "address", address, "value", registerValue)
-	return registerValue, nil
+	s.logger.Info("MODBUS读取保持寄存器成功", "ip", breaker.IPAddress, "port", breaker.Port, "address", address, "value", values[0])
+	return values[0], nil
 }
 
 // ReadHoldingRegister 公开的读取保持寄存器方法
@@ -841,7 +700,7 @@ func (s *ModbusService) checkCommunicationStatus(breaker *models.Breaker) *Commu
 // 在所有读取操作前调用，检测设备通信状态但不执行复位
 func (s *ModbusService) detectAndResetIfNeeded(breaker *models.Breaker) error {
 	// 尝试读取一个简单的寄存器来检测通信状态
-	_, err := s.sendModbusReadInputRegister(breaker.IPAddress, breaker.Port, 30001)
+	_, err := s.sendModbusReadInputRegister(breaker, 30001)
 	if err != nil {
 		s.logger.Warn("检测到通信异常，安全模式下不执行复位", "breaker_id", breaker.ID, "error", err)
 		return fmt.Errorf("通信异常（安全模式）: %v", err)
@@ -901,8 +760,8 @@ func (s *ModbusService) resetDevice(breaker *models.Breaker, resetType ResetType
 func (s *ModbusService) executeConfigReset(breaker *models.Breaker, result *ResetResult) error {
 	result.RecoverySteps = append(result.RecoverySteps, "执行配置复位...")
 
-	// 使用线圈00001进行配置复位
-	err := s.writeCoil(breaker, 1, 0xFF00)
+	// LX47LE-125 使用线圈00001进行配置复位
+	err := s.resetViaDriver(breaker, drivers.ResetConfig)
 	if err != nil {
 		return fmt.Errorf("配置复位命令发送失败: %v", err)
 	}
@@ -915,8 +774,8 @@ func (s *ModbusService) executeConfigReset(breaker *models.Breaker, result *Rese
 func (s *ModbusService) executeRecordsReset(breaker *models.Breaker, result *ResetResult) error {
 	result.RecoverySteps = append(result.RecoverySteps, "执行记录清零...")
 
-	// 使用线圈00005进行记录清零
-	err := s.writeCoil(breaker, 5, 0xFF00)
+	// LX47LE-125 使用线圈00005进行记录清零
+	err := s.resetViaDriver(breaker, drivers.ResetRecords)
 	if err != nil {
 		return fmt.Errorf("记录清零命令发送失败: %v", err)
 	}
@@ -925,6 +784,26 @@ func (s *ModbusService) executeRecordsReset(breaker *models.Breaker, result *Res
 	return nil
 }
 
+// resetViaDriver 通过驱动发送复位命令
+func (s *ModbusService) resetViaDriver(breaker *models.Breaker, kind drivers.ResetKind) error {
+	driver, err := s.breakerDriver(breaker)
+	if err != nil {
+		return err
+	}
+	resetter, ok := driver.(drivers.Resetter)
+	if !ok {
+		return fmt.Errorf("设备型号 %s 不支持远程复位", driver.Info().Model)
+	}
+
+	client, err := s.openClient(breaker, modbus.PriorityControl)
+	if err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	return resetter.Reset(client, kind)
+}
+
 // executeFullReset 执行完全复位
 func (s *ModbusService) executeFullReset(breaker *models.Breaker, result *ResetResult) error {
 	// 先清零记录
@@ -1002,96 +881,29 @@ func (s *ModbusService) ensureCommunication(breaker *models.Breaker) error {
 	return nil
 }
 
-// writeHoldingRegister 写入保持寄存器
-func (s *ModbusService) writeHoldingRegister(breaker *models.Breaker, address uint16, value uint16) error {
-	// 添加操作间隔，避免网关连接数限制 (基于测试文档经验)
-	time.Sleep(100 * time.Millisecond)
-
-	// 建立TCP连接 (基于测试文档的超时设置)
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", breaker.IPAddress, breaker.Port), 5*time.Second)
+// openClient 获取断路器所在网关端口（或本地串口总线）的调度器，站号取自断路器配置。
+// 同一网关下的所有断路器共用一条长连接，请求按优先级排队发送。
+func (s *ModbusService) openClient(breaker *models.Breaker, priority modbus.Priority) (*modbus.ModbusClient, error) {
+	cfg, err := breakerEndpoint(breaker)
 	if err != nil {
-		return fmt.Errorf("连接MODBUS设备失败: %w", err)
-	}
-	defer conn.Close()
-
-	// 设置读写超时 (基于测试文档)
-	conn.SetDeadline(time.Now().Add(5 * time.Second))
-
-	// 地址转换：保持寄存器地址需要减去40001
-	modbusAddress := address - 40001
-
-	s.logger.Info("发送MODBUS TCP写入保持寄存器请求",
-		"ip", breaker.IPAddress,
-		"port", breaker.Port,
-		"address", address,
-		"modbus_address", modbusAddress,
-		"value", value)
-
-	// 构建MODBUS TCP请求 (功能码06 - 写单个保持寄存器)
-	request := []byte{
-		0x00, 0x01, // 事务标识符
-		0x00, 0x00, // 协议标识符
-		0x00, 0x06, // 长度
-		byte(breaker.StationID), // 单元标识符
-		0x06,                    // 功能码06 - 写单个保持寄存器
-		byte(modbusAddress >> 8),      // 寄存器地址高字节
-		byte(modbusAddress & 0xFF),    // 寄存器地址低字节
-		byte(value >> 8),        // 寄存器值高字节
-		byte(value & 0xFF),      // 寄存器值低字节
+		return nil, err
 	}
 
-	s.logger.Info("发送MODBUS TCP写入保持寄存器请求",
-		"request_hex", fmt.Sprintf("%X", request))
-
-	// 发送请求
-	_, err = conn.Write(request)
-	if err != nil {
-		return fmt.Errorf("发送MODBUS请求失败: %w", err)
-	}
-
-	// 读取响应
-	response := make([]byte, 12)
-	n, err := conn.Read(response)
-	if err != nil {
-		return fmt.Errorf("读取MODBUS响应失败: %w", err)
-	}
-
-	s.logger.Info("收到MODBUS TCP写入保持寄存器响应",
-		"response_hex", fmt.Sprintf("%X", response[:n]),
-		"response_length", n)
-
-	if n < 12 {
-		return fmt.Errorf("MODBUS响应长度不足")
-	}
-
-	// 验证响应
-	if response[7] != 0x06 {
-		return fmt.Errorf("MODBUS响应功能码不匹配: 期望=06, 实际=%02X", response[7])
-	}
-
-	s.logger.Info("MODBUS TCP写入保持寄存器成功",
-		"ip", breaker.IPAddress,
-		"port", breaker.Port,
-		"address", address,
-		"value", value)
+	gateway := modbus.DefaultGatewayPool().Gateway(cfg)
+	return modbus.NewModbusClientWithTransport(gateway.Transport(priority), byte(breaker.StationID)), nil
+}
 
-	return nil
+// breakerEndpoint 断路器的传输层配置：rtu 报文格式使用串口参数，其余使用 IP:端口
+func breakerEndpoint(breaker *models.Breaker) (modbus.Config, error) {
+	return modbus.EndpointConfig(breaker.Framing, breaker.IPAddress, breaker.Port, serialConfig(breaker.SerialSettings))
 }
 
-// calculateCRC16 计算MODBUS RTU的CRC16校验码
-func (s *ModbusService) calculateCRC16(data []byte) uint16 {
-	crc := uint16(0xFFFF)
-
-	for _, b := range data {
-		crc ^= uint16(b)
-		for i := 0; i < 8; i++ {
-			if crc&1 != 0 {
-				crc = (crc >> 1) ^ 0xA001
-			} else {
-				crc >>= 1
-			}
-		}
+// serialConfig 把设备的串口参数转换为传输层串口配置
+func serialConfig(settings models.SerialSettings) modbus.SerialConfig {
+	return modbus.SerialConfig{
+		Device:   settings.SerialDevice,
+		BaudRate: settings.BaudRate,
+		Parity:   settings.Parity,
+		StopBits: settings.StopBits,
 	}
-
-	return crc
 }
@@ -0,0 +1,749 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+
+	"gorm.io/gorm"
+)
+
+const (
+	slaveIdleTimeout   = 5 * time.Minute  // BMS连接空闲断开时间
+	slaveDataMaxAge    = 10 * time.Minute // 温度和遥测数据超过该时长视为无数据
+	slaveMinRefresh    = time.Second
+	slaveDefaultUnitID = 0
+)
+
+// ModbusSlaveOptions 从站运行参数
+type ModbusSlaveOptions struct {
+	Listen          string
+	MapFile         string
+	RefreshInterval time.Duration
+	AllowedClients  []string // 允许连接的客户端IP或网段，为空不限制
+	WriteUser       string   // 写线圈时以该用户身份控制断路器，为空禁止写入
+}
+
+// ModbusSlaveStatus 从站运行状态
+type ModbusSlaveStatus struct {
+	Running      bool       `json:"running"`
+	Listen       string     `json:"listen"`
+	MapFile      string     `json:"map_file"`
+	UnitID       int        `json:"unit_id"`
+	Registers    int        `json:"registers"`
+	Coils        int        `json:"coils"`
+	WritableCoil int        `json:"writable_coils"`
+	WriteUser    string     `json:"write_user,omitempty"`
+	Connections  int        `json:"connections"`
+	RefreshedAt  *time.Time `json:"refreshed_at,omitempty"`
+	RefreshError string     `json:"refresh_error,omitempty"`
+	Requests     uint64     `json:"requests"`
+	Writes       uint64     `json:"writes"`
+	Rejected     uint64     `json:"rejected"`
+}
+
+// slaveImage 一次刷新得到的寄存器和线圈数据
+type slaveImage struct {
+	registers map[uint16]uint16
+	bits      map[uint16]bool
+}
+
+// ModbusSlaveService 内置MODBUS TCP从站：按寄存器映射把温度、断路器状态、功率和告警数
+// 提供给只能轮询MODBUS的BMS/SCADA，可写线圈经 BreakerService.ControlBreaker 分合闸
+type ModbusSlaveService struct {
+	db             *gorm.DB
+	breakerService *BreakerService
+	logger         *logger.Logger
+	options        ModbusSlaveOptions
+	allowed        []*net.IPNet
+
+	mutex     sync.RWMutex
+	slaveMap  *models.ModbusSlaveMap
+	image     *slaveImage
+	refreshed *time.Time
+	lastError string
+	requests  uint64
+	writes    uint64
+	rejected  uint64
+
+	server   *modbus.Server
+	stopChan chan struct{}
+}
+
+// NewModbusSlaveService 创建从站服务，加载并校验寄存器映射
+func NewModbusSlaveService(db *gorm.DB, breakerService *BreakerService, logger *logger.Logger, options ModbusSlaveOptions) (*ModbusSlaveService, error) {
+	if options.RefreshInterval < slaveMinRefresh {
+		options.RefreshInterval = slaveMinRefresh
+	}
+	allowed, err := parseAllowedClients(options.AllowedClients)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ModbusSlaveService{
+		db:             db,
+		breakerService: breakerService,
+		logger:         logger,
+		options:        options,
+		allowed:        allowed,
+		image:          &slaveImage{registers: map[uint16]uint16{}, bits: map[uint16]bool{}},
+	}
+	slaveMap, err := loadSlaveMap(options.MapFile)
+	if err != nil {
+		return nil, err
+	}
+	s.slaveMap = slaveMap
+	return s, nil
+}
+
+// Start 开始监听并定时刷新数据
+func (s *ModbusSlaveService) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.server != nil {
+		return fmt.Errorf("MODBUS从站已在运行")
+	}
+
+	server := modbus.NewServer(s, slaveIdleTimeout)
+	server.SetAllow(s.allow)
+	addr, err := server.Listen(s.options.Listen)
+	if err != nil {
+		return fmt.Errorf("MODBUS从站监听 %s 失败: %w", s.options.Listen, err)
+	}
+	s.server = server
+	s.stopChan = make(chan struct{})
+	go s.loop(s.stopChan)
+
+	s.logger.Info("启动MODBUS从站", "listen", addr.String(), "map_file", s.options.MapFile,
+		"registers", len(s.slaveMap.Registers), "coils", len(s.slaveMap.Coils), "write_user", s.options.WriteUser)
+	return nil
+}
+
+// Stop 停止从站
+func (s *ModbusSlaveService) Stop() error {
+	s.mutex.Lock()
+	server := s.server
+	if server == nil {
+		s.mutex.Unlock()
+		return fmt.Errorf("MODBUS从站未在运行")
+	}
+	s.server = nil
+	close(s.stopChan)
+	s.mutex.Unlock()
+
+	s.logger.Info("停止MODBUS从站")
+	return server.Close()
+}
+
+// Reload 重新加载寄存器映射文件并立即刷新数据
+func (s *ModbusSlaveService) Reload() (*ModbusSlaveStatus, error) {
+	slaveMap, err := loadSlaveMap(s.options.MapFile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.slaveMap = slaveMap
+	s.mutex.Unlock()
+
+	s.refresh()
+	s.logger.Info("重新加载MODBUS从站寄存器映射", "registers", len(slaveMap.Registers), "coils", len(slaveMap.Coils))
+	return s.GetStatus(), nil
+}
+
+// GetStatus 获取从站运行状态
+func (s *ModbusSlaveService) GetStatus() *ModbusSlaveStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	status := &ModbusSlaveStatus{
+		Running:      s.server != nil,
+		Listen:       s.options.Listen,
+		MapFile:      s.options.MapFile,
+		UnitID:       s.slaveMap.UnitID,
+		Registers:    len(s.slaveMap.Registers),
+		Coils:        len(s.slaveMap.Coils),
+		WriteUser:    s.options.WriteUser,
+		RefreshedAt:  s.refreshed,
+		RefreshError: s.lastError,
+		Requests:     s.requests,
+		Writes:       s.writes,
+		Rejected:     s.rejected,
+	}
+	for _, point := range s.slaveMap.Coils {
+		if point.Writable {
+			status.WritableCoil++
+		}
+	}
+	if s.server != nil {
+		status.Connections = s.server.Connections()
+	}
+	return status
+}
+
+func (s *ModbusSlaveService) loop(stop <-chan struct{}) {
+	s.refresh()
+
+	ticker := time.NewTicker(s.options.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.refresh()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// refresh 从数据库读取映射中用到的最新数据，生成新的寄存器镜像
+func (s *ModbusSlaveService) refresh() {
+	s.mutex.RLock()
+	slaveMap := s.slaveMap
+	s.mutex.RUnlock()
+
+	values, err := s.collect(slaveMap)
+	image := buildSlaveImage(slaveMap, values)
+	now := time.Now().UTC()
+
+	s.mutex.Lock()
+	s.image = image
+	s.refreshed = &now
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+	}
+	s.mutex.Unlock()
+
+	if err != nil {
+		s.logger.Warn("MODBUS从站数据刷新不完整", "error", err)
+	}
+}
+
+// slaveValues 映射中数据点的当前值，缺少的数据点视为无数据
+type slaveValues map[string]float64
+
+func slavePointKey(p models.ModbusSlavePoint) string {
+	switch p.Source {
+	case models.SlaveSourceTemperature:
+		return fmt.Sprintf("%s:%d:%d", p.Source, p.SensorID, p.Channel)
+	case models.SlaveSourceAlarmCount:
+		return fmt.Sprintf("%s:%s", p.Source, p.Severity)
+	}
+	return fmt.Sprintf("%s:%d", p.Source, p.BreakerID)
+}
+
+// collect 读取映射用到的温度、断路器、遥测和告警数据
+func (s *ModbusSlaveService) collect(slaveMap *models.ModbusSlaveMap) (slaveValues, error) {
+	values := make(slaveValues)
+	points := append(append([]models.ModbusSlavePoint{}, slaveMap.Registers...), slaveMap.Coils...)
+	since := time.Now().Add(-slaveDataMaxAge).UTC()
+
+	var errs []error
+	breakerIDs := make(map[uint]bool)
+	channels := make(map[string]models.ModbusSlavePoint)
+	wantAlarms := false
+	for _, p := range points {
+		switch p.Source {
+		case models.SlaveSourceTemperature:
+			channels[slavePointKey(p)] = p
+		case models.SlaveSourceAlarmCount:
+			wantAlarms = true
+		default:
+			breakerIDs[p.BreakerID] = true
+		}
+	}
+
+	for key, p := range channels {
+		var readings []struct {
+			Temperature float64
+			Status      string
+		}
+		err := s.db.Raw(`SELECT temperature, status FROM temperature_readings
+			WHERE sensor_id = ? AND channel = ? AND recorded_at > ?
+			ORDER BY recorded_at DESC LIMIT 1`, p.SensorID, p.Channel, since).Scan(&readings).Error
+		if err != nil {
+			errs = append(errs, fmt.Errorf("读取温度失败: %w", err))
+			break
+		}
+		// 最新读数被健康检测判为异常时按无数据处理
+		if len(readings) > 0 && readings[0].Status == "normal" {
+			values[key] = readings[0].Temperature
+		}
+	}
+
+	if len(breakerIDs) > 0 {
+		ids := make([]uint, 0, len(breakerIDs))
+		for id := range breakerIDs {
+			ids = append(ids, id)
+		}
+
+		var breakers []models.Breaker
+		if err := s.db.Select("id", "status", "is_locked").Where("id IN ?", ids).Find(&breakers).Error; err != nil {
+			errs = append(errs, fmt.Errorf("读取断路器状态失败: %w", err))
+		}
+		for _, b := range breakers {
+			values[fmt.Sprintf("%s:%d", models.SlaveSourceBreakerClosed, b.ID)] = boolValue(b.Status == models.SwitchStatusOn)
+			values[fmt.Sprintf("%s:%d", models.SlaveSourceBreakerLocked, b.ID)] = boolValue(b.IsLocked)
+		}
+
+		var counters []models.BreakerEnergyCounter
+		if err := s.db.Where("breaker_id IN ?", ids).Find(&counters).Error; err != nil {
+			errs = append(errs, fmt.Errorf("读取累计电量失败: %w", err))
+		}
+		for _, c := range counters {
+			values[fmt.Sprintf("%s:%d", models.SlaveSourceBreakerEnergy, c.BreakerID)] = c.TotalKWh
+		}
+
+		for _, id := range ids {
+			var samples []models.BreakerTelemetrySample
+			err := s.db.Where("breaker_id = ? AND sampled_at > ?", id, since).
+				Order("sampled_at DESC").Limit(1).Find(&samples).Error
+			if err != nil {
+				errs = append(errs, fmt.Errorf("读取断路器遥测失败: %w", err))
+				break
+			}
+			if len(samples) > 0 {
+				values[fmt.Sprintf("%s:%d", models.SlaveSourceBreakerPower, id)] = samples[0].ActivePower
+				values[fmt.Sprintf("%s:%d", models.SlaveSourceBreakerVoltage, id)] = samples[0].Voltage
+				values[fmt.Sprintf("%s:%d", models.SlaveSourceBreakerCurrent, id)] = samples[0].Current
+			}
+		}
+	}
+
+	if wantAlarms {
+		counts, err := s.activeAlarmCounts()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			total := 0
+			for _, n := range counts {
+				total += n
+			}
+			for _, p := range points {
+				if p.Source != models.SlaveSourceAlarmCount {
+					continue
+				}
+				if p.Severity == "" {
+					values[slavePointKey(p)] = float64(total)
+				} else {
+					values[slavePointKey(p)] = float64(counts[p.Severity])
+				}
+			}
+		}
+	}
+
+	return values, errors.Join(errs...)
+}
+
+// activeAlarmCounts 按级别统计未恢复的告警（alarm_records 表），表不存在时视为没有告警
+func (s *ModbusSlaveService) activeAlarmCounts() (map[string]int, error) {
+	counts := make(map[string]int)
+	if !s.db.Migrator().HasTable("alarm_records") {
+		return counts, nil
+	}
+
+	var rows []struct {
+		Severity string
+		Count    int
+	}
+	err := s.db.Raw(`SELECT severity, COUNT(*) AS count FROM alarm_records
+		WHERE status IN ('active', 'acknowledged') GROUP BY severity`).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计告警失败: %w", err)
+	}
+	for _, row := range rows {
+		counts[row.Severity] = row.Count
+	}
+	return counts, nil
+}
+
+// buildSlaveImage 按数据类型和倍率编码寄存器，无数据时有符号类型为最小值、无符号类型为最大值、float32为NaN
+func buildSlaveImage(slaveMap *models.ModbusSlaveMap, values slaveValues) *slaveImage {
+	image := &slaveImage{
+		registers: make(map[uint16]uint16),
+		bits:      make(map[uint16]bool),
+	}
+
+	for _, p := range slaveMap.Registers {
+		value, ok := values[slavePointKey(p)]
+		words := encodeSlaveValue(p, value, ok)
+		for i, w := range words {
+			image.registers[p.Address+uint16(i)] = w
+		}
+	}
+	for _, p := range slaveMap.Coils {
+		image.bits[p.Address] = values[slavePointKey(p)] != 0
+	}
+	return image
+}
+
+func encodeSlaveValue(p models.ModbusSlavePoint, value float64, ok bool) []uint16 {
+	scale := p.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	scaled := math.Round(value * scale)
+
+	switch p.Type {
+	case models.SlaveTypeUint16:
+		if !ok {
+			return []uint16{math.MaxUint16}
+		}
+		return []uint16{uint16(clamp(scaled, 0, math.MaxUint16))}
+	case models.SlaveTypeUint32:
+		v := uint32(math.MaxUint32)
+		if ok {
+			v = uint32(clamp(scaled, 0, math.MaxUint32))
+		}
+		return []uint16{uint16(v >> 16), uint16(v)}
+	case models.SlaveTypeInt32:
+		v := int32(math.MinInt32)
+		if ok {
+			v = int32(clamp(scaled, math.MinInt32+1, math.MaxInt32))
+		}
+		return []uint16{uint16(uint32(v) >> 16), uint16(uint32(v))}
+	case models.SlaveTypeFloat32:
+		v := math.Float32bits(float32(math.NaN()))
+		if ok {
+			v = math.Float32bits(float32(value * scale))
+		}
+		return []uint16{uint16(v >> 16), uint16(v)}
+	}
+
+	v := int16(math.MinInt16)
+	if ok {
+		v = int16(clamp(scaled, math.MinInt16+1, math.MaxInt16))
+	}
+	return []uint16{uint16(v)}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ReadBits 读线圈/离散输入
+func (s *ModbusSlaveService) ReadBits(req modbus.ServerRequest) ([]bool, error) {
+	image, slaveMap := s.snapshot()
+	if err := checkSlaveUnit(slaveMap, req.UnitID); err != nil {
+		return nil, err
+	}
+
+	bits := make([]bool, req.Quantity)
+	defined := false
+	for i := range bits {
+		v, ok := image.bits[req.Address+uint16(i)]
+		bits[i] = v
+		defined = defined || ok
+	}
+	if !defined {
+		return nil, &modbus.ExceptionError{FunctionCode: req.FunctionCode, Code: modbus.ExceptionIllegalAddress}
+	}
+	return bits, nil
+}
+
+// ReadRegisters 读保持/输入寄存器，请求范围内未映射的地址读为0，整个范围都未映射时返回非法地址
+func (s *ModbusSlaveService) ReadRegisters(req modbus.ServerRequest) ([]uint16, error) {
+	image, slaveMap := s.snapshot()
+	if err := checkSlaveUnit(slaveMap, req.UnitID); err != nil {
+		return nil, err
+	}
+
+	values := make([]uint16, req.Quantity)
+	defined := false
+	for i := range values {
+		v, ok := image.registers[req.Address+uint16(i)]
+		values[i] = v
+		defined = defined || ok
+	}
+	if !defined {
+		return nil, &modbus.ExceptionError{FunctionCode: req.FunctionCode, Code: modbus.ExceptionIllegalAddress}
+	}
+	return values, nil
+}
+
+// WriteRegisters 寄存器只读
+func (s *ModbusSlaveService) WriteRegisters(req modbus.ServerRequest, values []uint16) error {
+	s.reject()
+	return &modbus.ExceptionError{FunctionCode: req.FunctionCode, Code: modbus.ExceptionIllegalFunction}
+}
+
+// WriteCoils 写可写线圈分合闸断路器：先校验全部线圈、写入用户权限和断路器锁定状态，
+// 再逐个经 BreakerService.ControlBreaker 下发（与REST接口相同的控制记录和执行流程）
+func (s *ModbusSlaveService) WriteCoils(req modbus.ServerRequest, values []bool) error {
+	_, slaveMap := s.snapshot()
+	if err := checkSlaveUnit(slaveMap, req.UnitID); err != nil {
+		return err
+	}
+	exception := func(code byte) error {
+		s.reject()
+		return &modbus.ExceptionError{FunctionCode: req.FunctionCode, Code: code}
+	}
+
+	points := make(map[uint16]models.ModbusSlavePoint)
+	for _, p := range slaveMap.Coils {
+		points[p.Address] = p
+	}
+	targets := make([]models.ModbusSlavePoint, len(values))
+	for i := range values {
+		p, ok := points[req.Address+uint16(i)]
+		if !ok || !p.Writable {
+			s.logger.Warn("MODBUS从站拒绝写入只读或未映射的线圈", "address", req.Address+uint16(i), "client", req.RemoteAddr.String())
+			return exception(modbus.ExceptionIllegalAddress)
+		}
+		targets[i] = p
+	}
+
+	if s.options.WriteUser == "" {
+		s.logger.Warn("MODBUS从站未配置写入用户，拒绝写线圈", "client", req.RemoteAddr.String())
+		return exception(modbus.ExceptionIllegalFunction)
+	}
+	if err := s.checkWriteUser(); err != nil {
+		s.logger.Warn("MODBUS从站写入用户无权控制断路器", "user", s.options.WriteUser, "client", req.RemoteAddr.String(), "error", err)
+		return exception(modbus.ExceptionServerDeviceFailed)
+	}
+
+	breakers := make([]*models.Breaker, len(targets))
+	for i, p := range targets {
+		var breaker models.Breaker
+		if err := s.db.First(&breaker, p.BreakerID).Error; err != nil {
+			s.logger.Warn("MODBUS从站写线圈对应的断路器不存在", "breaker_id", p.BreakerID, "error", err)
+			return exception(modbus.ExceptionServerDeviceFailed)
+		}
+		if breaker.IsLocked {
+			s.logger.Warn("断路器已锁定，拒绝MODBUS从站分合闸", "breaker_id", breaker.ID, "client", req.RemoteAddr.String())
+			return exception(modbus.ExceptionServerDeviceFailed)
+		}
+		breakers[i] = &breaker
+	}
+
+	for i, p := range targets {
+		action, want := models.BreakerActionOff, models.SwitchStatusOff
+		if values[i] {
+			action, want = models.BreakerActionOn, models.SwitchStatusOn
+		}
+		// BMS常周期性重复写入同一值，状态已一致时不再动作
+		if breakers[i].Status == want {
+			continue
+		}
+
+		control, err := s.breakerService.ControlBreaker(p.BreakerID, models.BreakerControlRequest{
+			Action:       action,
+			Confirmation: "modbus-slave",
+			Reason: fmt.Sprintf("MODBUS从站写线圈 %d（客户端 %s，用户 %s）", p.Address,
+				req.RemoteAddr.String(), s.options.WriteUser),
+		})
+		if err != nil {
+			s.logger.Error("MODBUS从站控制断路器失败", "breaker_id", p.BreakerID, "action", action, "error", err)
+			return exception(modbus.ExceptionServerDeviceFailed)
+		}
+
+		s.mutex.Lock()
+		s.writes++
+		s.mutex.Unlock()
+		s.logger.Info("MODBUS从站下发断路器控制", "breaker_id", p.BreakerID, "action", action,
+			"control_id", control.ControlID, "client", req.RemoteAddr.String(), "user", s.options.WriteUser)
+	}
+	return nil
+}
+
+// checkWriteUser 写入用户需为启用的管理员或操作员（与REST控制接口的 RequireOperator 一致），每次写入时重新检查
+func (s *ModbusSlaveService) checkWriteUser() error {
+	var user models.User
+	if err := s.db.Where("username = ?", s.options.WriteUser).First(&user).Error; err != nil {
+		return fmt.Errorf("用户不存在: %w", err)
+	}
+	if !user.IsActive() {
+		return fmt.Errorf("用户未启用")
+	}
+	if user.Role != models.RoleAdmin && user.Role != models.RoleOperator {
+		return fmt.Errorf("用户角色 %s 无控制权限", user.Role)
+	}
+	return nil
+}
+
+func (s *ModbusSlaveService) snapshot() (*slaveImage, *models.ModbusSlaveMap) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests++
+	return s.image, s.slaveMap
+}
+
+func (s *ModbusSlaveService) reject() {
+	s.mutex.Lock()
+	s.rejected++
+	s.mutex.Unlock()
+}
+
+// allow 检查客户端地址是否在允许列表中
+func (s *ModbusSlaveService) allow(remote net.Addr) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+	tcp, ok := remote.(*net.TCPAddr)
+	if ok {
+		for _, network := range s.allowed {
+			if network.Contains(tcp.IP) {
+				return true
+			}
+		}
+	}
+	s.logger.Warn("拒绝不在允许列表中的MODBUS从站客户端", "client", remote.String())
+	return false
+}
+
+func checkSlaveUnit(slaveMap *models.ModbusSlaveMap, unitID byte) error {
+	if slaveMap.UnitID != slaveDefaultUnitID && int(unitID) != slaveMap.UnitID {
+		return &modbus.ExceptionError{Code: modbus.ExceptionGatewayTargetFailed}
+	}
+	return nil
+}
+
+// parseAllowedClients 解析IP或网段列表
+func parseAllowedClients(clients []string) ([]*net.IPNet, error) {
+	var result []*net.IPNet
+	for _, client := range clients {
+		client = strings.TrimSpace(client)
+		if client == "" {
+			continue
+		}
+		if !strings.Contains(client, "/") {
+			ip := net.ParseIP(client)
+			if ip == nil {
+				return nil, fmt.Errorf("无效的客户端地址: %s", client)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(client)
+		if err != nil {
+			return nil, fmt.Errorf("无效的客户端网段: %s", client)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
+// loadSlaveMap 读取并校验寄存器映射文件
+func loadSlaveMap(path string) (*models.ModbusSlaveMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取MODBUS从站寄存器映射失败: %w", err)
+	}
+	var slaveMap models.ModbusSlaveMap
+	if err := json.Unmarshal(data, &slaveMap); err != nil {
+		return nil, fmt.Errorf("解析MODBUS从站寄存器映射失败: %w", err)
+	}
+	if err := validateSlaveMap(&slaveMap); err != nil {
+		return nil, fmt.Errorf("MODBUS从站寄存器映射无效: %w", err)
+	}
+	return &slaveMap, nil
+}
+
+// validateSlaveMap 校验数据来源、数据类型和地址重叠
+func validateSlaveMap(slaveMap *models.ModbusSlaveMap) error {
+	if slaveMap.UnitID < 0 || slaveMap.UnitID > 255 {
+		return fmt.Errorf("unit_id 超出范围: %d", slaveMap.UnitID)
+	}
+
+	type span struct {
+		from, to int
+		name     string
+	}
+	checkOverlap := func(spans []span) error {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+		for i := 1; i < len(spans); i++ {
+			if spans[i].from < spans[i-1].to {
+				return fmt.Errorf("地址 %d 与 %s 重叠", spans[i].from, spans[i-1].name)
+			}
+		}
+		return nil
+	}
+
+	var spans []span
+	for i := range slaveMap.Registers {
+		p := &slaveMap.Registers[i]
+		if err := validateSlavePoint(p); err != nil {
+			return fmt.Errorf("寄存器 %d: %w", p.Address, err)
+		}
+		if p.Writable {
+			return fmt.Errorf("寄存器 %d: 寄存器不支持写入", p.Address)
+		}
+		if p.Type == "" {
+			p.Type = models.SlaveTypeInt16
+		}
+		width := 1
+		switch p.Type {
+		case models.SlaveTypeInt16, models.SlaveTypeUint16:
+		case models.SlaveTypeInt32, models.SlaveTypeUint32, models.SlaveTypeFloat32:
+			width = 2
+		default:
+			return fmt.Errorf("寄存器 %d: 不支持的数据类型 %s", p.Address, p.Type)
+		}
+		if int(p.Address)+width > 0x10000 {
+			return fmt.Errorf("寄存器 %d: 地址超出范围", p.Address)
+		}
+		spans = append(spans, span{int(p.Address), int(p.Address) + width, fmt.Sprintf("寄存器 %d", p.Address)})
+	}
+	if err := checkOverlap(spans); err != nil {
+		return err
+	}
+
+	spans = spans[:0]
+	for i := range slaveMap.Coils {
+		p := &slaveMap.Coils[i]
+		if err := validateSlavePoint(p); err != nil {
+			return fmt.Errorf("线圈 %d: %w", p.Address, err)
+		}
+		if p.Writable && p.Source != models.SlaveSourceBreakerClosed {
+			return fmt.Errorf("线圈 %d: 只有 %s 线圈可写", p.Address, models.SlaveSourceBreakerClosed)
+		}
+		spans = append(spans, span{int(p.Address), int(p.Address) + 1, fmt.Sprintf("线圈 %d", p.Address)})
+	}
+	return checkOverlap(spans)
+}
+
+func validateSlavePoint(p *models.ModbusSlavePoint) error {
+	switch p.Source {
+	case models.SlaveSourceTemperature:
+		if p.SensorID == 0 || p.Channel <= 0 {
+			return fmt.Errorf("temperature 需要 sensor_id 和 channel")
+		}
+	case models.SlaveSourceBreakerClosed, models.SlaveSourceBreakerLocked, models.SlaveSourceBreakerPower,
+		models.SlaveSourceBreakerVoltage, models.SlaveSourceBreakerCurrent, models.SlaveSourceBreakerEnergy:
+		if p.BreakerID == 0 {
+			return fmt.Errorf("%s 需要 breaker_id", p.Source)
+		}
+	case models.SlaveSourceAlarmCount:
+	default:
+		return fmt.Errorf("不支持的数据来源: %q", p.Source)
+	}
+	if p.Scale < 0 {
+		return fmt.Errorf("scale 不能为负数")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"smart-device-management/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeSlaveValue(t *testing.T) {
+	temperature := models.ModbusSlavePoint{Type: models.SlaveTypeInt16, Scale: 10}
+	assert.Equal(t, []uint16{0xFF9C}, encodeSlaveValue(temperature, -10, true))
+	assert.Equal(t, []uint16{256}, encodeSlaveValue(temperature, 25.55, true))
+	assert.Equal(t, []uint16{0x8000}, encodeSlaveValue(temperature, 0, false))
+
+	power := models.ModbusSlavePoint{Type: models.SlaveTypeUint32}
+	assert.Equal(t, []uint16{0x0001, 0x86A0}, encodeSlaveValue(power, 100000, true))
+	assert.Equal(t, []uint16{0xFFFF, 0xFFFF}, encodeSlaveValue(power, 0, false))
+	assert.Equal(t, []uint16{0, 0}, encodeSlaveValue(power, -5, true))
+
+	energy := models.ModbusSlavePoint{Type: models.SlaveTypeFloat32}
+	bits := math.Float32bits(12.5)
+	assert.Equal(t, []uint16{uint16(bits >> 16), uint16(bits)}, encodeSlaveValue(energy, 12.5, true))
+}
+
+func TestValidateSlaveMap(t *testing.T) {
+	valid := &models.ModbusSlaveMap{
+		UnitID: 1,
+		Registers: []models.ModbusSlavePoint{
+			{Address: 0, Source: models.SlaveSourceTemperature, SensorID: 1, Channel: 1},
+			{Address: 1, Source: models.SlaveSourceBreakerPower, BreakerID: 1, Type: models.SlaveTypeUint32},
+			{Address: 3, Source: models.SlaveSourceAlarmCount},
+		},
+		Coils: []models.ModbusSlavePoint{
+			{Address: 0, Source: models.SlaveSourceBreakerClosed, BreakerID: 1, Writable: true},
+		},
+	}
+	require.NoError(t, validateSlaveMap(valid))
+	assert.Equal(t, models.SlaveTypeInt16, valid.Registers[0].Type)
+
+	invalid := []models.ModbusSlaveMap{
+		// 32位寄存器与下一个地址重叠
+		{Registers: []models.ModbusSlavePoint{
+			{Address: 0, Source: models.SlaveSourceBreakerPower, BreakerID: 1, Type: models.SlaveTypeFloat32},
+			{Address: 1, Source: models.SlaveSourceAlarmCount},
+		}},
+		{Registers: []models.ModbusSlavePoint{{Address: 0, Source: "humidity"}}},
+		{Registers: []models.ModbusSlavePoint{{Address: 0, Source: models.SlaveSourceTemperature, SensorID: 1}}},
+		{Registers: []models.ModbusSlavePoint{{Address: 0, Source: models.SlaveSourceBreakerPower, BreakerID: 1, Type: "int64"}}},
+		{Coils: []models.ModbusSlavePoint{{Address: 0, Source: models.SlaveSourceBreakerLocked, BreakerID: 1, Writable: true}}},
+		{Coils: []models.ModbusSlavePoint{{Address: 0, Source: models.SlaveSourceBreakerClosed}}},
+	}
+	for i := range invalid {
+		assert.Error(t, validateSlaveMap(&invalid[i]), "case %d", i)
+	}
+}
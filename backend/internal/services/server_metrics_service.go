@@ -0,0 +1,357 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/ssh"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// serverMetricsSSHTimeout 采集负载指标的SSH连接超时
+	serverMetricsSSHTimeout = 10 * time.Second
+	// defaultServerMetricsMaxAge 未配置时策略条件的指标有效时间
+	defaultServerMetricsMaxAge = 5 * time.Minute
+	// serverMetricsCommand 两次读取 /proc/stat 间隔1秒计算CPU使用率，同时读取内存、根分区和1分钟负载
+	serverMetricsCommand = "head -n1 /proc/stat; sleep 1; head -n1 /proc/stat; " +
+		"grep -E '^(MemTotal|MemAvailable):' /proc/meminfo; df -P / | tail -n1; cat /proc/loadavg"
+)
+
+// serverMetricColumns 策略条件负载类型对应的指标列
+var serverMetricColumns = map[string]string{
+	"cpu":    "cpu_usage",
+	"memory": "memory_usage",
+	"disk":   "disk_usage",
+}
+
+// ServerMetricsService 服务器负载指标：定时通过SSH采集受监控服务器的CPU、内存、磁盘使用率，
+// 也接收代理上报，供AI策略的 server_load 条件按时间窗口求平均
+type ServerMetricsService struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	interval  time.Duration
+	maxAge    time.Duration
+	retention time.Duration
+
+	collecting sync.Map // 服务器ID -> 正在采集，避免慢连接重叠
+	lastPrune  time.Time
+
+	mutex     sync.Mutex
+	isRunning bool
+	stopChan  chan struct{}
+}
+
+// NewServerMetricsService 创建服务器负载指标服务。interval 为0时不通过SSH采集，只接收代理上报；
+// maxAge 为策略条件默认的指标有效时间，retention 为0时不清理历史指标
+func NewServerMetricsService(db *gorm.DB, logger *logger.Logger, interval, maxAge, retention time.Duration) *ServerMetricsService {
+	if maxAge <= 0 {
+		maxAge = defaultServerMetricsMaxAge
+	}
+	return &ServerMetricsService{
+		db:        db,
+		logger:    logger,
+		interval:  interval,
+		maxAge:    maxAge,
+		retention: retention,
+	}
+}
+
+// Start 启动SSH定时采集
+func (s *ServerMetricsService) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("服务器负载采集已在运行")
+	}
+	if s.interval <= 0 {
+		return fmt.Errorf("未配置服务器负载采集间隔")
+	}
+
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	go s.loop(s.stopChan)
+
+	s.logger.Info("启动服务器负载采集", "interval", s.interval.String(), "max_age", s.maxAge.String())
+	return nil
+}
+
+// Stop 停止SSH定时采集
+func (s *ServerMetricsService) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning {
+		return fmt.Errorf("服务器负载采集未在运行")
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+	s.logger.Info("停止服务器负载采集")
+	return nil
+}
+
+func (s *ServerMetricsService) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.CollectAll()
+	for {
+		select {
+		case <-ticker.C:
+			s.CollectAll()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// CollectAll 对所有受监控的SSH服务器各发起一次采集，上一次采集尚未结束的服务器跳过
+func (s *ServerMetricsService) CollectAll() {
+	var servers []models.Server
+	err := s.db.Where("is_monitored = ? AND protocol = ? AND username <> ?", true, "SSH", "").Find(&servers).Error
+	if err != nil {
+		s.logger.Error("获取受监控服务器失败", "error", err)
+		return
+	}
+
+	for i := range servers {
+		server := servers[i]
+		if _, busy := s.collecting.LoadOrStore(server.ID, true); busy {
+			continue
+		}
+		go func() {
+			defer s.collecting.Delete(server.ID)
+			metric, err := s.CollectViaSSH(&server)
+			if err != nil {
+				s.logger.Warn("采集服务器负载失败", "server_id", server.ID, "ip_address", server.IPAddress, "error", err)
+				return
+			}
+			if err := s.db.Create(metric).Error; err != nil {
+				s.logger.Error("保存服务器负载失败", "server_id", server.ID, "error", err)
+			}
+		}()
+	}
+
+	now := time.Now().UTC()
+	if s.retention > 0 && now.Sub(s.lastPrune) >= time.Hour {
+		s.lastPrune = now
+		if err := s.db.Where("collected_at < ?", now.Add(-s.retention)).Delete(&models.ServerMetric{}).Error; err != nil {
+			s.logger.Error("清理服务器负载指标失败", "error", err)
+		}
+	}
+}
+
+// CollectViaSSH 通过SSH读取服务器的负载指标
+func (s *ServerMetricsService) CollectViaSSH(server *models.Server) (*models.ServerMetric, error) {
+	port := server.Port
+	if port == 0 {
+		port = 22
+	}
+	var client *ssh.SSHClient
+	if server.PrivateKey != "" {
+		client = ssh.NewSSHClientWithKey(server.IPAddress, port, server.Username, server.PrivateKey)
+	} else {
+		client = ssh.NewSSHClient(server.IPAddress, port, server.Username, server.Password)
+	}
+	client.SetTimeout(serverMetricsSSHTimeout)
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	defer client.Disconnect()
+
+	result, err := client.ExecuteCommand(serverMetricsCommand)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("采集命令退出码 %d: %s", result.ExitCode, strings.TrimSpace(result.Error))
+	}
+
+	metric, err := parseServerMetrics(result.Output)
+	if err != nil {
+		return nil, err
+	}
+	metric.ServerID = server.ID
+	metric.Source = models.ServerMetricSourceSSH
+	metric.CollectedAt = time.Now().UTC()
+	return metric, nil
+}
+
+// Record 保存代理上报的负载指标
+func (s *ServerMetricsService) Record(serverID uint, report models.ServerMetricReport) (*models.ServerMetric, error) {
+	var server models.Server
+	if err := s.db.Select("id").First(&server, serverID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("服务器不存在")
+		}
+		return nil, fmt.Errorf("获取服务器失败: %w", err)
+	}
+	if report.CPUUsage == nil && report.MemoryUsage == nil && report.DiskUsage == nil && report.Load1 == nil {
+		return nil, fmt.Errorf("至少需要上报一项负载指标")
+	}
+
+	now := time.Now().UTC()
+	collectedAt := now
+	if report.CollectedAt != nil {
+		collectedAt = report.CollectedAt.UTC()
+		if collectedAt.After(now.Add(time.Minute)) {
+			return nil, fmt.Errorf("采集时间 %s 晚于平台时间，请检查服务器时钟", collectedAt.Format(time.RFC3339))
+		}
+	}
+
+	metric := &models.ServerMetric{
+		ServerID:    serverID,
+		CPUUsage:    report.CPUUsage,
+		MemoryUsage: report.MemoryUsage,
+		DiskUsage:   report.DiskUsage,
+		Load1:       report.Load1,
+		Source:      models.ServerMetricSourceAgent,
+		CollectedAt: collectedAt,
+	}
+	if err := s.db.Create(metric).Error; err != nil {
+		return nil, fmt.Errorf("保存服务器负载失败: %w", err)
+	}
+	return metric, nil
+}
+
+// ListMetrics 获取服务器在 since 之后的负载指标，按时间倒序
+func (s *ServerMetricsService) ListMetrics(serverID uint, since time.Time, limit int) ([]models.ServerMetric, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	var metrics []models.ServerMetric
+	err := s.db.Where("server_id = ? AND collected_at >= ?", serverID, since).Order("collected_at DESC").Limit(limit).Find(&metrics).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取服务器负载失败: %w", err)
+	}
+	return metrics, nil
+}
+
+// LoadAverage 服务器某项负载（cpu/memory/disk）在 now 之前 window 内的平均值，window 为0时取最新值。
+// 没有采样或最新采样早于 maxAge（为0时使用默认有效时间）时 known 为 false，表示结果未知
+func (s *ServerMetricsService) LoadAverage(serverID uint, loadType string, window, maxAge time.Duration, now time.Time) (value float64, known bool, err error) {
+	column, ok := serverMetricColumns[loadType]
+	if !ok {
+		return 0, false, fmt.Errorf("不支持的负载类型: %s", loadType)
+	}
+	if maxAge <= 0 {
+		maxAge = s.maxAge
+	}
+
+	var latest []models.ServerMetric
+	err = s.db.Where("server_id = ? AND "+column+" IS NOT NULL AND collected_at <= ?", serverID, now).
+		Order("collected_at DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return 0, false, fmt.Errorf("获取服务器负载失败: %w", err)
+	}
+	if len(latest) == 0 || now.Sub(latest[0].CollectedAt) > maxAge {
+		return 0, false, nil
+	}
+	if window <= 0 {
+		return *serverMetricValue(&latest[0], loadType), true, nil
+	}
+
+	var result struct {
+		Count   int64
+		Average float64
+	}
+	err = s.db.Model(&models.ServerMetric{}).
+		Select("COUNT("+column+") AS count, COALESCE(AVG("+column+"), 0) AS average").
+		Where("server_id = ? AND collected_at BETWEEN ? AND ?", serverID, now.Add(-window), now).
+		Scan(&result).Error
+	if err != nil {
+		return 0, false, fmt.Errorf("计算服务器负载平均值失败: %w", err)
+	}
+	if result.Count == 0 {
+		// 窗口短于采集间隔时窗口内可能没有采样，使用有效期内的最新值
+		return *serverMetricValue(&latest[0], loadType), true, nil
+	}
+	return result.Average, true, nil
+}
+
+// serverMetricValue 采样中负载类型对应的值
+func serverMetricValue(metric *models.ServerMetric, loadType string) *float64 {
+	switch loadType {
+	case "cpu":
+		return metric.CPUUsage
+	case "memory":
+		return metric.MemoryUsage
+	case "disk":
+		return metric.DiskUsage
+	}
+	return nil
+}
+
+// parseServerMetrics 解析 serverMetricsCommand 的输出
+func parseServerMetrics(output string) (*models.ServerMetric, error) {
+	metric := &models.ServerMetric{}
+	var cpuSamples [][]uint64
+	var memTotal, memAvailable float64
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case fields[0] == "cpu":
+			var sample []uint64
+			for _, field := range fields[1:] {
+				v, err := strconv.ParseUint(field, 10, 64)
+				if err != nil {
+					break
+				}
+				sample = append(sample, v)
+			}
+			if len(sample) >= 4 {
+				cpuSamples = append(cpuSamples, sample)
+			}
+		case fields[0] == "MemTotal:" && len(fields) >= 2:
+			memTotal, _ = strconv.ParseFloat(fields[1], 64)
+		case fields[0] == "MemAvailable:" && len(fields) >= 2:
+			memAvailable, _ = strconv.ParseFloat(fields[1], 64)
+		case len(fields) == 6 && fields[5] == "/" && strings.HasSuffix(fields[4], "%"):
+			if v, err := strconv.ParseFloat(strings.TrimSuffix(fields[4], "%"), 64); err == nil {
+				metric.DiskUsage = &v
+			}
+		case len(fields) == 5 && strings.Contains(fields[3], "/"):
+			if v, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				metric.Load1 = &v
+			}
+		}
+	}
+
+	if len(cpuSamples) == 2 {
+		var total, idle float64
+		for i := range cpuSamples[1] {
+			if i >= len(cpuSamples[0]) {
+				break
+			}
+			delta := float64(cpuSamples[1][i]) - float64(cpuSamples[0][i])
+			total += delta
+			if i == 3 || i == 4 { // idle、iowait
+				idle += delta
+			}
+		}
+		if total > 0 {
+			usage := math.Round((1-idle/total)*10000) / 100
+			metric.CPUUsage = &usage
+		}
+	}
+	if memTotal > 0 {
+		usage := math.Round((1-memAvailable/memTotal)*10000) / 100
+		metric.MemoryUsage = &usage
+	}
+
+	if metric.CPUUsage == nil && metric.MemoryUsage == nil && metric.DiskUsage == nil {
+		return nil, fmt.Errorf("无法解析服务器负载: %s", strings.TrimSpace(output))
+	}
+	return metric, nil
+}
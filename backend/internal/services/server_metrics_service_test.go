@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestParseServerMetrics(t *testing.T) {
+	output := `cpu  1000 0 500 8000 500 0 0 0 0 0
+cpu  1010 0 510 8170 510 0 0 0 0 0
+MemTotal:       16000000 kB
+MemAvailable:    4000000 kB
+/dev/sda1        102400000  38000000  64400000      38% /
+0.52 0.58 0.59 1/389 12345
+`
+	metric, err := parseServerMetrics(output)
+	require.NoError(t, err)
+	require.NotNil(t, metric.CPUUsage)
+	assert.InDelta(t, 10, *metric.CPUUsage, 0.01) // 200 个时钟周期中空闲 180
+	assert.InDelta(t, 75, *metric.MemoryUsage, 0.01)
+	assert.InDelta(t, 38, *metric.DiskUsage, 0.01)
+	assert.InDelta(t, 0.52, *metric.Load1, 0.001)
+
+	_, err = parseServerMetrics("bash: /proc/stat: No such file or directory")
+	assert.Error(t, err)
+}
+
+func TestServerLoadCondition(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Server{}, &models.ServerMetric{}))
+	server := models.Server{ServerName: "非关键业务", IPAddress: "10.0.0.8", Protocol: "SSH"}
+	require.NoError(t, db.Create(&server).Error)
+
+	service := NewServerMetricsService(db, logger.NewLogger(), 0, 5*time.Minute, 0)
+	now := time.Now().UTC()
+	for i, cpu := range []float64{4, 8, 12} {
+		collectedAt := now.Add(time.Duration(i-3) * time.Minute)
+		_, err := service.Record(server.ID, models.ServerMetricReport{CPUUsage: &cpu, CollectedAt: &collectedAt})
+		require.NoError(t, err)
+	}
+
+	// 最新值 12%，5分钟平均 8%
+	load, known, err := service.LoadAverage(server.ID, "cpu", 0, 0, now)
+	require.NoError(t, err)
+	assert.True(t, known)
+	assert.InDelta(t, 12, load, 0.01)
+	load, known, err = service.LoadAverage(server.ID, "cpu", 5*time.Minute, 0, now)
+	require.NoError(t, err)
+	assert.True(t, known)
+	assert.InDelta(t, 8, load, 0.01)
+
+	// 最新采样超过有效时间、或没有该项指标时结果未知
+	_, known, err = service.LoadAverage(server.ID, "cpu", 0, 30*time.Second, now)
+	require.NoError(t, err)
+	assert.False(t, known)
+	_, known, err = service.LoadAverage(server.ID, "memory", 0, 0, now)
+	require.NoError(t, err)
+	assert.False(t, known)
+
+	// "UPS间 > 35℃ 且 CPU 5分钟平均 < 10%"
+	monitor := &AIStrategyMonitor{logger: logrus.New(), serverMetricsService: service, temperatureData: map[string]float64{"24-1": 36}}
+	strategy := &models.AIStrategy{LogicOperator: "AND", ConditionsList: []models.AIStrategyCondition{
+		{Type: "temperature", SensorID: "24-1", Operator: ">", Value: 35},
+		{Type: "server_load", ServerID: "1", LoadType: "cpu", Operator: "<", Value: 10, Window: 300},
+	}}
+	assert.True(t, monitor.evaluateStrategyConditions(strategy))
+
+	strategy.ConditionsList[1].Window = 0
+	assert.False(t, monitor.evaluateStrategyConditions(strategy))
+
+	// 指标过期时 AND/NOT 不触发，OR 仍按其他条件触发
+	strategy.ConditionsList[1].MaxAge = 30
+	result, known := monitor.evaluateSingleCondition(strategy.ConditionsList[1])
+	assert.False(t, result)
+	assert.False(t, known)
+	strategy.LogicOperator = "NOT"
+	strategy.ConditionsList[0].Value = 40
+	assert.False(t, monitor.evaluateStrategyConditions(strategy))
+	strategy.LogicOperator = "OR"
+	strategy.ConditionsList[0].Value = 35
+	assert.True(t, monitor.evaluateStrategyConditions(strategy))
+}
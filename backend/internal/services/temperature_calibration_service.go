@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// referenceReadingMaxAge 按参考温度计算偏移时，最近采集读数的最长时效
+const referenceReadingMaxAge = 10 * time.Minute
+
+// TemperatureCalibrationService 温度通道校准：设置校准参数、按参考温度计算偏移、查询校准记录。
+// 校准参数保存在传感器的通道配置中，采集服务重新加载配置后对新读数生效。
+type TemperatureCalibrationService struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+// NewTemperatureCalibrationService 创建温度校准服务
+func NewTemperatureCalibrationService(db *gorm.DB, logger *logger.Logger) *TemperatureCalibrationService {
+	return &TemperatureCalibrationService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// GetCalibration 获取通道当前校准参数，未校准时返回 nil
+func (s *TemperatureCalibrationService) GetCalibration(sensorID uint, channel int) (*models.TemperatureCalibration, error) {
+	sensor, err := s.getChannelSensor(sensorID, channel)
+	if err != nil {
+		return nil, err
+	}
+	return sensor.ChannelCalibration(channel), nil
+}
+
+// UpdateCalibration 设置通道校准参数
+func (s *TemperatureCalibrationService) UpdateCalibration(sensorID uint, channel int, req models.UpdateTemperatureCalibrationRequest, operator string) (*models.TemperatureCalibrationRecord, error) {
+	calibration := &models.TemperatureCalibration{Offset: req.Offset, Gain: req.Gain, Points: req.Points}
+	if err := calibration.Validate(); err != nil {
+		return nil, err
+	}
+	record := &models.TemperatureCalibrationRecord{
+		SensorID:    sensorID,
+		Channel:     channel,
+		Calibration: calibration,
+		Source:      models.CalibrationSourceManual,
+		Note:        req.Note,
+		Operator:    operator,
+	}
+	if err := s.save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// CalibrateFromReference 按参考温度计算偏移：保持增益和校准点不变，调整偏移使探头读数校准后等于参考温度。
+// 未提供探头读数时使用最近采集的未校准读数。
+func (s *TemperatureCalibrationService) CalibrateFromReference(sensorID uint, channel int, req models.ReferenceCalibrationRequest, operator string) (*models.TemperatureCalibrationRecord, error) {
+	sensor, err := s.getChannelSensor(sensorID, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := req.RawTemperature
+	if raw == nil {
+		if raw, err = s.latestRawTemperature(sensorID, channel); err != nil {
+			return nil, err
+		}
+	}
+
+	calibration := &models.TemperatureCalibration{Gain: 1}
+	if current := sensor.ChannelCalibration(channel); current != nil {
+		copied := *current
+		calibration = &copied
+	}
+	calibration.Offset = calibration.OffsetFor(*raw, *req.Reference)
+	if err := calibration.Validate(); err != nil {
+		return nil, fmt.Errorf("参考温度与探头读数偏差过大: %w", err)
+	}
+
+	record := &models.TemperatureCalibrationRecord{
+		SensorID:      sensorID,
+		Channel:       channel,
+		Calibration:   calibration,
+		Source:        models.CalibrationSourceReference,
+		ReferenceTemp: req.Reference,
+		RawTemp:       raw,
+		Note:          req.Note,
+		Operator:      operator,
+	}
+	if err := s.save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// GetHistory 获取通道校准记录，按时间倒序
+func (s *TemperatureCalibrationService) GetHistory(sensorID uint, channel int, limit int) ([]models.TemperatureCalibrationRecord, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	var records []models.TemperatureCalibrationRecord
+	err := s.db.Where("sensor_id = ? AND channel = ?", sensorID, channel).
+		Order("created_at DESC, id DESC").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// save 在同一事务中更新通道校准参数并写入校准记录
+func (s *TemperatureCalibrationService) save(record *models.TemperatureCalibrationRecord) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var sensor models.TemperatureSensor
+		if err := tx.First(&sensor, record.SensorID).Error; err != nil {
+			return fmt.Errorf("传感器不存在")
+		}
+
+		calibration := record.Calibration
+		if calibration.IsZero() {
+			calibration = nil
+		}
+		found := false
+		for i := range sensor.Channels {
+			if sensor.Channels[i].Channel == record.Channel {
+				record.Previous = sensor.Channels[i].Calibration
+				sensor.Channels[i].Calibration = calibration
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("传感器 %s 未配置通道 %d", sensor.Name, record.Channel)
+		}
+		if err := tx.Model(&sensor).Select("channels").Updates(&sensor).Error; err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("更新温度通道校准", "sensor_id", record.SensorID, "channel", record.Channel,
+		"source", record.Source, "offset", record.Calibration.Offset, "gain", record.Calibration.Gain,
+		"points", len(record.Calibration.Points), "operator", record.Operator)
+	return nil
+}
+
+// getChannelSensor 获取传感器并确认通道已配置
+func (s *TemperatureCalibrationService) getChannelSensor(sensorID uint, channel int) (*models.TemperatureSensor, error) {
+	var sensor models.TemperatureSensor
+	if err := s.db.First(&sensor, sensorID).Error; err != nil {
+		return nil, fmt.Errorf("传感器不存在")
+	}
+	for _, ch := range sensor.Channels {
+		if ch.Channel == channel {
+			return &sensor, nil
+		}
+	}
+	return nil, fmt.Errorf("传感器 %s 未配置通道 %d", sensor.Name, channel)
+}
+
+// latestRawTemperature 通道最近采集的未校准读数
+func (s *TemperatureCalibrationService) latestRawTemperature(sensorID uint, channel int) (*float64, error) {
+	column := "temperature"
+	if s.db.Migrator().HasColumn("temperature_readings", "raw_temperature") {
+		column = "COALESCE(raw_temperature, temperature)"
+	}
+
+	var values []float64
+	err := s.db.Table("temperature_readings").
+		Where("sensor_id = ? AND channel = ? AND status = ? AND recorded_at > ?", sensorID, channel,
+			rawReadingStatus, time.Now().UTC().Add(-referenceReadingMaxAge)).
+		Order("recorded_at DESC").Limit(1).Pluck(column, &values).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("通道最近 %v 内没有有效读数，请提供探头读数", referenceReadingMaxAge)
+	}
+	return &values[0], nil
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTemperatureCalibrationApply(t *testing.T) {
+	var none *models.TemperatureCalibration
+	assert.Equal(t, 25.0, none.Apply(25))
+
+	linear := &models.TemperatureCalibration{Offset: -1.2, Gain: 1.01}
+	assert.Equal(t, 24.05, linear.Apply(25))
+
+	multi := &models.TemperatureCalibration{Offset: 0.1, Points: []models.CalibrationPoint{
+		{Raw: 40, Actual: 39}, {Raw: 0, Actual: 0.5}, {Raw: 20, Actual: 20},
+	}}
+	assert.Equal(t, 10.35, multi.Apply(10)) // 0~20 段插值 10.25，再加偏移
+	assert.Equal(t, 29.6, multi.Apply(30))
+	assert.Equal(t, 48.6, multi.Apply(50)) // 超出校准点按末段外推
+
+	assert.Equal(t, -1.5, linear.OffsetFor(26.5, 25.265))
+	assert.Error(t, (&models.TemperatureCalibration{Gain: 2}).Validate())
+	assert.Error(t, (&models.TemperatureCalibration{Points: []models.CalibrationPoint{{Raw: 1, Actual: 1}}}).Validate())
+}
+
+func TestCalibrateFromReference(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TemperatureSensor{}, &models.TemperatureCalibrationRecord{}, &testTemperatureReading{}))
+
+	sensor := models.TemperatureSensor{Name: "机柜B", DeviceType: "KLT-18B20-6H1", IPAddress: "127.0.0.1", Port: 502, Enabled: true,
+		Channels: []models.TemperatureChannel{{Channel: 1, Name: "冷通道", Enabled: true}, {Channel: 2, Name: "热通道", Enabled: true}}}
+	require.NoError(t, db.Create(&sensor).Error)
+	require.NoError(t, db.Create(&testTemperatureReading{SensorID: sensor.ID, Channel: 1, Temperature: 24.3, Status: "normal", RecordedAt: time.Now().UTC()}).Error)
+
+	service := NewTemperatureCalibrationService(db, logger.NewLogger())
+
+	reference := 22.8
+	record, err := service.CalibrateFromReference(sensor.ID, 1, models.ReferenceCalibrationRequest{Reference: &reference}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, -1.5, record.Calibration.Offset)
+	assert.Equal(t, 24.3, *record.RawTemp)
+	assert.Nil(t, record.Previous)
+
+	record, err = service.UpdateCalibration(sensor.ID, 1, models.UpdateTemperatureCalibrationRequest{Offset: -1.5, Gain: 0.99}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, -1.5, record.Previous.Offset)
+
+	var saved models.TemperatureSensor
+	require.NoError(t, db.First(&saved, sensor.ID).Error)
+	assert.Equal(t, 23.25, saved.CalibrateTemperature(1, 25))
+	assert.Equal(t, 25.0, saved.CalibrateTemperature(2, 25))
+
+	// 零值清除校准
+	_, err = service.UpdateCalibration(sensor.ID, 1, models.UpdateTemperatureCalibrationRequest{}, "admin")
+	require.NoError(t, err)
+	require.NoError(t, db.First(&saved, sensor.ID).Error)
+	assert.Nil(t, saved.ChannelCalibration(1))
+
+	history, err := service.GetHistory(sensor.ID, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, models.CalibrationSourceReference, history[2].Source)
+
+	_, err = service.UpdateCalibration(sensor.ID, 3, models.UpdateTemperatureCalibrationRequest{Offset: 1}, "admin")
+	assert.Error(t, err)
+	_, err = service.CalibrateFromReference(sensor.ID, 2, models.ReferenceCalibrationRequest{Reference: &reference}, "admin")
+	assert.Error(t, err, "没有最近读数时需要提供探头读数")
+}
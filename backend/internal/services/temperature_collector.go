@@ -0,0 +1,516 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+	"smart-device-management/pkg/validation"
+
+	"gorm.io/gorm"
+)
+
+const (
+	collectDefaultInterval = 30 * time.Second // 传感器和通道都未配置间隔时的采集间隔
+	collectMinInterval     = 5 * time.Second  // 最短采集间隔，避免过于频繁地占用总线
+	collectCoalesceWindow  = time.Second      // 同一传感器相差不到该时长到期的通道合并为一次读取
+	collectReadTimeout     = 3 * time.Second  // 单帧响应超时
+	defaultVirtualMaxAge   = 2 * time.Minute  // 虚拟传感器未配置读数有效时间时，源通道读数的最长有效时间
+)
+
+// channelPlan 单个通道的采集计划
+type channelPlan struct {
+	channel  int
+	interval time.Duration
+	next     time.Time
+}
+
+// sensorPlan 单个传感器的采集计划。一个传感器的所有通道一次读出，
+// 只保存到期通道的读数，各通道按自己的间隔推进。
+type sensorPlan struct {
+	sensor   models.TemperatureSensor
+	channels []*channelPlan
+}
+
+// newSensorPlan 按传感器和通道配置生成采集计划：通道间隔优先，未配置时使用传感器间隔；
+// 未配置通道时采集驱动的全部通道；停用的通道不采集。
+// 首次采集时间在最短间隔内随机错开，避免大量传感器同时发起读取。
+func newSensorPlan(sensor models.TemperatureSensor, driverChannels int, now time.Time, rnd *rand.Rand) *sensorPlan {
+	sensorInterval := collectInterval(sensor.Interval, collectDefaultInterval)
+
+	plan := &sensorPlan{sensor: sensor}
+	if len(sensor.Channels) == 0 {
+		for ch := 1; ch <= driverChannels; ch++ {
+			plan.channels = append(plan.channels, &channelPlan{channel: ch, interval: sensorInterval})
+		}
+	}
+	for _, ch := range sensor.Channels {
+		if !ch.Enabled || ch.Channel < 1 || ch.Channel > driverChannels {
+			continue
+		}
+		plan.channels = append(plan.channels, &channelPlan{channel: ch.Channel, interval: collectInterval(ch.Interval, sensorInterval)})
+	}
+	if len(plan.channels) == 0 {
+		return plan
+	}
+
+	shortest := plan.channels[0].interval
+	for _, ch := range plan.channels[1:] {
+		if ch.interval < shortest {
+			shortest = ch.interval
+		}
+	}
+	first := now.Add(time.Duration(rnd.Int63n(int64(shortest))))
+	for _, ch := range plan.channels {
+		ch.next = first
+	}
+	return plan
+}
+
+// collectInterval 配置的采集间隔（秒），未配置时使用 fallback，不短于最短采集间隔
+func collectInterval(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	if interval := time.Duration(seconds) * time.Second; interval > collectMinInterval {
+		return interval
+	}
+	return collectMinInterval
+}
+
+// nextDue 最早到期时间
+func (p *sensorPlan) nextDue() time.Time {
+	next := p.channels[0].next
+	for _, ch := range p.channels[1:] {
+		if ch.next.Before(next) {
+			next = ch.next
+		}
+	}
+	return next
+}
+
+// take 取出到期（含合并窗口内即将到期）的通道并推进其下次采集时间。
+// 错过多个周期（如读取阻塞）时不补采，从当前时间重新计时。
+func (p *sensorPlan) take(now time.Time) []int {
+	var due []int
+	for _, ch := range p.channels {
+		if ch.next.After(now.Add(collectCoalesceWindow)) {
+			continue
+		}
+		due = append(due, ch.channel)
+		ch.next = ch.next.Add(ch.interval)
+		if !ch.next.After(now) {
+			ch.next = now.Add(ch.interval)
+		}
+	}
+	sort.Ints(due)
+	return due
+}
+
+// sensorWorker 运行中的传感器采集协程
+type sensorWorker struct {
+	config string
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// TemperatureCollector 温度采集服务：定期从数据库加载传感器配置，新增、修改、删除或停用的
+// 传感器无需重启即可生效；每个传感器一个采集协程，按通道间隔读取。
+// 物理传感器经进程内共用的网关调度器读取，与同一端口上断路器的控制和轮询按优先级串行发送；
+// 虚拟传感器的读数由源通道的最新读数聚合计算。读数经健康检测和校准后入库，
+// 连续异常时产生传感器故障，由 TemperatureSensorFaultService 推送告警。
+type TemperatureCollector struct {
+	db             *gorm.DB
+	logger         *logger.Logger
+	health         *validation.SensorHealthChecker
+	reloadInterval time.Duration
+
+	// read 读取传感器全部通道，save 保存到期通道的读数
+	read func(sensor models.TemperatureSensor) ([]drivers.ChannelReading, error)
+	save func(sensor models.TemperatureSensor, readings []drivers.ChannelReading, at time.Time)
+
+	mutex     sync.Mutex
+	isRunning bool
+	stopChan  chan struct{}
+	doneChan  chan struct{}
+
+	workersMutex sync.Mutex
+	rnd          *rand.Rand
+	workers      map[uint]*sensorWorker
+}
+
+// NewTemperatureCollector 创建温度采集服务
+func NewTemperatureCollector(db *gorm.DB, logger *logger.Logger, reloadInterval time.Duration, options validation.HealthOptions) *TemperatureCollector {
+	if reloadInterval <= 0 {
+		reloadInterval = 15 * time.Second
+	}
+	if options.FaultSamples <= 0 {
+		options.FaultSamples = validation.DefaultHealthOptions().FaultSamples
+	}
+	c := &TemperatureCollector{
+		db:             db,
+		logger:         logger,
+		health:         validation.NewSensorHealthChecker(validation.NewDataValidator(), options),
+		reloadInterval: reloadInterval,
+		rnd:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		workers:        make(map[uint]*sensorWorker),
+	}
+	c.read = c.readSensor
+	c.save = c.saveReadings
+	return c
+}
+
+// Start 启动温度采集
+func (c *TemperatureCollector) Start() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.isRunning {
+		return fmt.Errorf("温度采集已在运行")
+	}
+
+	c.isRunning = true
+	c.stopChan = make(chan struct{})
+	c.doneChan = make(chan struct{})
+	go c.loop(c.stopChan, c.doneChan)
+
+	c.logger.Info("启动温度采集", "reload_interval", c.reloadInterval.String())
+	return nil
+}
+
+// Stop 停止温度采集，等待各传感器的采集协程退出
+func (c *TemperatureCollector) Stop() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.isRunning {
+		return fmt.Errorf("温度采集未在运行")
+	}
+
+	close(c.stopChan)
+	<-c.doneChan
+	c.isRunning = false
+	c.logger.Info("停止温度采集")
+	return nil
+}
+
+// loop 加载传感器并定期重新加载，直到 stop 关闭
+func (c *TemperatureCollector) loop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	c.restoreFaults()
+	c.reload()
+
+	ticker := time.NewTicker(c.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.reload()
+		case <-stop:
+			c.stopAll()
+			return
+		}
+	}
+}
+
+// restoreFaults 恢复未解除的传感器故障，通道恢复正常时能解除告警
+func (c *TemperatureCollector) restoreFaults() {
+	var faults []models.TemperatureSensorFault
+	if err := c.db.Where("status = ?", models.SensorFaultActive).Find(&faults).Error; err != nil {
+		c.logger.Warn("加载传感器故障失败", "error", err)
+		return
+	}
+	for _, fault := range faults {
+		c.health.MarkFaulted(channelKey(fault.SensorID, fault.Channel))
+	}
+}
+
+// reload 对比数据库中的传感器配置，启动新增的、重启修改过的、停止删除或停用的采集协程
+func (c *TemperatureCollector) reload() {
+	var sensors []models.TemperatureSensor
+	if err := c.db.Where("enabled = ?", true).Find(&sensors).Error; err != nil {
+		c.logger.Error("加载传感器配置失败", "error", err)
+		return
+	}
+
+	c.workersMutex.Lock()
+	defer c.workersMutex.Unlock()
+
+	seen := make(map[uint]bool)
+	for _, sensor := range sensors {
+		seen[sensor.ID] = true
+		config := sensorConfigKey(sensor)
+		if worker, ok := c.workers[sensor.ID]; ok {
+			if worker.config == config {
+				continue
+			}
+			c.logger.Info("传感器配置已变更，重新调度", "sensor", sensor.Name)
+			c.stopWorker(sensor.ID)
+		}
+		c.startWorker(sensor, config)
+	}
+
+	for id := range c.workers {
+		if !seen[id] {
+			c.logger.Info("传感器已删除或停用，停止采集", "sensor_id", id)
+			c.stopWorker(id)
+		}
+	}
+}
+
+// startWorker 启动传感器采集协程，驱动不存在或没有需要采集的通道时只记录配置不采集
+func (c *TemperatureCollector) startWorker(sensor models.TemperatureSensor, config string) {
+	worker := &sensorWorker{config: config, stop: make(chan struct{}), done: make(chan struct{})}
+	c.workers[sensor.ID] = worker
+
+	driver, err := drivers.TemperatureSensor(sensor.DeviceType)
+	if err != nil {
+		c.logger.Warn("传感器不采集", "sensor", sensor.Name, "error", err)
+		close(worker.done)
+		return
+	}
+	plan := newSensorPlan(sensor, driver.Channels(), time.Now(), c.rnd)
+	if len(plan.channels) == 0 {
+		c.logger.Warn("传感器没有启用的通道，不采集", "sensor", sensor.Name)
+		close(worker.done)
+		return
+	}
+
+	if drivers.IsVirtualSensor(sensor.DeviceType) {
+		c.logger.Info("调度虚拟传感器", "sensor", sensor.Name, "first", plan.nextDue().Format("15:04:05"))
+	} else {
+		c.logger.Info("调度传感器", "sensor", sensor.Name, "address", fmt.Sprintf("%s:%d", sensor.IPAddress, sensor.Port),
+			"slave_id", sensor.SlaveID, "channels", len(plan.channels), "first", plan.nextDue().Format("15:04:05"))
+	}
+	go c.runSensor(plan, worker)
+}
+
+func (c *TemperatureCollector) stopWorker(id uint) {
+	worker := c.workers[id]
+	delete(c.workers, id)
+	close(worker.stop)
+	<-worker.done
+}
+
+func (c *TemperatureCollector) stopAll() {
+	c.workersMutex.Lock()
+	defer c.workersMutex.Unlock()
+	for id := range c.workers {
+		c.stopWorker(id)
+	}
+}
+
+// runSensor 等到最早到期的通道，读取传感器并保存到期通道的读数
+func (c *TemperatureCollector) runSensor(plan *sensorPlan, worker *sensorWorker) {
+	defer close(worker.done)
+
+	timer := time.NewTimer(time.Until(plan.nextDue()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-worker.stop:
+			return
+		case <-timer.C:
+		}
+
+		if due := plan.take(time.Now()); len(due) > 0 {
+			c.collect(plan.sensor, due)
+		}
+		timer.Reset(time.Until(plan.nextDue()))
+	}
+}
+
+func (c *TemperatureCollector) collect(sensor models.TemperatureSensor, channels []int) {
+	readings, err := c.read(sensor)
+	if errors.Is(err, modbus.ErrGatewayBusy) {
+		c.logger.Debug("端口正在投运调试，跳过本次采集", "sensor", sensor.Name)
+		return
+	}
+	if err != nil {
+		c.logger.Error("采集传感器数据失败", "sensor", sensor.Name, "error", err)
+		return
+	}
+
+	want := make(map[int]bool, len(channels))
+	for _, ch := range channels {
+		want[ch] = true
+	}
+	selected := readings[:0]
+	for _, reading := range readings {
+		if want[reading.Channel] {
+			selected = append(selected, reading)
+		}
+	}
+	c.save(sensor, selected, time.Now().UTC())
+}
+
+// readSensor 读取传感器全部通道。物理传感器经网关调度器读取：同一端口上的传感器和断路器
+// 共用一个调度器和长连接，请求按优先级串行发送；虚拟传感器由源通道读数计算。
+func (c *TemperatureCollector) readSensor(sensor models.TemperatureSensor) ([]drivers.ChannelReading, error) {
+	if drivers.IsVirtualSensor(sensor.DeviceType) {
+		return c.readVirtual(sensor, time.Now())
+	}
+
+	driver, err := drivers.TemperatureSensor(sensor.DeviceType)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := modbus.EndpointConfig(sensor.Framing, sensor.IPAddress, sensor.Port, serialConfig(sensor.SerialSettings))
+	if err != nil {
+		return nil, err
+	}
+
+	// 端口配置须与同端口的断路器一致才能取到同一调度器（配置不同会重建调度器），单帧超时只作用于本次读取
+	transport := modbus.DefaultGatewayPool().Gateway(cfg).TransportWithTimeout(modbus.PriorityPoll, collectReadTimeout)
+	return driver.ReadTemperatures(modbus.NewModbusClientWithTransport(transport, byte(sensor.SlaveID)))
+}
+
+// readVirtual 计算虚拟传感器的读数：取各源通道在有效时间内的最新读数按定义聚合，
+// 最新读数异常（状态不是 normal）或过期的通道不参与计算。结果作为通道1的读数返回，
+// 与物理传感器一样经过健康检测和校准后入库。
+func (c *TemperatureCollector) readVirtual(sensor models.TemperatureSensor, now time.Time) ([]drivers.ChannelReading, error) {
+	definition := sensor.Virtual
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	maxAge := time.Duration(definition.MaxAge) * time.Second
+	if maxAge <= 0 {
+		maxAge = defaultVirtualMaxAge
+	}
+
+	values := make(map[models.VirtualSensorChannel]float64)
+	for _, source := range definition.Channels() {
+		var latest []models.TemperatureReading
+		err := c.db.Where("sensor_id = ? AND channel = ? AND recorded_at > ?", source.SensorID, source.Channel, now.UTC().Add(-maxAge)).
+			Order("recorded_at DESC").Limit(1).Find(&latest).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) > 0 && latest[0].Status == "normal" {
+			values[source] = latest[0].Temperature
+		}
+	}
+
+	value, err := definition.Compute(values)
+	if err != nil {
+		return nil, err
+	}
+	return []drivers.ChannelReading{{Channel: 1, Value: &value, Status: drivers.ChannelOK}}, nil
+}
+
+// saveReadings 对通道读数做健康检测，按通道校准参数换算后入库：有效读数状态为 normal，
+// 异常读数以健康状态（stuck/out_of_range/spike/disconnected）保存，开路等没有读数的不保存。
+// 连续异常达到阈值时产生传感器故障，恢复有效读数后解除。
+func (c *TemperatureCollector) saveReadings(sensor models.TemperatureSensor, readings []drivers.ChannelReading, at time.Time) {
+	for _, reading := range readings {
+		// 断开、卡死和跳变按探头原始读数检测（DS18B20 上电默认值等按原始值识别），量程按校准后的温度比较
+		var raw *float64
+		switch {
+		case reading.Status == drivers.ChannelOK && reading.Value != nil:
+			raw = reading.Value
+		case reading.Status == drivers.ChannelOutOfRange:
+			decoded := float64(int16(reading.Raw)) / 10
+			raw = &decoded
+		}
+		minTemp, maxTemp := channelRange(sensor, reading.Channel)
+		channel := reading.Channel
+		calibrate := func(v float64) float64 { return sensor.CalibrateTemperature(channel, v) }
+		result := c.health.Check(channelKey(sensor.ID, reading.Channel), raw, reading.Status == drivers.ChannelOpenCircuit, calibrate, minTemp, maxTemp)
+
+		if result.FaultRaised {
+			c.raiseFault(sensor, reading.Channel, result, raw, at)
+		}
+		if result.FaultCleared {
+			c.clearFault(sensor, reading.Channel, at)
+		}
+
+		if raw == nil || math.Abs(*raw) >= 1000 {
+			// 开路没有读数；超出 decimal(5,2) 的异常编码也不入库
+			c.logger.Warn("传感器通道无有效读数", "sensor", sensor.Name, "channel", reading.Channel, "status", reading.Status)
+			continue
+		}
+
+		status := "normal"
+		if !result.IsValid() {
+			status = result.State
+			c.logger.Warn("传感器读数异常", "sensor", sensor.Name, "channel", reading.Channel, "state", result.State, "reason", result.Reason)
+		}
+		value := *raw
+		record := models.TemperatureReading{
+			SensorID:       sensor.ID,
+			Channel:        reading.Channel,
+			Temperature:    sensor.CalibrateTemperature(reading.Channel, value),
+			RawTemperature: &value,
+			Status:         status,
+			RecordedAt:     at,
+		}
+		if err := c.db.Create(&record).Error; err != nil {
+			c.logger.Error("保存温度数据失败", "sensor", sensor.Name, "channel", reading.Channel, "error", err)
+		} else if result.IsValid() {
+			c.logger.Debug("温度读数", "sensor", sensor.Name, "channel", reading.Channel, "temperature", record.Temperature)
+		}
+	}
+}
+
+// raiseFault 产生传感器故障，由 TemperatureSensorFaultService 推送告警通知
+func (c *TemperatureCollector) raiseFault(sensor models.TemperatureSensor, channel int, result validation.HealthResult, value *float64, at time.Time) {
+	fault := models.TemperatureSensorFault{
+		SensorID: sensor.ID,
+		Channel:  channel,
+		State:    result.State,
+		Reason:   result.Reason,
+		Value:    value,
+		Status:   models.SensorFaultActive,
+		RaisedAt: at,
+	}
+	if err := c.db.Create(&fault).Error; err != nil {
+		c.logger.Error("保存传感器故障失败", "sensor", sensor.Name, "channel", channel, "error", err)
+		return
+	}
+	c.logger.Warn("传感器故障", "sensor", sensor.Name, "channel", channel, "state", result.State, "reason", result.Reason)
+}
+
+// clearFault 解除通道未恢复的传感器故障，由 TemperatureSensorFaultService 推送恢复通知
+func (c *TemperatureCollector) clearFault(sensor models.TemperatureSensor, channel int, at time.Time) {
+	err := c.db.Model(&models.TemperatureSensorFault{}).
+		Where("sensor_id = ? AND channel = ? AND status = ?", sensor.ID, channel, models.SensorFaultActive).
+		Updates(map[string]interface{}{"status": models.SensorFaultCleared, "cleared_at": at, "notified": false}).Error
+	if err != nil {
+		c.logger.Error("解除传感器故障失败", "sensor", sensor.Name, "channel", channel, "error", err)
+		return
+	}
+	c.logger.Info("传感器恢复正常", "sensor", sensor.Name, "channel", channel)
+}
+
+func channelKey(sensorID uint, channel int) string {
+	return fmt.Sprintf("%d-%d", sensorID, channel)
+}
+
+// channelRange 通道量程：通道未配置时使用传感器量程
+func channelRange(sensor models.TemperatureSensor, channel int) (float64, float64) {
+	for _, ch := range sensor.Channels {
+		if ch.Channel == channel && (ch.MinTemp != 0 || ch.MaxTemp != 0) {
+			return ch.MinTemp, ch.MaxTemp
+		}
+	}
+	return sensor.MinTemp, sensor.MaxTemp
+}
+
+// sensorConfigKey 传感器配置指纹，用于判断配置是否变更
+func sensorConfigKey(sensor models.TemperatureSensor) string {
+	sensor.CreatedAt, sensor.UpdatedAt = time.Time{}, time.Time{}
+	data, _ := json.Marshal(sensor)
+	return string(data)
+}
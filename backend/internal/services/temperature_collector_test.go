@@ -0,0 +1,306 @@
+package services
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/drivers"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/modbus"
+	"smart-device-management/pkg/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSensorPlan(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sensor := models.TemperatureSensor{
+		Interval: 60,
+		Channels: []models.TemperatureChannel{
+			{Channel: 1, Enabled: true, Interval: 10},
+			{Channel: 2, Enabled: false, Interval: 10},
+			{Channel: 3, Enabled: true},
+			{Channel: 4, Enabled: true, Interval: 1},
+			{Channel: 9, Enabled: true, Interval: 10},
+		},
+	}
+	plan := newSensorPlan(sensor, 6, now, rand.New(rand.NewSource(1)))
+	require.Len(t, plan.channels, 3)
+	assert.Equal(t, 10*time.Second, plan.channels[0].interval)
+	assert.Equal(t, 60*time.Second, plan.channels[1].interval) // 未配置时用传感器间隔
+	assert.Equal(t, collectMinInterval, plan.channels[2].interval)
+
+	// 首次采集在最短间隔内错开
+	first := plan.nextDue()
+	assert.False(t, first.Before(now))
+	assert.True(t, first.Before(now.Add(collectMinInterval)))
+
+	assert.Equal(t, []int{1, 3, 4}, plan.take(first))
+	assert.Equal(t, first.Add(5*time.Second), plan.nextDue())
+	assert.Equal(t, []int{4}, plan.take(first.Add(5*time.Second)))
+	assert.Equal(t, []int{1, 4}, plan.take(first.Add(10*time.Second)))
+
+	// 读取阻塞错过多个周期后从当前时间重新计时
+	late := first.Add(time.Minute + 500*time.Millisecond)
+	assert.Equal(t, []int{1, 3, 4}, plan.take(late))
+	assert.Equal(t, late.Add(5*time.Second), plan.nextDue())
+
+	// 未配置通道时采集驱动的全部通道
+	plan = newSensorPlan(models.TemperatureSensor{}, 6, now, rand.New(rand.NewSource(1)))
+	assert.Len(t, plan.channels, 6)
+	assert.Equal(t, collectDefaultInterval, plan.channels[0].interval)
+}
+
+func TestTemperatureCollectorReload(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TemperatureSensor{}))
+
+	var mu sync.Mutex
+	slaves := make(map[uint]int)
+	c := NewTemperatureCollector(db, logger.NewLogger(), time.Hour, validation.DefaultHealthOptions())
+	c.read = func(sensor models.TemperatureSensor) ([]drivers.ChannelReading, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		slaves[sensor.ID] = sensor.SlaveID
+		return nil, nil
+	}
+	c.save = func(models.TemperatureSensor, []drivers.ChannelReading, time.Time) {}
+
+	sensor := models.TemperatureSensor{Name: "s1", DeviceType: "KLT-18B20-6H1", IPAddress: "127.0.0.1", Port: 502, SlaveID: 3, Enabled: true,
+		Channels: []models.TemperatureChannel{{Channel: 1, Name: "c1", Enabled: true, Interval: 5}}}
+	require.NoError(t, db.Create(&sensor).Error)
+	c.reload()
+	require.Len(t, c.workers, 1)
+	config := c.workers[sensor.ID].config
+
+	// 配置未变更时保留原协程
+	c.reload()
+	assert.Equal(t, config, c.workers[sensor.ID].config)
+
+	require.NoError(t, db.Model(&sensor).Update("slave_id", 7).Error)
+	c.reload()
+	assert.NotEqual(t, config, c.workers[sensor.ID].config)
+
+	// 首次采集在5秒内
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return slaves[sensor.ID] == 7
+	}, 6*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, db.Delete(&sensor).Error)
+	c.reload()
+	assert.Empty(t, c.workers)
+}
+
+func TestTemperatureCollectorSaveReadings(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TemperatureReading{}, &models.TemperatureSensorFault{}))
+
+	c := NewTemperatureCollector(db, logger.NewLogger(), time.Hour, validation.HealthOptions{SpikeDelta: 5, FaultSamples: 2})
+	sensor := models.TemperatureSensor{ID: 1, Name: "s1", MinTemp: -35, MaxTemp: 125,
+		Channels: []models.TemperatureChannel{{Channel: 1, Name: "c1", Enabled: true, Calibration: &models.TemperatureCalibration{Offset: -0.5}}}}
+	value := func(v float64) []drivers.ChannelReading {
+		return []drivers.ChannelReading{{Channel: 1, Status: drivers.ChannelOK, Value: &v}}
+	}
+	at := time.Now().UTC()
+
+	c.saveReadings(sensor, value(85), at)
+	c.saveReadings(sensor, []drivers.ChannelReading{{Channel: 1, Status: drivers.ChannelOpenCircuit, Raw: 0x8000}}, at)
+
+	var faults []models.TemperatureSensorFault
+	require.NoError(t, db.Find(&faults).Error)
+	require.Len(t, faults, 1)
+	assert.Equal(t, models.SensorFaultActive, faults[0].Status)
+
+	c.saveReadings(sensor, value(24), at)
+	require.NoError(t, db.Find(&faults).Error)
+	assert.Equal(t, models.SensorFaultCleared, faults[0].Status)
+
+	var readings []models.TemperatureReading
+	require.NoError(t, db.Order("id").Find(&readings).Error)
+	require.Len(t, readings, 2, "开路没有读数不入库")
+	assert.Equal(t, validation.HealthDisconnected, readings[0].Status)
+	assert.Equal(t, "normal", readings[1].Status)
+	assert.Equal(t, 23.5, readings[1].Temperature)
+	assert.Equal(t, 24.0, *readings[1].RawTemperature)
+
+	// 量程按校准后的温度比较：原始 123°C 加偏移 +3 后超出 125°C 上限
+	hot := models.TemperatureSensor{ID: 2, Name: "s2", MinTemp: -35, MaxTemp: 125,
+		Channels: []models.TemperatureChannel{{Channel: 1, Name: "c1", Enabled: true, Calibration: &models.TemperatureCalibration{Offset: 3}}}}
+	c.saveReadings(hot, value(123), at)
+	var last models.TemperatureReading
+	require.NoError(t, db.Where("sensor_id = ?", hot.ID).Last(&last).Error)
+	assert.Equal(t, validation.HealthOutOfRange, last.Status)
+	assert.Equal(t, 126.0, last.Temperature)
+}
+
+func TestTemperatureCollectorReadVirtual(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TemperatureReading{}))
+	c := NewTemperatureCollector(db, logger.NewLogger(), time.Hour, validation.DefaultHealthOptions())
+
+	now := time.Now().UTC()
+	require.NoError(t, db.Create([]models.TemperatureReading{
+		{SensorID: 3, Channel: 1, Temperature: 22, Status: "normal", RecordedAt: now.Add(-10 * time.Second)},
+		{SensorID: 3, Channel: 2, Temperature: 24, Status: "normal", RecordedAt: now.Add(-10 * time.Second)},
+		{SensorID: 3, Channel: 3, Temperature: 30, Status: "normal", RecordedAt: now.Add(-10 * time.Second)},
+		{SensorID: 3, Channel: 4, Temperature: 60, Status: "spike", RecordedAt: now.Add(-5 * time.Second)},
+		{SensorID: 3, Channel: 4, Temperature: 25, Status: "normal", RecordedAt: now.Add(-15 * time.Second)},
+		{SensorID: 4, Channel: 1, Temperature: 35, Status: "normal", RecordedAt: now.Add(-10 * time.Second)},
+		{SensorID: 4, Channel: 2, Temperature: 50, Status: "normal", RecordedAt: now.Add(-time.Hour)},
+	}).Error)
+
+	rack := []models.VirtualSensorChannel{{SensorID: 3, Channel: 1}, {SensorID: 3, Channel: 2}, {SensorID: 3, Channel: 3}, {SensorID: 3, Channel: 4}}
+	read := func(definition models.VirtualSensorDefinition) (float64, error) {
+		readings, err := c.readVirtual(models.TemperatureSensor{Virtual: &definition}, now)
+		if err != nil {
+			return 0, err
+		}
+		require.Len(t, readings, 1)
+		assert.Equal(t, drivers.ChannelOK, readings[0].Status)
+		return *readings[0].Value, nil
+	}
+
+	// 通道4最新读数异常，不参与计算
+	v, err := read(models.VirtualSensorDefinition{Aggregation: models.VirtualAggregationAvg, Sources: rack})
+	require.NoError(t, err)
+	assert.Equal(t, 25.33, v)
+
+	v, _ = read(models.VirtualSensorDefinition{Aggregation: models.VirtualAggregationMedian, Sources: rack})
+	assert.Equal(t, 24.0, v)
+	v, _ = read(models.VirtualSensorDefinition{Aggregation: models.VirtualAggregationMax, Sources: rack})
+	assert.Equal(t, 30.0, v)
+
+	// 热通道减冷通道温差，过期的读数不参与计算
+	v, err = read(models.VirtualSensorDefinition{
+		Aggregation: models.VirtualAggregationDifference,
+		Sources:     []models.VirtualSensorChannel{{SensorID: 4, Channel: 1}, {SensorID: 4, Channel: 2}},
+		Subtrahend:  rack[:2],
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, v)
+
+	_, err = read(models.VirtualSensorDefinition{Aggregation: models.VirtualAggregationMin, Sources: rack, MinSources: 4})
+	assert.Error(t, err, "有效读数不足")
+
+	_, err = read(models.VirtualSensorDefinition{Aggregation: models.VirtualAggregationAvg, Sources: rack, Subtrahend: rack[:1]})
+	assert.Error(t, err)
+}
+
+func TestTemperatureCollectorSharesBreakerGateway(t *testing.T) {
+	// 模拟 MODBUS TCP 网关：读寄存器返回0，写请求原样应答；统计连接数和同时处理中的请求数
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	var (
+		mu                    sync.Mutex
+		conns, inFlight, peak int
+		functions             = make(map[byte]int)
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns++
+			mu.Unlock()
+			go func(conn net.Conn) {
+				defer conn.Close()
+				header := make([]byte, 7)
+				for {
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					pdu := make([]byte, binary.BigEndian.Uint16(header[4:6])-1)
+					if _, err := io.ReadFull(conn, pdu); err != nil {
+						return
+					}
+					mu.Lock()
+					inFlight++
+					if inFlight > peak {
+						peak = inFlight
+					}
+					functions[pdu[0]]++
+					mu.Unlock()
+
+					time.Sleep(10 * time.Millisecond)
+					resp := pdu
+					if pdu[0] == 0x03 || pdu[0] == 0x04 {
+						count := binary.BigEndian.Uint16(pdu[3:5])
+						resp = append([]byte{pdu[0], byte(2 * count)}, make([]byte, 2*count)...)
+					}
+
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+					frame := append([]byte{}, header[:4]...)
+					frame = binary.BigEndian.AppendUint16(frame, uint16(len(resp)+1))
+					conn.Write(append(append(frame, header[6]), resp...))
+				}
+			}(conn)
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Device{}, &models.Server{}, &models.Breaker{}, &models.BreakerServerBinding{}))
+	log := logger.NewLogger()
+
+	// 断路器（站号1）和温度传感器（站号2）接在同一网关端口
+	breaker := models.Breaker{BreakerName: "机柜A1", IPAddress: "127.0.0.1", Port: port, StationID: 1}
+	require.NoError(t, db.Create(&breaker).Error)
+	sensor := models.TemperatureSensor{ID: 1, Name: "s1", DeviceType: "KLT-18B20-6H1", IPAddress: "127.0.0.1", Port: port, SlaveID: 2}
+	modbusService := NewModbusService(log, db)
+	collector := NewTemperatureCollector(db, log, time.Hour, validation.DefaultHealthOptions())
+	cfg, err := breakerEndpoint(&breaker)
+	require.NoError(t, err)
+	defer modbus.DefaultGatewayPool().Evict(cfg)
+
+	const rounds = 5
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			readings, err := collector.readSensor(sensor)
+			assert.NoError(t, err)
+			assert.Len(t, readings, 6)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			action := "on"
+			if i%2 == 1 {
+				action = "off"
+			}
+			assert.NoError(t, modbusService.ControlBreaker(&breaker, action))
+		}
+	}()
+	wg.Wait()
+
+	// 控制和采集经同一调度器串行发送：只有一条连接，任何时刻只有一个请求在处理
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, rounds, functions[0x05], "分合闸写线圈")
+	assert.Equal(t, rounds, functions[0x03], "读取温度")
+	assert.Equal(t, 1, peak)
+	assert.Equal(t, 1, conns)
+}
@@ -0,0 +1,543 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	rollupLag        = time.Minute   // 等待迟到的原始读数入库后再聚合
+	rollupChunk      = 6 * time.Hour // 单次聚合的最大时间跨度，补算历史数据时分批进行
+	rollupBatchSize  = 500
+	rawReadingStatus = "normal" // 参与聚合的原始读数状态
+)
+
+// TemperatureRetention 各分辨率数据的保留时长，0 表示永久保留
+type TemperatureRetention struct {
+	Raw    time.Duration
+	Minute time.Duration
+	Hour   time.Duration
+	Day    time.Duration
+}
+
+// rawTemperatureReading 原始温度读数（temperature_readings，由温度采集服务写入）
+type rawTemperatureReading struct {
+	SensorID    uint
+	Channel     int
+	Temperature float64
+	Status      string
+	RecordedAt  time.Time
+}
+
+// rollupKey 聚合分组键
+type rollupKey struct {
+	sensorID uint
+	channel  int
+	bucket   time.Time
+}
+
+// rollupAcc 聚合累加器
+type rollupAcc struct {
+	min, max, sum float64
+	count         int
+}
+
+func (a *rollupAcc) add(min, max, avg float64, count int) {
+	if a.count == 0 || min < a.min {
+		a.min = min
+	}
+	if a.count == 0 || max > a.max {
+		a.max = max
+	}
+	a.sum += avg * float64(count)
+	a.count += count
+}
+
+// TemperatureRollupService 温度数据降采样：定期把原始读数聚合为1分钟、1小时、1天的
+// 最小/最大/平均值和条数，按各分辨率的保留时长清理旧数据，并按查询时间范围选择分辨率。
+// 聚合和分桶在Go中完成，PostgreSQL和SQLite行为一致。
+type TemperatureRollupService struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	interval  time.Duration
+	retention TemperatureRetention
+	location  *time.Location // 按天聚合使用的时区
+
+	mutex     sync.Mutex
+	isRunning bool
+	stopChan  chan struct{}
+}
+
+// NewTemperatureRollupService 创建温度数据降采样服务
+func NewTemperatureRollupService(db *gorm.DB, logger *logger.Logger, interval time.Duration, retention TemperatureRetention) *TemperatureRollupService {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &TemperatureRollupService{
+		db:        db,
+		logger:    logger,
+		interval:  interval,
+		retention: retention,
+		location:  time.Local,
+	}
+}
+
+// Start 启动定期聚合和清理
+func (s *TemperatureRollupService) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("温度数据降采样已在运行")
+	}
+
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	go s.loop(s.stopChan)
+
+	s.logger.Info("启动温度数据降采样", "interval", s.interval.String(),
+		"raw_retention", s.retention.Raw.String(), "minute_retention", s.retention.Minute.String(),
+		"hour_retention", s.retention.Hour.String(), "day_retention", s.retention.Day.String())
+	return nil
+}
+
+// Stop 停止定期聚合
+func (s *TemperatureRollupService) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning {
+		return fmt.Errorf("温度数据降采样未在运行")
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+	s.logger.Info("停止温度数据降采样")
+	return nil
+}
+
+func (s *TemperatureRollupService) loop(stop <-chan struct{}) {
+	if err := s.Run(time.Now()); err != nil {
+		s.logger.Error("温度数据降采样失败", "error", err)
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Run(time.Now()); err != nil {
+				s.logger.Error("温度数据降采样失败", "error", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Run 聚合截至 now 的已完成时间桶，然后按保留时长清理
+func (s *TemperatureRollupService) Run(now time.Time) error {
+	// temperature_readings 由服务启动时迁移创建，不存在时跳过
+	if !s.db.Migrator().HasTable("temperature_readings") {
+		return nil
+	}
+	if err := s.rollupRaw(now); err != nil {
+		return fmt.Errorf("聚合1分钟数据失败: %w", err)
+	}
+	if err := s.rollupTier(models.ResolutionMinute, models.ResolutionHour); err != nil {
+		return fmt.Errorf("聚合1小时数据失败: %w", err)
+	}
+	if err := s.rollupTier(models.ResolutionHour, models.ResolutionDay); err != nil {
+		return fmt.Errorf("聚合1天数据失败: %w", err)
+	}
+	if err := s.cleanup(now); err != nil {
+		return fmt.Errorf("清理过期温度数据失败: %w", err)
+	}
+	return nil
+}
+
+// rollupRaw 把原始读数聚合为1分钟数据
+func (s *TemperatureRollupService) rollupRaw(now time.Time) error {
+	state, err := s.state(models.ResolutionMinute)
+	if err != nil {
+		return err
+	}
+	end := s.bucketStart(models.ResolutionMinute, now.Add(-rollupLag))
+
+	from := state.ProcessedUntil
+	if from.IsZero() {
+		var first []rawTemperatureReading
+		if err := s.db.Table("temperature_readings").Select("recorded_at").
+			Order("recorded_at ASC").Limit(1).Find(&first).Error; err != nil {
+			return err
+		}
+		from = end
+		if len(first) > 0 {
+			from = s.bucketStart(models.ResolutionMinute, first[0].RecordedAt)
+		}
+	}
+
+	for from.Before(end) || state.ProcessedUntil.IsZero() {
+		chunkEnd := from.Add(rollupChunk)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		var readings []rawTemperatureReading
+		if err := s.db.Table("temperature_readings").Select("sensor_id, channel, temperature, recorded_at").
+			Where("recorded_at >= ? AND recorded_at < ? AND status = ?", from, chunkEnd, rawReadingStatus).
+			Find(&readings).Error; err != nil {
+			return err
+		}
+		accs := make(map[rollupKey]*rollupAcc)
+		for _, r := range readings {
+			key := rollupKey{r.SensorID, r.Channel, s.bucketStart(models.ResolutionMinute, r.RecordedAt)}
+			s.accumulate(accs, key, r.Temperature, r.Temperature, r.Temperature, 1)
+		}
+
+		if err := s.save(models.ResolutionMinute, accs, chunkEnd); err != nil {
+			return err
+		}
+		state.ProcessedUntil = chunkEnd
+		from = chunkEnd
+	}
+	return nil
+}
+
+// rollupTier 把 source 分辨率的聚合数据再聚合为 target 分辨率，只处理 source 已完整覆盖的时间桶
+func (s *TemperatureRollupService) rollupTier(source, target string) error {
+	sourceState, err := s.state(source)
+	if err != nil {
+		return err
+	}
+	if sourceState.ProcessedUntil.IsZero() {
+		return nil
+	}
+	state, err := s.state(target)
+	if err != nil {
+		return err
+	}
+	end := s.bucketStart(target, sourceState.ProcessedUntil)
+
+	from := state.ProcessedUntil
+	if from.IsZero() {
+		var first []models.TemperatureRollup
+		if err := s.db.Where("resolution = ?", source).Order("bucket_start ASC").Limit(1).Find(&first).Error; err != nil {
+			return err
+		}
+		from = end
+		if len(first) > 0 {
+			from = s.bucketStart(target, first[0].BucketStart)
+		}
+	}
+
+	for from.Before(end) || state.ProcessedUntil.IsZero() {
+		chunkEnd := from.Add(rollupChunk)
+		if target == models.ResolutionDay {
+			chunkEnd = from.AddDate(0, 0, 30)
+		}
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		chunkEnd = s.bucketStart(target, chunkEnd)
+		if !chunkEnd.After(from) {
+			chunkEnd = end
+		}
+
+		var rollups []models.TemperatureRollup
+		if err := s.db.Where("resolution = ? AND bucket_start >= ? AND bucket_start < ?", source, from, chunkEnd).
+			Find(&rollups).Error; err != nil {
+			return err
+		}
+		accs := make(map[rollupKey]*rollupAcc)
+		for _, r := range rollups {
+			key := rollupKey{r.SensorID, r.Channel, s.bucketStart(target, r.BucketStart)}
+			s.accumulate(accs, key, r.MinTemp, r.MaxTemp, r.AvgTemp, r.Count)
+		}
+
+		if err := s.save(target, accs, chunkEnd); err != nil {
+			return err
+		}
+		state.ProcessedUntil = chunkEnd
+		from = chunkEnd
+	}
+	return nil
+}
+
+func (s *TemperatureRollupService) accumulate(accs map[rollupKey]*rollupAcc, key rollupKey, min, max, avg float64, count int) {
+	acc, ok := accs[key]
+	if !ok {
+		acc = &rollupAcc{}
+		accs[key] = acc
+	}
+	acc.add(min, max, avg, count)
+}
+
+// save 在同一事务中写入聚合结果（已存在的时间桶覆盖）并推进聚合进度
+func (s *TemperatureRollupService) save(resolution string, accs map[rollupKey]*rollupAcc, processedUntil time.Time) error {
+	rollups := make([]models.TemperatureRollup, 0, len(accs))
+	for key, acc := range accs {
+		rollups = append(rollups, models.TemperatureRollup{
+			Resolution:  resolution,
+			SensorID:    key.sensorID,
+			Channel:     key.channel,
+			BucketStart: key.bucket,
+			MinTemp:     acc.min,
+			MaxTemp:     acc.max,
+			AvgTemp:     roundTemperature(acc.sum / float64(acc.count)),
+			Count:       acc.count,
+		})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if len(rollups) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "resolution"}, {Name: "sensor_id"}, {Name: "channel"}, {Name: "bucket_start"}},
+				DoUpdates: clause.AssignmentColumns([]string{"min_temp", "max_temp", "avg_temp", "count"}),
+			}).CreateInBatches(rollups, rollupBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "resolution"}},
+			DoUpdates: clause.AssignmentColumns([]string{"processed_until", "updated_at"}),
+		}).Create(&models.TemperatureRollupState{Resolution: resolution, ProcessedUntil: processedUntil.UTC()}).Error
+	})
+}
+
+// cleanup 按保留时长删除过期数据。较细分辨率的数据在聚合到上一级之前不删除。
+func (s *TemperatureRollupService) cleanup(now time.Time) error {
+	tiers := []struct {
+		resolution string
+		retention  time.Duration
+		next       string // 需先聚合到的上一级分辨率
+	}{
+		{models.ResolutionRaw, s.retention.Raw, models.ResolutionMinute},
+		{models.ResolutionMinute, s.retention.Minute, models.ResolutionHour},
+		{models.ResolutionHour, s.retention.Hour, models.ResolutionDay},
+		{models.ResolutionDay, s.retention.Day, ""},
+	}
+
+	for _, tier := range tiers {
+		if tier.retention <= 0 {
+			continue
+		}
+		cutoff := now.Add(-tier.retention).UTC()
+		if tier.next != "" {
+			next, err := s.state(tier.next)
+			if err != nil {
+				return err
+			}
+			if next.ProcessedUntil.Before(cutoff) {
+				cutoff = next.ProcessedUntil
+			}
+		}
+		if cutoff.IsZero() {
+			continue
+		}
+
+		var result *gorm.DB
+		if tier.resolution == models.ResolutionRaw {
+			result = s.db.Exec("DELETE FROM temperature_readings WHERE recorded_at < ?", cutoff)
+		} else {
+			result = s.db.Where("resolution = ? AND bucket_start < ?", tier.resolution, cutoff).Delete(&models.TemperatureRollup{})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "resolution"}},
+			DoUpdates: clause.AssignmentColumns([]string{"retained_from", "updated_at"}),
+		}).Create(&models.TemperatureRollupState{Resolution: tier.resolution, RetainedFrom: cutoff}).Error
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected > 0 {
+			s.logger.Info("清理过期温度数据", "resolution", tier.resolution, "before", cutoff, "rows", result.RowsAffected)
+		}
+	}
+	return nil
+}
+
+func (s *TemperatureRollupService) state(resolution string) (*models.TemperatureRollupState, error) {
+	var states []models.TemperatureRollupState
+	if err := s.db.Where("resolution = ?", resolution).Limit(1).Find(&states).Error; err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return &models.TemperatureRollupState{Resolution: resolution}, nil
+	}
+	return &states[0], nil
+}
+
+// bucketStart 时间所在时间桶的起点（UTC）。小时和天按服务时区划分，兼容非整点时区
+func (s *TemperatureRollupService) bucketStart(resolution string, t time.Time) time.Time {
+	local := t.In(s.location)
+	y, m, d := local.Date()
+	switch resolution {
+	case models.ResolutionMinute:
+		return t.UTC().Truncate(time.Minute)
+	case models.ResolutionHour:
+		return time.Date(y, m, d, local.Hour(), 0, 0, 0, s.location).UTC()
+	case models.ResolutionDay:
+		return time.Date(y, m, d, 0, 0, 0, 0, s.location).UTC()
+	}
+	return t.UTC()
+}
+
+// historyResolution 按查询时间跨度选择分辨率，单通道数据点控制在约两千个以内
+func historyResolution(span time.Duration) string {
+	switch {
+	case span <= 6*time.Hour:
+		return models.ResolutionRaw
+	case span <= 48*time.Hour:
+		return models.ResolutionMinute
+	case span <= 90*24*time.Hour:
+		return models.ResolutionHour
+	}
+	return models.ResolutionDay
+}
+
+// coarserResolution 上一级分辨率
+func coarserResolution(resolution string) string {
+	switch resolution {
+	case models.ResolutionRaw:
+		return models.ResolutionMinute
+	case models.ResolutionMinute:
+		return models.ResolutionHour
+	}
+	return models.ResolutionDay
+}
+
+// GetHistory 查询温度历史。未指定分辨率时按时间跨度选择，查询起点早于该分辨率的保留范围时
+// 改用更粗的分辨率。尚未聚合的最近一段由原始读数即时聚合补齐。
+func (s *TemperatureRollupService) GetHistory(query models.TemperatureHistoryQuery) (string, []models.TemperatureHistoryPoint, error) {
+	resolution := query.Resolution
+	if resolution == "" {
+		resolution = historyResolution(query.End.Sub(query.Start))
+		for resolution != models.ResolutionDay {
+			state, err := s.state(resolution)
+			if err != nil {
+				return "", nil, err
+			}
+			if !query.Start.Before(state.RetainedFrom) {
+				break
+			}
+			resolution = coarserResolution(resolution)
+		}
+	}
+
+	if resolution == models.ResolutionRaw {
+		readings, err := s.rawReadings(query, query.Start, query.End)
+		if err != nil {
+			return "", nil, err
+		}
+		points := make([]models.TemperatureHistoryPoint, 0, len(readings))
+		for _, r := range readings {
+			points = append(points, models.TemperatureHistoryPoint{
+				Timestamp: r.RecordedAt.UTC(), SensorID: r.SensorID, Channel: r.Channel,
+				Temperature: r.Temperature, Min: r.Temperature, Max: r.Temperature, Avg: r.Temperature, Count: 1,
+				Status: r.Status,
+			})
+		}
+		return resolution, points, nil
+	}
+
+	state, err := s.state(resolution)
+	if err != nil {
+		return "", nil, err
+	}
+	start := s.bucketStart(resolution, query.Start)
+	processed := state.ProcessedUntil
+	if processed.Before(start) {
+		processed = start
+	}
+
+	points := []models.TemperatureHistoryPoint{}
+	if processed.After(start) {
+		db := s.db.Where("resolution = ? AND bucket_start >= ? AND bucket_start < ? AND bucket_start < ?",
+			resolution, start, query.End, processed)
+		if query.SensorID > 0 {
+			db = db.Where("sensor_id = ?", query.SensorID)
+		}
+		if query.Channel > 0 {
+			db = db.Where("channel = ?", query.Channel)
+		}
+		var rollups []models.TemperatureRollup
+		if err := db.Order("bucket_start ASC, sensor_id ASC, channel ASC").Find(&rollups).Error; err != nil {
+			return "", nil, err
+		}
+		for _, r := range rollups {
+			points = append(points, rollupPoint(r.BucketStart, r.SensorID, r.Channel, r.MinTemp, r.MaxTemp, r.AvgTemp, r.Count))
+		}
+	}
+
+	// 尚未聚合的部分由原始读数即时聚合
+	if processed.Before(query.End) {
+		readings, err := s.rawReadings(query, processed, query.End)
+		if err != nil {
+			return "", nil, err
+		}
+		accs := make(map[rollupKey]*rollupAcc)
+		for _, r := range readings {
+			if r.Status != rawReadingStatus {
+				continue
+			}
+			key := rollupKey{r.SensorID, r.Channel, s.bucketStart(resolution, r.RecordedAt)}
+			s.accumulate(accs, key, r.Temperature, r.Temperature, r.Temperature, 1)
+		}
+		tail := make([]models.TemperatureHistoryPoint, 0, len(accs))
+		for key, acc := range accs {
+			tail = append(tail, rollupPoint(key.bucket, key.sensorID, key.channel, acc.min, acc.max, roundTemperature(acc.sum/float64(acc.count)), acc.count))
+		}
+		sort.Slice(tail, func(i, j int) bool {
+			a, b := tail[i], tail[j]
+			if !a.Timestamp.Equal(b.Timestamp) {
+				return a.Timestamp.Before(b.Timestamp)
+			}
+			if a.SensorID != b.SensorID {
+				return a.SensorID < b.SensorID
+			}
+			return a.Channel < b.Channel
+		})
+		points = append(points, tail...)
+	}
+	return resolution, points, nil
+}
+
+func (s *TemperatureRollupService) rawReadings(query models.TemperatureHistoryQuery, start, end time.Time) ([]rawTemperatureReading, error) {
+	db := s.db.Table("temperature_readings").Select("sensor_id, channel, temperature, status, recorded_at").
+		Where("recorded_at >= ? AND recorded_at < ?", start.UTC(), end.UTC())
+	if query.SensorID > 0 {
+		db = db.Where("sensor_id = ?", query.SensorID)
+	}
+	if query.Channel > 0 {
+		db = db.Where("channel = ?", query.Channel)
+	}
+	var readings []rawTemperatureReading
+	err := db.Order("recorded_at ASC").Find(&readings).Error
+	return readings, err
+}
+
+func rollupPoint(bucket time.Time, sensorID uint, channel int, min, max, avg float64, count int) models.TemperatureHistoryPoint {
+	return models.TemperatureHistoryPoint{
+		Timestamp: bucket.UTC(), SensorID: sensorID, Channel: channel,
+		Temperature: avg, Min: min, Max: max, Avg: avg, Count: count,
+	}
+}
+
+func roundTemperature(v float64) float64 {
+	return math.Round(v*100) / 100
+}
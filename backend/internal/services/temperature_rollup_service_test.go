@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testTemperatureReading struct {
+	ID          uint `gorm:"primaryKey"`
+	SensorID    uint
+	Channel     int
+	Temperature float64
+	Status      string
+	RecordedAt  time.Time
+}
+
+func (testTemperatureReading) TableName() string { return "temperature_readings" }
+
+func TestHistoryResolution(t *testing.T) {
+	assert.Equal(t, models.ResolutionRaw, historyResolution(time.Hour))
+	assert.Equal(t, models.ResolutionMinute, historyResolution(24*time.Hour))
+	assert.Equal(t, models.ResolutionHour, historyResolution(30*24*time.Hour))
+	assert.Equal(t, models.ResolutionDay, historyResolution(365*24*time.Hour))
+}
+
+func TestTemperatureRollup(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&testTemperatureReading{}, &models.TemperatureRollup{}, &models.TemperatureRollupState{}))
+
+	// 两天的读数：每30秒一条，1号传感器通道1 20℃/22℃交替，通道2恒为30℃
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var readings []testTemperatureReading
+	for at := start; at.Before(start.Add(48 * time.Hour)); at = at.Add(30 * time.Second) {
+		temp := 20.0
+		if at.Second() == 30 {
+			temp = 22
+		}
+		readings = append(readings,
+			testTemperatureReading{SensorID: 1, Channel: 1, Temperature: temp, Status: "normal", RecordedAt: at},
+			testTemperatureReading{SensorID: 1, Channel: 2, Temperature: 30, Status: "normal", RecordedAt: at})
+	}
+	require.NoError(t, db.CreateInBatches(readings, 500).Error)
+
+	service := NewTemperatureRollupService(db, logger.NewLogger(), time.Minute, TemperatureRetention{Raw: 24 * time.Hour})
+	service.location = time.UTC
+	now := start.Add(48*time.Hour + 2*time.Minute)
+	require.NoError(t, service.Run(now))
+
+	var count int64
+	db.Model(&models.TemperatureRollup{}).Where("resolution = ?", models.ResolutionMinute).Count(&count)
+	assert.Equal(t, int64(2*48*60), count)
+	db.Model(&models.TemperatureRollup{}).Where("resolution = ?", models.ResolutionHour).Count(&count)
+	assert.Equal(t, int64(2*48), count)
+	db.Model(&models.TemperatureRollup{}).Where("resolution = ?", models.ResolutionDay).Count(&count)
+	assert.Equal(t, int64(2*2), count)
+
+	var day models.TemperatureRollup
+	require.NoError(t, db.Where("resolution = ? AND channel = 1", models.ResolutionDay).Order("bucket_start").First(&day).Error)
+	assert.Equal(t, 20.0, day.MinTemp)
+	assert.Equal(t, 22.0, day.MaxTemp)
+	assert.Equal(t, 21.0, day.AvgTemp)
+	assert.Equal(t, 2880, day.Count)
+
+	// 原始读数只保留最近一天
+	db.Model(&testTemperatureReading{}).Where("recorded_at < ?", now.Add(-24*time.Hour)).Count(&count)
+	assert.Zero(t, count)
+
+	// 再次运行不重复聚合
+	require.NoError(t, service.Run(now))
+	db.Model(&models.TemperatureRollup{}).Where("resolution = ?", models.ResolutionMinute).Count(&count)
+	assert.Equal(t, int64(2*48*60), count)
+
+	// 最近一小时用原始读数
+	resolution, points, err := service.GetHistory(models.TemperatureHistoryQuery{SensorID: 1, Channel: 1, Start: now.Add(-time.Hour), End: now})
+	require.NoError(t, err)
+	assert.Equal(t, models.ResolutionRaw, resolution)
+	assert.Len(t, points, 116) // 读数截止于 now-2m
+
+	// 查询起点早于原始读数保留范围时改用1分钟数据
+	resolution, points, err = service.GetHistory(models.TemperatureHistoryQuery{SensorID: 1, Channel: 1, Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, models.ResolutionMinute, resolution)
+	require.Len(t, points, 60)
+	assert.Equal(t, 21.0, points[0].Avg)
+	assert.Equal(t, 2, points[0].Count)
+
+	// 未聚合的最近部分由原始读数补齐
+	db.Create(&testTemperatureReading{SensorID: 1, Channel: 1, Temperature: 25, Status: "normal", RecordedAt: now.Add(-30 * time.Second)})
+	resolution, points, err = service.GetHistory(models.TemperatureHistoryQuery{SensorID: 1, Channel: 1, Start: now.Add(-48 * time.Hour), End: now})
+	require.NoError(t, err)
+	assert.Equal(t, models.ResolutionMinute, resolution)
+	last := points[len(points)-1]
+	assert.Equal(t, now.Truncate(time.Minute).Add(-time.Minute), last.Timestamp)
+	assert.Equal(t, 25.0, last.Avg)
+
+	resolution, points, err = service.GetHistory(models.TemperatureHistoryQuery{Start: start, End: now, Resolution: models.ResolutionDay})
+	require.NoError(t, err)
+	assert.Equal(t, models.ResolutionDay, resolution)
+	assert.Len(t, points, 5)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"smart-device-management/internal/models"
+	"smart-device-management/pkg/logger"
+	"smart-device-management/pkg/validation"
+	"smart-device-management/pkg/websocket"
+
+	"gorm.io/gorm"
+)
+
+// sensorFaultNotifyInterval 检查新产生的传感器故障并推送告警的间隔
+const sensorFaultNotifyInterval = 10 * time.Second
+
+// sensorFaultStateNames 健康状态的告警描述
+var sensorFaultStateNames = map[string]string{
+	validation.HealthDisconnected: "探头断开",
+	validation.HealthStuck:        "读数卡死",
+	validation.HealthOutOfRange:   "读数超量程",
+	validation.HealthSpike:        "读数跳变",
+}
+
+// TemperatureSensorFaultService 温度传感器故障告警：故障由温度采集服务的健康检测产生，
+// 本服务推送告警通知并提供查询。
+type TemperatureSensorFaultService struct {
+	db     *gorm.DB
+	logger *logger.Logger
+
+	mutex     sync.Mutex
+	isRunning bool
+	stopChan  chan struct{}
+}
+
+// NewTemperatureSensorFaultService 创建温度传感器故障告警服务
+func NewTemperatureSensorFaultService(db *gorm.DB, logger *logger.Logger) *TemperatureSensorFaultService {
+	return &TemperatureSensorFaultService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Start 启动故障告警推送
+func (s *TemperatureSensorFaultService) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("传感器故障告警已在运行")
+	}
+
+	s.isRunning = true
+	s.stopChan = make(chan struct{})
+	go s.loop(s.stopChan)
+
+	s.logger.Info("启动传感器故障告警推送", "interval", sensorFaultNotifyInterval.String())
+	return nil
+}
+
+// Stop 停止故障告警推送
+func (s *TemperatureSensorFaultService) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isRunning {
+		return fmt.Errorf("传感器故障告警未在运行")
+	}
+
+	close(s.stopChan)
+	s.isRunning = false
+	s.logger.Info("停止传感器故障告警推送")
+	return nil
+}
+
+func (s *TemperatureSensorFaultService) loop(stop <-chan struct{}) {
+	ticker := time.NewTicker(sensorFaultNotifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.NotifyNewFaults(); err != nil {
+				s.logger.Error("推送传感器故障告警失败", "error", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NotifyNewFaults 推送尚未通知的故障产生和恢复
+func (s *TemperatureSensorFaultService) NotifyNewFaults() error {
+	if !s.db.Migrator().HasTable(&models.TemperatureSensorFault{}) {
+		return nil
+	}
+
+	var faults []models.TemperatureSensorFault
+	if err := s.db.Where("notified = ?", false).Order("id ASC").Limit(100).Find(&faults).Error; err != nil {
+		return err
+	}
+
+	for _, fault := range faults {
+		var sensors []models.TemperatureSensor
+		s.db.Limit(1).Find(&sensors, fault.SensorID)
+		name := fmt.Sprintf("传感器%d", fault.SensorID)
+		if len(sensors) > 0 {
+			name = sensors[0].Name
+		}
+
+		status, at := "active", fault.RaisedAt
+		if fault.Status == models.SensorFaultCleared && fault.ClearedAt != nil {
+			status, at = "resolved", *fault.ClearedAt
+		}
+		websocket.BroadcastAlarmTriggered(map[string]interface{}{
+			"id":        fmt.Sprintf("sensor-fault-%d", fault.ID),
+			"type":      "sensor_fault",
+			"level":     "warning",
+			"status":    status,
+			"title":     fmt.Sprintf("温度传感器故障：%s", sensorFaultStateNames[fault.State]),
+			"message":   fmt.Sprintf("%s 通道%d %s", name, fault.Channel, fault.Reason),
+			"deviceId":  fmt.Sprintf("%d-%d", fault.SensorID, fault.Channel),
+			"timestamp": at.Format(time.RFC3339),
+		})
+
+		if err := s.db.Model(&fault).Update("notified", true).Error; err != nil {
+			return err
+		}
+		s.logger.Warn("温度传感器故障", "sensor_id", fault.SensorID, "channel", fault.Channel,
+			"state", fault.State, "reason", fault.Reason, "status", fault.Status)
+	}
+	return nil
+}
+
+// ListFaults 查询传感器故障，status 为空时返回全部，按产生时间倒序
+func (s *TemperatureSensorFaultService) ListFaults(status string, sensorID uint, limit int) ([]models.TemperatureSensorFault, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	db := s.db.Model(&models.TemperatureSensorFault{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if sensorID > 0 {
+		db = db.Where("sensor_id = ?", sensorID)
+	}
+	var faults []models.TemperatureSensorFault
+	err := db.Order("raised_at DESC, id DESC").Limit(limit).Find(&faults).Error
+	return faults, err
+}
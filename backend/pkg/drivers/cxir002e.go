@@ -0,0 +1,31 @@
+package drivers
+
+import (
+	"smart-device-management/pkg/infrared"
+)
+
+// cxir002e CX-IR002E 红外空调控制器驱动，协议实现见 pkg/infrared
+type cxir002e struct{}
+
+func init() {
+	Register(cxir002e{})
+}
+
+func (cxir002e) Info() Info {
+	return Info{
+		Model:        DefaultIRControllerModel,
+		Aliases:      []string{"CX-IR002", "CXIR002E"},
+		Kind:         KindIRController,
+		Manufacturer: "CORN智能互联",
+		Description:  "红外空调控制器，内置空调码库，4路红外输出，支持网口TCP/UDP JSON协议和RS485 MODBUS-RTU",
+		Capabilities: []Capability{CapACControl, CapACMatch, CapIRLearning, CapAnalogInput},
+		ConfigSchema: []ConfigField{
+			{Key: "mode", Label: "通信模式", Type: "enum", Default: string(infrared.ModeTCPServer), Options: []string{
+				string(infrared.ModeTCPServer), string(infrared.ModeTCPClient), string(infrared.ModeUDP), string(infrared.ModeRTU),
+			}},
+			{Key: "ir_port", Label: "红外输出口（0为四路同时发送）", Type: "int", Default: 0, Min: float(0), Max: float(infrared.MaxIRPort)},
+			{Key: "ac_code", Label: "空调码库代号", Type: "int", Min: float(1), Max: float(0xFFFF)},
+		},
+		DefaultPort: 50000,
+	}
+}
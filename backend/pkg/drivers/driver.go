@@ -0,0 +1,241 @@
+// Package drivers 设备驱动注册表。
+//
+// 每种设备型号对应一个驱动，驱动声明自身的能力（遥测、分合闸、锁定、复位、跳闸记录、温度采集）
+// 和配置项说明，并在 init 中调用 Register 注册。服务层按 Device.DeviceModel 或
+// TemperatureSensor.DeviceType 查找驱动，再通过能力接口访问设备，接入新品牌只需新增驱动文件。
+package drivers
+
+import (
+	"smart-device-management/pkg/modbus"
+)
+
+// Kind 驱动适用的设备类别
+type Kind string
+
+const (
+	KindBreaker           Kind = "breaker"
+	KindTemperatureSensor Kind = "temperature_sensor"
+	KindIRController      Kind = "ir_controller"
+)
+
+// Capability 驱动能力
+type Capability string
+
+const (
+	CapTelemetry   Capability = "telemetry"    // 读取遥测数据
+	CapSwitch      Capability = "switch"       // 远程分合闸
+	CapLock        Capability = "lock"         // 远程锁定
+	CapReset       Capability = "reset"        // 配置复位/记录清零
+	CapTripHistory Capability = "trip_history" // 跳闸记录
+	CapTemperature Capability = "temperature"  // 多路温度采集
+	CapProtection  Capability = "protection"   // 保护参数读写
+	CapBroadcast   Capability = "broadcast"    // 广播地址（站号0）分闸
+	CapLeakageTest Capability = "leakage_test" // 漏电试验（远程按下试验按钮）
+	CapCommission  Capability = "commission"   // 投运调试（读取和修改站号、波特率）
+	CapACControl   Capability = "ac_control"   // 红外空调控制（开关机、模式、温度、风速）
+	CapACMatch     Capability = "ac_match"     // 空调码库一键匹配
+	CapIRLearning  Capability = "ir_learning"  // 红外学习
+	CapAnalogInput Capability = "analog_input" // 模拟量输入
+)
+
+// ConfigField 驱动配置项说明，用于前端生成表单和校验 Device.Config
+type ConfigField struct {
+	Key      string      `json:"key"`
+	Label    string      `json:"label"`
+	Type     string      `json:"type"` // int/float/string/bool/enum
+	Unit     string      `json:"unit,omitempty"`
+	Required bool        `json:"required"`
+	Default  interface{} `json:"default,omitempty"`
+	Options  []string    `json:"options,omitempty"` // enum 可选值
+	Min      *float64    `json:"min,omitempty"`
+	Max      *float64    `json:"max,omitempty"`
+	Step     float64     `json:"step,omitempty"` // 数值分辨率，取值须为其整数倍
+}
+
+// Info 驱动描述
+type Info struct {
+	Model          string         `json:"model"`
+	Aliases        []string       `json:"aliases,omitempty"`
+	Kind           Kind           `json:"kind"`
+	Manufacturer   string         `json:"manufacturer"`
+	Description    string         `json:"description"`
+	Capabilities   []Capability   `json:"capabilities"`
+	ConfigSchema   []ConfigField  `json:"config_schema"`
+	DefaultPort    int            `json:"default_port"`
+	DefaultFraming modbus.Framing `json:"default_framing"`
+}
+
+// Driver 设备驱动，具体能力通过下列能力接口提供
+type Driver interface {
+	Info() Info
+}
+
+// Supports 判断驱动是否声明了指定能力
+func Supports(d Driver, c Capability) bool {
+	for _, capability := range d.Info().Capabilities {
+		if capability == c {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakerTelemetry 断路器遥测快照（统一单位）
+type BreakerTelemetry struct {
+	Closed         bool    `json:"closed"`
+	LocalLocked    bool    `json:"local_locked"`
+	Voltage        float64 `json:"voltage"`         // V
+	Current        float64 `json:"current"`         // A
+	ActivePower    float64 `json:"active_power"`    // W
+	PowerFactor    float64 `json:"power_factor"`    // 0-1
+	Frequency      float64 `json:"frequency"`       // Hz
+	LeakageCurrent float64 `json:"leakage_current"` // mA
+	Temperature    float64 `json:"temperature"`     // °C
+	// Energy 设备累计电能 kWh，型号无电能寄存器时为空（由有功功率积分）
+	Energy *float64 `json:"energy,omitempty"`
+	// 保护阈值
+	OverCurrentLimit float64 `json:"over_current_limit"` // A
+	LeakageLimit     float64 `json:"leakage_limit"`      // mA
+	OverTempLimit    float64 `json:"over_temp_limit"`    // °C
+	// RawStatus 设备原始状态字，便于日志排查
+	RawStatus uint16 `json:"raw_status"`
+}
+
+// TripHistory 断路器跳闸记录，Records 按时间从新到旧
+type TripHistory struct {
+	Latest  uint16   `json:"latest"`
+	Records []uint16 `json:"records"`
+}
+
+// 跳闸原因分类
+const (
+	TripCategoryProtection = "protection" // 保护动作（过流、漏电、过温、过载、过压、欠压）
+	TripCategoryOperation  = "operation"  // 本地/远程操作、锁定
+	TripCategoryFault      = "fault"      // 模块故障、电源故障
+	TripCategoryLimit      = "limit"      // 电量限制
+	TripCategoryUnknown    = "unknown"
+)
+
+// TripAnalysis 跳闸原因解析结果
+type TripAnalysis struct {
+	Code        uint16   `json:"code"`
+	HexCode     string   `json:"hex_code"`
+	Type        string   `json:"type"` // single 单一原因 / composite 复合原因
+	Category    string   `json:"category"`
+	Remote      bool     `json:"remote"`  // 远程命令分闸（由控制记录追溯，不作为跳闸事件）
+	Leakage     bool     `json:"leakage"` // 包含漏电保护动作，用于判定漏电自检是否通过
+	Reasons     []string `json:"reasons"`
+	Description string   `json:"description"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// ResetKind 复位类型
+type ResetKind string
+
+const (
+	ResetConfig  ResetKind = "config"  // 恢复出厂配置
+	ResetRecords ResetKind = "records" // 清除跳闸记录
+)
+
+// TelemetryReader 读取断路器遥测
+type TelemetryReader interface {
+	ReadTelemetry(c *modbus.ModbusClient) (*BreakerTelemetry, error)
+}
+
+// Switcher 远程分合闸
+type Switcher interface {
+	Switch(c *modbus.ModbusClient, on bool) error
+}
+
+// BroadcastSwitcher 通过广播地址让总线上所有设备同时分闸，客户端站号必须为0，设备不应答
+type BroadcastSwitcher interface {
+	BroadcastOff(c *modbus.ModbusClient) error
+}
+
+// LeakageTester 远程漏电试验：模拟漏电使断路器跳闸，由调用方回读状态和跳闸原因判定结果
+type LeakageTester interface {
+	TestLeakage(c *modbus.ModbusClient) error
+}
+
+// BreakerIdentity 断路器通信参数，附带实时量用于区分同一地址上的多台设备
+type BreakerIdentity struct {
+	StationAddress int     `json:"station_address"`
+	BaudRate       int     `json:"baud_rate"`
+	Closed         bool    `json:"closed"`
+	Voltage        float64 `json:"voltage"` // V
+	Current        float64 `json:"current"` // A
+}
+
+// Commissioner 投运调试：读取通信参数，修改站号和波特率。
+// 修改后设备以新参数通信，由调用方在新地址（或新波特率）上回读确认
+type Commissioner interface {
+	ReadIdentity(c *modbus.ModbusClient) (*BreakerIdentity, error)
+	SetStationAddress(c *modbus.ModbusClient, address int) error
+	SetBaudRate(c *modbus.ModbusClient, baud int) error
+	BaudRates() []int
+}
+
+// Fingerprinter 按型号特有的寄存器特征判断站号上的应答设备是否为本型号，用于网络自动发现。
+// 读取成功但特征不符时返回 false，通信失败或异常响应时返回原始错误，由调用方判断站号是否有设备
+type Fingerprinter interface {
+	Fingerprint(c *modbus.ModbusClient, station int) (matched bool, detail string, err error)
+}
+
+// Locker 远程锁定/解锁
+type Locker interface {
+	SetLock(c *modbus.ModbusClient, locked bool) error
+}
+
+// Resetter 设备复位
+type Resetter interface {
+	Reset(c *modbus.ModbusClient, kind ResetKind) error
+}
+
+// TripHistoryReader 读取跳闸记录
+type TripHistoryReader interface {
+	ReadTripHistory(c *modbus.ModbusClient) (*TripHistory, error)
+}
+
+// TripDecoder 解析跳闸原因代码
+type TripDecoder interface {
+	DecodeTrip(code uint16) *TripAnalysis
+}
+
+// ProtectionConfigurator 保护参数读写，参数名、单位和取值范围由 ProtectionSchema 描述，值均为工程单位（延时为秒）
+type ProtectionConfigurator interface {
+	ProtectionSchema() []ConfigField
+	ReadProtection(c *modbus.ModbusClient) (map[string]float64, error)
+	WriteProtection(c *modbus.ModbusClient, name string, value float64) error
+}
+
+// SensorIdentity 温度模块识别信息
+type SensorIdentity struct {
+	DeviceType int    `json:"device_type"` // 设备类型寄存器原始值
+	Address    int    `json:"address"`
+	BaudRate   string `json:"baud_rate"`
+	CRCOrder   string `json:"crc_order"`
+	Recognized bool   `json:"recognized"` // 设备类型与驱动匹配
+}
+
+// 温度通道状态
+const (
+	ChannelOK          = "OK"
+	ChannelOpenCircuit = "OPEN_CIRCUIT"
+	ChannelOutOfRange  = "OUT_OF_RANGE"
+)
+
+// ChannelReading 单路温度读数，Value 为空表示无有效温度
+type ChannelReading struct {
+	Channel int      `json:"channel"`
+	Raw     uint16   `json:"raw"`
+	Value   *float64 `json:"value"`
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// TemperatureReader 多路温度模块
+type TemperatureReader interface {
+	Channels() int
+	Identify(c *modbus.ModbusClient) (*SensorIdentity, error)
+	ReadTemperatures(c *modbus.ModbusClient) ([]ChannelReading, error)
+}
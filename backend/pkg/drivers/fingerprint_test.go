@@ -0,0 +1,74 @@
+package drivers
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"smart-device-management/pkg/modbus"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerBank 按功能码03/04应答固定寄存器值的传输层桩，未定义的地址返回非法地址异常
+type registerBank struct {
+	holding map[uint16]uint16
+	input   map[uint16]uint16
+}
+
+func (b *registerBank) Send(unitID byte, pdu []byte) ([]byte, error) {
+	regs := b.holding
+	if pdu[0] == 0x04 {
+		regs = b.input
+	}
+	start, count := binary.BigEndian.Uint16(pdu[1:3]), binary.BigEndian.Uint16(pdu[3:5])
+	resp := []byte{pdu[0], byte(count * 2)}
+	for addr := start; addr < start+count; addr++ {
+		v, ok := regs[addr]
+		if !ok {
+			return nil, &modbus.ExceptionError{FunctionCode: pdu[0], Code: 0x02}
+		}
+		resp = binary.BigEndian.AppendUint16(resp, v)
+	}
+	return resp, nil
+}
+
+func (b *registerBank) Close() error { return nil }
+
+func TestFingerprint(t *testing.T) {
+	lx47, err := Breaker("LX47LE-125")
+	require.NoError(t, err)
+	klt, err := TemperatureSensor("KLT-18B20-6H1")
+	require.NoError(t, err)
+
+	breaker := &registerBank{
+		holding: map[uint16]uint16{0: 3, 1: 9600},
+		input:   map[uint16]uint16{0: 0x00F0},
+	}
+	sensor := &registerBank{
+		holding: map[uint16]uint16{0: 235, 1: 241, 0x10: 19, 0x11: 3, 0x12: 3, 0x13: 0},
+	}
+
+	matched, detail, err := lx47.(Fingerprinter).Fingerprint(modbus.NewModbusClientWithTransport(breaker, 3), 3)
+	require.NoError(t, err)
+	assert.True(t, matched)
+	assert.Contains(t, detail, "合闸")
+
+	// 站号寄存器与应答站号不一致
+	matched, _, err = lx47.(Fingerprinter).Fingerprint(modbus.NewModbusClientWithTransport(breaker, 4), 4)
+	require.NoError(t, err)
+	assert.False(t, matched)
+
+	matched, _, err = klt.(Fingerprinter).Fingerprint(modbus.NewModbusClientWithTransport(breaker, 3), 3)
+	assert.Error(t, err)
+	assert.False(t, matched)
+
+	matched, _, err = klt.(Fingerprinter).Fingerprint(modbus.NewModbusClientWithTransport(sensor, 3), 3)
+	require.NoError(t, err)
+	assert.True(t, matched)
+
+	// 温度模块没有输入寄存器，LX47 特征读取返回异常响应
+	_, _, err = lx47.(Fingerprinter).Fingerprint(modbus.NewModbusClientWithTransport(sensor, 3), 3)
+	var exception *modbus.ExceptionError
+	assert.ErrorAs(t, err, &exception)
+}
@@ -0,0 +1,121 @@
+package drivers
+
+import (
+	"fmt"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// KLT-18B20-6H1 保持寄存器
+const (
+	kltTempStart    = 0x0000 // 0x0000-0x0005 六路温度，0.1°C，16位补码
+	kltDeviceType   = 0x0010 // 设备类型，固定为19
+	kltDeviceTypeID = 19
+	kltChannels     = 6
+)
+
+// klt18b20 KLT-18B20-6H1 六路DS18B20温度采集模块驱动
+type klt18b20 struct{}
+
+func init() {
+	Register(klt18b20{})
+}
+
+func (klt18b20) Info() Info {
+	return Info{
+		Model:        "KLT-18B20-6H1",
+		Aliases:      []string{"RS485-18B20-6H1"},
+		Kind:         KindTemperatureSensor,
+		Description:  "六路DS18B20温度采集模块，RS485 MODBUS-RTU",
+		Capabilities: []Capability{CapTemperature},
+		ConfigSchema: []ConfigField{
+			{Key: "channels", Label: "启用通道数", Type: "int", Default: kltChannels, Min: float(1), Max: float(kltChannels)},
+		},
+		DefaultPort:    502,
+		DefaultFraming: modbus.FramingMBAP,
+	}
+}
+
+func (klt18b20) Channels() int {
+	return kltChannels
+}
+
+// Identify 读取设备类型(0x0010)、地址(0x0011)、波特率(0x0012)、CRC字节序(0x0013)
+func (klt18b20) Identify(c *modbus.ModbusClient) (*SensorIdentity, error) {
+	regs, err := c.ReadHoldingRegisters(kltDeviceType, 4)
+	if err != nil {
+		return nil, fmt.Errorf("读取设备类型失败: %w", err)
+	}
+
+	identity := &SensorIdentity{
+		DeviceType: int(regs[0]),
+		Address:    int(regs[1]),
+		BaudRate:   kltBaudRate(regs[2]),
+		CRCOrder:   "高字节在前",
+		Recognized: regs[0] == kltDeviceTypeID,
+	}
+	if regs[3] != 0 {
+		identity.CRCOrder = "低字节在前"
+	}
+	return identity, nil
+}
+
+// Fingerprint 设备类型寄存器(0x0010)固定为19
+func (d klt18b20) Fingerprint(c *modbus.ModbusClient, station int) (bool, string, error) {
+	identity, err := d.Identify(c)
+	if err != nil {
+		return false, "", err
+	}
+	if !identity.Recognized {
+		return false, "", nil
+	}
+	return true, fmt.Sprintf("设备类型 %d，地址 %d，波特率 %s", identity.DeviceType, identity.Address, identity.BaudRate), nil
+}
+
+// ReadTemperatures 一帧读取六路温度
+func (klt18b20) ReadTemperatures(c *modbus.ModbusClient) ([]ChannelReading, error) {
+	regs, err := c.ReadHoldingRegisters(kltTempStart, kltChannels)
+	if err != nil {
+		return nil, fmt.Errorf("读取温度失败: %w", err)
+	}
+
+	readings := make([]ChannelReading, 0, kltChannels)
+	for i, raw := range regs {
+		readings = append(readings, parseDS18B20(i+1, raw))
+	}
+	return readings, nil
+}
+
+// parseDS18B20 解析温度原始值：开路时模块返回 0xF8CE/0xFFFF/0x7FFF/0x8000 等异常值。
+// 上电默认值 85.0°C 是合法的温度编码，由采集端的传感器健康检测识别。
+func parseDS18B20(channel int, raw uint16) ChannelReading {
+	reading := ChannelReading{Channel: channel, Raw: raw}
+
+	if raw == 0xF8CE || raw == 0xFFFF || raw == 0x7FFF || raw == 0x8000 || (raw > 30000 && raw <= 0x7FFF) {
+		reading.Status = ChannelOpenCircuit
+		reading.Error = "传感器开路"
+		return reading
+	}
+
+	temperature := float64(int16(raw)) / 10.0
+	if temperature < -55 || temperature > 125 {
+		reading.Status = ChannelOutOfRange
+		reading.Error = fmt.Sprintf("温度超出范围: %.1f°C", temperature)
+		return reading
+	}
+
+	reading.Status = ChannelOK
+	reading.Value = &temperature
+	return reading
+}
+
+func kltBaudRate(code uint16) string {
+	baudRates := map[uint16]string{
+		0: "300", 1: "1200", 2: "2400", 3: "4800", 4: "9600",
+		5: "19200", 6: "38400", 7: "57600", 8: "115200",
+	}
+	if rate, ok := baudRates[code]; ok {
+		return rate
+	}
+	return "未知"
+}
@@ -0,0 +1,244 @@
+package drivers
+
+import (
+	"fmt"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// LX47LE-125 线圈地址（协议地址，00001 -> 0）
+const (
+	lx47CoilResetConfig  = 0 // 00001 恢复出厂配置
+	lx47CoilSwitch       = 1 // 00002 远程分合闸
+	lx47CoilLock         = 2 // 00003 远程锁定
+	lx47CoilClearRecords = 4 // 00005 清除跳闸记录
+	lx47CoilLeakageTest  = 5 // 00006 漏电试验按钮（协议V4.3）
+	lx47StationReg       = 0 // 40001 站号
+	lx47BaudRateReg      = 1 // 40002 波特率
+	lx47RemoteSwitchReg  = 13
+	lx47NoTrip           = 0xF
+)
+
+// lx47le125 LX47LE-125 智能漏电断路器驱动
+type lx47le125 struct{}
+
+func init() {
+	Register(lx47le125{})
+}
+
+func (lx47le125) Info() Info {
+	return Info{
+		Model:        "LX47LE-125",
+		Aliases:      []string{"LX47LE"},
+		Kind:         KindBreaker,
+		Description:  "单相智能漏电断路器，RS485 MODBUS-RTU，经网关接入",
+		Capabilities: []Capability{CapTelemetry, CapSwitch, CapLock, CapReset, CapTripHistory, CapProtection, CapBroadcast, CapLeakageTest, CapCommission},
+		ConfigSchema: []ConfigField{
+			{Key: "rated_current", Label: "额定电流", Type: "float", Unit: "A", Default: 63.0, Min: float(1), Max: float(125)},
+			{Key: "rated_voltage", Label: "额定电压", Type: "float", Unit: "V", Default: 220.0, Min: float(100), Max: float(450)},
+		},
+		DefaultPort:    502,
+		DefaultFraming: modbus.FramingMBAP,
+	}
+}
+
+// ReadTelemetry 输入寄存器 30001-30015 与保持寄存器 40005-40007 各一帧
+func (lx47le125) ReadTelemetry(c *modbus.ModbusClient) (*BreakerTelemetry, error) {
+	s, err := c.ReadRegisterMap(modbus.LX47LE125Map, modbus.LX47TelemetryPoints...)
+	if err != nil {
+		return nil, err
+	}
+
+	// 30001：高字节本地锁定，低字节 0xF0合闸/0x0F分闸
+	status := uint16(s.Raw(modbus.LX47Status))
+	energy := s.Value(modbus.LX47Energy)
+	return &BreakerTelemetry{
+		Closed:           status&0xFF == 0xF0,
+		LocalLocked:      (status>>8)&0x01 != 0,
+		Voltage:          s.Value(modbus.LX47Voltage),
+		Current:          s.Value(modbus.LX47Current),
+		ActivePower:      s.Value(modbus.LX47ActivePower),
+		PowerFactor:      s.Value(modbus.LX47PowerFactor),
+		Frequency:        s.Value(modbus.LX47Frequency),
+		LeakageCurrent:   s.Value(modbus.LX47LeakageCurrent),
+		Temperature:      s.Value(modbus.LX47Temperature),
+		Energy:           &energy,
+		OverCurrentLimit: s.Value(modbus.LX47OverCurrent),
+		LeakageLimit:     s.Value(modbus.LX47LeakageLimit),
+		OverTempLimit:    s.Value(modbus.LX47OverTemp),
+		RawStatus:        status,
+	}, nil
+}
+
+// Switch 优先写线圈00002，失败时写保持寄存器40014作为备用方案
+func (lx47le125) Switch(c *modbus.ModbusClient, on bool) error {
+	coilErr := c.WriteSingleCoil(lx47CoilSwitch, on)
+	if coilErr == nil {
+		return nil
+	}
+
+	var value uint16
+	if on {
+		value = 0xFF00
+	}
+	if err := c.WriteSingleRegister(lx47RemoteSwitchReg, value); err != nil {
+		return fmt.Errorf("线圈控制失败(%v)，寄存器控制也失败: %w", coilErr, err)
+	}
+	return nil
+}
+
+// BroadcastOff 站号0写线圈00002为OFF。广播帧没有应答，寄存器备用方案无法确认，不做回退
+func (lx47le125) BroadcastOff(c *modbus.ModbusClient) error {
+	return c.WriteSingleCoil(lx47CoilSwitch, false)
+}
+
+// TestLeakage 写线圈00006，合闸状态下设备模拟漏电并以漏电保护跳闸
+func (lx47le125) TestLeakage(c *modbus.ModbusClient) error {
+	return c.WriteSingleCoil(lx47CoilLeakageTest, true)
+}
+
+// lx47BaudRates 40002 支持的波特率（寄存器直接存放波特率数值）
+var lx47BaudRates = []int{1200, 2400, 4800, 9600, 19200}
+
+// ReadIdentity 保持寄存器 40001-40002 与输入寄存器 30001-30009 各一帧
+func (lx47le125) ReadIdentity(c *modbus.ModbusClient) (*BreakerIdentity, error) {
+	s, err := c.ReadRegisterMap(modbus.LX47LE125Map,
+		modbus.LX47StationAddress, modbus.LX47BaudRate, modbus.LX47Status, modbus.LX47Voltage, modbus.LX47Current)
+	if err != nil {
+		return nil, err
+	}
+	return &BreakerIdentity{
+		StationAddress: int(s.Raw(modbus.LX47StationAddress)),
+		BaudRate:       int(s.Raw(modbus.LX47BaudRate)),
+		Closed:         uint16(s.Raw(modbus.LX47Status))&0xFF == 0xF0,
+		Voltage:        s.Value(modbus.LX47Voltage),
+		Current:        s.Value(modbus.LX47Current),
+	}, nil
+}
+
+// Fingerprint 40001 等于应答站号、40002 为支持的波特率、30001 低字节为合闸(0xF0)或分闸(0x0F)
+func (d lx47le125) Fingerprint(c *modbus.ModbusClient, station int) (bool, string, error) {
+	s, err := c.ReadRegisterMap(modbus.LX47LE125Map, modbus.LX47StationAddress, modbus.LX47BaudRate, modbus.LX47Status)
+	if err != nil {
+		return false, "", err
+	}
+
+	address, baud := int(s.Raw(modbus.LX47StationAddress)), int(s.Raw(modbus.LX47BaudRate))
+	state := uint16(s.Raw(modbus.LX47Status)) & 0xFF
+	if address != station || (state != 0xF0 && state != 0x0F) {
+		return false, "", nil
+	}
+	for _, supported := range d.BaudRates() {
+		if baud == supported {
+			closed := "分闸"
+			if state == 0xF0 {
+				closed = "合闸"
+			}
+			return true, fmt.Sprintf("站号 %d，波特率 %d，%s", address, baud, closed), nil
+		}
+	}
+	return false, "", nil
+}
+
+// SetStationAddress 写40001，设备应答后即以新站号通信
+func (lx47le125) SetStationAddress(c *modbus.ModbusClient, address int) error {
+	if address < 1 || address > 247 {
+		return fmt.Errorf("站号超出范围(1-247): %d", address)
+	}
+	return c.WriteSingleRegister(lx47StationReg, uint16(address))
+}
+
+// SetBaudRate 写40002
+func (d lx47le125) SetBaudRate(c *modbus.ModbusClient, baud int) error {
+	for _, supported := range d.BaudRates() {
+		if baud == supported {
+			return c.WriteSingleRegister(lx47BaudRateReg, uint16(baud))
+		}
+	}
+	return fmt.Errorf("不支持的波特率: %d", baud)
+}
+
+func (lx47le125) BaudRates() []int {
+	return lx47BaudRates
+}
+
+// SetLock 写线圈00003
+func (lx47le125) SetLock(c *modbus.ModbusClient, locked bool) error {
+	return c.WriteSingleCoil(lx47CoilLock, locked)
+}
+
+// Reset 线圈00001恢复出厂配置，线圈00005清除跳闸记录
+func (lx47le125) Reset(c *modbus.ModbusClient, kind ResetKind) error {
+	switch kind {
+	case ResetConfig:
+		return c.WriteSingleCoil(lx47CoilResetConfig, true)
+	case ResetRecords:
+		return c.WriteSingleCoil(lx47CoilClearRecords, true)
+	}
+	return fmt.Errorf("不支持的复位类型: %s", kind)
+}
+
+// ReadTripHistory 30002-30004 每个寄存器4条记录（每条4位，最新记录在低位），30024 为最近一次跳闸原因
+func (lx47le125) ReadTripHistory(c *modbus.ModbusClient) (*TripHistory, error) {
+	s, err := c.ReadRegisterMap(modbus.LX47LE125Map,
+		modbus.LX47TripRecords1, modbus.LX47TripRecords2, modbus.LX47TripRecords3, modbus.LX47LatestTrip)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &TripHistory{Latest: uint16(s.Raw(modbus.LX47LatestTrip))}
+	for _, name := range []string{modbus.LX47TripRecords1, modbus.LX47TripRecords2, modbus.LX47TripRecords3} {
+		reg := uint16(s.Raw(name))
+		for i := 0; i < 4; i++ {
+			code := (reg >> (4 * uint(i))) & 0x0F
+			if code == lx47NoTrip {
+				return history, nil
+			}
+			history.Records = append(history.Records, code)
+		}
+	}
+	return history, nil
+}
+
+// lx47ProtectionSchema 保护参数（依据协议V4.5），延时寄存器 40017-40021 单位0.1s
+var lx47ProtectionSchema = []ConfigField{
+	{Key: modbus.LX47OverVoltage, Label: "过压阈值", Type: "int", Unit: "V", Default: 275, Min: float(250), Max: float(300)},
+	{Key: modbus.LX47UnderVoltage, Label: "欠压阈值", Type: "int", Unit: "V", Default: 160, Min: float(150), Max: float(200)},
+	{Key: modbus.LX47OverCurrent, Label: "过流阈值", Type: "float", Unit: "A", Default: 63.0, Min: float(1), Max: float(100), Step: 0.01},
+	{Key: modbus.LX47LeakageLimit, Label: "漏电阈值", Type: "int", Unit: "mA", Default: 30, Min: float(10), Max: float(90)},
+	{Key: modbus.LX47OverTemp, Label: "过温阈值", Type: "int", Unit: "°C", Default: 80, Min: float(40), Max: float(150)},
+	{Key: modbus.LX47OverloadPower, Label: "过载功率阈值", Type: "int", Unit: "W", Default: 13000, Min: float(1000), Max: float(13000)},
+	{Key: modbus.LX47OverVoltDelay, Label: "过压动作延时", Type: "float", Unit: "s", Min: float(0), Max: float(1000), Step: 0.1},
+	{Key: modbus.LX47UnderVoltDelay, Label: "欠压动作延时", Type: "float", Unit: "s", Min: float(0), Max: float(1000), Step: 0.1},
+	{Key: modbus.LX47LeakageDelay, Label: "漏电动作延时", Type: "float", Unit: "s", Min: float(0), Max: float(1000), Step: 0.1},
+	{Key: modbus.LX47OverCurrDelay, Label: "过流动作延时", Type: "float", Unit: "s", Min: float(0), Max: float(1000), Step: 0.1},
+	{Key: modbus.LX47OverloadDelay, Label: "过载动作延时", Type: "float", Unit: "s", Min: float(0), Max: float(1000), Step: 0.1},
+}
+
+func (lx47le125) ProtectionSchema() []ConfigField {
+	return lx47ProtectionSchema
+}
+
+// ReadProtection 保护参数位于 40003-40008 与 40017-40021，各读取一帧
+func (lx47le125) ReadProtection(c *modbus.ModbusClient) (map[string]float64, error) {
+	names := make([]string, 0, len(lx47ProtectionSchema))
+	for _, field := range lx47ProtectionSchema {
+		names = append(names, field.Key)
+	}
+
+	s, err := c.ReadRegisterMap(modbus.LX47LE125Map, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64, len(names))
+	for _, name := range names {
+		values[name] = s.Value(name)
+	}
+	return values, nil
+}
+
+// WriteProtection 功能码06写入单个保护参数
+func (lx47le125) WriteProtection(c *modbus.ModbusClient, name string, value float64) error {
+	return c.WriteRegisterMap(modbus.LX47LE125Map, name, value)
+}
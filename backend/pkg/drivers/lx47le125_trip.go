@@ -0,0 +1,105 @@
+package drivers
+
+import (
+	"fmt"
+)
+
+// LX47LE-125 跳闸原因代码（30002-30004 每条记录4位，30024 最近一次跳闸原因）
+const (
+	lx47TripLocal       = 0x0
+	lx47TripOverCurrent = 0x1
+	lx47TripLeakage     = 0x2
+	lx47TripOverTemp    = 0x3
+	lx47TripOverload    = 0x4
+	lx47TripOverVoltage = 0x5
+	lx47TripUnderVolt   = 0x6
+	lx47TripRemote      = 0x7
+	lx47TripModule      = 0x8
+	lx47TripPowerLoss   = 0x9
+	lx47TripLock        = 0xA
+	lx47TripEnergyLimit = 0xB
+)
+
+type lx47TripReason struct {
+	name        string
+	detail      string
+	category    string
+	suggestions []string
+}
+
+var lx47TripReasons = map[uint16]lx47TripReason{
+	lx47TripLocal: {"本地操作", "设备通过本地按钮或开关手动操作断开", TripCategoryOperation,
+		[]string{"检查是否为人工操作", "确认操作原因"}},
+	lx47TripOverCurrent: {"过流保护", "检测到电流超过过流保护阈值，自动断开保护负载", TripCategoryProtection,
+		[]string{"检查负载是否过大", "检查线路是否短路", "调整过流保护阈值"}},
+	lx47TripLeakage: {"漏电保护", "检测到漏电流超过漏电保护阈值，自动断开防止触电", TripCategoryProtection,
+		[]string{"检查线路绝缘情况", "检查设备是否漏电", "调整漏电保护阈值"}},
+	lx47TripOverTemp: {"过温保护", "检测到温度超过过温保护阈值，自动断开防止过热损坏", TripCategoryProtection,
+		[]string{"检查环境温度", "检查散热情况", "调整过温保护阈值"}},
+	lx47TripOverload: {"过载保护", "检测到功率超过过载保护阈值，自动断开防止过载", TripCategoryProtection,
+		[]string{"减少负载功率", "检查负载配置", "调整过载保护阈值"}},
+	lx47TripOverVoltage: {"过压保护", "检测到电压超过过压保护上限，自动断开保护设备", TripCategoryProtection,
+		[]string{"检查供电电压", "安装稳压设备", "调整过压保护阈值"}},
+	lx47TripUnderVolt: {"欠压保护", "检测到电压低于欠压保护下限，自动断开保护设备", TripCategoryProtection,
+		[]string{"检查供电电压", "检查供电线路", "调整欠压保护阈值"}},
+	lx47TripRemote: {"远程操作", "通过远程控制命令操作断开", TripCategoryOperation,
+		[]string{"检查远程控制命令", "确认操作权限", "检查通信链路"}},
+	lx47TripModule: {"模块故障", "内部控制模块发生故障，自动断开确保安全", TripCategoryFault,
+		[]string{"重启设备", "检查固件版本", "联系技术支持"}},
+	lx47TripPowerLoss: {"电源故障", "供电电源发生故障或断电，设备自动断开", TripCategoryFault,
+		[]string{"检查供电电源", "检查电源线路", "安装UPS设备"}},
+	lx47TripLock: {"锁定状态", "设备处于锁定状态，无法合闸操作", TripCategoryOperation,
+		[]string{"解除设备锁定", "检查锁定原因", "确认操作权限"}},
+	lx47TripEnergyLimit: {"电量限制", "电量消耗达到预设限制，自动断开", TripCategoryLimit,
+		[]string{"重置电量计数", "调整电量限制", "检查计费系统"}},
+	lx47NoTrip: {"无跳闸记录", "寄存器中无有效的跳闸记录", TripCategoryUnknown, nil},
+}
+
+// DecodeTrip 解析跳闸原因：0-0xF 为单一原因代码，大于0xF时按位组合解析（第n位对应代码n）
+func (lx47le125) DecodeTrip(code uint16) *TripAnalysis {
+	result := &TripAnalysis{
+		Code:    code,
+		HexCode: fmt.Sprintf("0x%04X", code),
+	}
+
+	if code <= 0xF {
+		result.Type = "single"
+		reason, ok := lx47TripReasons[code]
+		if !ok {
+			result.Category = TripCategoryUnknown
+			result.Reasons = []string{fmt.Sprintf("未知代码(%d)", code)}
+			result.Description = "未定义的跳闸原因"
+			result.Suggestions = []string{"联系技术支持"}
+			return result
+		}
+		result.Category = reason.category
+		result.Remote = code == lx47TripRemote
+		result.Leakage = code == lx47TripLeakage
+		result.Reasons = []string{reason.name}
+		result.Description = reason.detail
+		result.Suggestions = reason.suggestions
+		return result
+	}
+
+	result.Type = "composite"
+	result.Category = TripCategoryProtection
+	for bit := uint16(0); bit < 16; bit++ {
+		if code&(1<<bit) == 0 {
+			continue
+		}
+		if reason, ok := lx47TripReasons[bit]; ok {
+			result.Reasons = append(result.Reasons, reason.name)
+		} else {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("位%d", bit))
+		}
+	}
+	result.Leakage = code&(1<<lx47TripLeakage) != 0
+	result.Description = "多个保护条件同时触发"
+	result.Suggestions = []string{
+		"按优先级逐一排查各个触发条件",
+		"优先处理安全相关的保护 (漏电、过温)",
+		"检查设备整体运行状态",
+		"必要时联系专业技术人员",
+	}
+	return result
+}
@@ -0,0 +1,29 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLX47DecodeTrip(t *testing.T) {
+	var d lx47le125
+
+	leakage := d.DecodeTrip(2)
+	assert.Equal(t, "single", leakage.Type)
+	assert.Equal(t, TripCategoryProtection, leakage.Category)
+	assert.Equal(t, []string{"漏电保护"}, leakage.Reasons)
+	assert.False(t, leakage.Remote)
+	assert.True(t, leakage.Leakage)
+
+	assert.True(t, d.DecodeTrip(7).Remote)
+
+	// 0x0012 = 位1(过流) + 位4(过载)
+	composite := d.DecodeTrip(0x0012)
+	assert.Equal(t, "composite", composite.Type)
+	assert.Equal(t, []string{"过流保护", "过载保护"}, composite.Reasons)
+	assert.False(t, composite.Leakage)
+
+	// 0x0016 = 位1(过流) + 位2(漏电) + 位4(过载)
+	assert.True(t, d.DecodeTrip(0x0016).Leakage)
+}
@@ -0,0 +1,222 @@
+package drivers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// 未填写型号时使用的默认驱动，兼容已有数据
+const (
+	DefaultBreakerModel = "LX47LE-125"
+	DefaultSensorModel  = "KLT-18B20-6H1"
+
+	DefaultIRControllerModel = "CX-IR002E"
+)
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Driver) // 规范化型号/别名 -> 驱动
+	models   = make(map[string]Driver) // 规范化型号 -> 驱动
+)
+
+func normalize(model string) string {
+	return strings.ToUpper(strings.TrimSpace(model))
+}
+
+// Register 注册驱动，型号或别名重复时panic（与 database/sql.Register 一致）
+func Register(d Driver) {
+	info := d.Info()
+	keys := append([]string{info.Model}, info.Aliases...)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range keys {
+		k := normalize(key)
+		if k == "" {
+			panic("drivers: 驱动型号不能为空")
+		}
+		if _, dup := registry[k]; dup {
+			panic("drivers: 重复注册驱动 " + key)
+		}
+		registry[k] = d
+	}
+	models[normalize(info.Model)] = d
+}
+
+// Lookup 按型号或别名查找驱动（不区分大小写）
+func Lookup(model string) (Driver, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	d, ok := registry[normalize(model)]
+	return d, ok
+}
+
+// List 返回全部驱动描述，按类别和型号排序
+func List() []Info {
+	mu.RLock()
+	infos := make([]Info, 0, len(models))
+	for _, d := range models {
+		infos = append(infos, d.Info())
+	}
+	mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Kind != infos[j].Kind {
+			return infos[i].Kind < infos[j].Kind
+		}
+		return infos[i].Model < infos[j].Model
+	})
+	return infos
+}
+
+// ForKind 查找指定类别的驱动，型号为空时使用该类别的默认驱动
+func ForKind(kind Kind, model string) (Driver, error) {
+	if strings.TrimSpace(model) == "" {
+		switch kind {
+		case KindBreaker:
+			model = DefaultBreakerModel
+		case KindTemperatureSensor:
+			model = DefaultSensorModel
+		case KindIRController:
+			model = DefaultIRControllerModel
+		}
+	}
+
+	d, ok := Lookup(model)
+	if !ok {
+		return nil, fmt.Errorf("不支持的设备型号: %s", model)
+	}
+	if d.Info().Kind != kind {
+		return nil, fmt.Errorf("设备型号 %s 不是%s驱动", model, kind)
+	}
+	return d, nil
+}
+
+// Breaker 按断路器型号查找驱动
+func Breaker(model string) (Driver, error) {
+	return ForKind(KindBreaker, model)
+}
+
+// TemperatureSensor 按温度传感器设备类型查找驱动
+func TemperatureSensor(deviceType string) (TemperatureReader, error) {
+	d, err := ForKind(KindTemperatureSensor, deviceType)
+	if err != nil {
+		return nil, err
+	}
+	reader, ok := d.(TemperatureReader)
+	if !ok {
+		return nil, fmt.Errorf("设备型号 %s 的驱动不支持温度采集", d.Info().Model)
+	}
+	return reader, nil
+}
+
+// ValidateConfig 按驱动的配置项说明校验设备配置
+func ValidateConfig(d Driver, cfg map[string]interface{}) error {
+	return validateFields(d.Info().ConfigSchema, cfg)
+}
+
+// ValidateProtection 校验待写入的保护参数：参数名必须在 ProtectionSchema 中，取值符合范围和分辨率
+func ValidateProtection(p ProtectionConfigurator, values map[string]float64) error {
+	if len(values) == 0 {
+		return fmt.Errorf("未指定要修改的保护参数")
+	}
+
+	schema := p.ProtectionSchema()
+	known := make(map[string]bool, len(schema))
+	for _, field := range schema {
+		known[field.Key] = true
+	}
+
+	cfg := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if !known[key] {
+			return fmt.Errorf("不支持的保护参数: %s", key)
+		}
+		cfg[key] = value
+	}
+	return validateFields(schema, cfg)
+}
+
+// ValidateProtectionChange 校验写入后整组保护参数是否自洽：待写入值合并到设备当前值上，
+// 欠压阈值必须低于过压阈值
+func ValidateProtectionChange(current, values map[string]float64) error {
+	merged := make(map[string]float64, len(current)+len(values))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+
+	over, hasOver := merged[modbus.LX47OverVoltage]
+	under, hasUnder := merged[modbus.LX47UnderVoltage]
+	if hasOver && hasUnder && under >= over {
+		return fmt.Errorf("欠压阈值 %gV 必须低于过压阈值 %gV", under, over)
+	}
+	return nil
+}
+
+func validateFields(schema []ConfigField, cfg map[string]interface{}) error {
+	for _, field := range schema {
+		value, ok := cfg[field.Key]
+		if !ok || value == nil {
+			if field.Required {
+				return fmt.Errorf("缺少配置项: %s", field.Key)
+			}
+			continue
+		}
+
+		switch field.Type {
+		case "int", "float":
+			n, ok := value.(float64) // JSON数字统一解码为float64
+			if !ok {
+				return fmt.Errorf("配置项 %s 应为数字", field.Key)
+			}
+			if field.Type == "int" && n != float64(int64(n)) {
+				return fmt.Errorf("配置项 %s 应为整数", field.Key)
+			}
+			if field.Min != nil && n < *field.Min {
+				return fmt.Errorf("配置项 %s 不能小于 %v", field.Key, *field.Min)
+			}
+			if field.Max != nil && n > *field.Max {
+				return fmt.Errorf("配置项 %s 不能大于 %v", field.Key, *field.Max)
+			}
+			if field.Step > 0 {
+				steps := n / field.Step
+				if math.Abs(steps-math.Round(steps)) > 1e-6 {
+					return fmt.Errorf("配置项 %s 应为 %v 的整数倍", field.Key, field.Step)
+				}
+			}
+		case "bool":
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("配置项 %s 应为布尔值", field.Key)
+			}
+		case "string":
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("配置项 %s 应为字符串", field.Key)
+			}
+		case "enum":
+			s, _ := value.(string)
+			valid := false
+			for _, option := range field.Options {
+				if s == option {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return fmt.Errorf("配置项 %s 取值无效，可选: %s", field.Key, strings.Join(field.Options, "/"))
+			}
+		}
+	}
+	return nil
+}
+
+func float(v float64) *float64 {
+	return &v
+}
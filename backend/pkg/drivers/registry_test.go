@@ -0,0 +1,69 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupByModelAndAlias(t *testing.T) {
+	d, err := Breaker("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBreakerModel, d.Info().Model)
+	assert.True(t, Supports(d, CapSwitch))
+
+	s, err := TemperatureSensor("rs485-18b20-6h1")
+	require.NoError(t, err)
+	assert.Equal(t, 6, s.Channels())
+
+	_, err = Breaker("KLT-18B20-6H1")
+	assert.Error(t, err, "温度模块型号不能用作断路器驱动")
+
+	_, err = Breaker("UNKNOWN")
+	assert.Error(t, err)
+}
+
+func TestValidateConfig(t *testing.T) {
+	d, _ := Breaker(DefaultBreakerModel)
+	assert.NoError(t, ValidateConfig(d, map[string]interface{}{"rated_current": 32.0}))
+	assert.Error(t, ValidateConfig(d, map[string]interface{}{"rated_current": 200.0}))
+	assert.Error(t, ValidateConfig(d, map[string]interface{}{"rated_current": "32"}))
+}
+
+func TestParseDS18B20(t *testing.T) {
+	r := parseDS18B20(1, 253)
+	require.NotNil(t, r.Value)
+	assert.Equal(t, 25.3, *r.Value)
+
+	r = parseDS18B20(2, 0xFF9C) // -10.0°C
+	require.NotNil(t, r.Value)
+	assert.Equal(t, -10.0, *r.Value)
+
+	assert.Equal(t, ChannelOpenCircuit, parseDS18B20(3, 0xF8CE).Status)
+	assert.Equal(t, ChannelOpenCircuit, parseDS18B20(4, 0x7FFF).Status)
+}
+
+func TestValidateProtection(t *testing.T) {
+	d, _ := Breaker(DefaultBreakerModel)
+	p, ok := d.(ProtectionConfigurator)
+	require.True(t, ok)
+
+	assert.NoError(t, ValidateProtection(p, map[string]float64{"over_current": 32.5, "leakage_delay": 0.3}))
+	assert.Error(t, ValidateProtection(p, map[string]float64{}))
+	assert.Error(t, ValidateProtection(p, map[string]float64{"station_address": 2}), "站号不属于保护参数")
+	assert.Error(t, ValidateProtection(p, map[string]float64{"over_voltage": 320}))
+	assert.Error(t, ValidateProtection(p, map[string]float64{"leakage_delay": 0.25}), "延时分辨率为0.1s")
+	assert.Error(t, ValidateProtection(p, map[string]float64{"leakage_limit": 30.5}))
+}
+
+func TestValidateProtectionChange(t *testing.T) {
+	current := map[string]float64{"over_voltage": 260, "under_voltage": 180, "over_current": 63}
+
+	assert.NoError(t, ValidateProtectionChange(current, map[string]float64{"under_voltage": 200}))
+	assert.NoError(t, ValidateProtectionChange(current, map[string]float64{"over_voltage": 300, "under_voltage": 200}))
+	assert.Error(t, ValidateProtectionChange(current, map[string]float64{"under_voltage": 260}), "与当前过压阈值相等")
+	assert.Error(t, ValidateProtectionChange(current, map[string]float64{"over_voltage": 170}), "低于当前欠压阈值")
+	assert.Error(t, ValidateProtectionChange(current, map[string]float64{"over_voltage": 190, "under_voltage": 195}))
+	assert.NoError(t, ValidateProtectionChange(map[string]float64{}, map[string]float64{"under_voltage": 300}), "缺少过压阈值时不比较")
+}
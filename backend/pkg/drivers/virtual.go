@@ -0,0 +1,47 @@
+package drivers
+
+import (
+	"errors"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// VirtualSensorModel 虚拟温度传感器的设备类型：读数由温度采集服务按定义对其他传感器通道的
+// 最新读数聚合得到，不访问设备
+const VirtualSensorModel = "VIRTUAL"
+
+// ErrVirtualSensor 虚拟传感器不能通过 Modbus 读取
+var ErrVirtualSensor = errors.New("虚拟传感器没有物理设备，读数由其他通道聚合计算")
+
+func init() {
+	Register(virtualSensor{})
+}
+
+// virtualSensor 虚拟温度传感器，只有一个通道（通道1）
+type virtualSensor struct{}
+
+// IsVirtualSensor 判断设备类型是否为虚拟传感器
+func IsVirtualSensor(deviceType string) bool {
+	return normalize(deviceType) == VirtualSensorModel
+}
+
+func (virtualSensor) Info() Info {
+	return Info{
+		Model:        VirtualSensorModel,
+		Kind:         KindTemperatureSensor,
+		Description:  "虚拟温度传感器，对多个物理通道取平均/最小/最大/中位数或差值",
+		Capabilities: []Capability{CapTemperature},
+	}
+}
+
+func (virtualSensor) Channels() int {
+	return 1
+}
+
+func (virtualSensor) Identify(c *modbus.ModbusClient) (*SensorIdentity, error) {
+	return nil, ErrVirtualSensor
+}
+
+func (virtualSensor) ReadTemperatures(c *modbus.ModbusClient) ([]ChannelReading, error) {
+	return nil, ErrVirtualSensor
+}
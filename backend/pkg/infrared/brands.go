@@ -0,0 +1,63 @@
+package infrared
+
+import (
+	"strings"
+)
+
+// Brand 空调品牌及其码库代号，同一品牌的不同代号对应不同年代或系列的遥控器编码
+type Brand struct {
+	Name    string `json:"name"`
+	English string `json:"english"`
+	Codes   []int  `json:"codes"`
+}
+
+// brands 内置空调品牌码库（docs/devices/CX-IR002E/brand-code-database.md），代号按常用程度排列。
+// 表中没有的型号使用一键匹配获取代号。
+var brands = []Brand{
+	{Name: "格力", English: "Gree", Codes: []int{0x006E, 0x0078, 0x0082}},
+	{Name: "美的", English: "Midea", Codes: []int{0x00A5, 0x00AD, 0x00B5}},
+	{Name: "海尔", English: "Haier", Codes: []int{0x0089, 0x0093, 0x009D}},
+	{Name: "奥克斯", English: "AUX", Codes: []int{0x004F, 0x005D, 0x005F}},
+	{Name: "海信", English: "Hisense", Codes: []int{0x008B, 0x0095, 0x009F}},
+	{Name: "长虹", English: "Changhong", Codes: []int{0x0052, 0x0060, 0x00C3, 0x00CB}},
+	{Name: "春兰", English: "Chunlan", Codes: []int{0x0050, 0x0062}},
+	{Name: "志高", English: "Chigo", Codes: []int{0x00C1, 0x00C9, 0x00D0, 0x00D4}},
+	{Name: "三菱", English: "Mitsubishi", Codes: []int{0x00A9, 0x00B1, 0x00B9}},
+	{Name: "松下", English: "Panasonic", Codes: []int{0x00A6, 0x00AE, 0x00B6}},
+	{Name: "大金", English: "Daikin", Codes: []int{0x006D, 0x007D}},
+	{Name: "东芝", English: "Toshiba", Codes: []int{0x006F, 0x007B}},
+	{Name: "三星", English: "Samsung", Codes: []int{0x00AB, 0x00B3, 0x00BB}},
+	{Name: "LG", English: "LG", Codes: []int{0x008A, 0x0094, 0x009E}},
+}
+
+// Brands 返回内置空调品牌码库
+func Brands() []Brand {
+	out := make([]Brand, len(brands))
+	for i, b := range brands {
+		out[i] = Brand{Name: b.Name, English: b.English, Codes: append([]int(nil), b.Codes...)}
+	}
+	return out
+}
+
+// LookupBrand 按中文名或英文名（不区分大小写）查找品牌
+func LookupBrand(name string) (Brand, bool) {
+	name = strings.TrimSpace(name)
+	for _, b := range brands {
+		if b.Name == name || strings.EqualFold(b.English, name) {
+			return Brand{Name: b.Name, English: b.English, Codes: append([]int(nil), b.Codes...)}, true
+		}
+	}
+	return Brand{}, false
+}
+
+// BrandOfCode 查找码库代号所属的品牌，未收录时返回 false
+func BrandOfCode(code int) (Brand, bool) {
+	for _, b := range brands {
+		for _, c := range b.Codes {
+			if c == code {
+				return b, true
+			}
+		}
+	}
+	return Brand{}, false
+}
@@ -0,0 +1,264 @@
+package infrared
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// MatchTimeout 一键匹配等待用户用遥控器对准控制器按电源键的最长时间
+const MatchTimeout = 60 * time.Second
+
+// Client CX-IR002E 客户端，在任意通信模式的传输层上按寄存器表访问设备
+type Client struct {
+	transport modbus.Transport
+	unitID    byte
+}
+
+// NewClient 创建客户端，unitID 为 RS485 设备地址（默认1，网口模式帧内同样使用该地址）
+func NewClient(transport modbus.Transport, unitID byte) *Client {
+	if unitID == 0 {
+		unitID = 1
+	}
+	return &Client{transport: transport, unitID: unitID}
+}
+
+// Close 关闭传输层
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
+
+// DeviceInfo 设备信息
+type DeviceInfo struct {
+	Firmware uint16 `json:"firmware"`
+	ChipID   uint16 `json:"chip_id"`
+	Address  uint16 `json:"address"`
+	BaudRate uint16 `json:"baud_rate"` // 波特率代码，2 为 9600
+	MAC      string `json:"mac"`
+}
+
+// AnalogInputs 模拟量：ADC 采集值与互感器开关机状态来自寄存器，C01-C04 等来自网口 JSON 协议
+type AnalogInputs struct {
+	ADCVoltage  float64            `json:"adc_voltage"`  // ADC 电压（V）
+	PowerSensed bool               `json:"power_sensed"` // 互感器检测到空调有电流（开机）
+	Inputs      map[string]float64 `json:"inputs,omitempty"`
+	Interval    int                `json:"interval,omitempty"` // 主动上传间隔（秒）
+}
+
+func (c *Client) send(pdu []byte, timeout time.Duration) ([]byte, error) {
+	if sender, ok := c.transport.(modbus.TimeoutSender); ok && timeout > 0 {
+		return sender.SendTimeout(c.unitID, pdu, timeout)
+	}
+	return c.transport.Send(c.unitID, pdu)
+}
+
+// ReadRegisters 读保持寄存器（功能码03）
+func (c *Client) ReadRegisters(addr, quantity uint16) ([]uint16, error) {
+	pdu := []byte{0x03}
+	pdu = binary.BigEndian.AppendUint16(pdu, addr)
+	pdu = binary.BigEndian.AppendUint16(pdu, quantity)
+	reply, err := c.send(pdu, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 || int(reply[1]) != int(quantity)*2 || len(reply) < 2+int(reply[1]) {
+		return nil, fmt.Errorf("%w: 读寄存器回复长度错误", modbus.ErrInvalidResponse)
+	}
+	regs := make([]uint16, quantity)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(reply[2+i*2:])
+	}
+	return regs, nil
+}
+
+// WriteRegister 写单个寄存器（功能码06），返回回复帧中的寄存器值：普通寄存器为写入值的回显，
+// 空调匹配寄存器为匹配到的码库代号或 8003H
+func (c *Client) WriteRegister(addr, value uint16, timeout time.Duration) (uint16, error) {
+	pdu := []byte{0x06}
+	pdu = binary.BigEndian.AppendUint16(pdu, addr)
+	pdu = binary.BigEndian.AppendUint16(pdu, value)
+	reply, err := c.send(pdu, timeout)
+	if err != nil {
+		return 0, err
+	}
+	if len(reply) < 5 || binary.BigEndian.Uint16(reply[1:3]) != addr {
+		return 0, fmt.Errorf("%w: 写寄存器回复地址错误", modbus.ErrInvalidResponse)
+	}
+	return binary.BigEndian.Uint16(reply[3:5]), nil
+}
+
+// WriteRegisters 写多个寄存器（功能码10）
+func (c *Client) WriteRegisters(addr uint16, values []uint16) error {
+	pdu := []byte{0x10}
+	pdu = binary.BigEndian.AppendUint16(pdu, addr)
+	pdu = binary.BigEndian.AppendUint16(pdu, uint16(len(values)))
+	pdu = append(pdu, byte(len(values)*2))
+	for _, v := range values {
+		pdu = binary.BigEndian.AppendUint16(pdu, v)
+	}
+	_, err := c.send(pdu, 0)
+	return err
+}
+
+// ReadInfo 读取固件版本、芯片ID、地址、波特率和MAC地址
+func (c *Client) ReadInfo() (*DeviceInfo, error) {
+	regs, err := c.ReadRegisters(RegFirmware, 7)
+	if err != nil {
+		return nil, fmt.Errorf("读取设备信息失败: %w", err)
+	}
+	mac := make([]byte, 0, 6)
+	for _, reg := range regs[4:7] {
+		mac = binary.BigEndian.AppendUint16(mac, reg)
+	}
+	return &DeviceInfo{
+		Firmware: regs[0],
+		ChipID:   regs[1],
+		Address:  regs[2],
+		BaudRate: regs[3],
+		MAC:      fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X", mac[0], mac[1], mac[2], mac[3], mac[4], mac[5]),
+	}, nil
+}
+
+// MatchAC 空调一键匹配：控制器进入匹配状态后，用空调遥控器对准控制器按电源键，
+// 匹配成功返回码库代号。设备先回显启动指令时继续等待匹配结果通知。
+func (c *Client) MatchAC(timeout time.Duration) (int, error) {
+	if timeout <= 0 {
+		timeout = MatchTimeout
+	}
+	started := time.Now()
+	value, err := c.WriteRegister(RegACMatch, 0x0001, timeout)
+	if err != nil {
+		return 0, fmt.Errorf("启动空调匹配失败: %w", err)
+	}
+	if value == 0x0001 {
+		notifier, ok := c.transport.(Notifier)
+		if !ok {
+			// RTU 模式无法接收后续通知，读取匹配后保存的码库代号
+			time.Sleep(time.Until(started.Add(timeout)))
+			return c.ACCode()
+		}
+		if value, err = notifier.Await(c.unitID, RegACMatch, time.Until(started.Add(timeout))); err != nil {
+			return 0, fmt.Errorf("等待空调匹配结果失败: %w", err)
+		}
+	}
+	if value == ReplyTimeout || value == 0 {
+		return 0, ErrMatchFailed
+	}
+	return int(value), nil
+}
+
+// ACCode 读取当前空调码库代号
+func (c *Client) ACCode() (int, error) {
+	regs, err := c.ReadRegisters(RegACCode, 1)
+	if err != nil {
+		return 0, fmt.Errorf("读取空调码库代号失败: %w", err)
+	}
+	return int(regs[0]), nil
+}
+
+// SetACCode 设置空调码库代号（品牌代码）
+func (c *Client) SetACCode(code int) error {
+	if code <= 0 || code > 0xFFFF {
+		return fmt.Errorf("空调码库代号无效: %d", code)
+	}
+	if _, err := c.WriteRegister(RegACCode, uint16(code), 0); err != nil {
+		return fmt.Errorf("设置空调码库代号失败: %w", err)
+	}
+	return nil
+}
+
+// ReadACState 读取控制器最后一次发码的空调状态（电源、温度、模式、风速寄存器）
+func (c *Client) ReadACState() (*ACState, error) {
+	regs, err := c.ReadRegisters(RegACPower, 4)
+	if err != nil {
+		return nil, fmt.Errorf("读取空调状态失败: %w", err)
+	}
+	return &ACState{
+		Power:    regs[0] == 1,
+		SetPoint: int(regs[1]) + MinSetPoint,
+		Mode:     modeName(regs[2]),
+		FanSpeed: fanName(regs[3]),
+	}, nil
+}
+
+// SetPower 空调开关机。协议表中 0025H 写明 0-开启，但协议示例和实测均为写1开机、写0关机，以示例为准。
+func (c *Client) SetPower(on bool) error {
+	value := uint16(0)
+	if on {
+		value = 1
+	}
+	if _, err := c.WriteRegister(RegACPower, value, 0); err != nil {
+		return fmt.Errorf("空调开关机失败: %w", err)
+	}
+	return nil
+}
+
+// SetSetPoint 设置空调温度（16-30℃）
+func (c *Client) SetSetPoint(temperature int) error {
+	if temperature < MinSetPoint || temperature > MaxSetPoint {
+		return fmt.Errorf("设定温度必须在 %d-%d℃ 之间", MinSetPoint, MaxSetPoint)
+	}
+	if _, err := c.WriteRegister(RegACSetPoint, uint16(temperature-MinSetPoint), 0); err != nil {
+		return fmt.Errorf("设置空调温度失败: %w", err)
+	}
+	return nil
+}
+
+// SetMode 设置空调模式
+func (c *Client) SetMode(mode string) error {
+	code, err := ModeCode(mode)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteRegister(RegACMode, code, 0); err != nil {
+		return fmt.Errorf("设置空调模式失败: %w", err)
+	}
+	return nil
+}
+
+// SetFanSpeed 设置空调风速
+func (c *Client) SetFanSpeed(speed string) error {
+	code, err := FanCode(speed)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteRegister(RegACFan, code, 0); err != nil {
+		return fmt.Errorf("设置空调风速失败: %w", err)
+	}
+	return nil
+}
+
+// ApplyACState 以组合键方式一次发送完整空调状态；code 大于0时按指定码库代号发码，否则使用控制器当前代号
+func (c *Client) ApplyACState(state ACState, code int) error {
+	regs, err := combinedRegisters(state, code)
+	if err != nil {
+		return err
+	}
+	if err := c.WriteRegisters(RegACCombined, regs); err != nil {
+		return fmt.Errorf("发送空调状态失败: %w", err)
+	}
+	return nil
+}
+
+// ReadAnalog 读取模拟量：ADC 采集值和互感器开关机状态，网口模式同时读取 C01-C04 等上传数据
+func (c *Client) ReadAnalog() (*AnalogInputs, error) {
+	regs, err := c.ReadRegisters(RegPowerSense, 2)
+	if err != nil {
+		return nil, fmt.Errorf("读取模拟量失败: %w", err)
+	}
+	analog := &AnalogInputs{
+		PowerSensed: regs[0] == 1,
+		ADCVoltage:  float64(regs[1]) / 100,
+	}
+	if status, ok := c.transport.(StatusReader); ok {
+		msg, err := status.Status()
+		if err != nil {
+			return nil, fmt.Errorf("读取模拟量失败: %w", err)
+		}
+		analog.Inputs = msg.Analog
+		analog.Interval = msg.Interval
+	}
+	return analog, nil
+}
@@ -0,0 +1,244 @@
+package infrared
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDevice 在管道另一端模拟 CX-IR002E 网口 JSON 协议：按寄存器表应答 RTU 帧，
+// 空调匹配和红外学习寄存器先回显启动指令，再发送结果通知
+type fakeDevice struct {
+	t         *testing.T
+	conn      net.Conn
+	regs      map[uint16]uint16
+	matchCode uint16
+	commands  []string
+
+	learnReply uint16   // 红外学习结果通知的值
+	learned    []uint16 // 学习完成时写入学习通道的波形
+}
+
+func (d *fakeDevice) serve() {
+	reader := bufio.NewReader(d.conn)
+	for {
+		data, err := readStreamMessage(reader)
+		if err != nil {
+			return
+		}
+		var cmd map[string]interface{}
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			return
+		}
+		res, _ := cmd["res"].(string)
+		if _, ok := cmd["readsta"]; ok {
+			fmt.Fprintf(d.conn, `4{"T01":60,"C01":12.5,"C02":0,"res":"%s"}`, res)
+			continue
+		}
+		for key, value := range cmd {
+			if key == "res" {
+				continue
+			}
+			d.commands = append(d.commands, key)
+			frame, _ := hex.DecodeString(value.(string))
+			d.reply(frame[:len(frame)-2], res)
+		}
+	}
+}
+
+func (d *fakeDevice) reply(req []byte, res string) {
+	unitID, pdu := req[0], req[1:]
+	addr := binary.BigEndian.Uint16(pdu[1:3])
+	var out []byte
+	switch pdu[0] {
+	case 0x03:
+		quantity := binary.BigEndian.Uint16(pdu[3:5])
+		out = []byte{0x03, byte(quantity * 2)}
+		for i := uint16(0); i < quantity; i++ {
+			out = binary.BigEndian.AppendUint16(out, d.regs[addr+i])
+		}
+	case 0x06:
+		d.regs[addr] = binary.BigEndian.Uint16(pdu[3:5])
+		out = pdu[:5]
+	case 0x10:
+		quantity := binary.BigEndian.Uint16(pdu[3:5])
+		for i := uint16(0); i < quantity; i++ {
+			d.regs[addr+i] = binary.BigEndian.Uint16(pdu[6+i*2:])
+		}
+		out = pdu[:5]
+	}
+	fmt.Fprintf(d.conn, `4{"C01":12.5,"irout0s":"%s","res":"%s"}`, hex.EncodeToString(encodeFrame(unitID, out)), res)
+
+	if pdu[0] == 0x06 && addr == RegACMatch {
+		// 匹配结果通知不带 res
+		notice := binary.BigEndian.AppendUint16([]byte{0x06, 0x00, 0x10}, d.matchCode)
+		fmt.Fprintf(d.conn, `4{"irout0s":"%s"}`, hex.EncodeToString(encodeFrame(unitID, notice)))
+	}
+	if pdu[0] == 0x06 && addr == RegLearnStart {
+		channel := int(binary.BigEndian.Uint16(pdu[3:5]))
+		if d.learnReply == ReplyDone {
+			for i, reg := range d.learned {
+				d.regs[learnedAddress(channel)+uint16(i)] = reg
+			}
+		}
+		notice := binary.BigEndian.AppendUint16([]byte{0x06, 0x00, 0x16}, d.learnReply)
+		fmt.Fprintf(d.conn, `4{"irout0s":"%s"}`, hex.EncodeToString(encodeFrame(unitID, notice)))
+	}
+}
+
+func newFakeClient(t *testing.T, regs map[uint16]uint16) (*Client, *fakeDevice) {
+	client, device := net.Pipe()
+	t.Cleanup(func() { device.Close() })
+
+	d := &fakeDevice{t: t, conn: device, regs: regs}
+	go d.serve()
+
+	transport := &jsonTransport{conn: newJSONConn(client, true), timeout: time.Second, owned: true}
+	t.Cleanup(func() { transport.Close() })
+	return NewClient(transport, 1), d
+}
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage([]byte(`1{"T01":30,"C01":1.25,"C04":0,"C11":-3,"res":"7"}`))
+	require.NoError(t, err)
+	assert.True(t, msg.Upload)
+	assert.Equal(t, 30, msg.Interval)
+	assert.Equal(t, map[string]float64{"C01": 1.25, "C04": 0, "C11": -3}, msg.Analog)
+	assert.Equal(t, []string{"C01", "C04", "C11"}, msg.AnalogKeys())
+
+	msg, err = ParseMessage([]byte(`4{"irout0s":"01060025000159C1","res":"2"}`))
+	require.NoError(t, err)
+	assert.False(t, msg.Upload)
+	assert.Equal(t, "2", msg.Res)
+	assert.Equal(t, []byte{0x01, 0x06, 0x00, 0x25, 0x00, 0x01, 0x59, 0xC1}, msg.Frame)
+
+	_, err = ParseMessage([]byte(`4{"irout0s":"zz"}`))
+	assert.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestEncodeCommand(t *testing.T) {
+	frame := encodeFrame(1, []byte{0x06, 0x00, 0x25, 0x00, 0x01})
+	assert.Equal(t, `{"irout2h":"01060025000159C1","res":"9"}`, string(EncodeCommand(2, frame, "9")))
+}
+
+func TestCombinedRegisters(t *testing.T) {
+	regs, err := combinedRegisters(ACState{Power: true, Mode: ModeCool, SetPoint: 26, FanSpeed: FanLow}, 0x006E)
+	require.NoError(t, err)
+	// 开机 + (26-16)，制冷(1)<<4 | 低风(1)，按指定代号发码
+	assert.Equal(t, []uint16{0x006E, 0x8A11, 0x0001}, regs)
+
+	_, err = combinedRegisters(ACState{Mode: ModeCool, SetPoint: 31, FanSpeed: FanAuto}, 0)
+	assert.Error(t, err)
+}
+
+func TestClientACControl(t *testing.T) {
+	c, d := newFakeClient(t, map[uint16]uint16{})
+
+	require.NoError(t, c.SetPower(true))
+	require.NoError(t, c.SetSetPoint(24))
+	require.NoError(t, c.SetMode(ModeHeat))
+	require.NoError(t, c.SetFanSpeed(FanHigh))
+	state, err := c.ReadACState()
+	require.NoError(t, err)
+	assert.Equal(t, &ACState{Power: true, Mode: ModeHeat, SetPoint: 24, FanSpeed: FanHigh}, state)
+
+	require.NoError(t, c.ApplyACState(ACState{Mode: ModeCool, SetPoint: 20, FanSpeed: FanAuto}, 0))
+	assert.Equal(t, uint16(0x0410), d.regs[RegACCombined+1])
+	assert.Equal(t, "irout0h", d.commands[0])
+}
+
+func TestClientMatchAC(t *testing.T) {
+	c, d := newFakeClient(t, map[uint16]uint16{})
+	d.matchCode = 0x00A5
+
+	code, err := c.MatchAC(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 0x00A5, code)
+	brand, ok := BrandOfCode(code)
+	require.True(t, ok)
+	assert.Equal(t, "美的", brand.Name)
+
+	d.matchCode = ReplyTimeout
+	_, err = c.MatchAC(time.Second)
+	assert.ErrorIs(t, err, ErrMatchFailed)
+}
+
+func TestClientLearn(t *testing.T) {
+	c, d := newFakeClient(t, map[uint16]uint16{})
+	d.learnReply = ReplyDone
+	d.learned = []uint16{0xFA56, 0x6700, 0x4CE0}
+
+	waveform, err := c.Learn(LearnChannels-1, time.Second)
+	require.NoError(t, err)
+	require.Len(t, waveform, LearnedBytes)
+	assert.Equal(t, []byte{0xFA, 0x56, 0x67, 0x00, 0x4C, 0xE0, 0x00}, waveform[:7])
+	// 通道63波形从 114EH 开始
+	assert.Equal(t, uint16(0xFA56), d.regs[0x114E])
+
+	require.NoError(t, c.SendWaveform(2, waveform))
+	assert.Equal(t, uint16(0x4CE0), d.regs[learnedAddress(2)+2])
+	assert.Equal(t, uint16(2), d.regs[RegLearnSend])
+
+	d.learnReply = ReplyTimeout
+	_, err = c.Learn(5, time.Second)
+	assert.ErrorIs(t, err, ErrLearnFailed)
+
+	_, err = c.Learn(LearnChannels, time.Second)
+	assert.Error(t, err)
+	assert.Error(t, c.WriteLearned(0, waveform[:10]))
+}
+
+func TestClientReadAnalog(t *testing.T) {
+	c, _ := newFakeClient(t, map[uint16]uint16{RegPowerSense: 1, RegADC: 235})
+
+	analog, err := c.ReadAnalog()
+	require.NoError(t, err)
+	assert.True(t, analog.PowerSensed)
+	assert.InDelta(t, 2.35, analog.ADCVoltage, 1e-9)
+	assert.Equal(t, 60, analog.Interval)
+	assert.Equal(t, 12.5, analog.Inputs["C01"])
+}
+
+func TestListener(t *testing.T) {
+	l := NewListener(time.Second)
+	addr, err := l.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = l.Transport("127.0.0.1", 0)
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	d := &fakeDevice{t: t, conn: conn, regs: map[uint16]uint16{RegACCode: 0x006E}}
+	go d.serve()
+
+	require.Eventually(t, func() bool { return len(l.Connections()) == 1 }, time.Second, 10*time.Millisecond)
+	transport, err := l.Transport("127.0.0.1", 1)
+	require.NoError(t, err)
+	code, err := NewClient(transport, 1).ACCode()
+	require.NoError(t, err)
+	assert.Equal(t, 0x006E, code)
+	assert.Equal(t, "irout1h", d.commands[0])
+
+	conn.Close()
+	require.Eventually(t, func() bool { return len(l.Connections()) == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestLookupBrand(t *testing.T) {
+	brand, ok := LookupBrand("gree")
+	require.True(t, ok)
+	assert.Equal(t, "格力", brand.Name)
+	assert.Equal(t, []int{0x006E, 0x0078, 0x0082}, brand.Codes)
+
+	_, ok = LookupBrand("unknown")
+	assert.False(t, ok)
+}
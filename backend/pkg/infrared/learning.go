@@ -0,0 +1,135 @@
+package infrared
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// LearnTimeout 红外学习等待用户用遥控器对准控制器按键的最长时间
+const LearnTimeout = 30 * time.Second
+
+// Learn 红外学习：控制器进入学习模式后，用遥控器对准控制器的红外接收头（10cm 以内）按键，
+// 学习完成后读回该通道的波形。设备先回显启动指令，学习完成（8002H）或超时（8003H）时再发送结果通知。
+func (c *Client) Learn(channel int, timeout time.Duration) ([]byte, error) {
+	if err := checkLearnChannel(channel); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = LearnTimeout
+	}
+	started := time.Now()
+	value, err := c.WriteRegister(RegLearnStart, uint16(channel), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("启动红外学习失败: %w", err)
+	}
+	if value == uint16(channel) {
+		notifier, ok := c.transport.(Notifier)
+		if !ok {
+			// RTU 模式无法接收后续通知，等待结束后读取波形，通道为空视为学习失败
+			time.Sleep(time.Until(started.Add(timeout)))
+			value = ReplyDone
+		} else if value, err = notifier.Await(c.unitID, RegLearnStart, time.Until(started.Add(timeout))); err != nil {
+			c.StopLearning(channel)
+			return nil, fmt.Errorf("%w: %v", ErrLearnFailed, err)
+		}
+	}
+	if value != ReplyDone {
+		return nil, ErrLearnFailed
+	}
+
+	waveform, err := c.ReadLearned(channel)
+	if err != nil {
+		return nil, err
+	}
+	if emptyWaveform(waveform) {
+		return nil, ErrLearnFailed
+	}
+	return waveform, nil
+}
+
+// StopLearning 退出红外学习模式
+func (c *Client) StopLearning(channel int) error {
+	if err := checkLearnChannel(channel); err != nil {
+		return err
+	}
+	if _, err := c.WriteRegister(RegLearnStop, uint16(channel), 0); err != nil {
+		return fmt.Errorf("退出红外学习失败: %w", err)
+	}
+	return nil
+}
+
+// SendLearned 发送学习通道中的红外波形
+func (c *Client) SendLearned(channel int) error {
+	if err := checkLearnChannel(channel); err != nil {
+		return err
+	}
+	if _, err := c.WriteRegister(RegLearnSend, uint16(channel), 0); err != nil {
+		return fmt.Errorf("发送学习的红外码失败: %w", err)
+	}
+	return nil
+}
+
+// ReadLearned 读取学习通道的波形数据（LearnedBytes 字节）
+func (c *Client) ReadLearned(channel int) ([]byte, error) {
+	if err := checkLearnChannel(channel); err != nil {
+		return nil, err
+	}
+	regs, err := c.ReadRegisters(learnedAddress(channel), LearnedWords)
+	if err != nil {
+		return nil, fmt.Errorf("读取学习波形失败: %w", err)
+	}
+	waveform := make([]byte, 0, LearnedBytes)
+	for _, reg := range regs {
+		waveform = binary.BigEndian.AppendUint16(waveform, reg)
+	}
+	return waveform, nil
+}
+
+// WriteLearned 向学习通道写入波形数据，用于把平台保存的学习码下发到任一控制器
+func (c *Client) WriteLearned(channel int, waveform []byte) error {
+	if err := checkLearnChannel(channel); err != nil {
+		return err
+	}
+	if len(waveform) != LearnedBytes {
+		return fmt.Errorf("学习波形必须为 %d 字节，实际 %d 字节", LearnedBytes, len(waveform))
+	}
+	regs := make([]uint16, LearnedWords)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(waveform[i*2:])
+	}
+	if err := c.WriteRegisters(learnedAddress(channel), regs); err != nil {
+		return fmt.Errorf("写入学习波形失败: %w", err)
+	}
+	return nil
+}
+
+// SendWaveform 把波形写入学习通道后发送
+func (c *Client) SendWaveform(channel int, waveform []byte) error {
+	if err := c.WriteLearned(channel, waveform); err != nil {
+		return err
+	}
+	return c.SendLearned(channel)
+}
+
+// learnedAddress 学习通道波形的起始寄存器地址
+func learnedAddress(channel int) uint16 {
+	return RegLearnedBase + uint16(channel*LearnedWords)
+}
+
+func checkLearnChannel(channel int) error {
+	if channel < 0 || channel >= LearnChannels {
+		return fmt.Errorf("学习通道必须在 0-%d 之间", LearnChannels-1)
+	}
+	return nil
+}
+
+// emptyWaveform 通道从未学习过时波形全为0
+func emptyWaveform(waveform []byte) bool {
+	for _, b := range waveform {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package infrared
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// Listener TCP 客户端模式的监听端口：设备按配置的远程IP和端口连接平台，
+// 按设备IP保存连接，同一设备重新连接时替换旧连接。
+type Listener struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[string]*jsonConn // 设备IP -> 连接
+}
+
+// ListenerConn 已连接设备
+type ListenerConn struct {
+	Host     string    `json:"host"`
+	Remote   string    `json:"remote"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// NewListener 创建监听端口，timeout 为单条指令回复超时
+func NewListener(timeout time.Duration) *Listener {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Listener{timeout: timeout, conns: make(map[string]*jsonConn)}
+}
+
+// Listen 开始监听，address 如 :50001
+func (l *Listener) Listen(address string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.listener = listener
+	l.mu.Unlock()
+
+	go l.accept(listener)
+	return listener.Addr(), nil
+}
+
+func (l *Listener) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+
+		c := newJSONConn(conn, true)
+
+		l.mu.Lock()
+		if old, ok := l.conns[host]; ok {
+			old.conn.Close()
+		}
+		l.conns[host] = c
+		l.mu.Unlock()
+
+		go func() {
+			// 连接断开后移除
+			<-c.done
+			l.mu.Lock()
+			if l.conns[host] == c {
+				delete(l.conns, host)
+			}
+			l.mu.Unlock()
+		}()
+	}
+}
+
+// Transport 获取设备连接上的传输层，RTU 帧从 irPort 指定的红外输出口发送
+func (l *Listener) Transport(host string, irPort int) (modbus.Transport, error) {
+	if irPort < 0 || irPort > MaxIRPort {
+		return nil, fmt.Errorf("红外输出口必须在 0-%d 之间", MaxIRPort)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.conns[host]
+	if !ok {
+		return nil, fmt.Errorf("%w: 设备 %s 尚未连接监听端口", ErrNotConnected, host)
+	}
+	return &jsonTransport{conn: c, port: irPort, timeout: l.timeout}, nil
+}
+
+// Connections 当前已连接的设备
+func (l *Listener) Connections() []ListenerConn {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]ListenerConn, 0, len(l.conns))
+	for host, c := range l.conns {
+		c.stateMu.Lock()
+		result = append(result, ListenerConn{Host: host, Remote: c.conn.RemoteAddr().String(), LastSeen: c.lastSeen})
+		c.stateMu.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Host < result[j].Host })
+	return result
+}
+
+// Close 停止监听并断开全部设备连接
+func (l *Listener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var err error
+	if l.listener != nil {
+		err = l.listener.Close()
+		l.listener = nil
+	}
+	for host, c := range l.conns {
+		c.conn.Close()
+		delete(l.conns, host)
+	}
+	return err
+}
@@ -0,0 +1,284 @@
+// Package infrared CX-IR002E 红外空调控制器驱动。
+//
+// 设备内部是一张 Modbus-RTU 寄存器表（空调一键匹配、码库代号、空调状态、红外学习、模拟量等）。
+// RS485 接口直接收发 RTU 帧；网口的 TCP 服务器、TCP 客户端和 UDP 模式把 RTU 帧转成十六进制字符串
+// 放在 JSON 指令中下发：{"irout0h":"<请求帧>","res":"<序号>"}，设备回复
+// 4{...,"irout0s":"<回复帧>","res":"<序号>"}；以 1 开头的是设备主动上传，携带 C01-C04 等模拟量。
+package infrared
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// 寄存器地址（协议文档表 1-3-1）
+const (
+	RegFirmware    uint16 = 0x0000 // 固件版本号
+	RegChipID      uint16 = 0x0001 // 芯片ID
+	RegAddress     uint16 = 0x0002 // RS485 设备地址
+	RegBaudRate    uint16 = 0x0003 // 串口波特率代码
+	RegMAC         uint16 = 0x0004 // MAC 地址，3个寄存器
+	RegACMatch     uint16 = 0x0010 // 空调一键匹配：写1启动，回复匹配到的码库代号或 8003H
+	RegLearnStart  uint16 = 0x0016 // 启动红外学习，值为学习通道 0-63
+	RegLearnStop   uint16 = 0x0017 // 退出红外学习
+	RegLearnSend   uint16 = 0x0018 // 发送学习到的波形，值为学习通道
+	RegADThreshold uint16 = 0x001F // AD 开关机判断阈值（10mV）
+	RegACCode      uint16 = 0x0020 // 空调码库代号
+	RegACPower     uint16 = 0x0025 // 空调电源
+	RegACSetPoint  uint16 = 0x0026 // 空调温度：0-14 对应 16-30℃
+	RegACMode      uint16 = 0x0027 // 空调模式
+	RegACFan       uint16 = 0x0028 // 空调风速
+	RegACCombined  uint16 = 0x002A // 空调组合状态，3个寄存器：代号、状态字节1-2、状态字节3-4
+	RegPowerSense  uint16 = 0x003A // 互感器检测的空调开关机状态
+	RegADC         uint16 = 0x003B // ADC 采集值（10mV）
+	RegLearnedBase uint16 = 0x0500 // 学习通道0波形，每通道 LearnedWords 个寄存器
+)
+
+// 寄存器回复值
+const (
+	ReplyDone    uint16 = 0x8002 // 学习完成
+	ReplyTimeout uint16 = 0x8003 // 匹配或学习超时/失败
+)
+
+const (
+	LearnChannels = 64               // 红外学习通道数
+	LearnedWords  = 50               // 每个学习通道的波形寄存器数（100字节）
+	LearnedBytes  = LearnedWords * 2 // 每个学习通道的波形字节数
+	MaxIRPort     = 4                // 红外输出口 OUT1-OUT4，0 表示四路同时发送
+)
+
+// 空调模式，寄存器值为在 acModes 中的下标
+const (
+	ModeAuto = "auto"
+	ModeCool = "cool"
+	ModeDry  = "dry"
+	ModeFan  = "fan"
+	ModeHeat = "heat"
+)
+
+// 空调风速，寄存器值为在 acFanSpeeds 中的下标
+const (
+	FanAuto   = "auto"
+	FanLow    = "low"
+	FanMedium = "medium"
+	FanHigh   = "high"
+)
+
+// 空调设定温度范围
+const (
+	MinSetPoint = 16
+	MaxSetPoint = 30
+)
+
+var (
+	acModes     = []string{ModeAuto, ModeCool, ModeDry, ModeFan, ModeHeat}
+	acFanSpeeds = []string{FanAuto, FanLow, FanMedium, FanHigh}
+)
+
+// 协议错误
+var (
+	ErrMatchFailed    = errors.New("空调匹配超时或失败")
+	ErrLearnFailed    = errors.New("红外学习超时或失败")
+	ErrNotConnected   = errors.New("红外控制器未连接")
+	ErrNotSupported   = errors.New("当前通信模式不支持该操作")
+	ErrInvalidMessage = errors.New("红外控制器消息格式错误")
+)
+
+// ACState 空调状态。红外是单向的，读到的是控制器最后一次发码的状态，不代表空调实际状态。
+type ACState struct {
+	Power    bool   `json:"power"`
+	Mode     string `json:"mode"`      // auto/cool/dry/fan/heat
+	SetPoint int    `json:"set_point"` // 16-30℃
+	FanSpeed string `json:"fan_speed"` // auto/low/medium/high
+}
+
+// Validate 校验空调状态
+func (s ACState) Validate() error {
+	if _, err := ModeCode(s.Mode); err != nil {
+		return err
+	}
+	if _, err := FanCode(s.FanSpeed); err != nil {
+		return err
+	}
+	if s.SetPoint < MinSetPoint || s.SetPoint > MaxSetPoint {
+		return fmt.Errorf("设定温度必须在 %d-%d℃ 之间", MinSetPoint, MaxSetPoint)
+	}
+	return nil
+}
+
+// ModeCode 空调模式的寄存器值
+func ModeCode(mode string) (uint16, error) {
+	for i, m := range acModes {
+		if m == mode {
+			return uint16(i), nil
+		}
+	}
+	return 0, fmt.Errorf("不支持的空调模式: %s", mode)
+}
+
+// FanCode 空调风速的寄存器值
+func FanCode(speed string) (uint16, error) {
+	for i, s := range acFanSpeeds {
+		if s == speed {
+			return uint16(i), nil
+		}
+	}
+	return 0, fmt.Errorf("不支持的空调风速: %s", speed)
+}
+
+func modeName(code uint16) string {
+	if int(code) < len(acModes) {
+		return acModes[code]
+	}
+	return fmt.Sprintf("unknown(%d)", code)
+}
+
+func fanName(code uint16) string {
+	if int(code) < len(acFanSpeeds) {
+		return acFanSpeeds[code]
+	}
+	return fmt.Sprintf("unknown(%d)", code)
+}
+
+// combinedRegisters 空调组合状态寄存器（002AH-002CH）的值：
+// 代号；状态字节1（Bit7 开机，Bit3-0 温度-16）和状态字节2（Bit7-4 模式，Bit3-0 风速）；
+// 状态字节3（组合键键值，0 为电源键）和状态字节4（Bit0 按指定代号发码）。
+// 组合键发码时一帧红外携带完整状态，与空调遥控器的工作方式一致。
+func combinedRegisters(state ACState, code int) ([]uint16, error) {
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+	mode, _ := ModeCode(state.Mode)
+	fan, _ := FanCode(state.FanSpeed)
+
+	byte1 := uint16(state.SetPoint - MinSetPoint)
+	if state.Power {
+		byte1 |= 0x80
+	}
+	byte2 := mode<<4 | fan
+	var byte4 uint16
+	if code > 0 {
+		byte4 = 0x01
+	}
+	return []uint16{uint16(code), byte1<<8 | byte2, byte4}, nil
+}
+
+// Message 设备上传或回复的消息
+type Message struct {
+	Upload   bool               `json:"upload"`             // 主动上传（前缀1），否则为指令回复（前缀4）
+	Interval int                `json:"interval,omitempty"` // T01 主动上传间隔（秒）
+	Analog   map[string]float64 `json:"analog,omitempty"`   // C01-C04 自带模拟量、C11-C35 自定义485数据、C51-C78 扩展模块
+	Frame    []byte             `json:"-"`                  // irout*s 红外指令回复帧（RTU帧）
+	Res      string             `json:"res,omitempty"`
+}
+
+// EncodeCommand 生成红外指令：把 RTU 帧按十六进制放入 irout<port>h 字段，port 为 0 时四路同时发送
+func EncodeCommand(port int, frame []byte, res string) []byte {
+	return []byte(fmt.Sprintf(`{"irout%dh":"%s","res":"%s"}`, port, strings.ToUpper(hex.EncodeToString(frame)), res))
+}
+
+// ParseMessage 解析设备上传或回复的消息
+func ParseMessage(data []byte) (*Message, error) {
+	data = bytes.TrimSpace(data)
+	msg := &Message{}
+	if len(data) > 0 && (data[0] == '1' || data[0] == '4') {
+		msg.Upload = data[0] == '1'
+		data = data[1:]
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	for key, raw := range fields {
+		switch {
+		case key == "res":
+			json.Unmarshal(raw, &msg.Res)
+		case key == "T01":
+			var v float64
+			if json.Unmarshal(raw, &v) == nil {
+				msg.Interval = int(v)
+			}
+		case strings.HasPrefix(key, "irout") && strings.HasSuffix(key, "s"):
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, fmt.Errorf("%w: %s 不是字符串", ErrInvalidMessage, key)
+			}
+			frame, err := hex.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s 不是十六进制帧", ErrInvalidMessage, key)
+			}
+			msg.Frame = frame
+		case len(key) == 3 && key[0] == 'C':
+			if _, err := strconv.Atoi(key[1:]); err != nil {
+				continue
+			}
+			var v float64
+			if json.Unmarshal(raw, &v) == nil {
+				if msg.Analog == nil {
+					msg.Analog = make(map[string]float64)
+				}
+				msg.Analog[key] = v
+			}
+		}
+	}
+	return msg, nil
+}
+
+// AnalogKeys 按通道号排序的模拟量键
+func (m *Message) AnalogKeys() []string {
+	keys := make([]string, 0, len(m.Analog))
+	for key := range m.Analog {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encodeFrame 生成 RTU 帧：站号 + PDU + CRC16（低字节在前）
+func encodeFrame(unitID byte, pdu []byte) []byte {
+	frame := append([]byte{unitID}, pdu...)
+	return binary.LittleEndian.AppendUint16(frame, modbus.CRC16(frame))
+}
+
+// decodeFrame 校验 RTU 回复帧并返回 PDU
+func decodeFrame(frame []byte, unitID byte) ([]byte, error) {
+	n := len(frame)
+	if n < 4 {
+		return nil, fmt.Errorf("%w: 帧长度 %d", modbus.ErrInvalidResponse, n)
+	}
+	if modbus.CRC16(frame[:n-2]) != binary.LittleEndian.Uint16(frame[n-2:]) {
+		return nil, modbus.ErrCRCMismatch
+	}
+	if frame[0] != unitID {
+		return nil, fmt.Errorf("%w: 站号不匹配 期望=%d, 实际=%d", modbus.ErrInvalidResponse, unitID, frame[0])
+	}
+	return frame[1 : n-2], nil
+}
+
+// checkReply 检查回复 PDU 的功能码和异常响应
+func checkReply(request, reply []byte) error {
+	if len(reply) == 0 {
+		return modbus.ErrInvalidResponse
+	}
+	if reply[0] == request[0]|0x80 {
+		code := byte(0)
+		if len(reply) > 1 {
+			code = reply[1]
+		}
+		return &modbus.ExceptionError{FunctionCode: request[0], Code: code}
+	}
+	if reply[0] != request[0] {
+		return fmt.Errorf("%w: 功能码不匹配 期望=%02X, 实际=%02X", modbus.ErrInvalidResponse, request[0], reply[0])
+	}
+	return nil
+}
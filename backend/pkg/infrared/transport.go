@@ -0,0 +1,352 @@
+package infrared
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"smart-device-management/pkg/modbus"
+)
+
+// Mode 通信模式（设备网络参数配置软件中的工作模式）
+type Mode string
+
+const (
+	ModeTCPServer Mode = "tcp_server" // 设备作为 TCP 服务器，平台主动连接
+	ModeTCPClient Mode = "tcp_client" // 设备作为 TCP 客户端连接平台的监听端口
+	ModeUDP       Mode = "udp"        // 设备作为 UDP 服务端，回复到发送方
+	ModeRTU       Mode = "rtu"        // RS485 Modbus-RTU：本地串口，或经串口服务器透传
+)
+
+// ParseMode 解析通信模式
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(s); mode {
+	case ModeTCPServer, ModeTCPClient, ModeUDP, ModeRTU:
+		return mode, nil
+	}
+	return "", fmt.Errorf("不支持的红外控制器通信模式: %s", s)
+}
+
+// Config 连接配置
+type Config struct {
+	Mode    Mode
+	Address string              // host:port，TCP服务器/UDP模式为设备地址，RTU模式为串口服务器地址
+	Serial  modbus.SerialConfig // RTU 模式本地串口，Device 为空时经 Address 透传
+	IRPort  int                 // 红外输出口 1-4，0 表示四路同时发送
+	Timeout time.Duration       // 单条指令回复超时
+}
+
+// Dial 建立连接，返回的传输层按 Modbus PDU 收发。TCP 客户端模式由 Listener 接受设备连接，
+// RTU 模式返回网关调度器的视图，Close 不断开共用的连接。
+func Dial(cfg Config) (modbus.Transport, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.IRPort < 0 || cfg.IRPort > MaxIRPort {
+		return nil, fmt.Errorf("红外输出口必须在 0-%d 之间", MaxIRPort)
+	}
+
+	switch cfg.Mode {
+	case ModeTCPServer:
+		conn, err := net.DialTimeout("tcp", cfg.Address, cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("连接红外控制器失败: %w", err)
+		}
+		return &jsonTransport{conn: newJSONConn(conn, true), port: cfg.IRPort, timeout: cfg.Timeout, owned: true}, nil
+	case ModeUDP:
+		conn, err := net.DialTimeout("udp", cfg.Address, cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("连接红外控制器失败: %w", err)
+		}
+		return &jsonTransport{conn: newJSONConn(conn, false), port: cfg.IRPort, timeout: cfg.Timeout, owned: true}, nil
+	case ModeRTU:
+		// 与同一串口或串口服务器端口上的断路器、温度模块共用调度器长连接，空调指令按控制优先级排队；
+		// 回复超时按请求指定，不改变调度器的连接配置
+		endpoint := modbus.Config{Framing: modbus.FramingRTUOverTCP, Address: cfg.Address}
+		if cfg.Serial.Device != "" {
+			endpoint = modbus.Config{Framing: modbus.FramingRTU, Serial: cfg.Serial}
+		}
+		return modbus.DefaultGatewayPool().Gateway(endpoint).TransportWithTimeout(modbus.PriorityControl, cfg.Timeout), nil
+	case ModeTCPClient:
+		return nil, fmt.Errorf("TCP客户端模式由设备主动连接，请通过监听端口获取连接")
+	}
+	return nil, fmt.Errorf("不支持的红外控制器通信模式: %s", cfg.Mode)
+}
+
+// jsonConn 网口 JSON 协议连接（TCP 或 UDP）。后台协程接收设备消息：主动上传只更新最新数据，
+// 指令回复送入 inbox，由等待回复的一方按 res 匹配。
+type jsonConn struct {
+	conn net.Conn
+
+	mu  sync.Mutex // 同一时刻只有一条指令在等待回复
+	seq uint32
+
+	inbox   chan *Message
+	notices [][]byte      // 等待回复时收到的其他回复帧（如学习完成通知），供 await 使用
+	done    chan struct{} // 连接断开、接收协程退出时关闭
+
+	stateMu  sync.Mutex
+	latest   *Message
+	lastSeen time.Time
+}
+
+func newJSONConn(conn net.Conn, stream bool) *jsonConn {
+	c := &jsonConn{conn: conn, inbox: make(chan *Message, 16), done: make(chan struct{}), lastSeen: time.Now()}
+	go c.receive(stream)
+	return c
+}
+
+// receive 接收设备消息直到连接关闭
+func (c *jsonConn) receive(stream bool) {
+	defer close(c.done)
+	defer close(c.inbox)
+
+	reader := bufio.NewReader(c.conn)
+	buf := make([]byte, 2048)
+	for {
+		var data []byte
+		var err error
+		if stream {
+			data, err = readStreamMessage(reader)
+		} else {
+			var n int
+			n, err = c.conn.Read(buf)
+			data = buf[:n]
+		}
+		if err != nil {
+			return
+		}
+
+		msg, err := ParseMessage(data)
+		if err != nil {
+			continue
+		}
+		c.stateMu.Lock()
+		c.lastSeen = time.Now()
+		if msg.Analog != nil {
+			c.latest = msg
+		}
+		c.stateMu.Unlock()
+
+		if msg.Upload && msg.Frame == nil {
+			continue
+		}
+		select {
+		case c.inbox <- msg:
+		default:
+			// 无人等待时丢弃最旧的回复
+			select {
+			case <-c.inbox:
+			default:
+			}
+			c.inbox <- msg
+		}
+	}
+}
+
+// readStreamMessage 从 TCP 字节流中读出一条消息：可选的前缀 1/4 加一个不含嵌套的 JSON 对象，
+// 对象之前的其他字节（如版本字符串）丢弃
+func readStreamMessage(r *bufio.Reader) ([]byte, error) {
+	var prefix byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == '{' {
+			break
+		}
+		prefix = b
+	}
+	body, err := r.ReadBytes('}')
+	if err != nil {
+		return nil, err
+	}
+	msg := []byte{'{'}
+	if prefix == '1' || prefix == '4' {
+		msg = []byte{prefix, '{'}
+	}
+	return append(msg, body...), nil
+}
+
+// command 下发一条 JSON 指令（build 按序号生成指令）并等待 res 相同的回复
+func (c *jsonConn) command(build func(res string) []byte, timeout time.Duration) (*Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	res := strconv.FormatUint(uint64(c.seq), 10)
+	c.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err := c.conn.Write(build(res)); err != nil {
+		return nil, fmt.Errorf("发送红外控制器指令失败: %w", err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-c.inbox:
+			if !ok {
+				return nil, ErrNotConnected
+			}
+			if msg.Res == res {
+				return msg, nil
+			}
+			c.keepNotice(msg.Frame)
+		case <-timer.C:
+			return nil, fmt.Errorf("等待红外控制器回复超时: %w", timeoutError{})
+		}
+	}
+}
+
+func (c *jsonConn) keepNotice(frame []byte) {
+	if frame == nil {
+		return
+	}
+	if len(c.notices) >= 16 {
+		c.notices = c.notices[1:]
+	}
+	c.notices = append(c.notices, frame)
+}
+
+// await 等待设备稍后发来的写单个寄存器回复帧（如学习完成、匹配结果），返回寄存器值
+func (c *jsonConn) await(unitID byte, addr uint16, timeout time.Duration) (uint16, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	match := func(frame []byte) (uint16, bool) {
+		pdu, err := decodeFrame(frame, unitID)
+		if err != nil || len(pdu) < 5 || pdu[0] != 0x06 || binary.BigEndian.Uint16(pdu[1:3]) != addr {
+			return 0, false
+		}
+		return binary.BigEndian.Uint16(pdu[3:5]), true
+	}
+	for i, frame := range c.notices {
+		if value, ok := match(frame); ok {
+			c.notices = append(c.notices[:i], c.notices[i+1:]...)
+			return value, nil
+		}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-c.inbox:
+			if !ok {
+				return 0, ErrNotConnected
+			}
+			if value, ok := match(msg.Frame); ok {
+				return value, nil
+			}
+		case <-timer.C:
+			return 0, fmt.Errorf("等待红外控制器通知超时: %w", timeoutError{})
+		}
+	}
+}
+
+// timeoutError 等待回复超时，满足 net.Error 以便 modbus.IsTimeout 识别
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// jsonTransport 把 JSON 连接包装为 Modbus 传输层，RTU 帧从指定红外输出口发送
+type jsonTransport struct {
+	conn    *jsonConn
+	port    int
+	timeout time.Duration
+	owned   bool // 连接由本传输层建立，Close 时断开；监听端口接受的连接由 Listener 管理
+}
+
+func (t *jsonTransport) Send(unitID byte, pdu []byte) ([]byte, error) {
+	return t.SendTimeout(unitID, pdu, t.timeout)
+}
+
+// SendTimeout 以指定的回复超时发送一帧，一键匹配等需要等待用户操作的指令使用较长超时
+func (t *jsonTransport) SendTimeout(unitID byte, pdu []byte, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = t.timeout
+	}
+	if len(pdu) == 0 {
+		return nil, fmt.Errorf("MODBUS请求PDU为空")
+	}
+	frame := encodeFrame(unitID, pdu)
+	msg, err := t.conn.command(func(res string) []byte { return EncodeCommand(t.port, frame, res) }, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Frame == nil {
+		return nil, fmt.Errorf("%w: 回复中没有红外指令回复帧", modbus.ErrInvalidResponse)
+	}
+	reply, err := decodeFrame(msg.Frame, unitID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReply(pdu, reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Await 等待设备稍后发来的寄存器通知
+func (t *jsonTransport) Await(unitID byte, addr uint16, timeout time.Duration) (uint16, error) {
+	return t.conn.await(unitID, addr, timeout)
+}
+
+// Status 读取模拟量：{"readsta":0}
+func (t *jsonTransport) Status() (*Message, error) {
+	return t.conn.command(func(res string) []byte {
+		return []byte(fmt.Sprintf(`{"readsta":0,"res":"%s"}`, res))
+	}, t.timeout)
+}
+
+// SetUploadInterval 设置主动上传间隔（秒），4位数字不足补0
+func (t *jsonTransport) SetUploadInterval(seconds int) error {
+	if seconds < 0 || seconds > 9999 {
+		return fmt.Errorf("上传间隔必须在 0-9999 秒之间")
+	}
+	_, err := t.conn.command(func(res string) []byte {
+		return []byte(fmt.Sprintf(`{"uptime":%04d,"res":"%s"}`, seconds, res))
+	}, t.timeout)
+	return err
+}
+
+// Latest 最近一次收到的模拟量数据和收到设备消息的时间
+func (t *jsonTransport) Latest() (*Message, time.Time) {
+	t.conn.stateMu.Lock()
+	defer t.conn.stateMu.Unlock()
+	return t.conn.latest, t.conn.lastSeen
+}
+
+func (t *jsonTransport) Close() error {
+	if !t.owned {
+		return nil
+	}
+	return t.conn.conn.Close()
+}
+
+// Notifier 支持等待设备主动通知的传输层（网口 JSON 模式）
+type Notifier interface {
+	Await(unitID byte, addr uint16, timeout time.Duration) (uint16, error)
+}
+
+// StatusReader 支持读取 C01-C04 等模拟量的传输层（网口 JSON 模式）
+type StatusReader interface {
+	Status() (*Message, error)
+	SetUploadInterval(seconds int) error
+	Latest() (*Message, time.Time)
+}
+
+var (
+	_ modbus.TimeoutSender = (*jsonTransport)(nil)
+	_ Notifier             = (*jsonTransport)(nil)
+	_ StatusReader         = (*jsonTransport)(nil)
+	_ io.Closer            = (*jsonTransport)(nil)
+)
@@ -0,0 +1,118 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// CRC16 计算MODBUS RTU的CRC16校验码（多项式0xA001，初值0xFFFF，低字节在前发送）
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// mbapFramer MODBUS TCP报文：事务号(2) + 协议号(2) + 长度(2) + 单元号(1) + PDU
+type mbapFramer struct {
+	transID uint16
+}
+
+func (f *mbapFramer) encode(unitID byte, pdu []byte) []byte {
+	f.transID++
+	if f.transID == 0 {
+		f.transID = 1
+	}
+	adu := make([]byte, 7, 7+len(pdu))
+	binary.BigEndian.PutUint16(adu[0:2], f.transID)
+	binary.BigEndian.PutUint16(adu[2:4], 0)
+	binary.BigEndian.PutUint16(adu[4:6], uint16(len(pdu)+1))
+	adu[6] = unitID
+	return append(adu, pdu...)
+}
+
+func (f *mbapFramer) decode(r io.Reader, unitID byte) ([]byte, error) {
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint16(header[4:6])
+		if binary.BigEndian.Uint16(header[2:4]) != 0 || length < 2 || length > 254 {
+			return nil, fmt.Errorf("%w: MBAP头 % X", ErrInvalidResponse, header)
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(r, pdu); err != nil {
+			return nil, err
+		}
+
+		// 丢弃上一次超时请求迟到的响应
+		if binary.BigEndian.Uint16(header[0:2]) != f.transID {
+			continue
+		}
+		if header[6] != unitID {
+			return nil, fmt.Errorf("%w: 单元号不匹配 期望=%d, 实际=%d", ErrInvalidResponse, unitID, header[6])
+		}
+		return pdu, nil
+	}
+}
+
+// rtuFramer MODBUS RTU报文：站号(1) + PDU + CRC16(2, 低字节在前)
+type rtuFramer struct{}
+
+func (f *rtuFramer) encode(unitID byte, pdu []byte) []byte {
+	adu := make([]byte, 0, len(pdu)+3)
+	adu = append(adu, unitID)
+	adu = append(adu, pdu...)
+	return binary.LittleEndian.AppendUint16(adu, CRC16(adu))
+}
+
+func (f *rtuFramer) decode(r io.Reader, unitID byte) ([]byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+
+	// RTU没有长度字段，按功能码推算剩余字节数
+	var rest int
+	fc := head[1]
+	switch {
+	case fc&0x80 != 0:
+		rest = 1
+	case fc == 0x01 || fc == 0x02 || fc == 0x03 || fc == 0x04:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(r, n); err != nil {
+			return nil, err
+		}
+		head = append(head, n[0])
+		rest = int(n[0])
+	case fc == 0x05 || fc == 0x06 || fc == 0x0F || fc == 0x10:
+		rest = 4
+	default:
+		return nil, fmt.Errorf("%w: 未知功能码 %02X", ErrInvalidResponse, fc)
+	}
+
+	tail := make([]byte, rest+2)
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return nil, err
+	}
+	frame := append(head, tail...)
+
+	n := len(frame)
+	if CRC16(frame[:n-2]) != binary.LittleEndian.Uint16(frame[n-2:]) {
+		return nil, ErrCRCMismatch
+	}
+	if frame[0] != unitID {
+		return nil, fmt.Errorf("%w: 站号不匹配 期望=%d, 实际=%d", ErrInvalidResponse, unitID, frame[0])
+	}
+	return frame[1 : n-2], nil
+}
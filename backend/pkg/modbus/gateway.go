@@ -0,0 +1,622 @@
+package modbus
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority 请求优先级，数值越大越先发送
+type Priority int
+
+const (
+	PriorityPoll    Priority = iota // 后台周期轮询
+	PriorityNormal                  // 页面实时读取、参数读取等交互请求
+	PriorityControl                 // 分合闸、锁定等控制命令
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityPoll:
+		return "poll"
+	case PriorityNormal:
+		return "normal"
+	case PriorityControl:
+		return "control"
+	}
+	return fmt.Sprintf("priority_%d", int(p))
+}
+
+// ErrGatewayClosed 调度器已关闭
+var ErrGatewayClosed = errors.New("MODBUS网关调度器已关闭")
+
+// ErrGatewayBusy 端口正被独占会话以其他串口参数使用（如投运调试按出厂波特率扫描）
+var ErrGatewayBusy = errors.New("MODBUS网关端口正被投运调试独占")
+
+// GatewayOptions 网关调度参数
+type GatewayOptions struct {
+	FrameGap    time.Duration // 相邻两帧之间的最小间隔
+	IdleTimeout time.Duration // 空闲多久后关闭长连接，0表示不关闭
+	DialTimeout time.Duration
+	Timeout     time.Duration // 单帧请求-响应超时
+}
+
+// DefaultGatewayOptions 默认调度参数
+func DefaultGatewayOptions() GatewayOptions {
+	return GatewayOptions{
+		FrameGap:    50 * time.Millisecond,
+		IdleTimeout: 5 * time.Minute,
+		DialTimeout: 3 * time.Second,
+		Timeout:     3 * time.Second,
+	}
+}
+
+// GatewayStats 网关调度指标
+type GatewayStats struct {
+	Key          string         `json:"key"`
+	Framing      Framing        `json:"framing"`
+	Connected    bool           `json:"connected"`
+	QueueDepth   int            `json:"queue_depth"`
+	QueueByLevel map[string]int `json:"queue_by_level"`
+	Requests     uint64         `json:"requests"`
+	Failures     uint64         `json:"failures"`
+	Timeouts     uint64         `json:"timeouts"`
+	Reconnects   uint64         `json:"reconnects"`
+	AvgLatencyMs float64        `json:"avg_latency_ms"` // 帧往返时间（指数滑动平均）
+	MaxLatencyMs float64        `json:"max_latency_ms"`
+	AvgWaitMs    float64        `json:"avg_wait_ms"` // 排队等待时间（指数滑动平均）
+	MaxWaitMs    float64        `json:"max_wait_ms"`
+	LastError    string         `json:"last_error,omitempty"`
+	LastActivity *time.Time     `json:"last_activity,omitempty"`
+}
+
+// gatewayRequest 排队中的请求
+type gatewayRequest struct {
+	unitID   byte
+	pdu      []byte
+	priority Priority
+	timeout  time.Duration // 单帧响应超时，0表示使用网关配置
+	connect  bool          // 只建立连接，不发送报文
+	seq      uint64
+	enqueued time.Time
+	done     chan gatewayResult
+}
+
+type gatewayResult struct {
+	resp []byte
+	err  error
+}
+
+// requestQueue 按优先级排序、同优先级先进先出的队列
+type requestQueue []*gatewayRequest
+
+func (q requestQueue) Len() int { return len(q) }
+func (q requestQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q requestQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *requestQueue) Push(x interface{}) { *q = append(*q, x.(*gatewayRequest)) }
+func (q *requestQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
+
+// Gateway 单个网关端口（或串口）的请求调度器：
+// 持有一条长连接，所有请求按优先级串行发送，并保证帧间隔。
+type Gateway struct {
+	key       string
+	cfg       Config
+	requested Config // 创建时调用方给出的配置（未填充网关默认超时），用于判断配置是否变化
+	opts      GatewayOptions
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     requestQueue
+	seq       uint64
+	closed    bool
+	busy      bool // 端口被其他配置的独占会话占用，请求直接返回 ErrGatewayBusy
+	inFlight  bool // 已出队的请求尚未完成（含帧间隔等待），此时不能关闭连接
+	transport Transport
+	lastFrame time.Time
+	stats     GatewayStats
+}
+
+func newGateway(key string, cfg Config, opts GatewayOptions) *Gateway {
+	requested := cfg
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = opts.Timeout
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = opts.DialTimeout
+	}
+	g := &Gateway{
+		key:       key,
+		cfg:       cfg,
+		requested: requested,
+		opts:      opts,
+		stats:     GatewayStats{Key: key, Framing: cfg.Framing},
+	}
+	g.cond = sync.NewCond(&g.mu)
+	go g.run()
+	go g.idleWatch()
+	return g
+}
+
+// Do 以指定优先级提交请求并等待响应
+func (g *Gateway) Do(priority Priority, unitID byte, pdu []byte) ([]byte, error) {
+	return g.DoTimeout(priority, unitID, pdu, 0)
+}
+
+// DoTimeout 以指定优先级和单帧响应超时提交请求，timeout 为0时使用网关配置的超时
+func (g *Gateway) DoTimeout(priority Priority, unitID byte, pdu []byte, timeout time.Duration) ([]byte, error) {
+	return g.submit(&gatewayRequest{unitID: unitID, pdu: pdu, priority: priority, timeout: timeout})
+}
+
+// Connect 确保长连接已建立，用于登记、检测设备前确认网关端口或串口可达。
+// 与其他请求一起排队执行，不会另开连接
+func (g *Gateway) Connect(priority Priority) error {
+	_, err := g.submit(&gatewayRequest{priority: priority, connect: true})
+	return err
+}
+
+// submit 请求入队并等待结果
+func (g *Gateway) submit(req *gatewayRequest) ([]byte, error) {
+	req.enqueued = time.Now()
+	req.done = make(chan gatewayResult, 1)
+
+	g.mu.Lock()
+	if g.busy {
+		g.mu.Unlock()
+		return nil, ErrGatewayBusy
+	}
+	if g.closed {
+		g.mu.Unlock()
+		return nil, ErrGatewayClosed
+	}
+	g.seq++
+	req.seq = g.seq
+	heap.Push(&g.queue, req)
+	g.cond.Signal()
+	g.mu.Unlock()
+
+	res := <-req.done
+	return res.resp, res.err
+}
+
+// Transport 返回以固定优先级提交请求的传输层视图，供ModbusClient使用。
+// 视图的Close不会关闭网关长连接。
+func (g *Gateway) Transport(priority Priority) Transport {
+	return &gatewayTransport{gateway: g, priority: priority}
+}
+
+// TransportWithTimeout 返回使用指定单帧响应超时的传输层视图，
+// 用于站号扫描等大量请求预期无应答、需要快速失败的场景
+func (g *Gateway) TransportWithTimeout(priority Priority, timeout time.Duration) Transport {
+	return &gatewayTransport{gateway: g, priority: priority, timeout: timeout}
+}
+
+// Stats 返回调度指标快照
+func (g *Gateway) Stats() GatewayStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	s := g.stats
+	s.Connected = g.transport != nil
+	s.QueueDepth = len(g.queue)
+	s.QueueByLevel = make(map[string]int)
+	for _, req := range g.queue {
+		s.QueueByLevel[req.priority.String()]++
+	}
+	return s
+}
+
+// Close 关闭调度器，排队中的请求全部返回错误
+func (g *Gateway) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return
+	}
+	g.closed = true
+	for g.queue.Len() > 0 {
+		req := heap.Pop(&g.queue).(*gatewayRequest)
+		req.done <- gatewayResult{err: ErrGatewayClosed}
+	}
+	if g.transport != nil {
+		g.transport.Close()
+		g.transport = nil
+	}
+	g.cond.Broadcast()
+}
+
+// run 调度循环：每次取出优先级最高的请求发送
+func (g *Gateway) run() {
+	for {
+		g.mu.Lock()
+		for g.queue.Len() == 0 && !g.closed {
+			g.cond.Wait()
+		}
+		if g.closed {
+			g.mu.Unlock()
+			return
+		}
+		req := heap.Pop(&g.queue).(*gatewayRequest)
+		wait := time.Since(g.lastFrame)
+		g.inFlight = true
+		g.mu.Unlock()
+
+		// 帧间隔：给网关和总线上的从站留出处理时间
+		if gap := g.opts.FrameGap; gap > 0 && wait < gap {
+			time.Sleep(gap - wait)
+		}
+
+		resp, err := g.exchange(req)
+		g.mu.Lock()
+		g.inFlight = false
+		g.mu.Unlock()
+		req.done <- gatewayResult{resp: resp, err: err}
+	}
+}
+
+// exchange 在长连接上完成一次请求-响应，连接异常时断开以便下次重连
+func (g *Gateway) exchange(req *gatewayRequest) ([]byte, error) {
+	waited := time.Since(req.enqueued)
+
+	g.mu.Lock()
+	transport := g.transport
+	g.mu.Unlock()
+
+	if transport == nil {
+		t, err := Open(g.cfg)
+		if err != nil {
+			g.record(waited, 0, err, false)
+			return nil, err
+		}
+		g.mu.Lock()
+		if g.closed {
+			g.mu.Unlock()
+			t.Close()
+			return nil, ErrGatewayClosed
+		}
+		g.transport = t
+		g.stats.Reconnects++
+		g.mu.Unlock()
+		transport = t
+	}
+	if req.connect {
+		return nil, nil
+	}
+
+	start := time.Now()
+	var resp []byte
+	var err error
+	if ts, ok := transport.(TimeoutSender); ok && req.timeout > 0 {
+		resp, err = ts.SendTimeout(req.unitID, req.pdu, req.timeout)
+	} else {
+		resp, err = transport.Send(req.unitID, req.pdu)
+	}
+	latency := time.Since(start)
+
+	g.record(waited, latency, err, linkBroken(err))
+	return resp, err
+}
+
+// linkBroken 判断请求失败后是否需要重连。从站异常响应、响应超时和总线乱码（CRC错误、帧格式错误）
+// 都说明连接本身正常，迟到或残留的字节由传输层在下一帧前丢弃；只有读写出错、对端关闭才断开长连接
+func linkBroken(err error) bool {
+	if err == nil {
+		return false
+	}
+	var exception *ExceptionError
+	switch {
+	case errors.As(err, &exception), IsTimeout(err), errors.Is(err, ErrCRCMismatch), errors.Is(err, ErrInvalidResponse):
+		return false
+	}
+	return true
+}
+
+// record 更新调度指标
+func (g *Gateway) record(waited, latency time.Duration, err error, broken bool) {
+	const alpha = 0.2
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.lastFrame = now
+	g.stats.LastActivity = &now
+	g.stats.Requests++
+
+	waitMs := float64(waited) / float64(time.Millisecond)
+	if g.stats.Requests == 1 {
+		g.stats.AvgWaitMs = waitMs
+	} else {
+		g.stats.AvgWaitMs = alpha*waitMs + (1-alpha)*g.stats.AvgWaitMs
+	}
+	if waitMs > g.stats.MaxWaitMs {
+		g.stats.MaxWaitMs = waitMs
+	}
+
+	if latency > 0 {
+		latencyMs := float64(latency) / float64(time.Millisecond)
+		if g.stats.AvgLatencyMs == 0 {
+			g.stats.AvgLatencyMs = latencyMs
+		} else {
+			g.stats.AvgLatencyMs = alpha*latencyMs + (1-alpha)*g.stats.AvgLatencyMs
+		}
+		if latencyMs > g.stats.MaxLatencyMs {
+			g.stats.MaxLatencyMs = latencyMs
+		}
+	}
+
+	if err != nil {
+		g.stats.Failures++
+		g.stats.LastError = err.Error()
+		if IsTimeout(err) {
+			g.stats.Timeouts++
+		}
+	}
+	if broken && g.transport != nil {
+		g.transport.Close()
+		g.transport = nil
+	}
+}
+
+// idleWatch 长时间无请求时关闭连接，释放网关的TCP连接数
+func (g *Gateway) idleWatch() {
+	if g.opts.IdleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(g.opts.IdleTimeout / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		g.mu.Lock()
+		if g.closed {
+			g.mu.Unlock()
+			return
+		}
+		if g.transport != nil && !g.inFlight && g.queue.Len() == 0 && time.Since(g.lastFrame) > g.opts.IdleTimeout {
+			g.transport.Close()
+			g.transport = nil
+		}
+		g.mu.Unlock()
+	}
+}
+
+// gatewayTransport 固定优先级的网关传输层视图
+type gatewayTransport struct {
+	gateway  *Gateway
+	priority Priority
+	timeout  time.Duration
+}
+
+func (t *gatewayTransport) Send(unitID byte, pdu []byte) ([]byte, error) {
+	return t.gateway.DoTimeout(t.priority, unitID, pdu, t.timeout)
+}
+
+// SendTimeout 以指定的单帧响应超时发送，timeout 为0时使用视图或网关配置的超时
+func (t *gatewayTransport) SendTimeout(unitID byte, pdu []byte, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = t.timeout
+	}
+	return t.gateway.DoTimeout(t.priority, unitID, pdu, timeout)
+}
+
+func (t *gatewayTransport) Close() error {
+	return nil
+}
+
+// GatewayPool 按网关端口复用调度器，保证同一端口只有一条连接
+type GatewayPool struct {
+	mu       sync.Mutex
+	opts     GatewayOptions
+	gateways map[string]*Gateway
+	reserved map[string]*GatewaySession // 被独占的端口
+}
+
+// NewGatewayPool 创建网关调度器池
+func NewGatewayPool(opts GatewayOptions) *GatewayPool {
+	return &GatewayPool{
+		opts:     opts,
+		gateways: make(map[string]*Gateway),
+		reserved: make(map[string]*GatewaySession),
+	}
+}
+
+var (
+	defaultPool     *GatewayPool
+	defaultPoolOnce sync.Once
+)
+
+// DefaultGatewayPool 进程内共享的网关调度器池
+func DefaultGatewayPool() *GatewayPool {
+	defaultPoolOnce.Do(func() {
+		defaultPool = NewGatewayPool(DefaultGatewayOptions())
+	})
+	return defaultPool
+}
+
+// SetOptions 调整之后新建网关使用的调度参数
+func (p *GatewayPool) SetOptions(opts GatewayOptions) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.opts = opts
+}
+
+// GatewayKey 网关标识：TCP为 报文格式://host:port，串口为 rtu://设备路径。
+// 同一标识只保留一个调度器，串口参数不同也不会同时打开同一串口
+func GatewayKey(cfg Config) string {
+	framing := cfg.Framing
+	if framing == "" {
+		framing = FramingMBAP
+	}
+	if framing == FramingRTU {
+		return fmt.Sprintf("%s://%s", framing, cfg.Serial.Device)
+	}
+	return fmt.Sprintf("%s://%s", framing, cfg.Address)
+}
+
+// Gateway 获取（必要时创建）指定端口的调度器。串口参数、超时与现有调度器不同时
+// （如修改了设备的波特率），关闭旧调度器并按新配置重建，旧调度器排队中的请求返回 ErrGatewayClosed。
+// 端口被独占会话以其他参数使用时不重建，返回的调度器对所有请求返回 ErrGatewayBusy
+func (p *GatewayPool) Gateway(cfg Config) *Gateway {
+	cfg = poolConfig(cfg)
+	key := GatewayKey(cfg)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if g, ok := p.gateways[key]; ok {
+		if g.requested == cfg {
+			return g
+		}
+		if _, reserved := p.reserved[key]; reserved {
+			return &Gateway{key: key, cfg: cfg, requested: cfg, busy: true, closed: true}
+		}
+		g.Close()
+	}
+	return p.create(key, cfg)
+}
+
+// Reserve 独占指定端口，按 cfg 获取（必要时重建）调度器。会话期间配置相同的调用方照常共用调度器、
+// 按优先级排队；配置不同的调用方（如按工作波特率轮询的后台任务）不会重建调度器，请求返回 ErrGatewayBusy。
+// 端口已被独占时返回 ErrGatewayBusy
+func (p *GatewayPool) Reserve(cfg Config) (*GatewaySession, error) {
+	cfg = poolConfig(cfg)
+	key := GatewayKey(cfg)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, reserved := p.reserved[key]; reserved {
+		return nil, ErrGatewayBusy
+	}
+	g, ok := p.gateways[key]
+	if !ok || g.requested != cfg {
+		if ok {
+			g.Close()
+		}
+		g = p.create(key, cfg)
+	}
+	session := &GatewaySession{pool: p, key: key, gateway: g}
+	p.reserved[key] = session
+	return session, nil
+}
+
+func (p *GatewayPool) create(key string, cfg Config) *Gateway {
+	g := newGateway(key, cfg, p.opts)
+	p.gateways[key] = g
+	return g
+}
+
+// Evict 关闭并移除指定端口的调度器，下次访问时重新连接。端口被独占时不处理，由会话结束时决定
+func (p *GatewayPool) Evict(cfg Config) {
+	key := GatewayKey(poolConfig(cfg))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, reserved := p.reserved[key]; reserved {
+		return
+	}
+	if g, ok := p.gateways[key]; ok {
+		g.Close()
+		delete(p.gateways, key)
+	}
+}
+
+// GatewaySession 端口独占会话，见 GatewayPool.Reserve
+type GatewaySession struct {
+	pool    *GatewayPool
+	key     string
+	gateway *Gateway
+
+	mu             sync.Mutex
+	closeOnRelease bool
+	released       bool
+}
+
+// Transport 返回会话调度器上以固定优先级提交请求的传输层视图
+func (s *GatewaySession) Transport(priority Priority) Transport {
+	return s.gateway.Transport(priority)
+}
+
+// TransportWithTimeout 返回会话调度器上使用指定单帧响应超时的传输层视图
+func (s *GatewaySession) TransportWithTimeout(priority Priority, timeout time.Duration) Transport {
+	return s.gateway.TransportWithTimeout(priority, timeout)
+}
+
+// CloseOnRelease 会话结束时关闭调度器，如设备已切换波特率、链路上可能残留错位字节，
+// 其他调用方下次访问时重新连接
+func (s *GatewaySession) CloseOnRelease() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeOnRelease = true
+}
+
+// Release 结束独占，之后配置不同的调用方按各自的配置重建调度器。可重复调用
+func (s *GatewaySession) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.released {
+		return
+	}
+	s.released = true
+
+	s.pool.mu.Lock()
+	defer s.pool.mu.Unlock()
+	delete(s.pool.reserved, s.key)
+	if s.closeOnRelease {
+		s.gateway.Close()
+		if s.pool.gateways[s.key] == s.gateway {
+			delete(s.pool.gateways, s.key)
+		}
+	}
+}
+
+// poolConfig 填充报文格式和串口参数的默认值，使等价配置比较结果一致
+func poolConfig(cfg Config) Config {
+	if cfg.Framing == "" {
+		cfg.Framing = FramingMBAP
+	}
+	if cfg.Framing == FramingRTU {
+		if serial, err := cfg.Serial.normalize(); err == nil {
+			cfg.Serial = serial
+		}
+	}
+	return cfg
+}
+
+// Stats 返回所有网关的调度指标
+func (p *GatewayPool) Stats() []GatewayStats {
+	p.mu.Lock()
+	list := make([]*Gateway, 0, len(p.gateways))
+	for _, g := range p.gateways {
+		list = append(list, g)
+	}
+	p.mu.Unlock()
+
+	stats := make([]GatewayStats, 0, len(list))
+	for _, g := range list {
+		stats = append(stats, g.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats
+}
+
+// Close 关闭所有网关调度器
+func (p *GatewayPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, g := range p.gateways {
+		g.Close()
+		delete(p.gateways, key)
+	}
+}
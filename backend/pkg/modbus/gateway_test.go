@@ -0,0 +1,200 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGatewayControlBeforePoll 控制命令应插到排队中的轮询请求之前，且所有请求复用同一连接
+func TestGatewayControlBeforePoll(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	var (
+		mu      sync.Mutex
+		order   []byte
+		accepts int
+	)
+	first := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepts++
+			mu.Unlock()
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for n := 0; ; n++ {
+					req := make([]byte, 12)
+					if _, err := io.ReadFull(conn, req); err != nil {
+						return
+					}
+					mu.Lock()
+					order = append(order, req[7])
+					mu.Unlock()
+					if n == 0 {
+						close(first)
+						<-release
+					}
+					// FC03/FC05 的请求与响应长度一致，直接回显
+					resp := append([]byte(nil), req...)
+					if req[7] == 0x03 {
+						resp = append(resp[:8], 0x02, 0x00, 0x01)
+						binary.BigEndian.PutUint16(resp[4:6], 5)
+					}
+					conn.Write(resp)
+				}
+			}(conn)
+		}
+	}()
+
+	pool := NewGatewayPool(GatewayOptions{FrameGap: time.Millisecond, Timeout: time.Second})
+	defer pool.Close()
+	g := pool.Gateway(Config{Address: ln.Addr().String()})
+
+	poll := NewModbusClientWithTransport(g.Transport(PriorityPoll), 1)
+	control := NewModbusClientWithTransport(g.Transport(PriorityControl), 1)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() { defer wg.Done(); poll.ReadHoldingRegisters(0, 1) }()
+	<-first
+
+	go func() { defer wg.Done(); poll.ReadHoldingRegisters(0, 1) }()
+	require.Eventually(t, func() bool { return g.Stats().QueueDepth == 1 }, time.Second, time.Millisecond)
+	go func() { defer wg.Done(); control.WriteSingleCoil(1, true) }()
+	require.Eventually(t, func() bool { return g.Stats().QueueDepth == 2 }, time.Second, time.Millisecond)
+
+	close(release)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []byte{0x03, 0x05, 0x03}, order)
+	assert.Equal(t, 1, accepts)
+
+	stats := g.Stats()
+	assert.Equal(t, uint64(3), stats.Requests)
+	assert.Equal(t, uint64(0), stats.Failures)
+	assert.True(t, stats.Connected)
+}
+
+// TestGatewayKeepsConnection 响应超时不断开长连接，空闲检查也不能关闭正在等待响应的连接
+func TestGatewayKeepsConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	var (
+		mu      sync.Mutex
+		accepts int
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			accepts++
+			mu.Unlock()
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					req := make([]byte, 12)
+					if _, err := io.ReadFull(conn, req); err != nil {
+						return
+					}
+					// 站号2不应答；站号1延时应答，超过空闲检查周期
+					if req[6] == 2 {
+						continue
+					}
+					time.Sleep(150 * time.Millisecond)
+					conn.Write(req)
+				}
+			}(conn)
+		}
+	}()
+
+	pool := NewGatewayPool(GatewayOptions{FrameGap: time.Millisecond, IdleTimeout: 40 * time.Millisecond, Timeout: time.Second})
+	defer pool.Close()
+	g := pool.Gateway(Config{Address: ln.Addr().String()})
+	require.NoError(t, g.Connect(PriorityNormal))
+
+	_, err = NewModbusClientWithTransport(g.TransportWithTimeout(PriorityNormal, 50*time.Millisecond), 2).ReadHoldingRegisters(0, 1)
+	require.Error(t, err)
+	assert.True(t, IsTimeout(err))
+	assert.True(t, g.Stats().Connected)
+
+	require.NoError(t, NewModbusClientWithTransport(g.Transport(PriorityControl), 1).WriteSingleCoil(1, true))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, accepts)
+	stats := g.Stats()
+	assert.Equal(t, uint64(1), stats.Reconnects)
+	assert.Equal(t, uint64(1), stats.Timeouts)
+}
+
+// TestGatewayPoolReconfigure 串口参数变化时重建调度器，等价配置复用同一调度器
+func TestGatewayPoolReconfigure(t *testing.T) {
+	pool := NewGatewayPool(GatewayOptions{Timeout: time.Second})
+	defer pool.Close()
+
+	cfg := Config{Framing: FramingRTU, Serial: SerialConfig{Device: "/dev/ttyUSB9"}}
+	g := pool.Gateway(cfg)
+	assert.Same(t, g, pool.Gateway(Config{Framing: FramingRTU, Serial: SerialConfig{Device: "/dev/ttyUSB9", BaudRate: 9600, Parity: "n"}}))
+
+	cfg.Serial.BaudRate = 19200
+	rebuilt := pool.Gateway(cfg)
+	assert.NotSame(t, g, rebuilt)
+	_, err := g.Do(PriorityNormal, 1, []byte{0x03, 0, 0, 0, 1})
+	assert.ErrorIs(t, err, ErrGatewayClosed)
+	assert.Equal(t, 19200, rebuilt.cfg.Serial.BaudRate)
+	assert.Len(t, pool.Stats(), 1)
+
+	pool.Evict(cfg)
+	assert.Empty(t, pool.Stats())
+	assert.NotSame(t, rebuilt, pool.Gateway(cfg))
+}
+
+// TestGatewayPoolReserve 独占期间配置相同的调用方共用调度器，配置不同的调用方不重建调度器、请求返回忙
+func TestGatewayPoolReserve(t *testing.T) {
+	pool := NewGatewayPool(GatewayOptions{Timeout: time.Second})
+	defer pool.Close()
+
+	working := Config{Framing: FramingRTU, Serial: SerialConfig{Device: "/dev/ttyUSB9", BaudRate: 19200}}
+	scan := Config{Framing: FramingRTU, Serial: SerialConfig{Device: "/dev/ttyUSB9", BaudRate: 9600}}
+	polling := pool.Gateway(working)
+
+	session, err := pool.Reserve(scan)
+	require.NoError(t, err)
+	_, err = pool.Reserve(scan)
+	assert.ErrorIs(t, err, ErrGatewayBusy)
+
+	reserved := pool.Gateway(scan)
+	assert.NotSame(t, polling, reserved)
+	_, err = pool.Gateway(working).Do(PriorityPoll, 1, []byte{0x04, 0, 0, 0, 1})
+	assert.ErrorIs(t, err, ErrGatewayBusy)
+	assert.Same(t, reserved, pool.Gateway(scan), "轮询没有重建独占中的调度器")
+
+	session.CloseOnRelease()
+	session.Release()
+	session.Release()
+	_, err = reserved.Do(PriorityNormal, 1, []byte{0x03, 0, 0, 0, 1})
+	assert.ErrorIs(t, err, ErrGatewayClosed)
+	assert.Equal(t, 19200, pool.Gateway(working).cfg.Serial.BaudRate)
+}
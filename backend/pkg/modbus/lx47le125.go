@@ -0,0 +1,90 @@
+package modbus
+
+// LX47LE-125 智能断路器测点名称
+const (
+	LX47Status         = "status"              // 30001 高字节本地锁定，低字节 0xF0合闸/0x0F分闸
+	LX47TripRecords1   = "trip_records_1"      // 30002 跳闸记录1-4
+	LX47TripRecords2   = "trip_records_2"      // 30003 跳闸记录5-8
+	LX47TripRecords3   = "trip_records_3"      // 30004 跳闸记录9-12
+	LX47Frequency      = "frequency"           // 30005
+	LX47LeakageCurrent = "leakage_current"     // 30006
+	LX47Temperature    = "temperature"         // 30007
+	LX47Voltage        = "voltage"             // 30008
+	LX47Current        = "current"             // 30009
+	LX47PowerFactor    = "power_factor"        // 30011
+	LX47ActivePower    = "active_power"        // 30012
+	LX47ReactivePower  = "reactive_power"      // 30013
+	LX47Energy         = "energy"              // 30014-30015
+	LX47LatestTrip     = "latest_trip"         // 30024
+	LX47StationAddress = "station_address"     // 40001
+	LX47BaudRate       = "baud_rate"           // 40002
+	LX47OverVoltage    = "over_voltage"        // 40003
+	LX47UnderVoltage   = "under_voltage"       // 40004
+	LX47OverCurrent    = "over_current"        // 40005
+	LX47LeakageLimit   = "leakage_limit"       // 40006
+	LX47OverTemp       = "over_temp"           // 40007
+	LX47OverloadPower  = "overload_power"      // 40008
+	LX47ControlFlags   = "control_flags"       // 40013 bit0自动合闸，bit1远程锁定
+	LX47RemoteSwitch   = "remote_switch"       // 40014
+	LX47ProtectEnable  = "protect_enable"      // 40016
+	LX47OverVoltDelay  = "over_voltage_delay"  // 40017 0.1s
+	LX47UnderVoltDelay = "under_voltage_delay" // 40018 0.1s
+	LX47LeakageDelay   = "leakage_delay"       // 40019 0.1s
+	LX47OverCurrDelay  = "over_current_delay"  // 40020 0.1s
+	LX47OverloadDelay  = "overload_delay"      // 40021 0.1s
+)
+
+// LX47LE125Map LX47LE-125 寄存器表（依据设备MODBUS协议手册）
+var LX47LE125Map = &RegisterMap{
+	Model:  "LX47LE-125",
+	MaxGap: 4,
+	Registers: []RegisterDef{
+		{Name: LX47Status, Address: 30001, Label: "分合闸/本地锁定状态"},
+		{Name: LX47TripRecords1, Address: 30002, Label: "跳闸记录1-4"},
+		{Name: LX47TripRecords2, Address: 30003, Label: "跳闸记录5-8"},
+		{Name: LX47TripRecords3, Address: 30004, Label: "跳闸记录9-12"},
+		{Name: LX47Frequency, Address: 30005, Scale: 0.1, Unit: "Hz", Label: "频率"},
+		{Name: LX47LeakageCurrent, Address: 30006, Unit: "mA", Label: "漏电流"},
+		{Name: LX47Temperature, Address: 30007, Offset: -40, Unit: "°C", Label: "N相温度"},
+		{Name: LX47Voltage, Address: 30008, Unit: "V", Label: "A相电压"},
+		{Name: LX47Current, Address: 30009, Scale: 0.01, Unit: "A", Label: "A相电流"},
+		{Name: LX47PowerFactor, Address: 30011, Scale: 0.01, Label: "功率因数"},
+		{Name: LX47ActivePower, Address: 30012, Unit: "W", Label: "有功功率"},
+		{Name: LX47ReactivePower, Address: 30013, Unit: "var", Label: "无功功率"},
+		{Name: LX47Energy, Address: 30014, Words: 2, Scale: 0.001, Unit: "kWh", Label: "累计电能"},
+		{Name: LX47LatestTrip, Address: 30024, Label: "最近跳闸原因"},
+
+		{Name: LX47StationAddress, Address: 40001, Label: "站号"},
+		{Name: LX47BaudRate, Address: 40002, Label: "波特率"},
+		{Name: LX47OverVoltage, Address: 40003, Unit: "V", Label: "过压阈值"},
+		{Name: LX47UnderVoltage, Address: 40004, Unit: "V", Label: "欠压阈值"},
+		{Name: LX47OverCurrent, Address: 40005, Scale: 0.01, Unit: "A", Label: "过流阈值"},
+		{Name: LX47LeakageLimit, Address: 40006, Unit: "mA", Label: "漏电阈值"},
+		{Name: LX47OverTemp, Address: 40007, Unit: "°C", Label: "过温阈值"},
+		{Name: LX47OverloadPower, Address: 40008, Unit: "W", Label: "过载功率阈值"},
+		{Name: LX47ControlFlags, Address: 40013, Label: "自动合闸/远程锁定"},
+		{Name: LX47RemoteSwitch, Address: 40014, Label: "远程分合闸"},
+		{Name: LX47ProtectEnable, Address: 40016, Label: "保护使能"},
+		{Name: LX47OverVoltDelay, Address: 40017, Scale: 0.1, Unit: "s", Label: "过压动作延时"},
+		{Name: LX47UnderVoltDelay, Address: 40018, Scale: 0.1, Unit: "s", Label: "欠压动作延时"},
+		{Name: LX47LeakageDelay, Address: 40019, Scale: 0.1, Unit: "s", Label: "漏电动作延时"},
+		{Name: LX47OverCurrDelay, Address: 40020, Scale: 0.1, Unit: "s", Label: "过流动作延时"},
+		{Name: LX47OverloadDelay, Address: 40021, Scale: 0.1, Unit: "s", Label: "过载动作延时"},
+	},
+}
+
+// LX47TelemetryPoints 实时遥测快照需要的测点：输入寄存器 30001-30015 与保持寄存器 40005-40007 各一帧
+var LX47TelemetryPoints = []string{
+	LX47Status,
+	LX47Frequency,
+	LX47LeakageCurrent,
+	LX47Temperature,
+	LX47Voltage,
+	LX47Current,
+	LX47PowerFactor,
+	LX47ActivePower,
+	LX47Energy,
+	LX47OverCurrent,
+	LX47LeakageLimit,
+	LX47OverTemp,
+}
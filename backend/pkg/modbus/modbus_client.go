@@ -4,39 +4,20 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 )
 
-// ModbusClient Modbus TCP客户端
+// ModbusClient Modbus客户端，通过Transport支持MBAP、RTU over TCP和串口RTU
 type ModbusClient struct {
-	host       string
-	port       int
-	conn       net.Conn
-	timeout    time.Duration
-	unitID     byte
-	transID    uint16
-	connected  bool
-}
-
-// ModbusRequest Modbus请求结构
-type ModbusRequest struct {
-	TransactionID uint16
-	ProtocolID    uint16
-	Length        uint16
-	UnitID        byte
-	FunctionCode  byte
-	Data          []byte
-}
-
-// ModbusResponse Modbus响应结构
-type ModbusResponse struct {
-	TransactionID uint16
-	ProtocolID    uint16
-	Length        uint16
-	UnitID        byte
-	FunctionCode  byte
-	Data          []byte
-	Error         error
+	host      string
+	port      int
+	framing   Framing
+	serial    SerialConfig
+	transport Transport
+	timeout   time.Duration
+	unitID    byte
+	connected bool
 }
 
 // TemperatureData 温度数据结构
@@ -58,124 +39,127 @@ type BreakerData struct {
 	Timestamp int64   `json:"timestamp"`
 }
 
-// NewModbusClient 创建新的Modbus客户端
+// NewModbusClient 创建新的Modbus TCP客户端
 func NewModbusClient(host string, port int, unitID byte) *ModbusClient {
 	return &ModbusClient{
 		host:      host,
 		port:      port,
+		framing:   FramingMBAP,
 		unitID:    unitID,
 		timeout:   5 * time.Second,
-		transID:   1,
 		connected: false,
 	}
 }
 
+// NewModbusClientWithTransport 在已有传输层上创建客户端（连接由调用方管理）
+func NewModbusClientWithTransport(transport Transport, unitID byte) *ModbusClient {
+	return &ModbusClient{
+		transport: transport,
+		unitID:    unitID,
+		timeout:   5 * time.Second,
+		connected: true,
+	}
+}
+
+// SetFraming 设置报文格式，需在Connect之前调用
+func (c *ModbusClient) SetFraming(framing Framing) {
+	c.framing = framing
+}
+
+// SetSerial 设置串口参数（报文格式为串口RTU时使用）
+func (c *ModbusClient) SetSerial(serial SerialConfig) {
+	c.serial = serial
+}
+
 // Connect 连接到Modbus设备
 func (c *ModbusClient) Connect() error {
 	if c.connected {
 		return nil
 	}
 
-	address := fmt.Sprintf("%s:%d", c.host, c.port)
-	conn, err := net.DialTimeout("tcp", address, c.timeout)
+	transport, err := Open(Config{
+		Framing: c.framing,
+		Address: net.JoinHostPort(c.host, strconv.Itoa(c.port)),
+		Serial:  c.serial,
+		Timeout: c.timeout,
+	})
 	if err != nil {
-		return fmt.Errorf("连接Modbus设备失败: %v", err)
+		return err
 	}
 
-	c.conn = conn
+	c.transport = transport
 	c.connected = true
 	return nil
 }
 
 // Disconnect 断开连接
 func (c *ModbusClient) Disconnect() error {
-	if !c.connected || c.conn == nil {
+	if !c.connected || c.transport == nil {
 		return nil
 	}
 
-	err := c.conn.Close()
+	err := c.transport.Close()
 	c.connected = false
-	c.conn = nil
+	c.transport = nil
 	return err
 }
 
 // IsConnected 检查连接状态
 func (c *ModbusClient) IsConnected() bool {
-	return c.connected && c.conn != nil
+	return c.connected && c.transport != nil
 }
 
-// ReadHoldingRegisters 读取保持寄存器
-func (c *ModbusClient) ReadHoldingRegisters(startAddr uint16, quantity uint16) ([]uint16, error) {
-	if !c.connected {
-		if err := c.Connect(); err != nil {
-			return nil, err
-		}
-	}
-
-	// 构建Modbus请求
-	request := &ModbusRequest{
-		TransactionID: c.getNextTransID(),
-		ProtocolID:    0,
-		Length:        6,
-		UnitID:        c.unitID,
-		FunctionCode:  0x03, // 读取保持寄存器
-		Data:          make([]byte, 4),
-	}
-
-	// 设置起始地址和数量
-	binary.BigEndian.PutUint16(request.Data[0:2], startAddr)
-	binary.BigEndian.PutUint16(request.Data[2:4], quantity)
-
-	// 发送请求
-	response, err := c.sendRequest(request)
+// ReadCoils 读取线圈 (功能码01)
+func (c *ModbusClient) ReadCoils(startAddr uint16, quantity uint16) ([]bool, error) {
+	resp, err := c.send(0x01, uint16Bytes(startAddr, quantity))
 	if err != nil {
 		return nil, err
 	}
-
-	// 解析响应
-	if len(response.Data) < 1 {
-		return nil, fmt.Errorf("响应数据长度不足")
-	}
-
-	byteCount := response.Data[0]
-	if len(response.Data) < int(1+byteCount) {
+	if len(resp) < 2 || int(resp[1]) < int(quantity+7)/8 || len(resp) < 2+int(resp[1]) {
 		return nil, fmt.Errorf("响应数据不完整")
 	}
 
-	// 解析寄存器值
-	registers := make([]uint16, quantity)
+	coils := make([]bool, quantity)
 	for i := uint16(0); i < quantity; i++ {
-		offset := 1 + i*2
-		registers[i] = binary.BigEndian.Uint16(response.Data[offset : offset+2])
+		coils[i] = resp[2+i/8]&(1<<(i%8)) != 0
 	}
+	return coils, nil
+}
 
-	return registers, nil
+// ReadHoldingRegisters 读取保持寄存器 (功能码03)
+func (c *ModbusClient) ReadHoldingRegisters(startAddr uint16, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(0x03, startAddr, quantity)
 }
 
-// WriteSingleRegister 写入单个寄存器
-func (c *ModbusClient) WriteSingleRegister(addr uint16, value uint16) error {
-	if !c.connected {
-		if err := c.Connect(); err != nil {
-			return err
-		}
-	}
+// ReadInputRegisters 读取输入寄存器 (功能码04)
+func (c *ModbusClient) ReadInputRegisters(startAddr uint16, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(0x04, startAddr, quantity)
+}
 
-	// 构建Modbus请求
-	request := &ModbusRequest{
-		TransactionID: c.getNextTransID(),
-		ProtocolID:    0,
-		Length:        6,
-		UnitID:        c.unitID,
-		FunctionCode:  0x06, // 写入单个寄存器
-		Data:          make([]byte, 4),
+// WriteSingleCoil 写单个线圈 (功能码05)
+func (c *ModbusClient) WriteSingleCoil(addr uint16, on bool) error {
+	value := uint16(0x0000)
+	if on {
+		value = 0xFF00
 	}
+	_, err := c.send(0x05, uint16Bytes(addr, value))
+	return err
+}
 
-	// 设置地址和值
-	binary.BigEndian.PutUint16(request.Data[0:2], addr)
-	binary.BigEndian.PutUint16(request.Data[2:4], value)
+// WriteSingleRegister 写入单个寄存器 (功能码06)
+func (c *ModbusClient) WriteSingleRegister(addr uint16, value uint16) error {
+	_, err := c.send(0x06, uint16Bytes(addr, value))
+	return err
+}
 
-	// 发送请求
-	_, err := c.sendRequest(request)
+// WriteMultipleRegisters 写多个保持寄存器 (功能码16)
+func (c *ModbusClient) WriteMultipleRegisters(startAddr uint16, values []uint16) error {
+	data := uint16Bytes(startAddr, uint16(len(values)))
+	data = append(data, byte(len(values)*2))
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint16(data, v)
+	}
+	_, err := c.send(0x10, data)
 	return err
 }
 
@@ -275,64 +259,44 @@ func (c *ModbusClient) ControlBreaker(breakerID string, action string) error {
 	return nil
 }
 
-// sendRequest 发送Modbus请求
-func (c *ModbusClient) sendRequest(request *ModbusRequest) (*ModbusResponse, error) {
-	// 构建MBAP头部
-	mbap := make([]byte, 7)
-	binary.BigEndian.PutUint16(mbap[0:2], request.TransactionID)
-	binary.BigEndian.PutUint16(mbap[2:4], request.ProtocolID)
-	binary.BigEndian.PutUint16(mbap[4:6], request.Length)
-	mbap[6] = request.UnitID
-
-	// 构建PDU
-	pdu := append([]byte{request.FunctionCode}, request.Data...)
-
-	// 发送请求
-	packet := append(mbap, pdu...)
-	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
-	_, err := c.conn.Write(packet)
+// readRegisters 读取寄存器并解析为uint16数组
+func (c *ModbusClient) readRegisters(functionCode byte, startAddr uint16, quantity uint16) ([]uint16, error) {
+	resp, err := c.send(functionCode, uint16Bytes(startAddr, quantity))
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %v", err)
+		return nil, err
 	}
 
-	// 读取响应
-	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
-	buffer := make([]byte, 256)
-	n, err := c.conn.Read(buffer)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %v", err)
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("响应数据长度不足")
 	}
-
-	if n < 7 {
-		return nil, fmt.Errorf("响应长度不足")
+	byteCount := int(resp[1])
+	if byteCount != int(quantity)*2 || len(resp) < 2+byteCount {
+		return nil, fmt.Errorf("响应数据不完整")
 	}
 
-	// 解析响应
-	response := &ModbusResponse{
-		TransactionID: binary.BigEndian.Uint16(buffer[0:2]),
-		ProtocolID:    binary.BigEndian.Uint16(buffer[2:4]),
-		Length:        binary.BigEndian.Uint16(buffer[4:6]),
-		UnitID:        buffer[6],
-		FunctionCode:  buffer[7],
-		Data:          buffer[8:n],
+	registers := make([]uint16, quantity)
+	for i := range registers {
+		registers[i] = binary.BigEndian.Uint16(resp[2+i*2:])
 	}
+	return registers, nil
+}
 
-	// 检查错误
-	if response.FunctionCode&0x80 != 0 {
-		errorCode := response.Data[0]
-		return nil, fmt.Errorf("Modbus错误: %d", errorCode)
+// send 发送请求PDU并返回响应PDU
+func (c *ModbusClient) send(functionCode byte, data []byte) ([]byte, error) {
+	if !c.connected {
+		if err := c.Connect(); err != nil {
+			return nil, err
+		}
 	}
-
-	return response, nil
+	return c.transport.Send(c.unitID, append([]byte{functionCode}, data...))
 }
 
-// getNextTransID 获取下一个事务ID
-func (c *ModbusClient) getNextTransID() uint16 {
-	c.transID++
-	if c.transID == 0 {
-		c.transID = 1
+func uint16Bytes(values ...uint16) []byte {
+	buf := make([]byte, 0, len(values)*2)
+	for _, v := range values {
+		buf = binary.BigEndian.AppendUint16(buf, v)
 	}
-	return c.transID
+	return buf
 }
 
 // SetTimeout 设置超时时间
@@ -346,6 +310,7 @@ func (c *ModbusClient) GetConnectionInfo() map[string]interface{} {
 		"host":      c.host,
 		"port":      c.port,
 		"unit_id":   c.unitID,
+		"framing":   c.framing,
 		"connected": c.connected,
 		"timeout":   c.timeout.String(),
 	}
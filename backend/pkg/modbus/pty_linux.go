@@ -0,0 +1,44 @@
+//go:build linux
+
+package modbus
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// OpenPTY 创建一对伪终端，返回主端和从端设备路径（如 /dev/pts/3）。
+// 从端可以像真实串口一样用 rtu 报文格式打开，主端由模拟从站读写，用于无硬件联调和测试。
+func OpenPTY() (*os.File, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, "", fmt.Errorf("解锁伪终端失败: %w", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, "", fmt.Errorf("获取伪终端编号失败: %w", err)
+	}
+
+	// 主端保持原始模式，避免行规程改写二进制帧
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err == nil {
+		t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+		t.Oflag &^= unix.OPOST
+		t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+		err = unix.IoctlSetTermios(fd, unix.TCSETS, t)
+	}
+	if err != nil {
+		master.Close()
+		return nil, "", fmt.Errorf("设置伪终端参数失败: %w", err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package modbus
+
+import (
+	"fmt"
+	"os"
+)
+
+// OpenPTY 当前仅支持Linux伪终端
+func OpenPTY() (*os.File, string, error) {
+	return nil, "", fmt.Errorf("当前平台不支持伪终端")
+}
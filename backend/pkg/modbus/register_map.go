@@ -0,0 +1,265 @@
+package modbus
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RegisterTable 寄存器所在的数据表
+type RegisterTable int
+
+const (
+	InputRegisters   RegisterTable = iota // 3xxxx，功能码04
+	HoldingRegisters                      // 4xxxx，功能码03
+)
+
+func (t RegisterTable) String() string {
+	if t == HoldingRegisters {
+		return "holding"
+	}
+	return "input"
+}
+
+// functionCode 读取该数据表使用的功能码
+func (t RegisterTable) functionCode() byte {
+	if t == HoldingRegisters {
+		return 0x03
+	}
+	return 0x04
+}
+
+// RegisterDef 单个测点的寄存器定义。
+// Address 使用设备手册中的地址（如 30008、40005），工程值 = 原始值*Scale + Offset。
+type RegisterDef struct {
+	Name    string  `json:"name"`
+	Address uint16  `json:"address"`
+	Words   int     `json:"words"` // 占用寄存器数，1或2（32位，高字在前）
+	Scale   float64 `json:"scale"`
+	Offset  float64 `json:"offset"`
+	Unit    string  `json:"unit"`
+	Signed  bool    `json:"signed"`
+	Label   string  `json:"label"`
+}
+
+// Table 根据手册地址判断数据表
+func (d RegisterDef) Table() RegisterTable {
+	if d.Address >= 40001 {
+		return HoldingRegisters
+	}
+	return InputRegisters
+}
+
+// offset 协议地址：30001 -> 0x0000，40001 -> 0x0000
+func (d RegisterDef) offset() uint16 {
+	if d.Table() == HoldingRegisters {
+		return d.Address - 40001
+	}
+	return d.Address - 30001
+}
+
+func (d RegisterDef) words() int {
+	if d.Words == 2 {
+		return 2
+	}
+	return 1
+}
+
+// decode 把原始寄存器值转换为工程值
+func (d RegisterDef) decode(regs []uint16) (raw uint32, value float64) {
+	if d.words() == 2 {
+		raw = uint32(regs[0])<<16 | uint32(regs[1])
+		if d.Signed {
+			value = float64(int32(raw))
+		} else {
+			value = float64(raw)
+		}
+	} else {
+		raw = uint32(regs[0])
+		if d.Signed {
+			value = float64(int16(regs[0]))
+		} else {
+			value = float64(regs[0])
+		}
+	}
+	scale := d.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return raw, value*scale + d.Offset
+}
+
+// encode 把工程值转换为原始寄存器值（四舍五入到寄存器分辨率）
+func (d RegisterDef) encode(value float64) ([]uint16, error) {
+	scale := d.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	raw := math.Round((value - d.Offset) / scale)
+
+	minRaw, maxRaw := 0.0, float64(math.MaxUint16)
+	switch {
+	case d.words() == 2 && d.Signed:
+		minRaw, maxRaw = math.MinInt32, math.MaxInt32
+	case d.words() == 2:
+		maxRaw = math.MaxUint32
+	case d.Signed:
+		minRaw, maxRaw = math.MinInt16, math.MaxInt16
+	}
+	if raw < minRaw || raw > maxRaw {
+		return nil, fmt.Errorf("测点 %s 的值 %v 超出寄存器范围", d.Name, value)
+	}
+
+	if d.words() == 2 {
+		v := uint32(int64(raw))
+		return []uint16{uint16(v >> 16), uint16(v)}, nil
+	}
+	return []uint16{uint16(int32(raw))}, nil
+}
+
+// RegisterMap 设备型号的寄存器表
+type RegisterMap struct {
+	Model     string
+	Registers []RegisterDef
+	// MaxGap 合并读取时允许跨越的未使用寄存器数，多读几个寄存器比多发一帧划算
+	MaxGap uint16
+	// MaxCount 单帧最多读取的寄存器数（协议上限125，部分网关更小）
+	MaxCount uint16
+}
+
+// Lookup 按名称查找测点定义
+func (m *RegisterMap) Lookup(name string) (RegisterDef, bool) {
+	for _, def := range m.Registers {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return RegisterDef{}, false
+}
+
+// ReadBlock 合并后的一次批量读取
+type ReadBlock struct {
+	Table RegisterTable
+	Start uint16 // 协议地址
+	Count uint16
+	Defs  []RegisterDef
+}
+
+// Plan 把要读取的测点按数据表分组，并把相邻地址合并为尽量少的批量读取。
+// names 为空时读取寄存器表中的全部测点。
+func (m *RegisterMap) Plan(names ...string) ([]ReadBlock, error) {
+	defs := m.Registers
+	if len(names) > 0 {
+		defs = make([]RegisterDef, 0, len(names))
+		for _, name := range names {
+			def, ok := m.Lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("寄存器表 %s 中不存在测点: %s", m.Model, name)
+			}
+			defs = append(defs, def)
+		}
+	}
+
+	maxCount := m.MaxCount
+	if maxCount == 0 || maxCount > 125 {
+		maxCount = 125
+	}
+
+	sorted := append([]RegisterDef(nil), defs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Table() != sorted[j].Table() {
+			return sorted[i].Table() < sorted[j].Table()
+		}
+		return sorted[i].offset() < sorted[j].offset()
+	})
+
+	var blocks []ReadBlock
+	for _, def := range sorted {
+		start, end := def.offset(), def.offset()+uint16(def.words())
+		if n := len(blocks); n > 0 {
+			last := &blocks[n-1]
+			lastEnd := last.Start + last.Count
+			if last.Table == def.Table() && start <= lastEnd+m.MaxGap && end-last.Start <= maxCount {
+				if end > lastEnd {
+					last.Count = end - last.Start
+				}
+				last.Defs = append(last.Defs, def)
+				continue
+			}
+		}
+		blocks = append(blocks, ReadBlock{
+			Table: def.Table(),
+			Start: start,
+			Count: end - start,
+			Defs:  []RegisterDef{def},
+		})
+	}
+	return blocks, nil
+}
+
+// Reading 单个测点的读数
+type Reading struct {
+	Def   RegisterDef
+	Raw   uint32
+	Value float64
+}
+
+// Snapshot 一次批量读取得到的测点读数
+type Snapshot map[string]Reading
+
+// Value 工程值
+func (s Snapshot) Value(name string) float64 {
+	return s[name].Value
+}
+
+// Raw 原始寄存器值
+func (s Snapshot) Raw(name string) uint32 {
+	return s[name].Raw
+}
+
+// ReadRegisterMap 按寄存器表合并读取指定测点，每个ReadBlock只发送一帧
+func (c *ModbusClient) ReadRegisterMap(m *RegisterMap, names ...string) (Snapshot, error) {
+	blocks, err := m.Plan(names...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(Snapshot)
+	for _, block := range blocks {
+		regs, err := c.readRegisters(block.Table.functionCode(), block.Start, block.Count)
+		if err != nil {
+			return nil, fmt.Errorf("读取%s寄存器 %d-%d 失败: %w", block.Table, block.Start, block.Start+block.Count-1, err)
+		}
+		for _, def := range block.Defs {
+			i := def.offset() - block.Start
+			raw, value := def.decode(regs[i : int(i)+def.words()])
+			snapshot[def.Name] = Reading{Def: def, Raw: raw, Value: value}
+		}
+	}
+	return snapshot, nil
+}
+
+// WriteRegisterMap 按寄存器表写入单个保持寄存器测点，单字用功能码06，双字用功能码16
+func (c *ModbusClient) WriteRegisterMap(m *RegisterMap, name string, value float64) error {
+	def, ok := m.Lookup(name)
+	if !ok {
+		return fmt.Errorf("寄存器表 %s 中不存在测点: %s", m.Model, name)
+	}
+	if def.Table() != HoldingRegisters {
+		return fmt.Errorf("测点 %s 不是保持寄存器，不可写", name)
+	}
+
+	regs, err := def.encode(value)
+	if err != nil {
+		return err
+	}
+	if len(regs) == 1 {
+		err = c.WriteSingleRegister(def.offset(), regs[0])
+	} else {
+		err = c.WriteMultipleRegisters(def.offset(), regs)
+	}
+	if err != nil {
+		return fmt.Errorf("写入%s(%d)失败: %w", def.Label, def.Address, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package modbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMapPlanCoalescesTelemetry(t *testing.T) {
+	blocks, err := LX47LE125Map.Plan(LX47TelemetryPoints...)
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+
+	assert.Equal(t, InputRegisters, blocks[0].Table)
+	assert.Equal(t, uint16(0), blocks[0].Start)
+	assert.Equal(t, uint16(15), blocks[0].Count) // 30001-30015
+
+	assert.Equal(t, HoldingRegisters, blocks[1].Table)
+	assert.Equal(t, uint16(4), blocks[1].Start)
+	assert.Equal(t, uint16(3), blocks[1].Count) // 40005-40007
+}
+
+func TestRegisterMapPlanSplitsLargeGaps(t *testing.T) {
+	blocks, err := LX47LE125Map.Plan(LX47Status, LX47LatestTrip)
+	require.NoError(t, err)
+	assert.Len(t, blocks, 2)
+
+	_, err = LX47LE125Map.Plan("unknown")
+	assert.Error(t, err)
+}
+
+func TestRegisterDefDecode(t *testing.T) {
+	energy, _ := LX47LE125Map.Lookup(LX47Energy)
+	raw, value := energy.decode([]uint16{0x0001, 0x0002})
+	assert.Equal(t, uint32(0x00010002), raw)
+	assert.InDelta(t, 65.538, value, 1e-9)
+
+	temp, _ := LX47LE125Map.Lookup(LX47Temperature)
+	_, value = temp.decode([]uint16{65})
+	assert.Equal(t, 25.0, value)
+
+	signed := RegisterDef{Signed: true, Scale: 0.1}
+	_, value = signed.decode([]uint16{0xFFF6})
+	assert.InDelta(t, -1.0, value, 1e-9)
+}
+
+func TestRegisterDefEncode(t *testing.T) {
+	current, _ := LX47LE125Map.Lookup(LX47OverCurrent)
+	regs, err := current.encode(63)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{6300}, regs)
+
+	delay, _ := LX47LE125Map.Lookup(LX47LeakageDelay)
+	regs, err = delay.encode(0.3)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{3}, regs)
+
+	_, err = delay.encode(-1)
+	assert.Error(t, err)
+
+	energy, _ := LX47LE125Map.Lookup(LX47Energy)
+	regs, err = energy.encode(65.538)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{0x0001, 0x0002}, regs)
+}
@@ -0,0 +1,162 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// replayTimeout 回放数据耗尽时返回的超时错误，与真实连接的读超时一样满足 IsTimeout
+type replayTimeout struct{}
+
+func (replayTimeout) Error() string   { return "回放报文已读完: i/o timeout" }
+func (replayTimeout) Timeout() bool   { return true }
+func (replayTimeout) Temporary() bool { return true }
+
+// ReplayConn 按抓包记录回放的假连接：每次写入请求帧时取出下一条记录，
+// 之后的读取依次返回当时收到的原始字节，读完后返回超时错误。
+// 配合 NewTransport 使用，可以把现场的CRC错误、超时、错位响应原样复现为回归测试。
+type ReplayConn struct {
+	records    []TraceRecord
+	next       int
+	pending    []byte
+	mismatches []string
+}
+
+// NewReplayConn 创建回放连接
+func NewReplayConn(records []TraceRecord) *ReplayConn {
+	return &ReplayConn{records: records}
+}
+
+// Write 接收请求帧，与抓包记录中的请求比较（MBAP事务号除外）并准备对应的响应字节
+func (c *ReplayConn) Write(p []byte) (int, error) {
+	if c.next >= len(c.records) {
+		return 0, fmt.Errorf("回放报文已用完，多余的请求: % X", p)
+	}
+	rec := c.records[c.next]
+	c.next++
+
+	resp := append([]byte(nil), rec.Response...)
+	if rec.Framing == FramingMBAP && len(p) >= 7 && len(rec.Request) >= 7 {
+		if !bytes.Equal(p[2:], rec.Request[2:]) {
+			c.mismatch(rec, p)
+		}
+		// 响应事务号按本次请求重新编号，保留迟到响应与请求之间的相对偏移
+		delta := binary.BigEndian.Uint16(p[0:2]) - binary.BigEndian.Uint16(rec.Request[0:2])
+		renumberMBAP(resp, delta)
+	} else if !bytes.Equal(p, rec.Request) {
+		c.mismatch(rec, p)
+	}
+
+	c.pending = resp
+	return len(p), nil
+}
+
+func (c *ReplayConn) mismatch(rec TraceRecord, got []byte) {
+	c.mismatches = append(c.mismatches, fmt.Sprintf("第 %d 帧请求不一致: 抓包=%s, 回放=%s", rec.Seq, rec.Request, HexBytes(got)))
+}
+
+// Read 返回当前记录剩余的响应字节，读完后返回超时
+func (c *ReplayConn) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		return 0, replayTimeout{}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// SetDeadline 回放不需要真实超时
+func (c *ReplayConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *ReplayConn) Close() error {
+	return nil
+}
+
+// Mismatches 回放过程中与抓包不一致的请求
+func (c *ReplayConn) Mismatches() []string {
+	return c.mismatches
+}
+
+// renumberMBAP 把响应字节中各个MBAP帧的事务号加上 delta，无法解析的部分保持原样
+func renumberMBAP(buf []byte, delta uint16) {
+	for len(buf) >= 7 {
+		tid := binary.BigEndian.Uint16(buf[0:2])
+		binary.BigEndian.PutUint16(buf[0:2], tid+delta)
+		length := int(binary.BigEndian.Uint16(buf[4:6]))
+		if length < 2 || 6+length > len(buf) {
+			return
+		}
+		buf = buf[6+length:]
+	}
+}
+
+// ReplayResult 单帧回放结果
+type ReplayResult struct {
+	Seq      uint64   `json:"seq"`
+	UnitID   byte     `json:"unit_id"`
+	Function byte     `json:"function"`
+	Expected string   `json:"expected"` // 抓包时的结果
+	Got      string   `json:"got"`      // 回放得到的结果
+	Response HexBytes `json:"response"` // 回放解码得到的响应PDU
+	Error    string   `json:"error,omitempty"`
+}
+
+// Match 回放结果与抓包一致
+func (r ReplayResult) Match() bool {
+	return r.Expected == r.Got
+}
+
+// Replay 把抓包记录依次送入基于 ReplayConn 的传输层，返回每一帧的解码结果。
+// 抓包中的请求帧会被还原为站号和PDU重新发送，因此解码逻辑的任何变化都会体现在结果中。
+func Replay(records []TraceRecord) ([]ReplayResult, *ReplayConn, error) {
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("抓包为空")
+	}
+	framing := records[0].Framing
+	conn := NewReplayConn(records)
+	transport := NewTransport(conn, framing, time.Second)
+
+	results := make([]ReplayResult, 0, len(records))
+	for _, rec := range records {
+		if rec.Framing != framing {
+			return results, conn, fmt.Errorf("第 %d 帧报文格式 %s 与首帧 %s 不一致", rec.Seq, rec.Framing, framing)
+		}
+		pdu, err := requestPDU(framing, rec.Request)
+		if err != nil {
+			return results, conn, fmt.Errorf("第 %d 帧: %w", rec.Seq, err)
+		}
+
+		resp, err := transport.Send(rec.UnitID, pdu)
+		result := ReplayResult{
+			Seq:      rec.Seq,
+			UnitID:   rec.UnitID,
+			Function: rec.Function,
+			Expected: rec.Result,
+			Got:      traceResult(rec.UnitID, err),
+			Response: resp,
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, conn, nil
+}
+
+// requestPDU 从请求帧中取出PDU
+func requestPDU(framing Framing, frame []byte) ([]byte, error) {
+	if framing == FramingMBAP {
+		if len(frame) < 8 {
+			return nil, fmt.Errorf("MBAP请求帧过短: %s", HexBytes(frame))
+		}
+		return frame[7:], nil
+	}
+	if len(frame) < 4 {
+		return nil, fmt.Errorf("RTU请求帧过短: %s", HexBytes(frame))
+	}
+	return frame[1 : len(frame)-2], nil
+}
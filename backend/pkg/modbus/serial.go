@@ -0,0 +1,46 @@
+package modbus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SerialConfig 串口参数
+type SerialConfig struct {
+	Device   string // 串口设备路径，如 /dev/ttyUSB0
+	BaudRate int    // 默认9600
+	DataBits int    // 默认8
+	Parity   string // N(无)/E(偶)/O(奇)，默认N
+	StopBits int    // 1或2，默认1
+}
+
+// normalize 填充默认值并校验串口参数
+func (c SerialConfig) normalize() (SerialConfig, error) {
+	if c.Device == "" {
+		return c, fmt.Errorf("未配置串口设备路径")
+	}
+	if c.BaudRate == 0 {
+		c.BaudRate = 9600
+	}
+	if c.DataBits == 0 {
+		c.DataBits = 8
+	}
+	if c.StopBits == 0 {
+		c.StopBits = 1
+	}
+	c.Parity = strings.ToUpper(c.Parity)
+	if c.Parity == "" {
+		c.Parity = "N"
+	}
+
+	if c.DataBits != 7 && c.DataBits != 8 {
+		return c, fmt.Errorf("不支持的数据位: %d", c.DataBits)
+	}
+	if c.StopBits != 1 && c.StopBits != 2 {
+		return c, fmt.Errorf("不支持的停止位: %d", c.StopBits)
+	}
+	if c.Parity != "N" && c.Parity != "E" && c.Parity != "O" {
+		return c, fmt.Errorf("不支持的校验方式: %s", c.Parity)
+	}
+	return c, nil
+}
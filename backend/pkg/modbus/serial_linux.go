@@ -0,0 +1,79 @@
+//go:build linux
+
+package modbus
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+var baudRates = map[int]uint32{
+	1200:   unix.B1200,
+	2400:   unix.B2400,
+	4800:   unix.B4800,
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+}
+
+// openSerial 以原始模式打开串口并设置波特率、数据位、校验和停止位
+func openSerial(cfg SerialConfig) (*os.File, error) {
+	cfg, err := cfg.normalize()
+	if err != nil {
+		return nil, err
+	}
+	speed, ok := baudRates[cfg.BaudRate]
+	if !ok {
+		return nil, fmt.Errorf("不支持的波特率: %d", cfg.BaudRate)
+	}
+
+	// 以非阻塞方式打开，使读写超时由运行时poller控制
+	f, err := os.OpenFile(cfg.Device, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("读取串口参数失败: %w", err)
+	}
+
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY | unix.INPCK
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.PARODD | unix.CSTOPB | unix.CBAUD
+	t.Cflag |= unix.CREAD | unix.CLOCAL | speed
+
+	if cfg.DataBits == 7 {
+		t.Cflag |= unix.CS7
+	} else {
+		t.Cflag |= unix.CS8
+	}
+	switch cfg.Parity {
+	case "E":
+		t.Cflag |= unix.PARENB
+		t.Iflag |= unix.INPCK
+	case "O":
+		t.Cflag |= unix.PARENB | unix.PARODD
+		t.Iflag |= unix.INPCK
+	}
+	if cfg.StopBits == 2 {
+		t.Cflag |= unix.CSTOPB
+	}
+	t.Ispeed = speed
+	t.Ospeed = speed
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, t); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("设置串口参数失败: %w", err)
+	}
+	return f, nil
+}
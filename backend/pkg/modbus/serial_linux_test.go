@@ -0,0 +1,89 @@
+//go:build linux
+
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestSerialRTUOverPTY(t *testing.T) {
+	master, slavePath, err := OpenPTY()
+	if err != nil {
+		t.Skipf("无法创建伪终端: %v", err)
+	}
+	defer master.Close()
+
+	cfg, err := EndpointConfig("rtu", "", 0, SerialConfig{Device: slavePath, BaudRate: 19200, Parity: "e", StopBits: 2})
+	require.NoError(t, err)
+	cfg.Timeout = time.Second
+
+	transport, err := Open(cfg)
+	require.NoError(t, err)
+	defer transport.Close()
+
+	// 串口参数已写入从端（伪终端驱动不保留校验位设置，只检查波特率和停止位）
+	port, err := os.OpenFile(slavePath, os.O_RDWR|unix.O_NOCTTY, 0)
+	require.NoError(t, err)
+	defer port.Close()
+	termios, err := unix.IoctlGetTermios(int(port.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	assert.NotZero(t, termios.Cflag&unix.CSTOPB)
+	assert.Equal(t, uint32(unix.B19200), termios.Cflag&unix.CBAUD)
+
+	// 主端模拟站号5的从站
+	go func() {
+		req := make([]byte, 8)
+		if _, err := io.ReadFull(master, req); err != nil {
+			return
+		}
+		resp := []byte{req[0], 0x03, 0x02, 0x01, 0x2C}
+		master.Write(binary.LittleEndian.AppendUint16(resp, CRC16(resp)))
+	}()
+
+	c := NewModbusClientWithTransport(transport, 5)
+	values, err := c.ReadHoldingRegisters(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{300}, values)
+}
+
+func TestSerialRTUTimeout(t *testing.T) {
+	master, slavePath, err := OpenPTY()
+	if err != nil {
+		t.Skipf("无法创建伪终端: %v", err)
+	}
+	defer master.Close()
+
+	transport, err := Open(Config{Framing: FramingRTU, Serial: SerialConfig{Device: slavePath}, Timeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+	defer transport.Close()
+
+	_, err = NewModbusClientWithTransport(transport, 1).ReadInputRegisters(0, 1)
+	assert.True(t, IsTimeout(err), "err = %v", err)
+}
+
+func TestEndpointConfig(t *testing.T) {
+	cfg, err := EndpointConfig("rtu_over_tcp", "192.168.1.10", 503, SerialConfig{Device: "/dev/ttyUSB0"})
+	require.NoError(t, err)
+	assert.Equal(t, "rtu_over_tcp://192.168.1.10:503", GatewayKey(cfg))
+
+	cfg, err = EndpointConfig("rtu", "192.168.1.10", 503, SerialConfig{Device: "/dev/ttyUSB0"})
+	require.NoError(t, err)
+	assert.Equal(t, "rtu:///dev/ttyUSB0", GatewayKey(cfg))
+	assert.Equal(t, 9600, cfg.Serial.BaudRate)
+	assert.Equal(t, "N", cfg.Serial.Parity)
+
+	_, err = EndpointConfig("rtu", "", 0, SerialConfig{})
+	assert.Error(t, err)
+	_, err = EndpointConfig("rtu", "", 0, SerialConfig{Device: "/dev/ttyUSB0", Parity: "M"})
+	assert.Error(t, err)
+	_, err = EndpointConfig("mbap", "", 502, SerialConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package modbus
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSerial 当前仅支持Linux串口
+func openSerial(cfg SerialConfig) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("当前平台不支持串口RTU: %s", cfg.Device)
+}
@@ -0,0 +1,300 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// 从站异常码
+const (
+	ExceptionIllegalFunction     byte = 0x01
+	ExceptionIllegalAddress      byte = 0x02
+	ExceptionIllegalValue        byte = 0x03
+	ExceptionServerDeviceFailed  byte = 0x04
+	ExceptionGatewayTargetFailed byte = 0x0B
+)
+
+// ServerRequest 从站收到的一次请求
+type ServerRequest struct {
+	RemoteAddr   net.Addr
+	UnitID       byte
+	FunctionCode byte
+	Address      uint16
+	Quantity     uint16
+}
+
+// ServerHandler 从站数据访问接口。返回 *ExceptionError 时按其异常码应答，
+// 其他错误按 0x04(从站设备故障) 应答。
+type ServerHandler interface {
+	// ReadBits 读线圈(01)或离散输入(02)
+	ReadBits(req ServerRequest) ([]bool, error)
+	// ReadRegisters 读保持寄存器(03)或输入寄存器(04)
+	ReadRegisters(req ServerRequest) ([]uint16, error)
+	// WriteCoils 写单个线圈(05)或多个线圈(15)
+	WriteCoils(req ServerRequest, values []bool) error
+	// WriteRegisters 写单个寄存器(06)或多个寄存器(16)
+	WriteRegisters(req ServerRequest, values []uint16) error
+}
+
+// Server MODBUS TCP(MBAP) 从站，每个连接按顺序处理请求
+type Server struct {
+	handler     ServerHandler
+	idleTimeout time.Duration
+	allow       func(remote net.Addr) bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer 创建从站，idleTimeout 内没有请求的连接会被关闭（0 表示不限制）
+func NewServer(handler ServerHandler, idleTimeout time.Duration) *Server {
+	return &Server{
+		handler:     handler,
+		idleTimeout: idleTimeout,
+		conns:       make(map[net.Conn]struct{}),
+	}
+}
+
+// SetAllow 设置连接过滤，返回 false 的客户端连接会被直接关闭，需在 Listen 前调用
+func (s *Server) SetAllow(allow func(remote net.Addr) bool) {
+	s.allow = allow
+}
+
+// Listen 监听地址并在后台接受连接，返回实际监听地址
+func (s *Server) Listen(address string) (net.Addr, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.accept(listener)
+	return listener.Addr(), nil
+}
+
+// Close 停止监听并断开所有连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// Connections 当前连接数
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *Server) accept(listener net.Listener) {
+	defer s.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return
+		}
+		if s.allow != nil && !s.allow(conn.RemoteAddr()) {
+			conn.Close()
+			continue
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	header := make([]byte, 7)
+	for {
+		if s.idleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.idleTimeout))
+		}
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint16(header[4:6])
+		if binary.BigEndian.Uint16(header[2:4]) != 0 || length < 2 || length > 254 {
+			return
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		resp := s.handle(conn.RemoteAddr(), header[6], pdu)
+		adu := make([]byte, 7, 7+len(resp))
+		copy(adu, header[:4])
+		binary.BigEndian.PutUint16(adu[4:6], uint16(len(resp)+1))
+		adu[6] = header[6]
+		if _, err := conn.Write(append(adu, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+// handle 解析请求PDU并返回响应PDU
+func (s *Server) handle(remote net.Addr, unitID byte, pdu []byte) []byte {
+	fc := pdu[0]
+	req := ServerRequest{RemoteAddr: remote, UnitID: unitID, FunctionCode: fc}
+	data := pdu[1:]
+
+	switch fc {
+	case 0x01, 0x02:
+		if len(data) != 4 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		req.Address, req.Quantity = binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if req.Quantity < 1 || req.Quantity > 2000 || int(req.Address)+int(req.Quantity) > 0x10000 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		bits, err := s.handler.ReadBits(req)
+		if err != nil {
+			return errorPDU(fc, err)
+		}
+		resp := []byte{fc, byte((req.Quantity + 7) / 8)}
+		resp = append(resp, make([]byte, resp[1])...)
+		for i := 0; i < int(req.Quantity) && i < len(bits); i++ {
+			if bits[i] {
+				resp[2+i/8] |= 1 << (i % 8)
+			}
+		}
+		return resp
+
+	case 0x03, 0x04:
+		if len(data) != 4 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		req.Address, req.Quantity = binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if req.Quantity < 1 || req.Quantity > 125 || int(req.Address)+int(req.Quantity) > 0x10000 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		values, err := s.handler.ReadRegisters(req)
+		if err != nil {
+			return errorPDU(fc, err)
+		}
+		resp := []byte{fc, byte(req.Quantity * 2)}
+		for i := 0; i < int(req.Quantity); i++ {
+			var v uint16
+			if i < len(values) {
+				v = values[i]
+			}
+			resp = binary.BigEndian.AppendUint16(resp, v)
+		}
+		return resp
+
+	case 0x05:
+		if len(data) != 4 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		req.Address, req.Quantity = binary.BigEndian.Uint16(data[0:2]), 1
+		value := binary.BigEndian.Uint16(data[2:4])
+		if value != 0xFF00 && value != 0x0000 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		if err := s.handler.WriteCoils(req, []bool{value == 0xFF00}); err != nil {
+			return errorPDU(fc, err)
+		}
+		return append([]byte{fc}, data...)
+
+	case 0x06:
+		if len(data) != 4 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		req.Address, req.Quantity = binary.BigEndian.Uint16(data[0:2]), 1
+		if err := s.handler.WriteRegisters(req, []uint16{binary.BigEndian.Uint16(data[2:4])}); err != nil {
+			return errorPDU(fc, err)
+		}
+		return append([]byte{fc}, data...)
+
+	case 0x0F:
+		if len(data) < 5 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		req.Address, req.Quantity = binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if req.Quantity < 1 || req.Quantity > 1968 || int(data[4]) != int(req.Quantity+7)/8 || len(data) != 5+int(data[4]) ||
+			int(req.Address)+int(req.Quantity) > 0x10000 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		values := make([]bool, req.Quantity)
+		for i := range values {
+			values[i] = data[5+i/8]&(1<<(i%8)) != 0
+		}
+		if err := s.handler.WriteCoils(req, values); err != nil {
+			return errorPDU(fc, err)
+		}
+		return append([]byte{fc}, data[0:4]...)
+
+	case 0x10:
+		if len(data) < 5 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		req.Address, req.Quantity = binary.BigEndian.Uint16(data[0:2]), binary.BigEndian.Uint16(data[2:4])
+		if req.Quantity < 1 || req.Quantity > 123 || int(data[4]) != int(req.Quantity)*2 || len(data) != 5+int(data[4]) ||
+			int(req.Address)+int(req.Quantity) > 0x10000 {
+			return exceptionPDU(fc, ExceptionIllegalValue)
+		}
+		values := make([]uint16, req.Quantity)
+		for i := range values {
+			values[i] = binary.BigEndian.Uint16(data[5+2*i:])
+		}
+		if err := s.handler.WriteRegisters(req, values); err != nil {
+			return errorPDU(fc, err)
+		}
+		return append([]byte{fc}, data[0:4]...)
+	}
+	return exceptionPDU(fc, ExceptionIllegalFunction)
+}
+
+func errorPDU(fc byte, err error) []byte {
+	var exception *ExceptionError
+	if errors.As(err, &exception) {
+		return exceptionPDU(fc, exception.Code)
+	}
+	return exceptionPDU(fc, ExceptionServerDeviceFailed)
+}
+
+func exceptionPDU(fc, code byte) []byte {
+	return []byte{fc | 0x80, code}
+}
@@ -0,0 +1,97 @@
+package modbus
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryHandler 只读寄存器 0-3，线圈 0-1 可写
+type memoryHandler struct {
+	registers []uint16
+	coils     []bool
+}
+
+func (h *memoryHandler) ReadBits(req ServerRequest) ([]bool, error) {
+	if int(req.Address)+int(req.Quantity) > len(h.coils) {
+		return nil, &ExceptionError{Code: ExceptionIllegalAddress}
+	}
+	return h.coils[req.Address : req.Address+req.Quantity], nil
+}
+
+func (h *memoryHandler) ReadRegisters(req ServerRequest) ([]uint16, error) {
+	if int(req.Address)+int(req.Quantity) > len(h.registers) {
+		return nil, &ExceptionError{Code: ExceptionIllegalAddress}
+	}
+	return h.registers[req.Address : req.Address+req.Quantity], nil
+}
+
+func (h *memoryHandler) WriteCoils(req ServerRequest, values []bool) error {
+	if int(req.Address)+len(values) > len(h.coils) {
+		return &ExceptionError{Code: ExceptionIllegalAddress}
+	}
+	copy(h.coils[req.Address:], values)
+	return nil
+}
+
+func (h *memoryHandler) WriteRegisters(req ServerRequest, values []uint16) error {
+	return &ExceptionError{Code: ExceptionIllegalFunction}
+}
+
+func TestServer(t *testing.T) {
+	handler := &memoryHandler{registers: []uint16{215, 0xFF38, 1, 2}, coils: []bool{false, true}}
+	server := NewServer(handler, time.Minute)
+	addr, err := server.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	c := NewModbusClientWithTransport(NewTransport(conn, FramingMBAP, time.Second), 1)
+	defer c.Disconnect()
+
+	values, err := c.ReadHoldingRegisters(0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{215, 0xFF38}, values)
+	values, err = c.ReadInputRegisters(2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{1, 2}, values)
+
+	_, err = c.ReadHoldingRegisters(3, 2)
+	assert.Equal(t, &ExceptionError{FunctionCode: 0x03, Code: ExceptionIllegalAddress}, err)
+	err = c.WriteSingleRegister(0, 1)
+	assert.Equal(t, &ExceptionError{FunctionCode: 0x06, Code: ExceptionIllegalFunction}, err)
+
+	require.NoError(t, c.WriteSingleCoil(0, true))
+	coils, err := c.ReadCoils(0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, coils)
+
+	// 写多个线圈(15)：地址0起2个，值 0b10
+	_, err = c.send(0x0F, []byte{0x00, 0x00, 0x00, 0x02, 0x01, 0x02})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true}, handler.coils)
+
+	_, err = c.send(0x2B, []byte{0x0E, 0x01, 0x00})
+	assert.Equal(t, &ExceptionError{FunctionCode: 0x2B, Code: ExceptionIllegalFunction}, err)
+	assert.Equal(t, 1, server.Connections())
+}
+
+func TestServerAllow(t *testing.T) {
+	server := NewServer(&memoryHandler{registers: []uint16{1}}, time.Minute)
+	server.SetAllow(func(net.Addr) bool { return false })
+	addr, err := server.Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	c := NewModbusClientWithTransport(NewTransport(conn, FramingMBAP, 500*time.Millisecond), 1)
+	defer c.Disconnect()
+
+	_, err = c.ReadHoldingRegisters(0, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,404 @@
+package modbus
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 报文追踪结果
+const (
+	TraceOK        = "ok"        // 正常响应
+	TraceBroadcast = "broadcast" // 广播帧，不等待响应
+	TraceException = "exception" // 从站异常响应
+	TraceTimeout   = "timeout"   // 响应超时
+	TraceCRCError  = "crc_error" // CRC校验失败
+	TraceInvalid   = "invalid"   // 响应格式错误（单元号/站号不匹配、未知功能码等）
+	TraceError     = "error"     // 其他传输错误（连接断开等）
+)
+
+// HexBytes 以空格分隔的十六进制字符串序列化的字节串，便于人工阅读抓包文件
+type HexBytes []byte
+
+func (b HexBytes) String() string {
+	return strings.ToUpper(fmt.Sprintf("% x", []byte(b)))
+}
+
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	raw, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		return fmt.Errorf("十六进制报文格式错误: %w", err)
+	}
+	*b = raw
+	return nil
+}
+
+// TraceRecord 一次请求-响应的报文记录，Request/Response为线路上的原始字节
+type TraceRecord struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Gateway   string    `json:"gateway"`
+	Framing   Framing   `json:"framing"`
+	UnitID    byte      `json:"unit_id"`
+	Function  byte      `json:"function"`
+	Request   HexBytes  `json:"request"`
+	Response  HexBytes  `json:"response"` // 本次收到的全部字节，超时或校验失败时可能不完整
+	LatencyMs float64   `json:"latency_ms"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// traceResult 按错误类型归类追踪结果
+func traceResult(unitID byte, err error) string {
+	var exception *ExceptionError
+	switch {
+	case err == nil && unitID == 0:
+		return TraceBroadcast
+	case err == nil:
+		return TraceOK
+	case errors.As(err, &exception):
+		return TraceException
+	case IsTimeout(err):
+		return TraceTimeout
+	case errors.Is(err, ErrCRCMismatch):
+		return TraceCRCError
+	case errors.Is(err, ErrInvalidResponse):
+		return TraceInvalid
+	}
+	return TraceError
+}
+
+// CaptureFilter 抓包范围：网关标识必填，站号为0时抓取网关上的全部站号
+type CaptureFilter struct {
+	Gateway string `json:"gateway"`
+	UnitID  byte   `json:"unit_id"`
+}
+
+func (f CaptureFilter) match(gateway string, unitID byte) bool {
+	return f.Gateway == gateway && (f.UnitID == 0 || f.UnitID == unitID)
+}
+
+// CaptureInfo 抓包任务信息
+type CaptureInfo struct {
+	ID        string        `json:"id"`
+	Label     string        `json:"label"` // 抓包对象说明，如 断路器#3
+	Filter    CaptureFilter `json:"filter"`
+	Active    bool          `json:"active"`
+	StartedAt time.Time     `json:"started_at"`
+	StoppedAt *time.Time    `json:"stopped_at,omitempty"`
+	Frames    int           `json:"frames"`
+	Errors    int           `json:"errors"`
+	MaxFrames int           `json:"max_frames"` // 达到后自动停止
+	File      string        `json:"file"`
+}
+
+// capture 抓包任务：最近的报文保存在环形缓冲区，全部报文按行写入JSON抓包文件
+type capture struct {
+	info CaptureInfo
+	path string
+	file *os.File
+	enc  *json.Encoder
+	ring []TraceRecord
+	next int
+}
+
+func (c *capture) add(rec TraceRecord) {
+	if len(c.ring) < cap(c.ring) {
+		c.ring = append(c.ring, rec)
+	} else {
+		c.ring[c.next] = rec
+		c.next = (c.next + 1) % len(c.ring)
+	}
+	c.info.Frames++
+	if rec.Result != TraceOK && rec.Result != TraceBroadcast {
+		c.info.Errors++
+	}
+	if c.enc != nil {
+		c.enc.Encode(rec)
+	}
+}
+
+// recent 按时间顺序返回环形缓冲区中最近的 limit 条记录
+func (c *capture) recent(limit int) []TraceRecord {
+	ordered := make([]TraceRecord, 0, len(c.ring))
+	ordered = append(ordered, c.ring[c.next:]...)
+	ordered = append(ordered, c.ring[:c.next]...)
+	if limit > 0 && len(ordered) > limit {
+		ordered = ordered[len(ordered)-limit:]
+	}
+	return ordered
+}
+
+func (c *capture) stop() {
+	if !c.info.Active {
+		return
+	}
+	now := time.Now()
+	c.info.Active = false
+	c.info.StoppedAt = &now
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+		c.enc = nil
+	}
+}
+
+// Tracer 报文追踪器：默认关闭，只有存在进行中的抓包任务时才记录匹配的报文
+type Tracer struct {
+	mu        sync.Mutex
+	dir       string
+	ringSize  int
+	maxFrames int
+	captures  map[string]*capture
+	active    atomic.Int32
+	seq       uint64
+	nextID    uint64
+}
+
+// NewTracer 创建报文追踪器，抓包文件保存在 dir 下
+func NewTracer(dir string, ringSize, maxFrames int) *Tracer {
+	t := &Tracer{captures: make(map[string]*capture)}
+	t.Configure(dir, ringSize, maxFrames)
+	return t
+}
+
+var (
+	defaultTracer     *Tracer
+	defaultTracerOnce sync.Once
+)
+
+// DefaultTracer 进程内共享的报文追踪器，Open 建立的所有传输层都会向其上报
+func DefaultTracer() *Tracer {
+	defaultTracerOnce.Do(func() {
+		defaultTracer = NewTracer(filepath.Join(os.TempDir(), "modbus-captures"), 1000, 100000)
+	})
+	return defaultTracer
+}
+
+// Configure 设置抓包目录、环形缓冲区大小和单个抓包任务的最大帧数，对之后新建的任务生效
+func (t *Tracer) Configure(dir string, ringSize, maxFrames int) {
+	if ringSize <= 0 {
+		ringSize = 1000
+	}
+	if maxFrames <= 0 {
+		maxFrames = 100000
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dir = dir
+	t.ringSize = ringSize
+	t.maxFrames = maxFrames
+}
+
+// Start 开始抓包
+func (t *Tracer) Start(filter CaptureFilter, label string) (CaptureInfo, error) {
+	if filter.Gateway == "" {
+		return CaptureInfo{}, fmt.Errorf("未指定抓包网关")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, c := range t.captures {
+		if c.info.Active && c.info.Filter == filter {
+			return CaptureInfo{}, fmt.Errorf("%s 已有进行中的抓包任务 %s", label, c.info.ID)
+		}
+	}
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return CaptureInfo{}, fmt.Errorf("创建抓包目录失败: %w", err)
+	}
+
+	t.nextID++
+	now := time.Now()
+	id := fmt.Sprintf("%s-%d", now.Format("20060102-150405"), t.nextID)
+	path := filepath.Join(t.dir, "modbus-"+id+".jsonl")
+	file, err := os.Create(path)
+	if err != nil {
+		return CaptureInfo{}, fmt.Errorf("创建抓包文件失败: %w", err)
+	}
+
+	c := &capture{
+		info: CaptureInfo{
+			ID:        id,
+			Label:     label,
+			Filter:    filter,
+			Active:    true,
+			StartedAt: now,
+			MaxFrames: t.maxFrames,
+			File:      filepath.Base(path),
+		},
+		path: path,
+		file: file,
+		enc:  json.NewEncoder(file),
+		ring: make([]TraceRecord, 0, t.ringSize),
+	}
+	t.captures[id] = c
+	t.active.Add(1)
+	return c.info, nil
+}
+
+// Stop 停止抓包，抓包文件和缓冲区保留以便下载
+func (t *Tracer) Stop(id string) (CaptureInfo, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.captures[id]
+	if !ok {
+		return CaptureInfo{}, fmt.Errorf("抓包任务不存在: %s", id)
+	}
+	if c.info.Active {
+		c.stop()
+		t.active.Add(-1)
+	}
+	return c.info, nil
+}
+
+// Delete 删除已停止的抓包任务及其文件
+func (t *Tracer) Delete(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.captures[id]
+	if !ok {
+		return fmt.Errorf("抓包任务不存在: %s", id)
+	}
+	if c.info.Active {
+		return fmt.Errorf("抓包任务进行中，请先停止")
+	}
+	delete(t.captures, id)
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Captures 返回全部抓包任务，最新的在前
+func (t *Tracer) Captures() []CaptureInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]CaptureInfo, 0, len(t.captures))
+	for _, c := range t.captures {
+		list = append(list, c.info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.After(list[j].StartedAt) })
+	return list
+}
+
+// Capture 返回抓包任务信息及抓包文件路径
+func (t *Tracer) Capture(id string) (CaptureInfo, string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.captures[id]
+	if !ok {
+		return CaptureInfo{}, "", fmt.Errorf("抓包任务不存在: %s", id)
+	}
+	if c.file != nil {
+		c.file.Sync()
+	}
+	return c.info, c.path, nil
+}
+
+// Recent 返回抓包任务环形缓冲区中最近的 limit 条报文（limit<=0 时全部返回）
+func (t *Tracer) Recent(id string, limit int) ([]TraceRecord, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.captures[id]
+	if !ok {
+		return nil, fmt.Errorf("抓包任务不存在: %s", id)
+	}
+	return c.recent(limit), nil
+}
+
+// Enabled 判断指定网关和站号的报文是否需要记录，无进行中的抓包任务时开销仅为一次原子读
+func (t *Tracer) Enabled(gateway string, unitID byte) bool {
+	if t.active.Load() == 0 || gateway == "" {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.captures {
+		if c.info.Active && c.info.Filter.match(gateway, unitID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record 把报文写入所有匹配的抓包任务
+func (t *Tracer) Record(rec TraceRecord) {
+	if t.active.Load() == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seq++
+	rec.Seq = t.seq
+	for _, c := range t.captures {
+		if !c.info.Active || !c.info.Filter.match(rec.Gateway, rec.UnitID) {
+			continue
+		}
+		c.add(rec)
+		if c.info.Frames >= c.info.MaxFrames {
+			c.stop()
+			t.active.Add(-1)
+		}
+	}
+}
+
+// LoadCapture 读取抓包文件（每行一条JSON记录）
+func LoadCapture(r io.Reader) ([]TraceRecord, error) {
+	var records []TraceRecord
+	dec := json.NewDecoder(r)
+	for {
+		var rec TraceRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, fmt.Errorf("解析第 %d 条报文失败: %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// LoadCaptureFile 读取抓包文件
+func LoadCaptureFile(path string) ([]TraceRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadCapture(f)
+}
+
+// recordingReader 记录从连接读到的全部原始字节
+type recordingReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	return n, err
+}
@@ -0,0 +1,124 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracerCaptureAndReplay(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// 从站第一次返回CRC错误的响应，第二次正常
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for i := 0; i < 2; i++ {
+			req := make([]byte, 8)
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			resp := []byte{req[0], 0x03, 0x02, 0x00, 0x2A}
+			resp = binary.LittleEndian.AppendUint16(resp, CRC16(resp))
+			if i == 0 {
+				resp[len(resp)-1] ^= 0xFF
+			}
+			conn.Write(resp)
+		}
+	}()
+
+	tracer := DefaultTracer()
+	tracer.Configure(t.TempDir(), 10, 0)
+
+	cfg := Config{Framing: FramingRTUOverTCP, Address: ln.Addr().String(), Timeout: time.Second}
+	info, err := tracer.Start(CaptureFilter{Gateway: GatewayKey(cfg), UnitID: 7}, "断路器#1")
+	require.NoError(t, err)
+
+	transport, err := Open(cfg)
+	require.NoError(t, err)
+	defer transport.Close()
+
+	c := NewModbusClientWithTransport(transport, 7)
+	_, err = c.ReadHoldingRegisters(0, 1)
+	assert.ErrorIs(t, err, ErrCRCMismatch)
+	values, err := c.ReadHoldingRegisters(0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{42}, values)
+
+	info, err = tracer.Stop(info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, info.Frames)
+	assert.Equal(t, 1, info.Errors)
+	assert.False(t, tracer.Enabled(GatewayKey(cfg), 7))
+
+	recent, err := tracer.Recent(info.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, TraceCRCError, recent[0].Result)
+
+	_, path, err := tracer.Capture(info.ID)
+	require.NoError(t, err)
+	records, err := LoadCaptureFile(path)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "07 03 00 00 00 01 84 6C", records[1].Request.String())
+
+	results, conn, err := Replay(records)
+	require.NoError(t, err)
+	assert.Empty(t, conn.Mismatches())
+	for _, r := range results {
+		assert.True(t, r.Match(), "seq=%d expected=%s got=%s", r.Seq, r.Expected, r.Got)
+	}
+	assert.Equal(t, "03 02 00 2A", results[1].Response.String())
+
+	require.NoError(t, tracer.Delete(info.ID))
+}
+
+func TestTracerRingBuffer(t *testing.T) {
+	tracer := NewTracer(t.TempDir(), 3, 5)
+	info, err := tracer.Start(CaptureFilter{Gateway: "mbap://10.0.0.1:502"}, "")
+	require.NoError(t, err)
+
+	assert.True(t, tracer.Enabled("mbap://10.0.0.1:502", 9))
+	assert.False(t, tracer.Enabled("mbap://10.0.0.2:502", 9))
+
+	for i := 0; i < 6; i++ {
+		tracer.Record(TraceRecord{Gateway: "mbap://10.0.0.1:502", UnitID: byte(i + 1), Result: TraceOK})
+	}
+
+	// 达到最大帧数后自动停止
+	info, _, err = tracer.Capture(info.ID)
+	require.NoError(t, err)
+	assert.False(t, info.Active)
+	assert.Equal(t, 5, info.Frames)
+
+	recent, err := tracer.Recent(info.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, recent, 3)
+	assert.Equal(t, []byte{3, 4, 5}, []byte{recent[0].UnitID, recent[1].UnitID, recent[2].UnitID})
+}
+
+// 现场抓包：第一帧超时，第二帧收到了第一帧迟到的响应和本帧响应
+func TestReplayMBAPLateResponse(t *testing.T) {
+	records, err := LoadCaptureFile(filepath.Join("testdata", "mbap_late_response.jsonl"))
+	require.NoError(t, err)
+
+	results, conn, err := Replay(records)
+	require.NoError(t, err)
+	assert.Empty(t, conn.Mismatches())
+	require.Len(t, results, 2)
+	assert.Equal(t, TraceTimeout, results[0].Got)
+	assert.Equal(t, TraceOK, results[1].Got)
+	assert.Equal(t, "04 02 00 DD", results[1].Response.String())
+}
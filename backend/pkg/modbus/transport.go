@@ -0,0 +1,266 @@
+package modbus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Framing MODBUS报文格式
+type Framing string
+
+const (
+	FramingMBAP       Framing = "mbap"         // MODBUS TCP（MBAP头，网关做TCP转RTU）
+	FramingRTUOverTCP Framing = "rtu_over_tcp" // RTU帧经网关透传（带CRC）
+	FramingRTU        Framing = "rtu"          // 本地串口RTU，如 /dev/ttyUSB0
+)
+
+// ParseFraming 解析报文格式，空值默认为MBAP
+func ParseFraming(s string) (Framing, error) {
+	switch Framing(strings.ToLower(strings.TrimSpace(s))) {
+	case "", FramingMBAP:
+		return FramingMBAP, nil
+	case FramingRTUOverTCP:
+		return FramingRTUOverTCP, nil
+	case FramingRTU:
+		return FramingRTU, nil
+	}
+	return "", fmt.Errorf("不支持的MODBUS报文格式: %s", s)
+}
+
+// 传输层错误
+var (
+	ErrCRCMismatch     = errors.New("MODBUS响应CRC校验失败")
+	ErrInvalidResponse = errors.New("MODBUS响应格式错误")
+)
+
+// ExceptionError 从站返回的异常响应
+type ExceptionError struct {
+	FunctionCode byte
+	Code         byte
+}
+
+func (e *ExceptionError) Error() string {
+	return fmt.Sprintf("MODBUS异常响应: 功能码=%02X, 异常码=%02X", e.FunctionCode|0x80, e.Code)
+}
+
+// IsTimeout 判断错误是否为通信超时（TCP读写超时或串口读截止时间到达）
+func IsTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// Transport MODBUS传输层：把PDU按报文格式发送给指定站号并返回响应PDU。
+// 站号为0时视为广播，只发送不等待响应。
+type Transport interface {
+	Send(unitID byte, pdu []byte) ([]byte, error)
+	Close() error
+}
+
+// TimeoutSender 支持按请求指定响应超时的传输层，用于总线扫描等需要快速判定无应答的场景
+type TimeoutSender interface {
+	SendTimeout(unitID byte, pdu []byte, timeout time.Duration) ([]byte, error)
+}
+
+// Config 传输层配置
+type Config struct {
+	Framing     Framing
+	Address     string // TCP类报文格式的 host:port
+	Serial      SerialConfig
+	DialTimeout time.Duration
+	Timeout     time.Duration // 单帧请求-响应超时
+}
+
+// EndpointConfig 按设备配置的报文格式生成传输层配置：rtu 使用本地串口，其余使用 host:port
+func EndpointConfig(framing, host string, port int, serial SerialConfig) (Config, error) {
+	mode, err := ParseFraming(framing)
+	if err != nil {
+		return Config{}, err
+	}
+	if mode == FramingRTU {
+		if serial, err = serial.normalize(); err != nil {
+			return Config{}, err
+		}
+		return Config{Framing: mode, Serial: serial}, nil
+	}
+	if host == "" {
+		return Config{}, fmt.Errorf("%s 报文格式需要配置IP地址", mode)
+	}
+	return Config{Framing: mode, Address: net.JoinHostPort(host, strconv.Itoa(port))}, nil
+}
+
+// Open 按配置建立传输层连接
+func Open(cfg Config) (Transport, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = cfg.Timeout
+	}
+	if cfg.Framing == "" {
+		cfg.Framing = FramingMBAP
+	}
+
+	switch cfg.Framing {
+	case FramingMBAP, FramingRTUOverTCP:
+		conn, err := net.DialTimeout("tcp", cfg.Address, cfg.DialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("连接MODBUS设备失败: %w", err)
+		}
+		return newStreamTransport(conn, cfg.Framing, cfg.Timeout, GatewayKey(cfg)), nil
+	case FramingRTU:
+		port, err := openSerial(cfg.Serial)
+		if err != nil {
+			return nil, fmt.Errorf("打开串口失败: %w", err)
+		}
+		return newStreamTransport(port, cfg.Framing, cfg.Timeout, GatewayKey(cfg)), nil
+	}
+	return nil, fmt.Errorf("不支持的MODBUS报文格式: %s", cfg.Framing)
+}
+
+// NewTransport 在已建立的字节流（TCP连接、串口、pty或测试桩）上创建传输层
+func NewTransport(conn io.ReadWriteCloser, framing Framing, timeout time.Duration) Transport {
+	return newStreamTransport(conn, framing, timeout, "")
+}
+
+// newStreamTransport 创建传输层，gateway 非空时向 DefaultTracer 上报报文
+func newStreamTransport(conn io.ReadWriteCloser, framing Framing, timeout time.Duration, gateway string) *streamTransport {
+	var f framer
+	if framing == FramingMBAP {
+		f = &mbapFramer{}
+	} else {
+		f = &rtuFramer{}
+	}
+	return &streamTransport{conn: conn, framer: f, framing: framing, timeout: timeout, gateway: gateway}
+}
+
+// framer 报文编解码
+type framer interface {
+	encode(unitID byte, pdu []byte) []byte
+	decode(r io.Reader, unitID byte) ([]byte, error)
+}
+
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// streamTransport 基于字节流的传输层实现，同一连接上的请求串行执行
+type streamTransport struct {
+	mu      sync.Mutex
+	conn    io.ReadWriteCloser
+	framer  framer
+	framing Framing
+	timeout time.Duration
+	gateway string // 网关标识，用于报文追踪
+	dirty   bool   // 上一帧失败，缓冲区中可能残留迟到的响应
+}
+
+func (t *streamTransport) Send(unitID byte, pdu []byte) ([]byte, error) {
+	return t.SendTimeout(unitID, pdu, t.timeout)
+}
+
+// SendTimeout 以指定的响应超时完成一次请求，timeout 不大于0时使用连接的默认超时
+func (t *streamTransport) SendTimeout(unitID byte, pdu []byte, timeout time.Duration) ([]byte, error) {
+	if timeout <= 0 {
+		timeout = t.timeout
+	}
+	if len(pdu) == 0 {
+		return nil, fmt.Errorf("MODBUS请求PDU为空")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.dirty {
+		t.drain()
+	}
+	frame := t.framer.encode(unitID, pdu)
+
+	tracer := DefaultTracer()
+	if !tracer.Enabled(t.gateway, unitID) {
+		return t.exchange(unitID, pdu, frame, t.conn, timeout)
+	}
+
+	raw := &recordingReader{r: t.conn}
+	start := time.Now()
+	resp, err := t.exchange(unitID, pdu, frame, raw, timeout)
+	rec := TraceRecord{
+		Time:      start,
+		Gateway:   t.gateway,
+		Framing:   t.framing,
+		UnitID:    unitID,
+		Function:  pdu[0],
+		Request:   frame,
+		Response:  raw.buf,
+		LatencyMs: float64(time.Since(start)) / float64(time.Millisecond),
+		Result:    traceResult(unitID, err),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	tracer.Record(rec)
+	return resp, err
+}
+
+// exchange 发送已编码的请求帧并从 r 解码响应
+func (t *streamTransport) exchange(unitID byte, pdu, frame []byte, r io.Reader, timeout time.Duration) ([]byte, error) {
+	if d, ok := t.conn.(deadliner); ok && timeout > 0 {
+		d.SetDeadline(time.Now().Add(timeout))
+	}
+
+	if _, err := t.conn.Write(frame); err != nil {
+		return nil, fmt.Errorf("发送MODBUS请求失败: %w", err)
+	}
+	if unitID == 0 {
+		return nil, nil
+	}
+
+	resp, err := t.framer.decode(r, unitID)
+	if err != nil {
+		t.dirty = true
+		return nil, fmt.Errorf("读取MODBUS响应失败: %w", err)
+	}
+	if len(resp) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	if resp[0] == pdu[0]|0x80 {
+		code := byte(0)
+		if len(resp) > 1 {
+			code = resp[1]
+		}
+		return nil, &ExceptionError{FunctionCode: pdu[0], Code: code}
+	}
+	if resp[0] != pdu[0] {
+		return nil, fmt.Errorf("MODBUS响应功能码不匹配: 期望=%02X, 实际=%02X", pdu[0], resp[0])
+	}
+	return resp, nil
+}
+
+// drain 丢弃缓冲区中残留的字节，避免RTU帧错位
+func (t *streamTransport) drain() {
+	t.dirty = false
+	d, ok := t.conn.(deadliner)
+	if !ok {
+		return
+	}
+	buf := make([]byte, 256)
+	for {
+		d.SetDeadline(time.Now().Add(20 * time.Millisecond))
+		if _, err := t.conn.Read(buf); err != nil {
+			return
+		}
+	}
+}
+
+func (t *streamTransport) Close() error {
+	return t.conn.Close()
+}
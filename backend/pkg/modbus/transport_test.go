@@ -0,0 +1,93 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC16(t *testing.T) {
+	// 01 03 00 00 00 01 -> CRC 84 0A
+	assert.Equal(t, uint16(0x0A84), CRC16([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01}))
+}
+
+// serveOnce 在管道另一端模拟从站，读取一帧请求后写回给定响应
+func serveOnce(conn net.Conn, reqLen int, respond func(req []byte) []byte) {
+	go func() {
+		req := make([]byte, reqLen)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		conn.Write(respond(req))
+	}()
+}
+
+func TestRTUTransportReadInputRegisters(t *testing.T) {
+	client, slave := net.Pipe()
+	defer slave.Close()
+
+	serveOnce(slave, 8, func(req []byte) []byte {
+		assert.Equal(t, CRC16(req[:6]), binary.LittleEndian.Uint16(req[6:]))
+		resp := []byte{req[0], 0x04, 0x04, 0x00, 0xF0, 0x00, 0xDC}
+		return binary.LittleEndian.AppendUint16(resp, CRC16(resp))
+	})
+
+	c := NewModbusClientWithTransport(NewTransport(client, FramingRTUOverTCP, time.Second), 3)
+	values, err := c.ReadInputRegisters(0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{0x00F0, 220}, values)
+}
+
+func TestRTUTransportCRCMismatch(t *testing.T) {
+	client, slave := net.Pipe()
+	defer slave.Close()
+
+	serveOnce(slave, 8, func(req []byte) []byte {
+		return []byte{req[0], 0x03, 0x02, 0x00, 0x01, 0xFF, 0xFF}
+	})
+
+	c := NewModbusClientWithTransport(NewTransport(client, FramingRTU, time.Second), 1)
+	_, err := c.ReadHoldingRegisters(0, 1)
+	assert.True(t, errors.Is(err, ErrCRCMismatch), "err = %v", err)
+}
+
+func TestMBAPTransportException(t *testing.T) {
+	client, slave := net.Pipe()
+	defer slave.Close()
+
+	serveOnce(slave, 12, func(req []byte) []byte {
+		resp := make([]byte, 9)
+		copy(resp, req[:4])
+		binary.BigEndian.PutUint16(resp[4:6], 3)
+		resp[6] = req[6]
+		resp[7] = 0x85
+		resp[8] = 0x02
+		return resp
+	})
+
+	c := NewModbusClientWithTransport(NewTransport(client, FramingMBAP, time.Second), 1)
+	err := c.WriteSingleCoil(1, true)
+
+	var ex *ExceptionError
+	require.True(t, errors.As(err, &ex), "err = %v", err)
+	assert.Equal(t, byte(0x05), ex.FunctionCode)
+	assert.Equal(t, byte(0x02), ex.Code)
+}
+
+func TestBroadcastDoesNotWaitForResponse(t *testing.T) {
+	client, slave := net.Pipe()
+	defer slave.Close()
+
+	go io.Copy(io.Discard, slave)
+
+	transport := NewTransport(client, FramingRTU, 200*time.Millisecond)
+	resp, err := transport.Send(0, []byte{0x05, 0x00, 0x01, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Nil(t, resp)
+}
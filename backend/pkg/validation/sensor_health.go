@@ -0,0 +1,186 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// 传感器读数健康状态
+const (
+	HealthValid        = "valid"
+	HealthDisconnected = "disconnected" // 探头断开：开路、0x8000 或 DS18B20 上电默认值 85.0°C
+	HealthStuck        = "stuck"        // 连续多个读数完全相同
+	HealthOutOfRange   = "out_of_range" // 超出通道量程
+	HealthSpike        = "spike"        // 与上一有效读数相差过大且未被下一个读数确认
+)
+
+// ds18b20PowerOnValue DS18B20 上电复位后、完成首次转换前温度寄存器的默认值
+const ds18b20PowerOnValue = 85.0
+
+// HealthOptions 传感器健康检测参数
+type HealthOptions struct {
+	StuckSamples int     // 连续多少个读数完全相同视为卡死，0 表示不检测
+	SpikeDelta   float64 // 与上一有效读数相差超过该值（°C）视为跳变，0 表示不检测
+	FaultSamples int     // 连续多少个异常读数后产生传感器故障告警
+}
+
+// DefaultHealthOptions 默认检测参数：30秒采集间隔下约20分钟不变视为卡死，单次跳变超过5°C视为异常
+func DefaultHealthOptions() HealthOptions {
+	return HealthOptions{
+		StuckSamples: 40,
+		SpikeDelta:   5,
+		FaultSamples: 3,
+	}
+}
+
+// HealthResult 单个读数的健康判定结果
+type HealthResult struct {
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+	// FaultRaised 本次读数使通道连续异常达到告警阈值
+	FaultRaised bool `json:"fault_raised"`
+	// FaultCleared 通道处于故障状态时收到有效读数
+	FaultCleared bool `json:"fault_cleared"`
+}
+
+// IsValid 读数是否可用于策略评估和统计
+func (r HealthResult) IsValid() bool {
+	return r.State == HealthValid
+}
+
+// channelHealth 单个通道的检测状态
+type channelHealth struct {
+	lastValid *float64 // 上一有效读数，跳变检测的基准
+	pending   *float64 // 疑似跳变的读数，下一个读数与之接近时确认为真实变化
+	lastValue *float64 // 上一读数（不论是否有效），卡死检测用
+	same      int      // 连续相同读数个数
+	unhealthy int      // 连续异常读数个数
+	faulted   bool
+}
+
+// SensorHealthChecker 传感器读数健康检测：在 DataValidator 的温度量程规则基础上，按通道跟踪
+// 最近的读数，把每个读数判定为有效、断开、卡死、超量程或跳变，连续异常时产生故障。
+type SensorHealthChecker struct {
+	validator *DataValidator
+	options   HealthOptions
+
+	mu       sync.Mutex
+	channels map[string]*channelHealth
+}
+
+// NewSensorHealthChecker 创建传感器健康检测器
+func NewSensorHealthChecker(validator *DataValidator, options HealthOptions) *SensorHealthChecker {
+	if validator == nil {
+		validator = NewDataValidator()
+	}
+	if options.FaultSamples <= 0 {
+		options.FaultSamples = 1
+	}
+	return &SensorHealthChecker{
+		validator: validator,
+		options:   options,
+		channels:  make(map[string]*channelHealth),
+	}
+}
+
+// Check 判定通道的一个读数。key 标识通道（如 "传感器ID-通道号"）；value 为探头原始读数，
+// disconnected 为驱动已识别的开路，此时 value 可为空。断开、卡死和跳变按原始读数判定；
+// 量程 minTemp/maxTemp 是校准后的温度，由 calibrate 换算后比较（为空时不换算），
+// 都为0时使用 DataValidator 的温度规则。
+func (c *SensorHealthChecker) Check(key string, value *float64, disconnected bool, calibrate func(float64) float64, minTemp, maxTemp float64) HealthResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.channels[key]
+	if !ok {
+		ch = &channelHealth{}
+		c.channels[key] = ch
+	}
+
+	result := c.classify(ch, value, disconnected, calibrate, minTemp, maxTemp)
+
+	if result.IsValid() {
+		ch.unhealthy = 0
+		if ch.faulted {
+			ch.faulted = false
+			result.FaultCleared = true
+		}
+		return result
+	}
+	ch.unhealthy++
+	if !ch.faulted && ch.unhealthy >= c.options.FaultSamples {
+		ch.faulted = true
+		result.FaultRaised = true
+	}
+	return result
+}
+
+// MarkFaulted 标记通道已处于故障状态（如采集服务重启后恢复未解除的故障），收到有效读数时产生恢复
+func (c *SensorHealthChecker) MarkFaulted(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.channels[key]
+	if !ok {
+		ch = &channelHealth{}
+		c.channels[key] = ch
+	}
+	ch.faulted = true
+	ch.unhealthy = c.options.FaultSamples
+}
+
+// Forget 清除通道的检测状态（传感器删除或重新配置时）
+func (c *SensorHealthChecker) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.channels, key)
+}
+
+func (c *SensorHealthChecker) classify(ch *channelHealth, value *float64, disconnected bool, calibrate func(float64) float64, minTemp, maxTemp float64) HealthResult {
+	if disconnected || value == nil {
+		ch.lastValue, ch.same = nil, 0
+		return HealthResult{State: HealthDisconnected, Reason: "传感器开路"}
+	}
+	v := *value
+
+	if ch.lastValue != nil && *ch.lastValue == v {
+		ch.same++
+	} else {
+		ch.same = 1
+	}
+	ch.lastValue = &v
+
+	// 85.0°C 是 DS18B20 上电默认值，只有从接近的温度变化过来时才认为是真实读数
+	if v == ds18b20PowerOnValue && (ch.lastValid == nil || math.Abs(*ch.lastValid-v) > math.Max(c.options.SpikeDelta, 1)) {
+		return HealthResult{State: HealthDisconnected, Reason: "DS18B20上电默认值85.0°C，探头可能断开或复位"}
+	}
+
+	if minTemp == 0 && maxTemp == 0 {
+		if rule, ok := c.validator.GetRule("temperature"); ok {
+			minTemp, maxTemp = rule.MinValue, rule.MaxValue
+		}
+	}
+	calibrated := v
+	if calibrate != nil {
+		calibrated = calibrate(v)
+	}
+	if minTemp < maxTemp && (calibrated < minTemp || calibrated > maxTemp) {
+		return HealthResult{State: HealthOutOfRange, Reason: fmt.Sprintf("%.1f°C 超出量程 [%.1f, %.1f]", calibrated, minTemp, maxTemp)}
+	}
+
+	if c.options.StuckSamples > 0 && ch.same >= c.options.StuckSamples {
+		return HealthResult{State: HealthStuck, Reason: fmt.Sprintf("连续 %d 个读数均为 %.1f°C", ch.same, v)}
+	}
+
+	if c.options.SpikeDelta > 0 && ch.lastValid != nil && math.Abs(v-*ch.lastValid) > c.options.SpikeDelta {
+		// 下一个读数与疑似跳变读数接近，说明温度确实发生了变化
+		if ch.pending == nil || math.Abs(v-*ch.pending) > c.options.SpikeDelta {
+			ch.pending = &v
+			return HealthResult{State: HealthSpike, Reason: fmt.Sprintf("较上一有效读数 %.1f°C 跳变 %.1f°C", *ch.lastValid, v-*ch.lastValid)}
+		}
+	}
+
+	ch.lastValid, ch.pending = &v, nil
+	return HealthResult{State: HealthValid}
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSensorHealthChecker(t *testing.T) {
+	checker := NewSensorHealthChecker(nil, HealthOptions{StuckSamples: 4, SpikeDelta: 5, FaultSamples: 2})
+	check := func(v float64) HealthResult { return checker.Check("1-1", &v, false, nil, -35, 125) }
+
+	// 上电默认值 85.0°C 没有接近的历史读数时视为断开
+	assert.Equal(t, HealthDisconnected, check(85).State)
+	assert.Equal(t, HealthValid, check(24.5).State)
+
+	// 单次跳变被排除，下一个读数确认后接受为真实变化
+	assert.Equal(t, HealthSpike, check(40).State)
+	assert.Equal(t, HealthValid, check(24.6).State)
+	assert.Equal(t, HealthSpike, check(31).State)
+	assert.Equal(t, HealthValid, check(31.2).State)
+
+	assert.Equal(t, HealthOutOfRange, check(130).State)
+	r := check(-40)
+	assert.Equal(t, HealthOutOfRange, r.State)
+	assert.True(t, r.FaultRaised, "连续两个异常读数产生故障")
+	assert.False(t, check(-40).FaultRaised, "故障未恢复前不重复产生")
+
+	r = check(31.3)
+	assert.True(t, r.IsValid())
+	assert.True(t, r.FaultCleared)
+
+	// 连续相同读数视为卡死
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, HealthValid, check(30).State)
+	}
+	assert.Equal(t, HealthStuck, check(30).State)
+	assert.Equal(t, HealthValid, check(30.1).State)
+
+	// 开路
+	r = checker.Check("1-2", nil, true, nil, 0, 0)
+	assert.Equal(t, HealthDisconnected, r.State)
+	checker.MarkFaulted("1-3")
+	v := 22.0
+	assert.True(t, checker.Check("1-3", &v, false, nil, 0, 0).FaultCleared)
+
+	// 量程按校准后的温度比较：原始读数 124°C 校准后 126°C 超量程，原始 -36°C 校准后 -34°C 在量程内
+	plus2 := func(raw float64) float64 { return raw + 2 }
+	v = 124
+	assert.Equal(t, HealthOutOfRange, checker.Check("1-4", &v, false, plus2, -35, 125).State)
+	v = -36
+	assert.Equal(t, HealthValid, checker.Check("1-5", &v, false, plus2, -35, 125).State)
+}
@@ -29,6 +29,10 @@ const (
 	MessageTypeServerData         MessageType = "server_data"
 	MessageTypeAlarmTriggered     MessageType = "alarm_triggered"
 	MessageTypeAIControlExecuted  MessageType = "ai_control_executed"
+	MessageTypeBreakerTrip        MessageType = "breaker_trip"
+	MessageTypeEmergencyPowerOff  MessageType = "emergency_power_off"
+	MessageTypeBreakerSelfTest    MessageType = "breaker_self_test"
+	MessageTypeDiscoveryProgress  MessageType = "discovery_progress"
 	MessageTypePing               MessageType = "ping"
 	MessageTypePong               MessageType = "pong"
 )
@@ -221,6 +225,10 @@ func (h *Hub) PushAIControlExecuted(data interface{}) {
 	h.BroadcastMessage(MessageTypeAIControlExecuted, data)
 }
 
+func (h *Hub) PushBreakerTrip(data interface{}) {
+	h.BroadcastMessage(MessageTypeBreakerTrip, data)
+}
+
 func (h *Hub) PushDeviceStatusUpdate(data interface{}) {
 	h.BroadcastMessage(MessageTypeDeviceStatusUpdate, data)
 }
@@ -434,6 +442,34 @@ func BroadcastAlarmTriggered(data interface{}) {
 	}
 }
 
+// 广播断路器跳闸事件
+func BroadcastBreakerTrip(data interface{}) {
+	if GlobalHub != nil {
+		GlobalHub.BroadcastMessage(MessageTypeBreakerTrip, data)
+	}
+}
+
+// 广播断路器漏电自检结果
+func BroadcastBreakerSelfTest(data interface{}) {
+	if GlobalHub != nil {
+		GlobalHub.BroadcastMessage(MessageTypeBreakerSelfTest, data)
+	}
+}
+
+// 广播紧急断电告警（开始执行和执行完成各一次）
+func BroadcastEmergencyPowerOff(data interface{}) {
+	if GlobalHub != nil {
+		GlobalHub.BroadcastMessage(MessageTypeEmergencyPowerOff, data)
+	}
+}
+
+// 广播网络设备发现任务进度
+func BroadcastDiscoveryProgress(data interface{}) {
+	if GlobalHub != nil {
+		GlobalHub.BroadcastMessage(MessageTypeDiscoveryProgress, data)
+	}
+}
+
 // 广播AI控制执行
 func BroadcastAIControlExecuted(data interface{}) {
 	if GlobalHub != nil {
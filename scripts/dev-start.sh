@@ -191,13 +191,6 @@ start_backend() {
     BACKEND_PID=$!
     echo $BACKEND_PID > ../backend.pid
 
-    # 启动温度数据采集服务
-    log_info "启动温度数据采集服务..."
-    nohup go run cmd/temperature-collector/main.go > ../logs/temperature-collector.log 2>&1 &
-    COLLECTOR_PID=$!
-    echo $COLLECTOR_PID > ../temperature-collector.pid
-    log_success "温度数据采集服务启动完成 (PID: $COLLECTOR_PID)"
-
     # 启动服务器状态监控服务
     log_info "启动服务器状态监控服务..."
     nohup go run cmd/server-monitor/main.go > ../logs/server-monitor.log 2>&1 &
@@ -320,7 +313,6 @@ show_logs_info() {
     echo -e "${CYAN}📋 日志查看命令:${NC}"
     echo -e "  后端日志: ${YELLOW}tail -f logs/backend.log${NC}"
     echo -e "  前端日志: ${YELLOW}tail -f logs/frontend.log${NC}"
-    echo -e "  温度采集日志: ${YELLOW}tail -f logs/temperature-collector.log${NC}"
     echo -e "  服务器监控日志: ${YELLOW}tail -f logs/server-monitor.log${NC}"
     echo -e "  查看所有日志: ${YELLOW}tail -f logs/*.log${NC}"
     echo ""
@@ -347,22 +339,6 @@ cleanup() {
         log_success "后端服务已停止"
     fi
 
-    # 停止温度数据采集服务
-    if [ -f "temperature-collector.pid" ]; then
-        COLLECTOR_PID=$(cat temperature-collector.pid)
-        if kill -0 $COLLECTOR_PID 2>/dev/null; then
-            kill -TERM $COLLECTOR_PID 2>/dev/null || true
-            sleep 2
-
-            # 如果还在运行，强制停止
-            if kill -0 $COLLECTOR_PID 2>/dev/null; then
-                kill -KILL $COLLECTOR_PID 2>/dev/null || true
-            fi
-        fi
-        rm -f temperature-collector.pid
-        log_success "温度数据采集服务已停止"
-    fi
-
     # 停止服务器状态监控服务
     if [ -f "server-monitor.pid" ]; then
         MONITOR_PID=$(cat server-monitor.pid)
@@ -420,13 +396,12 @@ force_cleanup() {
 
     # 清理可能的进程
     pkill -f "go run cmd/server/main.go" 2>/dev/null || true
-    pkill -f "go run cmd/temperature-collector/main.go" 2>/dev/null || true
     pkill -f "npm run dev" 2>/dev/null || true
     pkill -f "vite" 2>/dev/null || true
     pkill -f "bin/server" 2>/dev/null || true
 
     # 清理PID文件
-    rm -f backend.pid frontend.pid temperature-collector.pid 2>/dev/null || true
+    rm -f backend.pid frontend.pid 2>/dev/null || true
 
     sleep 2
     log_success "强制清理完成"